@@ -98,7 +98,8 @@ function get-password {
   cat << EOF > ~/.kubernetes_auth
 {
   "User": "$user",
-  "Password": "$passwd"
+  "Password": "$passwd",
+  "Insecure": true
 }
 EOF
   chmod 0600 ~/.kubernetes_auth
@@ -29,7 +29,8 @@ if [ "$KUBERNETES_PROVIDER" == "vagrant" ]; then
   cat >~/.kubernetes_vagrant_auth <<EOF
 {
   "User": "vagrant",
-  "Password": "vagrant"
+  "Password": "vagrant",
+  "Insecure": true
 }
 EOF
   AUTH_CONFIG="-auth $HOME/.kubernetes_vagrant_auth"
@@ -22,16 +22,17 @@ import (
 	"flag"
 	"net"
 	"net/http"
-	"os"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/apiserver"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/capabilities"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/cloudprovider"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/master"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/tools"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/version/verflag"
 	"github.com/golang/glog"
@@ -40,21 +41,37 @@ import (
 var (
 	port                  = flag.Uint("port", 8080, "The port to listen on.  Default 8080.")
 	address               = flag.String("address", "127.0.0.1", "The address on the local server to listen to. Default 127.0.0.1")
-	apiPrefix             = flag.String("api_prefix", "/api/v1beta1", "The prefix for API requests on the server. Default '/api/v1beta1'")
+	apiRoot               = flag.String("api_root", "/api", "The path under which each API version is served, e.g. '/api/v1beta1'. Default '/api'")
 	cloudProvider         = flag.String("cloud_provider", "", "The provider for cloud services.  Empty string for no provider.")
 	cloudConfigFile       = flag.String("cloud_config", "", "The path to the cloud provider configuration file.  Empty string for no configuration file.")
 	minionRegexp          = flag.String("minion_regexp", "", "If non empty, and -cloud_provider is specified, a regular expression for matching minion VMs")
 	minionPort            = flag.Uint("minion_port", 10250, "The port at which kubelet will be listening on the minions.")
-	healthCheckMinions    = flag.Bool("health_check_minions", true, "If true, health check minions and filter unhealthy ones. [default true]")
+	healthCheckMinions    = flag.Bool("health_check_minions", true, "If true, health check minions, filter unhealthy ones and take minion capacity from the kubelet. [default true]")
 	minionCacheTTL        = flag.Duration("minion_cache_ttl", 30*time.Second, "Duration of time to cache minion information. [default 30 seconds]")
+	minionSyncPeriod      = flag.Duration("minion_sync_period", 30*time.Second, "How often the minions matching -minion_regexp are synced from the cloud provider.")
+	endpointWorkers       = flag.Int("endpoint_workers", 1, "The number of services whose endpoints are synced at once.")
 	etcdServerList        util.StringList
+	etcdCertFile          = flag.String("etcd_certfile", "", "The client certificate presented to etcd servers that require client auth.  Requires -etcd_keyfile.")
+	etcdKeyFile           = flag.String("etcd_keyfile", "", "The private key for -etcd_certfile.")
+	etcdCAFile            = flag.String("etcd_cafile", "", "The CA certificate used to verify etcd servers.  Empty string to use the system roots.")
+	maxLongRunning        = flag.Int("max_long_running_requests", 0, "The most watches and proxied connections served at once.  Beyond it, they're refused with 429 Too Many Requests.  0 for no limit.")
+	etcdCache             = flag.Bool("etcd_cache", false, "If true, serve lists and gets from memory, kept up to date by watches of etcd, rather than reading etcd every time.  Reads may lag writes slightly.")
+	etcdPrefix            = flag.String("etcd_prefix", "", "Prepended to every etcd key, so that several clusters can share an etcd cluster.  Must match the -etcd_prefix of the other components.")
 	machineList           util.StringList
 	corsAllowedOriginList util.StringList
 	allowPrivileged       = flag.Bool("allow_privileged", false, "If true, allow privileged containers.")
+	nodeMilliCPU          = flag.Int("node_milli_cpu", 1000, "The amount of MilliCPU provisioned on each node")
+	nodeMemory            = flag.Int("node_memory", 3*1024*1024*1024, "The amount of memory (in bytes) provisioned on each node")
+	nodeMaxPods           = flag.Int("node_max_pods", 0, "The maximum number of pods that may be placed on each node.  0 means no limit.")
+	sshUser               = flag.String("ssh_user", "", "If non empty, reach minions through SSH tunnels, logging in as this user.  For masters that cannot connect to minions directly.")
+	sshKeyFile            = flag.String("ssh_keyfile", "", "The private key used for SSH tunnels to minions.")
+	sshKnownHosts         = flag.String("ssh_known_hosts", "", "The known_hosts file the host keys of minions (or of -ssh_gateway) are checked against.  Empty string for ssh's default files.  Hosts whose keys aren't in it can't be tunneled to.")
+	sshGateway            = flag.String("ssh_gateway", "", "If non empty, the host all SSH tunnels go through, which lets the master proxy to pods as well as minions.  Otherwise each minion is tunneled to directly.")
+	portalNet             = flag.String("portal_net", "", "A CIDR from which services are given portal IPs, which the proxy on every minion intercepts.  Must not overlap with any IP ranges assigned to minions or pods.  Empty string for no portal IPs.")
 )
 
 func init() {
-	flag.Var(&etcdServerList, "etcd_servers", "List of etcd servers to watch (http://ip:port), comma separated")
+	flag.Var(&etcdServerList, "etcd_servers", "List of etcd servers to watch (http://ip:port), comma separated.  Requests fail over to another server when one can't be reached.")
 	flag.Var(&machineList, "machines", "List of machines to schedule onto, comma separated.")
 	flag.Var(&corsAllowedOriginList, "cors_allowed_origins", "List of allowed origins for CORS, comma separated.  An allowed origin can be a regular expression to support subdomain matching.  If this list is empty CORS will not be enabled.")
 }
@@ -71,37 +88,6 @@ func verifyMinionFlags() {
 	}
 }
 
-func initCloudProvider(name string, configFilePath string) cloudprovider.Interface {
-	var config *os.File
-
-	if name == "" {
-		glog.Info("No cloud provider specified.")
-		return nil
-	}
-
-	if configFilePath != "" {
-		var err error
-
-		config, err = os.Open(configFilePath)
-		if err != nil {
-			glog.Fatalf("Couldn't open cloud provider configuration %s: %#v",
-				configFilePath, err)
-		}
-
-		defer config.Close()
-	}
-
-	cloud, err := cloudprovider.GetCloudProvider(name, config)
-	if err != nil {
-		glog.Fatalf("Couldn't init cloud provider %q: %#v", name, err)
-	}
-	if cloud == nil {
-		glog.Fatalf("Unknown cloud provider: %s", name)
-	}
-
-	return cloud
-}
-
 func main() {
 	flag.Parse()
 	util.InitLogs()
@@ -118,10 +104,18 @@ func main() {
 		AllowPrivileged: *allowPrivileged,
 	})
 
-	cloud := initCloudProvider(*cloudProvider, *cloudConfigFile)
+	cloud := cloudprovider.InitCloudProvider(*cloudProvider, *cloudConfigFile)
+
+	var minionTransport http.RoundTripper
+	if *sshUser != "" {
+		tunneler := client.NewSSHTunneler(*sshUser, *sshKeyFile, *sshKnownHosts, *sshGateway)
+		defer tunneler.Close()
+		minionTransport = tunneler.Transport()
+		apiserver.MinionTransport = minionTransport
+	}
 
 	podInfoGetter := &client.HTTPPodInfoGetter{
-		Client: http.DefaultClient,
+		Client: &http.Client{Transport: minionTransport},
 		Port:   *minionPort,
 	}
 
@@ -130,20 +124,54 @@ func main() {
 		glog.Fatalf("Invalid server address: %v", err)
 	}
 
+	resources := api.ResourceList{
+		api.ResourceCPU:    util.NewIntOrStringFromInt(*nodeMilliCPU),
+		api.ResourceMemory: util.NewIntOrStringFromInt(*nodeMemory),
+	}
+	if *nodeMaxPods > 0 {
+		resources[api.ResourcePods] = util.NewIntOrStringFromInt(*nodeMaxPods)
+	}
+
+	var portalNetwork *net.IPNet
+	if *portalNet != "" {
+		_, portalNetwork, err = net.ParseCIDR(*portalNet)
+		if err != nil {
+			glog.Fatalf("Invalid -portal_net: %v", err)
+		}
+	}
+
+	etcdClient, err := tools.NewEtcdClient(etcdServerList, *etcdCertFile, *etcdKeyFile, *etcdCAFile)
+	if err != nil {
+		glog.Fatalf("Invalid etcd client configuration: %v", err)
+	}
+
 	m := master.New(&master.Config{
 		Client:             client,
 		Cloud:              cloud,
-		EtcdServers:        etcdServerList,
+		EtcdClient:         etcdClient,
+		EtcdPathPrefix:     *etcdPrefix,
+		EtcdCache:          *etcdCache,
 		HealthCheckMinions: *healthCheckMinions,
 		Minions:            machineList,
 		MinionCacheTTL:     *minionCacheTTL,
 		MinionRegexp:       *minionRegexp,
+		MinionSyncPeriod:   *minionSyncPeriod,
 		PodInfoGetter:      podInfoGetter,
+		NodeResources:      api.NodeResources{Capacity: resources},
+		MinionTransport:    minionTransport,
+		EndpointWorkers:    *endpointWorkers,
+		PortalNet:          portalNetwork,
 	})
 
-	storage, codec := m.API_v1beta1()
+	mux := http.NewServeMux()
+	longRunning := apiserver.NewLongRunningTracker(*maxLongRunning)
+	apiserver.NewAPIGroup(m.API_v1beta1()).TrackLongRunning(longRunning).InstallREST(mux, *apiRoot+"/v1beta1")
+	apiserver.NewAPIGroup(m.API_v1beta2()).TrackLongRunning(longRunning).InstallREST(mux, *apiRoot+"/v1beta2")
+	mux.Handle("/longrunning", longRunning)
+	mux.Handle(*apiRoot, apiserver.APIVersionHandler("v1beta1", "v1beta2"))
+	apiserver.InstallSupport(mux)
 
-	handler := apiserver.Handle(storage, codec, *apiPrefix)
+	handler := http.Handler(mux)
 	if len(corsAllowedOriginList) > 0 {
 		allowedOriginRegexps, err := util.CompileRegexps(corsAllowedOriginList)
 		if err != nil {
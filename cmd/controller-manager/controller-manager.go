@@ -17,20 +17,27 @@ limitations under the License.
 // The controller manager is responsible for monitoring replication
 // controllers, and creating corresponding pods to achieve the desired
 // state.  It uses the API to listen for new controllers and to create/delete
-// pods.
+// pods.  It also deletes pods bound to minions that have left the cluster.
 package main
 
 import (
 	"flag"
+	"fmt"
 	"net"
 	"net/http"
+	"os"
 	"strconv"
 	"time"
 
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/cloudprovider"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/controller"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/dnsprovider"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/dnsprovider/skydns"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/election"
 	_ "github.com/GoogleCloudPlatform/kubernetes/pkg/healthz"
 	masterPkg "github.com/GoogleCloudPlatform/kubernetes/pkg/master"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/tools"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/version/verflag"
 	"github.com/golang/glog"
@@ -40,8 +47,51 @@ var (
 	master  = flag.String("master", "", "The address of the Kubernetes API server")
 	port    = flag.Int("port", masterPkg.ControllerManagerPort, "The port that the controller-manager's http service runs on")
 	address = flag.String("address", "127.0.0.1", "The address to serve from")
+
+	minionGracePeriod = flag.Duration("minion_grace_period", time.Minute, "How long a minion must be missing before the pods bound to it are deleted")
+	etcdServerList    util.StringList
+	etcdPrefix        = flag.String("etcd_prefix", "", "Prepended to every etcd key, so that several clusters can share an etcd cluster.  Must match the apiserver's -etcd_prefix.")
+
+	shardIndex         = flag.Int("shard_index", 0, "Which of -shard_count shards of the replication controllers this controller manager syncs")
+	shardCount         = flag.Int("shard_count", 1, "The number of controller managers to split the replication controllers between, by a hash of their names.  Only shard 0 runs the other controllers.")
+	loopDeadline       = flag.Duration("loop_deadline", 5*time.Minute, "How long one sync of the replication controllers or minions may take before the controller manager is considered wedged, and exits to be restarted.  0 to never exit.")
+	replicationWorkers = flag.Int("replication_workers", 10, "The number of replication controllers synced at once.  0 for no limit.")
+
+	cloudProvider   = flag.String("cloud_provider", "", "The provider for cloud services, used to find the IPs of external load balancers.  Empty string for no provider.")
+	cloudConfigFile = flag.String("cloud_config", "", "The path to the cloud provider configuration file.  Empty string for no configuration file.")
+	dnsProvider     = flag.String("dns_provider", "", "The provider with which to publish the external IPs of services.  Empty string to not publish them.")
+	dnsConfigFile   = flag.String("dns_config", "", "The path to the DNS provider configuration file.  Empty string for no configuration file.")
+	dnsZones        util.StringList
+	allocateRoutes  = flag.Bool("configure_cloud_routes", false, "If true, program a route in the cloud for the pod CIDR of every minion (requires -cloud_provider).")
+	clusterName     = flag.String("cluster_name", "kubernetes", "The prefix of the names of the cloud routes this cluster owns.")
+	clusterDomain   = flag.String("cluster_domain", "", "The domain under which the portal IP of every service is published as <service>.<domain> for SkyDNS, in etcd (requires -etcd_servers).  Empty string to not publish them.")
 )
 
+func init() {
+	flag.Var(&etcdServerList, "etcd_servers", "List of etcd servers (http://ip:port), comma separated, used to elect a single active controller manager amongst replicas (optional)")
+	flag.Var(&dnsZones, "dns_zones", "List of DNS zones, comma separated, under which services with external load balancers are published as <service>.<zone>; records of that form in these zones are managed by the controller (requires -dns_provider and -cloud_provider)")
+}
+
+// masterID identifies this replica in the master election.
+func masterID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		glog.Fatalf("Couldn't get hostname: %v", err)
+	}
+	return hostname + ":" + strconv.Itoa(os.Getpid())
+}
+
+// newWatchdog returns a watchdog which exits if a call of the named loop takes
+// longer than -loop_deadline, or nil if -loop_deadline is 0.
+func newWatchdog(name string) *util.Watchdog {
+	if *loopDeadline <= 0 {
+		return nil
+	}
+	watchdog := util.NewWatchdog(name, *loopDeadline, util.ExitWhenWedged)
+	watchdog.Run(*loopDeadline / 10)
+	return watchdog
+}
+
 func main() {
 	flag.Parse()
 	util.InitLogs()
@@ -52,6 +102,9 @@ func main() {
 	if len(*master) == 0 {
 		glog.Fatal("usage: controller-manager -master <master>")
 	}
+	if *shardCount < 1 || *shardIndex < 0 || *shardIndex >= *shardCount {
+		glog.Fatalf("-shard_index must be in [0, %d)", *shardCount)
+	}
 
 	kubeClient, err := client.New(*master, nil)
 	if err != nil {
@@ -60,7 +113,65 @@ func main() {
 
 	go http.ListenAndServe(net.JoinHostPort(*address, strconv.Itoa(*port)), nil)
 
-	controllerManager := controller.NewReplicationManager(kubeClient)
-	controllerManager.Run(10 * time.Second)
+	var etcdClient tools.EtcdClient
+	if len(etcdServerList) > 0 {
+		etcdClient, err = tools.NewEtcdClient(etcdServerList, "", "", "")
+		if err != nil {
+			glog.Fatalf("Invalid etcd client configuration: %v", err)
+		}
+		// Only one replica of each shard may act at a time; the others wait here.
+		path := *etcdPrefix + "/registry/masters/controller-manager"
+		if *shardCount > 1 {
+			path = fmt.Sprintf("%s-%d-of-%d", path, *shardIndex, *shardCount)
+		}
+		elector := election.NewEtcdMasterElector(etcdClient)
+		election.BecomeMaster(elector, path, masterID(), func() {
+			glog.Fatalf("Lost mastership, exiting so that another replica takes over")
+		})
+	}
+
+	shard := util.Shard{Index: *shardIndex, Count: *shardCount}
+	controllerManager := controller.NewShardedReplicationManager(kubeClient, shard, *replicationWorkers)
+	controllerManager.Run(10*time.Second, newWatchdog("replication"))
+
+	if *shardIndex != 0 {
+		select {}
+	}
+
+	nodeController := controller.NewNodeController(kubeClient, *minionGracePeriod)
+	nodeController.Run(10*time.Second, newWatchdog("node"))
+
+	cloud := cloudprovider.InitCloudProvider(*cloudProvider, *cloudConfigFile)
+
+	if dns := dnsprovider.InitDNSProvider(*dnsProvider, *dnsConfigFile); dns != nil {
+		if cloud == nil {
+			glog.Fatal("-dns_provider requires -cloud_provider")
+		}
+		dnsController, err := controller.NewServiceDNSController(kubeClient, cloud, dns, dnsZones)
+		if err != nil {
+			glog.Fatalf("Couldn't publish services in DNS: %v", err)
+		}
+		dnsController.Run(30 * time.Second)
+	}
+
+	if *allocateRoutes {
+		if cloud == nil {
+			glog.Fatal("-configure_cloud_routes requires -cloud_provider")
+		}
+		routes, ok := cloud.Routes()
+		if !ok {
+			glog.Fatalf("Cloud provider %s doesn't support routes", *cloudProvider)
+		}
+		routeController := controller.NewRouteController(kubeClient.Minions(), routes, *clusterName)
+		routeController.Run(10 * time.Second)
+	}
+
+	if len(*clusterDomain) > 0 {
+		if len(etcdServerList) == 0 {
+			glog.Fatal("-cluster_domain requires -etcd_servers")
+		}
+		clusterDNS := controller.NewClusterDNSController(kubeClient, skydns.New(etcdClient), *clusterDomain)
+		clusterDNS.Run(10 * time.Second)
+	}
 	select {}
 }
@@ -0,0 +1,295 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// density runs a configurable number of pods on every minion of a running
+// cluster and measures how long they take to start. In soak mode it does this
+// repeatedly for a long period. Pod startup latency percentiles and API error
+// rates are written out as a JSON report.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/kubecfg"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util/wait"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/version/verflag"
+	"github.com/golang/glog"
+)
+
+var (
+	master          = flag.String("master", "", "The address of the Kubernetes API server. Defaults to $KUBERNETES_MASTER.")
+	authConfig      = flag.String("auth", os.Getenv("HOME")+"/.kubernetes_auth", "Path to the auth info file.  Only used if doing https.")
+	podsPerMinion   = flag.Int("pods_per_minion", 10, "The number of pods to run on each minion.")
+	image           = flag.String("image", "kubernetes/pause", "The container image to run in each pod.")
+	duration        = flag.Duration("duration", 0, "If non-zero, run in soak mode: keep starting and stopping pods until this much time has passed. Otherwise do a single pass.")
+	podStartTimeout = flag.Duration("pod_start_timeout", 10*time.Minute, "How long to wait for all of the pods in a pass to be running.")
+	pollInterval    = flag.Duration("poll_interval", 2*time.Second, "How often to check the state of pods. This bounds the accuracy of the measured latencies.")
+	reportPath      = flag.String("report", "", "If non-empty, write the JSON report to this file instead of stdout.")
+	passInterval    = flag.Duration("pass_interval", 10*time.Second, "In soak mode, how long to wait between passes. The wait doubles after each failed pass, up to 5 minutes.")
+	maxFailures     = flag.Int("max_consecutive_failures", 3, "In soak mode, stop after this many passes in a row have failed. Zero means never stop early.")
+)
+
+const (
+	controllerName = "density"
+	// maxPassInterval caps the wait between passes while passes keep failing.
+	maxPassInterval = 5 * time.Minute
+)
+
+// LatencySummary holds percentiles of a set of latencies, in milliseconds.
+type LatencySummary struct {
+	Samples int   `json:"samples"`
+	P50     int64 `json:"p50Ms"`
+	P90     int64 `json:"p90Ms"`
+	P99     int64 `json:"p99Ms"`
+	Max     int64 `json:"maxMs"`
+}
+
+// Report is the machine-readable result of a density run.
+type Report struct {
+	Start          time.Time      `json:"start"`
+	End            time.Time      `json:"end"`
+	Minions        int            `json:"minions"`
+	PodsPerMinion  int            `json:"podsPerMinion"`
+	Passes         int            `json:"passes"`
+	FailedPasses   int            `json:"failedPasses"`
+	Aborted        bool           `json:"aborted"`
+	StartupLatency LatencySummary `json:"startupLatency"`
+	APICalls       int            `json:"apiCalls"`
+	APIErrors      int            `json:"apiErrors"`
+	APIErrorRate   float64        `json:"apiErrorRate"`
+}
+
+// tester drives the cluster and records what it observes.
+type tester struct {
+	client    *client.Client
+	latencies []time.Duration
+	calls     int
+	errors    int
+}
+
+// record counts an API call and its outcome, and passes the error through.
+func (t *tester) record(err error) error {
+	t.calls++
+	if err != nil {
+		t.errors++
+		glog.Errorf("API error: %v", err)
+	}
+	return err
+}
+
+// runPass creates a replication controller with the given number of replicas,
+// waits for all of its pods to be running, and tears it down again.
+func (t *tester) runPass(replicas int) error {
+	selector := map[string]string{"name": controllerName}
+	controller := &api.ReplicationController{
+		JSONBase: api.JSONBase{ID: controllerName},
+		DesiredState: api.ReplicationControllerState{
+			Replicas:        replicas,
+			ReplicaSelector: selector,
+			PodTemplate: api.PodTemplate{
+				DesiredState: api.PodState{
+					Manifest: api.ContainerManifest{
+						Version: "v1beta1",
+						Containers: []api.Container{
+							{
+								Name:  controllerName,
+								Image: *image,
+							},
+						},
+					},
+				},
+				Labels: selector,
+			},
+		},
+		Labels: selector,
+	}
+	if _, err := t.client.CreateReplicationController(controller); t.record(err) != nil {
+		return err
+	}
+	defer t.teardown(controller)
+
+	running := map[string]bool{}
+	err := wait.Poll(*pollInterval, *podStartTimeout, func() (bool, error) {
+		pods, err := t.client.ListPods(labels.Set(selector).AsSelector())
+		if t.record(err) != nil {
+			// Keep trying; transient errors are part of what we measure.
+			return false, nil
+		}
+		now := time.Now()
+		for _, pod := range pods.Items {
+			if running[pod.ID] || pod.CurrentState.Status != api.PodRunning {
+				continue
+			}
+			running[pod.ID] = true
+			t.latencies = append(t.latencies, now.Sub(pod.CreationTimestamp.Time))
+		}
+		return len(running) >= replicas, nil
+	})
+	if err != nil {
+		return fmt.Errorf("only %d of %d pods running: %v", len(running), replicas, err)
+	}
+	return nil
+}
+
+// teardown resizes the controller to zero, waits for its pods to go away, and
+// deletes it.
+func (t *tester) teardown(controller *api.ReplicationController) {
+	current, err := t.client.GetReplicationController(controller.ID)
+	if t.record(err) != nil {
+		return
+	}
+	current.DesiredState.Replicas = 0
+	if _, err := t.client.UpdateReplicationController(current); t.record(err) != nil {
+		return
+	}
+	err = wait.Poll(*pollInterval, *podStartTimeout, func() (bool, error) {
+		pods, err := t.client.ListPods(labels.Set(controller.DesiredState.ReplicaSelector).AsSelector())
+		if t.record(err) != nil {
+			return false, nil
+		}
+		return len(pods.Items) == 0, nil
+	})
+	if err != nil {
+		glog.Errorf("Pods of %s were not removed: %v", controller.ID, err)
+	}
+	t.record(t.client.DeleteReplicationController(controller.ID))
+}
+
+// summarize computes percentiles over latencies.
+func summarize(latencies []time.Duration) LatencySummary {
+	if len(latencies) == 0 {
+		return LatencySummary{}
+	}
+	sorted := make([]int, len(latencies))
+	for i, latency := range latencies {
+		sorted[i] = int(latency / time.Millisecond)
+	}
+	sort.Ints(sorted)
+	percentile := func(p int) int64 {
+		return int64(sorted[(len(sorted)-1)*p/100])
+	}
+	return LatencySummary{
+		Samples: len(sorted),
+		P50:     percentile(50),
+		P90:     percentile(90),
+		P99:     percentile(99),
+		Max:     int64(sorted[len(sorted)-1]),
+	}
+}
+
+func newClient() *client.Client {
+	masterServer := *master
+	if len(masterServer) == 0 {
+		masterServer = os.Getenv("KUBERNETES_MASTER")
+	}
+	if len(masterServer) == 0 {
+		masterServer = "http://localhost:8080"
+	}
+	kubeClient, err := client.New(masterServer, nil)
+	if err != nil {
+		glog.Fatalf("Unable to parse %s as a URL: %v", masterServer, err)
+	}
+	if kubeClient.Secure() {
+		auth, err := kubecfg.LoadAuthInfo(*authConfig, os.Stdin)
+		if err != nil {
+			glog.Fatalf("Error loading auth: %v", err)
+		}
+		kubeClient, err = client.New(masterServer, auth)
+		if err != nil {
+			glog.Fatalf("Unable to parse %s as a URL: %v", masterServer, err)
+		}
+	}
+	return kubeClient
+}
+
+func main() {
+	flag.Parse()
+	util.InitLogs()
+	defer util.FlushLogs()
+
+	verflag.PrintAndExitIfRequested()
+
+	t := &tester{client: newClient()}
+	minions, err := t.client.ListMinions()
+	if t.record(err) != nil {
+		glog.Fatalf("Unable to list minions: %v", err)
+	}
+	if len(minions.Items) == 0 {
+		glog.Fatalf("No minions found")
+	}
+
+	report := Report{
+		Start:         time.Now(),
+		Minions:       len(minions.Items),
+		PodsPerMinion: *podsPerMinion,
+	}
+	replicas := len(minions.Items) * *podsPerMinion
+	backoff := client.Backoff{Initial: *passInterval, Max: maxPassInterval}
+	failures := 0
+	for {
+		glog.Infof("Starting pass %d with %d pods", report.Passes+1, replicas)
+		if err := t.runPass(replicas); err != nil {
+			glog.Errorf("Pass %d failed: %v", report.Passes+1, err)
+			report.FailedPasses++
+			failures++
+		} else {
+			failures = 0
+			backoff.Reset()
+		}
+		report.Passes++
+		if time.Since(report.Start) >= *duration {
+			break
+		}
+		if *maxFailures > 0 && failures >= *maxFailures {
+			glog.Errorf("Giving up after %d consecutive failed passes", failures)
+			report.Aborted = true
+			break
+		}
+		time.Sleep(backoff.Next())
+	}
+	report.End = time.Now()
+	report.StartupLatency = summarize(t.latencies)
+	report.APICalls = t.calls
+	report.APIErrors = t.errors
+	if t.calls > 0 {
+		report.APIErrorRate = float64(t.errors) / float64(t.calls)
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		glog.Fatalf("Unable to encode report: %v", err)
+	}
+	if len(*reportPath) > 0 {
+		if err := ioutil.WriteFile(*reportPath, data, 0644); err != nil {
+			glog.Fatalf("Unable to write report: %v", err)
+		}
+	} else {
+		fmt.Printf("%s\n", data)
+	}
+	if report.FailedPasses > 0 {
+		os.Exit(1)
+	}
+}
@@ -113,12 +113,16 @@ func startComponents(manifestURL string) (apiServerURL string) {
 	// Master
 	m := master.New(&master.Config{
 		Client:        cl,
-		EtcdServers:   servers,
+		EtcdClient:    etcdClient,
 		Minions:       machineList,
 		PodInfoGetter: fakePodInfoGetter{},
 	})
-	storage, codec := m.API_v1beta1()
-	handler.delegate = apiserver.Handle(storage, codec, "/api/v1beta1")
+	mux := http.NewServeMux()
+	apiserver.NewAPIGroup(m.API_v1beta1()).InstallREST(mux, "/api/v1beta1")
+	apiserver.NewAPIGroup(m.API_v1beta2()).InstallREST(mux, "/api/v1beta2")
+	mux.Handle("/api", apiserver.APIVersionHandler("v1beta1", "v1beta2"))
+	apiserver.InstallSupport(mux)
+	handler.delegate = mux
 
 	// Scheduler
 	scheduler.New((&factory.ConfigFactory{cl}).Create()).Run()
@@ -127,7 +131,7 @@ func startComponents(manifestURL string) (apiServerURL string) {
 
 	// Prove that controllerManager's watch works by making it not sync until after this
 	// test is over. (Hopefully we don't take 10 minutes!)
-	controllerManager.Run(10 * time.Minute)
+	controllerManager.Run(10*time.Minute, nil)
 
 	// Kubelet (localhost)
 	cfg1 := config.NewPodConfig(config.PodConfigNotificationSnapshotAndUpdates)
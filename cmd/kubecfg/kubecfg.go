@@ -26,13 +26,13 @@ import (
 	"sort"
 	"strconv"
 	"strings"
-	"text/template"
 	"time"
 
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/latest"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/kubecfg"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/version"
@@ -45,19 +45,32 @@ var (
 	preventSkew   = flag.Bool("expect_version_match", false, "Fail if server's version doesn't match own version.")
 	httpServer    = flag.String("h", "", "The host to connect to.")
 	config        = flag.String("c", "", "Path or URL to the config file.")
+	dryRun        = flag.Bool("dry_run", false, "If true, apply only shows the changes it would make")
+	objectFiles   = flag.String("f", "", "Path to a file or directory of objects for create.  A file may hold a JSON array of objects or several YAML documents.")
 	selector      = flag.String("l", "", "Selector (label query) to use for listing")
+	overwrite     = flag.Bool("overwrite", false, "If true, 'label' may change the values of existing labels")
+	watchList     = flag.Bool("w", false, "If true, 'list' keeps printing a line for each change to the listed objects")
 	updatePeriod  = flag.Duration("u", 60*time.Second, "Update interval period")
 	portSpec      = flag.String("p", "", "The port spec, comma-separated list of <external>:<internal>,...")
 	servicePort   = flag.Int("s", -1, "If positive, create and run a corresponding service on this port, only used with 'run'")
 	authConfig    = flag.String("auth", os.Getenv("HOME")+"/.kubernetes_auth", "Path to the auth info file.  If missing, prompt the user.  Only used if doing https.")
+	output        = flag.String("o", "", "Output format: json|yaml|template=<Go template>|templatefile=<file>|path=<dotted path>|columns=<fields>, e.g. -o path=currentState.podIP")
 	json          = flag.Bool("json", false, "If true, print raw JSON for responses")
 	yaml          = flag.Bool("yaml", false, "If true, print raw YAML for responses")
 	verbose       = flag.Bool("verbose", false, "If true, print extra information")
-	proxy         = flag.Bool("proxy", false, "If true, run a proxy to the api server")
-	www           = flag.String("www", "", "If -proxy is true, use this directory to serve static files")
+	proxy         = flag.Bool("proxy", false, "If true, run a proxy to the api server; the same as 'proxy'")
+	proxyPort     = flag.Int("port", 8001, "The port on localhost on which 'proxy' listens")
+	www           = flag.String("www", "", "If proxying, use this directory to serve static files")
 	templateFile  = flag.String("template_file", "", "If present, load this file as a golang template and use it for output printing")
 	templateStr   = flag.String("template", "", "If present, parse this string as a golang template and use it for output printing")
+	columns       = flag.String("columns", "", "If present, print a table of these comma separated fields, e.g. -columns=ID,CurrentState.Host,Labels")
 	imageName     = flag.String("image", "", "Image used when updating a replicationController.  Will apply to the first container in the pod template.")
+	waitTimeout   = flag.Duration("wait_timeout", 5*time.Minute, "How long 'wait' waits for a pod to be running, or a replicationController's pods to be running, and 'stop' waits for a replicationController's pods to be deleted, before failing")
+	healthTimeout = flag.Duration("health_timeout", 5*time.Minute, "How long a rolling update to a new replicationController (-c) waits for new pods to be running before giving up")
+	clientConfig  = flag.String("kubeconfig", os.Getenv("HOME")+"/.kubernetes_config", "Path to the client config file, whose current context is used if neither -h nor $KUBERNETES_MASTER is set")
+	contextName   = flag.String("context", "", "The client config context to use instead of the current one; overrides $KUBERNETES_MASTER")
+	insecure      = flag.Bool("insecure_skip_tls_verify", false, "If true, the server's certificate is not checked, even if a CA is configured")
+	minify        = flag.Bool("minify", false, "If true, 'config view' only shows the current context")
 )
 
 var parser = kubecfg.NewParser(map[string]runtime.Object{
@@ -73,20 +86,40 @@ func usage() {
 Kubernetes REST API:
 
   kubecfg [OPTIONS] get|list|create|delete|update <%s>[/<id>]
+  kubecfg [OPTIONS] -w list <%s>
+  kubecfg [OPTIONS] -f <file or directory> create
+  kubecfg [OPTIONS] -l <selector> delete <%s>
+  kubecfg [OPTIONS] [-dry_run] -c <config/file.yaml> apply <%s>/<id>
+  kubecfg [OPTIONS] [-overwrite] label <%s>/<id> <key>=<value>|<key>- ...
+  kubecfg [OPTIONS] describe <pods|replicationControllers|services|minions>/<id>
+  kubecfg [OPTIONS] [-wait_timeout <time>] wait <pods|replicationControllers>/<id>
+
+Proxy the API on localhost, adding your credentials:
+
+  kubecfg [OPTIONS] [-port <port>] [-www <dir>] proxy
 
 Manage replication controllers:
 
   kubecfg [OPTIONS] stop|rm <controller>
   kubecfg [OPTIONS] [-u <time>] [-image <image>] rollingupdate <controller>
+  kubecfg [OPTIONS] [-u <time>] [-health_timeout <time>] -c <new controller> rollingupdate <controller>
   kubecfg [OPTIONS] resize <controller> <replicas>
 
 Launch a simple ReplicationController with a single container based
 on the given image:
 
-  kubecfg [OPTIONS] [-p <port spec>] run <image> <replicas> <controller>
+  kubecfg [OPTIONS] [-p <port spec>] [-s <service port>] run <image> <replicas> <controller>
+
+Manage the client config file:
+
+  kubecfg [OPTIONS] config set-cluster <name> <server>
+  kubecfg [OPTIONS] config set-credentials <name> <user> <password>
+  kubecfg [OPTIONS] config set-context <name> <cluster> [<credentials>]
+  kubecfg [OPTIONS] config use-context <name>
+  kubecfg [OPTIONS] [-minify] config view
 
 Options:
-`, prettyWireStorage())
+`, prettyWireStorage(), prettyWireStorage(), prettyWireStorage(), prettyWireStorage(), prettyWireStorage())
 	flag.PrintDefaults()
 
 }
@@ -130,7 +163,7 @@ func readConfig(storage string) []byte {
 		glog.Fatal("Need config file (-c)")
 	}
 
-	data, err := parser.ToWireFormat(readConfigData(), storage, latest.Codec)
+	data, err := parser.ToWireFormat(readConfigData(), storage, latest.StrictCodec)
 
 	if err != nil {
 		glog.Fatalf("Error parsing %v as an object for %v: %v\n", *config, storage, err)
@@ -152,11 +185,25 @@ func main() {
 
 	verflag.PrintAndExitIfRequested()
 
+	if flag.Arg(0) == "config" {
+		executeConfigRequest()
+		return
+	}
+
 	var masterServer string
+	var contextAuth *client.AuthInfo
 	if len(*httpServer) > 0 {
 		masterServer = *httpServer
+	} else if len(*contextName) > 0 {
+		cluster, auth, ok := loadClientConfig().Context(*contextName)
+		if !ok {
+			glog.Fatalf("No context named %q in %s", *contextName, *clientConfig)
+		}
+		masterServer, contextAuth = cluster.Server, auth
 	} else if len(os.Getenv("KUBERNETES_MASTER")) > 0 {
 		masterServer = os.Getenv("KUBERNETES_MASTER")
+	} else if cluster, auth, ok := loadClientConfig().Current(); ok {
+		masterServer, contextAuth = cluster.Server, auth
 	} else {
 		masterServer = "http://localhost:8080"
 	}
@@ -165,17 +212,24 @@ func main() {
 		glog.Fatalf("Unable to parse %s as a URL: %v", masterServer, err)
 	}
 
-	// TODO: this won't work if TLS is enabled with client cert auth, but no
-	// passwords are required. Refactor when we address client auth abstraction.
-	if kubeClient.Secure() {
-		auth, err := kubecfg.LoadAuthInfo(*authConfig, os.Stdin)
+	// Without credentials from the client config, https masters use the
+	// auth file.
+	auth := contextAuth
+	if auth == nil && kubeClient.Secure() {
+		auth, err = kubecfg.LoadAuthInfo(*authConfig, os.Stdin)
 		if err != nil {
 			glog.Fatalf("Error loading auth: %v", err)
 		}
-		kubeClient, err = client.New(masterServer, auth)
-		if err != nil {
-			glog.Fatalf("Unable to parse %s as a URL: %v", masterServer, err)
+	}
+	if *insecure {
+		if auth == nil {
+			auth = &client.AuthInfo{}
 		}
+		auth.Insecure = true
+	}
+	kubeClient, err = client.NewNegotiated(masterServer, auth)
+	if err != nil {
+		glog.Fatalf("Unable to create a client for %s: %v", masterServer, err)
 	}
 
 	if *serverVersion != verflag.VersionFalse {
@@ -205,10 +259,13 @@ func main() {
 		}
 	}
 
-	if *proxy {
-		glog.Info("Starting to serve on localhost:8001")
-		server := kubecfg.NewProxyServer(*www, kubeClient)
-		glog.Fatal(server.Serve())
+	if *proxy || flag.Arg(0) == "proxy" {
+		server, err := kubecfg.NewProxyServer(*www, kubeClient)
+		if err != nil {
+			glog.Fatalf("Error creating proxy: %v", err)
+		}
+		glog.Infof("Starting to serve on localhost:%d", *proxyPort)
+		glog.Fatal(server.Serve(*proxyPort))
 	}
 
 	if len(flag.Args()) < 1 {
@@ -217,12 +274,85 @@ func main() {
 	}
 	method := flag.Arg(0)
 
-	matchFound := executeAPIRequest(method, kubeClient) || executeControllerRequest(method, kubeClient)
+	matchFound := executeCreateRequest(method, kubeClient) || executeApplyRequest(method, kubeClient) || executeDescribeRequest(method, kubeClient) || executeLabelRequest(method, kubeClient) || executeWaitRequest(method, kubeClient) || executeAPIRequest(method, kubeClient) || executeControllerRequest(method, kubeClient)
 	if matchFound == false {
 		glog.Fatalf("Unknown command %s", method)
 	}
 }
 
+// loadClientConfig reads the client config file.
+func loadClientConfig() *kubecfg.ClientConfig {
+	config, err := kubecfg.LoadClientConfig(*clientConfig)
+	if err != nil {
+		glog.Fatalf("Error loading client config: %v", err)
+	}
+	return config
+}
+
+// executeConfigRequest runs a "config" command, which reads or modifies the
+// client config file.
+func executeConfigRequest() {
+	args := flag.Args()[1:]
+	if len(args) < 1 {
+		usage()
+		os.Exit(1)
+	}
+	checkArgs := func(min, max int) {
+		if len(args)-1 < min || len(args)-1 > max {
+			glog.Fatalf("Wrong number of arguments to config %s", args[0])
+		}
+	}
+
+	var modify func(*kubecfg.ClientConfig) error
+	switch args[0] {
+	case "set-cluster":
+		checkArgs(2, 2)
+		modify = func(config *kubecfg.ClientConfig) error {
+			config.SetCluster(args[1], kubecfg.Cluster{Server: args[2]})
+			return nil
+		}
+	case "set-credentials":
+		checkArgs(3, 3)
+		modify = func(config *kubecfg.ClientConfig) error {
+			config.SetCredentials(args[1], client.AuthInfo{User: args[2], Password: args[3]})
+			return nil
+		}
+	case "set-context":
+		checkArgs(2, 3)
+		modify = func(config *kubecfg.ClientConfig) error {
+			context := kubecfg.Context{Cluster: args[2]}
+			if len(args) > 3 {
+				context.Credentials = args[3]
+			}
+			config.SetContext(args[1], context)
+			return nil
+		}
+	case "use-context":
+		checkArgs(1, 1)
+		modify = func(config *kubecfg.ClientConfig) error {
+			return config.UseContext(args[1])
+		}
+	case "view":
+		checkArgs(0, 0)
+		config, err := kubecfg.LoadClientConfig(*clientConfig)
+		if err != nil {
+			glog.Fatalf("Error loading client config: %v", err)
+		}
+		if *minify {
+			config = config.Minify()
+		}
+		if err := config.Redact().Print(os.Stdout); err != nil {
+			glog.Fatalf("Error printing client config: %v", err)
+		}
+		return
+	default:
+		glog.Fatalf("Unknown config command %s", args[0])
+	}
+	if err := kubecfg.ModifyClientConfig(*clientConfig, modify); err != nil {
+		glog.Fatalf("Error updating client config: %v", err)
+	}
+}
+
 // storagePathFromArg normalizes a path and breaks out the first segment if available
 func storagePathFromArg(arg string) (storage, path string, hasSuffix bool) {
 	path = strings.Trim(arg, "/")
@@ -263,6 +393,10 @@ func executeAPIRequest(method string, c *client.Client) bool {
 		}
 	case "delete":
 		verb = "DELETE"
+		if validStorage && !hasSuffix && len(*selector) > 0 {
+			deleteBySelector(storage, c)
+			return true
+		}
 		if !validStorage || !hasSuffix {
 			glog.Fatalf("usage: kubecfg [OPTIONS] %s <%s>/<id>", method, prettyWireStorage())
 		}
@@ -322,34 +456,24 @@ func executeAPIRequest(method string, c *client.Client) bool {
 		return false
 	}
 
-	var printer kubecfg.ResourcePrinter
+	format := *output
 	switch {
+	case len(format) > 0:
 	case *json:
-		printer = &kubecfg.IdentityPrinter{}
+		format = "json"
 	case *yaml:
-		printer = &kubecfg.YAMLPrinter{}
-	case len(*templateFile) > 0 || len(*templateStr) > 0:
-		var data []byte
-		if len(*templateFile) > 0 {
-			var err error
-			data, err = ioutil.ReadFile(*templateFile)
-			if err != nil {
-				glog.Fatalf("Error reading template %s, %v\n", *templateFile, err)
-				return false
-			}
-		} else {
-			data = []byte(*templateStr)
-		}
-		tmpl, err := template.New("output").Parse(string(data))
-		if err != nil {
-			glog.Fatalf("Error parsing template %s, %v\n", string(data), err)
-			return false
-		}
-		printer = &kubecfg.TemplatePrinter{
-			Template: tmpl,
-		}
-	default:
-		printer = humanReadablePrinter()
+		format = "yaml"
+	case len(*templateFile) > 0:
+		format = "templatefile=" + *templateFile
+	case len(*templateStr) > 0:
+		format = "template=" + *templateStr
+	case len(*columns) > 0:
+		format = "columns=" + *columns
+	}
+	printer, err := kubecfg.GetPrinter(format, humanReadablePrinter())
+	if err != nil {
+		glog.Fatalf("%v", err)
+		return false
 	}
 
 	if err = printer.PrintObj(obj, os.Stdout); err != nil {
@@ -358,6 +482,161 @@ func executeAPIRequest(method string, c *client.Client) bool {
 	}
 	fmt.Print("\n")
 
+	if method == "list" && *watchList {
+		watchObjects(storage, obj, c)
+	}
+	return true
+}
+
+// watchObjects prints the changes to the objects of a resource type matching
+// -l, after those in list.
+func watchObjects(resource string, list runtime.Object, c *client.Client) {
+	sel, err := labels.ParseSelector(*selector)
+	if err != nil {
+		glog.Fatalf("Error parsing selector: %v", err)
+	}
+	version, err := kubecfg.LatestResourceVersion(list)
+	if err != nil {
+		glog.Fatalf("Error reading the list's resource versions: %v", err)
+	}
+	if err := kubecfg.WatchList(resource, sel, version, c.RESTClient, os.Stdout); err != nil {
+		glog.Fatalf("Error watching %s: %v", resource, err)
+	}
+}
+
+// deleteBySelector deletes the objects of a resource type matching -l.
+func deleteBySelector(resource string, c *client.Client) {
+	sel, err := labels.ParseSelector(*selector)
+	if err != nil {
+		glog.Fatalf("Error parsing selector: %v", err)
+	}
+	deleted, err := kubecfg.DeleteBySelector(resource, sel, c.RESTClient)
+	for _, id := range deleted {
+		fmt.Printf("Deleted %s/%s\n", resource, id)
+	}
+	if err != nil {
+		glog.Fatalf("Error: %v", err)
+	}
+}
+
+// executeApplyRequest makes an object match the config file, showing the
+// changes.
+func executeApplyRequest(method string, c *client.Client) bool {
+	if method != "apply" {
+		return false
+	}
+	storage, _, hasSuffix := storagePathFromArg(flag.Arg(1))
+	if len(flag.Args()) != 2 || !checkStorage(storage) || !hasSuffix {
+		glog.Fatalf("usage: kubecfg [OPTIONS] [-dry_run] -c <config/file> apply <%s>/<id>", prettyWireStorage())
+	}
+	id := strings.TrimPrefix(strings.Trim(flag.Arg(1), "/"), storage+"/")
+	if len(*config) == 0 {
+		glog.Fatal("Need config file (-c)")
+	}
+	data := readConfigData()
+	// Check that the file holds the right kind of object.
+	if _, err := parser.ToWireFormat(data, storage, latest.StrictCodec); err != nil {
+		glog.Fatalf("Error parsing %v as an object for %v: %v\n", *config, storage, err)
+	}
+	diff, err := kubecfg.Apply(storage, id, data, c.RESTClient, *dryRun)
+	if err != nil {
+		glog.Fatalf("Error applying %s/%s: %v", storage, id, err)
+	}
+	switch {
+	case len(diff) == 0:
+		fmt.Printf("%s/%s is unchanged\n", storage, id)
+	case *dryRun:
+		fmt.Printf("%sWould update %s/%s\n", diff, storage, id)
+	default:
+		fmt.Printf("%sUpdated %s/%s\n", diff, storage, id)
+	}
+	return true
+}
+
+// executeDescribeRequest prints a report on an object and the objects
+// related to it.
+func executeDescribeRequest(method string, c *client.Client) bool {
+	if method != "describe" {
+		return false
+	}
+	storage, _, hasSuffix := storagePathFromArg(flag.Arg(1))
+	if len(flag.Args()) != 2 || !hasSuffix {
+		glog.Fatal("usage: kubecfg [OPTIONS] describe <pods|replicationControllers|services|minions>/<id>")
+	}
+	id := strings.TrimPrefix(strings.Trim(flag.Arg(1), "/"), storage+"/")
+	report, err := kubecfg.Describe(storage, id, c)
+	if err != nil {
+		glog.Fatalf("Error describing %s/%s: %v", storage, id, err)
+	}
+	fmt.Print(report)
+	return true
+}
+
+// executeLabelRequest sets and removes labels on an object.
+func executeLabelRequest(method string, c *client.Client) bool {
+	if method != "label" {
+		return false
+	}
+	storage, _, hasSuffix := storagePathFromArg(flag.Arg(1))
+	if len(flag.Args()) < 3 || !checkStorage(storage) || !hasSuffix {
+		glog.Fatalf("usage: kubecfg [OPTIONS] [-overwrite] label <%s>/<id> <key>=<value>|<key>- ...", prettyWireStorage())
+	}
+	id := strings.TrimPrefix(strings.Trim(flag.Arg(1), "/"), storage+"/")
+	set, remove, err := kubecfg.ParseLabelChanges(flag.Args()[2:])
+	if err != nil {
+		glog.Fatalf("Error parsing labels: %v", err)
+	}
+	labelSet, err := kubecfg.Label(storage, id, set, remove, *overwrite, c.RESTClient)
+	if err != nil {
+		glog.Fatalf("Error labeling %s/%s: %v", storage, id, err)
+	}
+	fmt.Printf("%s/%s is labeled %s\n", storage, id, labels.Set(labelSet))
+	return true
+}
+
+// executeWaitRequest blocks until a pod is running, or a replicationController's
+// pods are running.
+func executeWaitRequest(method string, c *client.Client) bool {
+	if method != "wait" {
+		return false
+	}
+	storage, _, hasSuffix := storagePathFromArg(flag.Arg(1))
+	if len(flag.Args()) != 2 || !hasSuffix {
+		glog.Fatal("usage: kubecfg [OPTIONS] [-wait_timeout <time>] wait <pods|replicationControllers>/<id>")
+	}
+	id := strings.TrimPrefix(strings.Trim(flag.Arg(1), "/"), storage+"/")
+	if err := kubecfg.WaitForCondition(storage, id, c, *waitTimeout); err != nil {
+		glog.Fatalf("Error waiting for %s/%s: %v", storage, id, err)
+	}
+	fmt.Printf("%s/%s is running\n", storage, id)
+	return true
+}
+
+// executeCreateRequest creates the objects named by -f, reporting the outcome
+// for each.
+func executeCreateRequest(method string, c *client.Client) bool {
+	if method != "create" || len(*objectFiles) == 0 {
+		return false
+	}
+	if len(flag.Args()) != 1 {
+		glog.Fatal("usage: kubecfg [OPTIONS] -f <file or directory> create")
+	}
+	objs, err := kubecfg.LoadObjects(*objectFiles)
+	if err != nil {
+		glog.Fatalf("Error loading objects: %v", err)
+	}
+	failed := false
+	for _, result := range kubecfg.CreateObjects(objs, c) {
+		if result.Err != nil {
+			fmt.Printf("Failed to create %s/%s: %v\n", result.Resource, result.ID, result.Err)
+			failed = true
+			continue
+		}
+		fmt.Printf("Created %s/%s\n", result.Resource, result.ID)
+	}
+	if failed {
+		os.Exit(1)
+	}
 	return true
 }
 
@@ -372,11 +651,20 @@ func executeControllerRequest(method string, c *client.Client) bool {
 	var err error
 	switch method {
 	case "stop":
-		err = kubecfg.StopController(parseController(), c)
+		err = kubecfg.StopController(parseController(), c, *waitTimeout)
 	case "rm":
 		err = kubecfg.DeleteController(parseController(), c)
 	case "rollingupdate":
-		err = kubecfg.Update(parseController(), c, *updatePeriod, *imageName)
+		name := parseController()
+		if len(*config) == 0 {
+			err = kubecfg.Update(name, c, *updatePeriod, *imageName)
+			break
+		}
+		var newController api.ReplicationController
+		if err := latest.Codec.DecodeInto(readConfig("replicationControllers"), &newController); err != nil {
+			glog.Fatalf("Error decoding new controller: %v", err)
+		}
+		err = kubecfg.RollingUpdate(name, &newController, c, *updatePeriod, *healthTimeout)
 	case "run":
 		if len(flag.Args()) != 4 {
 			glog.Fatal("usage: kubecfg [OPTIONS] run <image> <replicas> <controller>")
@@ -23,6 +23,7 @@ package main
 import (
 	"flag"
 	"math/rand"
+	"net"
 	"net/http"
 	"os"
 	"os/exec"
@@ -59,12 +60,15 @@ var (
 	hostnameOverride   = flag.String("hostname_override", "", "If non-empty, will use this string as identification instead of the actual hostname.")
 	dockerEndpoint     = flag.String("docker_endpoint", "", "If non-empty, use this for the docker endpoint to communicate with")
 	etcdServerList     util.StringList
+	etcdPrefix         = flag.String("etcd_prefix", "", "Prepended to every etcd key, so that several clusters can share an etcd cluster.  Must match the apiserver's -etcd_prefix.")
 	rootDirectory      = flag.String("root_dir", defaultRootDir, "Directory path for managing kubelet files (volume mounts,etc).")
 	allowPrivileged    = flag.Bool("allow_privileged", false, "If true, allow containers to request privileged mode. [default=false]")
+	clusterDNS         = flag.String("cluster_dns", "", "IP address of a cluster DNS server.  If set, containers resolve names with it rather than the host's DNS servers.")
+	clusterDomain      = flag.String("cluster_domain", "", "Domain for this cluster.  If set, containers search it in addition to the host's search domains.")
 )
 
 func init() {
-	flag.Var(&etcdServerList, "etcd_servers", "List of etcd servers to watch (http://ip:port), comma separated")
+	flag.Var(&etcdServerList, "etcd_servers", "List of etcd servers to watch (http://ip:port), comma separated.  Requests fail over to another server when one can't be reached.")
 }
 
 func getDockerEndpoint() string {
@@ -144,20 +148,33 @@ func main() {
 	var etcdClient tools.EtcdClient
 	if len(etcdServerList) > 0 {
 		glog.Infof("Watching for etcd configs at %v", etcdServerList)
-		etcdClient = etcd.NewClient(etcdServerList)
-		kconfig.NewSourceEtcd(kconfig.EtcdKeyForHost(hostname), etcdClient, cfg.Channel("etcd"))
+		var err error
+		etcdClient, err = tools.NewEtcdClient(etcdServerList, "", "", "")
+		if err != nil {
+			glog.Fatalf("Invalid etcd client configuration: %v", err)
+		}
+		kconfig.NewSourceEtcd(*etcdPrefix+kconfig.EtcdKeyForHost(hostname), etcdClient, cfg.Channel("etcd"))
 	}
 
 	// TODO: block until all sources have delivered at least one update to the channel, or break the sync loop
 	// up into "per source" synchronizations
 
+	var dnsIP net.IP
+	if len(*clusterDNS) > 0 {
+		if dnsIP = net.ParseIP(*clusterDNS); dnsIP == nil {
+			glog.Fatalf("Invalid -cluster_dns: %q", *clusterDNS)
+		}
+	}
+
 	k := kubelet.NewMainKubelet(
 		getHostname(),
 		dockerClient,
 		cadvisorClient,
 		etcdClient,
 		*rootDirectory,
-		*syncFrequency)
+		*syncFrequency,
+		dnsIP,
+		*clusterDomain)
 
 	health.AddHealthChecker("exec", health.NewExecHealthChecker(k))
 	health.AddHealthChecker("http", health.NewHTTPHealthChecker(&http.Client{}))
@@ -18,12 +18,17 @@ package main
 
 import (
 	"flag"
+	"net"
+	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	masterPkg "github.com/GoogleCloudPlatform/kubernetes/pkg/master"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/proxy"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/proxy/config"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util/iptables"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/version/verflag"
 	"github.com/coreos/go-etcd/etcd"
 	"github.com/golang/glog"
@@ -33,7 +38,12 @@ var (
 	configFile     = flag.String("configfile", "/tmp/proxy_config", "Configuration file for the proxy")
 	master         = flag.String("master", "", "The address of the Kubernetes API server (optional)")
 	etcdServerList util.StringList
+	etcdPrefix     = flag.String("etcd_prefix", "", "Prepended to every etcd key, so that several clusters can share an etcd cluster.  Must match the apiserver's -etcd_prefix.")
 	bindAddress    = flag.String("bindaddress", "0.0.0.0", "The address for the proxy server to serve on (set to 0.0.0.0 or \"\" for all interfaces)")
+	proxyMode      = flag.String("proxy_mode", "userspace", "How to proxy services: \"userspace\" copies connections through the proxy, \"iptables\" programs iptables to send them to endpoints directly, falling back to userspace if iptables can't be used")
+	checkpointDir  = flag.String("checkpoint_dir", "", "If set, directory in which services and endpoints from the API server are checkpointed, so that a restarted proxy resumes watching them instead of listing them again (optional)")
+	statsAddress   = flag.String("stats_address", "127.0.0.1", "The address to serve per-service connection counters from, at /stats")
+	statsPort      = flag.Int("stats_port", masterPkg.ProxyPort, "The port to serve per-service connection counters on (set to 0 to disable)")
 )
 
 func init() {
@@ -61,6 +71,7 @@ func main() {
 		config.NewSourceAPI(
 			client,
 			30*time.Second,
+			*checkpointDir,
 			serviceConfig.Channel("api"),
 			endpointsConfig.Channel("api"),
 		)
@@ -73,7 +84,7 @@ func main() {
 		// Set up logger for etcd client
 		etcd.SetLogger(util.NewLogger("etcd "))
 		etcdClient := etcd.NewClient(etcdServerList)
-		config.NewConfigSourceEtcd(etcdClient,
+		config.NewConfigSourceEtcd(etcdClient, *etcdPrefix,
 			serviceConfig.Channel("etcd"),
 			endpointsConfig.Channel("etcd"))
 	}
@@ -84,12 +95,37 @@ func main() {
 		endpointsConfig.Channel("file"))
 	glog.Infof("Using configuration file %s", *configFile)
 
-	loadBalancer := proxy.NewLoadBalancerRR()
-	proxier := proxy.NewProxier(loadBalancer, *bindAddress)
-	// Wire proxier to handle changes to services
-	serviceConfig.RegisterHandler(proxier)
-	// And wire loadBalancer to handle changes to endpoints to services
-	endpointsConfig.RegisterHandler(loadBalancer)
+	if *proxyMode != "userspace" && *proxyMode != "iptables" {
+		glog.Fatalf("Unknown -proxy_mode %q", *proxyMode)
+	}
+	var iptablesProxier *proxy.IptablesProxier
+	if *proxyMode == "iptables" {
+		var err error
+		iptablesProxier, err = proxy.NewIptablesProxier(iptables.New(), net.ParseIP(*bindAddress))
+		if err != nil {
+			glog.Errorf("Can't use iptables, falling back to the userspace proxy: %v", err)
+		}
+	}
+
+	if iptablesProxier != nil {
+		serviceConfig.RegisterHandler(iptablesProxier)
+		endpointsConfig.RegisterHandler(config.EndpointsConfigHandlerFunc(iptablesProxier.OnEndpointsUpdate))
+	} else {
+		loadBalancer := proxy.NewLoadBalancerRR()
+		proxier := proxy.NewProxier(loadBalancer, *bindAddress)
+		if err := proxier.InterceptPortals(iptables.New()); err != nil {
+			glog.Errorf("Can't use iptables, so services' portal IPs won't be proxied: %v", err)
+		}
+		// Wire proxier to handle changes to services
+		serviceConfig.RegisterHandler(proxier)
+		// And wire loadBalancer to handle changes to endpoints to services
+		endpointsConfig.RegisterHandler(loadBalancer)
+
+		if *statsPort != 0 {
+			http.Handle("/stats", proxier.Stats())
+			go http.ListenAndServe(net.JoinHostPort(*statsAddress, strconv.Itoa(*statsPort)), nil)
+		}
+	}
 
 	// Just loop forever for now...
 	select {}
@@ -103,7 +103,7 @@ func TestApiExamples(t *testing.T) {
 			return
 		}
 		tested += 1
-		if err := latest.Codec.DecodeInto(data, expectedType); err != nil {
+		if err := latest.StrictCodec.DecodeInto(data, expectedType); err != nil {
 			t.Errorf("%s did not decode correctly: %v\n%s", path, err, string(data))
 			return
 		}
@@ -137,7 +137,7 @@ func TestExamples(t *testing.T) {
 			return
 		}
 		tested += 1
-		if err := latest.Codec.DecodeInto(data, expectedType); err != nil {
+		if err := latest.StrictCodec.DecodeInto(data, expectedType); err != nil {
 			t.Errorf("%s did not decode correctly: %v\n%s", path, err, string(data))
 			return
 		}
@@ -168,7 +168,7 @@ func TestReadme(t *testing.T) {
 	}
 	for _, json := range match[1:] {
 		expectedType := &api.Pod{}
-		if err := latest.Codec.DecodeInto([]byte(json), expectedType); err != nil {
+		if err := latest.StrictCodec.DecodeInto([]byte(json), expectedType); err != nil {
 			t.Errorf("%s did not decode correctly: %v\n%s", path, err, string(data))
 			return
 		}
@@ -24,4 +24,3 @@ export KUBECFG=${KUBECFG-$KUBE_REPO_ROOT/cluster/kubecfg.sh}
 set -x
 
 $KUBECFG stop update-demo
-$KUBECFG rm update-demo
@@ -0,0 +1,47 @@
+#!/bin/bash
+
+# Copyright 2014 Google Inc. All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+# Runs the go benchmarks for the apiserver list/watch paths and the scheduler.
+# The output is in the standard "go test -bench" format, so two runs can be
+# compared with a tool such as benchcmp:
+#
+#   hack/benchmark-go.sh > old.txt
+#   <make changes>
+#   hack/benchmark-go.sh > new.txt
+#   benchcmp old.txt new.txt
+
+set -e
+
+source $(dirname $0)/config-go.sh
+
+# Go to the top of the tree.
+cd "${KUBE_REPO_ROOT}"
+
+# Check for `go` binary and set ${GOPATH}.
+kube::setup_go_environment
+
+cd "${KUBE_TARGET}"
+
+KUBE_BENCH_PKGS=(
+  pkg/apiserver
+  pkg/registry/etcd
+  pkg/scheduler
+  pkg/watch
+)
+
+for pkg in "${KUBE_BENCH_PKGS[@]}"; do
+  go test -run=NONE -bench=. -benchmem "$@" "${KUBE_GO_PACKAGE}/${pkg}"
+done
@@ -0,0 +1,35 @@
+#!/bin/bash
+
+# Copyright 2014 Google Inc. All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+# Runs the density/soak test against an already running cluster and writes
+# a JSON report. Any arguments are passed through to the density binary, e.g.
+#
+#   hack/e2e-density.sh -pods_per_minion=30 -duration=4h -report=density.json
+
+set -e
+
+source $(dirname $0)/../cluster/kube-env.sh
+source $(dirname $0)/../cluster/$KUBERNETES_PROVIDER/util.sh
+
+KUBE_REPO_ROOT="$(dirname $0)/.."
+
+detect-master > /dev/null
+
+"${KUBE_REPO_ROOT}/hack/build-go.sh" cmd/density
+
+"${KUBE_REPO_ROOT}/_output/go/bin/density" \
+  -master="https://${KUBE_MASTER_IP}" \
+  "$@"
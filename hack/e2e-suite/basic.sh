@@ -36,7 +36,6 @@ function remove-quotes() {
 function teardown() {
   echo "Cleaning up test artifacts"
   $KUBECFG stop myNginx
-  $KUBECFG rm myNginx
 }
 
 trap "teardown" EXIT
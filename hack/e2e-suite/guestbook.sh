@@ -36,9 +36,6 @@ POD_LIST_1=$($KUBECFG '-template={{range.Items}}{{.ID}} {{end}}' list pods)
 echo "Pods running: ${POD_LIST_1}"
 
 $KUBECFG stop redisSlaveController
-# Needed until issue #103 gets fixed
-sleep 25
-$KUBECFG rm redisSlaveController
 $KUBECFG delete services/redismaster
 $KUBECFG delete pods/redis-master-2
 
@@ -81,16 +81,17 @@ func NewConflict(kind, name string, err error) error {
 }
 
 // NewInvalid returns an error indicating the item is invalid and cannot be processed.
+// Every error in errs is reported as a cause, so that clients can show all of them
+// at once; errors other than ValidationErrors have no type or field.
 func NewInvalid(kind, name string, errs ErrorList) error {
 	causes := make([]api.StatusCause, 0, len(errs))
 	for i := range errs {
+		cause := api.StatusCause{Message: errs[i].Error()}
 		if err, ok := errs[i].(ValidationError); ok {
-			causes = append(causes, api.StatusCause{
-				Type:    api.CauseType(err.Type),
-				Message: err.Error(),
-				Field:   err.Field,
-			})
+			cause.Type = api.CauseType(err.Type)
+			cause.Field = err.Field
 		}
+		causes = append(causes, cause)
 	}
 	return &statusError{api.Status{
 		Status: api.StatusFailure,
@@ -105,6 +106,20 @@ func NewInvalid(kind, name string, errs ErrorList) error {
 	}}
 }
 
+// NewTimeout returns an error indicating the request could not be completed in
+// time, and that the client should retry it after retryAfterSeconds.
+func NewTimeout(message string, retryAfterSeconds int) error {
+	return &statusError{api.Status{
+		Status: api.StatusFailure,
+		Code:   http.StatusGatewayTimeout,
+		Reason: api.StatusReasonTimeout,
+		Details: &api.StatusDetails{
+			RetryAfterSeconds: retryAfterSeconds,
+		},
+		Message: fmt.Sprintf("the request timed out, retry after %d seconds: %s", retryAfterSeconds, message),
+	}}
+}
+
 // IsNotFound returns true if the specified error was created by NewNotFoundErr.
 func IsNotFound(err error) bool {
 	return reasonForError(err) == api.StatusReasonNotFound
@@ -125,6 +140,11 @@ func IsInvalid(err error) bool {
 	return reasonForError(err) == api.StatusReasonInvalid
 }
 
+// IsTimeout determines if the err is an error which indicates the request timed out.
+func IsTimeout(err error) bool {
+	return reasonForError(err) == api.StatusReasonTimeout
+}
+
 func reasonForError(err error) api.StatusReason {
 	switch t := err.(type) {
 	case *statusError:
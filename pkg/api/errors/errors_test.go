@@ -49,6 +49,9 @@ func TestErrorNew(t *testing.T) {
 	if !IsInvalid(NewInvalid("test", "2", nil)) {
 		t.Errorf("expected to be invalid")
 	}
+	if !IsTimeout(NewTimeout("test", 1)) {
+		t.Errorf("expected to be a timeout")
+	}
 }
 
 func TestNewInvalid(t *testing.T) {
@@ -126,6 +129,23 @@ func TestNewInvalid(t *testing.T) {
 	}
 }
 
+func TestNewInvalidAllCauses(t *testing.T) {
+	errs := ErrorList{
+		NewFieldRequired("id", ""),
+		NewFieldInvalid("labels.foo", "bar baz"),
+		fmt.Errorf("not a field error"),
+	}
+	status := NewInvalid("kind", "name", errs).(*statusError).Status()
+	expected := []api.StatusCause{
+		{Type: api.CauseTypeFieldValueRequired, Field: "id", Message: errs[0].Error()},
+		{Type: api.CauseTypeFieldValueInvalid, Field: "labels.foo", Message: errs[1].Error()},
+		{Message: "not a field error"},
+	}
+	if !reflect.DeepEqual(expected, status.Details.Causes) {
+		t.Errorf("expected %#v, got %#v", expected, status.Details.Causes)
+	}
+}
+
 func Test_reasonForError(t *testing.T) {
 	if e, a := api.StatusReasonUnknown, reasonForError(nil); e != a {
 		t.Errorf("unexpected reason type: %#v", a)
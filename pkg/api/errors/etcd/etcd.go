@@ -43,23 +43,29 @@ func InterpretCreateError(err error, kind, name string) error {
 	}
 }
 
-// InterpretUpdateError converts a generic etcd error on a create
+// InterpretUpdateError converts a generic etcd error on an update
 // operation into the appropriate API error.
 func InterpretUpdateError(err error, kind, name string) error {
 	switch {
 	case tools.IsEtcdTestFailed(err), tools.IsEtcdNodeExist(err):
 		return errors.NewConflict(kind, name, err)
+	case tools.IsEtcdNotFound(err):
+		return errors.NewNotFound(kind, name)
+	case err == tools.ErrResourceVersionRequired:
+		return errors.NewInvalid(kind, name, errors.ErrorList{errors.NewFieldRequired("resourceVersion", 0)})
 	default:
 		return err
 	}
 }
 
-// InterpretDeleteError converts a generic etcd error on a create
+// InterpretDeleteError converts a generic etcd error on a delete
 // operation into the appropriate API error.
 func InterpretDeleteError(err error, kind, name string) error {
 	switch {
 	case tools.IsEtcdNotFound(err):
 		return errors.NewNotFound(kind, name)
+	case tools.IsEtcdTestFailed(err):
+		return errors.NewConflict(kind, name, err)
 	default:
 		return err
 	}
@@ -44,6 +44,9 @@ const (
 	// ValidationErrorTypeNotSupported is used to report valid (as per formatting rules)
 	// values that can not be handled (e.g. an enumerated string).
 	ValidationErrorTypeNotSupported ValidationErrorType = "fieldValueNotSupported"
+	// ValidationErrorTypeUnknown is used to report fields which the object doesn't
+	// have (e.g. a misspelled field name).
+	ValidationErrorTypeUnknown ValidationErrorType = "fieldUnknown"
 )
 
 func ValueOf(t ValidationErrorType) string {
@@ -58,6 +61,8 @@ func ValueOf(t ValidationErrorType) string {
 		return "invalid value"
 	case ValidationErrorTypeNotSupported:
 		return "unsupported value"
+	case ValidationErrorTypeUnknown:
+		return "unknown field"
 	default:
 		glog.Errorf("unrecognized validation type: %#v", t)
 		return ""
@@ -90,6 +95,11 @@ func NewFieldNotSupported(field string, value interface{}) ValidationError {
 	return ValidationError{ValidationErrorTypeNotSupported, field, value}
 }
 
+// NewFieldUnknown returns a ValidationError indicating "unknown field"
+func NewFieldUnknown(field string, value interface{}) ValidationError {
+	return ValidationError{ValidationErrorTypeUnknown, field, value}
+}
+
 // NewFieldDuplicate returns a ValidationError indicating "duplicate value"
 func NewFieldDuplicate(field string, value interface{}) ValidationError {
 	return ValidationError{ValidationErrorTypeDuplicate, field, value}
@@ -47,6 +47,10 @@ func TestMakeFuncs(t *testing.T) {
 			func() ValidationError { return NewFieldRequired("f", "v") },
 			ValidationErrorTypeRequired,
 		},
+		{
+			func() ValidationError { return NewFieldUnknown("f", "v") },
+			ValidationErrorTypeUnknown,
+		},
 	}
 
 	for _, testCase := range testCases {
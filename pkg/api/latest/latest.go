@@ -18,19 +18,38 @@ package latest
 
 import (
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/v1beta1"
-	_ "github.com/GoogleCloudPlatform/kubernetes/pkg/api/v1beta2"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/v1beta2"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
 )
 
 // Version is the string that represents the current external default version
 var Version = "v1beta1"
 
+// Versions lists the external versions this code can speak, oldest first.
+var Versions = []string{"v1beta1", "v1beta2"}
+
+// CodecFor returns the codec for an external version, or false if the version
+// is not one of Versions.
+func CodecFor(version string) (runtime.Codec, bool) {
+	switch version {
+	case "v1beta1":
+		return v1beta1.Codec, true
+	case "v1beta2":
+		return v1beta2.Codec, true
+	}
+	return nil, false
+}
+
 // Codec is the default codec for serializing output that should use
 // the latest supported version.  Use this Codec when writing to
 // disk, a data store that is not dynamically versioned, or in tests.
 // This codec can decode any object that Kubernetes is aware of.
 var Codec = v1beta1.Codec
 
+// StrictCodec is like Codec, but decoding data with fields its objects don't
+// have fails instead of dropping them. Use it for input written by hand.
+var StrictCodec = v1beta1.StrictCodec
+
 // ResourceVersioner describes a default versioner that can handle all types
 // of versioning.
 // TODO: when versioning changes, make this part of each API definition.
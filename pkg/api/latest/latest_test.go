@@ -18,103 +18,55 @@ package latest
 
 import (
 	"encoding/json"
+	"math/rand"
 	"reflect"
 	"testing"
 
 	internal "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	apitesting "github.com/GoogleCloudPlatform/kubernetes/pkg/api/testing"
 	_ "github.com/GoogleCloudPlatform/kubernetes/pkg/api/v1beta1"
 	_ "github.com/GoogleCloudPlatform/kubernetes/pkg/api/v1beta2"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
-	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
-	"github.com/fsouza/go-dockerclient"
-	"github.com/google/gofuzz"
-)
-
-// apiObjectFuzzer can randomly populate api objects.
-var apiObjectFuzzer = fuzz.New().NilChance(.5).NumElements(1, 1).Funcs(
-	func(j *internal.JSONBase, c fuzz.Continue) {
-		// We have to customize the randomization of JSONBases because their
-		// APIVersion and Kind must remain blank in memory.
-		j.APIVersion = ""
-		j.Kind = ""
-		j.ID = c.RandString()
-		// TODO: Fix JSON/YAML packages and/or write custom encoding
-		// for uint64's. Somehow the LS *byte* of this is lost, but
-		// only when all 8 bytes are set.
-		j.ResourceVersion = c.RandUint64() >> 8
-		j.SelfLink = c.RandString()
-
-		var sec, nsec int64
-		c.Fuzz(&sec)
-		c.Fuzz(&nsec)
-		j.CreationTimestamp = util.Unix(sec, nsec).Rfc3339Copy()
-	},
-	func(intstr *util.IntOrString, c fuzz.Continue) {
-		// util.IntOrString will panic if its kind is set wrong.
-		if c.RandBool() {
-			intstr.Kind = util.IntstrInt
-			intstr.IntVal = int(c.RandUint64())
-			intstr.StrVal = ""
-		} else {
-			intstr.Kind = util.IntstrString
-			intstr.IntVal = 0
-			intstr.StrVal = c.RandString()
-		}
-	},
-	func(u64 *uint64, c fuzz.Continue) {
-		// TODO: uint64's are NOT handled right.
-		*u64 = c.RandUint64() >> 8
-	},
-	func(pb map[docker.Port][]docker.PortBinding, c fuzz.Continue) {
-		// This is necessary because keys with nil values get omitted.
-		// TODO: Is this a bug?
-		pb[docker.Port(c.RandString())] = []docker.PortBinding{
-			{c.RandString(), c.RandString()},
-			{c.RandString(), c.RandString()},
-		}
-	},
-	func(pm map[string]docker.PortMapping, c fuzz.Continue) {
-		// This is necessary because keys with nil values get omitted.
-		// TODO: Is this a bug?
-		pm[c.RandString()] = docker.PortMapping{
-			c.RandString(): c.RandString(),
-		}
-	},
 )
 
+// TestInternalRoundTrip converts every internal type to each version and back.
 func TestInternalRoundTrip(t *testing.T) {
-	latest := "v1beta2"
-
-	for k, _ := range internal.Scheme.KnownTypes("") {
-		obj, err := internal.Scheme.New("", k)
-		if err != nil {
-			t.Errorf("%s: unexpected error: %v", k, err)
-			continue
-		}
-		apiObjectFuzzer.Fuzz(obj)
-
-		newer, err := internal.Scheme.New(latest, k)
-		if err != nil {
-			t.Errorf("%s: unexpected error: %v", k, err)
-			continue
-		}
-
-		if err := internal.Scheme.Convert(obj, newer); err != nil {
-			t.Errorf("unable to convert %#v to %#v: %v", obj, newer, err)
-		}
-
-		actual, err := internal.Scheme.New("", k)
-		if err != nil {
-			t.Errorf("%s: unexpected error: %v", k, err)
-			continue
-		}
-
-		if err := internal.Scheme.Convert(newer, actual); err != nil {
-			t.Errorf("unable to convert %#v to %#v: %v", newer, actual, err)
-		}
-
-		if !reflect.DeepEqual(obj, actual) {
-			t.Errorf("%s: diff %s", k, runtime.ObjectDiff(obj, actual))
+	seed := rand.Int63()
+	t.Logf("fuzzing with seed %d", seed)
+	fuzzer := apitesting.NewFuzzer(rand.NewSource(seed))
+
+	for _, version := range Versions {
+		for k := range internal.Scheme.KnownTypes("") {
+			obj, err := internal.Scheme.New("", k)
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", k, err)
+				continue
+			}
+			fuzzer.Fuzz(obj)
+
+			external, err := internal.Scheme.New(version, k)
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", k, err)
+				continue
+			}
+
+			if err := internal.Scheme.Convert(obj, external); err != nil {
+				t.Errorf("unable to convert %#v to %#v: %v", obj, external, err)
+			}
+
+			actual, err := internal.Scheme.New("", k)
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", k, err)
+				continue
+			}
+
+			if err := internal.Scheme.Convert(external, actual); err != nil {
+				t.Errorf("unable to convert %#v to %#v: %v", external, actual, err)
+			}
+
+			if !reflect.DeepEqual(obj, actual) {
+				t.Errorf("%s %s: diff %s", version, k, runtime.ObjectDiff(obj, actual))
+			}
 		}
 	}
 }
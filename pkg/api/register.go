@@ -17,6 +17,7 @@ limitations under the License.
 package api
 
 import (
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/fields"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
 )
 
@@ -40,4 +41,7 @@ func init() {
 		&EndpointsList{},
 		&Binding{},
 	)
+	fields.Register("pods", "ID", "DesiredState.Status", "DesiredState.Host")
+	fields.Register("services", "ID")
+	fields.Register("endpoints", "ID")
 }
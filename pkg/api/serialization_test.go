@@ -18,96 +18,23 @@ package api_test
 
 import (
 	"flag"
+	"math/rand"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/latest"
-	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/v1beta1"
-	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/v1beta2"
+	apitesting "github.com/GoogleCloudPlatform/kubernetes/pkg/api/testing"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
-	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
-	"github.com/fsouza/go-dockerclient"
 	"github.com/google/gofuzz"
 )
 
 var fuzzIters = flag.Int("fuzz_iters", 40, "How many fuzzing iterations to do.")
 
-// apiObjectFuzzer can randomly populate api objects.
-var apiObjectFuzzer = fuzz.New().NilChance(.5).NumElements(1, 1).Funcs(
-	func(j *runtime.PluginBase, c fuzz.Continue) {
-		// Do nothing; this struct has only a Kind field and it must stay blank in memory.
-	},
-	func(j *runtime.JSONBase, c fuzz.Continue) {
-		// We have to customize the randomization of JSONBases because their
-		// APIVersion and Kind must remain blank in memory.
-		j.APIVersion = ""
-		j.Kind = ""
-		j.ID = c.RandString()
-		// TODO: Fix JSON/YAML packages and/or write custom encoding
-		// for uint64's. Somehow the LS *byte* of this is lost, but
-		// only when all 8 bytes are set.
-		j.ResourceVersion = c.RandUint64() >> 8
-		j.SelfLink = c.RandString()
-
-		var sec, nsec int64
-		c.Fuzz(&sec)
-		c.Fuzz(&nsec)
-		j.CreationTimestamp = util.Unix(sec, nsec).Rfc3339Copy()
-	},
-	func(j *api.JSONBase, c fuzz.Continue) {
-		// We have to customize the randomization of JSONBases because their
-		// APIVersion and Kind must remain blank in memory.
-		j.APIVersion = ""
-		j.Kind = ""
-		j.ID = c.RandString()
-		// TODO: Fix JSON/YAML packages and/or write custom encoding
-		// for uint64's. Somehow the LS *byte* of this is lost, but
-		// only when all 8 bytes are set.
-		j.ResourceVersion = c.RandUint64() >> 8
-		j.SelfLink = c.RandString()
-
-		var sec, nsec int64
-		c.Fuzz(&sec)
-		c.Fuzz(&nsec)
-		j.CreationTimestamp = util.Unix(sec, nsec).Rfc3339Copy()
-	},
-	func(intstr *util.IntOrString, c fuzz.Continue) {
-		// util.IntOrString will panic if its kind is set wrong.
-		if c.RandBool() {
-			intstr.Kind = util.IntstrInt
-			intstr.IntVal = int(c.RandUint64())
-			intstr.StrVal = ""
-		} else {
-			intstr.Kind = util.IntstrString
-			intstr.IntVal = 0
-			intstr.StrVal = c.RandString()
-		}
-	},
-	func(u64 *uint64, c fuzz.Continue) {
-		// TODO: uint64's are NOT handled right.
-		*u64 = c.RandUint64() >> 8
-	},
-	func(pb map[docker.Port][]docker.PortBinding, c fuzz.Continue) {
-		// This is necessary because keys with nil values get omitted.
-		// TODO: Is this a bug?
-		pb[docker.Port(c.RandString())] = []docker.PortBinding{
-			{c.RandString(), c.RandString()},
-			{c.RandString(), c.RandString()},
-		}
-	},
-	func(pm map[string]docker.PortMapping, c fuzz.Continue) {
-		// This is necessary because keys with nil values get omitted.
-		// TODO: Is this a bug?
-		pm[c.RandString()] = docker.PortMapping{
-			c.RandString(): c.RandString(),
-		}
-	},
-)
-
-func runTest(t *testing.T, codec runtime.Codec, source runtime.Object) {
+func runTest(t *testing.T, fuzzer *fuzz.Fuzzer, codec runtime.Codec, source runtime.Object) {
 	name := reflect.TypeOf(source).Elem().Name()
-	apiObjectFuzzer.Fuzz(source)
+	fuzzer.Fuzz(source)
 	j, err := runtime.FindJSONBase(source)
 	if err != nil {
 		t.Fatalf("Unexpected error %v for %#v", err, source)
@@ -144,29 +71,45 @@ func runTest(t *testing.T, codec runtime.Codec, source runtime.Object) {
 	}
 }
 
+// TestTypes round trips every internal type through every version's codec, so
+// new types and fields are covered as soon as they're registered.
 func TestTypes(t *testing.T) {
-	table := []runtime.Object{
-		&api.PodList{},
-		&api.Pod{},
-		&api.ServiceList{},
-		&api.Service{},
-		&api.ReplicationControllerList{},
-		&api.ReplicationController{},
-		&api.MinionList{},
-		&api.Minion{},
-		&api.Status{},
-		&api.ServerOpList{},
-		&api.ServerOp{},
-		&api.ContainerManifestList{},
-		&api.Endpoints{},
-		&api.Binding{},
+	seed := rand.Int63()
+	t.Logf("fuzzing with seed %d", seed)
+	fuzzer := apitesting.NewFuzzer(rand.NewSource(seed))
+
+	codecs := []runtime.Codec{api.Codec}
+	for _, version := range latest.Versions {
+		codec, ok := latest.CodecFor(version)
+		if !ok {
+			t.Fatalf("no codec for %s", version)
+		}
+		// Everything a version encodes must also decode strictly.
+		codecs = append(codecs, codec, runtime.StrictCodecFor(api.Scheme, version))
 	}
-	for _, item := range table {
+	for kind := range api.Scheme.KnownTypes("") {
 		// Try a few times, since runTest uses random values.
 		for i := 0; i < *fuzzIters; i++ {
-			runTest(t, v1beta1.Codec, item)
-			runTest(t, v1beta2.Codec, item)
-			runTest(t, api.Codec, item)
+			for _, codec := range codecs {
+				item, err := api.Scheme.New("", kind)
+				if err != nil {
+					t.Fatalf("%s: unexpected error: %v", kind, err)
+				}
+				runTest(t, fuzzer, codec, item)
+			}
+		}
+	}
+}
+
+func TestUnmatchedFields(t *testing.T) {
+	// Every field must either be converted automatically, by having the same name
+	// in each version, or be handled by a conversion func.
+	for _, version := range []string{"v1beta1", "v1beta2"} {
+		if unmatched := api.Scheme.UnmatchedFields("", version); len(unmatched) != 0 {
+			t.Errorf("fields can't be converted to %s:\n%s", version, strings.Join(unmatched, "\n"))
+		}
+		if unmatched := api.Scheme.UnmatchedFields(version, ""); len(unmatched) != 0 {
+			t.Errorf("fields can't be converted from %s:\n%s", version, strings.Join(unmatched, "\n"))
 		}
 	}
 }
@@ -174,6 +117,16 @@ func TestTypes(t *testing.T) {
 func TestEncode_Ptr(t *testing.T) {
 	pod := &api.Pod{
 		Labels: map[string]string{"name": "foo"},
+		DesiredState: api.PodState{
+			Manifest: api.ContainerManifest{
+				RestartPolicy: api.RestartPolicy{Always: &api.RestartPolicyAlways{}},
+			},
+		},
+		CurrentState: api.PodState{
+			Manifest: api.ContainerManifest{
+				RestartPolicy: api.RestartPolicy{Always: &api.RestartPolicyAlways{}},
+			},
+		},
 	}
 	obj := runtime.Object(pod)
 	data, err := latest.Codec.Encode(obj)
@@ -14,20 +14,7 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
-package registrytest
-
-import (
-	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
-	"github.com/GoogleCloudPlatform/kubernetes/pkg/scheduler"
-)
-
-type Scheduler struct {
-	Err     error
-	Pod     api.Pod
-	Machine string
-}
-
-func (s *Scheduler) Schedule(pod api.Pod, lister scheduler.MinionLister) (string, error) {
-	s.Pod = pod
-	return s.Machine, s.Err
-}
+// Package testing provides helpers for tests which exercise every API type,
+// such as a fuzzer which fills API objects with random values that survive a
+// round trip through any version.
+package testing
@@ -0,0 +1,130 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testing
+
+import (
+	"math/rand"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/fsouza/go-dockerclient"
+	"github.com/google/gofuzz"
+)
+
+// NewFuzzer returns a fuzzer which randomly populates internal API objects,
+// drawing from src. The values it picks are ones which encoding, decoding and
+// conversion are expected to preserve, so that any difference after a round
+// trip is a bug.
+func NewFuzzer(src rand.Source) *fuzz.Fuzzer {
+	return fuzz.New().NilChance(.5).NumElements(1, 1).RandSource(src).Funcs(
+		func(j *runtime.PluginBase, c fuzz.Continue) {
+			// Do nothing; this struct has only a Kind field and it must stay blank in memory.
+		},
+		func(j *runtime.JSONBase, c fuzz.Continue) {
+			// We have to customize the randomization of JSONBases because their
+			// APIVersion and Kind must remain blank in memory.
+			j.APIVersion = ""
+			j.Kind = ""
+			j.ID = c.RandString()
+			// TODO: Fix JSON/YAML packages and/or write custom encoding
+			// for uint64's. Somehow the LS *byte* of this is lost, but
+			// only when all 8 bytes are set.
+			j.ResourceVersion = c.RandUint64() >> 8
+			j.SelfLink = c.RandString()
+
+			var sec, nsec int64
+			c.Fuzz(&sec)
+			c.Fuzz(&nsec)
+			j.CreationTimestamp = util.Unix(sec, nsec).Rfc3339Copy()
+		},
+		func(j *api.JSONBase, c fuzz.Continue) {
+			// We have to customize the randomization of JSONBases because their
+			// APIVersion and Kind must remain blank in memory.
+			j.APIVersion = ""
+			j.Kind = ""
+			j.ID = c.RandString()
+			// TODO: Fix JSON/YAML packages and/or write custom encoding
+			// for uint64's. Somehow the LS *byte* of this is lost, but
+			// only when all 8 bytes are set.
+			j.ResourceVersion = c.RandUint64() >> 8
+			j.SelfLink = c.RandString()
+
+			var sec, nsec int64
+			c.Fuzz(&sec)
+			c.Fuzz(&nsec)
+			j.CreationTimestamp = util.Unix(sec, nsec).Rfc3339Copy()
+		},
+		func(intstr *util.IntOrString, c fuzz.Continue) {
+			// util.IntOrString will panic if its kind is set wrong.
+			if c.RandBool() {
+				intstr.Kind = util.IntstrInt
+				intstr.IntVal = int(c.RandUint64())
+				intstr.StrVal = ""
+			} else {
+				intstr.Kind = util.IntstrString
+				intstr.IntVal = 0
+				intstr.StrVal = c.RandString()
+			}
+		},
+		func(u64 *uint64, c fuzz.Continue) {
+			// TODO: uint64's are NOT handled right.
+			*u64 = c.RandUint64() >> 8
+		},
+		func(pb map[docker.Port][]docker.PortBinding, c fuzz.Continue) {
+			// This is necessary because keys with nil values get omitted.
+			// TODO: Is this a bug?
+			pb[docker.Port(c.RandString())] = []docker.PortBinding{
+				{HostIp: c.RandString(), HostPort: c.RandString()},
+				{HostIp: c.RandString(), HostPort: c.RandString()},
+			}
+		},
+		func(pm map[string]docker.PortMapping, c fuzz.Continue) {
+			// This is necessary because keys with nil values get omitted.
+			// TODO: Is this a bug?
+			pm[c.RandString()] = docker.PortMapping{
+				c.RandString(): c.RandString(),
+			}
+		},
+		func(p *api.Protocol, c fuzz.Continue) {
+			// Protocols are upper cased when decoded, and default to TCP.
+			*p = api.Protocol(strings.ToUpper(c.RandString()))
+			if *p == "" {
+				*p = api.ProtocolTCP
+			}
+		},
+		func(a *api.AffinityType, c fuzz.Continue) {
+			// Session affinity defaults to none.
+			*a = api.AffinityType(c.RandString())
+			if *a == "" {
+				*a = api.AffinityTypeNone
+			}
+		},
+		func(rp *api.RestartPolicy, c fuzz.Continue) {
+			// Exactly one restart policy is set, Always by default.
+			switch c.Intn(3) {
+			case 0:
+				rp.Always = &api.RestartPolicyAlways{}
+			case 1:
+				rp.OnFailure = &api.RestartPolicyOnFailure{}
+			case 2:
+				rp.Never = &api.RestartPolicyNever{}
+			}
+		},
+	)
+}
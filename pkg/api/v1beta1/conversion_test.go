@@ -192,3 +192,15 @@ func TestMinionListConversionToOld(t *testing.T) {
 		t.Errorf("Expected: %#v, got %#v", e, a)
 	}
 }
+
+func TestProtocolConversion(t *testing.T) {
+	for _, protocol := range []v1beta1.Protocol{"tcp", "TCP", "Tcp"} {
+		var got newer.Protocol
+		if err := Convert(&protocol, &got); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != newer.ProtocolTCP {
+			t.Errorf("expected %v, got %v", newer.ProtocolTCP, got)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	newer "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+)
+
+func init() {
+	newer.Scheme.AddDefaultingFuncs(
+		func(obj *Port) {
+			if obj.Protocol == "" {
+				obj.Protocol = ProtocolTCP
+			}
+		},
+		func(obj *Service) {
+			if obj.Protocol == "" {
+				obj.Protocol = ProtocolTCP
+			}
+			if obj.SessionAffinity == "" {
+				obj.SessionAffinity = AffinityTypeNone
+			}
+		},
+		// Containers are restarted unless the manifest says otherwise.
+		func(obj *RestartPolicy) {
+			if obj.Always == nil && obj.OnFailure == nil && obj.Never == nil {
+				obj.Always = &RestartPolicyAlways{}
+			}
+		},
+	)
+}
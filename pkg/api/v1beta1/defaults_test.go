@@ -0,0 +1,67 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1_test
+
+import (
+	"testing"
+
+	newer "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/v1beta1"
+)
+
+func TestDefaults(t *testing.T) {
+	data := []byte(`{
+		"kind": "Pod", "apiVersion": "v1beta1", "id": "foo",
+		"desiredState": {"manifest": {"containers": [{"name": "c", "ports": [{"containerPort": 80}]}]}}
+	}`)
+	obj, err := v1beta1.Codec.Decode(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pod := obj.(*newer.Pod)
+	if e, a := newer.ProtocolTCP, pod.DesiredState.Manifest.Containers[0].Ports[0].Protocol; e != a {
+		t.Errorf("expected protocol %v, got %v", e, a)
+	}
+	if pod.DesiredState.Manifest.RestartPolicy.Always == nil {
+		t.Errorf("expected the restart policy to default to always, got %#v", pod.DesiredState.Manifest.RestartPolicy)
+	}
+
+	data = []byte(`{
+		"kind": "Pod", "apiVersion": "v1beta1", "id": "foo",
+		"desiredState": {"manifest": {"restartPolicy": {"never": {}}, "containers": [{"name": "c", "ports": [{"containerPort": 80, "protocol": "udp"}]}]}}
+	}`)
+	pod = &newer.Pod{}
+	if err := v1beta1.Codec.DecodeInto(data, pod); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e, a := newer.ProtocolUDP, pod.DesiredState.Manifest.Containers[0].Ports[0].Protocol; e != a {
+		t.Errorf("expected protocol %v, got %v", e, a)
+	}
+	if rp := pod.DesiredState.Manifest.RestartPolicy; rp.Always != nil || rp.Never == nil {
+		t.Errorf("expected the restart policy to be left alone, got %#v", rp)
+	}
+
+	data = []byte(`{"kind": "Service", "apiVersion": "v1beta1", "id": "foo", "port": 80}`)
+	obj, err = v1beta1.Codec.Decode(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	service := obj.(*newer.Service)
+	if service.Protocol != newer.ProtocolTCP || service.SessionAffinity != newer.AffinityTypeNone {
+		t.Errorf("expected the service's defaults to be set, got %#v", service)
+	}
+}
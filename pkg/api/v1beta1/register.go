@@ -18,12 +18,16 @@ package v1beta1
 
 import (
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/fields"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
 )
 
 // Codec encodes internal objects to the v1beta1 scheme
 var Codec = runtime.CodecFor(api.Scheme, "v1beta1")
 
+// StrictCodec is like Codec, but refuses to decode data with unknown fields.
+var StrictCodec = runtime.StrictCodecFor(api.Scheme, "v1beta1")
+
 func init() {
 	api.Scheme.AddKnownTypes("v1beta1",
 		&PodList{},
@@ -42,4 +46,19 @@ func init() {
 		&EndpointsList{},
 		&Binding{},
 	)
+	fields.AddConversionFunc("v1beta1", "pods", fields.RenameConversionFunc(map[string]string{
+		"id":                  "ID",
+		"desiredState.status": "DesiredState.Status",
+		"desiredState.host":   "DesiredState.Host",
+		// Clients selected on the internal names before field selectors were versioned.
+		"ID":                  "ID",
+		"DesiredState.Status": "DesiredState.Status",
+		"DesiredState.Host":   "DesiredState.Host",
+	}))
+	for _, resource := range []string{"services", "endpoints"} {
+		fields.AddConversionFunc("v1beta1", resource, fields.RenameConversionFunc(map[string]string{
+			"id": "ID",
+			"ID": "ID",
+		}))
+	}
 }
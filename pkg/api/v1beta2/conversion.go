@@ -17,6 +17,8 @@ limitations under the License.
 package v1beta2
 
 import (
+	"strings"
+
 	newer "github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/conversion"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
@@ -24,18 +26,29 @@ import (
 
 func init() {
 	newer.Scheme.AddConversionFuncs(
+		// Protocols are case insensitive on input.
+		func(in *newer.Protocol, out *Protocol, s conversion.Scope) error {
+			*out = Protocol(*in)
+			return nil
+		},
+		func(in *Protocol, out *newer.Protocol, s conversion.Scope) error {
+			*out = newer.Protocol(strings.ToUpper(string(*in)))
+			return nil
+		},
+
 		// EnvVar's Key is deprecated in favor of Name.
 		func(in *newer.EnvVar, out *EnvVar, s conversion.Scope) error {
-			out.Value = in.Value
+			if err := s.DefaultConvert(in, out, conversion.IgnoreMissingFields); err != nil {
+				return err
+			}
 			out.Key = in.Name
-			out.Name = in.Name
 			return nil
 		},
 		func(in *EnvVar, out *newer.EnvVar, s conversion.Scope) error {
-			out.Value = in.Value
-			if in.Name != "" {
-				out.Name = in.Name
-			} else {
+			if err := s.DefaultConvert(in, out, 0); err != nil {
+				return err
+			}
+			if in.Name == "" {
 				out.Name = in.Key
 			}
 			return nil
@@ -63,17 +76,18 @@ func init() {
 
 		// MinionList.Items had a wrong name in v1beta1
 		func(in *newer.MinionList, out *MinionList, s conversion.Scope) error {
-			s.Convert(&in.JSONBase, &out.JSONBase, 0)
-			s.Convert(&in.Items, &out.Items, 0)
+			if err := s.DefaultConvert(in, out, conversion.IgnoreMissingFields); err != nil {
+				return err
+			}
 			out.Minions = out.Items
 			return nil
 		},
 		func(in *MinionList, out *newer.MinionList, s conversion.Scope) error {
-			s.Convert(&in.JSONBase, &out.JSONBase, 0)
+			if err := s.DefaultConvert(in, out, 0); err != nil {
+				return err
+			}
 			if len(in.Items) == 0 {
-				s.Convert(&in.Minions, &out.Items, 0)
-			} else {
-				s.Convert(&in.Items, &out.Items, 0)
+				return s.Convert(&in.Minions, &out.Items, 0)
 			}
 			return nil
 		},
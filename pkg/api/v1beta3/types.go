@@ -60,6 +60,8 @@ type ContainerManifest struct {
 	Volumes       []Volume      `yaml:"volumes" json:"volumes"`
 	Containers    []Container   `yaml:"containers" json:"containers"`
 	RestartPolicy RestartPolicy `json:"restartPolicy,omitempty" yaml:"restartPolicy,omitempty"`
+	// Optional: Annotations are attached to the Docker labels of every container in the pod.
+	Annotations map[string]string `json:"annotations,omitempty" yaml:"annotations,omitempty"`
 }
 
 // ContainerManifestList is used to communicate container manifests to kubelet.
@@ -100,6 +102,16 @@ type HostDirectory struct {
 
 type EmptyDirectory struct{}
 
+// Protocol defines network protocols supported for things like container ports.
+type Protocol string
+
+const (
+	// ProtocolTCP is the TCP protocol.
+	ProtocolTCP Protocol = "TCP"
+	// ProtocolUDP is the UDP protocol.
+	ProtocolUDP Protocol = "UDP"
+)
+
 // Port represents a network port in a single container.
 type Port struct {
 	// Optional: If specified, this must be a DNS_LABEL.  Each named port
@@ -110,7 +122,7 @@ type Port struct {
 	// Required: This must be a valid port number, 0 < x < 65536.
 	ContainerPort int `yaml:"containerPort" json:"containerPort"`
 	// Optional: Supports "TCP" and "UDP".  Defaults to "TCP".
-	Protocol string `yaml:"protocol,omitempty" json:"protocol,omitempty"`
+	Protocol Protocol `yaml:"protocol,omitempty" json:"protocol,omitempty"`
 	// Optional: What host IP to bind the external port to.
 	HostIP string `yaml:"hostIP,omitempty" json:"hostIP,omitempty"`
 }
@@ -193,8 +205,29 @@ type Container struct {
 	VolumeMounts  []VolumeMount  `yaml:"volumeMounts,omitempty" json:"volumeMounts,omitempty"`
 	LivenessProbe *LivenessProbe `yaml:"livenessProbe,omitempty" json:"livenessProbe,omitempty"`
 	Lifecycle     *Lifecycle     `yaml:"lifecycle,omitempty" json:"lifecycle,omitempty"`
+	// Optional: IDs of services that must accept TCP connections before this
+	// container is started. Until they do, the container is not created.
+	WaitFor []string `yaml:"waitFor,omitempty" json:"waitFor,omitempty"`
+	// Optional: Defaults to no soft limit.
+	SoftMemoryLimit *SoftMemoryLimit `yaml:"softMemoryLimit,omitempty" json:"softMemoryLimit,omitempty"`
 	// Optional: Default to false.
 	Privileged bool `json:"privileged,omitempty" yaml:"privileged,omitempty"`
+	// Optional: Images to use instead of Image on nodes of other platforms,
+	// keyed by "<os>/<arch>" as reported by Go, e.g. "linux/arm".
+	PlatformImages map[string]string `json:"platformImages,omitempty" yaml:"platformImages,omitempty"`
+	// Optional: Annotations are attached to the container's Docker labels.
+	Annotations map[string]string `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+}
+
+// SoftMemoryLimit describes when a container should be restarted because its
+// memory usage has grown too large, e.g. because it leaks. Unlike Memory it is
+// enforced by the kubelet restarting the container, never by an OOM kill.
+type SoftMemoryLimit struct {
+	// Required: Memory usage, in bytes, above which the container is restarted.
+	Memory int `yaml:"memory" json:"memory"`
+	// Optional: How long usage must stay above Memory before the container is
+	// restarted. Defaults to 0, restarting as soon as the excess is observed.
+	DurationSeconds int `yaml:"durationSeconds,omitempty" json:"durationSeconds,omitempty"`
 }
 
 // Handler defines a specific action that should be taken
@@ -316,6 +349,14 @@ type PodState struct {
 	HostIP   string            `json:"hostIP,omitempty" yaml:"hostIP,omitempty"`
 	PodIP    string            `json:"podIP,omitempty" yaml:"podIP,omitempty"`
 
+	// Transition timestamps, only meaningful in the current state. ScheduledAt
+	// is set when the pod is bound to a host, StartedAt when the kubelet
+	// started the pod's network container, and ReadyAt when the last of the
+	// manifest's containers started running.
+	ScheduledAt util.Time `json:"scheduledAt,omitempty" yaml:"scheduledAt,omitempty"`
+	StartedAt   util.Time `json:"startedAt,omitempty" yaml:"startedAt,omitempty"`
+	ReadyAt     util.Time `json:"readyAt,omitempty" yaml:"readyAt,omitempty"`
+
 	// The key of this map is the *name* of the container within the manifest; it has one
 	// entry per container in the manifest. The value of this map is currently the output
 	// of `docker inspect`. This output format is *not* final and should not be relied
@@ -339,6 +380,15 @@ type Pod struct {
 	Labels       map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
 	DesiredState PodState          `json:"desiredState,omitempty" yaml:"desiredState,omitempty"`
 	CurrentState PodState          `json:"currentState,omitempty" yaml:"currentState,omitempty"`
+	// NodeSelector is a selector which must be true for the pod to fit on a node,
+	// i.e. every label it lists must be set to the same value on the node.
+	NodeSelector map[string]string `json:"nodeSelector,omitempty" yaml:"nodeSelector,omitempty"`
+	// AntiAffinitySelector is a selector for pods which must not run on the same
+	// node as this pod. Pods it matches keep this pod off their nodes, and vice versa.
+	AntiAffinitySelector map[string]string `json:"antiAffinitySelector,omitempty" yaml:"antiAffinitySelector,omitempty"`
+	// Platforms lists the "<os>/<arch>" pairs, e.g. "linux/amd64", of the nodes the
+	// pod can run on. Empty means any node.
+	Platforms []string `json:"platforms,omitempty" yaml:"platforms,omitempty"`
 }
 
 func (*Pod) IsAnAPIObject() {}
@@ -350,6 +400,18 @@ type ReplicationControllerState struct {
 	PodTemplate     PodTemplate       `json:"podTemplate,omitempty" yaml:"podTemplate,omitempty"`
 }
 
+// ReplicationControllerStatus is the status of a replication controller's pods
+// as last observed by the replication manager.
+type ReplicationControllerStatus struct {
+	// Replicas is the number of active pods matched by the replica selector.
+	Replicas int `json:"replicas" yaml:"replicas"`
+	// FullyLabeledReplicas is the number of those pods which carry every label
+	// of the pod template.
+	FullyLabeledReplicas int `json:"fullyLabeledReplicas" yaml:"fullyLabeledReplicas"`
+	// ReadyReplicas is the number of those pods which are running.
+	ReadyReplicas int `json:"readyReplicas" yaml:"readyReplicas"`
+}
+
 // ReplicationControllerList is a collection of replication controllers.
 type ReplicationControllerList struct {
 	JSONBase `json:",inline" yaml:",inline"`
@@ -361,17 +423,21 @@ func (*ReplicationControllerList) IsAnAPIObject() {}
 // ReplicationController represents the configuration of a replication controller.
 type ReplicationController struct {
 	JSONBase     `json:",inline" yaml:",inline"`
-	DesiredState ReplicationControllerState `json:"desiredState,omitempty" yaml:"desiredState,omitempty"`
-	CurrentState ReplicationControllerState `json:"currentState,omitempty" yaml:"currentState,omitempty"`
-	Labels       map[string]string          `json:"labels,omitempty" yaml:"labels,omitempty"`
+	DesiredState ReplicationControllerState  `json:"desiredState,omitempty" yaml:"desiredState,omitempty"`
+	CurrentState ReplicationControllerState  `json:"currentState,omitempty" yaml:"currentState,omitempty"`
+	Status       ReplicationControllerStatus `json:"status,omitempty" yaml:"status,omitempty"`
+	Labels       map[string]string           `json:"labels,omitempty" yaml:"labels,omitempty"`
 }
 
 func (*ReplicationController) IsAnAPIObject() {}
 
 // PodTemplate holds the information used for creating pods.
 type PodTemplate struct {
-	DesiredState PodState          `json:"desiredState,omitempty" yaml:"desiredState,omitempty"`
-	Labels       map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+	DesiredState         PodState          `json:"desiredState,omitempty" yaml:"desiredState,omitempty"`
+	Labels               map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+	NodeSelector         map[string]string `json:"nodeSelector,omitempty" yaml:"nodeSelector,omitempty"`
+	AntiAffinitySelector map[string]string `json:"antiAffinitySelector,omitempty" yaml:"antiAffinitySelector,omitempty"`
+	Platforms            []string          `json:"platforms,omitempty" yaml:"platforms,omitempty"`
 }
 
 // ServiceList holds a list of services.
@@ -382,6 +448,23 @@ type ServiceList struct {
 
 func (*ServiceList) IsAnAPIObject() {}
 
+// AffinityType is the session affinity of a service.
+type AffinityType string
+
+const (
+	// AffinityTypeClientIP means connections from the same client IP go to the same endpoint.
+	AffinityTypeClientIP AffinityType = "ClientIP"
+	// AffinityTypeNone means no session affinity.
+	AffinityTypeNone AffinityType = "None"
+)
+
+// ServiceStatus is the state of a service's resources, as last observed by the system.
+type ServiceStatus struct {
+	// ExternalIP is the address of the service's external load balancer, if
+	// it has one.
+	ExternalIP string `json:"externalIP,omitempty" yaml:"externalIP,omitempty"`
+}
+
 // Service is a named abstraction of software service (for example, mysql) consisting of local port
 // (for example 3306) that the proxy listens on, and the selector that determines which pods
 // will answer requests sent through the proxy.
@@ -391,7 +474,7 @@ type Service struct {
 	// Required.
 	Port int `json:"port" yaml:"port"`
 	// Optional: Supports "TCP" and "UDP".  Defaults to "TCP".
-	Protocol string `yaml:"protocol,omitempty" json:"protocol,omitempty"`
+	Protocol Protocol `yaml:"protocol,omitempty" json:"protocol,omitempty"`
 
 	// This service's labels.
 	Labels map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
@@ -403,6 +486,23 @@ type Service struct {
 	// ContainerPort is the name of the port on the container to direct traffic to.
 	// Optional, if unspecified use the first port on the container.
 	ContainerPort util.IntOrString `json:"containerPort,omitempty" yaml:"containerPort,omitempty"`
+
+	// Optional: "ClientIP" sends all connections from one client to the same
+	// endpoint for a while.  Defaults to "None".
+	SessionAffinity AffinityType `json:"sessionAffinity,omitempty" yaml:"sessionAffinity,omitempty"`
+
+	// Optional: addresses of minions' external interfaces on which the
+	// service's port is also exposed, so that it can be reached from outside
+	// the cluster without an external load balancer.
+	PublicIPs []string `json:"publicIPs,omitempty" yaml:"publicIPs,omitempty"`
+
+	// PortalIP is a virtual IP, allocated from the cluster's portal network
+	// when the service is created, at which the service can be reached from
+	// any minion.  It is set by the system.
+	PortalIP string `json:"portalIP,omitempty" yaml:"portalIP,omitempty"`
+
+	// Status is set by the system and ignored when a service is created or updated.
+	Status ServiceStatus `json:"status,omitempty" yaml:"status,omitempty"`
 }
 
 func (*Service) IsAnAPIObject() {}
@@ -424,12 +524,44 @@ type EndpointsList struct {
 
 func (*EndpointsList) IsAnAPIObject() {}
 
+// NodeResources represents resources on a Kubernetes system node.
+type NodeResources struct {
+	// Capacity represents the available resources.
+	Capacity ResourceList `json:"capacity,omitempty" yaml:"capacity,omitempty"`
+}
+
+// ResourceName is the name identifying various resources in a ResourceList.
+type ResourceName string
+
+const (
+	// ResourceCPU is the CPU capacity of a node, in millicores (1000 = one core).
+	ResourceCPU ResourceName = "cpu"
+	// ResourceMemory is the memory capacity of a node, in bytes.
+	ResourceMemory ResourceName = "memory"
+	// ResourcePods is the maximum number of pods that may be bound to a node.
+	ResourcePods ResourceName = "pods"
+)
+
+// ResourceList is a set of (resource name, quantity) pairs.
+type ResourceList map[ResourceName]util.IntOrString
+
 // Minion is a worker node in Kubernetenes.
 // The name of the minion according to etcd is in JSONBase.ID.
 type Minion struct {
 	JSONBase `json:",inline" yaml:",inline"`
 	// Queried from cloud provider, if available.
 	HostIP string `json:"hostIP,omitempty" yaml:"hostIP,omitempty"`
+	// Resources available on the node.
+	NodeResources NodeResources `json:"resources,omitempty" yaml:"resources,omitempty"`
+	// Labels describing the node, e.g. its hardware, for pods' NodeSelectors to match.
+	Labels map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+	// The operating system and CPU architecture of the node, as reported by Go,
+	// e.g. "linux" and "amd64", for pods' Platforms to match.
+	OperatingSystem string `json:"operatingSystem,omitempty" yaml:"operatingSystem,omitempty"`
+	Architecture    string `json:"architecture,omitempty" yaml:"architecture,omitempty"`
+	// The range of IPs, in CIDR notation, from which pods on the node get their
+	// IPs. If set, the controller manager routes it to the node in the cloud.
+	PodCIDR string `json:"podCIDR,omitempty" yaml:"podCIDR,omitempty"`
 }
 
 func (*Minion) IsAnAPIObject() {}
@@ -492,6 +624,9 @@ type StatusDetails struct {
 	// The Causes array includes more details associated with the StatusReason
 	// failure. Not all StatusReasons may provide detailed causes.
 	Causes []StatusCause `json:"causes,omitempty" yaml:"causes,omitempty"`
+	// If specified, the number of seconds the client should wait before retrying
+	// the operation.
+	RetryAfterSeconds int `json:"retryAfterSeconds,omitempty" yaml:"retryAfterSeconds,omitempty"`
 }
 
 // Values of Status.Status
@@ -561,6 +696,27 @@ const (
 	//                   field attributes will be set.
 	// Status code 422
 	StatusReasonInvalid StatusReason = "invalid"
+
+	// StatusReasonTimeout means the server could not complete the request in time,
+	// for instance because its storage was unreachable. The request may succeed if
+	// it is retried unchanged.
+	// Details (optional):
+	//   "retryAfterSeconds" int - the number of seconds to wait before retrying
+	// Headers (optional):
+	//   "Retry-After" - HTTP header populated with the same value as
+	//                   "retryAfterSeconds".
+	// Status code 504
+	StatusReasonTimeout StatusReason = "timeout"
+
+	// StatusReasonTooManyRequests means the server is handling as many requests
+	// of this kind as it allows. The request may succeed if it is retried later.
+	// Details (optional):
+	//   "retryAfterSeconds" int - the number of seconds to wait before retrying
+	// Headers (optional):
+	//   "Retry-After" - HTTP header populated with the same value as
+	//                   "retryAfterSeconds".
+	// Status code 429
+	StatusReasonTooManyRequests StatusReason = "too_many_requests"
 )
 
 // StatusCause provides more information about an api.Status failure, including
@@ -605,6 +761,9 @@ const (
 	// CauseTypeFieldValueNotSupported is used to report valid (as per formatting rules)
 	// values that can not be handled (e.g. an enumerated string).
 	CauseTypeFieldValueNotSupported CauseType = "fieldValueNotSupported"
+	// CauseTypeFieldUnknown is used to report fields which the object doesn't
+	// have (e.g. a misspelled field name).
+	CauseTypeFieldUnknown CauseType = "fieldUnknown"
 )
 
 // ServerOp is an operation delivered to API clients.
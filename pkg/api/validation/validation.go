@@ -17,6 +17,7 @@ limitations under the License.
 package validation
 
 import (
+	"net"
 	"strings"
 
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
@@ -78,7 +79,9 @@ func validateHostDir(hostDir *api.HostDirectory) errs.ErrorList {
 	return allErrs
 }
 
-var supportedPortProtocols = util.NewStringSet("TCP", "UDP")
+var supportedPortProtocols = util.NewStringSet(string(api.ProtocolTCP), string(api.ProtocolUDP))
+
+var supportedSessionAffinityTypes = util.NewStringSet(string(api.AffinityTypeClientIP), string(api.AffinityTypeNone))
 
 func validatePorts(ports []api.Port) errs.ErrorList {
 	allErrs := errs.ErrorList{}
@@ -105,8 +108,8 @@ func validatePorts(ports []api.Port) errs.ErrorList {
 			pErrs = append(pErrs, errs.NewFieldInvalid("hostPort", port.HostPort))
 		}
 		if len(port.Protocol) == 0 {
-			port.Protocol = "TCP"
-		} else if !supportedPortProtocols.Has(strings.ToUpper(port.Protocol)) {
+			port.Protocol = api.ProtocolTCP
+		} else if !supportedPortProtocols.Has(string(port.Protocol)) {
 			pErrs = append(pErrs, errs.NewFieldNotSupported("protocol", port.Protocol))
 		}
 		allErrs = append(allErrs, pErrs.PrefixIndex(i)...)
@@ -220,6 +223,74 @@ func validateLifecycle(lifecycle *api.Lifecycle) errs.ErrorList {
 	return allErrs
 }
 
+// isPlatform returns true if platform has the form "<os>/<arch>".
+func isPlatform(platform string) bool {
+	parts := strings.Split(platform, "/")
+	return len(parts) == 2 && len(parts[0]) > 0 && len(parts[1]) > 0
+}
+
+func validatePlatforms(platforms []string) errs.ErrorList {
+	allErrs := errs.ErrorList{}
+
+	allPlatforms := util.StringSet{}
+	for i, platform := range platforms {
+		pErrs := errs.ErrorList{}
+		if !isPlatform(platform) {
+			pErrs = append(pErrs, errs.NewFieldInvalid("", platform))
+		} else if allPlatforms.Has(platform) {
+			pErrs = append(pErrs, errs.NewFieldDuplicate("", platform))
+		} else {
+			allPlatforms.Insert(platform)
+		}
+		allErrs = append(allErrs, pErrs.PrefixIndex(i)...)
+	}
+	return allErrs
+}
+
+func validatePlatformImages(images map[string]string) errs.ErrorList {
+	allErrs := errs.ErrorList{}
+	for platform, image := range images {
+		if !isPlatform(platform) {
+			allErrs = append(allErrs, errs.NewFieldInvalid("", platform))
+		} else if len(image) == 0 {
+			allErrs = append(allErrs, errs.NewFieldRequired(platform, image))
+		}
+	}
+	return allErrs
+}
+
+func validateWaitFor(services []string) errs.ErrorList {
+	allErrs := errs.ErrorList{}
+
+	allNames := util.StringSet{}
+	for i, service := range services {
+		sErrs := errs.ErrorList{}
+		if !util.IsDNS952Label(service) {
+			sErrs = append(sErrs, errs.NewFieldInvalid("", service))
+		} else if allNames.Has(service) {
+			sErrs = append(sErrs, errs.NewFieldDuplicate("", service))
+		} else {
+			allNames.Insert(service)
+		}
+		allErrs = append(allErrs, sErrs.PrefixIndex(i)...)
+	}
+	return allErrs
+}
+
+func validateSoftMemoryLimit(limit *api.SoftMemoryLimit, memory int) errs.ErrorList {
+	allErrs := errs.ErrorList{}
+	if limit.Memory <= 0 {
+		allErrs = append(allErrs, errs.NewFieldInvalid("memory", limit.Memory))
+	} else if memory > 0 && limit.Memory >= memory {
+		// The hard limit would always be hit first.
+		allErrs = append(allErrs, errs.NewFieldInvalid("memory", limit.Memory))
+	}
+	if limit.DurationSeconds < 0 {
+		allErrs = append(allErrs, errs.NewFieldInvalid("durationSeconds", limit.DurationSeconds))
+	}
+	return allErrs
+}
+
 func validateContainers(containers []api.Container, volumes util.StringSet) errs.ErrorList {
 	allErrs := errs.ErrorList{}
 
@@ -248,6 +319,11 @@ func validateContainers(containers []api.Container, volumes util.StringSet) errs
 		cErrs = append(cErrs, validatePorts(ctr.Ports).Prefix("ports")...)
 		cErrs = append(cErrs, validateEnv(ctr.Env).Prefix("env")...)
 		cErrs = append(cErrs, validateVolumeMounts(ctr.VolumeMounts, volumes).Prefix("volumeMounts")...)
+		cErrs = append(cErrs, validateWaitFor(ctr.WaitFor).Prefix("waitFor")...)
+		cErrs = append(cErrs, validatePlatformImages(ctr.PlatformImages).Prefix("platformImages")...)
+		if ctr.SoftMemoryLimit != nil {
+			cErrs = append(cErrs, validateSoftMemoryLimit(ctr.SoftMemoryLimit, ctr.Memory).Prefix("softMemoryLimit")...)
+		}
 		allErrs = append(allErrs, cErrs.PrefixIndex(i)...)
 	}
 	// Check for colliding ports across all containers.
@@ -307,13 +383,31 @@ func ValidatePodState(podState *api.PodState) errs.ErrorList {
 	return allErrs
 }
 
+// ValidateLabels tests that every label key is a qualified name, optionally
+// prefixed by a DNS subdomain (e.g. "example.com/name"), and every value is
+// empty or a name, so that they can be written in a selector.
+func ValidateLabels(labels map[string]string) errs.ErrorList {
+	allErrs := errs.ErrorList{}
+	for k, v := range labels {
+		if !util.IsQualifiedName(k) {
+			allErrs = append(allErrs, errs.NewFieldInvalid("key", k))
+		}
+		if !util.IsValidLabelValue(v) {
+			allErrs = append(allErrs, errs.NewFieldInvalid(k, v))
+		}
+	}
+	return allErrs
+}
+
 // ValidatePod tests if required fields in the pod are set.
 func ValidatePod(pod *api.Pod) errs.ErrorList {
 	allErrs := errs.ErrorList{}
 	if len(pod.ID) == 0 {
 		allErrs = append(allErrs, errs.NewFieldRequired("id", pod.ID))
 	}
+	allErrs = append(allErrs, ValidateLabels(pod.Labels).Prefix("labels")...)
 	allErrs = append(allErrs, ValidatePodState(&pod.DesiredState).Prefix("desiredState")...)
+	allErrs = append(allErrs, validatePlatforms(pod.Platforms).Prefix("platforms")...)
 	return allErrs
 }
 
@@ -326,25 +420,52 @@ func ValidateService(service *api.Service) errs.ErrorList {
 		allErrs = append(allErrs, errs.NewFieldInvalid("id", service.ID))
 	}
 	if !util.IsValidPortNum(service.Port) {
-		allErrs = append(allErrs, errs.NewFieldInvalid("Service.Port", service.Port))
+		allErrs = append(allErrs, errs.NewFieldInvalid("port", service.Port))
 	}
 	if len(service.Protocol) == 0 {
-		service.Protocol = "TCP"
-	} else if !supportedPortProtocols.Has(strings.ToUpper(service.Protocol)) {
+		allErrs = append(allErrs, errs.NewFieldRequired("protocol", service.Protocol))
+	} else if !supportedPortProtocols.Has(string(service.Protocol)) {
 		allErrs = append(allErrs, errs.NewFieldNotSupported("protocol", service.Protocol))
 	}
+	if len(service.SessionAffinity) == 0 {
+		allErrs = append(allErrs, errs.NewFieldRequired("sessionAffinity", service.SessionAffinity))
+	} else if !supportedSessionAffinityTypes.Has(string(service.SessionAffinity)) {
+		allErrs = append(allErrs, errs.NewFieldNotSupported("sessionAffinity", service.SessionAffinity))
+	}
+	for _, ip := range service.PublicIPs {
+		if net.ParseIP(ip) == nil {
+			allErrs = append(allErrs, errs.NewFieldInvalid("publicIPs", ip))
+		}
+	}
+	allErrs = append(allErrs, ValidateLabels(service.Labels).Prefix("labels")...)
 	if labels.Set(service.Selector).AsSelector().Empty() {
 		allErrs = append(allErrs, errs.NewFieldRequired("selector", service.Selector))
 	}
 	return allErrs
 }
 
+// ValidateMinion tests if required fields in the minion are set.
+func ValidateMinion(minion *api.Minion) errs.ErrorList {
+	allErrs := errs.ErrorList{}
+	if len(minion.ID) == 0 {
+		allErrs = append(allErrs, errs.NewFieldRequired("id", minion.ID))
+	}
+	if len(minion.PodCIDR) != 0 {
+		if _, _, err := net.ParseCIDR(minion.PodCIDR); err != nil {
+			allErrs = append(allErrs, errs.NewFieldInvalid("podCIDR", minion.PodCIDR))
+		}
+	}
+	allErrs = append(allErrs, ValidateLabels(minion.Labels).Prefix("labels")...)
+	return allErrs
+}
+
 // ValidateReplicationController tests if required fields in the replication controller are set.
 func ValidateReplicationController(controller *api.ReplicationController) errs.ErrorList {
 	allErrs := errs.ErrorList{}
 	if len(controller.ID) == 0 {
 		allErrs = append(allErrs, errs.NewFieldRequired("id", controller.ID))
 	}
+	allErrs = append(allErrs, ValidateLabels(controller.Labels).Prefix("labels")...)
 	allErrs = append(allErrs, ValidateReplicationControllerState(&controller.DesiredState).Prefix("desiredState")...)
 	return allErrs
 }
@@ -360,9 +481,11 @@ func ValidateReplicationControllerState(state *api.ReplicationControllerState) e
 	if !selector.Matches(labels) {
 		allErrs = append(allErrs, errs.NewFieldInvalid("podTemplate.labels", state.PodTemplate))
 	}
+	allErrs = append(allErrs, ValidateLabels(state.PodTemplate.Labels).Prefix("podTemplate.labels")...)
 	if state.Replicas < 0 {
 		allErrs = append(allErrs, errs.NewFieldInvalid("replicas", state.Replicas))
 	}
 	allErrs = append(allErrs, ValidateManifest(&state.PodTemplate.DesiredState.Manifest).Prefix("podTemplate.desiredState.manifest")...)
+	allErrs = append(allErrs, validatePlatforms(state.PodTemplate.Platforms).Prefix("podTemplate.platforms")...)
 	return allErrs
 }
@@ -17,6 +17,7 @@ limitations under the License.
 package validation
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 
@@ -83,7 +84,6 @@ func TestValidatePorts(t *testing.T) {
 		{Name: "easy", ContainerPort: 82, Protocol: "TCP"},
 		{Name: "as", ContainerPort: 83, Protocol: "UDP"},
 		{Name: "do-re-me", ContainerPort: 84},
-		{Name: "baby-you-and-me", ContainerPort: 82, Protocol: "tcp"},
 		{ContainerPort: 85},
 	}
 	if errs := validatePorts(successCase); len(errs) != 0 {
@@ -115,6 +115,7 @@ func TestValidatePorts(t *testing.T) {
 		"invalid container port": {[]api.Port{{ContainerPort: 65536}}, errors.ValidationErrorTypeInvalid, "[0].containerPort"},
 		"invalid host port":      {[]api.Port{{ContainerPort: 80, HostPort: 65536}}, errors.ValidationErrorTypeInvalid, "[0].hostPort"},
 		"invalid protocol":       {[]api.Port{{ContainerPort: 80, Protocol: "ICMP"}}, errors.ValidationErrorTypeNotSupported, "[0].protocol"},
+		"lower case protocol":    {[]api.Port{{ContainerPort: 80, Protocol: "tcp"}}, errors.ValidationErrorTypeNotSupported, "[0].protocol"},
 	}
 	for k, v := range errorCases {
 		errs := validatePorts(v.P)
@@ -198,6 +199,9 @@ func TestValidateContainers(t *testing.T) {
 			},
 		},
 		{Name: "abc-1234", Image: "image", Privileged: true},
+		{Name: "wait-123", Image: "image", WaitFor: []string{"database", "cache"}},
+		{Name: "leaky-123", Image: "image", Memory: 2048, SoftMemoryLimit: &api.SoftMemoryLimit{Memory: 1024, DurationSeconds: 60}},
+		{Name: "multi-arch", Image: "image", PlatformImages: map[string]string{"linux/arm": "image-arm"}},
 	}
 	if errs := validateContainers(successCase, volumes); len(errs) != 0 {
 		t.Errorf("expected success: %v", errs)
@@ -222,6 +226,27 @@ func TestValidateContainers(t *testing.T) {
 		"invalid env var name": {
 			{Name: "abc", Image: "image", Env: []api.EnvVar{{Name: "ev.1"}}},
 		},
+		"invalid waitFor service": {
+			{Name: "abc", Image: "image", WaitFor: []string{"Not_A_Service"}},
+		},
+		"duplicate waitFor service": {
+			{Name: "abc", Image: "image", WaitFor: []string{"database", "database"}},
+		},
+		"invalid platform image platform": {
+			{Name: "abc", Image: "image", PlatformImages: map[string]string{"arm": "image-arm"}},
+		},
+		"zero-length platform image": {
+			{Name: "abc", Image: "image", PlatformImages: map[string]string{"linux/arm": ""}},
+		},
+		"zero soft memory limit": {
+			{Name: "abc", Image: "image", SoftMemoryLimit: &api.SoftMemoryLimit{}},
+		},
+		"soft memory limit above hard limit": {
+			{Name: "abc", Image: "image", Memory: 1024, SoftMemoryLimit: &api.SoftMemoryLimit{Memory: 2048}},
+		},
+		"negative soft memory limit duration": {
+			{Name: "abc", Image: "image", SoftMemoryLimit: &api.SoftMemoryLimit{Memory: 1024, DurationSeconds: -1}},
+		},
 		"unknown volume name": {
 			{Name: "abc", Image: "image", VolumeMounts: []api.VolumeMount{{Name: "anything", MountPath: "/foo"}}},
 		},
@@ -364,6 +389,52 @@ func TestValidateManifest(t *testing.T) {
 	}
 }
 
+func TestValidateLabels(t *testing.T) {
+	successCases := []map[string]string{
+		{"simple": "bar"},
+		{"now-with-dashes": "bar"},
+		{"1-starts-with-num": "bar"},
+		{"1234": "bar"},
+		{"simple/simple": "bar"},
+		{"now-with-dashes/simple": "bar"},
+		{"now-with-dashes/now-with-dashes": "bar"},
+		{"now.with.dots/simple": "bar"},
+		{"now-with.dashes-and.dots/simple": "bar"},
+		{"1-num.2-num/3-num": "bar"},
+		{"1234/5678": "bar"},
+		{"1.2.3.4/5678": "bar"},
+		{"UpperCaseAreOK123": "bar"},
+		{"goodvalue": "123_-.BaR"},
+		{"empty": ""},
+	}
+	for i := range successCases {
+		errs := ValidateLabels(successCases[i])
+		if len(errs) != 0 {
+			t.Errorf("case[%d] expected success, got %#v", i, errs)
+		}
+	}
+
+	errorCases := []map[string]string{
+		{"nospecialchars^=@": "bar"},
+		{"cantendwithadash-": "bar"},
+		{"only/one/slash": "bar"},
+		{"Upper.Case/simple": "bar"},
+		{"/noprefix": "bar"},
+		{"with space": "bar"},
+		{strings.Repeat("a", 64): "bar"},
+		{"simple": "bad value"},
+		{"simple": "-startswithdash"},
+		{"simple": "no/slashes"},
+		{"simple": strings.Repeat("a", 64)},
+	}
+	for i := range errorCases {
+		errs := ValidateLabels(errorCases[i])
+		if len(errs) != 1 {
+			t.Errorf("case[%d] expected failure", i)
+		}
+	}
+}
+
 func TestValidatePod(t *testing.T) {
 	errs := ValidatePod(&api.Pod{
 		JSONBase: api.JSONBase{ID: "foo"},
@@ -413,6 +484,31 @@ func TestValidatePod(t *testing.T) {
 	if len(errs) != 1 {
 		t.Errorf("Unexpected error list: %#v", errs)
 	}
+
+	errs = ValidatePod(&api.Pod{
+		JSONBase: api.JSONBase{ID: "foo"},
+		DesiredState: api.PodState{
+			Manifest: api.ContainerManifest{Version: "v1beta1", ID: "abc"},
+		},
+		Platforms: []string{"linux/amd64", "linux", "linux/amd64"},
+	})
+	if len(errs) != 2 {
+		t.Errorf("Unexpected error list: %#v", errs)
+	}
+
+	errs = ValidatePod(&api.Pod{
+		JSONBase: api.JSONBase{ID: "foo"},
+		Labels: map[string]string{
+			"foo": "bar baz",
+		},
+		DesiredState: api.PodState{
+			Manifest: api.ContainerManifest{Version: "v1beta1", ID: "abc"},
+		},
+	})
+	if len(errs) != 1 {
+		t.Errorf("Unexpected error list: %#v", errs)
+	}
+	expectPrefix(t, "labels", errs)
 }
 
 func TestValidateService(t *testing.T) {
@@ -470,6 +566,28 @@ func TestValidateService(t *testing.T) {
 			// Should fail because the protocol is invalid.
 			numErrs: 1,
 		},
+		{
+			name: "invalid session affinity",
+			svc: api.Service{
+				JSONBase:        api.JSONBase{ID: "abc123"},
+				Port:            8675,
+				Selector:        map[string]string{"foo": "bar"},
+				SessionAffinity: "INVALID",
+			},
+			// Should fail because the session affinity is invalid.
+			numErrs: 1,
+		},
+		{
+			name: "invalid public IP",
+			svc: api.Service{
+				JSONBase:  api.JSONBase{ID: "abc123"},
+				Port:      8675,
+				Selector:  map[string]string{"foo": "bar"},
+				PublicIPs: []string{"1.2.3.4", "not.an.ip"},
+			},
+			// Should fail because one of the public IPs is invalid.
+			numErrs: 1,
+		},
 		{
 			name: "missing selector",
 			svc: api.Service{
@@ -508,9 +626,36 @@ func TestValidateService(t *testing.T) {
 			},
 			numErrs: 0,
 		},
+		{
+			name: "valid client IP affinity",
+			svc: api.Service{
+				JSONBase:        api.JSONBase{ID: "abc123"},
+				Port:            80,
+				Selector:        map[string]string{"foo": "bar"},
+				SessionAffinity: api.AffinityTypeClientIP,
+			},
+			numErrs: 0,
+		},
+		{
+			name: "valid public IPs",
+			svc: api.Service{
+				JSONBase:  api.JSONBase{ID: "abc123"},
+				Port:      80,
+				Selector:  map[string]string{"foo": "bar"},
+				PublicIPs: []string{"1.2.3.4", "2001:db8::1"},
+			},
+			numErrs: 0,
+		},
 	}
 
 	for _, tc := range testCases {
+		// The protocol and session affinity are defaulted when services are decoded.
+		if tc.svc.Protocol == "" {
+			tc.svc.Protocol = api.ProtocolTCP
+		}
+		if tc.svc.SessionAffinity == "" {
+			tc.svc.SessionAffinity = api.AffinityTypeNone
+		}
 		errs := ValidateService(&tc.svc)
 		if len(errs) != tc.numErrs {
 			t.Errorf("Unexpected error list for case %q: %+v", tc.name, errs)
@@ -523,11 +668,27 @@ func TestValidateService(t *testing.T) {
 		Selector: map[string]string{"foo": "bar"},
 	}
 	errs := ValidateService(&svc)
-	if len(errs) != 0 {
-		t.Errorf("Unexpected non-zero error list: %#v", errs)
+	if len(errs) != 2 {
+		t.Errorf("Expected the protocol and session affinity to be required: %#v", errs)
+	}
+	if svc.Protocol != "" || svc.SessionAffinity != "" {
+		t.Errorf("Expected validation to leave the service alone: %#v", svc)
+	}
+}
+
+func TestValidateMinion(t *testing.T) {
+	if errs := ValidateMinion(&api.Minion{JSONBase: api.JSONBase{ID: "foo"}, PodCIDR: "10.244.1.0/24"}); len(errs) != 0 {
+		t.Errorf("expected success, got %v", errs)
+	}
+
+	// All problems are reported, not just the first.
+	errs := ValidateMinion(&api.Minion{PodCIDR: "10.244.1.0", Labels: map[string]string{"disk": "bad value"}})
+	fields := []string{}
+	for _, err := range errs {
+		fields = append(fields, err.(errors.ValidationError).Field)
 	}
-	if svc.Protocol != "TCP" {
-		t.Errorf("Expected default protocol of 'TCP': %#v", errs)
+	if e, a := []string{"id", "podCIDR", "labels.disk"}, fields; !reflect.DeepEqual(e, a) {
+		t.Errorf("expected errors for %v, got %v", e, a)
 	}
 }
 
@@ -28,11 +28,12 @@ import (
 // WatchEvent objects are streamed from the api server in response to a watch request.
 // These are not API objects and are unversioned today.
 type WatchEvent struct {
-	// The type of the watch event; added, modified, or deleted.
+	// The type of the watch event; added, modified, deleted, or error.
 	Type watch.EventType
 
 	// For added or modified objects, this is the new object; for deleted objects,
-	// it's the state of the object immediately prior to its deletion.
+	// it's the state of the object immediately prior to its deletion. For errors,
+	// it's a Status describing the error.
 	Object EmbeddedObject
 }
 
@@ -18,11 +18,15 @@ package apiserver
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"net/http"
+	"path"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/healthz"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/version"
@@ -43,6 +47,7 @@ type defaultAPIServer struct {
 
 const (
 	StatusUnprocessableEntity = 422
+	StatusTooManyRequests     = 429
 )
 
 // Handle returns a Handler function that expose the provided storage interfaces
@@ -53,10 +58,21 @@ func Handle(storage map[string]RESTStorage, codec runtime.Codec, prefix string)
 
 	mux := http.NewServeMux()
 	group.InstallREST(mux, prefix)
+	if root := path.Dir(prefix); root != "/" {
+		mux.Handle(root, APIVersionHandler(path.Base(prefix)))
+	}
 	InstallSupport(mux)
 	return &defaultAPIServer{mux, group}
 }
 
+// APIVersionHandler returns a handler which lists versions as the API
+// versions the server supports.
+func APIVersionHandler(versions ...string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		writeRawJSON(http.StatusOK, api.APIVersions{Versions: versions}, w)
+	})
+}
+
 // APIGroup is a http.Handler that exposes multiple RESTStorage objects
 // It handles URLs of the form:
 // /${storage_key}[/${object_name}]
@@ -64,7 +80,8 @@ func Handle(storage map[string]RESTStorage, codec runtime.Codec, prefix string)
 //
 // TODO: consider migrating this to go-restful which is a more full-featured version of the same thing.
 type APIGroup struct {
-	handler RESTHandler
+	handler     RESTHandler
+	longRunning *LongRunningTracker
 }
 
 // NewAPIGroup returns an object that will serve a set of REST resources and their
@@ -73,7 +90,7 @@ type APIGroup struct {
 // prefixes onto a server.
 // TODO: add multitype codec serialization
 func NewAPIGroup(storage map[string]RESTStorage, codec runtime.Codec) *APIGroup {
-	return &APIGroup{RESTHandler{
+	return &APIGroup{handler: RESTHandler{
 		storage: storage,
 		codec:   codec,
 		ops:     NewOperations(),
@@ -82,12 +99,20 @@ func NewAPIGroup(storage map[string]RESTStorage, codec runtime.Codec) *APIGroup
 	}}
 }
 
+// TrackLongRunning counts the group's watches and proxied connections with
+// tracker, which refuses them beyond its limit. It must be called before
+// InstallREST.
+func (g *APIGroup) TrackLongRunning(tracker *LongRunningTracker) *APIGroup {
+	g.longRunning = tracker
+	return g
+}
+
 // InstallREST registers the REST handlers (storage, watch, and operations) into a mux.
 // It is expected that the provided prefix will serve all operations. Path MUST NOT end
-// in a slash.
+// in a slash. The last element of each path is taken to be the API version it serves,
+// e.g. "v1beta1" for "/api/v1beta1", for converting field selectors.
 func (g *APIGroup) InstallREST(mux mux, paths ...string) {
 	restHandler := &g.handler
-	watchHandler := &WatchHandler{g.handler.storage, g.handler.codec}
 	redirectHandler := &RedirectHandler{g.handler.storage, g.handler.codec}
 	opHandler := &OperationHandler{g.handler.ops, g.handler.codec}
 
@@ -103,10 +128,12 @@ func (g *APIGroup) InstallREST(mux mux, paths ...string) {
 	}
 	for _, prefix := range paths {
 		prefix = strings.TrimRight(prefix, "/")
+		version := path.Base(prefix)
+		watchHandler := &WatchHandler{g.handler.storage, g.handler.codec, version}
 		proxyHandler := &ProxyHandler{prefix + "/proxy/", g.handler.storage, g.handler.codec}
-		mux.Handle(prefix+"/", http.StripPrefix(prefix, restHandler))
-		mux.Handle(prefix+"/watch/", http.StripPrefix(prefix+"/watch/", watchHandler))
-		mux.Handle(prefix+"/proxy/", http.StripPrefix(prefix+"/proxy/", proxyHandler))
+		mux.Handle(prefix+"/", http.StripPrefix(prefix, restHandler.forVersion(version)))
+		mux.Handle(prefix+"/watch/", http.StripPrefix(prefix+"/watch/", g.longRunning.track(watchHandler, g.handler.codec)))
+		mux.Handle(prefix+"/proxy/", http.StripPrefix(prefix+"/proxy/", g.longRunning.track(proxyHandler, g.handler.codec)))
 		mux.Handle(prefix+"/redirect/", http.StripPrefix(prefix+"/redirect/", redirectHandler))
 		mux.Handle(prefix+"/operations", http.StripPrefix(prefix+"/operations", opHandler))
 		mux.Handle(prefix+"/operations/", http.StripPrefix(prefix+"/operations/", opHandler))
@@ -142,9 +169,52 @@ func writeJSON(statusCode int, codec runtime.Codec, object runtime.Object, w htt
 	w.Write(output)
 }
 
-// errorJSON renders an error to the response.
+// writeCacheableJSON renders an object read by a GET or HEAD request with an
+// ETag made from its resourceVersion, or responds with 304 Not Modified, without
+// encoding the object, if the request's If-None-Match header names the object's
+// current ETag. Objects without a resourceVersion are rendered without an ETag.
+// There is no Last-Modified time to offer, so If-Modified-Since is ignored.
+func writeCacheableJSON(req *http.Request, codec runtime.Codec, object runtime.Object, w http.ResponseWriter) {
+	jsonBase, err := runtime.FindJSONBase(object)
+	if err != nil || jsonBase.ResourceVersion() == 0 {
+		writeJSON(http.StatusOK, codec, object, w)
+		return
+	}
+	etag := fmt.Sprintf("\"%d\"", jsonBase.ResourceVersion())
+	if etagMatches(req.Header.Get("If-None-Match"), etag) {
+		w.Header().Set("ETag", etag)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	output, err := codec.Encode(object)
+	if err != nil {
+		errorJSON(err, codec, w)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(output)
+}
+
+// etagMatches returns true if the value of an If-None-Match header names etag.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// errorJSON renders an error to the response, setting Retry-After if the error
+// says when to retry.
 func errorJSON(err error, codec runtime.Codec, w http.ResponseWriter) {
 	status := errToAPIStatus(err)
+	if status.Details != nil && status.Details.RetryAfterSeconds > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(status.Details.RetryAfterSeconds))
+	}
 	writeJSON(status.Code, codec, status, w)
 }
 
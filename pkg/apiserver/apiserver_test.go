@@ -33,6 +33,8 @@ import (
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	apierrs "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/latest"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/v1beta1"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/v1beta2"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
@@ -49,6 +51,7 @@ var codec = latest.Codec
 func init() {
 	api.Scheme.AddKnownTypes("", &Simple{}, &SimpleList{})
 	api.Scheme.AddKnownTypes(latest.Version, &Simple{}, &SimpleList{})
+	api.Scheme.AddKnownTypes("v1beta2", &Simple{}, &SimpleList{})
 }
 
 type Simple struct {
@@ -66,12 +69,13 @@ type SimpleList struct {
 func (*SimpleList) IsAnAPIObject() {}
 
 type SimpleRESTStorage struct {
-	errors  map[string]error
-	list    []Simple
-	item    Simple
-	deleted string
-	updated *Simple
-	created *Simple
+	errors              map[string]error
+	list                []Simple
+	listResourceVersion uint64
+	item                Simple
+	deleted             string
+	updated             *Simple
+	created             *Simple
 
 	// These are set when Watch is called
 	fakeWatch                *watch.FakeWatcher
@@ -90,7 +94,8 @@ type SimpleRESTStorage struct {
 
 func (storage *SimpleRESTStorage) List(label, field labels.Selector) (runtime.Object, error) {
 	result := &SimpleList{
-		Items: storage.list,
+		JSONBase: api.JSONBase{ResourceVersion: storage.listResourceVersion},
+		Items:    storage.list,
 	}
 	return result, storage.errors["list"]
 }
@@ -213,6 +218,25 @@ func TestNotFound(t *testing.T) {
 	}
 }
 
+func TestAPIVersions(t *testing.T) {
+	handler := Handle(map[string]RESTStorage{}, codec, "/prefix/version")
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	response, err := http.Get(server.URL + "/prefix")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var versions api.APIVersions
+	if err := json.NewDecoder(response.Body).Decode(&versions); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := api.APIVersions{Versions: []string{"version"}}
+	if !reflect.DeepEqual(expected, versions) {
+		t.Errorf("Expected %#v, Got %#v", expected, versions)
+	}
+}
+
 func TestVersion(t *testing.T) {
 	handler := Handle(map[string]RESTStorage{}, codec, "/prefix/version")
 	server := httptest.NewServer(handler)
@@ -336,6 +360,160 @@ func TestGet(t *testing.T) {
 	}
 }
 
+func TestGetMultipleVersions(t *testing.T) {
+	storage := map[string]RESTStorage{}
+	simpleStorage := SimpleRESTStorage{
+		item: Simple{
+			Name: "foo",
+		},
+	}
+	storage["simple"] = &simpleStorage
+	mux := http.NewServeMux()
+	NewAPIGroup(storage, v1beta1.Codec).InstallREST(mux, "/prefix/v1beta1")
+	NewAPIGroup(storage, v1beta2.Codec).InstallREST(mux, "/prefix/v1beta2")
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	for _, version := range []string{"v1beta1", "v1beta2"} {
+		resp, err := http.Get(server.URL + "/prefix/" + version + "/simple/id")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var itemOut map[string]interface{}
+		if err := json.NewDecoder(resp.Body).Decode(&itemOut); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp.Body.Close()
+		if e, a := version, itemOut["apiVersion"]; e != a {
+			t.Errorf("expected version %v, got %v", e, a)
+		}
+		if e, a := simpleStorage.item.Name, itemOut["name"]; e != a {
+			t.Errorf("expected name %v, got %v", e, a)
+		}
+	}
+}
+
+func TestGetConditional(t *testing.T) {
+	storage := map[string]RESTStorage{}
+	simpleStorage := SimpleRESTStorage{
+		item: Simple{
+			JSONBase: api.JSONBase{ResourceVersion: 5},
+			Name:     "foo",
+		},
+		listResourceVersion: 7,
+	}
+	storage["simple"] = &simpleStorage
+	handler := Handle(storage, codec, "/prefix/version")
+	server := httptest.NewServer(handler)
+
+	for _, path := range []string{"/prefix/version/simple/id", "/prefix/version/simple"} {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp.Body.Close()
+		etag := resp.Header.Get("ETag")
+		if resp.StatusCode != http.StatusOK || etag == "" {
+			t.Fatalf("%s: expected 200 with an ETag, got %d %q", path, resp.StatusCode, etag)
+		}
+
+		tests := []struct {
+			ifNoneMatch string
+			code        int
+		}{
+			{etag, http.StatusNotModified},
+			{`"other", ` + etag, http.StatusNotModified},
+			{"W/" + etag, http.StatusNotModified},
+			{"*", http.StatusNotModified},
+			{`"other"`, http.StatusOK},
+		}
+		for _, test := range tests {
+			req, _ := http.NewRequest("GET", server.URL+path, nil)
+			req.Header.Set("If-None-Match", test.ifNoneMatch)
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			body, _ := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			if resp.StatusCode != test.code {
+				t.Errorf("%s, If-None-Match %s: expected %d, got %d", path, test.ifNoneMatch, test.code, resp.StatusCode)
+			}
+			if test.code == http.StatusNotModified && len(body) != 0 {
+				t.Errorf("%s: unexpected body for a 304: %s", path, string(body))
+			}
+		}
+	}
+
+	// A change to the object changes its ETag.
+	resp, err := http.Get(server.URL + "/prefix/version/simple/id")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if e, a := `"5"`, resp.Header.Get("ETag"); e != a {
+		t.Errorf("expected ETag %s, got %s", e, a)
+	}
+	simpleStorage.item.Name = "bar"
+	simpleStorage.item.ResourceVersion = 6
+	req, _ := http.NewRequest("GET", server.URL+"/prefix/version/simple/id", nil)
+	req.Header.Set("If-None-Match", resp.Header.Get("ETag"))
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected 200 for a changed object, got %d", resp.StatusCode)
+	}
+}
+
+func TestGetWithoutResourceVersion(t *testing.T) {
+	storage := map[string]RESTStorage{}
+	simpleStorage := SimpleRESTStorage{
+		item: Simple{
+			Name: "foo",
+		},
+	}
+	storage["simple"] = &simpleStorage
+	handler := Handle(storage, codec, "/prefix/version")
+	server := httptest.NewServer(handler)
+
+	req, _ := http.NewRequest("GET", server.URL+"/prefix/version/simple/id", nil)
+	req.Header.Set("If-None-Match", "*")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("ETag") != "" {
+		t.Errorf("expected 200 without an ETag, got %d %q", resp.StatusCode, resp.Header.Get("ETag"))
+	}
+}
+
+func TestHead(t *testing.T) {
+	storage := map[string]RESTStorage{}
+	simpleStorage := SimpleRESTStorage{
+		item: Simple{
+			JSONBase: api.JSONBase{ResourceVersion: 5},
+			Name:     "foo",
+		},
+	}
+	storage["simple"] = &simpleStorage
+	handler := Handle(storage, codec, "/prefix/version")
+	server := httptest.NewServer(handler)
+
+	resp, err := http.Head(server.URL + "/prefix/version/simple/id")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("ETag") == "" || len(body) != 0 {
+		t.Errorf("unexpected response: %d %v %q", resp.StatusCode, resp.Header, string(body))
+	}
+}
+
 func TestGetMissing(t *testing.T) {
 	storage := map[string]RESTStorage{}
 	simpleStorage := SimpleRESTStorage{
@@ -397,6 +575,74 @@ func TestDeleteMissing(t *testing.T) {
 	}
 }
 
+// VersionedRESTStorage is a SimpleRESTStorage which supports conditional deletes.
+type VersionedRESTStorage struct {
+	SimpleRESTStorage
+	deletedVersion uint64
+}
+
+func (storage *VersionedRESTStorage) DeleteIfVersion(id string, resourceVersion uint64) (<-chan runtime.Object, error) {
+	storage.deletedVersion = resourceVersion
+	return storage.Delete(id)
+}
+
+func TestDeleteIfVersion(t *testing.T) {
+	storage := map[string]RESTStorage{}
+	versionedStorage := VersionedRESTStorage{}
+	ID := "id"
+	storage["simple"] = &versionedStorage
+	handler := Handle(storage, codec, "/prefix/version")
+	server := httptest.NewServer(handler)
+
+	client := http.Client{}
+	request, err := http.NewRequest("DELETE", server.URL+"/prefix/version/simple/"+ID+"?resourceVersion=10", nil)
+	response, err := client.Do(request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if response.StatusCode != http.StatusOK {
+		t.Errorf("Unexpected response %#v", response)
+	}
+	if versionedStorage.deleted != ID || versionedStorage.deletedVersion != 10 {
+		t.Errorf("Unexpected delete: %s at %d, expected %s at 10", versionedStorage.deleted, versionedStorage.deletedVersion, ID)
+	}
+}
+
+func TestDeleteIfVersionRejected(t *testing.T) {
+	table := map[string]struct {
+		storage RESTStorage
+		query   string
+		code    int
+	}{
+		"unsupported": {&SimpleRESTStorage{}, "?resourceVersion=10", 422},
+		"invalid":     {&VersionedRESTStorage{}, "?resourceVersion=foo", 422},
+		"zero":        {&VersionedRESTStorage{}, "?resourceVersion=0", 422},
+		"conflict": {
+			&VersionedRESTStorage{SimpleRESTStorage: SimpleRESTStorage{
+				errors: map[string]error{"delete": apierrs.NewConflict("simple", "id", errors.New("changed"))},
+			}},
+			"?resourceVersion=10",
+			http.StatusConflict,
+		},
+	}
+	for name, item := range table {
+		handler := Handle(map[string]RESTStorage{"simple": item.storage}, codec, "/prefix/version")
+		server := httptest.NewServer(handler)
+
+		client := http.Client{}
+		request, err := http.NewRequest("DELETE", server.URL+"/prefix/version/simple/id"+item.query, nil)
+		response, err := client.Do(request)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if response.StatusCode != item.code {
+			t.Errorf("%s: expected %d, got %#v", name, item.code, response)
+		}
+		server.Close()
+	}
+}
+
 func TestUpdate(t *testing.T) {
 	storage := map[string]RESTStorage{}
 	simpleStorage := SimpleRESTStorage{}
@@ -521,6 +767,39 @@ func TestCreateNotFound(t *testing.T) {
 	}
 }
 
+func TestCreateUnknownFields(t *testing.T) {
+	simpleStorage := &SimpleRESTStorage{}
+	handler := Handle(map[string]RESTStorage{
+		"simple": simpleStorage,
+	}, runtime.StrictCodecFor(api.Scheme, latest.Version), "/prefix/version")
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	data := []byte(`{"kind": "Simple", "apiVersion": "` + latest.Version + `", "nmae": "foo"}`)
+	response, err := http.Post(server.URL+"/prefix/version/simple", "application/json", bytes.NewBuffer(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if response.StatusCode != StatusUnprocessableEntity {
+		t.Errorf("Unexpected response %#v", response)
+	}
+	var status api.Status
+	if _, err := extractBody(response, &status); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []api.StatusCause{{
+		Type:    api.CauseTypeFieldUnknown,
+		Message: "nmae: unknown field 'foo'",
+		Field:   "nmae",
+	}}
+	if status.Reason != api.StatusReasonInvalid || status.Details == nil || !reflect.DeepEqual(expected, status.Details.Causes) {
+		t.Errorf("Unexpected status %#v", status)
+	}
+	if simpleStorage.created != nil {
+		t.Errorf("Unexpected create of %#v", simpleStorage.created)
+	}
+}
+
 func TestParseTimeout(t *testing.T) {
 	if d := parseTimeout(""); d != 30*time.Second {
 		t.Errorf("blank timeout produces %v", d)
@@ -803,3 +1082,34 @@ func TestCORSAllowedOrigins(t *testing.T) {
 		}
 	}
 }
+
+func benchmarkSimpleList(b *testing.B, count int) {
+	simpleStorage := SimpleRESTStorage{}
+	for i := 0; i < count; i++ {
+		simpleStorage.list = append(simpleStorage.list, Simple{
+			JSONBase: api.JSONBase{ID: fmt.Sprintf("item-%d", i)},
+			Name:     "foo",
+		})
+	}
+	handler := Handle(map[string]RESTStorage{"simple": &simpleStorage}, codec, "/prefix/version")
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		resp, err := http.Get(server.URL + "/prefix/version/simple")
+		if err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := ioutil.ReadAll(resp.Body); err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			b.Fatalf("Unexpected status: %d, Expected: %d", resp.StatusCode, http.StatusOK)
+		}
+	}
+}
+
+func BenchmarkSimpleList1000(b *testing.B) { benchmarkSimpleList(b, 1000) }
+func BenchmarkSimpleList5000(b *testing.B) { benchmarkSimpleList(b, 5000) }
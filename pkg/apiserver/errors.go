@@ -19,8 +19,11 @@ package apiserver
 import (
 	"fmt"
 	"net/http"
+	"sort"
 
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	apierrs "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/tools"
 )
 
@@ -29,6 +32,10 @@ type statusError interface {
 	Status() api.Status
 }
 
+// storageRetryAfterSeconds is how long clients are asked to wait before
+// retrying requests that failed because etcd couldn't be reached.
+const storageRetryAfterSeconds = 5
+
 // errToAPIStatus converts an error to an api.Status object.
 func errToAPIStatus(err error) *api.Status {
 	switch t := err.(type) {
@@ -37,22 +44,41 @@ func errToAPIStatus(err error) *api.Status {
 		status.Status = api.StatusFailure
 		//TODO: check for invalid responses
 		return &status
+	case *runtime.UnknownFieldsError:
+		return errToAPIStatus(unknownFieldsToInvalid(t))
 	default:
-		status := http.StatusInternalServerError
+		status, reason := http.StatusInternalServerError, api.StatusReasonUnknown
 		switch {
 		//TODO: replace me with NewConflictErr
 		case tools.IsEtcdTestFailed(err):
-			status = http.StatusConflict
+			status, reason = http.StatusConflict, api.StatusReasonConflict
+		case tools.IsEtcdNotReachable(err):
+			return errToAPIStatus(apierrs.NewTimeout(err.Error(), storageRetryAfterSeconds))
 		}
 		return &api.Status{
 			Status:  api.StatusFailure,
 			Code:    status,
-			Reason:  api.StatusReasonUnknown,
+			Reason:  reason,
 			Message: err.Error(),
 		}
 	}
 }
 
+// unknownFieldsToInvalid converts an error from strict decoding into an invalid
+// error, with a cause for each unknown field.
+func unknownFieldsToInvalid(err *runtime.UnknownFieldsError) error {
+	fields := make([]string, 0, len(err.Fields))
+	for field := range err.Fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	errs := apierrs.ErrorList{}
+	for _, field := range fields {
+		errs = append(errs, apierrs.NewFieldUnknown(field, err.Fields[field]))
+	}
+	return apierrs.NewInvalid(err.Kind, "", errs)
+}
+
 // notFound renders a simple not found error.
 func notFound(w http.ResponseWriter, req *http.Request) {
 	w.WriteHeader(http.StatusNotFound)
@@ -19,11 +19,14 @@ package apiserver
 import (
 	stderrs "errors"
 	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"testing"
 
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/tools"
+	"github.com/coreos/go-etcd/etcd"
 )
 
 func Test_errToAPIStatus(t *testing.T) {
@@ -46,6 +49,15 @@ func TestErrorsToAPIStatus(t *testing.T) {
 				ID:   "bar",
 			},
 		},
+		errors.NewTimeout("failure", 5): {
+			Status:  api.StatusFailure,
+			Code:    http.StatusGatewayTimeout,
+			Reason:  "timeout",
+			Message: "the request timed out, retry after 5 seconds: failure",
+			Details: &api.StatusDetails{
+				RetryAfterSeconds: 5,
+			},
+		},
 		errors.NewConflict("foo", "bar", stderrs.New("failure")): {
 			Status:  api.StatusFailure,
 			Code:    http.StatusConflict,
@@ -64,3 +76,27 @@ func TestErrorsToAPIStatus(t *testing.T) {
 		}
 	}
 }
+
+func TestEtcdErrorsToAPIStatus(t *testing.T) {
+	status := errToAPIStatus(tools.EtcdErrorTestFailed)
+	if status.Code != http.StatusConflict || status.Reason != api.StatusReasonConflict {
+		t.Errorf("unexpected status: %#v", status)
+	}
+	status = errToAPIStatus(&etcd.EtcdError{ErrorCode: etcd.ErrCodeEtcdNotReachable})
+	if status.Code != http.StatusGatewayTimeout || status.Reason != api.StatusReasonTimeout || status.Details.RetryAfterSeconds != storageRetryAfterSeconds {
+		t.Errorf("unexpected status: %#v", status)
+	}
+}
+
+func TestErrorJSONRetryAfter(t *testing.T) {
+	w := httptest.NewRecorder()
+	errorJSON(errors.NewTimeout("failure", 5), codec, w)
+	if w.Code != http.StatusGatewayTimeout || w.Header().Get("Retry-After") != "5" {
+		t.Errorf("unexpected response: %#v", w)
+	}
+	w = httptest.NewRecorder()
+	errorJSON(errors.NewNotFound("foo", "bar"), codec, w)
+	if w.Header().Get("Retry-After") != "" {
+		t.Errorf("unexpected Retry-After: %#v", w)
+	}
+}
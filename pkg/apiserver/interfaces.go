@@ -46,13 +46,23 @@ type RESTStorage interface {
 	Update(runtime.Object) (<-chan runtime.Object, error)
 }
 
+// VersionedDeleter should be implemented by RESTStorage objects which can
+// refuse to delete a resource that has changed since the client last saw it.
+type VersionedDeleter interface {
+	// DeleteIfVersion deletes the resource only if its resourceVersion is
+	// still resourceVersion. IsConflict(err) is true for the returned error
+	// value err if it isn't.
+	DeleteIfVersion(id string, resourceVersion uint64) (<-chan runtime.Object, error)
+}
+
 // ResourceWatcher should be implemented by all RESTStorage objects that
 // want to offer the ability to watch for changes through the watch api.
 type ResourceWatcher interface {
 	// 'label' selects on labels; 'field' selects on the object's fields. Not all fields
 	// are supported; an error should be returned if 'field' tries to select on a field that
-	// isn't supported. 'resourceVersion' allows for continuing/starting a watch at a
-	// particular version.
+	// isn't supported. Only changes after 'resourceVersion' are sent, so a watch can be
+	// continued from a list's resourceVersion or the last event seen; zero starts with
+	// the current objects.
 	Watch(label, field labels.Selector, resourceVersion uint64) (watch.Interface, error)
 }
 
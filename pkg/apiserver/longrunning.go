@@ -0,0 +1,123 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package apiserver
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+)
+
+// LongRunningTracker counts the long-running requests, watches and proxied
+// connections, which API groups are serving, per resource. It refuses new
+// ones beyond a maximum, and serves the current counts as JSON.
+type LongRunningTracker struct {
+	max int
+
+	lock   sync.Mutex
+	total  int
+	counts map[string]int
+}
+
+// LongRunningCounts is what a LongRunningTracker serves.
+type LongRunningCounts struct {
+	Total int `json:"total"`
+	// Max is the most long-running requests allowed at once, or zero for
+	// no limit.
+	Max       int            `json:"max,omitempty"`
+	Resources map[string]int `json:"resources"`
+}
+
+// NewLongRunningTracker returns a tracker which allows at most max
+// long-running requests at once. Zero means no limit.
+func NewLongRunningTracker(max int) *LongRunningTracker {
+	return &LongRunningTracker{
+		max:    max,
+		counts: map[string]int{},
+	}
+}
+
+// begin records the start of a long-running request for resource, or
+// returns false if the limit has been reached.
+func (t *LongRunningTracker) begin(resource string) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.max > 0 && t.total >= t.max {
+		return false
+	}
+	t.total++
+	t.counts[resource]++
+	return true
+}
+
+// end records the end of a long-running request begun for resource.
+func (t *LongRunningTracker) end(resource string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.total--
+	if t.counts[resource]--; t.counts[resource] == 0 {
+		delete(t.counts, resource)
+	}
+}
+
+// Counts returns the number of long-running requests being served.
+func (t *LongRunningTracker) Counts() LongRunningCounts {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	counts := LongRunningCounts{
+		Total:     t.total,
+		Max:       t.max,
+		Resources: map[string]int{},
+	}
+	for resource, count := range t.counts {
+		counts.Resources[resource] = count
+	}
+	return counts
+}
+
+// ServeHTTP serves the current counts.
+func (t *LongRunningTracker) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	writeRawJSON(http.StatusOK, t.Counts(), w)
+}
+
+// track wraps handler, which serves long-running requests for paths which
+// start with a resource, so that they're counted. Requests beyond the limit
+// are refused with StatusTooManyRequests.
+func (t *LongRunningTracker) track(handler http.Handler, codec runtime.Codec) http.Handler {
+	if t == nil {
+		return handler
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		resource := strings.SplitN(strings.TrimLeft(req.URL.Path, "/"), "/", 2)[0]
+		if !t.begin(resource) {
+			w.Header().Set("Retry-After", "1")
+			writeJSON(StatusTooManyRequests, codec, &api.Status{
+				Status:  api.StatusFailure,
+				Code:    StatusTooManyRequests,
+				Reason:  api.StatusReasonTooManyRequests,
+				Details: &api.StatusDetails{RetryAfterSeconds: 1},
+				Message: fmt.Sprintf("too many long-running requests, the limit is %d", t.max),
+			}, w)
+			return
+		}
+		defer t.end(resource)
+		handler.ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package apiserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+)
+
+func TestLongRunningTrackerLimit(t *testing.T) {
+	simpleStorage := &SimpleRESTStorage{}
+	tracker := NewLongRunningTracker(1)
+	mux := http.NewServeMux()
+	NewAPIGroup(map[string]RESTStorage{"foo": simpleStorage}, codec).TrackLongRunning(tracker).InstallREST(mux, "/prefix/version")
+	mux.Handle("/longrunning", tracker)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	watching, err := http.Get(server.URL + "/prefix/version/watch/foo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if watching.StatusCode != http.StatusOK {
+		t.Errorf("unexpected response %#v", watching)
+	}
+
+	// The watch holds the only slot.
+	resp, err := http.Get(server.URL + "/prefix/version/watch/foo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var status api.Status
+	if _, err := extractBody(resp, &status); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != StatusTooManyRequests || resp.Header.Get("Retry-After") == "" {
+		t.Errorf("expected too many requests, got %#v", resp)
+	}
+	if status.Reason != api.StatusReasonTooManyRequests || status.Details == nil || status.Details.RetryAfterSeconds != 1 {
+		t.Errorf("unexpected status: %#v", status)
+	}
+
+	resp, err = http.Get(server.URL + "/longrunning")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var counts LongRunningCounts
+	if err := json.NewDecoder(resp.Body).Decode(&counts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	expected := LongRunningCounts{Total: 1, Max: 1, Resources: map[string]int{"foo": 1}}
+	if !reflect.DeepEqual(expected, counts) {
+		t.Errorf("expected %#v, got %#v", expected, counts)
+	}
+
+	simpleStorage.fakeWatch.Stop()
+	watching.Body.Close()
+}
+
+func TestLongRunningTrackerCounts(t *testing.T) {
+	tracker := NewLongRunningTracker(0)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	handler := tracker.track(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		started <- struct{}{}
+		<-release
+	}), codec)
+
+	for _, path := range []string{"/pods", "/pods/foo", "/services/bar/baz"} {
+		req, _ := http.NewRequest("GET", "http://example.com"+path, nil)
+		go handler.ServeHTTP(httptest.NewRecorder(), req)
+		<-started
+	}
+	expected := LongRunningCounts{Total: 3, Resources: map[string]int{"pods": 2, "services": 1}}
+	if counts := tracker.Counts(); !reflect.DeepEqual(expected, counts) {
+		t.Errorf("expected %#v, got %#v", expected, counts)
+	}
+
+	close(release)
+	for i := 0; i < 100; i++ {
+		if tracker.Counts().Total == 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	expected = LongRunningCounts{Resources: map[string]int{}}
+	if counts := tracker.Counts(); !reflect.DeepEqual(expected, counts) {
+		t.Errorf("expected %#v, got %#v", expected, counts)
+	}
+}
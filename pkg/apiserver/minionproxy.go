@@ -31,6 +31,11 @@ import (
 	"github.com/golang/glog"
 )
 
+// MinionTransport is used to reach minions, and the pods on them, when
+// proxying requests.  It may be replaced before serving, for example to
+// tunnel through SSH when the master cannot reach minions directly.
+var MinionTransport http.RoundTripper = http.DefaultTransport
+
 // TODO: replace with proxy handler on minions
 func handleProxyMinion(w http.ResponseWriter, req *http.Request) {
 	path := strings.TrimLeft(req.URL.Path, "/")
@@ -77,7 +82,7 @@ func handleProxyMinion(w http.ResponseWriter, req *http.Request) {
 type minionTransport struct{}
 
 func (t *minionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	resp, err := http.DefaultTransport.RoundTrip(req)
+	resp, err := MinionTransport.RoundTrip(req)
 
 	if err != nil {
 		if strings.Contains(err.Error(), "connection refused") {
@@ -18,9 +18,12 @@ package apiserver
 
 import (
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	apierrs "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/fields"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/httplog"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
@@ -31,6 +34,8 @@ type RESTHandler struct {
 	codec       runtime.Codec
 	ops         *Operations
 	asyncOpWait time.Duration
+	// version is the API version served, for converting field selectors.
+	version string
 }
 
 // ServeHTTP handles requests to all RESTStorage objects.
@@ -50,11 +55,22 @@ func (h *RESTHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	h.handleRESTStorage(parts, req, w, storage)
 }
 
+// forVersion returns a handler for h's storage which serves it as the given
+// API version.
+func (h *RESTHandler) forVersion(version string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		versioned := *h
+		versioned.version = version
+		versioned.ServeHTTP(w, req)
+	})
+}
+
 // handleRESTStorage is the main dispatcher for a storage object.  It switches on the HTTP method, and then
 // on path length, according to the following table:
 //   Method     Path          Action
 //   GET        /foo          list
 //   GET        /foo/bar      get 'bar'
+//   HEAD       /foo, /foo/bar  as GET, without the body
 //   POST       /foo          create
 //   PUT        /foo/bar      update 'bar'
 //   DELETE     /foo/bar      delete 'bar'
@@ -63,11 +79,14 @@ func (h *RESTHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 //    sync=[false|true] Synchronous request (only applies to create, update, delete operations)
 //    timeout=<duration> Timeout for synchronous requests, only applies if sync=true
 //    labels=<label-selector> Used for filtering list operations
+//    fields=<field-selector> Used for filtering list operations by the fields the resource supports
+//    resourceVersion=<version> Only delete the resource if it is still at this version
 func (h *RESTHandler) handleRESTStorage(parts []string, req *http.Request, w http.ResponseWriter, storage RESTStorage) {
 	sync := req.URL.Query().Get("sync") == "true"
 	timeout := parseTimeout(req.URL.Query().Get("timeout"))
 	switch req.Method {
-	case "GET":
+	case "GET", "HEAD":
+		// The response to a HEAD request has its body discarded by net/http.
 		switch len(parts) {
 		case 1:
 			label, err := labels.ParseSelector(req.URL.Query().Get("labels"))
@@ -75,7 +94,7 @@ func (h *RESTHandler) handleRESTStorage(parts []string, req *http.Request, w htt
 				errorJSON(err, h.codec, w)
 				return
 			}
-			field, err := labels.ParseSelector(req.URL.Query().Get("fields"))
+			field, err := fields.ParseSelector(h.version, parts[0], req.URL.Query().Get("fields"))
 			if err != nil {
 				errorJSON(err, h.codec, w)
 				return
@@ -85,14 +104,14 @@ func (h *RESTHandler) handleRESTStorage(parts []string, req *http.Request, w htt
 				errorJSON(err, h.codec, w)
 				return
 			}
-			writeJSON(http.StatusOK, h.codec, list, w)
+			writeCacheableJSON(req, h.codec, list, w)
 		case 2:
 			item, err := storage.Get(parts[1])
 			if err != nil {
 				errorJSON(err, h.codec, w)
 				return
 			}
-			writeJSON(http.StatusOK, h.codec, item, w)
+			writeCacheableJSON(req, h.codec, item, w)
 		default:
 			notFound(w, req)
 		}
@@ -126,7 +145,7 @@ func (h *RESTHandler) handleRESTStorage(parts []string, req *http.Request, w htt
 			notFound(w, req)
 			return
 		}
-		out, err := storage.Delete(parts[1])
+		out, err := h.delete(storage, parts, req.URL.Query().Get("resourceVersion"))
 		if err != nil {
 			errorJSON(err, h.codec, w)
 			return
@@ -163,6 +182,23 @@ func (h *RESTHandler) handleRESTStorage(parts []string, req *http.Request, w htt
 	}
 }
 
+// delete deletes the resource named by parts, only if it is at the given
+// resourceVersion when one is set.
+func (h *RESTHandler) delete(storage RESTStorage, parts []string, resourceVersion string) (<-chan runtime.Object, error) {
+	if resourceVersion == "" {
+		return storage.Delete(parts[1])
+	}
+	version, err := strconv.ParseUint(resourceVersion, 10, 64)
+	if err != nil || version == 0 {
+		return nil, apierrs.NewInvalid(parts[0], parts[1], apierrs.ErrorList{apierrs.NewFieldInvalid("resourceVersion", resourceVersion)})
+	}
+	deleter, ok := storage.(VersionedDeleter)
+	if !ok {
+		return nil, apierrs.NewInvalid(parts[0], parts[1], apierrs.ErrorList{apierrs.NewFieldNotSupported("resourceVersion", resourceVersion)})
+	}
+	return deleter.DeleteIfVersion(parts[1], version)
+}
+
 // createOperation creates an operation to process a channel response.
 func (h *RESTHandler) createOperation(out <-chan runtime.Object, sync bool, timeout time.Duration) *Operation {
 	op := h.ops.NewOperation(out)
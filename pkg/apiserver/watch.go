@@ -18,14 +18,18 @@ package apiserver
 
 import (
 	"encoding/json"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"code.google.com/p/go.net/websocket"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/fields"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/httplog"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
@@ -35,23 +39,21 @@ import (
 type WatchHandler struct {
 	storage map[string]RESTStorage
 	codec   runtime.Codec
+	// version is the API version served, for converting field selectors.
+	version string
 }
 
-func getWatchParams(query url.Values) (label, field labels.Selector, resourceVersion uint64) {
-	if s, err := labels.ParseSelector(query.Get("labels")); err != nil {
-		label = labels.Everything()
-	} else {
-		label = s
+func getWatchParams(query url.Values, version, resource string) (label, field labels.Selector, resourceVersion uint64, err error) {
+	if label, err = labels.ParseSelector(query.Get("labels")); err != nil {
+		return nil, nil, 0, err
 	}
-	if s, err := labels.ParseSelector(query.Get("fields")); err != nil {
-		field = labels.Everything()
-	} else {
-		field = s
+	if field, err = fields.ParseSelector(version, resource, query.Get("fields")); err != nil {
+		return nil, nil, 0, err
 	}
 	if rv, err := strconv.ParseUint(query.Get("resourceVersion"), 10, 64); err == nil {
 		resourceVersion = rv
 	}
-	return label, field, resourceVersion
+	return label, field, resourceVersion, nil
 }
 
 var connectionUpgradeRegex = regexp.MustCompile("(^|.*,\\s*)upgrade($|\\s*,)")
@@ -73,7 +75,11 @@ func (h *WatchHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 	if watcher, ok := storage.(ResourceWatcher); ok {
-		label, field, resourceVersion := getWatchParams(req.URL.Query())
+		label, field, resourceVersion, err := getWatchParams(req.URL.Query(), h.version, parts[0])
+		if err != nil {
+			errorJSON(err, h.codec, w)
+			return
+		}
 		watching, err := watcher.Watch(label, field, resourceVersion)
 		if err != nil {
 			errorJSON(err, h.codec, w)
@@ -100,24 +106,48 @@ type WatchServer struct {
 	codec    runtime.Codec
 }
 
+var (
+	// websocketPingInterval is how often websocket watchers are pinged, so that
+	// NATs and proxies don't drop connections that are idle between events.
+	websocketPingInterval = 30 * time.Second
+	// websocketReadTimeout is how long a websocket watcher may go without
+	// sending anything, pongs included, before its connection is closed.
+	websocketReadTimeout = 2 * websocketPingInterval
+	// websocketWriteTimeout bounds each frame written to a websocket watcher.
+	websocketWriteTimeout = 10 * time.Second
+)
+
+// pingCodec sends ping frames. Pings must not be empty: the websocket package
+// takes an empty ping for the end of the connection rather than answering it.
+var pingCodec = websocket.Codec{
+	Marshal: func(interface{}) ([]byte, byte, error) {
+		return []byte("ping"), websocket.PingFrame, nil
+	},
+}
+
 // HandleWS implements a websocket handler.
 func (w *WatchServer) HandleWS(ws *websocket.Conn) {
 	done := make(chan struct{})
-	go func() {
-		var unused interface{}
-		// Expect this to block until the connection is closed. Client should not
-		// send anything.
-		websocket.JSON.Receive(ws, &unused)
-		close(done)
-	}()
+	go receiveWS(ws, done)
+	ticker := time.NewTicker(websocketPingInterval)
+	defer ticker.Stop()
 	for {
 		select {
 		case <-done:
+			// The client closed the connection or stopped answering pings.
 			w.watching.Stop()
+			closeWS(ws)
 			return
+		case <-ticker.C:
+			if err := sendWS(ws, pingCodec, nil); err != nil {
+				// Client disconnect.
+				w.watching.Stop()
+				return
+			}
 		case event, ok := <-w.watching.ResultChan():
 			if !ok {
 				// End of results.
+				closeWS(ws)
 				return
 			}
 			obj, err := api.NewJSONWatchEvent(w.codec, event)
@@ -126,7 +156,7 @@ func (w *WatchServer) HandleWS(ws *websocket.Conn) {
 				w.watching.Stop()
 				return
 			}
-			if err := websocket.JSON.Send(ws, obj); err != nil {
+			if err := sendWS(ws, websocket.JSON, obj); err != nil {
 				// Client disconnect.
 				w.watching.Stop()
 				return
@@ -135,6 +165,41 @@ func (w *WatchServer) HandleWS(ws *websocket.Conn) {
 	}
 }
 
+// receiveWS reads from ws until the client sends a close frame or the
+// connection fails or goes quiet, then closes done. Clients should send
+// nothing but pongs; anything received counts as a sign of life.
+func receiveWS(ws *websocket.Conn, done chan<- struct{}) {
+	defer close(done)
+	for {
+		ws.SetReadDeadline(time.Now().Add(websocketReadTimeout))
+		// Frames are read one at a time rather than with a Codec, because the
+		// websocket package reports a pong as ErrNotImplemented and leaves its
+		// payload unread, to be mistaken for the next frame.
+		frame, err := ws.NewFrameReader()
+		if err != nil {
+			return
+		}
+		if _, err := ws.HandleFrame(frame); err != nil && err != websocket.ErrNotImplemented {
+			return
+		}
+		if _, err := io.Copy(ioutil.Discard, frame); err != nil {
+			return
+		}
+	}
+}
+
+// sendWS sends v over ws, giving up if the client doesn't keep up.
+func sendWS(ws *websocket.Conn, codec websocket.Codec, v interface{}) error {
+	ws.SetWriteDeadline(time.Now().Add(websocketWriteTimeout))
+	return codec.Send(ws, v)
+}
+
+// closeWS sends a close frame and closes the connection.
+func closeWS(ws *websocket.Conn) {
+	ws.SetWriteDeadline(time.Now().Add(websocketWriteTimeout))
+	ws.Close()
+}
+
 // ServeHTTP serves a series of JSON encoded events via straight HTTP with
 // Transfer-Encoding: chunked.
 func (self *WatchServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
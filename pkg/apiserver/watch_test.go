@@ -22,10 +22,13 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"code.google.com/p/go.net/websocket"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/fields"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
 )
@@ -86,6 +89,90 @@ func TestWatchWebsocket(t *testing.T) {
 	}
 }
 
+// serveWatchWS serves a fake watch directly over a websocket and dials it.
+func serveWatchWS(t *testing.T) (*watch.FakeWatcher, *websocket.Conn, func()) {
+	fakeWatch := watch.NewFake()
+	watchServer := &WatchServer{fakeWatch, codec}
+	server := httptest.NewServer(websocket.Handler(watchServer.HandleWS))
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http"), "", "http://localhost")
+	if err != nil {
+		server.Close()
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return fakeWatch, ws, func() {
+		ws.Close()
+		server.Close()
+	}
+}
+
+// setWebsocketTimeouts shortens the websocket keepalive timers, returning a
+// func that restores them.
+func setWebsocketTimeouts(ping, read time.Duration) func() {
+	oldPing, oldRead := websocketPingInterval, websocketReadTimeout
+	websocketPingInterval, websocketReadTimeout = ping, read
+	return func() {
+		websocketPingInterval, websocketReadTimeout = oldPing, oldRead
+	}
+}
+
+func waitForStop(t *testing.T, fakeWatch *watch.FakeWatcher) {
+	for i := 0; i < 100; i++ {
+		fakeWatch.Lock()
+		stopped := fakeWatch.Stopped
+		fakeWatch.Unlock()
+		if stopped {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Errorf("expected watch to be stopped")
+}
+
+func TestWatchWebsocketKeepalive(t *testing.T) {
+	defer setWebsocketTimeouts(10*time.Millisecond, 50*time.Millisecond)()
+	fakeWatch, ws, cleanup := serveWatchWS(t)
+	defer cleanup()
+
+	// The client answers pings while it waits, which keeps the connection
+	// open well past the read timeout.
+	received := make(chan error)
+	var got api.WatchEvent
+	go func() {
+		received <- websocket.JSON.Receive(ws, &got)
+	}()
+	time.Sleep(200 * time.Millisecond)
+	fakeWatch.Add(&Simple{Name: "foo"})
+	if err := <-received; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e, a := (&Simple{Name: "foo"}), got.Object.Object; got.Type != watch.Added || !reflect.DeepEqual(e, a) {
+		t.Errorf("expected added %v, got %v %v", e, got.Type, a)
+	}
+}
+
+func TestWatchWebsocketIdleTimeout(t *testing.T) {
+	defer setWebsocketTimeouts(10*time.Millisecond, 50*time.Millisecond)()
+	fakeWatch, ws, cleanup := serveWatchWS(t)
+	defer cleanup()
+
+	// Not reading means pings go unanswered, so the server gives up.
+	waitForStop(t, fakeWatch)
+	var got api.WatchEvent
+	if err := websocket.JSON.Receive(ws, &got); err == nil {
+		t.Errorf("unexpected non-error")
+	}
+}
+
+func TestWatchWebsocketClientClose(t *testing.T) {
+	fakeWatch, ws, cleanup := serveWatchWS(t)
+	defer cleanup()
+
+	if err := ws.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	waitForStop(t, fakeWatch)
+}
+
 func TestWatchHTTP(t *testing.T) {
 	simpleStorage := &SimpleRESTStorage{}
 	handler := Handle(map[string]RESTStorage{
@@ -140,6 +227,7 @@ func TestWatchHTTP(t *testing.T) {
 }
 
 func TestWatchParamParsing(t *testing.T) {
+	fields.Register("foo", "ID", "Host")
 	simpleStorage := &SimpleRESTStorage{}
 	handler := Handle(map[string]RESTStorage{
 		"foo": simpleStorage,
@@ -199,6 +287,20 @@ func TestWatchParamParsing(t *testing.T) {
 			t.Errorf("%v: expected %v, got %v", item.rawQuery, e, a)
 		}
 	}
+
+	for _, rawQuery := range []string{"fields=Unsupported%3Dfoo", "labels=x%3D%3Da%3D%3Db"} {
+		simpleStorage.requestedFieldSelector = nil
+		dest.RawQuery = rawQuery
+		resp, err := http.Get(dest.String())
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", rawQuery, err)
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK || simpleStorage.requestedFieldSelector != nil {
+			t.Errorf("%v: expected the watch to be refused, got %v", rawQuery, resp.StatusCode)
+		}
+	}
 }
 
 func TestWatchProtocolSelection(t *testing.T) {
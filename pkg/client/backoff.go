@@ -0,0 +1,51 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"time"
+)
+
+// Backoff computes exponentially increasing delays, e.g. between attempts to
+// re-establish a watch.  The zero value never waits.
+type Backoff struct {
+	// Initial is the first delay, and the delay after a Reset.
+	Initial time.Duration
+	// Max, if positive, caps the delay.
+	Max time.Duration
+
+	next time.Duration
+}
+
+// Next returns how long to wait before the next attempt, and doubles the
+// delay returned by the following call.
+func (b *Backoff) Next() time.Duration {
+	if b.next == 0 {
+		b.next = b.Initial
+	}
+	delay := b.next
+	b.next *= 2
+	if b.Max > 0 && b.next > b.Max {
+		b.next = b.Max
+	}
+	return delay
+}
+
+// Reset makes the next delay Initial again, e.g. after a successful attempt.
+func (b *Backoff) Reset() {
+	b.next = 0
+}
@@ -0,0 +1,45 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	b := Backoff{Initial: time.Second, Max: 5 * time.Second}
+	expected := []time.Duration{1, 2, 4, 5, 5}
+	for i, e := range expected {
+		if a := b.Next(); a != e*time.Second {
+			t.Errorf("%d: expected %v, got %v", i, e*time.Second, a)
+		}
+	}
+	b.Reset()
+	if a := b.Next(); a != time.Second {
+		t.Errorf("expected %v after reset, got %v", time.Second, a)
+	}
+}
+
+func TestBackoffZero(t *testing.T) {
+	b := Backoff{}
+	for i := 0; i < 3; i++ {
+		if a := b.Next(); a != 0 {
+			t.Errorf("expected no delay, got %v", a)
+		}
+	}
+}
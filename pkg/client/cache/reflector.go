@@ -17,6 +17,7 @@ limitations under the License.
 package cache
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
 	"time"
@@ -47,17 +48,28 @@ type Reflector struct {
 	// period controls timing between one watch ending and
 	// the beginning of the next one.
 	period time.Duration
+	// resyncPeriod, if positive, is how often the store is replaced with a
+	// fresh list, in case the watch missed anything.
+	resyncPeriod time.Duration
 }
 
 // NewReflector creates a new Reflector object which will keep the given store up to
 // date with the server's contents for the given resource. Reflector promises to
 // only put things in the store that have the type of expectedType.
 func NewReflector(lw ListerWatcher, expectedType interface{}, store Store) *Reflector {
+	return NewResyncingReflector(lw, expectedType, store, 0)
+}
+
+// NewResyncingReflector creates a new Reflector object which, in addition to
+// following the watch, lists the resource again every resyncPeriod.  This
+// repairs a store that missed an event, at the cost of a list per period.
+func NewResyncingReflector(lw ListerWatcher, expectedType interface{}, store Store, resyncPeriod time.Duration) *Reflector {
 	r := &Reflector{
 		listerWatcher: lw,
 		store:         store,
 		expectedType:  reflect.TypeOf(expectedType),
 		period:        time.Second,
+		resyncPeriod:  resyncPeriod,
 	}
 	return r
 }
@@ -93,16 +105,36 @@ func (r *Reflector) listAndWatch() {
 		return
 	}
 
+	var resync <-chan time.Time
+	if r.resyncPeriod > 0 {
+		timer := time.NewTimer(r.resyncPeriod)
+		defer timer.Stop()
+		resync = timer.C
+	}
 	for {
 		w, err := r.listerWatcher.Watch(resourceVersion)
 		if err != nil {
 			glog.Errorf("failed to watch %v: %v", r.expectedType, err)
 			return
 		}
-		r.watchHandler(w, &resourceVersion)
+		if err := r.watchHandler(w, &resourceVersion, resync); err != nil {
+			if err != errResync {
+				glog.Errorf("watch of %v ended: %v", r.expectedType, err)
+			}
+			return
+		}
 	}
 }
 
+var (
+	// errResync is returned by watchHandler when it is time to resync.
+	errResync = errors.New("resync requested")
+	// errStaleWatch is returned by watchHandler when the watch ended with an
+	// error, e.g. because the resource version is too old to watch from.
+	// Watching again would do the same.
+	errStaleWatch = errors.New("watch ended with an error, listing again")
+)
+
 // syncWith replaces the store's items with the given list.
 func (r *Reflector) syncWith(items []runtime.Object) error {
 	found := map[string]interface{}{}
@@ -118,13 +150,26 @@ func (r *Reflector) syncWith(items []runtime.Object) error {
 	return nil
 }
 
-// watchHandler watches w and keeps *resourceVersion up to date.
-func (r *Reflector) watchHandler(w watch.Interface, resourceVersion *uint64) {
+// watchHandler watches w and keeps *resourceVersion up to date, until w ends
+// or resync fires.  It returns an error if the resource should be listed
+// again rather than watched from *resourceVersion.
+func (r *Reflector) watchHandler(w watch.Interface, resourceVersion *uint64, resync <-chan time.Time) error {
+	defer w.Stop()
 	for {
-		event, ok := <-w.ResultChan()
+		var event watch.Event
+		var ok bool
+		select {
+		case <-resync:
+			return errResync
+		case event, ok = <-w.ResultChan():
+		}
 		if !ok {
-			glog.Errorf("unexpected watch close")
-			return
+			glog.V(2).Infof("watch of %v closed, resuming from %d", r.expectedType, *resourceVersion)
+			return nil
+		}
+		if event.Type == watch.Error {
+			glog.Errorf("watch of %v failed: %#v", r.expectedType, event.Object)
+			return errStaleWatch
 		}
 		if e, a := r.expectedType, reflect.TypeOf(event.Object); e != a {
 			glog.Errorf("expected type %v, but watch event object had type %v", e, a)
@@ -148,6 +193,6 @@ func (r *Reflector) watchHandler(w watch.Interface, resourceVersion *uint64) {
 		default:
 			glog.Errorf("unable to understand watch event %#v", event)
 		}
-		*resourceVersion = jsonBase.ResourceVersion() + 1
+		*resourceVersion = jsonBase.ResourceVersion()
 	}
 }
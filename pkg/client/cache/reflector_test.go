@@ -18,7 +18,9 @@ package cache
 
 import (
 	"fmt"
+	"net/http"
 	"testing"
+	"time"
 
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
@@ -49,7 +51,7 @@ func TestReflector_watchHandler(t *testing.T) {
 		fw.Stop()
 	}()
 	var resumeRV uint64
-	g.watchHandler(fw, &resumeRV)
+	g.watchHandler(fw, &resumeRV, nil)
 
 	table := []struct {
 		ID     string
@@ -74,8 +76,8 @@ func TestReflector_watchHandler(t *testing.T) {
 		}
 	}
 
-	// RV should stay 1 higher than the last id we see.
-	if e, a := uint64(33), resumeRV; e != a {
+	// RV should be the last one we see.
+	if e, a := uint64(32), resumeRV; e != a {
 		t.Errorf("expected %v, got %v", e, a)
 	}
 }
@@ -84,9 +86,9 @@ func TestReflector_listAndWatch(t *testing.T) {
 	createdFakes := make(chan *watch.FakeWatcher)
 
 	// The ListFunc says that it's at revision 1. Therefore, we expect our WatchFunc
-	// to get called at the beginning of the watch with 1, and again with 4 when we
+	// to get called at the beginning of the watch with 1, and again with 3 when we
 	// inject an error at 3.
-	expectedRVs := []uint64{1, 4}
+	expectedRVs := []uint64{1, 3}
 	lw := &testLW{
 		WatchFunc: func(rv uint64) (watch.Interface, error) {
 			fw := watch.NewFake()
@@ -225,3 +227,57 @@ func TestReflector_listAndWatchWithErrors(t *testing.T) {
 		r.listAndWatch()
 	}
 }
+
+func TestReflector_listAndWatchStaleWatch(t *testing.T) {
+	watches := 0
+	lw := &testLW{
+		WatchFunc: func(rv uint64) (watch.Interface, error) {
+			watches++
+			// Fail at once, as the server does for a resource version too old to watch from.
+			fw := watch.NewFake()
+			go func() {
+				fw.Error(&api.Status{Status: api.StatusFailure, Code: http.StatusGone})
+				fw.Stop()
+			}()
+			return fw, nil
+		},
+		ListFunc: func() (runtime.Object, error) {
+			return &api.PodList{JSONBase: api.JSONBase{ResourceVersion: 1}}, nil
+		},
+	}
+	r := NewReflector(lw, &api.Pod{}, NewStore())
+	r.listAndWatch()
+	if watches != 1 {
+		t.Errorf("expected to list again after 1 watch, watched %d times", watches)
+	}
+}
+
+func TestReflector_resync(t *testing.T) {
+	fw := watch.NewFake()
+	lists := 0
+	lw := &testLW{
+		WatchFunc: func(rv uint64) (watch.Interface, error) {
+			return fw, nil
+		},
+		ListFunc: func() (runtime.Object, error) {
+			lists++
+			return &api.PodList{
+				JSONBase: api.JSONBase{ResourceVersion: 1},
+				Items:    []api.Pod{{JSONBase: api.JSONBase{ID: "foo"}}},
+			}, nil
+		},
+	}
+	s := NewStore()
+	r := NewResyncingReflector(lw, &api.Pod{}, s, time.Millisecond)
+	// The watch never ends, so only the resync ends listAndWatch.
+	r.listAndWatch()
+	if lists != 1 {
+		t.Errorf("expected 1 list, got %d", lists)
+	}
+	if !fw.Stopped {
+		t.Errorf("expected the watch to be stopped")
+	}
+	if _, exists := s.Get("foo"); !exists {
+		t.Errorf("expected foo in the store")
+	}
+}
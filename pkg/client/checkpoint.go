@@ -0,0 +1,63 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/latest"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+)
+
+// WatchCheckpoint persists a watched collection, together with the
+// resourceVersion it was observed at, to a file.  A client which restarts can
+// load the checkpoint and resume its watch from that resourceVersion instead of
+// listing the whole collection from the apiserver again.
+type WatchCheckpoint struct {
+	path string
+}
+
+// NewWatchCheckpoint returns a WatchCheckpoint stored at path.
+func NewWatchCheckpoint(path string) *WatchCheckpoint {
+	return &WatchCheckpoint{path: path}
+}
+
+// Save replaces the checkpoint with obj, normally a list whose resourceVersion
+// is the one to resume watching from.  The file is replaced atomically, so a
+// crash while saving leaves the previous checkpoint intact.
+func (c *WatchCheckpoint) Save(obj runtime.Object) error {
+	data, err := latest.Codec.Encode(obj)
+	if err != nil {
+		return err
+	}
+	tmp := c.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
+
+// Load decodes the checkpoint into obj.  If nothing has been saved, the error
+// satisfies os.IsNotExist.
+func (c *WatchCheckpoint) Load(obj runtime.Object) error {
+	data, err := ioutil.ReadFile(c.path)
+	if err != nil {
+		return err
+	}
+	return latest.Codec.DecodeInto(data, obj)
+}
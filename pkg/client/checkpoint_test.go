@@ -0,0 +1,62 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+)
+
+func TestWatchCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoint")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	checkpoint := NewWatchCheckpoint(path.Join(dir, "services"))
+
+	var loaded api.ServiceList
+	if err := checkpoint.Load(&loaded); !os.IsNotExist(err) {
+		t.Errorf("expected a not exist error, got %v", err)
+	}
+
+	saved := &api.ServiceList{
+		JSONBase: api.JSONBase{ResourceVersion: 10},
+		Items: []api.Service{
+			{
+				JSONBase:        api.JSONBase{ID: "foo", ResourceVersion: 9},
+				Port:            80,
+				Protocol:        api.ProtocolTCP,
+				SessionAffinity: api.AffinityTypeNone,
+			},
+		},
+	}
+	if err := checkpoint.Save(saved); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := checkpoint.Load(&loaded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if loaded.ResourceVersion != 10 || !reflect.DeepEqual(saved.Items, loaded.Items) {
+		t.Errorf("expected %#v, got %#v", saved, loaded)
+	}
+}
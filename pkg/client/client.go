@@ -18,7 +18,9 @@ package client
 
 import (
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"expvar"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -29,8 +31,10 @@ import (
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/latest"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/version"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
+	"github.com/golang/glog"
 )
 
 // Interface holds the methods for clients of Kubernetes,
@@ -40,8 +44,10 @@ type Interface interface {
 	PodInterface
 	ReplicationControllerInterface
 	ServiceInterface
+	EndpointsInterface
 	VersionInterface
 	MinionInterface
+	ResourcesInterface
 }
 
 // PodInterface has methods to work with Pod resources.
@@ -76,12 +82,15 @@ type ServiceInterface interface {
 // EndpointsInterface has methods to work with Endpoints resources
 type EndpointsInterface interface {
 	ListEndpoints(selector labels.Selector) (*api.EndpointsList, error)
+	GetEndpoints(id string) (*api.Endpoints, error)
 	WatchEndpoints(label, field labels.Selector, resourceVersion uint64) (watch.Interface, error)
 }
 
-// VersionInterface has a method to retrieve the server version.
+// VersionInterface has methods to retrieve the server version and the API
+// versions it supports.
 type VersionInterface interface {
 	ServerVersion() (*version.Info, error)
+	ServerAPIVersions() (*api.APIVersions, error)
 }
 
 type MinionInterface interface {
@@ -106,6 +115,35 @@ func New(host string, auth *AuthInfo) (*Client, error) {
 	return &Client{restClient}, nil
 }
 
+// NewNegotiated creates a Kubernetes client that speaks the newest API version
+// supported by both the client and the server at host.  Servers that can't
+// list their versions are assumed to speak latest.Version.
+func NewNegotiated(host string, auth *AuthInfo) (*Client, error) {
+	c, err := New(host, auth)
+	if err != nil {
+		return nil, err
+	}
+	serverVersions, err := c.ServerAPIVersions()
+	if err != nil {
+		glog.V(2).Infof("Unable to list the server's API versions, using %s: %v", latest.Version, err)
+		return c, nil
+	}
+	supported := util.NewStringSet(serverVersions.Versions...)
+	for i := len(latest.Versions) - 1; i >= 0; i-- {
+		version := latest.Versions[i]
+		if !supported.Has(version) {
+			continue
+		}
+		codec, _ := latest.CodecFor(version)
+		restClient, err := NewRESTClient(host, auth, "/api/"+version+"/", codec)
+		if err != nil {
+			return nil, err
+		}
+		return &Client{restClient}, nil
+	}
+	return nil, fmt.Errorf("the server supports API versions %v, but this client only supports %v", serverVersions.Versions, latest.Versions)
+}
+
 // NewOrDie creates a Kubernetes client and panics if the provided host is invalid.
 func NewOrDie(host string, auth *AuthInfo) *Client {
 	client, err := New(host, auth)
@@ -129,6 +167,49 @@ func (s *StatusErr) Error() string {
 type AuthInfo struct {
 	User     string
 	Password string
+	// BearerToken, if set, is sent in place of User and Password.
+	BearerToken string `json:",omitempty"`
+
+	// CAFile or CAData (PEM encoded) hold the certificate authorities used
+	// to verify the server. If neither is set, the system's CAs are used.
+	CAFile string `json:",omitempty"`
+	CAData string `json:",omitempty"`
+	// Insecure, if true, skips verifying the server's certificate.
+	Insecure bool `json:",omitempty"`
+	// CertFile and KeyFile hold a client certificate presented to the server.
+	CertFile string `json:",omitempty"`
+	KeyFile  string `json:",omitempty"`
+}
+
+// tlsConfigFor returns the TLS configuration described by auth.
+func tlsConfigFor(auth *AuthInfo) (*tls.Config, error) {
+	config := &tls.Config{}
+	if auth == nil {
+		return config, nil
+	}
+	if auth.CAFile != "" || auth.CAData != "" {
+		data := []byte(auth.CAData)
+		if auth.CAFile != "" {
+			var err error
+			if data, err = ioutil.ReadFile(auth.CAFile); err != nil {
+				return nil, err
+			}
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificates found in CA data")
+		}
+		config.RootCAs = pool
+	}
+	config.InsecureSkipVerify = auth.Insecure
+	if auth.CertFile != "" || auth.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(auth.CertFile, auth.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
 }
 
 // RESTClient holds common code used to work with API resources that follow the
@@ -144,7 +225,39 @@ type RESTClient struct {
 	PollPeriod time.Duration
 	Timeout    time.Duration
 	Codec      runtime.Codec
-}
+
+	// Retries is how many times a GET, including a watch, is retried after
+	// a connection error or a 5xx response.  Zero means never.
+	Retries int
+	// RetryBackoff is the delay before each retry of a request, to which up
+	// to as much again is added at random so that clients don't retry in step.
+	RetryBackoff Backoff
+
+	// RequestTimeout is how long a request other than a watch may take,
+	// including reading the response, before it is abandoned.  It should be
+	// longer than Timeout, which the server waits for sync requests.  Zero
+	// means no limit.
+	RequestTimeout time.Duration
+	// WatchTimeout is how long a watch may take to start.  The watch itself
+	// may last any time.  Zero means no limit.
+	WatchTimeout time.Duration
+
+	// Throttle limits the rate of requests, including retries and watches,
+	// so that a client stuck in a loop can't overwhelm the server.  Nil
+	// means no limit.
+	Throttle util.RateLimiter
+}
+
+// Default limits on the rate of a RESTClient's requests.
+const (
+	DefaultQPS   float32 = 5.0
+	DefaultBurst int     = 10
+)
+
+// throttledRequests counts the requests delayed by RESTClients' throttles
+// ("requests"), and how long they waited ("milliseconds").  It is served with
+// the other expvars on /debug/vars.
+var throttledRequests = expvar.NewMap("throttled_requests")
 
 // NewRESTClient creates a new RESTClient. This client performs generic REST functions
 // such as Get, Put, Post, and Delete on specified paths.
@@ -157,6 +270,10 @@ func NewRESTClient(host string, auth *AuthInfo, path string, c runtime.Codec) (*
 	base.Path = ""
 	base.RawQuery = ""
 	base.Fragment = ""
+	tlsConfig, err := tlsConfigFor(auth)
+	if err != nil {
+		return nil, err
+	}
 	return &RESTClient{
 		host:   base.String(),
 		prefix: prefix.Path,
@@ -164,15 +281,21 @@ func NewRESTClient(host string, auth *AuthInfo, path string, c runtime.Codec) (*
 		auth:   auth,
 		httpClient: &http.Client{
 			Transport: &http.Transport{
-				TLSClientConfig: &tls.Config{
-					InsecureSkipVerify: true,
-				},
+				TLSClientConfig: tlsConfig,
 			},
 		},
 		Sync:       false,
 		PollPeriod: time.Second * 2,
 		Timeout:    time.Second * 20,
 		Codec:      c,
+
+		Retries:      3,
+		RetryBackoff: Backoff{Initial: time.Second / 2, Max: 5 * time.Second},
+
+		RequestTimeout: time.Minute,
+		WatchTimeout:   30 * time.Second,
+
+		Throttle: util.NewTokenBucketRateLimiter(DefaultQPS, DefaultBurst),
 	}, nil
 }
 
@@ -205,12 +328,49 @@ func (c *RESTClient) Secure() bool {
 	return c.secure
 }
 
+// throttle waits until the client's throttle allows another request.
+func (c *RESTClient) throttle() {
+	if c.Throttle == nil || c.Throttle.CanAccept() {
+		return
+	}
+	start := time.Now()
+	c.Throttle.Accept()
+	throttledRequests.Add("requests", 1)
+	throttledRequests.Add("milliseconds", int64(time.Since(start)/time.Millisecond))
+}
+
+// setAuth adds the client's credentials, if any, to request.
+func (c *RESTClient) setAuth(request *http.Request) {
+	switch {
+	case c.auth == nil:
+	case c.auth.BearerToken != "":
+		request.Header.Set("Authorization", "Bearer "+c.auth.BearerToken)
+	case c.auth.User != "" || c.auth.Password != "":
+		request.SetBasicAuth(c.auth.User, c.auth.Password)
+	}
+}
+
+// Host returns the scheme and address of the server, e.g. "https://10.0.0.1".
+func (c *RESTClient) Host() string {
+	return c.host
+}
+
+// RoundTrip implements http.RoundTripper, sending request as it is with the
+// client's credentials added, so that the client can be the transport of a
+// proxy to the server.  Requests sent this way are not throttled or retried.
+func (c *RESTClient) RoundTrip(request *http.Request) (*http.Response, error) {
+	c.setAuth(request)
+	transport := c.httpClient.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return transport.RoundTrip(request)
+}
+
 // doRequest executes a request, adds authentication (if auth != nil), and HTTPS
 // cert ignoring.
 func (c *RESTClient) doRequest(request *http.Request) ([]byte, error) {
-	if c.auth != nil {
-		request.SetBasicAuth(c.auth.User, c.auth.Password)
-	}
+	c.setAuth(request)
 	response, err := c.httpClient.Do(request)
 	if err != nil {
 		return nil, err
@@ -228,15 +388,18 @@ func (c *RESTClient) doRequest(request *http.Request) ([]byte, error) {
 		isStatusResponse = true
 	}
 
-	switch {
-	case response.StatusCode == http.StatusConflict:
+	if response.StatusCode < http.StatusOK || response.StatusCode > http.StatusPartialContent {
 		// Return error given by server, if there was one.
 		if isStatusResponse {
+			if status.Code == 0 {
+				status.Code = response.StatusCode
+			}
 			return nil, &StatusErr{status}
 		}
-		fallthrough
-	case response.StatusCode < http.StatusOK || response.StatusCode > http.StatusPartialContent:
-		return nil, fmt.Errorf("request [%#v] failed (%d) %s: %s", request, response.StatusCode, response.Status, string(body))
+		return nil, &requestError{
+			statusCode: response.StatusCode,
+			message:    fmt.Sprintf("request [%#v] failed (%d) %s: %s", request, response.StatusCode, response.Status, string(body)),
+		}
 	}
 
 	// If the server gave us a status back, look at what it was.
@@ -388,6 +551,13 @@ func (c *Client) ListEndpoints(selector labels.Selector) (result *api.EndpointsL
 	return
 }
 
+// GetEndpoints returns the endpoints of the service with the given id.
+func (c *Client) GetEndpoints(id string) (result *api.Endpoints, err error) {
+	result = &api.Endpoints{}
+	err = c.Get().Path("endpoints").Path(id).Do().Into(result)
+	return
+}
+
 // WatchEndpoints returns a watch.Interface that watches the requested endpoints for a service.
 func (c *Client) WatchEndpoints(label, field labels.Selector, resourceVersion uint64) (watch.Interface, error) {
 	return c.Get().
@@ -413,6 +583,20 @@ func (c *Client) ServerVersion() (*version.Info, error) {
 	return &info, nil
 }
 
+// ServerAPIVersions retrieves the API versions the server supports.
+func (c *Client) ServerAPIVersions() (*api.APIVersions, error) {
+	body, err := c.Get().AbsPath("/api").Do().Raw()
+	if err != nil {
+		return nil, err
+	}
+	var versions api.APIVersions
+	err = json.Unmarshal(body, &versions)
+	if err != nil {
+		return nil, fmt.Errorf("Got '%s': %v", string(body), err)
+	}
+	return &versions, nil
+}
+
 // ListMinions lists all the minions in the cluster.
 func (c *Client) ListMinions() (result *api.MinionList, err error) {
 	result = &api.MinionList{}
@@ -18,6 +18,7 @@ package client
 
 import (
 	"encoding/json"
+	"encoding/pem"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -366,9 +367,27 @@ func (c *testClient) Setup() *testClient {
 func (c *testClient) Validate(t *testing.T, received runtime.Object, err error) {
 	c.ValidateCommon(t, err)
 
-	if c.Response.Body != nil && !reflect.DeepEqual(c.Response.Body, received) {
-		t.Errorf("bad response for request %#v: expected %s, got %s", c.Request, c.Response.Body, received)
+	if c.Response.Body == nil {
+		return
+	}
+	// The client decodes the response, so the expected object carries the
+	// same defaults the codec fills in.
+	expected, decodeErr := decoded(c.Response.Body)
+	if decodeErr != nil {
+		t.Fatalf("unexpected error: %v", decodeErr)
+	}
+	if !reflect.DeepEqual(expected, received) {
+		t.Errorf("bad response for request %#v: expected %s, got %s", c.Request, expected, received)
+	}
+}
+
+// decoded returns obj as it looks after a round trip through the codec.
+func decoded(obj runtime.Object) (runtime.Object, error) {
+	data, err := latest.Codec.Encode(obj)
+	if err != nil {
+		return nil, err
 	}
+	return latest.Codec.Decode(data)
 }
 
 func (c *testClient) ValidateRaw(t *testing.T, received []byte, err error) {
@@ -479,6 +498,15 @@ func TestGetService(t *testing.T) {
 	c.Validate(t, response, err)
 }
 
+func TestGetEndpoints(t *testing.T) {
+	c := &testClient{
+		Request:  testRequest{Method: "GET", Path: "/endpoints/service-1"},
+		Response: Response{StatusCode: 200, Body: &api.Endpoints{JSONBase: api.JSONBase{ID: "service-1"}, Endpoints: []string{"10.0.0.1:8080"}}},
+	}
+	response, err := c.Setup().GetEndpoints("service-1")
+	c.Validate(t, response, err)
+}
+
 func TestCreateService(t *testing.T) {
 	c := (&testClient{
 		Request:  testRequest{Method: "POST", Path: "/services", Body: &api.Service{JSONBase: api.JSONBase{ID: "service-1"}}},
@@ -512,7 +540,7 @@ func TestDoRequest(t *testing.T) {
 	testClients := []testClient{
 		{Request: testRequest{Method: "GET", Path: "good"}, Response: Response{StatusCode: 200}},
 		{Request: testRequest{Method: "GET", Path: "bad%ZZ"}, Error: true},
-		{Client: NewOrDie("localhost", &AuthInfo{"foo", "bar"}), Request: testRequest{Method: "GET", Path: "auth", Header: "Authorization"}, Response: Response{StatusCode: 200}},
+		{Client: NewOrDie("localhost", &AuthInfo{User: "foo", Password: "bar"}), Request: testRequest{Method: "GET", Path: "auth", Header: "Authorization"}, Response: Response{StatusCode: 200}},
 		{Client: &Client{&RESTClient{httpClient: http.DefaultClient}}, Request: testRequest{Method: "GET", Path: "nocertificate"}, Error: true},
 		{Request: testRequest{Method: "GET", Path: "error"}, Response: Response{StatusCode: 500}, Error: true},
 		{Request: testRequest{Method: "POST", Path: "faildecode"}, Response: Response{StatusCode: 200, RawBody: &invalid}},
@@ -532,6 +560,68 @@ func TestDoRequest(t *testing.T) {
 	}
 }
 
+func TestDoRequestBearerToken(t *testing.T) {
+	var header string
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		header = req.Header.Get("Authorization")
+	}))
+	defer testServer.Close()
+	c, err := New(testServer.URL, &AuthInfo{User: "user", Password: "pass", BearerToken: "token"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	request, _ := http.NewRequest("GET", testServer.URL+"/foo", nil)
+	if _, err := c.doRequest(request); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if header != "Bearer token" {
+		t.Errorf("unexpected authorization header: %q", header)
+	}
+}
+
+func TestDoRequestVerifiesServer(t *testing.T) {
+	testServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	defer testServer.Close()
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: testServer.Certificate().Raw})
+
+	c, err := New(testServer.URL, &AuthInfo{CAData: string(ca)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	request, _ := http.NewRequest("GET", testServer.URL+"/foo", nil)
+	if _, err := c.doRequest(request); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// Without a CA the server is verified against the system's CAs, unless
+	// verification is turned off.
+	testCases := []struct {
+		Auth *AuthInfo
+		Err  bool
+	}{
+		{nil, true},
+		{&AuthInfo{}, true},
+		{&AuthInfo{Insecure: true}, false},
+	}
+	for i, testCase := range testCases {
+		c, err := New(testServer.URL, testCase.Auth)
+		if err != nil {
+			t.Fatalf("%d: unexpected error: %v", i, err)
+		}
+		request, _ := http.NewRequest("GET", testServer.URL+"/foo", nil)
+		if _, err := c.doRequest(request); (err != nil) != testCase.Err {
+			t.Errorf("%d: expected error %t, got %v", i, testCase.Err, err)
+		}
+	}
+
+	if _, err := New(testServer.URL, &AuthInfo{CAData: "not a certificate"}); err == nil {
+		t.Errorf("expected an error for invalid CA data")
+	}
+	if _, err := New(testServer.URL, &AuthInfo{CertFile: "/does/not/exist", KeyFile: "/does/not/exist"}); err == nil {
+		t.Errorf("expected an error for a missing client certificate")
+	}
+}
+
 func TestDoRequestAccepted(t *testing.T) {
 	status := &api.Status{Status: api.StatusWorking}
 	expectedBody, _ := latest.Codec.Encode(status)
@@ -628,6 +718,44 @@ func TestGetServerVersion(t *testing.T) {
 	}
 }
 
+func TestNewNegotiated(t *testing.T) {
+	testCases := []struct {
+		Versions *api.APIVersions
+		Prefix   string
+		Err      bool
+	}{
+		{&api.APIVersions{Versions: []string{"v1beta1", "v1beta2", "v9"}}, "/api/v1beta2/", false},
+		{&api.APIVersions{Versions: []string{"v1beta1"}}, "/api/v1beta1/", false},
+		// A server that predates version listing.
+		{nil, "/api/v1beta1/", false},
+		{&api.APIVersions{Versions: []string{"v9"}}, "", true},
+	}
+	for i, testCase := range testCases {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.URL.Path != "/api" || testCase.Versions == nil {
+				http.NotFound(w, req)
+				return
+			}
+			output, err := json.Marshal(testCase.Versions)
+			if err != nil {
+				t.Errorf("unexpected encoding error: %v", err)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(output)
+		}))
+		client, err := NewNegotiated(server.URL, nil)
+		server.Close()
+		if (err != nil) != testCase.Err {
+			t.Errorf("%d: unexpected error: %v", i, err)
+			continue
+		}
+		if client != nil && client.prefix != testCase.Prefix {
+			t.Errorf("%d: expected prefix %s, got %s", i, testCase.Prefix, client.prefix)
+		}
+	}
+}
+
 func TestListMinions(t *testing.T) {
 	c := &testClient{
 		Request:  testRequest{Method: "GET", Path: "/minions"},
@@ -18,132 +18,423 @@ package client
 
 import (
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/latest"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/version"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
 )
 
+// FakeAction records a call made to Fake.  Action names the verb and the
+// resource, e.g. "create-pod", and Value holds the name or object passed.
 type FakeAction struct {
 	Action string
 	Value  interface{}
 }
 
+// FakeReactor computes the result of a call to Fake in place of the default.
+// It may return handled == false to fall back to the default result.
+type FakeReactor func(action FakeAction) (handled bool, ret interface{}, err error)
+
 // Fake implements Interface. Meant to be embedded into a struct to get a default
 // implementation. This makes faking out just the method you want to test easier.
 type Fake struct {
 	// Fake by default keeps a simple list of the methods that have been called.
 	Actions       []FakeAction
-	Pods          api.PodList
+	PodList       api.PodList
 	Ctrl          api.ReplicationController
 	ServiceList   api.ServiceList
 	EndpointsList api.EndpointsList
-	Minions       api.MinionList
+	MinionList    api.MinionList
 	Err           error
-	Watch         watch.Interface
+	// Watch is returned by every watch call.  If it is nil, watches instead
+	// see the pods, controllers and services created, updated and deleted
+	// through the fake.
+	Watch watch.Interface
+	// Reactors, keyed by action, override the results of calls.
+	Reactors map[string]FakeReactor
+
+	broadcasters map[string]*watch.Broadcaster
+}
+
+// AddReactor makes reactor compute the results of calls recorded as action.
+func (c *Fake) AddReactor(action string, reactor FakeReactor) {
+	if c.Reactors == nil {
+		c.Reactors = map[string]FakeReactor{}
+	}
+	c.Reactors[action] = reactor
+}
+
+// invoke records action and runs its reactor, if there is one.
+func (c *Fake) invoke(action FakeAction) (handled bool, ret interface{}, err error) {
+	c.Actions = append(c.Actions, action)
+	if reactor, ok := c.Reactors[action.Action]; ok {
+		return reactor(action)
+	}
+	return false, nil, nil
+}
+
+// watch returns a watch on resource.
+func (c *Fake) watch(resource string) watch.Interface {
+	if c.Watch != nil {
+		return c.Watch
+	}
+	if c.broadcasters == nil {
+		c.broadcasters = map[string]*watch.Broadcaster{}
+	}
+	broadcaster, ok := c.broadcasters[resource]
+	if !ok {
+		broadcaster = watch.NewBroadcaster(100, watch.WaitIfChannelFull)
+		c.broadcasters[resource] = broadcaster
+	}
+	return broadcaster.Watch()
+}
+
+// notify sends an event to the watches started on resource, if any.
+func (c *Fake) notify(resource string, action watch.EventType, obj runtime.Object) {
+	if broadcaster, ok := c.broadcasters[resource]; ok {
+		broadcaster.Action(action, obj)
+	}
 }
 
 func (c *Fake) ListPods(selector labels.Selector) (*api.PodList, error) {
-	c.Actions = append(c.Actions, FakeAction{Action: "list-pods"})
-	return api.Scheme.CopyOrDie(&c.Pods).(*api.PodList), nil
+	if handled, ret, err := c.invoke(FakeAction{Action: "list-pods"}); handled {
+		list, _ := ret.(*api.PodList)
+		return list, err
+	}
+	return api.Scheme.CopyOrDie(&c.PodList).(*api.PodList), nil
 }
 
 func (c *Fake) GetPod(name string) (*api.Pod, error) {
-	c.Actions = append(c.Actions, FakeAction{Action: "get-pod", Value: name})
+	if handled, ret, err := c.invoke(FakeAction{Action: "get-pod", Value: name}); handled {
+		pod, _ := ret.(*api.Pod)
+		return pod, err
+	}
 	return &api.Pod{}, nil
 }
 
 func (c *Fake) DeletePod(name string) error {
-	c.Actions = append(c.Actions, FakeAction{Action: "delete-pod", Value: name})
+	if handled, _, err := c.invoke(FakeAction{Action: "delete-pod", Value: name}); handled {
+		return err
+	}
+	c.notify("pods", watch.Deleted, &api.Pod{JSONBase: api.JSONBase{ID: name}})
 	return nil
 }
 
 func (c *Fake) CreatePod(pod *api.Pod) (*api.Pod, error) {
-	c.Actions = append(c.Actions, FakeAction{Action: "create-pod"})
+	if handled, ret, err := c.invoke(FakeAction{Action: "create-pod", Value: pod}); handled {
+		pod, _ := ret.(*api.Pod)
+		return pod, err
+	}
+	c.notify("pods", watch.Added, pod)
 	return &api.Pod{}, nil
 }
 
 func (c *Fake) UpdatePod(pod *api.Pod) (*api.Pod, error) {
-	c.Actions = append(c.Actions, FakeAction{Action: "update-pod", Value: pod.ID})
+	if handled, ret, err := c.invoke(FakeAction{Action: "update-pod", Value: pod}); handled {
+		pod, _ := ret.(*api.Pod)
+		return pod, err
+	}
+	c.notify("pods", watch.Modified, pod)
 	return &api.Pod{}, nil
 }
 
 func (c *Fake) ListReplicationControllers(selector labels.Selector) (*api.ReplicationControllerList, error) {
-	c.Actions = append(c.Actions, FakeAction{Action: "list-controllers"})
+	if handled, ret, err := c.invoke(FakeAction{Action: "list-controllers"}); handled {
+		list, _ := ret.(*api.ReplicationControllerList)
+		return list, err
+	}
 	return &api.ReplicationControllerList{}, nil
 }
 
 func (c *Fake) GetReplicationController(name string) (*api.ReplicationController, error) {
-	c.Actions = append(c.Actions, FakeAction{Action: "get-controller", Value: name})
+	if handled, ret, err := c.invoke(FakeAction{Action: "get-controller", Value: name}); handled {
+		controller, _ := ret.(*api.ReplicationController)
+		return controller, err
+	}
 	return api.Scheme.CopyOrDie(&c.Ctrl).(*api.ReplicationController), nil
 }
 
 func (c *Fake) CreateReplicationController(controller *api.ReplicationController) (*api.ReplicationController, error) {
-	c.Actions = append(c.Actions, FakeAction{Action: "create-controller", Value: controller})
+	if handled, ret, err := c.invoke(FakeAction{Action: "create-controller", Value: controller}); handled {
+		controller, _ := ret.(*api.ReplicationController)
+		return controller, err
+	}
+	c.notify("controllers", watch.Added, controller)
 	return &api.ReplicationController{}, nil
 }
 
 func (c *Fake) UpdateReplicationController(controller *api.ReplicationController) (*api.ReplicationController, error) {
-	c.Actions = append(c.Actions, FakeAction{Action: "update-controller", Value: controller})
+	if handled, ret, err := c.invoke(FakeAction{Action: "update-controller", Value: controller}); handled {
+		controller, _ := ret.(*api.ReplicationController)
+		return controller, err
+	}
+	c.notify("controllers", watch.Modified, controller)
 	return &api.ReplicationController{}, nil
 }
 
 func (c *Fake) DeleteReplicationController(controller string) error {
-	c.Actions = append(c.Actions, FakeAction{Action: "delete-controller", Value: controller})
+	if handled, _, err := c.invoke(FakeAction{Action: "delete-controller", Value: controller}); handled {
+		return err
+	}
+	c.notify("controllers", watch.Deleted, &api.ReplicationController{JSONBase: api.JSONBase{ID: controller}})
 	return nil
 }
 
 func (c *Fake) WatchReplicationControllers(label, field labels.Selector, resourceVersion uint64) (watch.Interface, error) {
-	c.Actions = append(c.Actions, FakeAction{Action: "watch-controllers", Value: resourceVersion})
-	return c.Watch, nil
+	if handled, ret, err := c.invoke(FakeAction{Action: "watch-controllers", Value: resourceVersion}); handled {
+		w, _ := ret.(watch.Interface)
+		return w, err
+	}
+	return c.watch("controllers"), nil
 }
 
 func (c *Fake) ListServices(selector labels.Selector) (*api.ServiceList, error) {
-	c.Actions = append(c.Actions, FakeAction{Action: "list-services"})
+	if handled, ret, err := c.invoke(FakeAction{Action: "list-services"}); handled {
+		list, _ := ret.(*api.ServiceList)
+		return list, err
+	}
 	return &c.ServiceList, c.Err
 }
 
 func (c *Fake) GetService(name string) (*api.Service, error) {
-	c.Actions = append(c.Actions, FakeAction{Action: "get-service", Value: name})
+	if handled, ret, err := c.invoke(FakeAction{Action: "get-service", Value: name}); handled {
+		service, _ := ret.(*api.Service)
+		return service, err
+	}
 	return &api.Service{}, nil
 }
 
 func (c *Fake) CreateService(service *api.Service) (*api.Service, error) {
-	c.Actions = append(c.Actions, FakeAction{Action: "create-service", Value: service})
+	if handled, ret, err := c.invoke(FakeAction{Action: "create-service", Value: service}); handled {
+		service, _ := ret.(*api.Service)
+		return service, err
+	}
+	c.notify("services", watch.Added, service)
 	return &api.Service{}, nil
 }
 
 func (c *Fake) UpdateService(service *api.Service) (*api.Service, error) {
-	c.Actions = append(c.Actions, FakeAction{Action: "update-service", Value: service})
+	if handled, ret, err := c.invoke(FakeAction{Action: "update-service", Value: service}); handled {
+		service, _ := ret.(*api.Service)
+		return service, err
+	}
+	c.notify("services", watch.Modified, service)
 	return &api.Service{}, nil
 }
 
 func (c *Fake) DeleteService(service string) error {
-	c.Actions = append(c.Actions, FakeAction{Action: "delete-service", Value: service})
+	if handled, _, err := c.invoke(FakeAction{Action: "delete-service", Value: service}); handled {
+		return err
+	}
+	c.notify("services", watch.Deleted, &api.Service{JSONBase: api.JSONBase{ID: service}})
 	return nil
 }
 
 func (c *Fake) WatchServices(label, field labels.Selector, resourceVersion uint64) (watch.Interface, error) {
-	c.Actions = append(c.Actions, FakeAction{Action: "watch-services", Value: resourceVersion})
-	return c.Watch, c.Err
+	if handled, ret, err := c.invoke(FakeAction{Action: "watch-services", Value: resourceVersion}); handled {
+		w, _ := ret.(watch.Interface)
+		return w, err
+	}
+	return c.watch("services"), c.Err
 }
 
 func (c *Fake) ListEndpoints(selector labels.Selector) (*api.EndpointsList, error) {
-	c.Actions = append(c.Actions, FakeAction{Action: "list-endpoints"})
+	if handled, ret, err := c.invoke(FakeAction{Action: "list-endpoints"}); handled {
+		list, _ := ret.(*api.EndpointsList)
+		return list, err
+	}
 	return api.Scheme.CopyOrDie(&c.EndpointsList).(*api.EndpointsList), c.Err
 }
 
+func (c *Fake) GetEndpoints(id string) (*api.Endpoints, error) {
+	if handled, ret, err := c.invoke(FakeAction{Action: "get-endpoints", Value: id}); handled {
+		endpoints, _ := ret.(*api.Endpoints)
+		return endpoints, err
+	}
+	return &api.Endpoints{}, nil
+}
+
 func (c *Fake) WatchEndpoints(label, field labels.Selector, resourceVersion uint64) (watch.Interface, error) {
-	c.Actions = append(c.Actions, FakeAction{Action: "watch-endpoints", Value: resourceVersion})
-	return c.Watch, c.Err
+	if handled, ret, err := c.invoke(FakeAction{Action: "watch-endpoints", Value: resourceVersion}); handled {
+		w, _ := ret.(watch.Interface)
+		return w, err
+	}
+	return c.watch("endpoints"), c.Err
 }
 
 func (c *Fake) ServerVersion() (*version.Info, error) {
-	c.Actions = append(c.Actions, FakeAction{Action: "get-version", Value: nil})
+	if handled, ret, err := c.invoke(FakeAction{Action: "get-version", Value: nil}); handled {
+		info, _ := ret.(*version.Info)
+		return info, err
+	}
 	versionInfo := version.Get()
 	return &versionInfo, nil
 }
 
+func (c *Fake) ServerAPIVersions() (*api.APIVersions, error) {
+	if handled, ret, err := c.invoke(FakeAction{Action: "get-apiversions", Value: nil}); handled {
+		versions, _ := ret.(*api.APIVersions)
+		return versions, err
+	}
+	return &api.APIVersions{Versions: latest.Versions}, nil
+}
+
 func (c *Fake) ListMinions() (*api.MinionList, error) {
-	c.Actions = append(c.Actions, FakeAction{Action: "list-minions", Value: nil})
-	return &c.Minions, nil
+	if handled, ret, err := c.invoke(FakeAction{Action: "list-minions", Value: nil}); handled {
+		list, _ := ret.(*api.MinionList)
+		return list, err
+	}
+	return &c.MinionList, nil
+}
+func (c *Fake) Pods() PodsClient {
+	return &FakePods{c}
+}
+
+func (c *Fake) ReplicationControllers() ReplicationControllersClient {
+	return &FakeReplicationControllers{c}
+}
+
+func (c *Fake) Services() ServicesClient {
+	return &FakeServices{c}
+}
+
+func (c *Fake) Minions() MinionsClient {
+	return &FakeMinions{c}
+}
+
+// FakePods implements PodsClient, recording actions in Fake.
+type FakePods struct {
+	Fake *Fake
+}
+
+func (c *FakePods) List(selector labels.Selector) (*api.PodList, error) {
+	return c.Fake.ListPods(selector)
+}
+
+func (c *FakePods) Get(id string) (*api.Pod, error) {
+	return c.Fake.GetPod(id)
+}
+
+func (c *FakePods) Create(pod *api.Pod) (*api.Pod, error) {
+	return c.Fake.CreatePod(pod)
+}
+
+func (c *FakePods) Update(pod *api.Pod) (*api.Pod, error) {
+	return c.Fake.UpdatePod(pod)
+}
+
+func (c *FakePods) Delete(id string) error {
+	return c.Fake.DeletePod(id)
+}
+
+func (c *FakePods) Watch(label, field labels.Selector, resourceVersion uint64) (watch.Interface, error) {
+	if handled, ret, err := c.Fake.invoke(FakeAction{Action: "watch-pods", Value: resourceVersion}); handled {
+		w, _ := ret.(watch.Interface)
+		return w, err
+	}
+	return c.Fake.watch("pods"), c.Fake.Err
+}
+
+// FakeReplicationControllers implements ReplicationControllersClient, recording actions in Fake.
+type FakeReplicationControllers struct {
+	Fake *Fake
+}
+
+func (c *FakeReplicationControllers) List(selector labels.Selector) (*api.ReplicationControllerList, error) {
+	return c.Fake.ListReplicationControllers(selector)
+}
+
+func (c *FakeReplicationControllers) Get(id string) (*api.ReplicationController, error) {
+	return c.Fake.GetReplicationController(id)
+}
+
+func (c *FakeReplicationControllers) Create(controller *api.ReplicationController) (*api.ReplicationController, error) {
+	return c.Fake.CreateReplicationController(controller)
+}
+
+func (c *FakeReplicationControllers) Update(controller *api.ReplicationController) (*api.ReplicationController, error) {
+	return c.Fake.UpdateReplicationController(controller)
+}
+
+func (c *FakeReplicationControllers) Delete(id string) error {
+	return c.Fake.DeleteReplicationController(id)
+}
+
+func (c *FakeReplicationControllers) Watch(label, field labels.Selector, resourceVersion uint64) (watch.Interface, error) {
+	return c.Fake.WatchReplicationControllers(label, field, resourceVersion)
+}
+
+// FakeServices implements ServicesClient, recording actions in Fake.
+type FakeServices struct {
+	Fake *Fake
+}
+
+func (c *FakeServices) List(selector labels.Selector) (*api.ServiceList, error) {
+	return c.Fake.ListServices(selector)
+}
+
+func (c *FakeServices) Get(id string) (*api.Service, error) {
+	return c.Fake.GetService(id)
+}
+
+func (c *FakeServices) Create(service *api.Service) (*api.Service, error) {
+	return c.Fake.CreateService(service)
+}
+
+func (c *FakeServices) Update(service *api.Service) (*api.Service, error) {
+	return c.Fake.UpdateService(service)
+}
+
+func (c *FakeServices) Delete(id string) error {
+	return c.Fake.DeleteService(id)
+}
+
+func (c *FakeServices) Watch(label, field labels.Selector, resourceVersion uint64) (watch.Interface, error) {
+	return c.Fake.WatchServices(label, field, resourceVersion)
+}
+
+// FakeMinions implements MinionsClient, recording actions in Fake.
+type FakeMinions struct {
+	Fake *Fake
+}
+
+func (c *FakeMinions) List(selector labels.Selector) (*api.MinionList, error) {
+	return c.Fake.ListMinions()
+}
+
+func (c *FakeMinions) Get(id string) (*api.Minion, error) {
+	if handled, ret, err := c.Fake.invoke(FakeAction{Action: "get-minion", Value: id}); handled {
+		minion, _ := ret.(*api.Minion)
+		return minion, err
+	}
+	for i := range c.Fake.MinionList.Items {
+		if c.Fake.MinionList.Items[i].ID == id {
+			return &c.Fake.MinionList.Items[i], c.Fake.Err
+		}
+	}
+	return &api.Minion{}, c.Fake.Err
+}
+
+func (c *FakeMinions) Create(minion *api.Minion) (*api.Minion, error) {
+	if handled, ret, err := c.Fake.invoke(FakeAction{Action: "create-minion", Value: minion}); handled {
+		minion, _ := ret.(*api.Minion)
+		return minion, err
+	}
+	return &api.Minion{}, c.Fake.Err
+}
+
+func (c *FakeMinions) Update(minion *api.Minion) (*api.Minion, error) {
+	if handled, ret, err := c.Fake.invoke(FakeAction{Action: "update-minion", Value: minion}); handled {
+		minion, _ := ret.(*api.Minion)
+		return minion, err
+	}
+	return &api.Minion{}, c.Fake.Err
+}
+
+func (c *FakeMinions) Delete(id string) error {
+	if handled, _, err := c.Fake.invoke(FakeAction{Action: "delete-minion", Value: id}); handled {
+		return err
+	}
+	return c.Fake.Err
 }
@@ -17,7 +17,12 @@ limitations under the License.
 package client
 
 import (
+	"errors"
+	"reflect"
 	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
 )
 
 // This test file just ensures that Fake and structs it is embedded in
@@ -35,3 +40,82 @@ func TestEmbeddedFakeImplementsInterface(t *testing.T) {
 	_ = Interface(MyFake{&Fake{}})
 	_ = Interface(&MyFake{&Fake{}})
 }
+
+func TestFakeRecordsObjects(t *testing.T) {
+	fake := &Fake{}
+	pod := &api.Pod{JSONBase: api.JSONBase{ID: "foo"}}
+	fake.CreatePod(pod)
+	fake.UpdatePod(pod)
+	fake.DeletePod("foo")
+	expected := []FakeAction{
+		{Action: "create-pod", Value: pod},
+		{Action: "update-pod", Value: pod},
+		{Action: "delete-pod", Value: "foo"},
+	}
+	if !reflect.DeepEqual(expected, fake.Actions) {
+		t.Errorf("Expected %#v, got %#v", expected, fake.Actions)
+	}
+}
+
+func TestFakeReactors(t *testing.T) {
+	fake := &Fake{}
+	expected := &api.Pod{JSONBase: api.JSONBase{ID: "foo"}}
+	fake.AddReactor("get-pod", func(action FakeAction) (bool, interface{}, error) {
+		if action.Value != "foo" {
+			return false, nil, nil
+		}
+		return true, expected, nil
+	})
+	fake.AddReactor("delete-pod", func(action FakeAction) (bool, interface{}, error) {
+		return true, nil, errors.New("test error")
+	})
+
+	if pod, err := fake.Pods().Get("foo"); err != nil || pod != expected {
+		t.Errorf("Unexpected result: %#v %v", pod, err)
+	}
+	if pod, err := fake.GetPod("bar"); err != nil || pod.ID != "" {
+		t.Errorf("Expected the default result, got: %#v %v", pod, err)
+	}
+	if err := fake.DeletePod("foo"); err == nil {
+		t.Errorf("Expected an error")
+	}
+	if len(fake.Actions) != 3 {
+		t.Errorf("Unexpected actions: %#v", fake.Actions)
+	}
+}
+
+func TestFakeWatch(t *testing.T) {
+	fake := &Fake{}
+	w, err := fake.Pods().Watch(nil, nil, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer w.Stop()
+	services, err := fake.WatchServices(nil, nil, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer services.Stop()
+
+	pod := &api.Pod{JSONBase: api.JSONBase{ID: "foo"}}
+	go func() {
+		fake.CreatePod(pod)
+		fake.UpdatePod(pod)
+		fake.DeletePod("foo")
+	}()
+	expected := []watch.Event{
+		{Type: watch.Added, Object: pod},
+		{Type: watch.Modified, Object: pod},
+		{Type: watch.Deleted, Object: &api.Pod{JSONBase: api.JSONBase{ID: "foo"}}},
+	}
+	for i, e := range expected {
+		if a := <-w.ResultChan(); !reflect.DeepEqual(e, a) {
+			t.Errorf("%d: expected %#v, got %#v", i, e, a)
+		}
+	}
+	select {
+	case event := <-services.ResultChan():
+		t.Errorf("Unexpected service event: %#v", event)
+	default:
+	}
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
+	"github.com/golang/glog"
+)
+
+// ListWatch keeps up with a resource by listing it, then watching it from the
+// list's resource version.  A watch which ends is resumed after the last event
+// it delivered.  A watch which can't be started, or which ends with an error
+// event (e.g. because the server can no longer watch from that resource
+// version), is taken to mean the resource must be listed again.
+//
+// Example usage, to keep up with services:
+// lw := &ListWatch{
+//	List: func() (runtime.Object, error) {
+//		return c.ListServices(labels.Everything())
+//	},
+//	Watch: func(resourceVersion uint64) (watch.Interface, error) {
+//		return c.WatchServices(labels.Everything(), labels.Everything(), resourceVersion)
+//	},
+//	Backoff: Backoff{Initial: time.Second, Max: time.Minute},
+// }
+// go lw.Run(handleList, handleEvent, stop)
+//
+type ListWatch struct {
+	// List lists the resource.  The list's ResourceVersion is where watching starts.
+	List func() (runtime.Object, error)
+	// Watch watches the resource from resourceVersion.
+	Watch func(resourceVersion uint64) (watch.Interface, error)
+	// Backoff spaces attempts after failures.  The zero value tries again at once.
+	Backoff Backoff
+}
+
+// Run calls handleList with each list and handleEvent with each watch event,
+// in order, until stop is closed.  Events follow the list they come after.
+func (lw *ListWatch) Run(handleList func(list runtime.Object), handleEvent func(event watch.Event), stop <-chan struct{}) {
+	var resourceVersion uint64
+	listed := false
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		if !listed {
+			list, err := lw.List()
+			if err != nil {
+				glog.Errorf("Unable to list: %v", err)
+				lw.wait(stop)
+				continue
+			}
+			jsonBase, err := runtime.FindJSONBase(list)
+			if err != nil {
+				glog.Errorf("Unable to understand list result %#v: %v", list, err)
+				lw.wait(stop)
+				continue
+			}
+			resourceVersion = jsonBase.ResourceVersion()
+			listed = true
+			handleList(list)
+		}
+		w, err := lw.Watch(resourceVersion)
+		if err != nil {
+			glog.Errorf("Unable to watch from %d, listing again: %v", resourceVersion, err)
+			listed = false
+			lw.wait(stop)
+			continue
+		}
+		received, err := lw.handleWatch(w, &resourceVersion, handleEvent, stop)
+		if err != nil {
+			glog.Errorf("Watch from %d failed, listing again: %v", resourceVersion, err)
+			listed = false
+			lw.wait(stop)
+			continue
+		}
+		if received {
+			lw.Backoff.Reset()
+			continue
+		}
+		// Don't spin on a server which closes watches at once.
+		glog.V(2).Infof("Watch from %d ended without any events, watching again", resourceVersion)
+		lw.wait(stop)
+	}
+}
+
+// handleWatch passes the events of w to handleEvent, keeping *resourceVersion
+// at the last one, until w ends or stop is closed.  It returns whether any
+// event was received, and an error if w ended with an error event, which
+// isn't passed on.
+func (lw *ListWatch) handleWatch(w watch.Interface, resourceVersion *uint64, handleEvent func(event watch.Event), stop <-chan struct{}) (bool, error) {
+	defer w.Stop()
+	received := false
+	for {
+		select {
+		case <-stop:
+			return true, nil
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return received, nil
+			}
+			if event.Type == watch.Error {
+				return received, fmt.Errorf("watch failed: %#v", event.Object)
+			}
+			received = true
+			if jsonBase, err := runtime.FindJSONBase(event.Object); err == nil {
+				*resourceVersion = jsonBase.ResourceVersion()
+			} else {
+				glog.Errorf("Unable to understand watch event %#v: %v", event, err)
+			}
+			handleEvent(event)
+		}
+	}
+}
+
+// wait sleeps for the next backoff delay, or until stop is closed.
+func (lw *ListWatch) wait(stop <-chan struct{}) {
+	select {
+	case <-stop:
+	case <-time.After(lw.Backoff.Next()):
+	}
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
+)
+
+func TestListWatch(t *testing.T) {
+	lists := []*api.PodList{
+		{JSONBase: api.JSONBase{ResourceVersion: 1}},
+		{JSONBase: api.JSONBase{ResourceVersion: 5}},
+		{JSONBase: api.JSONBase{ResourceVersion: 7}},
+	}
+	watches := make(chan *watch.FakeWatcher)
+	var watchedFrom []uint64
+	lw := &ListWatch{
+		List: func() (runtime.Object, error) {
+			if len(lists) == 0 {
+				return nil, fmt.Errorf("no more lists")
+			}
+			list := lists[0]
+			lists = lists[1:]
+			return list, nil
+		},
+		Watch: func(resourceVersion uint64) (watch.Interface, error) {
+			watchedFrom = append(watchedFrom, resourceVersion)
+			if resourceVersion == 5 {
+				return nil, fmt.Errorf("can't watch from 5")
+			}
+			fw := watch.NewFake()
+			go func() { watches <- fw }()
+			return fw, nil
+		},
+	}
+
+	var seen []string
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		lw.Run(func(list runtime.Object) {
+			seen = append(seen, fmt.Sprintf("list %d", list.(*api.PodList).ResourceVersion))
+		}, func(event watch.Event) {
+			seen = append(seen, fmt.Sprintf("%s %s", event.Type, event.Object.(*api.Pod).ID))
+		}, stop)
+		close(done)
+	}()
+
+	// The first watch delivers an event and ends, so it is resumed.
+	fw := <-watches
+	fw.Add(&api.Pod{JSONBase: api.JSONBase{ID: "foo", ResourceVersion: 2}})
+	fw.Stop()
+	// The resumed watch ends quietly, so it is resumed again.
+	fw = <-watches
+	fw.Stop()
+	// That watch ends with an error, so the pods are listed again.
+	fw = <-watches
+	fw.Error(&api.Status{Status: api.StatusFailure, Code: http.StatusGone})
+	// The watch from 5 fails, so the pods are listed again.
+	fw = <-watches
+	fw.Modify(&api.Pod{JSONBase: api.JSONBase{ID: "foo", ResourceVersion: 8}})
+	close(stop)
+	<-done
+
+	expectedSeen := []string{"list 1", "ADDED foo", "list 5", "list 7", "MODIFIED foo"}
+	if !reflect.DeepEqual(seen, expectedSeen) {
+		t.Errorf("expected %v, got %v", expectedSeen, seen)
+	}
+	expectedFrom := []uint64{1, 2, 2, 5, 7}
+	if !reflect.DeepEqual(watchedFrom, expectedFrom) {
+		t.Errorf("expected watches from %v, got %v", expectedFrom, watchedFrom)
+	}
+}
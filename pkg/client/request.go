@@ -18,6 +18,7 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -32,6 +33,7 @@ import (
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util/wait"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
 	"github.com/golang/glog"
 )
@@ -62,6 +64,9 @@ func (c *RESTClient) Verb(verb string) *Request {
 		timeout:    c.Timeout,
 		params:     map[string]string{},
 		pollPeriod: c.PollPeriod,
+
+		requestTimeout: c.RequestTimeout,
+		watchTimeout:   c.WatchTimeout,
 	}
 }
 
@@ -104,6 +109,10 @@ type Request struct {
 	timeout    time.Duration
 	sync       bool
 	pollPeriod time.Duration
+
+	// How long the client waits for a response; see RESTClient.
+	requestTimeout time.Duration
+	watchTimeout   time.Duration
 }
 
 // Path appends an item to the request path. You must call Path at least once.
@@ -156,6 +165,14 @@ func (r *Request) SelectorParam(paramName string, s labels.Selector) *Request {
 	return r.setParam(paramName, s.String())
 }
 
+// Param creates a query parameter with the given string value.
+func (r *Request) Param(paramName, s string) *Request {
+	if r.err != nil {
+		return r
+	}
+	return r.setParam(paramName, s)
+}
+
 // UintParam creates a query parameter with the given value.
 func (r *Request) UintParam(paramName string, u uint64) *Request {
 	if r.err != nil {
@@ -183,6 +200,18 @@ func (r *Request) Timeout(d time.Duration) *Request {
 	return r
 }
 
+// ClientTimeout makes the client give up on the request if it takes longer
+// than d, instead of after the RESTClient's RequestTimeout, or WatchTimeout
+// for a watch.  Unlike Timeout, the server isn't told.  Zero means no limit.
+func (r *Request) ClientTimeout(d time.Duration) *Request {
+	if r.err != nil {
+		return r
+	}
+	r.requestTimeout = d
+	r.watchTimeout = d
+	return r
+}
+
 // Body makes the request use obj as the body. Optional.
 // If obj is a string, try to read a file of that name.
 // If obj is a []byte, send it directly.
@@ -255,21 +284,76 @@ func (r *Request) Watch() (watch.Interface, error) {
 	if r.err != nil {
 		return nil, r.err
 	}
-	req, err := http.NewRequest(r.verb, r.finalURL(), r.body)
+	var response *http.Response
+	err := r.retry(func() error {
+		req, err := http.NewRequest(r.verb, r.finalURL(), r.body)
+		if err != nil {
+			return err
+		}
+		r.c.throttle()
+		r.c.setAuth(req)
+		if r.watchTimeout > 0 {
+			// Only starting the watch is timed; once the server has
+			// answered, the events may take as long as they like.
+			ctx, cancel := context.WithCancel(context.Background())
+			timer := time.AfterFunc(r.watchTimeout, cancel)
+			defer timer.Stop()
+			req = req.WithContext(ctx)
+		}
+		response, err = r.c.httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		if response.StatusCode != http.StatusOK {
+			response.Body.Close()
+			return &requestError{statusCode: response.StatusCode, message: fmt.Sprintf("Got status: %v", response.StatusCode)}
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	if r.c.auth != nil {
-		req.SetBasicAuth(r.c.auth.User, r.c.auth.Password)
-	}
-	response, err := r.c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
+	return watch.NewStreamWatcher(cwatch.NewAPIEventDecoder(response.Body)), nil
+}
+
+// requestError is returned for a response with an unexpected status code.
+type requestError struct {
+	statusCode int
+	message    string
+}
+
+func (e *requestError) Error() string {
+	return e.message
+}
+
+// isRetryable returns whether a request which failed with err may succeed if
+// it is sent again: the server couldn't be reached, or had an internal error.
+func isRetryable(err error) bool {
+	switch e := err.(type) {
+	case *url.Error:
+		return true
+	case *requestError:
+		return e.statusCode >= http.StatusInternalServerError
+	case *StatusErr:
+		return e.Status.Code >= http.StatusInternalServerError
 	}
-	if response.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("Got status: %v", response.StatusCode)
+	return false
+}
+
+// retry calls attempt until it succeeds, or fails with an error that isn't
+// retryable, or the client's retries run out.  Only GETs are retried, since
+// sending anything else twice may not have the same effect as sending it once.
+func (r *Request) retry(attempt func() error) error {
+	backoff := r.c.RetryBackoff
+	for retries := 0; ; retries++ {
+		err := attempt()
+		if err == nil || r.verb != "GET" || retries >= r.c.Retries || !isRetryable(err) {
+			return err
+		}
+		delay := wait.Jitter(backoff.Next(), 1.0)
+		glog.V(2).Infof("Retrying GET %s in %v: %v", r.path, delay, err)
+		time.Sleep(delay)
 	}
-	return watch.NewStreamWatcher(cwatch.NewAPIEventDecoder(response.Body)), nil
 }
 
 // Do formats and executes the request. Returns the API object received, or an error.
@@ -278,11 +362,21 @@ func (r *Request) Do() Result {
 		if r.err != nil {
 			return Result{err: r.err}
 		}
-		req, err := http.NewRequest(r.verb, r.finalURL(), r.body)
-		if err != nil {
-			return Result{err: err}
-		}
-		respBody, err := r.c.doRequest(req)
+		var respBody []byte
+		err := r.retry(func() error {
+			req, err := http.NewRequest(r.verb, r.finalURL(), r.body)
+			if err != nil {
+				return err
+			}
+			r.c.throttle()
+			if r.requestTimeout > 0 {
+				ctx, cancel := context.WithTimeout(context.Background(), r.requestTimeout)
+				defer cancel()
+				req = req.WithContext(ctx)
+			}
+			respBody, err = r.c.doRequest(req)
+			return err
+		})
 		if err != nil {
 			if statusErr, ok := err.(*StatusErr); ok {
 				if statusErr.Status.Status == api.StatusWorking && r.pollPeriod != 0 {
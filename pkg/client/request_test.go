@@ -38,7 +38,7 @@ import (
 
 func TestDoRequestNewWay(t *testing.T) {
 	reqBody := "request body"
-	expectedObj := &api.Service{Port: 12345}
+	expectedObj := &api.Service{Port: 12345, Protocol: api.ProtocolTCP, SessionAffinity: api.AffinityTypeNone}
 	expectedBody, _ := latest.Codec.Encode(expectedObj)
 	fakeHandler := util.FakeHandler{
 		StatusCode:   200,
@@ -73,7 +73,7 @@ func TestDoRequestNewWay(t *testing.T) {
 func TestDoRequestNewWayReader(t *testing.T) {
 	reqObj := &api.Pod{JSONBase: api.JSONBase{ID: "foo"}}
 	reqBodyExpected, _ := latest.Codec.Encode(reqObj)
-	expectedObj := &api.Service{Port: 12345}
+	expectedObj := &api.Service{Port: 12345, Protocol: api.ProtocolTCP, SessionAffinity: api.AffinityTypeNone}
 	expectedBody, _ := latest.Codec.Encode(expectedObj)
 	fakeHandler := util.FakeHandler{
 		StatusCode:   200,
@@ -110,7 +110,7 @@ func TestDoRequestNewWayReader(t *testing.T) {
 func TestDoRequestNewWayObj(t *testing.T) {
 	reqObj := &api.Pod{JSONBase: api.JSONBase{ID: "foo"}}
 	reqBodyExpected, _ := latest.Codec.Encode(reqObj)
-	expectedObj := &api.Service{Port: 12345}
+	expectedObj := &api.Service{Port: 12345, Protocol: api.ProtocolTCP, SessionAffinity: api.AffinityTypeNone}
 	expectedBody, _ := latest.Codec.Encode(expectedObj)
 	fakeHandler := util.FakeHandler{
 		StatusCode:   200,
@@ -160,7 +160,7 @@ func TestDoRequestNewWayFile(t *testing.T) {
 		t.Errorf("unexpected error: %v", err)
 	}
 
-	expectedObj := &api.Service{Port: 12345}
+	expectedObj := &api.Service{Port: 12345, Protocol: api.ProtocolTCP, SessionAffinity: api.AffinityTypeNone}
 	expectedBody, _ := latest.Codec.Encode(expectedObj)
 	fakeHandler := util.FakeHandler{
 		StatusCode:   200,
@@ -254,6 +254,51 @@ func TestUintParam(t *testing.T) {
 	}
 }
 
+func TestParam(t *testing.T) {
+	c := NewOrDie("localhost", nil)
+	r := c.Get().AbsPath("").Param("foo", "a b").Param("bar", "")
+	if e, a := "http://localhost?bar=&foo=a+b", r.finalURL(); e != a {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+	if r := c.Get().Param("sync", "true"); r.err == nil {
+		t.Errorf("expected an error setting sync directly")
+	}
+}
+
+func TestStatusErrors(t *testing.T) {
+	table := []struct {
+		statusCode int
+		status     api.Status
+		expected   api.Status
+	}{
+		{
+			http.StatusNotFound,
+			api.Status{Status: api.StatusFailure, Reason: api.StatusReasonNotFound},
+			api.Status{Status: api.StatusFailure, Reason: api.StatusReasonNotFound, Code: http.StatusNotFound},
+		},
+		{
+			http.StatusUnprocessableEntity,
+			api.Status{Status: api.StatusFailure, Message: "invalid", Code: http.StatusUnprocessableEntity},
+			api.Status{Status: api.StatusFailure, Message: "invalid", Code: http.StatusUnprocessableEntity},
+		},
+	}
+	for i, item := range table {
+		body, _ := latest.Codec.Encode(&item.status)
+		server := httptest.NewServer(&util.FakeHandler{StatusCode: item.statusCode, ResponseBody: string(body)})
+		c := NewOrDie(server.URL, nil)
+		err := c.Post().Path("pods").Do().Error()
+		server.Close()
+		se, ok := err.(*StatusErr)
+		if !ok {
+			t.Errorf("%d: expected a status error, got %#v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(item.expected, se.Status) {
+			t.Errorf("%d: expected %#v, got %#v", i, item.expected, se.Status)
+		}
+	}
+}
+
 func TestUnacceptableParamNames(t *testing.T) {
 	table := []struct {
 		name          string
@@ -379,13 +424,18 @@ func checkAuth(t *testing.T, expect AuthInfo, r *http.Request) {
 }
 
 func TestWatch(t *testing.T) {
+	alwaysRestart := api.PodState{
+		Manifest: api.ContainerManifest{
+			RestartPolicy: api.RestartPolicy{Always: &api.RestartPolicyAlways{}},
+		},
+	}
 	var table = []struct {
 		t   watch.EventType
 		obj runtime.Object
 	}{
-		{watch.Added, &api.Pod{JSONBase: api.JSONBase{ID: "first"}}},
-		{watch.Modified, &api.Pod{JSONBase: api.JSONBase{ID: "second"}}},
-		{watch.Deleted, &api.Pod{JSONBase: api.JSONBase{ID: "third"}}},
+		{watch.Added, &api.Pod{JSONBase: api.JSONBase{ID: "first"}, DesiredState: alwaysRestart, CurrentState: alwaysRestart}},
+		{watch.Modified, &api.Pod{JSONBase: api.JSONBase{ID: "second"}, DesiredState: alwaysRestart, CurrentState: alwaysRestart}},
+		{watch.Deleted, &api.Pod{JSONBase: api.JSONBase{ID: "third"}, DesiredState: alwaysRestart, CurrentState: alwaysRestart}},
 	}
 
 	auth := AuthInfo{User: "user", Password: "pass"}
@@ -441,3 +491,193 @@ func TestWatch(t *testing.T) {
 		t.Fatal("Unexpected non-close")
 	}
 }
+
+// failingHandler fails the first failures requests with statusCode, and
+// succeeds after that.
+type failingHandler struct {
+	failures   int
+	statusCode int
+	requests   int
+}
+
+func (h *failingHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	h.requests++
+	if h.requests <= h.failures {
+		w.WriteHeader(h.statusCode)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(runtime.EncodeOrDie(latest.Codec, &api.Pod{JSONBase: api.JSONBase{ID: "foo"}})))
+}
+
+func TestRetries(t *testing.T) {
+	table := []struct {
+		verb       string
+		failures   int
+		statusCode int
+		succeeds   bool
+		requests   int
+	}{
+		{"GET", 2, http.StatusInternalServerError, true, 3},
+		{"GET", 2, http.StatusServiceUnavailable, true, 3},
+		{"GET", 5, http.StatusInternalServerError, false, 4},
+		{"GET", 1, http.StatusNotFound, false, 1},
+		{"POST", 1, http.StatusInternalServerError, false, 1},
+		{"DELETE", 1, http.StatusInternalServerError, false, 1},
+	}
+	for i, item := range table {
+		handler := &failingHandler{failures: item.failures, statusCode: item.statusCode}
+		server := httptest.NewServer(handler)
+		c := NewOrDie(server.URL, nil)
+		c.RetryBackoff = Backoff{}
+		err := c.Verb(item.verb).Path("pods").Path("foo").Do().Error()
+		if item.succeeds != (err == nil) {
+			t.Errorf("%d: unexpected error: %v", i, err)
+		}
+		if handler.requests != item.requests {
+			t.Errorf("%d: expected %d requests, got %d", i, item.requests, handler.requests)
+		}
+		server.Close()
+	}
+}
+
+func TestRetryConnectionError(t *testing.T) {
+	server := httptest.NewServer(&failingHandler{})
+	server.Close()
+	c := NewOrDie(server.URL, nil)
+	c.Retries = 2
+	c.RetryBackoff = Backoff{Initial: 10 * time.Millisecond}
+	start := time.Now()
+	if err := c.Get().Path("pods").Do().Error(); err == nil {
+		t.Errorf("unexpected non-error")
+	}
+	// Two retries, at least 10ms and 20ms apart.
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("expected to back off between retries, took %v", elapsed)
+	}
+}
+
+func TestWatchRetries(t *testing.T) {
+	handler := &failingHandler{failures: 1, statusCode: http.StatusBadGateway}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	c := NewOrDie(server.URL, nil)
+	c.RetryBackoff = Backoff{}
+	w, err := c.Get().Path("watch").Path("pods").Watch()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	w.Stop()
+	if handler.requests != 2 {
+		t.Errorf("expected 2 requests, got %d", handler.requests)
+	}
+
+	c.Retries = 0
+	handler.requests = 0
+	if _, err := c.Get().Path("watch").Path("pods").Watch(); err == nil {
+		t.Errorf("unexpected non-error")
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		<-done
+	}))
+	defer server.Close()
+	defer close(done)
+	c := NewOrDie(server.URL, nil)
+	c.Retries = 0
+	c.RequestTimeout = 10 * time.Millisecond
+	if err := c.Get().Path("pods").Do().Error(); err == nil {
+		t.Errorf("unexpected non-error")
+	}
+
+	// A request's own timeout overrides the client's.
+	c.RequestTimeout = time.Minute
+	if err := c.Post().Path("pods").ClientTimeout(10 * time.Millisecond).Do().Error(); err == nil {
+		t.Errorf("unexpected non-error")
+	}
+}
+
+func TestWatchTimeout(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if strings.HasSuffix(req.URL.Path, "/hang") {
+			<-done
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		time.Sleep(50 * time.Millisecond)
+		data, err := api.NewJSONWatchEvent(latest.Codec, watch.Event{Type: watch.Added, Object: &api.Pod{JSONBase: api.JSONBase{ID: "foo"}}})
+		if err != nil {
+			panic(err)
+		}
+		json.NewEncoder(w).Encode(data)
+	}))
+	defer server.Close()
+	defer close(done)
+	c := NewOrDie(server.URL, nil)
+	c.Retries = 0
+	c.WatchTimeout = 10 * time.Millisecond
+	if _, err := c.Get().Path("watch").Path("hang").Watch(); err == nil {
+		t.Errorf("unexpected non-error")
+	}
+
+	// Events may arrive after the timeout, once the watch has started.
+	w, err := c.Get().Path("watch").Path("pods").Watch()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer w.Stop()
+	if event, ok := <-w.ResultChan(); !ok || event.Type != watch.Added {
+		t.Errorf("expected an added event, got %#v", event)
+	}
+}
+
+type fakeRateLimiter struct {
+	accept  bool
+	accepts int
+}
+
+func (f *fakeRateLimiter) CanAccept() bool {
+	return f.accept
+}
+
+func (f *fakeRateLimiter) Accept() {
+	f.accepts++
+}
+
+func TestThrottle(t *testing.T) {
+	server := httptest.NewServer(&util.FakeHandler{StatusCode: 200, ResponseBody: "{}"})
+	defer server.Close()
+	c := NewOrDie(server.URL, nil)
+
+	limiter := &fakeRateLimiter{accept: true}
+	c.Throttle = limiter
+	if _, err := c.Get().Path("pods").Do().Raw(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if limiter.accepts != 0 {
+		t.Errorf("expected no wait, got %d", limiter.accepts)
+	}
+
+	limiter.accept = false
+	count := func() string {
+		if v := throttledRequests.Get("requests"); v != nil {
+			return v.String()
+		}
+		return "0"
+	}
+	throttled := count()
+	if _, err := c.Get().Path("pods").Do().Raw(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if limiter.accepts != 1 {
+		t.Errorf("expected one wait, got %d", limiter.accepts)
+	}
+	if count() == throttled {
+		t.Errorf("expected the throttled request to be counted")
+	}
+}
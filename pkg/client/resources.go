@@ -0,0 +1,218 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
+)
+
+// ResourcesInterface gives a client for each kind of resource, so that
+// callers can depend on just the resources they use.
+type ResourcesInterface interface {
+	Pods() PodsClient
+	ReplicationControllers() ReplicationControllersClient
+	Services() ServicesClient
+	Minions() MinionsClient
+}
+
+// PodsClient has methods to work with Pod resources.
+type PodsClient interface {
+	List(selector labels.Selector) (*api.PodList, error)
+	Get(id string) (*api.Pod, error)
+	Create(pod *api.Pod) (*api.Pod, error)
+	Update(pod *api.Pod) (*api.Pod, error)
+	Delete(id string) error
+	Watch(label, field labels.Selector, resourceVersion uint64) (watch.Interface, error)
+}
+
+// ReplicationControllersClient has methods to work with ReplicationController resources.
+type ReplicationControllersClient interface {
+	List(selector labels.Selector) (*api.ReplicationControllerList, error)
+	Get(id string) (*api.ReplicationController, error)
+	Create(controller *api.ReplicationController) (*api.ReplicationController, error)
+	Update(controller *api.ReplicationController) (*api.ReplicationController, error)
+	Delete(id string) error
+	Watch(label, field labels.Selector, resourceVersion uint64) (watch.Interface, error)
+}
+
+// ServicesClient has methods to work with Service resources.
+type ServicesClient interface {
+	List(selector labels.Selector) (*api.ServiceList, error)
+	Get(id string) (*api.Service, error)
+	Create(service *api.Service) (*api.Service, error)
+	Update(service *api.Service) (*api.Service, error)
+	Delete(id string) error
+	Watch(label, field labels.Selector, resourceVersion uint64) (watch.Interface, error)
+}
+
+// MinionsClient has methods to work with Minion resources.  Minions can't be
+// watched.
+type MinionsClient interface {
+	List(selector labels.Selector) (*api.MinionList, error)
+	Get(id string) (*api.Minion, error)
+	Create(minion *api.Minion) (*api.Minion, error)
+	Update(minion *api.Minion) (*api.Minion, error)
+	Delete(id string) error
+}
+
+// Pods returns a client for pods.
+func (c *Client) Pods() PodsClient {
+	return pods{c}
+}
+
+// ReplicationControllers returns a client for replication controllers.
+func (c *Client) ReplicationControllers() ReplicationControllersClient {
+	return replicationControllers{c}
+}
+
+// Services returns a client for services.
+func (c *Client) Services() ServicesClient {
+	return services{c}
+}
+
+// Minions returns a client for minions.
+func (c *Client) Minions() MinionsClient {
+	return minions{c}
+}
+
+// pods implements PodsClient.
+type pods struct {
+	c *Client
+}
+
+func (p pods) List(selector labels.Selector) (*api.PodList, error) {
+	return p.c.ListPods(selector)
+}
+
+func (p pods) Get(id string) (*api.Pod, error) {
+	return p.c.GetPod(id)
+}
+
+func (p pods) Create(pod *api.Pod) (*api.Pod, error) {
+	return p.c.CreatePod(pod)
+}
+
+func (p pods) Update(pod *api.Pod) (*api.Pod, error) {
+	return p.c.UpdatePod(pod)
+}
+
+func (p pods) Delete(id string) error {
+	return p.c.DeletePod(id)
+}
+
+func (p pods) Watch(label, field labels.Selector, resourceVersion uint64) (watch.Interface, error) {
+	return p.c.Get().
+		Path("watch").
+		Path("pods").
+		UintParam("resourceVersion", resourceVersion).
+		SelectorParam("labels", label).
+		SelectorParam("fields", field).
+		Watch()
+}
+
+// replicationControllers implements ReplicationControllersClient.
+type replicationControllers struct {
+	c *Client
+}
+
+func (r replicationControllers) List(selector labels.Selector) (*api.ReplicationControllerList, error) {
+	return r.c.ListReplicationControllers(selector)
+}
+
+func (r replicationControllers) Get(id string) (*api.ReplicationController, error) {
+	return r.c.GetReplicationController(id)
+}
+
+func (r replicationControllers) Create(controller *api.ReplicationController) (*api.ReplicationController, error) {
+	return r.c.CreateReplicationController(controller)
+}
+
+func (r replicationControllers) Update(controller *api.ReplicationController) (*api.ReplicationController, error) {
+	return r.c.UpdateReplicationController(controller)
+}
+
+func (r replicationControllers) Delete(id string) error {
+	return r.c.DeleteReplicationController(id)
+}
+
+func (r replicationControllers) Watch(label, field labels.Selector, resourceVersion uint64) (watch.Interface, error) {
+	return r.c.WatchReplicationControllers(label, field, resourceVersion)
+}
+
+// services implements ServicesClient.
+type services struct {
+	c *Client
+}
+
+func (s services) List(selector labels.Selector) (*api.ServiceList, error) {
+	return s.c.ListServices(selector)
+}
+
+func (s services) Get(id string) (*api.Service, error) {
+	return s.c.GetService(id)
+}
+
+func (s services) Create(service *api.Service) (*api.Service, error) {
+	return s.c.CreateService(service)
+}
+
+func (s services) Update(service *api.Service) (*api.Service, error) {
+	return s.c.UpdateService(service)
+}
+
+func (s services) Delete(id string) error {
+	return s.c.DeleteService(id)
+}
+
+func (s services) Watch(label, field labels.Selector, resourceVersion uint64) (watch.Interface, error) {
+	return s.c.WatchServices(label, field, resourceVersion)
+}
+
+// minions implements MinionsClient.
+type minions struct {
+	c *Client
+}
+
+func (m minions) List(selector labels.Selector) (result *api.MinionList, err error) {
+	result = &api.MinionList{}
+	err = m.c.Get().Path("minions").SelectorParam("labels", selector).Do().Into(result)
+	return
+}
+
+func (m minions) Get(id string) (result *api.Minion, err error) {
+	result = &api.Minion{}
+	err = m.c.Get().Path("minions").Path(id).Do().Into(result)
+	return
+}
+
+func (m minions) Create(minion *api.Minion) (result *api.Minion, err error) {
+	result = &api.Minion{}
+	err = m.c.Post().Path("minions").Body(minion).Do().Into(result)
+	return
+}
+
+func (m minions) Update(minion *api.Minion) (result *api.Minion, err error) {
+	result = &api.Minion{}
+	err = m.c.Put().Path("minions").Path(minion.ID).Body(minion).Do().Into(result)
+	return
+}
+
+func (m minions) Delete(id string) error {
+	return m.c.Delete().Path("minions").Path(id).Do().Error()
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+)
+
+func TestPodsClientList(t *testing.T) {
+	c := &testClient{
+		Request:  testRequest{Method: "GET", Path: "/pods", Query: url.Values{"labels": []string{"name=foo"}}},
+		Response: Response{StatusCode: 200, Body: &api.PodList{Items: []api.Pod{{JSONBase: api.JSONBase{ID: "foo"}}}}},
+	}
+	received, err := c.Setup().Pods().List(labels.Set{"name": "foo"}.AsSelector())
+	c.Validate(t, received, err)
+}
+
+func TestPodsClientWatch(t *testing.T) {
+	c := &testClient{
+		Request: testRequest{
+			Method: "GET",
+			Path:   "/watch/pods",
+			Query:  url.Values{"resourceVersion": []string{"42"}, "labels": []string{"name=foo"}},
+		},
+		Response: Response{StatusCode: 200},
+	}
+	w, err := c.Setup().Pods().Watch(labels.Set{"name": "foo"}.AsSelector(), labels.Everything(), 42)
+	if err == nil {
+		w.Stop()
+	}
+	c.Validate(t, nil, err)
+}
+
+func TestMinionsClientList(t *testing.T) {
+	c := &testClient{
+		Request:  testRequest{Method: "GET", Path: "/minions", Query: url.Values{"labels": []string{"region=a"}}},
+		Response: Response{StatusCode: 200, Body: &api.MinionList{Items: []api.Minion{{JSONBase: api.JSONBase{ID: "m1"}}}}},
+	}
+	received, err := c.Setup().Minions().List(labels.Set{"region": "a"}.AsSelector())
+	c.Validate(t, received, err)
+}
+
+func TestMinionsClientGet(t *testing.T) {
+	c := &testClient{
+		Request:  testRequest{Method: "GET", Path: "/minions/m1"},
+		Response: Response{StatusCode: 200, Body: &api.Minion{JSONBase: api.JSONBase{ID: "m1"}}},
+	}
+	received, err := c.Setup().Minions().Get("m1")
+	c.Validate(t, received, err)
+}
+
+func TestMinionsClientCreate(t *testing.T) {
+	minion := &api.Minion{JSONBase: api.JSONBase{ID: "m1"}, PodCIDR: "10.244.1.0/24"}
+	c := &testClient{
+		Request:  testRequest{Method: "POST", Path: "/minions", Body: minion},
+		Response: Response{StatusCode: 200, Body: minion},
+	}
+	received, err := c.Setup().Minions().Create(minion)
+	c.Validate(t, received, err)
+}
+
+func TestMinionsClientUpdate(t *testing.T) {
+	minion := &api.Minion{JSONBase: api.JSONBase{ID: "m1"}, Labels: map[string]string{"disk": "ssd"}}
+	c := &testClient{
+		Request:  testRequest{Method: "PUT", Path: "/minions/m1", Body: minion},
+		Response: Response{StatusCode: 200, Body: minion},
+	}
+	received, err := c.Setup().Minions().Update(minion)
+	c.Validate(t, received, err)
+}
+
+func TestMinionsClientDelete(t *testing.T) {
+	c := &testClient{
+		Request:  testRequest{Method: "DELETE", Path: "/minions/m1"},
+		Response: Response{StatusCode: 200},
+	}
+	err := c.Setup().Minions().Delete("m1")
+	c.Validate(t, nil, err)
+}
@@ -0,0 +1,243 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// sshCommand is the command run to start a tunnel; overridable for testing.
+var sshCommand = []string{"ssh"}
+
+// How long to wait for a new tunnel to accept connections.
+const sshTunnelTimeout = 10 * time.Second
+
+// SSHTunneler makes TCP connections through SSH port forwards, for masters
+// that cannot reach minions directly.  Tunnels are started with the ssh
+// binary on first use and kept open; one whose ssh process exits is
+// started again on the next connection.  Host keys are checked against
+// KnownHostsFile, or ssh's default known_hosts files if it is empty.
+type SSHTunneler struct {
+	User           string
+	KeyFile        string
+	KnownHostsFile string
+	// Gateway, if set, is the host every connection is forwarded through,
+	// which lets connections reach pods as well as minions.  Otherwise each
+	// connection is forwarded through the host it is to.
+	Gateway string
+
+	lock sync.Mutex
+	// Maps a destination address to the tunnel that forwards to it, including
+	// tunnels still being started.
+	tunnels map[string]*sshTunnel
+}
+
+type sshTunnel struct {
+	// The local address that is forwarded.
+	local string
+	cmd   *exec.Cmd
+	// Closed once the tunnel is started or has failed to start, after which
+	// cmd and err are set.
+	ready chan struct{}
+	err   error
+	// Closed when the ssh process exits.
+	done chan struct{}
+}
+
+func (t *sshTunnel) started() bool {
+	select {
+	case <-t.ready:
+		return true
+	default:
+		return false
+	}
+}
+
+func (t *sshTunnel) alive() bool {
+	select {
+	case <-t.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// NewSSHTunneler creates an SSHTunneler that logs in as user with the
+// private key in keyFile.  knownHostsFile and gateway may be empty.
+func NewSSHTunneler(user, keyFile, knownHostsFile, gateway string) *SSHTunneler {
+	return &SSHTunneler{
+		User:           user,
+		KeyFile:        keyFile,
+		KnownHostsFile: knownHostsFile,
+		Gateway:        gateway,
+		tunnels:        map[string]*sshTunnel{},
+	}
+}
+
+// Dial connects to addr through a tunnel.  It has the signature of
+// http.Transport's Dial.
+func (s *SSHTunneler) Dial(network, addr string) (net.Conn, error) {
+	tunnel, err := s.tunnelTo(addr)
+	if err != nil {
+		return nil, err
+	}
+	return net.Dial(network, tunnel.local)
+}
+
+// Transport returns an http.Transport whose connections go through s.
+func (s *SSHTunneler) Transport() *http.Transport {
+	return &http.Transport{Dial: s.Dial}
+}
+
+// Close stops every tunnel.  Tunnels still being started are stopped once
+// they have.
+func (s *SSHTunneler) Close() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for addr, tunnel := range s.tunnels {
+		if tunnel.started() && tunnel.err == nil {
+			tunnel.cmd.Process.Kill()
+		}
+		delete(s.tunnels, addr)
+	}
+}
+
+// tunnelTo returns a tunnel to addr, starting one if there is none.  The
+// lock is only held to look up and record tunnels, so that starting a tunnel
+// to one address doesn't hold up connections to others; connections to an
+// address whose tunnel is being started wait for it.
+func (s *SSHTunneler) tunnelTo(addr string) (*sshTunnel, error) {
+	s.lock.Lock()
+	tunnel, ok := s.tunnels[addr]
+	if ok && (!tunnel.started() || tunnel.alive()) {
+		s.lock.Unlock()
+		<-tunnel.ready
+		if tunnel.err != nil {
+			return nil, tunnel.err
+		}
+		return tunnel, nil
+	}
+	tunnel = &sshTunnel{
+		ready: make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	s.tunnels[addr] = tunnel
+	s.lock.Unlock()
+
+	err := s.startTunnel(addr, tunnel)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.tunnels[addr] != tunnel {
+		// Closed while starting.
+		if err == nil {
+			tunnel.cmd.Process.Kill()
+			err = fmt.Errorf("tunnel to %s was closed", addr)
+		}
+	} else if err != nil {
+		delete(s.tunnels, addr)
+	}
+	tunnel.err = err
+	close(tunnel.ready)
+	if err != nil {
+		return nil, err
+	}
+	return tunnel, nil
+}
+
+// startTunnel starts the ssh process of tunnel and waits for it to forward
+// to addr.
+func (s *SSHTunneler) startTunnel(addr string, tunnel *sshTunnel) error {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	through := s.Gateway
+	if through == "" {
+		through = host
+	}
+	localPort, err := unusedLocalPort()
+	if err != nil {
+		return err
+	}
+	tunnel.local = net.JoinHostPort("127.0.0.1", strconv.Itoa(localPort))
+	args := append([]string{}, sshCommand[1:]...)
+	args = append(args,
+		"-i", s.KeyFile,
+		"-N",
+		"-o", "BatchMode=yes",
+		"-o", "ExitOnForwardFailure=yes",
+		"-o", "StrictHostKeyChecking=yes")
+	if s.KnownHostsFile != "" {
+		args = append(args, "-o", "UserKnownHostsFile="+s.KnownHostsFile)
+	}
+	args = append(args,
+		"-L", fmt.Sprintf("%s:%s:%s", tunnel.local, host, port),
+		s.User+"@"+through)
+	tunnel.cmd = exec.Command(sshCommand[0], args...)
+	if err := tunnel.cmd.Start(); err != nil {
+		return err
+	}
+	go func() {
+		err := tunnel.cmd.Wait()
+		glog.Infof("SSH tunnel to %s through %s exited: %v", addr, through, err)
+		close(tunnel.done)
+	}()
+	if err := waitForTunnel(tunnel); err != nil {
+		tunnel.cmd.Process.Kill()
+		return fmt.Errorf("couldn't tunnel to %s through %s: %v", addr, through, err)
+	}
+	glog.Infof("Tunneling to %s through %s from %s", addr, through, tunnel.local)
+	return nil
+}
+
+// waitForTunnel waits until tunnel accepts connections.
+func waitForTunnel(tunnel *sshTunnel) error {
+	deadline := time.Now().Add(sshTunnelTimeout)
+	for {
+		conn, err := net.Dial("tcp", tunnel.local)
+		if err == nil {
+			conn.Close()
+			return nil
+		}
+		if !tunnel.alive() {
+			return fmt.Errorf("ssh exited")
+		}
+		if time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// unusedLocalPort returns a port that nothing is listening on locally.
+func unusedLocalPort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
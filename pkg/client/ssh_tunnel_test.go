@@ -0,0 +1,239 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestSSHHelperProcess isn't a real test; it stands in for ssh, forwarding
+// the -L address it is given.
+func TestSSHHelperProcess(t *testing.T) {
+	if os.Getenv("KUBE_WANT_SSH_HELPER") != "1" {
+		return
+	}
+	args := os.Args
+	for len(args) > 0 && args[0] != "--" {
+		args = args[1:]
+	}
+	var forward []string
+	for i := range args {
+		if args[i] == "-L" {
+			forward = strings.Split(args[i+1], ":")
+		}
+	}
+	f, err := os.OpenFile(os.Getenv("KUBE_SSH_HELPER_ARGS"), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		os.Exit(1)
+	}
+	if _, err := f.WriteString(strings.Join(args[1:], " ") + "\n"); err != nil {
+		os.Exit(1)
+	}
+	f.Close()
+	l, err := net.Listen("tcp", net.JoinHostPort(forward[0], forward[1]))
+	if err != nil {
+		os.Exit(1)
+	}
+	for {
+		in, err := l.Accept()
+		if err != nil {
+			os.Exit(1)
+		}
+		go func() {
+			out, err := net.Dial("tcp", net.JoinHostPort(forward[2], forward[3]))
+			if err != nil {
+				in.Close()
+				return
+			}
+			go io.Copy(out, in)
+			io.Copy(in, out)
+			in.Close()
+			out.Close()
+		}()
+	}
+}
+
+func useSSHHelper(t *testing.T) (argsFile string, restore func()) {
+	f, err := ioutil.TempFile("", "ssh_args")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	f.Close()
+	os.Setenv("KUBE_WANT_SSH_HELPER", "1")
+	os.Setenv("KUBE_SSH_HELPER_ARGS", f.Name())
+	old := sshCommand
+	sshCommand = []string{os.Args[0], "-test.run=TestSSHHelperProcess", "--"}
+	return f.Name(), func() {
+		sshCommand = old
+		os.Unsetenv("KUBE_WANT_SSH_HELPER")
+		os.Remove(f.Name())
+	}
+}
+
+func TestSSHTunnelerTransport(t *testing.T) {
+	argsFile, restore := useSSHHelper(t)
+	defer restore()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("hello"))
+	}))
+	defer server.Close()
+	addr := strings.TrimPrefix(server.URL, "http://")
+	host, port, _ := net.SplitHostPort(addr)
+
+	tunneler := NewSSHTunneler("kube", "/etc/kube/id_rsa", "/etc/kube/known_hosts", "")
+	defer tunneler.Close()
+	client := &http.Client{Transport: tunneler.Transport()}
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != "hello" {
+			t.Errorf("Unexpected body: %s", body)
+		}
+	}
+	if len(tunneler.tunnels) != 1 {
+		t.Errorf("Expected one tunnel, got %v", tunneler.tunnels)
+	}
+
+	data, err := ioutil.ReadFile(argsFile)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	args := strings.Split(strings.TrimSuffix(string(data), "\n"), " ")
+	expected := []string{
+		"-i", "/etc/kube/id_rsa",
+		"-N",
+		"-o", "BatchMode=yes",
+		"-o", "ExitOnForwardFailure=yes",
+		"-o", "StrictHostKeyChecking=yes",
+		"-o", "UserKnownHostsFile=/etc/kube/known_hosts",
+		"-L", tunneler.tunnels[addr].local + ":" + host + ":" + port,
+		"kube@" + host,
+	}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("Expected ssh args %v, got %v", expected, args)
+	}
+}
+
+func TestSSHTunnelerRestartsExitedTunnels(t *testing.T) {
+	_, restore := useSSHHelper(t)
+	defer restore()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	defer server.Close()
+	addr := strings.TrimPrefix(server.URL, "http://")
+
+	tunneler := NewSSHTunneler("kube", "id_rsa", "", "gateway")
+	defer tunneler.Close()
+	conn, err := tunneler.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	conn.Close()
+	first := tunneler.tunnels[addr]
+	first.cmd.Process.Kill()
+	<-first.done
+
+	conn, err = tunneler.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	conn.Close()
+	if tunneler.tunnels[addr] == first {
+		t.Errorf("Expected a new tunnel")
+	}
+}
+
+func TestSSHTunnelerFailedTunnel(t *testing.T) {
+	old := sshCommand
+	sshCommand = []string{"false"}
+	defer func() { sshCommand = old }()
+
+	tunneler := NewSSHTunneler("kube", "id_rsa", "", "")
+	if _, err := tunneler.Dial("tcp", "10.0.0.1:10250"); err == nil {
+		t.Errorf("Expected an error")
+	}
+	if len(tunneler.tunnels) != 0 {
+		t.Errorf("Unexpected tunnels: %v", tunneler.tunnels)
+	}
+}
+
+func TestSSHTunnelerStartsOneTunnelPerAddress(t *testing.T) {
+	argsFile, restore := useSSHHelper(t)
+	defer restore()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	defer server.Close()
+	addr := strings.TrimPrefix(server.URL, "http://")
+
+	tunneler := NewSSHTunneler("kube", "id_rsa", "", "")
+	defer tunneler.Close()
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			conn, err := tunneler.Dial("tcp", addr)
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+				return
+			}
+			conn.Close()
+		}()
+	}
+	wg.Wait()
+
+	data, err := ioutil.ReadFile(argsFile)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if n := strings.Count(string(data), "\n"); n != 1 {
+		t.Errorf("Expected one ssh process, got %d", n)
+	}
+}
+
+func TestSSHTunnelerDoesNotWaitForOtherAddresses(t *testing.T) {
+	_, restore := useSSHHelper(t)
+	defer restore()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	defer server.Close()
+	addr := strings.TrimPrefix(server.URL, "http://")
+
+	tunneler := NewSSHTunneler("kube", "id_rsa", "", "")
+	defer tunneler.Close()
+	// A tunnel that never finishes starting.
+	tunneler.tunnels["10.0.0.1:10250"] = &sshTunnel{
+		ready: make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+
+	conn, err := tunneler.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	conn.Close()
+}
@@ -52,7 +52,7 @@ func (d *APIEventDecoder) Decode() (action watch.EventType, object runtime.Objec
 		return action, nil, err
 	}
 	switch got.Type {
-	case watch.Added, watch.Modified, watch.Deleted:
+	case watch.Added, watch.Modified, watch.Deleted, watch.Error:
 		return got.Type, got.Object.Object, err
 	}
 	return action, nil, fmt.Errorf("got invalid watch event type: %v", got.Type)
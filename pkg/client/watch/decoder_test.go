@@ -37,7 +37,12 @@ func TestDecoder(t *testing.T) {
 	out, in := io.Pipe()
 	decoder := NewAPIEventDecoder(out)
 
-	expect := &api.Pod{JSONBase: api.JSONBase{ID: "foo"}}
+	alwaysRestart := api.PodState{
+		Manifest: api.ContainerManifest{
+			RestartPolicy: api.RestartPolicy{Always: &api.RestartPolicyAlways{}},
+		},
+	}
+	expect := &api.Pod{JSONBase: api.JSONBase{ID: "foo"}, DesiredState: alwaysRestart, CurrentState: alwaysRestart}
 	encoder := json.NewEncoder(in)
 	go func() {
 		data, err := v1beta1.Codec.Encode(expect)
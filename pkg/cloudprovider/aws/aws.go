@@ -26,6 +26,7 @@ import (
 	"github.com/mitchellh/goamz/aws"
 	"github.com/mitchellh/goamz/ec2"
 
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/cloudprovider"
 )
 
@@ -33,15 +34,23 @@ type EC2 interface {
 	Instances(instIds []string, filter *ec2.Filter) (resp *ec2.InstancesResp, err error)
 }
 
-// AWSCloud is an implementation of Interface, TCPLoadBalancer and Instances for Amazon Web Services.
+// AWSCloud is an implementation of Interface, TCPLoadBalancer, Instances and Zones for Amazon Web Services.
 type AWSCloud struct {
 	ec2 EC2
+	elb ELB
 	cfg *AWSCloudConfig
+	// metadata reads the EC2 instance metadata at a path.
+	metadata func(path string) ([]byte, error)
+	// lookupIP resolves the DNS names of load balancers.
+	lookupIP func(host string) ([]net.IP, error)
 }
 
 type AWSCloudConfig struct {
 	Global struct {
 		Region string
+		// Zone is the availability zone the cluster runs in. If empty, it is
+		// read from the metadata of the instance we are running on.
+		Zone string
 	}
 }
 
@@ -93,16 +102,18 @@ func newAWSCloud(config io.Reader, authFunc AuthFunc) (*AWSCloud, error) {
 		return nil, fmt.Errorf("Not a valid AWS region: %s", cfg.Global.Region)
 	}
 
-	ec2 := ec2.New(auth, region)
 	return &AWSCloud{
-		ec2: ec2,
-		cfg: cfg,
+		ec2:      ec2.New(auth, region),
+		elb:      newELBClient(auth, region),
+		cfg:      cfg,
+		metadata: aws.GetMetaData,
+		lookupIP: net.LookupIP,
 	}, nil
 }
 
 // TCPLoadBalancer returns an implementation of TCPLoadBalancer for Amazon Web Services.
 func (aws *AWSCloud) TCPLoadBalancer() (cloudprovider.TCPLoadBalancer, bool) {
-	return nil, false
+	return aws, true
 }
 
 // Instances returns an implementation of Instances for Amazon Web Services.
@@ -112,6 +123,16 @@ func (aws *AWSCloud) Instances() (cloudprovider.Instances, bool) {
 
 // Zones returns an implementation of Zones for Amazon Web Services.
 func (aws *AWSCloud) Zones() (cloudprovider.Zones, bool) {
+	return aws, true
+}
+
+// InstanceGroups returns an implementation of InstanceGroups for Amazon Web Services.
+func (aws *AWSCloud) InstanceGroups() (cloudprovider.InstanceGroups, bool) {
+	return nil, false
+}
+
+// Routes returns an implementation of Routes for Amazon Web Services.
+func (aws *AWSCloud) Routes() (cloudprovider.Routes, bool) {
 	return nil, false
 }
 
@@ -179,3 +200,193 @@ func (aws *AWSCloud) List(filter string) ([]string, error) {
 	// TODO: Should really use tag query. No need to go regexp.
 	return aws.getInstancesByRegex(filter)
 }
+
+// GetNodeResources implements Instances.GetNodeResources
+func (aws *AWSCloud) GetNodeResources(name string) (*api.NodeResources, error) {
+	return nil, nil
+}
+
+// GetZone is an implementation of Zones.GetZone.
+func (aws *AWSCloud) GetZone() (cloudprovider.Zone, error) {
+	zone := aws.cfg.Global.Zone
+	if zone == "" {
+		data, err := aws.metadata("placement/availability-zone")
+		if err != nil {
+			return cloudprovider.Zone{}, err
+		}
+		zone = string(data)
+	}
+	return cloudprovider.Zone{
+		FailureDomain: zone,
+		Region:        aws.cfg.Global.Region,
+	}, nil
+}
+
+// GetInstanceZone is an implementation of Zones.GetInstanceZone.
+func (aws *AWSCloud) GetInstanceZone(name string) (cloudprovider.Zone, error) {
+	instances, err := aws.getInstancesByDNSName([]string{name})
+	if err != nil {
+		return cloudprovider.Zone{}, err
+	}
+	return cloudprovider.Zone{
+		FailureDomain: instances[0].AvailZone,
+		Region:        aws.cfg.Global.Region,
+	}, nil
+}
+
+// checkRegion returns an error unless region is the one we were configured with,
+// since our ELB client only talks to that region.
+func (aws *AWSCloud) checkRegion(region string) error {
+	if region != aws.cfg.Global.Region {
+		return fmt.Errorf("Region %s is not the configured region %s", region, aws.cfg.Global.Region)
+	}
+	return nil
+}
+
+// getInstancesByDNSName returns the instances with the given private DNS names.
+func (aws *AWSCloud) getInstancesByDNSName(names []string) ([]ec2.Instance, error) {
+	if len(names) == 0 {
+		return []ec2.Instance{}, nil
+	}
+	f := ec2.NewFilter()
+	f.Add("private-dns-name", names...)
+	resp, err := aws.ec2.Instances(nil, f)
+	if err != nil {
+		return nil, err
+	}
+	found := map[string]ec2.Instance{}
+	for _, reservation := range resp.Reservations {
+		for _, instance := range reservation.Instances {
+			found[instance.PrivateDNSName] = instance
+		}
+	}
+	instances := []ec2.Instance{}
+	for _, name := range names {
+		instance, ok := found[name]
+		if !ok {
+			return nil, fmt.Errorf("No instance found for host: %s", name)
+		}
+		instances = append(instances, instance)
+	}
+	return instances, nil
+}
+
+// TCPLoadBalancerExists is an implementation of TCPLoadBalancer.TCPLoadBalancerExists.
+func (aws *AWSCloud) TCPLoadBalancerExists(name, region string) (bool, error) {
+	if err := aws.checkRegion(region); err != nil {
+		return false, err
+	}
+	lb, err := aws.elb.DescribeLoadBalancer(name)
+	if err != nil {
+		return false, err
+	}
+	return lb != nil, nil
+}
+
+// CreateTCPLoadBalancer is an implementation of TCPLoadBalancer.CreateTCPLoadBalancer.
+// The load balancer spans the availability zones of hosts.
+func (aws *AWSCloud) CreateTCPLoadBalancer(name, region string, port int, hosts []string) error {
+	if err := aws.checkRegion(region); err != nil {
+		return err
+	}
+	instances, err := aws.getInstancesByDNSName(hosts)
+	if err != nil {
+		return err
+	}
+	zones := []string{}
+	seen := map[string]bool{}
+	instanceIds := []string{}
+	for _, instance := range instances {
+		if !seen[instance.AvailZone] {
+			seen[instance.AvailZone] = true
+			zones = append(zones, instance.AvailZone)
+		}
+		instanceIds = append(instanceIds, instance.InstanceId)
+	}
+	if _, err := aws.elb.CreateLoadBalancer(name, port, zones); err != nil {
+		return err
+	}
+	if len(instanceIds) == 0 {
+		return nil
+	}
+	return aws.elb.RegisterInstances(name, instanceIds)
+}
+
+// UpdateTCPLoadBalancer is an implementation of TCPLoadBalancer.UpdateTCPLoadBalancer.
+func (aws *AWSCloud) UpdateTCPLoadBalancer(name, region string, hosts []string) error {
+	if err := aws.checkRegion(region); err != nil {
+		return err
+	}
+	lb, err := aws.elb.DescribeLoadBalancer(name)
+	if err != nil {
+		return err
+	}
+	if lb == nil {
+		return fmt.Errorf("No load balancer named %s", name)
+	}
+	instances, err := aws.getInstancesByDNSName(hosts)
+	if err != nil {
+		return err
+	}
+	wanted := map[string]bool{}
+	for _, instance := range instances {
+		wanted[instance.InstanceId] = true
+	}
+	registered := map[string]bool{}
+	toDeregister := []string{}
+	for _, id := range lb.InstanceIds {
+		registered[id] = true
+		if !wanted[id] {
+			toDeregister = append(toDeregister, id)
+		}
+	}
+	toRegister := []string{}
+	for _, instance := range instances {
+		if !registered[instance.InstanceId] {
+			toRegister = append(toRegister, instance.InstanceId)
+		}
+	}
+	if len(toRegister) > 0 {
+		if err := aws.elb.RegisterInstances(name, toRegister); err != nil {
+			return err
+		}
+	}
+	if len(toDeregister) > 0 {
+		return aws.elb.DeregisterInstances(name, toDeregister)
+	}
+	return nil
+}
+
+// DeleteTCPLoadBalancer is an implementation of TCPLoadBalancer.DeleteTCPLoadBalancer.
+func (aws *AWSCloud) DeleteTCPLoadBalancer(name, region string) error {
+	if err := aws.checkRegion(region); err != nil {
+		return err
+	}
+	return aws.elb.DeleteLoadBalancer(name)
+}
+
+// TCPLoadBalancerIP is an implementation of TCPLoadBalancer.TCPLoadBalancerIP.
+// Elastic load balancers are addressed by DNS name, and the addresses behind
+// it may change, so this returns the one it currently resolves to.
+func (aws *AWSCloud) TCPLoadBalancerIP(name, region string) (net.IP, error) {
+	if err := aws.checkRegion(region); err != nil {
+		return nil, err
+	}
+	lb, err := aws.elb.DescribeLoadBalancer(name)
+	if err != nil {
+		return nil, err
+	}
+	if lb == nil {
+		return nil, fmt.Errorf("No load balancer named %s", name)
+	}
+	ips, err := aws.lookupIP(lb.DNSName)
+	if err != nil {
+		return nil, err
+	}
+	for _, ip := range ips {
+		if ip.To4() != nil {
+			return ip, nil
+		}
+	}
+	return nil, fmt.Errorf("No IPv4 address for load balancer %s (%s)", name, lb.DNSName)
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package aws_cloud
 
 import (
+	"fmt"
+	"net"
 	"reflect"
 	"strings"
 	"testing"
@@ -85,13 +87,13 @@ func (ec2 *FakeEC2) Instances(instanceIds []string, filter *ec2.Filter) (resp *e
 
 func mockInstancesResp(instances []ec2.Instance) (aws *AWSCloud) {
 	return &AWSCloud{
-		&FakeEC2{
+		ec2: &FakeEC2{
 			func(instanceIds []string, filter *ec2.Filter) (resp *ec2.InstancesResp, err error) {
 				return &ec2.InstancesResp{"",
 					[]ec2.Reservation{
 						ec2.Reservation{"", "", "", nil, instances}}}, nil
 			}},
-		nil}
+	}
 }
 
 func TestList(t *testing.T) {
@@ -155,3 +157,182 @@ func TestIPAddress(t *testing.T) {
 		t.Errorf("Expected %v, got %v", e, a)
 	}
 }
+
+type FakeELB struct {
+	balancers map[string]*LoadBalancer
+	zones     map[string][]string
+	ports     map[string]int
+}
+
+func NewFakeELB() *FakeELB {
+	return &FakeELB{map[string]*LoadBalancer{}, map[string][]string{}, map[string]int{}}
+}
+
+func (elb *FakeELB) DescribeLoadBalancer(name string) (*LoadBalancer, error) {
+	return elb.balancers[name], nil
+}
+
+func (elb *FakeELB) CreateLoadBalancer(name string, port int, zones []string) (string, error) {
+	if _, ok := elb.balancers[name]; ok {
+		return "", fmt.Errorf("%s already exists", name)
+	}
+	elb.balancers[name] = &LoadBalancer{Name: name, DNSName: name + ".elb.amazonaws.com", InstanceIds: []string{}}
+	elb.zones[name] = zones
+	elb.ports[name] = port
+	return elb.balancers[name].DNSName, nil
+}
+
+func (elb *FakeELB) DeleteLoadBalancer(name string) error {
+	delete(elb.balancers, name)
+	return nil
+}
+
+func (elb *FakeELB) RegisterInstances(name string, instanceIds []string) error {
+	lb := elb.balancers[name]
+	lb.InstanceIds = append(lb.InstanceIds, instanceIds...)
+	return nil
+}
+
+func (elb *FakeELB) DeregisterInstances(name string, instanceIds []string) error {
+	lb := elb.balancers[name]
+	remaining := []string{}
+	for _, id := range lb.InstanceIds {
+		keep := true
+		for _, removed := range instanceIds {
+			if id == removed {
+				keep = false
+			}
+		}
+		if keep {
+			remaining = append(remaining, id)
+		}
+	}
+	lb.InstanceIds = remaining
+	return nil
+}
+
+func mockLoadBalancerCloud(instances []ec2.Instance) (*AWSCloud, *FakeELB) {
+	aws := mockInstancesResp(instances)
+	elb := NewFakeELB()
+	aws.elb = elb
+	aws.cfg = &AWSCloudConfig{}
+	aws.cfg.Global.Region = "us-west-1"
+	return aws, elb
+}
+
+func lbInstances() []ec2.Instance {
+	instances := make([]ec2.Instance, 3)
+	for i := range instances {
+		instances[i].InstanceId = fmt.Sprintf("i-%d", i)
+		instances[i].PrivateDNSName = fmt.Sprintf("instance%d", i)
+		instances[i].AvailZone = "us-west-1a"
+	}
+	instances[2].AvailZone = "us-west-1b"
+	return instances
+}
+
+func TestCreateTCPLoadBalancer(t *testing.T) {
+	aws, elb := mockLoadBalancerCloud(lbInstances())
+
+	if err := aws.CreateTCPLoadBalancer("foo", "eu-west-1", 80, []string{"instance0"}); err == nil {
+		t.Errorf("Should error when creating a load balancer outside the configured region")
+	}
+	if err := aws.CreateTCPLoadBalancer("foo", "us-west-1", 80, []string{"instance9"}); err == nil {
+		t.Errorf("Should error when a host has no instance")
+	}
+	if err := aws.CreateTCPLoadBalancer("foo", "us-west-1", 80, []string{"instance0", "instance1", "instance2"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if e, a := []string{"i-0", "i-1", "i-2"}, elb.balancers["foo"].InstanceIds; !reflect.DeepEqual(e, a) {
+		t.Errorf("Expected %v, got %v", e, a)
+	}
+	if e, a := []string{"us-west-1a", "us-west-1b"}, elb.zones["foo"]; !reflect.DeepEqual(e, a) {
+		t.Errorf("Expected %v, got %v", e, a)
+	}
+	if elb.ports["foo"] != 80 {
+		t.Errorf("Expected port 80, got %d", elb.ports["foo"])
+	}
+	exists, err := aws.TCPLoadBalancerExists("foo", "us-west-1")
+	if err != nil || !exists {
+		t.Errorf("Expected foo to exist, got %v %v", exists, err)
+	}
+}
+
+func TestUpdateTCPLoadBalancer(t *testing.T) {
+	aws, elb := mockLoadBalancerCloud(lbInstances())
+	if err := aws.UpdateTCPLoadBalancer("foo", "us-west-1", []string{"instance0"}); err == nil {
+		t.Errorf("Should error when the load balancer doesn't exist")
+	}
+	if err := aws.CreateTCPLoadBalancer("foo", "us-west-1", 80, []string{"instance0", "instance1"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := aws.UpdateTCPLoadBalancer("foo", "us-west-1", []string{"instance1", "instance2"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if e, a := []string{"i-1", "i-2"}, elb.balancers["foo"].InstanceIds; !reflect.DeepEqual(e, a) {
+		t.Errorf("Expected %v, got %v", e, a)
+	}
+}
+
+func TestDeleteTCPLoadBalancer(t *testing.T) {
+	aws, _ := mockLoadBalancerCloud(lbInstances())
+	if err := aws.CreateTCPLoadBalancer("foo", "us-west-1", 80, []string{"instance0"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := aws.DeleteTCPLoadBalancer("foo", "us-west-1"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	exists, err := aws.TCPLoadBalancerExists("foo", "us-west-1")
+	if err != nil || exists {
+		t.Errorf("Expected foo to be deleted, got %v %v", exists, err)
+	}
+}
+
+func TestTCPLoadBalancerIP(t *testing.T) {
+	aws, _ := mockLoadBalancerCloud(lbInstances())
+	aws.lookupIP = func(host string) ([]net.IP, error) {
+		if host != "foo.elb.amazonaws.com" {
+			return nil, fmt.Errorf("unexpected host %s", host)
+		}
+		return []net.IP{net.ParseIP("::1"), net.ParseIP("1.2.3.4")}, nil
+	}
+	if _, err := aws.TCPLoadBalancerIP("foo", "us-west-1"); err == nil {
+		t.Errorf("Should error when the load balancer doesn't exist")
+	}
+	if err := aws.CreateTCPLoadBalancer("foo", "us-west-1", 80, []string{"instance0"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ip, err := aws.TCPLoadBalancerIP("foo", "us-west-1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if ip.String() != "1.2.3.4" {
+		t.Errorf("Expected 1.2.3.4, got %v", ip)
+	}
+}
+
+func TestGetZone(t *testing.T) {
+	aws, _ := mockLoadBalancerCloud(nil)
+	aws.metadata = func(path string) ([]byte, error) {
+		if path != "placement/availability-zone" {
+			return nil, fmt.Errorf("unexpected path %s", path)
+		}
+		return []byte("us-west-1b"), nil
+	}
+	zone, err := aws.GetZone()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if zone.FailureDomain != "us-west-1b" || zone.Region != "us-west-1" {
+		t.Errorf("Unexpected zone: %#v", zone)
+	}
+
+	aws.cfg.Global.Zone = "us-west-1a"
+	zone, err = aws.GetZone()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if zone.FailureDomain != "us-west-1a" {
+		t.Errorf("Expected the configured zone, got %#v", zone)
+	}
+}
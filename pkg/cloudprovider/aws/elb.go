@@ -0,0 +1,202 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws_cloud
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/goamz/aws"
+)
+
+// ELB is the subset of the Elastic Load Balancing API used to implement TCPLoadBalancer.
+type ELB interface {
+	// DescribeLoadBalancer returns the named load balancer, or nil if it doesn't exist.
+	DescribeLoadBalancer(name string) (*LoadBalancer, error)
+	// CreateLoadBalancer creates a load balancer forwarding TCP port to the same port of its
+	// instances, in the given availability zones. It returns the DNS name of the load balancer.
+	CreateLoadBalancer(name string, port int, zones []string) (string, error)
+	DeleteLoadBalancer(name string) error
+	RegisterInstances(name string, instanceIds []string) error
+	DeregisterInstances(name string, instanceIds []string) error
+}
+
+// LoadBalancer describes an elastic load balancer.
+type LoadBalancer struct {
+	Name        string   `xml:"LoadBalancerName"`
+	DNSName     string   `xml:"DNSName"`
+	InstanceIds []string `xml:"Instances>member>InstanceId"`
+}
+
+// ELBError is an error returned by the Elastic Load Balancing API.
+type ELBError struct {
+	StatusCode int
+	Code       string `xml:"Error>Code"`
+	Message    string `xml:"Error>Message"`
+}
+
+func (e *ELBError) Error() string {
+	return fmt.Sprintf("%s (%s)", e.Message, e.Code)
+}
+
+const elbVersion = "2012-06-01"
+
+// elbClient calls the Elastic Load Balancing query API of a region.
+type elbClient struct {
+	auth     aws.Auth
+	endpoint string
+	client   *http.Client
+	// now is replaceable for testing.
+	now func() time.Time
+}
+
+func newELBClient(auth aws.Auth, region aws.Region) *elbClient {
+	return &elbClient{
+		auth:     auth,
+		endpoint: region.ELBEndpoint,
+		client:   aws.RetryingClient,
+		now:      time.Now,
+	}
+}
+
+// query signs and sends a request for action, decoding the XML response into resp.
+func (c *elbClient) query(action string, params map[string]string, resp interface{}) error {
+	params["Action"] = action
+	params["Version"] = elbVersion
+	params["Timestamp"] = c.now().In(time.UTC).Format(time.RFC3339)
+	endpoint, err := url.Parse(c.endpoint)
+	if err != nil {
+		return err
+	}
+	if endpoint.Path == "" {
+		endpoint.Path = "/"
+	}
+	signV2(c.auth, "GET", endpoint.Host, endpoint.Path, params)
+	query := url.Values{}
+	for k, v := range params {
+		query.Set(k, v)
+	}
+	endpoint.RawQuery = query.Encode()
+
+	r, err := c.client.Get(endpoint.String())
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+	if r.StatusCode != http.StatusOK {
+		elbErr := &ELBError{StatusCode: r.StatusCode}
+		xml.NewDecoder(r.Body).Decode(elbErr)
+		if elbErr.Message == "" {
+			elbErr.Message = r.Status
+		}
+		return elbErr
+	}
+	if resp == nil {
+		return nil
+	}
+	return xml.NewDecoder(r.Body).Decode(resp)
+}
+
+// signV2 adds an AWS version 2 signature of a request to params.
+func signV2(auth aws.Auth, method, host, path string, params map[string]string) {
+	params["AWSAccessKeyId"] = auth.AccessKey
+	params["SignatureVersion"] = "2"
+	params["SignatureMethod"] = "HmacSHA256"
+	if auth.Token != "" {
+		params["SecurityToken"] = auth.Token
+	}
+	keys := []string{}
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := []string{}
+	for _, k := range keys {
+		pairs = append(pairs, aws.Encode(k)+"="+aws.Encode(params[k]))
+	}
+	hash := hmac.New(sha256.New, []byte(auth.SecretKey))
+	hash.Write([]byte(method + "\n" + host + "\n" + path + "\n" + strings.Join(pairs, "&")))
+	params["Signature"] = base64.StdEncoding.EncodeToString(hash.Sum(nil))
+}
+
+func addMembers(params map[string]string, prefix, suffix string, values []string) {
+	for i, value := range values {
+		params[prefix+".member."+strconv.Itoa(i+1)+suffix] = value
+	}
+}
+
+func (c *elbClient) DescribeLoadBalancer(name string) (*LoadBalancer, error) {
+	params := map[string]string{}
+	addMembers(params, "LoadBalancerNames", "", []string{name})
+	var resp struct {
+		LoadBalancers []LoadBalancer `xml:"DescribeLoadBalancersResult>LoadBalancerDescriptions>member"`
+	}
+	err := c.query("DescribeLoadBalancers", params, &resp)
+	if elbErr, ok := err.(*ELBError); ok && elbErr.Code == "LoadBalancerNotFound" {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.LoadBalancers) == 0 {
+		return nil, nil
+	}
+	return &resp.LoadBalancers[0], nil
+}
+
+func (c *elbClient) CreateLoadBalancer(name string, port int, zones []string) (string, error) {
+	params := map[string]string{
+		"LoadBalancerName":                    name,
+		"Listeners.member.1.Protocol":         "TCP",
+		"Listeners.member.1.LoadBalancerPort": strconv.Itoa(port),
+		"Listeners.member.1.InstanceProtocol": "TCP",
+		"Listeners.member.1.InstancePort":     strconv.Itoa(port),
+	}
+	addMembers(params, "AvailabilityZones", "", zones)
+	var resp struct {
+		DNSName string `xml:"CreateLoadBalancerResult>DNSName"`
+	}
+	if err := c.query("CreateLoadBalancer", params, &resp); err != nil {
+		return "", err
+	}
+	return resp.DNSName, nil
+}
+
+func (c *elbClient) DeleteLoadBalancer(name string) error {
+	return c.query("DeleteLoadBalancer", map[string]string{"LoadBalancerName": name}, nil)
+}
+
+func (c *elbClient) RegisterInstances(name string, instanceIds []string) error {
+	params := map[string]string{"LoadBalancerName": name}
+	addMembers(params, "Instances", ".InstanceId", instanceIds)
+	return c.query("RegisterInstancesWithLoadBalancer", params, nil)
+}
+
+func (c *elbClient) DeregisterInstances(name string, instanceIds []string) error {
+	params := map[string]string{"LoadBalancerName": name}
+	addMembers(params, "Instances", ".InstanceId", instanceIds)
+	return c.query("DeregisterInstancesFromLoadBalancer", params, nil)
+}
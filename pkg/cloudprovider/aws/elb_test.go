@@ -0,0 +1,114 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws_cloud
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/goamz/aws"
+)
+
+func newTestELBClient(handler http.HandlerFunc) (*elbClient, *httptest.Server) {
+	server := httptest.NewServer(handler)
+	return &elbClient{
+		auth:     aws.Auth{AccessKey: "access", SecretKey: "secret"},
+		endpoint: server.URL,
+		client:   http.DefaultClient,
+		now:      func() time.Time { return time.Unix(0, 0) },
+	}, server
+}
+
+func TestELBDescribeLoadBalancer(t *testing.T) {
+	c, server := newTestELBClient(func(w http.ResponseWriter, req *http.Request) {
+		q := req.URL.Query()
+		if q.Get("Action") != "DescribeLoadBalancers" || q.Get("Signature") == "" || q.Get("AWSAccessKeyId") != "access" {
+			t.Errorf("Unexpected query: %v", q)
+		}
+		if q.Get("LoadBalancerNames.member.1") != "foo" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`<ErrorResponse><Error><Code>LoadBalancerNotFound</Code><Message>not found</Message></Error></ErrorResponse>`))
+			return
+		}
+		w.Write([]byte(`<DescribeLoadBalancersResponse><DescribeLoadBalancersResult><LoadBalancerDescriptions><member>
+<LoadBalancerName>foo</LoadBalancerName><DNSName>foo.elb.amazonaws.com</DNSName>
+<Instances><member><InstanceId>i-1</InstanceId></member><member><InstanceId>i-2</InstanceId></member></Instances>
+</member></LoadBalancerDescriptions></DescribeLoadBalancersResult></DescribeLoadBalancersResponse>`))
+	})
+	defer server.Close()
+
+	lb, err := c.DescribeLoadBalancer("foo")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := &LoadBalancer{Name: "foo", DNSName: "foo.elb.amazonaws.com", InstanceIds: []string{"i-1", "i-2"}}
+	if !reflect.DeepEqual(expected, lb) {
+		t.Errorf("Expected %#v, got %#v", expected, lb)
+	}
+
+	lb, err = c.DescribeLoadBalancer("bar")
+	if err != nil || lb != nil {
+		t.Errorf("Expected no load balancer, got %#v %v", lb, err)
+	}
+}
+
+func TestELBCreateLoadBalancer(t *testing.T) {
+	c, server := newTestELBClient(func(w http.ResponseWriter, req *http.Request) {
+		q := req.URL.Query()
+		expected := map[string]string{
+			"Action":                              "CreateLoadBalancer",
+			"LoadBalancerName":                    "foo",
+			"Listeners.member.1.Protocol":         "TCP",
+			"Listeners.member.1.LoadBalancerPort": "8080",
+			"Listeners.member.1.InstancePort":     "8080",
+			"AvailabilityZones.member.1":          "us-west-1a",
+			"AvailabilityZones.member.2":          "us-west-1b",
+		}
+		for k, v := range expected {
+			if q.Get(k) != v {
+				t.Errorf("Expected %s=%s, got %q", k, v, q.Get(k))
+			}
+		}
+		w.Write([]byte(`<CreateLoadBalancerResponse><CreateLoadBalancerResult><DNSName>foo.elb.amazonaws.com</DNSName></CreateLoadBalancerResult></CreateLoadBalancerResponse>`))
+	})
+	defer server.Close()
+
+	dnsName, err := c.CreateLoadBalancer("foo", 8080, []string{"us-west-1a", "us-west-1b"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if dnsName != "foo.elb.amazonaws.com" {
+		t.Errorf("Unexpected DNS name: %s", dnsName)
+	}
+}
+
+func TestELBError(t *testing.T) {
+	c, server := newTestELBClient(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`<ErrorResponse><Error><Code>AccessDenied</Code><Message>denied</Message></Error></ErrorResponse>`))
+	})
+	defer server.Close()
+
+	err := c.RegisterInstances("foo", []string{"i-1"})
+	elbErr, ok := err.(*ELBError)
+	if !ok || elbErr.Code != "AccessDenied" || elbErr.StatusCode != http.StatusForbidden {
+		t.Errorf("Unexpected error: %#v", err)
+	}
+}
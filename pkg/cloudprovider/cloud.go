@@ -18,6 +18,8 @@ package cloudprovider
 
 import (
 	"net"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 )
 
 // Interface is an abstract, pluggable interface for cloud providers.
@@ -28,6 +30,10 @@ type Interface interface {
 	Instances() (Instances, bool)
 	// Zones returns a zones interface. Also returns true if the interface is supported, false otherwise.
 	Zones() (Zones, bool)
+	// InstanceGroups returns an instance groups interface. Also returns true if the interface is supported, false otherwise.
+	InstanceGroups() (InstanceGroups, bool)
+	// Routes returns a routes interface. Also returns true if the interface is supported, false otherwise.
+	Routes() (Routes, bool)
 }
 
 // TCPLoadBalancer is an abstract, pluggable interface for TCP load balancers.
@@ -41,6 +47,8 @@ type TCPLoadBalancer interface {
 	UpdateTCPLoadBalancer(name, region string, hosts []string) error
 	// DeleteTCPLoadBalancer deletes a specified load balancer.
 	DeleteTCPLoadBalancer(name, region string) error
+	// TCPLoadBalancerIP returns the external IP address of the specified load balancer.
+	TCPLoadBalancerIP(name, region string) (net.IP, error)
 }
 
 // Instances is an abstract, pluggable interface for sets of instances.
@@ -49,6 +57,9 @@ type Instances interface {
 	IPAddress(name string) (net.IP, error)
 	// List lists instances that match 'filter' which is a regular expression which must match the entire instance name (fqdn)
 	List(filter string) ([]string, error)
+	// GetNodeResources gets the resources for a particular node. If the resources
+	// are unknown, it returns nil and no error.
+	GetNodeResources(name string) (*api.NodeResources, error)
 }
 
 // Zone represents the location of a particular machine.
@@ -61,4 +72,41 @@ type Zone struct {
 type Zones interface {
 	// GetZone returns the Zone containing the current failure zone and locality region that the program is running in
 	GetZone() (Zone, error)
+	// GetInstanceZone returns the Zone of the named instance. Fields the provider doesn't know are empty.
+	GetInstanceZone(name string) (Zone, error)
+}
+
+// Labels which the minion registry puts on minions with the Zone of their
+// instance, so that the scheduler can spread pods across failure domains.
+const (
+	LabelFailureDomain = "failureDomain"
+	LabelRegion        = "region"
+)
+
+// InstanceGroups is an abstract, pluggable interface for resizable groups of
+// identical instances, such as the minions of a cluster. An autoscaler uses it
+// to add or remove minions.
+type InstanceGroups interface {
+	// GetInstanceGroupSize returns the number of instances the named group is meant to have.
+	GetInstanceGroupSize(name string) (int, error)
+	// ResizeInstanceGroup sets the number of instances the named group is meant to have.
+	ResizeInstanceGroup(name string, size int) error
+}
+
+// Route sends traffic for DestinationCIDR to the instance named TargetInstance.
+type Route struct {
+	Name            string
+	TargetInstance  string
+	DestinationCIDR string
+}
+
+// Routes is an abstract, pluggable interface for the routes of the cluster's
+// network, used to send the traffic for each minion's pods to that minion.
+type Routes interface {
+	// ListRoutes lists the routes whose names start with prefix.
+	ListRoutes(prefix string) ([]Route, error)
+	// CreateRoute creates the described route.
+	CreateRoute(route Route) error
+	// DeleteRoute deletes the named route.
+	DeleteRoute(name string) error
 }
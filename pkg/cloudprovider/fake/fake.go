@@ -19,18 +19,28 @@ package fake_cloud
 import (
 	"net"
 	"regexp"
+	"strings"
 
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/cloudprovider"
 )
 
 // FakeCloud is a test-double implementation of Interface, TCPLoadBalancer and Instances. It is useful for testing.
 type FakeCloud struct {
-	Exists   bool
-	Err      error
-	Calls    []string
-	IP       net.IP
-	Machines []string
+	Exists        bool
+	Err           error
+	Calls         []string
+	IP            net.IP
+	ExternalIP    net.IP
+	Machines      []string
+	NodeResources *api.NodeResources
+	// InstanceGroupSizes maps instance group names to their sizes.
+	InstanceGroupSizes map[string]int
 	cloudprovider.Zone
+	// InstanceZones maps instance names to their zones. Instances not in it are in Zone.
+	InstanceZones map[string]cloudprovider.Zone
+	// RouteMap maps route names to routes.
+	RouteMap map[string]cloudprovider.Route
 }
 
 func (f *FakeCloud) addCall(desc string) {
@@ -60,6 +70,20 @@ func (f *FakeCloud) Zones() (cloudprovider.Zones, bool) {
 	return f, true
 }
 
+// InstanceGroups returns a fake implementation of InstanceGroups.
+//
+// Actually it just returns f itself.
+func (f *FakeCloud) InstanceGroups() (cloudprovider.InstanceGroups, bool) {
+	return f, true
+}
+
+// Routes returns a fake implementation of Routes.
+//
+// Actually it just returns f itself.
+func (f *FakeCloud) Routes() (cloudprovider.Routes, bool) {
+	return f, true
+}
+
 // TCPLoadBalancerExists is a stub implementation of TCPLoadBalancer.TCPLoadBalancerExists.
 func (f *FakeCloud) TCPLoadBalancerExists(name, region string) (bool, error) {
 	return f.Exists, f.Err
@@ -86,6 +110,13 @@ func (f *FakeCloud) DeleteTCPLoadBalancer(name, region string) error {
 	return f.Err
 }
 
+// TCPLoadBalancerIP is a test-spy implementation of TCPLoadBalancer.TCPLoadBalancerIP.
+// It adds an entry "external-ip" into the internal method call record.
+func (f *FakeCloud) TCPLoadBalancerIP(name, region string) (net.IP, error) {
+	f.addCall("external-ip")
+	return f.ExternalIP, f.Err
+}
+
 // IPAddress is a test-spy implementation of Instances.IPAddress.
 // It adds an entry "ip-address" into the internal method call record.
 func (f *FakeCloud) IPAddress(instance string) (net.IP, error) {
@@ -106,7 +137,83 @@ func (f *FakeCloud) List(filter string) ([]string, error) {
 	return result, f.Err
 }
 
+// GetNodeResources is a test-spy implementation of Instances.GetNodeResources.
+// It adds an entry "get-node-resources" into the internal method call record.
+func (f *FakeCloud) GetNodeResources(name string) (*api.NodeResources, error) {
+	f.addCall("get-node-resources")
+	return f.NodeResources, f.Err
+}
+
 func (f *FakeCloud) GetZone() (cloudprovider.Zone, error) {
 	f.addCall("get-zone")
 	return f.Zone, f.Err
 }
+
+// GetInstanceZone is a test-spy implementation of Zones.GetInstanceZone.
+// It adds an entry "get-instance-zone" into the internal method call record.
+func (f *FakeCloud) GetInstanceZone(name string) (cloudprovider.Zone, error) {
+	f.addCall("get-instance-zone")
+	if zone, ok := f.InstanceZones[name]; ok {
+		return zone, f.Err
+	}
+	return f.Zone, f.Err
+}
+
+// GetInstanceGroupSize is a test-spy implementation of InstanceGroups.GetInstanceGroupSize.
+// It adds an entry "get-instance-group-size" into the internal method call record.
+func (f *FakeCloud) GetInstanceGroupSize(name string) (int, error) {
+	f.addCall("get-instance-group-size")
+	return f.InstanceGroupSizes[name], f.Err
+}
+
+// ResizeInstanceGroup is a test-spy implementation of InstanceGroups.ResizeInstanceGroup.
+// It adds an entry "resize-instance-group" into the internal method call record.
+func (f *FakeCloud) ResizeInstanceGroup(name string, size int) error {
+	f.addCall("resize-instance-group")
+	if f.Err != nil {
+		return f.Err
+	}
+	if f.InstanceGroupSizes == nil {
+		f.InstanceGroupSizes = map[string]int{}
+	}
+	f.InstanceGroupSizes[name] = size
+	return nil
+}
+
+// ListRoutes is a test-spy implementation of Routes.ListRoutes.
+// It adds an entry "list-routes" into the internal method call record.
+func (f *FakeCloud) ListRoutes(prefix string) ([]cloudprovider.Route, error) {
+	f.addCall("list-routes")
+	var routes []cloudprovider.Route
+	for name, route := range f.RouteMap {
+		if strings.HasPrefix(name, prefix) {
+			routes = append(routes, route)
+		}
+	}
+	return routes, f.Err
+}
+
+// CreateRoute is a test-spy implementation of Routes.CreateRoute.
+// It adds an entry "create-route" into the internal method call record.
+func (f *FakeCloud) CreateRoute(route cloudprovider.Route) error {
+	f.addCall("create-route")
+	if f.Err != nil {
+		return f.Err
+	}
+	if f.RouteMap == nil {
+		f.RouteMap = map[string]cloudprovider.Route{}
+	}
+	f.RouteMap[route.Name] = route
+	return nil
+}
+
+// DeleteRoute is a test-spy implementation of Routes.DeleteRoute.
+// It adds an entry "delete-route" into the internal method call record.
+func (f *FakeCloud) DeleteRoute(name string) error {
+	f.addCall("delete-route")
+	if f.Err != nil {
+		return f.Err
+	}
+	delete(f.RouteMap, name)
+	return nil
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package gce_cloud
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -29,12 +30,17 @@ import (
 
 	"code.google.com/p/goauth2/compute/serviceaccount"
 	compute "code.google.com/p/google-api-go-client/compute/v1"
+	"code.google.com/p/google-api-go-client/googleapi"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/cloudprovider"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/golang/glog"
 )
 
 // GCECloud is an implementation of Interface, TCPLoadBalancer and Instances for Google Compute Engine.
 type GCECloud struct {
 	service    *compute.Service
+	client     *http.Client
 	projectID  string
 	zone       string
 	instanceRE string
@@ -84,6 +90,7 @@ func newGCECloud() (*GCECloud, error) {
 	}
 	return &GCECloud{
 		service:   svc,
+		client:    client,
 		projectID: projectID,
 		zone:      zone,
 	}, nil
@@ -104,6 +111,16 @@ func (gce *GCECloud) Zones() (cloudprovider.Zones, bool) {
 	return gce, true
 }
 
+// InstanceGroups returns an implementation of InstanceGroups for Google Compute Engine.
+func (gce *GCECloud) InstanceGroups() (cloudprovider.InstanceGroups, bool) {
+	return gce, true
+}
+
+// Routes returns an implementation of Routes for Google Compute Engine.
+func (gce *GCECloud) Routes() (cloudprovider.Routes, bool) {
+	return gce, true
+}
+
 func makeHostLink(projectID, zone, host string) string {
 	ix := strings.Index(host, ".")
 	if ix != -1 {
@@ -189,6 +206,19 @@ func (gce *GCECloud) DeleteTCPLoadBalancer(name, region string) error {
 	return err
 }
 
+// TCPLoadBalancerIP is an implementation of TCPLoadBalancer.TCPLoadBalancerIP.
+func (gce *GCECloud) TCPLoadBalancerIP(name, region string) (net.IP, error) {
+	rule, err := gce.service.ForwardingRules.Get(gce.projectID, region, name).Do()
+	if err != nil {
+		return nil, err
+	}
+	ip := net.ParseIP(rule.IPAddress)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP address %q for load balancer %s", rule.IPAddress, name)
+	}
+	return ip, nil
+}
+
 // IPAddress is an implementation of Instances.IPAddress.
 func (gce *GCECloud) IPAddress(instance string) (net.IP, error) {
 	res, err := gce.service.Instances.Get(gce.projectID, gce.zone, instance).Do()
@@ -243,6 +273,60 @@ func (gce *GCECloud) List(filter string) ([]string, error) {
 	return instances, nil
 }
 
+// makeResources returns the NodeResources of a machine with the given number of
+// cores and gigabytes of memory.
+func makeResources(cpu float32, memory float32) *api.NodeResources {
+	return &api.NodeResources{
+		Capacity: api.ResourceList{
+			api.ResourceCPU:    util.NewIntOrStringFromInt(int(cpu * 1000)),
+			api.ResourceMemory: util.NewIntOrStringFromInt(int(memory * 1024 * 1024 * 1024)),
+		},
+	}
+}
+
+// canonicalizeInstanceName strips the fqdn suffix added by List.
+func canonicalizeInstanceName(name string) string {
+	ix := strings.Index(name, ".")
+	if ix != -1 {
+		name = name[:ix]
+	}
+	return name
+}
+
+// canonicalizeMachineType strips the zone URL prefix from a machine type.
+func canonicalizeMachineType(machineType string) string {
+	ix := strings.LastIndex(machineType, "/")
+	return machineType[ix+1:]
+}
+
+// GetNodeResources is an implementation of Instances.GetNodeResources.
+func (gce *GCECloud) GetNodeResources(name string) (*api.NodeResources, error) {
+	instance := canonicalizeInstanceName(name)
+	res, err := gce.service.Instances.Get(gce.projectID, gce.zone, instance).Do()
+	if err != nil {
+		return nil, err
+	}
+	switch canonicalizeMachineType(res.MachineType) {
+	case "f1-micro":
+		return makeResources(1, 0.6), nil
+	case "g1-small":
+		return makeResources(1, 1.70), nil
+	case "n1-standard-1":
+		return makeResources(1, 3.75), nil
+	case "n1-standard-2":
+		return makeResources(2, 7.5), nil
+	case "n1-standard-4":
+		return makeResources(4, 15), nil
+	case "n1-standard-8":
+		return makeResources(8, 30), nil
+	case "n1-standard-16":
+		return makeResources(16, 60), nil
+	default:
+		glog.Errorf("unknown machine type: %s", res.MachineType)
+		return nil, nil
+	}
+}
+
 func (gce *GCECloud) GetZone() (cloudprovider.Zone, error) {
 	region, err := getGceRegion(gce.zone)
 	if err != nil {
@@ -254,6 +338,12 @@ func (gce *GCECloud) GetZone() (cloudprovider.Zone, error) {
 	}, nil
 }
 
+// GetInstanceZone is an implementation of Zones.GetInstanceZone. All our
+// instances are in the zone we are running in.
+func (gce *GCECloud) GetInstanceZone(name string) (cloudprovider.Zone, error) {
+	return gce.GetZone()
+}
+
 // getGceRegion returns region of the gce zone. Zone names
 // are of the form: ${region-name}-${ix}.
 // For example "us-central1-b" has a region of "us-central1".
@@ -265,3 +355,111 @@ func getGceRegion(zone string) (string, error) {
 	}
 	return zone[:ix], nil
 }
+
+// ListRoutes is an implementation of Routes.ListRoutes.
+func (gce *GCECloud) ListRoutes(prefix string) ([]cloudprovider.Route, error) {
+	var routes []cloudprovider.Route
+	pageToken := ""
+	for {
+		listCall := gce.service.Routes.List(gce.projectID).Filter("name eq " + prefix + ".*")
+		if len(pageToken) > 0 {
+			listCall = listCall.PageToken(pageToken)
+		}
+		res, err := listCall.Do()
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range res.Items {
+			// Routes we didn't create may have no instance as their next hop.
+			if len(r.NextHopInstance) == 0 {
+				continue
+			}
+			routes = append(routes, cloudprovider.Route{
+				Name:            r.Name,
+				TargetInstance:  r.NextHopInstance[strings.LastIndex(r.NextHopInstance, "/")+1:],
+				DestinationCIDR: r.DestRange,
+			})
+		}
+		pageToken = res.NextPageToken
+		if len(pageToken) == 0 {
+			return routes, nil
+		}
+	}
+}
+
+// CreateRoute is an implementation of Routes.CreateRoute. The route is put on
+// the network of the target instance.
+func (gce *GCECloud) CreateRoute(route cloudprovider.Route) error {
+	instance, err := gce.service.Instances.Get(gce.projectID, gce.zone, canonicalizeInstanceName(route.TargetInstance)).Do()
+	if err != nil {
+		return err
+	}
+	if len(instance.NetworkInterfaces) == 0 {
+		return fmt.Errorf("instance %s has no network interfaces", route.TargetInstance)
+	}
+	_, err = gce.service.Routes.Insert(gce.projectID, &compute.Route{
+		Name:            route.Name,
+		DestRange:       route.DestinationCIDR,
+		NextHopInstance: makeHostLink(gce.projectID, gce.zone, route.TargetInstance),
+		Network:         instance.NetworkInterfaces[0].Network,
+		Priority:        1000,
+	}).Do()
+	return err
+}
+
+// DeleteRoute is an implementation of Routes.DeleteRoute.
+func (gce *GCECloud) DeleteRoute(name string) error {
+	_, err := gce.service.Routes.Delete(gce.projectID, name).Do()
+	return err
+}
+
+// instanceGroupManager is the part of a managed instance group we use. The
+// vendored compute client predates managed instance groups, so they are
+// requested directly.
+type instanceGroupManager struct {
+	TargetSize int `json:"targetSize"`
+}
+
+// makeInstanceGroupManagerURL returns the URL of the named managed instance
+// group in our zone.
+func (gce *GCECloud) makeInstanceGroupManagerURL(name string) string {
+	return fmt.Sprintf("%s%s/zones/%s/instanceGroupManagers/%s", gce.service.BasePath, gce.projectID, gce.zone, name)
+}
+
+// doInstanceGroupRequest sends a request about a managed instance group and
+// decodes the response into into, if it is not nil.
+func (gce *GCECloud) doInstanceGroupRequest(method, url string, into interface{}) error {
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return err
+	}
+	res, err := gce.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if err := googleapi.CheckResponse(res); err != nil {
+		return err
+	}
+	if into == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(into)
+}
+
+// GetInstanceGroupSize is an implementation of InstanceGroups.GetInstanceGroupSize.
+// The name is that of a managed instance group in our zone.
+func (gce *GCECloud) GetInstanceGroupSize(name string) (int, error) {
+	var manager instanceGroupManager
+	if err := gce.doInstanceGroupRequest("GET", gce.makeInstanceGroupManagerURL(name), &manager); err != nil {
+		return 0, err
+	}
+	return manager.TargetSize, nil
+}
+
+// ResizeInstanceGroup is an implementation of InstanceGroups.ResizeInstanceGroup.
+// It returns once GCE has accepted the new size, before instances are added or removed.
+func (gce *GCECloud) ResizeInstanceGroup(name string, size int) error {
+	url := fmt.Sprintf("%s/resize?size=%d", gce.makeInstanceGroupManagerURL(name), size)
+	return gce.doInstanceGroupRequest("POST", url, nil)
+}
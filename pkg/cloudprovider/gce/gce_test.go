@@ -17,7 +17,12 @@ limitations under the License.
 package gce_cloud
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+
+	compute "code.google.com/p/google-api-go-client/compute/v1"
 )
 
 func TestGetRegion(t *testing.T) {
@@ -36,3 +41,58 @@ func TestGetRegion(t *testing.T) {
 		t.Errorf("Unexpected region: %s", zone.Region)
 	}
 }
+
+func TestInstanceGroups(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests = append(requests, req.Method+" "+req.URL.String())
+		switch req.URL.Path {
+		case "/project/zones/us-central1-b/instanceGroupManagers/minions":
+			fmt.Fprint(w, `{"name": "minions", "targetSize": 3}`)
+		case "/project/zones/us-central1-b/instanceGroupManagers/minions/resize":
+			fmt.Fprint(w, `{"kind": "compute#operation"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error": {"code": 404, "message": "not found"}}`)
+		}
+	}))
+	defer server.Close()
+	service, err := compute.New(http.DefaultClient)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	service.BasePath = server.URL + "/"
+	gce := &GCECloud{
+		service:   service,
+		client:    http.DefaultClient,
+		projectID: "project",
+		zone:      "us-central1-b",
+	}
+	groups, ok := gce.InstanceGroups()
+	if !ok {
+		t.Fatalf("Unexpected missing instance groups impl")
+	}
+
+	size, err := groups.GetInstanceGroupSize("minions")
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if size != 3 {
+		t.Errorf("Expected size 3, got %d", size)
+	}
+	if err := groups.ResizeInstanceGroup("minions", 5); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := groups.GetInstanceGroupSize("other"); err == nil {
+		t.Errorf("Expected an error for a missing group")
+	}
+
+	expected := []string{
+		"GET /project/zones/us-central1-b/instanceGroupManagers/minions",
+		"POST /project/zones/us-central1-b/instanceGroupManagers/minions/resize?size=5",
+		"GET /project/zones/us-central1-b/instanceGroupManagers/other",
+	}
+	if fmt.Sprint(requests) != fmt.Sprint(expected) {
+		t.Errorf("Expected requests %v, got %v", expected, requests)
+	}
+}
@@ -0,0 +1,30 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package openstack_cloud is an implementation of Interface, TCPLoadBalancer,
+// Instances and Zones for OpenStack. Instances are Nova servers and load
+// balancers are Neutron LBaaS VIPs. It is configured with a file like:
+//
+//	[global]
+//	auth-url = https://keystone.example.com:5000/v2.0
+//	username = kubernetes
+//	password = secret
+//	tenant-name = kubernetes
+//	region = RegionOne
+//
+//	[loadbalancer]
+//	subnet-id = 2a1b...
+package openstack_cloud
@@ -0,0 +1,229 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstack_cloud
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+
+	"github.com/golang/glog"
+)
+
+// A load balancer is an LBaaS VIP, named after the service, in front of a pool
+// of the same name whose members are the hosts.
+
+type vip struct {
+	ID           string `json:"id,omitempty"`
+	Name         string `json:"name"`
+	Address      string `json:"address,omitempty"`
+	Protocol     string `json:"protocol"`
+	ProtocolPort int    `json:"protocol_port"`
+	SubnetID     string `json:"subnet_id"`
+	PoolID       string `json:"pool_id"`
+}
+
+type pool struct {
+	ID       string `json:"id,omitempty"`
+	Name     string `json:"name"`
+	Protocol string `json:"protocol"`
+	SubnetID string `json:"subnet_id"`
+	LBMethod string `json:"lb_method"`
+}
+
+type member struct {
+	ID           string `json:"id,omitempty"`
+	PoolID       string `json:"pool_id"`
+	Address      string `json:"address"`
+	ProtocolPort int    `json:"protocol_port"`
+}
+
+const lbPath = "/v2.0/lb"
+
+// checkRegion returns an error unless region is the one we were configured with.
+func (o *OpenStack) checkRegion(region string) error {
+	if region != o.cfg.Global.Region {
+		return fmt.Errorf("Region %s is not the configured region %s", region, o.cfg.Global.Region)
+	}
+	return nil
+}
+
+// getVip returns the VIP of the named load balancer, or nil if there is none.
+func (o *OpenStack) getVip(name string) (*vip, error) {
+	var resp struct {
+		Vips []vip `json:"vips"`
+	}
+	if err := o.Do("GET", "network", lbPath+"/vips?"+url.Values{"name": {name}}.Encode(), nil, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Vips) == 0 {
+		return nil, nil
+	}
+	if len(resp.Vips) > 1 {
+		return nil, fmt.Errorf("Multiple load balancers named %s", name)
+	}
+	return &resp.Vips[0], nil
+}
+
+func (o *OpenStack) listMembers(poolID string) ([]member, error) {
+	var resp struct {
+		Members []member `json:"members"`
+	}
+	if err := o.Do("GET", "network", lbPath+"/members?"+url.Values{"pool_id": {poolID}}.Encode(), nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Members, nil
+}
+
+func (o *OpenStack) addMember(poolID, host string, port int) error {
+	ip, err := o.IPAddress(host)
+	if err != nil {
+		return err
+	}
+	request := map[string]member{"member": {PoolID: poolID, Address: ip.String(), ProtocolPort: port}}
+	return o.Do("POST", "network", lbPath+"/members", request, nil)
+}
+
+// TCPLoadBalancerExists is an implementation of TCPLoadBalancer.TCPLoadBalancerExists.
+func (o *OpenStack) TCPLoadBalancerExists(name, region string) (bool, error) {
+	if err := o.checkRegion(region); err != nil {
+		return false, err
+	}
+	v, err := o.getVip(name)
+	if err != nil {
+		return false, err
+	}
+	return v != nil, nil
+}
+
+// CreateTCPLoadBalancer is an implementation of TCPLoadBalancer.CreateTCPLoadBalancer.
+func (o *OpenStack) CreateTCPLoadBalancer(name, region string, port int, hosts []string) error {
+	if err := o.checkRegion(region); err != nil {
+		return err
+	}
+	subnetID := o.cfg.LoadBalancer.SubnetID
+	var poolResp struct {
+		Pool pool `json:"pool"`
+	}
+	poolRequest := map[string]pool{"pool": {Name: name, Protocol: "TCP", SubnetID: subnetID, LBMethod: o.cfg.LoadBalancer.Method}}
+	if err := o.Do("POST", "network", lbPath+"/pools", poolRequest, &poolResp); err != nil {
+		return err
+	}
+	poolID := poolResp.Pool.ID
+
+	err := func() error {
+		for _, host := range hosts {
+			if err := o.addMember(poolID, host, port); err != nil {
+				return err
+			}
+		}
+		vipRequest := map[string]vip{"vip": {Name: name, Protocol: "TCP", ProtocolPort: port, SubnetID: subnetID, PoolID: poolID}}
+		return o.Do("POST", "network", lbPath+"/vips", vipRequest, nil)
+	}()
+	if err != nil {
+		// Don't leave a pool without a VIP behind, since nothing would find it to delete it.
+		if deleteErr := o.Do("DELETE", "network", lbPath+"/pools/"+poolID, nil, nil); deleteErr != nil {
+			glog.Errorf("Failed to clean up pool %s of load balancer %s: %v", poolID, name, deleteErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// UpdateTCPLoadBalancer is an implementation of TCPLoadBalancer.UpdateTCPLoadBalancer.
+func (o *OpenStack) UpdateTCPLoadBalancer(name, region string, hosts []string) error {
+	if err := o.checkRegion(region); err != nil {
+		return err
+	}
+	v, err := o.getVip(name)
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		return fmt.Errorf("No load balancer named %s", name)
+	}
+	members, err := o.listMembers(v.PoolID)
+	if err != nil {
+		return err
+	}
+	wanted := map[string]string{}
+	for _, host := range hosts {
+		ip, err := o.IPAddress(host)
+		if err != nil {
+			return err
+		}
+		wanted[ip.String()] = host
+	}
+	existing := map[string]bool{}
+	for _, m := range members {
+		existing[m.Address] = true
+		if _, ok := wanted[m.Address]; !ok {
+			if err := o.Do("DELETE", "network", lbPath+"/members/"+m.ID, nil, nil); err != nil && !IsNotFound(err) {
+				return err
+			}
+		}
+	}
+	for address, host := range wanted {
+		if !existing[address] {
+			if err := o.addMember(v.PoolID, host, v.ProtocolPort); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// DeleteTCPLoadBalancer is an implementation of TCPLoadBalancer.DeleteTCPLoadBalancer.
+// Deleting the pool deletes its members.
+func (o *OpenStack) DeleteTCPLoadBalancer(name, region string) error {
+	if err := o.checkRegion(region); err != nil {
+		return err
+	}
+	v, err := o.getVip(name)
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		return nil
+	}
+	if err := o.Do("DELETE", "network", lbPath+"/vips/"+v.ID, nil, nil); err != nil && !IsNotFound(err) {
+		return err
+	}
+	if err := o.Do("DELETE", "network", lbPath+"/pools/"+v.PoolID, nil, nil); err != nil && !IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// TCPLoadBalancerIP is an implementation of TCPLoadBalancer.TCPLoadBalancerIP.
+func (o *OpenStack) TCPLoadBalancerIP(name, region string) (net.IP, error) {
+	if err := o.checkRegion(region); err != nil {
+		return nil, err
+	}
+	v, err := o.getVip(name)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, fmt.Errorf("No load balancer named %s", name)
+	}
+	ip := net.ParseIP(v.Address)
+	if ip == nil {
+		return nil, fmt.Errorf("Invalid VIP address: %s", v.Address)
+	}
+	return ip, nil
+}
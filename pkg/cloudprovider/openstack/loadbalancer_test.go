@@ -0,0 +1,129 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstack_cloud
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func memberAddresses(f *fakeOpenStack) []string {
+	addresses := []string{}
+	for _, m := range f.members {
+		addresses = append(addresses, m.Address)
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
+func newLoadBalancerFake(t *testing.T) *fakeOpenStack {
+	f := newFakeOpenStack(t)
+	f.addServer("minion-1", "ACTIVE", "10.1.0.1", "m1.small")
+	f.addServer("minion-2", "ACTIVE", "10.1.0.2", "m1.small")
+	f.addServer("minion-3", "ACTIVE", "10.1.0.3", "m1.small")
+	return f
+}
+
+func TestCreateTCPLoadBalancer(t *testing.T) {
+	f := newLoadBalancerFake(t)
+	defer f.server.Close()
+	o := f.newOpenStack()
+
+	if err := o.CreateTCPLoadBalancer("foo", "RegionTwo", 80, []string{"minion-1"}); err == nil {
+		t.Errorf("Should error when creating a load balancer outside the configured region")
+	}
+	if err := o.CreateTCPLoadBalancer("foo", "RegionOne", 80, []string{"minion-1", "minion-2"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(f.vips) != 1 || len(f.pools) != 1 {
+		t.Fatalf("Expected a VIP and a pool, got %#v %#v", f.vips, f.pools)
+	}
+	for _, v := range f.vips {
+		if v.ProtocolPort != 80 || v.SubnetID != "subnet" || f.pools[v.PoolID].Name != "foo" {
+			t.Errorf("Unexpected VIP: %#v", v)
+		}
+	}
+	if e, a := []string{"10.1.0.1", "10.1.0.2"}, memberAddresses(f); !reflect.DeepEqual(e, a) {
+		t.Errorf("Expected %v, got %v", e, a)
+	}
+	exists, err := o.TCPLoadBalancerExists("foo", "RegionOne")
+	if err != nil || !exists {
+		t.Errorf("Expected foo to exist, got %v %v", exists, err)
+	}
+	ip, err := o.TCPLoadBalancerIP("foo", "RegionOne")
+	if err != nil || ip == nil {
+		t.Errorf("Unexpected IP: %v %v", ip, err)
+	}
+}
+
+func TestCreateTCPLoadBalancerCleansUpPool(t *testing.T) {
+	f := newLoadBalancerFake(t)
+	defer f.server.Close()
+	o := f.newOpenStack()
+
+	if err := o.CreateTCPLoadBalancer("foo", "RegionOne", 80, []string{"minion-1", "minion-9"}); err == nil {
+		t.Errorf("Should error when a host has no server")
+	}
+	if len(f.pools) != 0 || len(f.members) != 0 || len(f.vips) != 0 {
+		t.Errorf("Expected the pool to be deleted, got %#v %#v %#v", f.pools, f.members, f.vips)
+	}
+}
+
+func TestUpdateTCPLoadBalancer(t *testing.T) {
+	f := newLoadBalancerFake(t)
+	defer f.server.Close()
+	o := f.newOpenStack()
+
+	if err := o.UpdateTCPLoadBalancer("foo", "RegionOne", []string{"minion-1"}); err == nil {
+		t.Errorf("Should error when the load balancer doesn't exist")
+	}
+	if err := o.CreateTCPLoadBalancer("foo", "RegionOne", 80, []string{"minion-1", "minion-2"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := o.UpdateTCPLoadBalancer("foo", "RegionOne", []string{"minion-2", "minion-3"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if e, a := []string{"10.1.0.2", "10.1.0.3"}, memberAddresses(f); !reflect.DeepEqual(e, a) {
+		t.Errorf("Expected %v, got %v", e, a)
+	}
+	for _, m := range f.members {
+		if m.ProtocolPort != 80 {
+			t.Errorf("Unexpected member: %#v", m)
+		}
+	}
+}
+
+func TestDeleteTCPLoadBalancer(t *testing.T) {
+	f := newLoadBalancerFake(t)
+	defer f.server.Close()
+	o := f.newOpenStack()
+
+	if err := o.CreateTCPLoadBalancer("foo", "RegionOne", 80, []string{"minion-1"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := o.DeleteTCPLoadBalancer("foo", "RegionOne"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(f.pools) != 0 || len(f.members) != 0 || len(f.vips) != 0 {
+		t.Errorf("Expected everything to be deleted, got %#v %#v %#v", f.pools, f.members, f.vips)
+	}
+	// Deleting a load balancer which doesn't exist succeeds.
+	if err := o.DeleteTCPLoadBalancer("foo", "RegionOne"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
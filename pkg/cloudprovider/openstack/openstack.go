@@ -0,0 +1,408 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstack_cloud
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"code.google.com/p/gcfg"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/cloudprovider"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+)
+
+// OpenStack is an implementation of Interface, TCPLoadBalancer, Instances and Zones for OpenStack.
+type OpenStack struct {
+	cfg    *Config
+	client *http.Client
+
+	// lock protects token and endpoints, which are filled in by authenticate.
+	lock sync.Mutex
+	// token is the Keystone token sent with each request, or "" if we must authenticate.
+	token string
+	// endpoints maps service types, such as "compute", to their URLs in our region.
+	endpoints map[string]string
+}
+
+// Config is the configuration of the OpenStack cloud provider.
+type Config struct {
+	Global struct {
+		AuthURL    string `gcfg:"auth-url"`
+		Username   string
+		Password   string
+		TenantName string `gcfg:"tenant-name"`
+		// APIKey is used instead of Password and TenantName with identity
+		// services which accept API keys, such as Rackspace's.
+		APIKey string `gcfg:"api-key"`
+		Region string
+	}
+	LoadBalancer struct {
+		// SubnetID is the Neutron subnet that load balancer VIPs and members are on.
+		SubnetID string `gcfg:"subnet-id"`
+		// Method is the LBaaS load balancing method, ROUND_ROBIN by default.
+		Method string `gcfg:"lb-method"`
+	}
+}
+
+func init() {
+	cloudprovider.RegisterCloudProvider("openstack", func(config io.Reader) (cloudprovider.Interface, error) {
+		cfg, err := readConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		return NewOpenStack(cfg), nil
+	})
+}
+
+// readConfig reads a Config from config, checking that the required fields are set.
+func readConfig(config io.Reader) (*Config, error) {
+	if config == nil {
+		return nil, fmt.Errorf("No OpenStack cloud provider config file given")
+	}
+	var cfg Config
+	cfg.LoadBalancer.Method = "ROUND_ROBIN"
+	if err := gcfg.ReadInto(&cfg, config); err != nil {
+		return nil, err
+	}
+	if cfg.Global.AuthURL == "" {
+		return nil, fmt.Errorf("No auth-url specified in configuration file")
+	}
+	if cfg.Global.Username == "" || (cfg.Global.TenantName == "" && cfg.Global.APIKey == "") {
+		return nil, fmt.Errorf("No username, or tenant-name or api-key, specified in configuration file")
+	}
+	if cfg.Global.Region == "" {
+		return nil, fmt.Errorf("No region specified in configuration file")
+	}
+	return &cfg, nil
+}
+
+// NewOpenStack returns an OpenStack which authenticates with the credentials in cfg.
+func NewOpenStack(cfg *Config) *OpenStack {
+	return newOpenStack(cfg, http.DefaultClient)
+}
+
+func newOpenStack(cfg *Config, client *http.Client) *OpenStack {
+	return &OpenStack{cfg: cfg, client: client}
+}
+
+// TCPLoadBalancer returns an implementation of TCPLoadBalancer for OpenStack.
+func (o *OpenStack) TCPLoadBalancer() (cloudprovider.TCPLoadBalancer, bool) {
+	if o.cfg.LoadBalancer.SubnetID == "" {
+		return nil, false
+	}
+	return o, true
+}
+
+// Instances returns an implementation of Instances for OpenStack.
+func (o *OpenStack) Instances() (cloudprovider.Instances, bool) {
+	return o, true
+}
+
+// Zones returns an implementation of Zones for OpenStack.
+func (o *OpenStack) Zones() (cloudprovider.Zones, bool) {
+	return o, true
+}
+
+// InstanceGroups returns an implementation of InstanceGroups for OpenStack.
+func (o *OpenStack) InstanceGroups() (cloudprovider.InstanceGroups, bool) {
+	return nil, false
+}
+
+// Routes returns an implementation of Routes for OpenStack.
+func (o *OpenStack) Routes() (cloudprovider.Routes, bool) {
+	return nil, false
+}
+
+// GetZone is an implementation of Zones.GetZone. OpenStack regions are our
+// only notion of locality, so FailureDomain is left empty.
+func (o *OpenStack) GetZone() (cloudprovider.Zone, error) {
+	return cloudprovider.Zone{Region: o.cfg.Global.Region}, nil
+}
+
+// GetInstanceZone is an implementation of Zones.GetInstanceZone.
+func (o *OpenStack) GetInstanceZone(name string) (cloudprovider.Zone, error) {
+	srv, err := o.getServer(name)
+	if err != nil {
+		return cloudprovider.Zone{}, err
+	}
+	return cloudprovider.Zone{
+		FailureDomain: srv.AvailabilityZone,
+		Region:        o.cfg.Global.Region,
+	}, nil
+}
+
+type keystoneAccess struct {
+	Access struct {
+		Token struct {
+			ID string `json:"id"`
+		} `json:"token"`
+		ServiceCatalog []struct {
+			Type      string `json:"type"`
+			Endpoints []struct {
+				Region    string `json:"region"`
+				PublicURL string `json:"publicURL"`
+			} `json:"endpoints"`
+		} `json:"serviceCatalog"`
+	} `json:"access"`
+}
+
+// authenticate gets a token from Keystone, and the endpoints of the services
+// in our region from its service catalog. o.lock must be held.
+func (o *OpenStack) authenticate() error {
+	var auth map[string]interface{}
+	if o.cfg.Global.APIKey != "" {
+		auth = map[string]interface{}{
+			"RAX-KSKEY:apiKeyCredentials": map[string]string{
+				"username": o.cfg.Global.Username,
+				"apiKey":   o.cfg.Global.APIKey,
+			},
+		}
+	} else {
+		auth = map[string]interface{}{
+			"passwordCredentials": map[string]string{
+				"username": o.cfg.Global.Username,
+				"password": o.cfg.Global.Password,
+			},
+			"tenantName": o.cfg.Global.TenantName,
+		}
+	}
+	request := map[string]interface{}{"auth": auth}
+	var access keystoneAccess
+	if err := o.send("POST", strings.TrimRight(o.cfg.Global.AuthURL, "/")+"/tokens", "", &request, &access); err != nil {
+		return fmt.Errorf("Authenticating with Keystone failed: %v", err)
+	}
+
+	endpoints := map[string]string{}
+	for _, service := range access.Access.ServiceCatalog {
+		for _, endpoint := range service.Endpoints {
+			if endpoint.Region == o.cfg.Global.Region {
+				endpoints[service.Type] = strings.TrimRight(endpoint.PublicURL, "/")
+			}
+		}
+	}
+	o.token = access.Access.Token.ID
+	o.endpoints = endpoints
+	return nil
+}
+
+// statusError is returned for requests which OpenStack rejected.
+type statusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%d: %s", e.StatusCode, e.Message)
+}
+
+// IsNotFound returns whether err is from a request for something which doesn't exist.
+func IsNotFound(err error) bool {
+	statusErr, ok := err.(*statusError)
+	return ok && statusErr.StatusCode == http.StatusNotFound
+}
+
+// send makes a JSON request to url, decoding the response into out if it isn't nil.
+func (o *OpenStack) send(method, url, token string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("X-Auth-Token", token)
+	}
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message, _ := ioutil.ReadAll(resp.Body)
+		return &statusError{resp.StatusCode, string(message)}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// Do makes a JSON request to path under the endpoint of serviceType, such as
+// "compute", authenticating first if we have no token, and again if our token
+// has expired. The response is decoded into out if it isn't nil.
+func (o *OpenStack) Do(method, serviceType, path string, in, out interface{}) error {
+	for attempt := 0; ; attempt++ {
+		o.lock.Lock()
+		if o.token == "" {
+			if err := o.authenticate(); err != nil {
+				o.lock.Unlock()
+				return err
+			}
+		}
+		token, endpoint := o.token, o.endpoints[serviceType]
+		o.lock.Unlock()
+		if endpoint == "" {
+			return fmt.Errorf("No %s endpoint in region %s", serviceType, o.cfg.Global.Region)
+		}
+
+		err := o.send(method, endpoint+path, token, in, out)
+		if statusErr, ok := err.(*statusError); ok && statusErr.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			o.lock.Lock()
+			if o.token == token {
+				o.token = ""
+			}
+			o.lock.Unlock()
+			continue
+		}
+		return err
+	}
+}
+
+type server struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	Addresses map[string][]struct {
+		Addr    string `json:"addr"`
+		Version int    `json:"version"`
+	} `json:"addresses"`
+	Flavor struct {
+		ID string `json:"id"`
+	} `json:"flavor"`
+	// AvailabilityZone is only reported by clouds with the extended availability zone extension.
+	AvailabilityZone string `json:"OS-EXT-AZ:availability_zone"`
+}
+
+// listServers returns the servers whose names match the regular expression
+// nameRegexp anywhere, which is how Nova filters names.
+func (o *OpenStack) listServers(nameRegexp string) ([]server, error) {
+	var resp struct {
+		Servers []server `json:"servers"`
+	}
+	path := "/servers/detail?" + url.Values{"name": {nameRegexp}}.Encode()
+	if err := o.Do("GET", "compute", path, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Servers, nil
+}
+
+// getServer returns the server with exactly the given name.
+func (o *OpenStack) getServer(name string) (*server, error) {
+	servers, err := o.listServers("^" + regexp.QuoteMeta(name) + "$")
+	if err != nil {
+		return nil, err
+	}
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("No server found for host: %s", name)
+	}
+	if len(servers) > 1 {
+		return nil, fmt.Errorf("Multiple servers found for host: %s", name)
+	}
+	return &servers[0], nil
+}
+
+// IPAddress is an implementation of Instances.IPAddress. It returns the first
+// IPv4 address of the server, taking its networks in name order.
+func (o *OpenStack) IPAddress(name string) (net.IP, error) {
+	srv, err := o.getServer(name)
+	if err != nil {
+		return nil, err
+	}
+	networks := []string{}
+	for network := range srv.Addresses {
+		networks = append(networks, network)
+	}
+	sort.Strings(networks)
+	for _, network := range networks {
+		for _, address := range srv.Addresses[network] {
+			if address.Version != 4 {
+				continue
+			}
+			ip := net.ParseIP(address.Addr)
+			if ip == nil {
+				return nil, fmt.Errorf("Invalid network IP: %s", address.Addr)
+			}
+			return ip, nil
+		}
+	}
+	return nil, fmt.Errorf("No IPv4 address found for host: %s", name)
+}
+
+// List is an implementation of Instances.List. Only active servers are listed.
+func (o *OpenStack) List(filter string) ([]string, error) {
+	re, err := regexp.Compile("^(?:" + filter + ")$")
+	if err != nil {
+		return nil, err
+	}
+	servers, err := o.listServers(filter)
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, srv := range servers {
+		if srv.Status == "ACTIVE" && re.MatchString(srv.Name) {
+			names = append(names, srv.Name)
+		}
+	}
+	return names, nil
+}
+
+// GetNodeResources is an implementation of Instances.GetNodeResources. It
+// returns the capacity of the server's flavor.
+func (o *OpenStack) GetNodeResources(name string) (*api.NodeResources, error) {
+	srv, err := o.getServer(name)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Flavor struct {
+			VCPUs int `json:"vcpus"`
+			// RAM is in megabytes.
+			RAM int `json:"ram"`
+		} `json:"flavor"`
+	}
+	if err := o.Do("GET", "compute", "/flavors/"+srv.Flavor.ID, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &api.NodeResources{
+		Capacity: api.ResourceList{
+			api.ResourceCPU:    util.NewIntOrStringFromInt(resp.Flavor.VCPUs * 1000),
+			api.ResourceMemory: util.NewIntOrStringFromInt(resp.Flavor.RAM * 1024 * 1024),
+		},
+	}, nil
+}
@@ -0,0 +1,346 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstack_cloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+)
+
+// fakeOpenStack serves the parts of the Keystone, Nova and Neutron LBaaS APIs
+// that the provider uses.
+type fakeOpenStack struct {
+	t      *testing.T
+	server *httptest.Server
+
+	lock sync.Mutex
+	// tokens is the number of tokens issued; only the last one is valid.
+	tokens  int
+	servers []map[string]interface{}
+	nextID  int
+	vips    map[string]vip
+	pools   map[string]pool
+	members map[string]member
+}
+
+func newFakeOpenStack(t *testing.T) *fakeOpenStack {
+	f := &fakeOpenStack{
+		t:       t,
+		vips:    map[string]vip{},
+		pools:   map[string]pool{},
+		members: map[string]member{},
+	}
+	f.server = httptest.NewServer(http.HandlerFunc(f.serveHTTP))
+	return f
+}
+
+func (f *fakeOpenStack) addServer(name, status, ip, flavor string) {
+	f.servers = append(f.servers, map[string]interface{}{
+		"id":     "id-" + name,
+		"name":   name,
+		"status": status,
+		"addresses": map[string]interface{}{
+			"private": []map[string]interface{}{
+				{"addr": "fe80::1", "version": 6},
+				{"addr": ip, "version": 4},
+			},
+		},
+		"flavor": map[string]string{"id": flavor},
+	})
+}
+
+func (f *fakeOpenStack) newOpenStack() *OpenStack {
+	cfg := &Config{}
+	cfg.Global.AuthURL = f.server.URL + "/identity/v2.0"
+	cfg.Global.Username = "user"
+	cfg.Global.Password = "secret"
+	cfg.Global.TenantName = "tenant"
+	cfg.Global.Region = "RegionOne"
+	cfg.LoadBalancer.SubnetID = "subnet"
+	cfg.LoadBalancer.Method = "ROUND_ROBIN"
+	return newOpenStack(cfg, http.DefaultClient)
+}
+
+func (f *fakeOpenStack) id() string {
+	f.nextID++
+	return fmt.Sprintf("%d", f.nextID)
+}
+
+func (f *fakeOpenStack) reply(w http.ResponseWriter, obj interface{}) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		f.t.Fatalf("unexpected error: %v", err)
+	}
+	w.Write(data)
+}
+
+func (f *fakeOpenStack) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	path := req.URL.Path
+
+	if path == "/identity/v2.0/tokens" && req.Method == "POST" {
+		var body map[string]map[string]interface{}
+		json.NewDecoder(req.Body).Decode(&body)
+		creds, _ := body["auth"]["passwordCredentials"].(map[string]interface{})
+		keyCreds, _ := body["auth"]["RAX-KSKEY:apiKeyCredentials"].(map[string]interface{})
+		passwordOK := creds["username"] == "user" && creds["password"] == "secret" && body["auth"]["tenantName"] == "tenant"
+		apiKeyOK := keyCreds["username"] == "user" && keyCreds["apiKey"] == "key"
+		if !passwordOK && !apiKeyOK {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		f.tokens++
+		endpoint := func(region, url string) map[string]string {
+			return map[string]string{"region": region, "publicURL": url}
+		}
+		f.reply(w, map[string]interface{}{
+			"access": map[string]interface{}{
+				"token": map[string]string{"id": fmt.Sprintf("token-%d", f.tokens)},
+				"serviceCatalog": []map[string]interface{}{
+					{"type": "compute", "endpoints": []map[string]string{
+						endpoint("RegionTwo", "http://elsewhere.invalid/"),
+						endpoint("RegionOne", f.server.URL+"/compute/v2/tenant/"),
+					}},
+					{"type": "network", "endpoints": []map[string]string{
+						endpoint("RegionOne", f.server.URL+"/network"),
+					}},
+				},
+			},
+		})
+		return
+	}
+	if req.Header.Get("X-Auth-Token") != fmt.Sprintf("token-%d", f.tokens) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case path == "/compute/v2/tenant/servers/detail":
+		re := regexp.MustCompile(req.URL.Query().Get("name"))
+		servers := []map[string]interface{}{}
+		for _, srv := range f.servers {
+			if re.MatchString(srv["name"].(string)) {
+				servers = append(servers, srv)
+			}
+		}
+		f.reply(w, map[string]interface{}{"servers": servers})
+	case path == "/compute/v2/tenant/flavors/m1.small":
+		f.reply(w, map[string]interface{}{"flavor": map[string]int{"vcpus": 2, "ram": 2048}})
+	case path == "/network/v2.0/lb/vips" && req.Method == "GET":
+		vips := []vip{}
+		for _, v := range f.vips {
+			if v.Name == req.URL.Query().Get("name") {
+				vips = append(vips, v)
+			}
+		}
+		f.reply(w, map[string][]vip{"vips": vips})
+	case path == "/network/v2.0/lb/vips" && req.Method == "POST":
+		var body map[string]vip
+		json.NewDecoder(req.Body).Decode(&body)
+		v := body["vip"]
+		v.ID = f.id()
+		v.Address = "10.0.0." + v.ID
+		f.vips[v.ID] = v
+		f.reply(w, map[string]vip{"vip": v})
+	case path == "/network/v2.0/lb/pools" && req.Method == "POST":
+		var body map[string]pool
+		json.NewDecoder(req.Body).Decode(&body)
+		p := body["pool"]
+		p.ID = f.id()
+		f.pools[p.ID] = p
+		f.reply(w, map[string]pool{"pool": p})
+	case path == "/network/v2.0/lb/members" && req.Method == "GET":
+		members := []member{}
+		for _, m := range f.members {
+			if m.PoolID == req.URL.Query().Get("pool_id") {
+				members = append(members, m)
+			}
+		}
+		f.reply(w, map[string][]member{"members": members})
+	case path == "/network/v2.0/lb/members" && req.Method == "POST":
+		var body map[string]member
+		json.NewDecoder(req.Body).Decode(&body)
+		m := body["member"]
+		m.ID = f.id()
+		f.members[m.ID] = m
+		f.reply(w, map[string]member{"member": m})
+	case strings.HasPrefix(path, "/network/v2.0/lb/") && req.Method == "DELETE":
+		parts := strings.Split(path, "/")
+		id := parts[len(parts)-1]
+		switch parts[len(parts)-2] {
+		case "vips":
+			delete(f.vips, id)
+		case "pools":
+			delete(f.pools, id)
+			for memberID, m := range f.members {
+				if m.PoolID == id {
+					delete(f.members, memberID)
+				}
+			}
+		case "members":
+			delete(f.members, id)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestReadConfig(t *testing.T) {
+	if _, err := readConfig(nil); err == nil {
+		t.Errorf("Should error when no config reader is given")
+	}
+	if _, err := readConfig(strings.NewReader("[global]\nauth-url = http://keystone\nusername = user\ntenant-name = tenant\n")); err == nil {
+		t.Errorf("Should error when no region is specified")
+	}
+	cfg, err := readConfig(strings.NewReader("[global]\nauth-url = http://keystone\nusername = user\npassword = secret\ntenant-name = tenant\nregion = RegionOne\n[loadbalancer]\nsubnet-id = subnet\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.Global.TenantName != "tenant" || cfg.LoadBalancer.SubnetID != "subnet" || cfg.LoadBalancer.Method != "ROUND_ROBIN" {
+		t.Errorf("Unexpected config: %#v", cfg)
+	}
+}
+
+func TestList(t *testing.T) {
+	f := newFakeOpenStack(t)
+	defer f.server.Close()
+	f.addServer("minion-1", "ACTIVE", "10.1.0.1", "m1.small")
+	f.addServer("minion-2", "ACTIVE", "10.1.0.2", "m1.small")
+	f.addServer("minion-3", "BUILD", "10.1.0.3", "m1.small")
+	f.addServer("other-minion-4", "ACTIVE", "10.1.0.4", "m1.small")
+	o := f.newOpenStack()
+
+	names, err := o.List("minion-.*")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if e, a := []string{"minion-1", "minion-2"}, names; !reflect.DeepEqual(e, a) {
+		t.Errorf("Expected %v, got %v", e, a)
+	}
+}
+
+func TestIPAddress(t *testing.T) {
+	f := newFakeOpenStack(t)
+	defer f.server.Close()
+	f.addServer("minion-1", "ACTIVE", "10.1.0.1", "m1.small")
+	f.addServer("minion-10", "ACTIVE", "10.1.0.10", "m1.small")
+	o := f.newOpenStack()
+
+	ip, err := o.IPAddress("minion-1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if ip.String() != "10.1.0.1" {
+		t.Errorf("Expected 10.1.0.1, got %v", ip)
+	}
+	if _, err := o.IPAddress("minion-2"); err == nil {
+		t.Errorf("Should error when no server is found")
+	}
+}
+
+func TestGetNodeResources(t *testing.T) {
+	f := newFakeOpenStack(t)
+	defer f.server.Close()
+	f.addServer("minion-1", "ACTIVE", "10.1.0.1", "m1.small")
+	o := f.newOpenStack()
+
+	resources, err := o.GetNodeResources("minion-1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := &api.NodeResources{
+		Capacity: api.ResourceList{
+			api.ResourceCPU:    util.NewIntOrStringFromInt(2000),
+			api.ResourceMemory: util.NewIntOrStringFromInt(2048 * 1024 * 1024),
+		},
+	}
+	if !reflect.DeepEqual(expected, resources) {
+		t.Errorf("Expected %#v, got %#v", expected, resources)
+	}
+}
+
+func TestReauthenticatesWhenTokenExpires(t *testing.T) {
+	f := newFakeOpenStack(t)
+	defer f.server.Close()
+	f.addServer("minion-1", "ACTIVE", "10.1.0.1", "m1.small")
+	o := f.newOpenStack()
+
+	if _, err := o.List(".*"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// Expire the provider's token.
+	f.lock.Lock()
+	f.tokens++
+	f.lock.Unlock()
+	names, err := o.List(".*")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(names) != 1 || f.tokens != 3 {
+		t.Errorf("Expected to authenticate again, got %v after %d tokens", names, f.tokens)
+	}
+}
+
+func TestBadCredentials(t *testing.T) {
+	f := newFakeOpenStack(t)
+	defer f.server.Close()
+	o := f.newOpenStack()
+	o.cfg.Global.Password = "wrong"
+
+	if _, err := o.List(".*"); err == nil {
+		t.Errorf("Should error when authentication fails")
+	}
+}
+
+func TestAPIKeyAuthentication(t *testing.T) {
+	f := newFakeOpenStack(t)
+	defer f.server.Close()
+	f.addServer("minion-1", "ACTIVE", "10.1.0.1", "m1.small")
+	o := f.newOpenStack()
+	o.cfg.Global.Password = ""
+	o.cfg.Global.TenantName = ""
+	o.cfg.Global.APIKey = "key"
+
+	names, err := o.List(".*")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(names) != 1 {
+		t.Errorf("Expected 1 server, got %v", names)
+	}
+}
+
+func TestGetZone(t *testing.T) {
+	o := newOpenStack(&Config{}, http.DefaultClient)
+	o.cfg.Global.Region = "RegionOne"
+	zone, err := o.GetZone()
+	if err != nil || zone.Region != "RegionOne" {
+		t.Errorf("Unexpected zone: %#v %v", zone, err)
+	}
+}
@@ -28,6 +28,7 @@ import (
 	"strings"
 
 	"code.google.com/p/gcfg"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/cloudprovider"
 )
 
@@ -109,6 +110,16 @@ func (v *OVirtCloud) Zones() (cloudprovider.Zones, bool) {
 	return nil, false
 }
 
+// InstanceGroups returns an implementation of InstanceGroups for oVirt cloud
+func (v *OVirtCloud) InstanceGroups() (cloudprovider.InstanceGroups, bool) {
+	return nil, false
+}
+
+// Routes returns an implementation of Routes for oVirt cloud.
+func (v *OVirtCloud) Routes() (cloudprovider.Routes, bool) {
+	return nil, false
+}
+
 // IPAddress returns the address of a particular machine instance
 func (v *OVirtCloud) IPAddress(instance string) (net.IP, error) {
 	// since the instance now is the IP in the ovirt env, this is trivial no-op
@@ -154,3 +165,8 @@ func (v *OVirtCloud) List(filter string) ([]string, error) {
 
 	return getInstancesFromXml(response.Body)
 }
+
+// GetNodeResources implements Instances.GetNodeResources
+func (v *OVirtCloud) GetNodeResources(name string) (*api.NodeResources, error) {
+	return nil, nil
+}
@@ -18,6 +18,8 @@ package cloudprovider
 
 import (
 	"io"
+	"os"
+	"sort"
 	"sync"
 
 	"github.com/golang/glog"
@@ -46,6 +48,18 @@ func RegisterCloudProvider(name string, cloud Factory) {
 	providers[name] = cloud
 }
 
+// CloudProviderNames returns the sorted names of the registered cloud providers.
+func CloudProviderNames() []string {
+	providersMutex.Lock()
+	defer providersMutex.Unlock()
+	names := []string{}
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // GetCloudProvider creates an instance of the named cloud provider, or nil if
 // the name is not known.  The error return is only used if the named provider
 // was known but failed to initialize. The config parameter specifies the
@@ -60,3 +74,38 @@ func GetCloudProvider(name string, config io.Reader) (Interface, error) {
 	}
 	return f(config)
 }
+
+// InitCloudProvider creates an instance of the named cloud provider, reading
+// its configuration from configFilePath if that is not empty.  It returns nil
+// if name is empty, and exits if the provider is unknown or fails to
+// initialize.
+func InitCloudProvider(name string, configFilePath string) Interface {
+	var config *os.File
+
+	if name == "" {
+		glog.Info("No cloud provider specified.")
+		return nil
+	}
+
+	if configFilePath != "" {
+		var err error
+
+		config, err = os.Open(configFilePath)
+		if err != nil {
+			glog.Fatalf("Couldn't open cloud provider configuration %s: %#v",
+				configFilePath, err)
+		}
+
+		defer config.Close()
+	}
+
+	cloud, err := GetCloudProvider(name, config)
+	if err != nil {
+		glog.Fatalf("Couldn't init cloud provider %q: %#v", name, err)
+	}
+	if cloud == nil {
+		glog.Fatalf("Unknown cloud provider: %s (registered providers are %v)", name, CloudProviderNames())
+	}
+
+	return cloud
+}
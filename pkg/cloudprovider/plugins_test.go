@@ -0,0 +1,53 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRegisterCloudProvider(t *testing.T) {
+	var gotConfig string
+	RegisterCloudProvider("test-provider", func(config io.Reader) (Interface, error) {
+		data := make([]byte, 3)
+		config.Read(data)
+		gotConfig = string(data)
+		return nil, nil
+	})
+	defer func() {
+		providersMutex.Lock()
+		delete(providers, "test-provider")
+		providersMutex.Unlock()
+	}()
+
+	if e, a := []string{"test-provider"}, CloudProviderNames(); !reflect.DeepEqual(e, a) {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+	if _, err := GetCloudProvider("test-provider", strings.NewReader("foo")); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if gotConfig != "foo" {
+		t.Errorf("expected the factory to read its config, got %q", gotConfig)
+	}
+	cloud, err := GetCloudProvider("no-such-provider", nil)
+	if cloud != nil || err != nil {
+		t.Errorf("expected no provider, got %v %v", cloud, err)
+	}
+}
@@ -0,0 +1,26 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package rackspace_cloud is an implementation of Interface, TCPLoadBalancer,
+// Instances and Zones for Rackspace Cloud. Instances are Cloud Servers, found
+// through the OpenStack provider, and load balancers are Cloud Load Balancers.
+// It is configured with a file like:
+//
+//	[global]
+//	username = kubernetes
+//	api-key = 0123456789abcdef
+//	region = ORD
+package rackspace_cloud
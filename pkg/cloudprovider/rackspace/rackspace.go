@@ -0,0 +1,282 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rackspace_cloud
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+
+	"code.google.com/p/gcfg"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/cloudprovider"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/cloudprovider/openstack"
+)
+
+// identityURL is the endpoint of Rackspace's identity service.
+const identityURL = "https://identity.api.rackspacecloud.com/v2.0"
+
+// loadBalancerService is the service type of Cloud Load Balancers in the service catalog.
+const loadBalancerService = "rax:load-balancer"
+
+// Rackspace is an implementation of Interface, TCPLoadBalancer, Instances and Zones for Rackspace Cloud.
+type Rackspace struct {
+	*openstack_cloud.OpenStack
+	region string
+}
+
+func init() {
+	cloudprovider.RegisterCloudProvider("rackspace", func(config io.Reader) (cloudprovider.Interface, error) {
+		cfg, err := readConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		return newRackspace(cfg), nil
+	})
+}
+
+// readConfig reads the configuration of the provider, which has the same
+// format as the OpenStack provider's but authenticates with an API key.
+func readConfig(config io.Reader) (*openstack_cloud.Config, error) {
+	if config == nil {
+		return nil, fmt.Errorf("No Rackspace cloud provider config file given")
+	}
+	var cfg openstack_cloud.Config
+	cfg.Global.AuthURL = identityURL
+	if err := gcfg.ReadInto(&cfg, config); err != nil {
+		return nil, err
+	}
+	if cfg.Global.Username == "" || cfg.Global.APIKey == "" {
+		return nil, fmt.Errorf("No username or api-key specified in configuration file")
+	}
+	if cfg.Global.Region == "" {
+		return nil, fmt.Errorf("No region specified in configuration file")
+	}
+	return &cfg, nil
+}
+
+func newRackspace(cfg *openstack_cloud.Config) *Rackspace {
+	return &Rackspace{
+		OpenStack: openstack_cloud.NewOpenStack(cfg),
+		region:    cfg.Global.Region,
+	}
+}
+
+// TCPLoadBalancer returns an implementation of TCPLoadBalancer for Rackspace Cloud.
+func (r *Rackspace) TCPLoadBalancer() (cloudprovider.TCPLoadBalancer, bool) {
+	return r, true
+}
+
+type virtualIP struct {
+	Address   string `json:"address,omitempty"`
+	Type      string `json:"type"`
+	IPVersion string `json:"ipVersion,omitempty"`
+}
+
+type node struct {
+	ID        int    `json:"id,omitempty"`
+	Address   string `json:"address"`
+	Port      int    `json:"port"`
+	Condition string `json:"condition"`
+}
+
+type loadBalancer struct {
+	ID         int         `json:"id,omitempty"`
+	Name       string      `json:"name"`
+	Port       int         `json:"port"`
+	Protocol   string      `json:"protocol"`
+	Status     string      `json:"status,omitempty"`
+	VirtualIPs []virtualIP `json:"virtualIps"`
+	Nodes      []node      `json:"nodes,omitempty"`
+}
+
+// checkRegion returns an error unless region is the one we were configured with.
+func (r *Rackspace) checkRegion(region string) error {
+	if region != r.region {
+		return fmt.Errorf("Region %s is not the configured region %s", region, r.region)
+	}
+	return nil
+}
+
+// getLoadBalancer returns the named load balancer, or nil if there is none.
+func (r *Rackspace) getLoadBalancer(name string) (*loadBalancer, error) {
+	var resp struct {
+		LoadBalancers []loadBalancer `json:"loadBalancers"`
+	}
+	if err := r.Do("GET", loadBalancerService, "/loadbalancers", nil, &resp); err != nil {
+		return nil, err
+	}
+	var found *loadBalancer
+	for i := range resp.LoadBalancers {
+		lb := &resp.LoadBalancers[i]
+		if lb.Name != name || lb.Status == "DELETED" || lb.Status == "PENDING_DELETE" {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("Multiple load balancers named %s", name)
+		}
+		found = lb
+	}
+	return found, nil
+}
+
+// makeNodes returns nodes for the private addresses of hosts.
+func (r *Rackspace) makeNodes(hosts []string, port int) ([]node, error) {
+	nodes := []node{}
+	for _, host := range hosts {
+		ip, err := r.IPAddress(host)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node{Address: ip.String(), Port: port, Condition: "ENABLED"})
+	}
+	return nodes, nil
+}
+
+// TCPLoadBalancerExists is an implementation of TCPLoadBalancer.TCPLoadBalancerExists.
+func (r *Rackspace) TCPLoadBalancerExists(name, region string) (bool, error) {
+	if err := r.checkRegion(region); err != nil {
+		return false, err
+	}
+	lb, err := r.getLoadBalancer(name)
+	if err != nil {
+		return false, err
+	}
+	return lb != nil, nil
+}
+
+// CreateTCPLoadBalancer is an implementation of TCPLoadBalancer.CreateTCPLoadBalancer.
+func (r *Rackspace) CreateTCPLoadBalancer(name, region string, port int, hosts []string) error {
+	if err := r.checkRegion(region); err != nil {
+		return err
+	}
+	nodes, err := r.makeNodes(hosts, port)
+	if err != nil {
+		return err
+	}
+	request := map[string]loadBalancer{
+		"loadBalancer": {
+			Name:       name,
+			Port:       port,
+			Protocol:   "TCP",
+			VirtualIPs: []virtualIP{{Type: "PUBLIC"}},
+			Nodes:      nodes,
+		},
+	}
+	return r.Do("POST", loadBalancerService, "/loadbalancers", request, nil)
+}
+
+// UpdateTCPLoadBalancer is an implementation of TCPLoadBalancer.UpdateTCPLoadBalancer.
+func (r *Rackspace) UpdateTCPLoadBalancer(name, region string, hosts []string) error {
+	if err := r.checkRegion(region); err != nil {
+		return err
+	}
+	lb, err := r.getLoadBalancer(name)
+	if err != nil {
+		return err
+	}
+	if lb == nil {
+		return fmt.Errorf("No load balancer named %s", name)
+	}
+	nodesPath := "/loadbalancers/" + strconv.Itoa(lb.ID) + "/nodes"
+	var resp struct {
+		Nodes []node `json:"nodes"`
+	}
+	if err := r.Do("GET", loadBalancerService, nodesPath, nil, &resp); err != nil {
+		return err
+	}
+	wanted, err := r.makeNodes(hosts, lb.Port)
+	if err != nil {
+		return err
+	}
+
+	wantedAddresses := map[string]bool{}
+	for _, n := range wanted {
+		wantedAddresses[n.Address] = true
+	}
+	existing := map[string]bool{}
+	toDelete := url.Values{}
+	for _, n := range resp.Nodes {
+		existing[n.Address] = true
+		if !wantedAddresses[n.Address] {
+			toDelete.Add("id", strconv.Itoa(n.ID))
+		}
+	}
+	toAdd := []node{}
+	for _, n := range wanted {
+		if !existing[n.Address] {
+			toAdd = append(toAdd, n)
+		}
+	}
+
+	// A load balancer is immutable while a change to it is pending, so this
+	// may fail; the change will be made again by the next update.
+	if len(toAdd) > 0 {
+		if err := r.Do("POST", loadBalancerService, nodesPath, map[string][]node{"nodes": toAdd}, nil); err != nil {
+			return err
+		}
+	}
+	if len(toDelete) > 0 {
+		return r.Do("DELETE", loadBalancerService, nodesPath+"?"+toDelete.Encode(), nil, nil)
+	}
+	return nil
+}
+
+// DeleteTCPLoadBalancer is an implementation of TCPLoadBalancer.DeleteTCPLoadBalancer.
+func (r *Rackspace) DeleteTCPLoadBalancer(name, region string) error {
+	if err := r.checkRegion(region); err != nil {
+		return err
+	}
+	lb, err := r.getLoadBalancer(name)
+	if err != nil {
+		return err
+	}
+	if lb == nil {
+		return nil
+	}
+	err = r.Do("DELETE", loadBalancerService, "/loadbalancers/"+strconv.Itoa(lb.ID), nil, nil)
+	if err != nil && !openstack_cloud.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// TCPLoadBalancerIP is an implementation of TCPLoadBalancer.TCPLoadBalancerIP.
+// It returns the public IPv4 address of the load balancer.
+func (r *Rackspace) TCPLoadBalancerIP(name, region string) (net.IP, error) {
+	if err := r.checkRegion(region); err != nil {
+		return nil, err
+	}
+	lb, err := r.getLoadBalancer(name)
+	if err != nil {
+		return nil, err
+	}
+	if lb == nil {
+		return nil, fmt.Errorf("No load balancer named %s", name)
+	}
+	for _, vip := range lb.VirtualIPs {
+		if vip.Type == "PUBLIC" && vip.IPVersion == "IPV4" {
+			ip := net.ParseIP(vip.Address)
+			if ip == nil {
+				return nil, fmt.Errorf("Invalid virtual IP: %s", vip.Address)
+			}
+			return ip, nil
+		}
+	}
+	return nil, fmt.Errorf("No public IPv4 address for load balancer %s", name)
+}
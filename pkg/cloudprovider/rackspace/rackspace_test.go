@@ -0,0 +1,288 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rackspace_cloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeRackspace serves the identity, Cloud Servers and Cloud Load Balancers
+// calls the provider makes.
+type fakeRackspace struct {
+	t             *testing.T
+	server        *httptest.Server
+	servers       map[string]string
+	nextID        int
+	loadBalancers map[int]*loadBalancer
+}
+
+func newFakeRackspace(t *testing.T) *fakeRackspace {
+	f := &fakeRackspace{
+		t: t,
+		servers: map[string]string{
+			"minion-1": "10.176.0.1",
+			"minion-2": "10.176.0.2",
+			"minion-3": "10.176.0.3",
+		},
+		loadBalancers: map[int]*loadBalancer{},
+	}
+	f.server = httptest.NewServer(f)
+	return f
+}
+
+func (f *fakeRackspace) newRackspace() *Rackspace {
+	cfg, err := readConfig(strings.NewReader("[global]\nusername = user\napi-key = key\nregion = ORD\n"))
+	if err != nil {
+		f.t.Fatalf("Unexpected error: %v", err)
+	}
+	cfg.Global.AuthURL = f.server.URL + "/identity"
+	return newRackspace(cfg)
+}
+
+func (f *fakeRackspace) reply(w http.ResponseWriter, obj interface{}) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		f.t.Fatalf("Unexpected error: %v", err)
+	}
+	w.Write(data)
+}
+
+func (f *fakeRackspace) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	path := req.URL.Path
+	if path == "/identity/tokens" {
+		var body map[string]map[string]map[string]string
+		json.NewDecoder(req.Body).Decode(&body)
+		if creds := body["auth"]["RAX-KSKEY:apiKeyCredentials"]; creds["username"] != "user" || creds["apiKey"] != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		f.reply(w, map[string]interface{}{
+			"access": map[string]interface{}{
+				"token": map[string]string{"id": "token"},
+				"serviceCatalog": []map[string]interface{}{
+					{"type": "compute", "endpoints": []map[string]string{{"region": "ORD", "publicURL": f.server.URL + "/servers/123"}}},
+					{"type": "rax:load-balancer", "endpoints": []map[string]string{{"region": "ORD", "publicURL": f.server.URL + "/lb/123"}}},
+				},
+			},
+		})
+		return
+	}
+	if req.Header.Get("X-Auth-Token") != "token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(path, "/lb/123/loadbalancers"), "/")
+	switch {
+	case path == "/servers/123/servers/detail":
+		servers := []map[string]interface{}{}
+		for name, ip := range f.servers {
+			if "^"+name+"$" == req.URL.Query().Get("name") {
+				servers = append(servers, map[string]interface{}{
+					"name":   name,
+					"status": "ACTIVE",
+					"addresses": map[string]interface{}{
+						"public":  []map[string]interface{}{{"addr": "166.78.0.1", "version": 4}},
+						"private": []map[string]interface{}{{"addr": ip, "version": 4}},
+					},
+				})
+			}
+		}
+		f.reply(w, map[string]interface{}{"servers": servers})
+	case path == "/lb/123/loadbalancers" && req.Method == "GET":
+		lbs := []loadBalancer{}
+		for _, lb := range f.loadBalancers {
+			summary := *lb
+			summary.Nodes = nil
+			lbs = append(lbs, summary)
+		}
+		f.reply(w, map[string][]loadBalancer{"loadBalancers": lbs})
+	case path == "/lb/123/loadbalancers" && req.Method == "POST":
+		var body map[string]*loadBalancer
+		json.NewDecoder(req.Body).Decode(&body)
+		lb := body["loadBalancer"]
+		f.nextID++
+		lb.ID = f.nextID
+		lb.Status = "BUILD"
+		lb.VirtualIPs = []virtualIP{
+			{Address: "2001:db8::1", Type: "PUBLIC", IPVersion: "IPV6"},
+			{Address: fmt.Sprintf("166.78.1.%d", lb.ID), Type: "PUBLIC", IPVersion: "IPV4"},
+		}
+		for i := range lb.Nodes {
+			f.nextID++
+			lb.Nodes[i].ID = f.nextID
+		}
+		f.loadBalancers[lb.ID] = lb
+		w.WriteHeader(http.StatusAccepted)
+		f.reply(w, map[string]*loadBalancer{"loadBalancer": lb})
+	case len(parts) >= 2:
+		id, _ := strconv.Atoi(parts[1])
+		lb, ok := f.loadBalancers[id]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch {
+		case len(parts) == 2 && req.Method == "DELETE":
+			delete(f.loadBalancers, id)
+			w.WriteHeader(http.StatusAccepted)
+		case len(parts) == 3 && req.Method == "GET":
+			f.reply(w, map[string][]node{"nodes": lb.Nodes})
+		case len(parts) == 3 && req.Method == "POST":
+			var body map[string][]node
+			json.NewDecoder(req.Body).Decode(&body)
+			for _, n := range body["nodes"] {
+				f.nextID++
+				n.ID = f.nextID
+				lb.Nodes = append(lb.Nodes, n)
+			}
+			w.WriteHeader(http.StatusAccepted)
+		case len(parts) == 3 && req.Method == "DELETE":
+			ids := map[string]bool{}
+			for _, id := range req.URL.Query()["id"] {
+				ids[id] = true
+			}
+			remaining := []node{}
+			for _, n := range lb.Nodes {
+				if !ids[strconv.Itoa(n.ID)] {
+					remaining = append(remaining, n)
+				}
+			}
+			lb.Nodes = remaining
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (f *fakeRackspace) nodeAddresses(name string) []string {
+	addresses := []string{}
+	for _, lb := range f.loadBalancers {
+		if lb.Name != name {
+			continue
+		}
+		for _, n := range lb.Nodes {
+			addresses = append(addresses, n.Address)
+		}
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
+func TestReadConfig(t *testing.T) {
+	if _, err := readConfig(nil); err == nil {
+		t.Errorf("Should error when no config reader is given")
+	}
+	if _, err := readConfig(strings.NewReader("[global]\nusername = user\nregion = ORD\n")); err == nil {
+		t.Errorf("Should error when no api-key is specified")
+	}
+	cfg, err := readConfig(strings.NewReader("[global]\nusername = user\napi-key = key\nregion = ORD\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.Global.AuthURL != identityURL {
+		t.Errorf("Expected the Rackspace identity service, got %s", cfg.Global.AuthURL)
+	}
+}
+
+func TestIPAddressIsPrivate(t *testing.T) {
+	f := newFakeRackspace(t)
+	defer f.server.Close()
+	ip, err := f.newRackspace().IPAddress("minion-1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if ip.String() != "10.176.0.1" {
+		t.Errorf("Expected 10.176.0.1, got %v", ip)
+	}
+}
+
+func TestCreateTCPLoadBalancer(t *testing.T) {
+	f := newFakeRackspace(t)
+	defer f.server.Close()
+	r := f.newRackspace()
+
+	if err := r.CreateTCPLoadBalancer("foo", "DFW", 80, []string{"minion-1"}); err == nil {
+		t.Errorf("Should error when creating a load balancer outside the configured region")
+	}
+	if err := r.CreateTCPLoadBalancer("foo", "ORD", 80, []string{"minion-1", "minion-2"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if e, a := []string{"10.176.0.1", "10.176.0.2"}, f.nodeAddresses("foo"); !reflect.DeepEqual(e, a) {
+		t.Errorf("Expected %v, got %v", e, a)
+	}
+	exists, err := r.TCPLoadBalancerExists("foo", "ORD")
+	if err != nil || !exists {
+		t.Errorf("Expected foo to exist, got %v %v", exists, err)
+	}
+	ip, err := r.TCPLoadBalancerIP("foo", "ORD")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if ip.String() != "166.78.1.1" {
+		t.Errorf("Expected 166.78.1.1, got %v", ip)
+	}
+}
+
+func TestUpdateTCPLoadBalancer(t *testing.T) {
+	f := newFakeRackspace(t)
+	defer f.server.Close()
+	r := f.newRackspace()
+
+	if err := r.UpdateTCPLoadBalancer("foo", "ORD", []string{"minion-1"}); err == nil {
+		t.Errorf("Should error when the load balancer doesn't exist")
+	}
+	if err := r.CreateTCPLoadBalancer("foo", "ORD", 80, []string{"minion-1", "minion-2"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := r.UpdateTCPLoadBalancer("foo", "ORD", []string{"minion-2", "minion-3"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if e, a := []string{"10.176.0.2", "10.176.0.3"}, f.nodeAddresses("foo"); !reflect.DeepEqual(e, a) {
+		t.Errorf("Expected %v, got %v", e, a)
+	}
+}
+
+func TestDeleteTCPLoadBalancer(t *testing.T) {
+	f := newFakeRackspace(t)
+	defer f.server.Close()
+	r := f.newRackspace()
+
+	if err := r.CreateTCPLoadBalancer("foo", "ORD", 80, []string{"minion-1"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := r.DeleteTCPLoadBalancer("foo", "ORD"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(f.loadBalancers) != 0 {
+		t.Errorf("Expected the load balancer to be deleted, got %#v", f.loadBalancers)
+	}
+	if err := r.DeleteTCPLoadBalancer("foo", "ORD"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
@@ -27,6 +27,7 @@ import (
 	neturl "net/url"
 	"sort"
 
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/cloudprovider"
 )
 
@@ -94,6 +95,16 @@ func (v *VagrantCloud) Zones() (cloudprovider.Zones, bool) {
 	return nil, false
 }
 
+// InstanceGroups returns an implementation of InstanceGroups for Vagrant cloud.
+func (v *VagrantCloud) InstanceGroups() (cloudprovider.InstanceGroups, bool) {
+	return nil, false
+}
+
+// Routes returns an implementation of Routes for Vagrant cloud.
+func (v *VagrantCloud) Routes() (cloudprovider.Routes, bool) {
+	return nil, false
+}
+
 // IPAddress returns the address of a particular machine instance.
 func (v *VagrantCloud) IPAddress(instance string) (net.IP, error) {
 	token, err := v.saltLogin()
@@ -209,3 +220,8 @@ func (v *VagrantCloud) List(filter string) ([]string, error) {
 
 	return instances, nil
 }
+
+// GetNodeResources implements Instances.GetNodeResources
+func (v *VagrantCloud) GetNodeResources(name string) (*api.NodeResources, error) {
+	return nil, nil
+}
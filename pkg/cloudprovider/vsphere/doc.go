@@ -0,0 +1,29 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package vsphere_cloud is an implementation of Interface, Instances and Zones
+// for VMware vSphere, talking to vCenter's SOAP API. Instances are virtual
+// machines, named by their inventory name. It is configured with a file like:
+//
+//	[global]
+//	url = https://vcenter.example.com/sdk
+//	username = kubernetes
+//	password = secret
+//	datacenter = dc1
+//	cluster = cluster1
+//
+// The datacenter and cluster are reported as the region and failure domain.
+package vsphere_cloud
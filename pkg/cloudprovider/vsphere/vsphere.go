@@ -0,0 +1,385 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vsphere_cloud
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"regexp"
+	"strings"
+	"sync"
+
+	"code.google.com/p/gcfg"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/cloudprovider"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+)
+
+// VSphere is an implementation of Interface, Instances and Zones for vSphere.
+type VSphere struct {
+	cfg    *VSphereConfig
+	client *http.Client
+
+	// lock protects content and view, which are filled in by login.
+	lock sync.Mutex
+	// content holds the references of the managers of the vCenter, or nil if we must log in.
+	content *serviceContent
+	// view is a container view of all the virtual machines.
+	view string
+}
+
+// VSphereConfig is the configuration of the vSphere cloud provider.
+type VSphereConfig struct {
+	Global struct {
+		// URL is the SOAP endpoint of vCenter, like https://vcenter/sdk.
+		URL      string `gcfg:"url"`
+		Username string
+		Password string
+		// Insecure skips verifying vCenter's certificate, which is often self-signed.
+		Insecure   bool
+		Datacenter string
+		Cluster    string
+	}
+}
+
+func init() {
+	cloudprovider.RegisterCloudProvider("vsphere", func(config io.Reader) (cloudprovider.Interface, error) {
+		cfg, err := readConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		return newVSphere(cfg), nil
+	})
+}
+
+// readConfig reads a VSphereConfig from config, checking that the required fields are set.
+func readConfig(config io.Reader) (*VSphereConfig, error) {
+	if config == nil {
+		return nil, fmt.Errorf("No vSphere cloud provider config file given")
+	}
+	var cfg VSphereConfig
+	if err := gcfg.ReadInto(&cfg, config); err != nil {
+		return nil, err
+	}
+	if cfg.Global.URL == "" {
+		return nil, fmt.Errorf("No url specified in configuration file")
+	}
+	if cfg.Global.Username == "" {
+		return nil, fmt.Errorf("No username specified in configuration file")
+	}
+	return &cfg, nil
+}
+
+func newVSphere(cfg *VSphereConfig) *VSphere {
+	// The session cookie returned by Login authenticates later calls.
+	jar, _ := cookiejar.New(nil)
+	return &VSphere{
+		cfg: cfg,
+		client: &http.Client{
+			Jar: jar,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: cfg.Global.Insecure},
+			},
+		},
+	}
+}
+
+// TCPLoadBalancer returns an implementation of TCPLoadBalancer for vSphere.
+func (v *VSphere) TCPLoadBalancer() (cloudprovider.TCPLoadBalancer, bool) {
+	return nil, false
+}
+
+// Instances returns an implementation of Instances for vSphere.
+func (v *VSphere) Instances() (cloudprovider.Instances, bool) {
+	return v, true
+}
+
+// Zones returns an implementation of Zones for vSphere.
+func (v *VSphere) Zones() (cloudprovider.Zones, bool) {
+	return v, true
+}
+
+// InstanceGroups returns an implementation of InstanceGroups for vSphere.
+func (v *VSphere) InstanceGroups() (cloudprovider.InstanceGroups, bool) {
+	return nil, false
+}
+
+// Routes returns an implementation of Routes for vSphere.
+func (v *VSphere) Routes() (cloudprovider.Routes, bool) {
+	return nil, false
+}
+
+// GetZone is an implementation of Zones.GetZone.
+func (v *VSphere) GetZone() (cloudprovider.Zone, error) {
+	return cloudprovider.Zone{
+		FailureDomain: v.cfg.Global.Cluster,
+		Region:        v.cfg.Global.Datacenter,
+	}, nil
+}
+
+// GetInstanceZone is an implementation of Zones.GetInstanceZone. Our
+// instances are all in the configured cluster.
+func (v *VSphere) GetInstanceZone(name string) (cloudprovider.Zone, error) {
+	return v.GetZone()
+}
+
+// soapFault is returned for calls which vCenter rejected.
+type soapFault struct {
+	Message string `xml:"Body>Fault>faultstring"`
+	Detail  struct {
+		Inner []struct {
+			XMLName xml.Name
+		} `xml:",any"`
+	} `xml:"Body>Fault>detail"`
+}
+
+func (f *soapFault) Error() string {
+	return "vSphere: " + f.Message
+}
+
+func (f *soapFault) isNotAuthenticated() bool {
+	for _, detail := range f.Detail.Inner {
+		if detail.XMLName.Local == "NotAuthenticatedFault" {
+			return true
+		}
+	}
+	return false
+}
+
+// escape returns s escaped for use as XML character data.
+func escape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+// call makes a SOAP request with the given body, and decodes the SOAP body of
+// the response into resp.
+func (v *VSphere) call(body string, resp interface{}) error {
+	envelope := `<?xml version="1.0" encoding="UTF-8"?>` +
+		`<soapenv:Envelope xmlns:soapenv="http://schemas.xmlsoap.org/soap/envelope/" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">` +
+		`<soapenv:Body>` + body + `</soapenv:Body></soapenv:Envelope>`
+	req, err := http.NewRequest("POST", v.cfg.Global.URL, strings.NewReader(envelope))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/xml; charset=utf-8")
+	req.Header.Set("SOAPAction", "urn:vim25/5.0")
+	r, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	if r.StatusCode != http.StatusOK {
+		fault := &soapFault{}
+		if err := xml.Unmarshal(data, fault); err != nil || fault.Message == "" {
+			return fmt.Errorf("vSphere: %s", r.Status)
+		}
+		return fault
+	}
+	return xml.Unmarshal(data, resp)
+}
+
+type serviceContent struct {
+	SessionManager    string `xml:"Body>RetrieveServiceContentResponse>returnval>sessionManager"`
+	PropertyCollector string `xml:"Body>RetrieveServiceContentResponse>returnval>propertyCollector"`
+	ViewManager       string `xml:"Body>RetrieveServiceContentResponse>returnval>viewManager"`
+	RootFolder        string `xml:"Body>RetrieveServiceContentResponse>returnval>rootFolder"`
+}
+
+// login starts a session, and creates a view of the virtual machines to
+// retrieve their properties through. v.lock must be held.
+func (v *VSphere) login() error {
+	content := &serviceContent{}
+	err := v.call(`<RetrieveServiceContent xmlns="urn:vim25"><_this type="ServiceInstance">ServiceInstance</_this></RetrieveServiceContent>`, content)
+	if err != nil {
+		return err
+	}
+	err = v.call(fmt.Sprintf(`<Login xmlns="urn:vim25"><_this type="SessionManager">%s</_this><userName>%s</userName><password>%s</password></Login>`,
+		escape(content.SessionManager), escape(v.cfg.Global.Username), escape(v.cfg.Global.Password)), &struct{}{})
+	if err != nil {
+		return fmt.Errorf("Logging in to vSphere failed: %v", err)
+	}
+	var view struct {
+		View string `xml:"Body>CreateContainerViewResponse>returnval"`
+	}
+	err = v.call(fmt.Sprintf(`<CreateContainerView xmlns="urn:vim25"><_this type="ViewManager">%s</_this><container type="Folder">%s</container><type>VirtualMachine</type><recursive>true</recursive></CreateContainerView>`,
+		escape(content.ViewManager), escape(content.RootFolder)), &view)
+	if err != nil {
+		return err
+	}
+	v.content = content
+	v.view = view.View
+	return nil
+}
+
+// virtualMachine holds the properties of a virtual machine which we use.
+type virtualMachine struct {
+	Name      string
+	IPAddress string
+	PoweredOn bool
+	NumCPU    int
+	MemoryMB  int
+}
+
+type retrievePropertiesResponse struct {
+	Objects []struct {
+		Props []struct {
+			Name  string `xml:"name"`
+			Value string `xml:"val"`
+		} `xml:"propSet"`
+	} `xml:"Body>RetrievePropertiesResponse>returnval"`
+}
+
+// listVirtualMachines returns all the virtual machines, logging in first if we
+// haven't, and again if our session has expired.
+func (v *VSphere) listVirtualMachines() ([]virtualMachine, error) {
+	for attempt := 0; ; attempt++ {
+		v.lock.Lock()
+		if v.content == nil {
+			if err := v.login(); err != nil {
+				v.lock.Unlock()
+				return nil, err
+			}
+		}
+		content, view := v.content, v.view
+		v.lock.Unlock()
+
+		resp := &retrievePropertiesResponse{}
+		err := v.call(fmt.Sprintf(`<RetrieveProperties xmlns="urn:vim25"><_this type="PropertyCollector">%s</_this><specSet>`+
+			`<propSet><type>VirtualMachine</type><pathSet>name</pathSet><pathSet>guest.ipAddress</pathSet><pathSet>runtime.powerState</pathSet>`+
+			`<pathSet>summary.config.numCpu</pathSet><pathSet>summary.config.memorySizeMB</pathSet></propSet>`+
+			`<objectSet><obj type="ContainerView">%s</obj><skip>true</skip>`+
+			`<selectSet xsi:type="TraversalSpec"><type>ContainerView</type><path>view</path><skip>false</skip></selectSet></objectSet>`+
+			`</specSet></RetrieveProperties>`, escape(content.PropertyCollector), escape(view)), resp)
+		if fault, ok := err.(*soapFault); ok && fault.isNotAuthenticated() && attempt == 0 {
+			v.lock.Lock()
+			if v.content == content {
+				v.content = nil
+			}
+			v.lock.Unlock()
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		vms := []virtualMachine{}
+		for _, obj := range resp.Objects {
+			vm := virtualMachine{}
+			for _, prop := range obj.Props {
+				switch prop.Name {
+				case "name":
+					vm.Name = prop.Value
+				case "guest.ipAddress":
+					vm.IPAddress = prop.Value
+				case "runtime.powerState":
+					vm.PoweredOn = prop.Value == "poweredOn"
+				case "summary.config.numCpu":
+					fmt.Sscan(prop.Value, &vm.NumCPU)
+				case "summary.config.memorySizeMB":
+					fmt.Sscan(prop.Value, &vm.MemoryMB)
+				}
+			}
+			vms = append(vms, vm)
+		}
+		return vms, nil
+	}
+}
+
+// getVirtualMachine returns the virtual machine with the given name.
+func (v *VSphere) getVirtualMachine(name string) (*virtualMachine, error) {
+	vms, err := v.listVirtualMachines()
+	if err != nil {
+		return nil, err
+	}
+	var found *virtualMachine
+	for i := range vms {
+		if vms[i].Name != name {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("Multiple virtual machines found for host: %s", name)
+		}
+		found = &vms[i]
+	}
+	if found == nil {
+		return nil, fmt.Errorf("No virtual machine found for host: %s", name)
+	}
+	return found, nil
+}
+
+// IPAddress is an implementation of Instances.IPAddress. It returns the
+// primary IP address reported by VMware Tools in the guest.
+func (v *VSphere) IPAddress(name string) (net.IP, error) {
+	vm, err := v.getVirtualMachine(name)
+	if err != nil {
+		return nil, err
+	}
+	if vm.IPAddress == "" {
+		return nil, fmt.Errorf("No IP address reported for host: %s", name)
+	}
+	ip := net.ParseIP(vm.IPAddress)
+	if ip == nil {
+		return nil, fmt.Errorf("Invalid network IP: %s", vm.IPAddress)
+	}
+	return ip, nil
+}
+
+// List is an implementation of Instances.List. Only powered on virtual machines are listed.
+func (v *VSphere) List(filter string) ([]string, error) {
+	re, err := regexp.Compile("^(?:" + filter + ")$")
+	if err != nil {
+		return nil, err
+	}
+	vms, err := v.listVirtualMachines()
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, vm := range vms {
+		if vm.PoweredOn && re.MatchString(vm.Name) {
+			names = append(names, vm.Name)
+		}
+	}
+	return names, nil
+}
+
+// GetNodeResources is an implementation of Instances.GetNodeResources.
+func (v *VSphere) GetNodeResources(name string) (*api.NodeResources, error) {
+	vm, err := v.getVirtualMachine(name)
+	if err != nil {
+		return nil, err
+	}
+	return &api.NodeResources{
+		Capacity: api.ResourceList{
+			api.ResourceCPU:    util.NewIntOrStringFromInt(vm.NumCPU * 1000),
+			api.ResourceMemory: util.NewIntOrStringFromInt(vm.MemoryMB * 1024 * 1024),
+		},
+	}, nil
+}
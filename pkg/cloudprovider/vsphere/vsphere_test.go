@@ -0,0 +1,258 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vsphere_cloud
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+)
+
+// fakeVCenter serves the SOAP calls the provider makes.
+type fakeVCenter struct {
+	t      *testing.T
+	server *httptest.Server
+	// vms holds the property sets of the virtual machines, as XML.
+	vms []string
+	// session is the current session cookie, if a client has logged in.
+	session string
+	logins  int
+	calls   []string
+}
+
+var operation = regexp.MustCompile(`<(\w+) xmlns="urn:vim25">`)
+
+func envelope(body string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?><soapenv:Envelope xmlns:soapenv="http://schemas.xmlsoap.org/soap/envelope/" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"><soapenv:Body>` +
+		body + `</soapenv:Body></soapenv:Envelope>`
+}
+
+func fault(detail string) string {
+	return envelope(`<soapenv:Fault><faultcode>ServerFaultCode</faultcode><faultstring>failed</faultstring><detail><` + detail + ` xmlns="urn:vim25"/></detail></soapenv:Fault>`)
+}
+
+func newFakeVCenter(t *testing.T) *fakeVCenter {
+	f := &fakeVCenter{t: t}
+	f.server = httptest.NewServer(f)
+	return f
+}
+
+func (f *fakeVCenter) addVM(name, ip, powerState string, cpus, memoryMB int) {
+	f.vms = append(f.vms, fmt.Sprintf(`<returnval><obj type="VirtualMachine">vm-%d</obj>`+
+		`<propSet><name>name</name><val xsi:type="xsd:string">%s</val></propSet>`+
+		`<propSet><name>guest.ipAddress</name><val xsi:type="xsd:string">%s</val></propSet>`+
+		`<propSet><name>runtime.powerState</name><val xsi:type="VirtualMachinePowerState">%s</val></propSet>`+
+		`<propSet><name>summary.config.numCpu</name><val xsi:type="xsd:int">%d</val></propSet>`+
+		`<propSet><name>summary.config.memorySizeMB</name><val xsi:type="xsd:int">%d</val></propSet>`+
+		`</returnval>`, len(f.vms), name, ip, powerState, cpus, memoryMB))
+}
+
+func (f *fakeVCenter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := ioutil.ReadAll(req.Body)
+	match := operation.FindStringSubmatch(string(body))
+	if match == nil {
+		f.t.Errorf("Unexpected request: %s", body)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	op := match[1]
+	f.calls = append(f.calls, op)
+	authenticated := false
+	if cookie, err := req.Cookie("vmware_soap_session"); err == nil && cookie.Value == f.session {
+		authenticated = true
+	}
+
+	switch op {
+	case "RetrieveServiceContent":
+		w.Write([]byte(envelope(`<RetrieveServiceContentResponse xmlns="urn:vim25"><returnval>` +
+			`<rootFolder type="Folder">group-d1</rootFolder><propertyCollector type="PropertyCollector">propertyCollector</propertyCollector>` +
+			`<viewManager type="ViewManager">ViewManager</viewManager><sessionManager type="SessionManager">SessionManager</sessionManager>` +
+			`</returnval></RetrieveServiceContentResponse>`)))
+	case "Login":
+		if !strings.Contains(string(body), "<userName>user</userName><password>s&amp;cret</password>") {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(fault("InvalidLogin")))
+			return
+		}
+		f.logins++
+		f.session = fmt.Sprintf("session-%d", f.logins)
+		http.SetCookie(w, &http.Cookie{Name: "vmware_soap_session", Value: f.session})
+		w.Write([]byte(envelope(`<LoginResponse xmlns="urn:vim25"><returnval><key>session</key></returnval></LoginResponse>`)))
+	case "CreateContainerView":
+		if !authenticated {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(fault("NotAuthenticatedFault")))
+			return
+		}
+		w.Write([]byte(envelope(`<CreateContainerViewResponse xmlns="urn:vim25"><returnval type="ContainerView">session[1]view</returnval></CreateContainerViewResponse>`)))
+	case "RetrieveProperties":
+		if !authenticated {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(fault("NotAuthenticatedFault")))
+			return
+		}
+		w.Write([]byte(envelope(`<RetrievePropertiesResponse xmlns="urn:vim25">` + strings.Join(f.vms, "") + `</RetrievePropertiesResponse>`)))
+	default:
+		f.t.Errorf("Unexpected operation %s", op)
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func (f *fakeVCenter) newVSphere() *VSphere {
+	cfg := &VSphereConfig{}
+	cfg.Global.URL = f.server.URL + "/sdk"
+	cfg.Global.Username = "user"
+	cfg.Global.Password = "s&cret"
+	cfg.Global.Datacenter = "dc1"
+	cfg.Global.Cluster = "cluster1"
+	return newVSphere(cfg)
+}
+
+func TestReadConfig(t *testing.T) {
+	if _, err := readConfig(nil); err == nil {
+		t.Errorf("Should error when no config reader is given")
+	}
+	if _, err := readConfig(strings.NewReader("[global]\nusername = user\n")); err == nil {
+		t.Errorf("Should error when no url is specified")
+	}
+	cfg, err := readConfig(strings.NewReader("[global]\nurl = https://vcenter/sdk\nusername = user\ninsecure = true\ndatacenter = dc1\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !cfg.Global.Insecure || cfg.Global.Datacenter != "dc1" {
+		t.Errorf("Unexpected config: %#v", cfg)
+	}
+}
+
+func TestList(t *testing.T) {
+	f := newFakeVCenter(t)
+	defer f.server.Close()
+	f.addVM("minion-1", "10.0.0.1", "poweredOn", 1, 1024)
+	f.addVM("minion-2", "10.0.0.2", "poweredOff", 1, 1024)
+	f.addVM("minion-3", "10.0.0.3", "poweredOn", 1, 1024)
+	f.addVM("master", "10.0.0.4", "poweredOn", 1, 1024)
+	v := f.newVSphere()
+
+	names, err := v.List("minion-.*")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if e, a := []string{"minion-1", "minion-3"}, names; !reflect.DeepEqual(e, a) {
+		t.Errorf("Expected %v, got %v", e, a)
+	}
+	// The session and view are reused.
+	if _, err := v.List("minion-.*"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []string{"RetrieveServiceContent", "Login", "CreateContainerView", "RetrieveProperties", "RetrieveProperties"}
+	if !reflect.DeepEqual(expected, f.calls) {
+		t.Errorf("Expected %v, got %v", expected, f.calls)
+	}
+}
+
+func TestIPAddress(t *testing.T) {
+	f := newFakeVCenter(t)
+	defer f.server.Close()
+	f.addVM("minion-1", "10.0.0.1", "poweredOn", 1, 1024)
+	f.addVM("minion-2", "", "poweredOn", 1, 1024)
+	v := f.newVSphere()
+
+	ip, err := v.IPAddress("minion-1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if ip.String() != "10.0.0.1" {
+		t.Errorf("Expected 10.0.0.1, got %v", ip)
+	}
+	if _, err := v.IPAddress("minion-2"); err == nil {
+		t.Errorf("Should error when the guest reports no IP")
+	}
+	if _, err := v.IPAddress("minion-3"); err == nil {
+		t.Errorf("Should error when there is no such virtual machine")
+	}
+}
+
+func TestGetNodeResources(t *testing.T) {
+	f := newFakeVCenter(t)
+	defer f.server.Close()
+	f.addVM("minion-1", "10.0.0.1", "poweredOn", 2, 4096)
+	v := f.newVSphere()
+
+	resources, err := v.GetNodeResources("minion-1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := &api.NodeResources{
+		Capacity: api.ResourceList{
+			api.ResourceCPU:    util.NewIntOrStringFromInt(2000),
+			api.ResourceMemory: util.NewIntOrStringFromInt(4096 * 1024 * 1024),
+		},
+	}
+	if !reflect.DeepEqual(expected, resources) {
+		t.Errorf("Expected %#v, got %#v", expected, resources)
+	}
+}
+
+func TestLogsInAgainWhenSessionExpires(t *testing.T) {
+	f := newFakeVCenter(t)
+	defer f.server.Close()
+	f.addVM("minion-1", "10.0.0.1", "poweredOn", 1, 1024)
+	v := f.newVSphere()
+
+	if _, err := v.List(".*"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	f.session = "expired"
+	names, err := v.List(".*")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(names) != 1 || f.logins != 2 {
+		t.Errorf("Expected to log in again, got %v after %d logins", names, f.logins)
+	}
+}
+
+func TestBadCredentials(t *testing.T) {
+	f := newFakeVCenter(t)
+	defer f.server.Close()
+	v := f.newVSphere()
+	v.cfg.Global.Password = "wrong"
+
+	if _, err := v.List(".*"); err == nil {
+		t.Errorf("Should error when logging in fails")
+	}
+}
+
+func TestGetZone(t *testing.T) {
+	f := newFakeVCenter(t)
+	defer f.server.Close()
+	zone, err := f.newVSphere().GetZone()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if zone.Region != "dc1" || zone.FailureDomain != "cluster1" {
+		t.Errorf("Unexpected zone: %#v", zone)
+	}
+}
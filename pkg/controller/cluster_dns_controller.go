@@ -0,0 +1,109 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/dnsprovider"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/golang/glog"
+)
+
+// ClusterDNSController publishes the portal IP of every service as an A
+// record named <service>.<domain>, so that pods can find services by name
+// instead of through environment variables fixed when they started.
+type ClusterDNSController struct {
+	kubeClient client.Interface
+	dns        dnsprovider.Interface
+	domain     string
+
+	lock sync.Mutex
+	// Maps a service to the IP last published for it.
+	published map[string]string
+}
+
+// NewClusterDNSController creates a new ClusterDNSController.
+func NewClusterDNSController(kubeClient client.Interface, dns dnsprovider.Interface, domain string) *ClusterDNSController {
+	return &ClusterDNSController{
+		kubeClient: kubeClient,
+		dns:        dns,
+		domain:     domain,
+		published:  map[string]string{},
+	}
+}
+
+// Run begins publishing service records every period.
+func (dc *ClusterDNSController) Run(period time.Duration) {
+	go util.Forever(func() {
+		if err := dc.Sync(); err != nil {
+			glog.Errorf("Error publishing cluster DNS records: %v", err)
+		}
+	}, period)
+}
+
+// Sync publishes records for services whose portal IP has changed since it
+// was last published, and deletes the records of services that are gone.
+func (dc *ClusterDNSController) Sync() error {
+	services, err := dc.kubeClient.ListServices(labels.Everything())
+	if err != nil {
+		return err
+	}
+	dc.lock.Lock()
+	defer dc.lock.Unlock()
+	current := util.StringSet{}
+	for _, service := range services.Items {
+		if len(service.PortalIP) == 0 {
+			continue
+		}
+		current.Insert(service.ID)
+		if dc.published[service.ID] == service.PortalIP {
+			continue
+		}
+		ip := net.ParseIP(service.PortalIP)
+		if ip == nil {
+			glog.Errorf("Service %s has an invalid portal IP %q", service.ID, service.PortalIP)
+			continue
+		}
+		if err := dc.dns.EnsureRecord(dc.domain, dc.recordName(service.ID), ip); err != nil {
+			glog.Errorf("Error publishing %s for service %s: %v", ip, service.ID, err)
+			continue
+		}
+		glog.Infof("Published %s for service %s", ip, service.ID)
+		dc.published[service.ID] = service.PortalIP
+	}
+	for id := range dc.published {
+		if current.Has(id) {
+			continue
+		}
+		if err := dc.dns.DeleteRecord(dc.domain, dc.recordName(id)); err != nil {
+			glog.Errorf("Error deleting the record for service %s: %v", id, err)
+			continue
+		}
+		glog.Infof("Deleted the record for service %s", id)
+		delete(dc.published, id)
+	}
+	return nil
+}
+
+func (dc *ClusterDNSController) recordName(service string) string {
+	return service + "." + dc.domain
+}
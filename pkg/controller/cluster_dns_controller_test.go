@@ -0,0 +1,78 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	fake_dns "github.com/GoogleCloudPlatform/kubernetes/pkg/dnsprovider/fake"
+)
+
+func makePortalService(id, portalIP string) api.Service {
+	return api.Service{
+		JSONBase: api.JSONBase{ID: id},
+		PortalIP: portalIP,
+	}
+}
+
+func TestClusterDNSControllerPublishesServices(t *testing.T) {
+	fakeClient := &client.Fake{}
+	fakeClient.ServiceList.Items = []api.Service{makePortalService("foo", "10.0.0.1"), makePortalService("bar", "")}
+	dns := &fake_dns.FakeDNS{}
+	dc := NewClusterDNSController(fakeClient, dns, "kubernetes.local")
+
+	if err := dc.Sync(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := map[string]net.IP{
+		"foo.kubernetes.local in kubernetes.local": net.ParseIP("10.0.0.1"),
+	}
+	if !reflect.DeepEqual(dns.Records, expected) {
+		t.Errorf("Expected records %v, got %v", expected, dns.Records)
+	}
+
+	// Unchanged IPs are not published again.
+	dns.ClearCalls()
+	if err := dc.Sync(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(dns.Calls) != 0 {
+		t.Errorf("Unexpected calls: %v", dns.Calls)
+	}
+}
+
+func TestClusterDNSControllerDeletesRemovedServices(t *testing.T) {
+	fakeClient := &client.Fake{}
+	fakeClient.ServiceList.Items = []api.Service{makePortalService("foo", "10.0.0.1")}
+	dns := &fake_dns.FakeDNS{}
+	dc := NewClusterDNSController(fakeClient, dns, "kubernetes.local")
+	if err := dc.Sync(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	fakeClient.ServiceList.Items = nil
+	if err := dc.Sync(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(dns.Records) != 0 {
+		t.Errorf("Expected no records, got %v", dns.Records)
+	}
+}
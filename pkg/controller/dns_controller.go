@@ -0,0 +1,146 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/cloudprovider"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/dnsprovider"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/golang/glog"
+)
+
+// ServiceDNSController publishes the external load balancer IP of every
+// service that asks for one as an A record named <service>.<zone> in each of
+// a set of DNS zones, so that externally exposed services get stable names.
+// Each sync compares the records in the zones with the services, so the
+// zones' records of the form <name>.<zone> must be left to the controller.
+type ServiceDNSController struct {
+	kubeClient client.Interface
+	balancer   cloudprovider.TCPLoadBalancer
+	region     string
+	dns        dnsprovider.Interface
+	zones      []string
+
+	lock sync.Mutex
+}
+
+// NewServiceDNSController creates a new ServiceDNSController.  The cloud
+// provider must support TCP load balancers and zones.
+func NewServiceDNSController(kubeClient client.Interface, cloud cloudprovider.Interface, dns dnsprovider.Interface, zones []string) (*ServiceDNSController, error) {
+	balancer, ok := cloud.TCPLoadBalancer()
+	if !ok {
+		return nil, fmt.Errorf("the cloud provider does not support external TCP load balancers")
+	}
+	cloudZones, ok := cloud.Zones()
+	if !ok {
+		return nil, fmt.Errorf("the cloud provider does not support zone enumeration")
+	}
+	zone, err := cloudZones.GetZone()
+	if err != nil {
+		return nil, err
+	}
+	return &ServiceDNSController{
+		kubeClient: kubeClient,
+		balancer:   balancer,
+		region:     zone.Region,
+		dns:        dns,
+		zones:      zones,
+	}, nil
+}
+
+// Run begins publishing service records every period.
+func (dc *ServiceDNSController) Run(period time.Duration) {
+	go util.Forever(func() {
+		if err := dc.Sync(); err != nil {
+			glog.Errorf("Error publishing service records: %v", err)
+		}
+	}, period)
+}
+
+// Sync publishes records for services with an external load balancer whose
+// record is missing or out of date, and deletes the records of services that
+// no longer have one.  Records of services whose load balancer has no IP yet
+// are left alone.
+func (dc *ServiceDNSController) Sync() error {
+	services, err := dc.kubeClient.ListServices(labels.Everything())
+	if err != nil {
+		return err
+	}
+	dc.lock.Lock()
+	defer dc.lock.Unlock()
+	external := util.StringSet{}
+	ips := map[string]net.IP{}
+	for _, service := range services.Items {
+		if !service.CreateExternalLoadBalancer {
+			continue
+		}
+		external.Insert(service.ID)
+		ip, err := dc.balancer.TCPLoadBalancerIP(service.ID, dc.region)
+		if err != nil || ip == nil {
+			// The load balancer may still be being created.
+			glog.Infof("No external IP for service %s yet: %v", service.ID, err)
+			continue
+		}
+		ips[service.ID] = ip
+	}
+	for _, zone := range dc.zones {
+		if err := dc.syncZone(zone, external, ips); err != nil {
+			glog.Errorf("Error listing the records in %s: %v", zone, err)
+		}
+	}
+	return nil
+}
+
+// syncZone brings the records in zone up to date with the IPs of the
+// external services.
+func (dc *ServiceDNSController) syncZone(zone string, external util.StringSet, ips map[string]net.IP) error {
+	records, err := dc.dns.ListRecords(zone)
+	if err != nil {
+		return err
+	}
+	for id, ip := range ips {
+		name := id + "." + zone
+		if records[name].Equal(ip) {
+			continue
+		}
+		if err := dc.dns.EnsureRecord(zone, name, ip); err != nil {
+			glog.Errorf("Error publishing %s for service %s in %s: %v", ip, id, zone, err)
+			continue
+		}
+		glog.Infof("Published %s for service %s in %s", ip, id, zone)
+	}
+	for name := range records {
+		id := strings.TrimSuffix(name, "."+zone)
+		if id == name || strings.Contains(id, ".") || external.Has(id) {
+			continue
+		}
+		if err := dc.dns.DeleteRecord(zone, name); err != nil {
+			glog.Errorf("Error deleting the record for service %s in %s: %v", id, zone, err)
+			continue
+		}
+		glog.Infof("Deleted the record for service %s in %s", id, zone)
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	fake_cloud "github.com/GoogleCloudPlatform/kubernetes/pkg/cloudprovider/fake"
+	fake_dns "github.com/GoogleCloudPlatform/kubernetes/pkg/dnsprovider/fake"
+)
+
+func makeService(id string, external bool) api.Service {
+	return api.Service{
+		JSONBase:                   api.JSONBase{ID: id},
+		CreateExternalLoadBalancer: external,
+	}
+}
+
+func TestServiceDNSControllerPublishesExternalServices(t *testing.T) {
+	fakeClient := &client.Fake{}
+	fakeClient.ServiceList.Items = []api.Service{makeService("foo", true), makeService("bar", false)}
+	cloud := &fake_cloud.FakeCloud{ExternalIP: net.ParseIP("1.2.3.4")}
+	dns := &fake_dns.FakeDNS{}
+	dc, err := NewServiceDNSController(fakeClient, cloud, dns, []string{"example.com", "example.org"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if err := dc.Sync(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := map[string]net.IP{
+		"foo.example.com in example.com": cloud.ExternalIP,
+		"foo.example.org in example.org": cloud.ExternalIP,
+	}
+	if !reflect.DeepEqual(dns.Records, expected) {
+		t.Errorf("Expected records %v, got %v", expected, dns.Records)
+	}
+
+	// Unchanged IPs are not published again.
+	dns.ClearCalls()
+	if err := dc.Sync(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(dns.Calls, []string{"list", "list"}) {
+		t.Errorf("Unexpected calls: %v", dns.Calls)
+	}
+
+	// A new IP is.
+	cloud.ExternalIP = net.ParseIP("5.6.7.8")
+	if err := dc.Sync(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if ip := dns.Records["foo.example.com in example.com"]; !ip.Equal(cloud.ExternalIP) {
+		t.Errorf("Expected %v, got %v", cloud.ExternalIP, ip)
+	}
+}
+
+func TestServiceDNSControllerDeletesRemovedServices(t *testing.T) {
+	fakeClient := &client.Fake{}
+	fakeClient.ServiceList.Items = []api.Service{makeService("foo", true)}
+	cloud := &fake_cloud.FakeCloud{ExternalIP: net.ParseIP("1.2.3.4")}
+	dns := &fake_dns.FakeDNS{}
+	dc, err := NewServiceDNSController(fakeClient, cloud, dns, []string{"example.com"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := dc.Sync(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	fakeClient.ServiceList.Items = nil
+	if err := dc.Sync(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(dns.Records) != 0 {
+		t.Errorf("Expected no records, got %v", dns.Records)
+	}
+	if !reflect.DeepEqual(dns.Calls, []string{"list", "ensure", "list", "delete"}) {
+		t.Errorf("Unexpected calls: %v", dns.Calls)
+	}
+}
+
+func TestServiceDNSControllerRetriesFailedPublish(t *testing.T) {
+	fakeClient := &client.Fake{}
+	fakeClient.ServiceList.Items = []api.Service{makeService("foo", true)}
+	cloud := &fake_cloud.FakeCloud{ExternalIP: net.ParseIP("1.2.3.4")}
+	dns := &fake_dns.FakeDNS{Err: errors.New("dns is down")}
+	dc, err := NewServiceDNSController(fakeClient, cloud, dns, []string{"example.com"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := dc.Sync(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	dns.Err = nil
+	if err := dc.Sync(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if ip := dns.Records["foo.example.com in example.com"]; !ip.Equal(cloud.ExternalIP) {
+		t.Errorf("Expected %v, got %v", cloud.ExternalIP, ip)
+	}
+}
+
+func TestServiceDNSControllerSkipsServicesWithoutIP(t *testing.T) {
+	fakeClient := &client.Fake{}
+	fakeClient.ServiceList.Items = []api.Service{makeService("foo", true)}
+	dns := &fake_dns.FakeDNS{}
+	dc, err := NewServiceDNSController(fakeClient, &fake_cloud.FakeCloud{}, dns, []string{"example.com"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := dc.Sync(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(dns.Calls, []string{"list"}) {
+		t.Errorf("Unexpected calls: %v", dns.Calls)
+	}
+}
+
+func TestServiceDNSControllerReconcilesExistingRecords(t *testing.T) {
+	fakeClient := &client.Fake{}
+	fakeClient.ServiceList.Items = []api.Service{makeService("foo", true), makeService("baz", true)}
+	cloud := &fake_cloud.FakeCloud{ExternalIP: net.ParseIP("1.2.3.4")}
+	dns := &fake_dns.FakeDNS{
+		Records: map[string]net.IP{
+			// Published before a restart.
+			"foo.example.com in example.com": cloud.ExternalIP,
+			// Left behind by a service deleted while the controller was down.
+			"bar.example.com in example.com": net.ParseIP("5.6.7.8"),
+			// Out of date.
+			"baz.example.com in example.com": net.ParseIP("5.6.7.8"),
+			// Not of the form <service>.<zone>.
+			"www.foo.example.com in example.com": net.ParseIP("5.6.7.8"),
+			// In another zone.
+			"bar.example.org in example.org": net.ParseIP("5.6.7.8"),
+		},
+	}
+	dc, err := NewServiceDNSController(fakeClient, cloud, dns, []string{"example.com"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := dc.Sync(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := map[string]net.IP{
+		"foo.example.com in example.com":     cloud.ExternalIP,
+		"baz.example.com in example.com":     cloud.ExternalIP,
+		"www.foo.example.com in example.com": net.ParseIP("5.6.7.8"),
+		"bar.example.org in example.org":     net.ParseIP("5.6.7.8"),
+	}
+	if !reflect.DeepEqual(dns.Records, expected) {
+		t.Errorf("Expected records %v, got %v", expected, dns.Records)
+	}
+	if !reflect.DeepEqual(dns.Calls, []string{"list", "ensure", "delete"}) {
+		t.Errorf("Unexpected calls: %v", dns.Calls)
+	}
+}
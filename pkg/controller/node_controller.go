@@ -0,0 +1,125 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"sync"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/golang/glog"
+)
+
+// NodeController deletes pods that are bound to minions which are no longer
+// part of the cluster, so that their replication controllers can recreate
+// them on a minion that is.
+type NodeController struct {
+	kubeClient client.Interface
+	// A minion must be missing from the minion list for at least this long
+	// before its pods are deleted, so that a single failed health check does
+	// not cause every pod on the minion to be rescheduled.
+	gracePeriod time.Duration
+	// now is overridable for testing.
+	now func() time.Time
+
+	lock sync.Mutex
+	// Maps a host to the first time it was seen missing from the minion list.
+	missingSince map[string]time.Time
+}
+
+// NewNodeController creates a new NodeController.
+func NewNodeController(kubeClient client.Interface, gracePeriod time.Duration) *NodeController {
+	return &NodeController{
+		kubeClient:   kubeClient,
+		gracePeriod:  gracePeriod,
+		now:          time.Now,
+		missingSince: map[string]time.Time{},
+	}
+}
+
+// Run begins syncing pods against the minion list every period. If watchdog
+// is not nil, it times each sync.
+func (nc *NodeController) Run(period time.Duration, watchdog *util.Watchdog) {
+	go util.Forever(func() {
+		watchdog.Do(func() {
+			if err := nc.Sync(); err != nil {
+				glog.Errorf("Error syncing pods with minions: %v", err)
+			}
+		})
+	}, period)
+}
+
+// Sync deletes the pods bound to hosts that have been missing from the
+// minion list for longer than the grace period.
+func (nc *NodeController) Sync() error {
+	minions, err := nc.kubeClient.ListMinions()
+	if err != nil {
+		return err
+	}
+	if len(minions.Items) == 0 {
+		// An empty minion list is far more likely to be a problem with the
+		// health checks than a cluster with no machines; don't empty it.
+		glog.Warningf("No minions found, not deleting any pods")
+		return nil
+	}
+	pods, err := nc.kubeClient.ListPods(labels.Everything())
+	if err != nil {
+		return err
+	}
+
+	alive := util.StringSet{}
+	for _, minion := range minions.Items {
+		alive.Insert(minion.ID)
+	}
+
+	nc.lock.Lock()
+	defer nc.lock.Unlock()
+	now := nc.now()
+	missing := map[string]time.Time{}
+	for _, pod := range pods.Items {
+		host := podHost(&pod)
+		if host == "" || alive.Has(host) {
+			continue
+		}
+		since, ok := nc.missingSince[host]
+		if !ok {
+			since = now
+		}
+		missing[host] = since
+		if now.Sub(since) < nc.gracePeriod {
+			continue
+		}
+		glog.Infof("Deleting pod %s bound to missing minion %s", pod.ID, host)
+		if err := nc.kubeClient.DeletePod(pod.ID); err != nil {
+			glog.Errorf("Failed to delete pod %s: %v", pod.ID, err)
+		}
+	}
+	// Forget about hosts that came back or no longer have any pods.
+	nc.missingSince = missing
+	return nil
+}
+
+// podHost returns the minion the pod is bound to, or "" if it is unscheduled.
+func podHost(pod *api.Pod) string {
+	if pod.DesiredState.Host != "" {
+		return pod.DesiredState.Host
+	}
+	return pod.CurrentState.Host
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+)
+
+func makeNodeControllerClient(minions []string, podHosts map[string]string) *client.Fake {
+	fake := &client.Fake{}
+	for _, minion := range minions {
+		fake.MinionList.Items = append(fake.MinionList.Items, api.Minion{JSONBase: api.JSONBase{ID: minion}})
+	}
+	for id, host := range podHosts {
+		pod := api.Pod{JSONBase: api.JSONBase{ID: id}}
+		pod.DesiredState.Host = host
+		fake.PodList.Items = append(fake.PodList.Items, pod)
+	}
+	return fake
+}
+
+func deletedPods(fake *client.Fake) []string {
+	deleted := []string{}
+	for _, action := range fake.Actions {
+		if action.Action == "delete-pod" {
+			deleted = append(deleted, action.Value.(string))
+		}
+	}
+	return deleted
+}
+
+func TestNodeControllerDeletesPodsOnMissingMinions(t *testing.T) {
+	fake := makeNodeControllerClient([]string{"m1"}, map[string]string{
+		"foo": "m1",
+		"bar": "m2",
+		"baz": "",
+	})
+	controller := NewNodeController(fake, 0)
+	if err := controller.Sync(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if deleted := deletedPods(fake); !reflect.DeepEqual(deleted, []string{"bar"}) {
+		t.Errorf("expected only bar to be deleted, got %v", deleted)
+	}
+}
+
+func TestNodeControllerGracePeriod(t *testing.T) {
+	fake := makeNodeControllerClient([]string{"m1"}, map[string]string{"bar": "m2"})
+	controller := NewNodeController(fake, time.Minute)
+	now := time.Unix(1000, 0)
+	controller.now = func() time.Time { return now }
+
+	if err := controller.Sync(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if deleted := deletedPods(fake); len(deleted) != 0 {
+		t.Errorf("expected no deletions within the grace period, got %v", deleted)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if err := controller.Sync(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if deleted := deletedPods(fake); !reflect.DeepEqual(deleted, []string{"bar"}) {
+		t.Errorf("expected bar to be deleted, got %v", deleted)
+	}
+}
+
+func TestNodeControllerForgetsReturningMinions(t *testing.T) {
+	fake := makeNodeControllerClient([]string{"m1"}, map[string]string{"bar": "m2"})
+	controller := NewNodeController(fake, time.Minute)
+	now := time.Unix(1000, 0)
+	controller.now = func() time.Time { return now }
+	if err := controller.Sync(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// m2 comes back, then disappears again; the grace period starts over.
+	fake.MinionList.Items = append(fake.MinionList.Items, api.Minion{JSONBase: api.JSONBase{ID: "m2"}})
+	now = now.Add(30 * time.Second)
+	if err := controller.Sync(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fake.MinionList.Items = fake.MinionList.Items[:1]
+	now = now.Add(45 * time.Second)
+	if err := controller.Sync(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if deleted := deletedPods(fake); len(deleted) != 0 {
+		t.Errorf("expected no deletions, got %v", deleted)
+	}
+}
+
+func TestNodeControllerNoMinions(t *testing.T) {
+	fake := makeNodeControllerClient(nil, map[string]string{"bar": "m2"})
+	controller := NewNodeController(fake, 0)
+	if err := controller.Sync(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if deleted := deletedPods(fake); len(deleted) != 0 {
+		t.Errorf("expected no deletions with an empty minion list, got %v", deleted)
+	}
+}
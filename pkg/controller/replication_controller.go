@@ -34,6 +34,15 @@ type ReplicationManager struct {
 	podControl PodControlInterface
 	syncTime   <-chan time.Time
 
+	// shard selects the controllers this manager is responsible for, so that
+	// several managers can split the controllers between them.
+	shard util.Shard
+	// workers bounds the number of controllers synced at once; zero means
+	// no bound.
+	workers int
+	// watchdog times each sync, if set.
+	watchdog *util.Watchdog
+
 	// To allow injection of syncReplicationController for testing.
 	syncHandler func(controllerSpec api.ReplicationController) error
 }
@@ -59,8 +68,11 @@ func (r RealPodControl) createReplica(controllerSpec api.ReplicationController)
 		labels["replicationController"] = controllerSpec.ID
 	}
 	pod := &api.Pod{
-		DesiredState: controllerSpec.DesiredState.PodTemplate.DesiredState,
-		Labels:       controllerSpec.DesiredState.PodTemplate.Labels,
+		DesiredState:         controllerSpec.DesiredState.PodTemplate.DesiredState,
+		Labels:               controllerSpec.DesiredState.PodTemplate.Labels,
+		NodeSelector:         controllerSpec.DesiredState.PodTemplate.NodeSelector,
+		AntiAffinitySelector: controllerSpec.DesiredState.PodTemplate.AntiAffinitySelector,
+		Platforms:            controllerSpec.DesiredState.PodTemplate.Platforms,
 	}
 	_, err := r.kubeClient.CreatePod(pod)
 	if err != nil {
@@ -74,18 +86,27 @@ func (r RealPodControl) deletePod(podID string) error {
 
 // NewReplicationManager creates a new ReplicationManager.
 func NewReplicationManager(kubeClient client.Interface) *ReplicationManager {
+	return NewShardedReplicationManager(kubeClient, util.Shard{}, 0)
+}
+
+// NewShardedReplicationManager creates a ReplicationManager that only syncs
+// the controllers in shard, using at most workers goroutines at a time.
+func NewShardedReplicationManager(kubeClient client.Interface, shard util.Shard, workers int) *ReplicationManager {
 	rm := &ReplicationManager{
 		kubeClient: kubeClient,
 		podControl: RealPodControl{
 			kubeClient: kubeClient,
 		},
+		shard:   shard,
+		workers: workers,
 	}
 	rm.syncHandler = rm.syncReplicationController
 	return rm
 }
 
-// Run begins watching and syncing.
-func (rm *ReplicationManager) Run(period time.Duration) {
+// Run begins watching and syncing. If watchdog is not nil, it times each sync.
+func (rm *ReplicationManager) Run(period time.Duration, watchdog *util.Watchdog) {
+	rm.watchdog = watchdog
 	rm.syncTime = time.Tick(period)
 	resourceVersion := uint64(0)
 	go util.Forever(func() { rm.watchControllers(&resourceVersion) }, period)
@@ -107,7 +128,7 @@ func (rm *ReplicationManager) watchControllers(resourceVersion *uint64) {
 	for {
 		select {
 		case <-rm.syncTime:
-			rm.synchronize()
+			rm.watchdog.Do(rm.synchronize)
 		case event, open := <-watching.ResultChan():
 			if !open {
 				// watchChannel has been closed, or something else went
@@ -122,11 +143,14 @@ func (rm *ReplicationManager) watchControllers(resourceVersion *uint64) {
 				continue
 			}
 			// If we get disconnected, start where we left off.
-			*resourceVersion = rc.ResourceVersion + 1
+			*resourceVersion = rc.ResourceVersion
+			if !rm.shard.Owns(rc.ID) {
+				continue
+			}
 			// Sync even if this is a deletion event, to ensure that we leave
 			// it in the desired state.
 			glog.Infof("About to sync from watch: %v", rc.ID)
-			rm.syncHandler(*rc)
+			rm.watchdog.Do(func() { rm.syncHandler(*rc) })
 		}
 	}
 }
@@ -148,6 +172,7 @@ func (rm *ReplicationManager) syncReplicationController(controllerSpec api.Repli
 		return err
 	}
 	filteredList := rm.filterActivePods(podList.Items)
+	rm.updateStatus(controllerSpec, filteredList)
 	diff := len(filteredList) - controllerSpec.DesiredState.Replicas
 	if diff < 0 {
 		diff *= -1
@@ -176,6 +201,30 @@ func (rm *ReplicationManager) syncReplicationController(controllerSpec api.Repli
 	return nil
 }
 
+// updateStatus records the observed status of the controller's active pods,
+// if it has changed since the controller was last written.
+func (rm *ReplicationManager) updateStatus(controllerSpec api.ReplicationController, pods []api.Pod) {
+	status := api.ReplicationControllerStatus{Replicas: len(pods)}
+	templateSelector := labels.Set(controllerSpec.DesiredState.PodTemplate.Labels).AsSelector()
+	for _, pod := range pods {
+		if templateSelector.Matches(labels.Set(pod.Labels)) {
+			status.FullyLabeledReplicas++
+		}
+		if pod.CurrentState.Status == api.PodRunning {
+			status.ReadyReplicas++
+		}
+	}
+	if status == controllerSpec.Status {
+		return
+	}
+	controllerSpec.Status = status
+	// The update carries the resource version the controller was read at, so
+	// it can't overwrite a newer spec; a failed update is retried on the next sync.
+	if _, err := rm.kubeClient.UpdateReplicationController(&controllerSpec); err != nil {
+		glog.Errorf("Failed to update status of replication controller %s: %v", controllerSpec.ID, err)
+	}
+}
+
 func (rm *ReplicationManager) synchronize() {
 	// TODO: remove this method completely and rely on the watch.
 	// Add resource version tracking to watch to make this work.
@@ -185,18 +234,18 @@ func (rm *ReplicationManager) synchronize() {
 		glog.Errorf("Synchronization error: %v (%#v)", err, err)
 		return
 	}
-	controllerSpecs = list.Items
-	wg := sync.WaitGroup{}
-	wg.Add(len(controllerSpecs))
-	for ix := range controllerSpecs {
-		go func(ix int) {
-			defer wg.Done()
+	for _, controller := range list.Items {
+		if rm.shard.Owns(controller.ID) {
+			controllerSpecs = append(controllerSpecs, controller)
+		}
+	}
+	util.WorkByName(len(controllerSpecs), rm.workers,
+		func(ix int) string { return controllerSpecs[ix].ID },
+		func(ix int) {
 			glog.Infof("periodic sync of %v", controllerSpecs[ix].ID)
 			err := rm.syncHandler(controllerSpecs[ix])
 			if err != nil {
 				glog.Errorf("Error synchronizing: %#v", err)
 			}
-		}(ix)
-	}
-	wg.Wait()
+		})
 }
@@ -117,7 +117,7 @@ func TestSyncReplicationControllerDoesNothing(t *testing.T) {
 		ResponseBody: string(body),
 	}
 	testServer := httptest.NewTLSServer(&fakeHandler)
-	client := client.NewOrDie(testServer.URL, nil)
+	client := client.NewOrDie(testServer.URL, &client.AuthInfo{Insecure: true})
 
 	fakePodControl := FakePodControl{}
 
@@ -137,7 +137,7 @@ func TestSyncReplicationControllerDeletes(t *testing.T) {
 		ResponseBody: string(body),
 	}
 	testServer := httptest.NewTLSServer(&fakeHandler)
-	client := client.NewOrDie(testServer.URL, nil)
+	client := client.NewOrDie(testServer.URL, &client.AuthInfo{Insecure: true})
 
 	fakePodControl := FakePodControl{}
 
@@ -157,7 +157,7 @@ func TestSyncReplicationControllerCreates(t *testing.T) {
 		ResponseBody: string(body),
 	}
 	testServer := httptest.NewTLSServer(&fakeHandler)
-	client := client.NewOrDie(testServer.URL, nil)
+	client := client.NewOrDie(testServer.URL, &client.AuthInfo{Insecure: true})
 
 	fakePodControl := FakePodControl{}
 
@@ -177,7 +177,7 @@ func TestCreateReplica(t *testing.T) {
 		ResponseBody: string(body),
 	}
 	testServer := httptest.NewTLSServer(&fakeHandler)
-	client := client.NewOrDie(testServer.URL, nil)
+	client := client.NewOrDie(testServer.URL, &client.AuthInfo{Insecure: true})
 
 	podControl := RealPodControl{
 		kubeClient: client,
@@ -202,6 +202,13 @@ func TestCreateReplica(t *testing.T) {
 					"name": "foo",
 					"type": "production",
 				},
+				NodeSelector: map[string]string{
+					"disk": "ssd",
+				},
+				AntiAffinitySelector: map[string]string{
+					"name": "foo",
+				},
+				Platforms: []string{"linux/arm"},
 			},
 		},
 	}
@@ -213,8 +220,11 @@ func TestCreateReplica(t *testing.T) {
 			Kind:       "Pod",
 			APIVersion: latest.Version,
 		},
-		Labels:       controllerSpec.DesiredState.PodTemplate.Labels,
-		DesiredState: controllerSpec.DesiredState.PodTemplate.DesiredState,
+		Labels:               controllerSpec.DesiredState.PodTemplate.Labels,
+		DesiredState:         controllerSpec.DesiredState.PodTemplate.DesiredState,
+		NodeSelector:         controllerSpec.DesiredState.PodTemplate.NodeSelector,
+		AntiAffinitySelector: controllerSpec.DesiredState.PodTemplate.AntiAffinitySelector,
+		Platforms:            controllerSpec.DesiredState.PodTemplate.Platforms,
 	}
 	fakeHandler.ValidateRequest(t, makeURL("/pods"), "POST", nil)
 	actualPod := api.Pod{}
@@ -320,6 +330,43 @@ func TestSyncronize(t *testing.T) {
 	validateSyncReplication(t, &fakePodControl, 7, 0)
 }
 
+type FakeLister struct {
+	list api.ReplicationControllerList
+	*client.Fake
+}
+
+func (fl FakeLister) ListReplicationControllers(selector labels.Selector) (*api.ReplicationControllerList, error) {
+	return &fl.list, nil
+}
+
+func TestSynchronizeSharded(t *testing.T) {
+	client := FakeLister{Fake: &client.Fake{}}
+	for i := 0; i < 20; i++ {
+		client.list.Items = append(client.list.Items, api.ReplicationController{
+			JSONBase: api.JSONBase{ID: fmt.Sprintf("controller-%d", i)},
+		})
+	}
+
+	lock := sync.Mutex{}
+	synced := map[string]int{}
+	for i := 0; i < 2; i++ {
+		manager := NewShardedReplicationManager(client, util.Shard{Index: i, Count: 2}, 3)
+		manager.syncHandler = func(controllerSpec api.ReplicationController) error {
+			lock.Lock()
+			defer lock.Unlock()
+			synced[controllerSpec.ID]++
+			return nil
+		}
+		manager.synchronize()
+	}
+
+	for _, controller := range client.list.Items {
+		if synced[controller.ID] != 1 {
+			t.Errorf("Expected %s to be synced once, got %d", controller.ID, synced[controller.ID])
+		}
+	}
+}
+
 type FakeWatcher struct {
 	w *watch.FakeWatcher
 	*client.Fake
@@ -355,3 +402,52 @@ func TestWatchControllers(t *testing.T) {
 		t.Errorf("Expected 1 call but got 0")
 	}
 }
+
+func TestSyncReplicationControllerUpdatesStatus(t *testing.T) {
+	controllerSpec := newReplicationController(3)
+	controllerSpec.DesiredState.PodTemplate.Labels = map[string]string{"name": "foo", "type": "production"}
+	fakeClient := &client.Fake{
+		PodList: api.PodList{
+			Items: []api.Pod{
+				{
+					Labels:       map[string]string{"name": "foo", "type": "production"},
+					CurrentState: api.PodState{Status: api.PodRunning},
+				},
+				{
+					Labels:       map[string]string{"name": "foo"},
+					CurrentState: api.PodState{Status: api.PodRunning},
+				},
+				{
+					Labels:       map[string]string{"name": "foo", "type": "production"},
+					CurrentState: api.PodState{Status: api.PodWaiting},
+				},
+			},
+		},
+	}
+	manager := NewReplicationManager(fakeClient)
+	manager.podControl = &FakePodControl{}
+
+	manager.syncReplicationController(controllerSpec)
+	var updated *api.ReplicationController
+	for _, action := range fakeClient.Actions {
+		if action.Action == "update-controller" {
+			updated = action.Value.(*api.ReplicationController)
+		}
+	}
+	if updated == nil {
+		t.Fatalf("Expected the controller status to be updated, got %#v", fakeClient.Actions)
+	}
+	expected := api.ReplicationControllerStatus{Replicas: 3, FullyLabeledReplicas: 2, ReadyReplicas: 2}
+	if updated.Status != expected {
+		t.Errorf("Expected status %#v, got %#v", expected, updated.Status)
+	}
+
+	// An unchanged status isn't written again.
+	fakeClient.Actions = nil
+	manager.syncReplicationController(*updated)
+	for _, action := range fakeClient.Actions {
+		if action.Action == "update-controller" {
+			t.Errorf("Unexpected update of an unchanged status: %#v", fakeClient.Actions)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/cloudprovider"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/golang/glog"
+)
+
+// RouteController programs a cloud route for the pod CIDR of every minion
+// which has one, so that pods on different minions can reach each other
+// without routes being set up by hand.
+type RouteController struct {
+	minions client.MinionsClient
+	routes  cloudprovider.Routes
+	// The names of the routes we own start with this, so that clusters
+	// sharing a network don't delete each other's routes.
+	clusterName string
+}
+
+// NewRouteController creates a new RouteController.
+func NewRouteController(minions client.MinionsClient, routes cloudprovider.Routes, clusterName string) *RouteController {
+	return &RouteController{
+		minions:     minions,
+		routes:      routes,
+		clusterName: clusterName,
+	}
+}
+
+// Run begins syncing routes with the minion list every period.
+func (rc *RouteController) Run(period time.Duration) {
+	go util.Forever(func() {
+		if err := rc.Sync(); err != nil {
+			glog.Errorf("Error syncing routes: %v", err)
+		}
+	}, period)
+}
+
+// Sync creates the routes of minions which don't have one, and deletes the
+// routes of minions which are gone or whose pod CIDR has changed.
+func (rc *RouteController) Sync() error {
+	minions, err := rc.minions.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	existing, err := rc.routes.ListRoutes(rc.clusterName + "-")
+	if err != nil {
+		return err
+	}
+	desired := map[string]cloudprovider.Route{}
+	for _, minion := range minions.Items {
+		if len(minion.PodCIDR) == 0 {
+			continue
+		}
+		route := cloudprovider.Route{
+			Name:            rc.routeName(minion.ID),
+			TargetInstance:  minion.ID,
+			DestinationCIDR: minion.PodCIDR,
+		}
+		desired[route.Name] = route
+	}
+	for _, route := range existing {
+		if want, ok := desired[route.Name]; ok && want.DestinationCIDR == route.DestinationCIDR {
+			delete(desired, route.Name)
+			continue
+		}
+		if err := rc.routes.DeleteRoute(route.Name); err != nil {
+			glog.Errorf("Error deleting route %s: %v", route.Name, err)
+			// Creating a route with the same name would fail too.
+			delete(desired, route.Name)
+			continue
+		}
+		glog.Infof("Deleted route %s to %s for %s", route.Name, route.TargetInstance, route.DestinationCIDR)
+	}
+	for _, route := range desired {
+		if err := rc.routes.CreateRoute(route); err != nil {
+			glog.Errorf("Error creating route %s: %v", route.Name, err)
+			continue
+		}
+		glog.Infof("Created route %s to %s for %s", route.Name, route.TargetInstance, route.DestinationCIDR)
+	}
+	return nil
+}
+
+// routeName returns the name of the route for a minion. Cloud route names
+// can't contain dots, which minion names usually do.
+func (rc *RouteController) routeName(minion string) string {
+	return rc.clusterName + "-" + strings.Replace(minion, ".", "-", -1)
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/cloudprovider"
+	fake_cloud "github.com/GoogleCloudPlatform/kubernetes/pkg/cloudprovider/fake"
+)
+
+func makeRoutedMinion(id, podCIDR string) api.Minion {
+	return api.Minion{
+		JSONBase: api.JSONBase{ID: id},
+		PodCIDR:  podCIDR,
+	}
+}
+
+func TestRouteControllerCreatesRoutes(t *testing.T) {
+	fakeClient := &client.Fake{}
+	fakeClient.MinionList.Items = []api.Minion{
+		makeRoutedMinion("m1.example.com", "10.244.1.0/24"),
+		makeRoutedMinion("m2.example.com", ""),
+	}
+	cloud := &fake_cloud.FakeCloud{}
+	rc := NewRouteController(fakeClient.Minions(), cloud, "kubernetes")
+
+	if err := rc.Sync(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := map[string]cloudprovider.Route{
+		"kubernetes-m1-example-com": {Name: "kubernetes-m1-example-com", TargetInstance: "m1.example.com", DestinationCIDR: "10.244.1.0/24"},
+	}
+	if !reflect.DeepEqual(cloud.RouteMap, expected) {
+		t.Errorf("Expected routes %v, got %v", expected, cloud.RouteMap)
+	}
+
+	// Routes which are already right are left alone.
+	cloud.ClearCalls()
+	if err := rc.Sync(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(cloud.Calls, []string{"list-routes"}) {
+		t.Errorf("Unexpected calls: %v", cloud.Calls)
+	}
+}
+
+func TestRouteControllerReplacesAndDeletesRoutes(t *testing.T) {
+	fakeClient := &client.Fake{}
+	fakeClient.MinionList.Items = []api.Minion{makeRoutedMinion("m1", "10.244.2.0/24")}
+	cloud := &fake_cloud.FakeCloud{
+		RouteMap: map[string]cloudprovider.Route{
+			"kubernetes-m1": {Name: "kubernetes-m1", TargetInstance: "m1", DestinationCIDR: "10.244.1.0/24"},
+			"kubernetes-m2": {Name: "kubernetes-m2", TargetInstance: "m2", DestinationCIDR: "10.244.3.0/24"},
+			"other-m3":      {Name: "other-m3", TargetInstance: "m3", DestinationCIDR: "10.245.1.0/24"},
+		},
+	}
+	rc := NewRouteController(fakeClient.Minions(), cloud, "kubernetes")
+
+	if err := rc.Sync(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := map[string]cloudprovider.Route{
+		"kubernetes-m1": {Name: "kubernetes-m1", TargetInstance: "m1", DestinationCIDR: "10.244.2.0/24"},
+		"other-m3":      {Name: "other-m3", TargetInstance: "m3", DestinationCIDR: "10.245.1.0/24"},
+	}
+	if !reflect.DeepEqual(cloud.RouteMap, expected) {
+		t.Errorf("Expected routes %v, got %v", expected, cloud.RouteMap)
+	}
+}
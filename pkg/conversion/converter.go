@@ -62,6 +62,12 @@ type Scope interface {
 	// parameters, you'll run out of stack space before anything useful happens.
 	Convert(src, dest interface{}, flags FieldMatchingFlags) error
 
+	// DefaultConvert converts src to dest field by field, as if there were no
+	// conversion func for their types, so that a conversion func only has to
+	// handle the fields that were renamed or changed. Fields within src and dest
+	// are still converted with any registered conversion funcs.
+	DefaultConvert(src, dest interface{}, flags FieldMatchingFlags) error
+
 	// SrcTags and DestTags contain the struct tags that src and dest had, respectively.
 	// If the enclosing object was not a struct, then these will contain no tags, of course.
 	SrcTag() reflect.StructTag
@@ -117,6 +123,11 @@ func (s *scope) Convert(src, dest interface{}, flags FieldMatchingFlags) error {
 	return s.converter.Convert(src, dest, flags, s.meta)
 }
 
+// DefaultConvert continues a conversion, skipping the conversion func for the types of src and dest.
+func (s *scope) DefaultConvert(src, dest interface{}, flags FieldMatchingFlags) error {
+	return s.converter.DefaultConvert(src, dest, flags, s.meta)
+}
+
 // SrcTag returns the tag of the struct containing the current source item, if any.
 func (s *scope) SrcTag() reflect.StructTag {
 	return s.srcTagStack[len(s.srcTagStack)-1]
@@ -213,6 +224,19 @@ func (f FieldMatchingFlags) IsSet(flag FieldMatchingFlags) bool {
 // it is not used by Convert() other than storing it in the scope.
 // Not safe for objects with cyclic references!
 func (c *Converter) Convert(src, dest interface{}, flags FieldMatchingFlags, meta *Meta) error {
+	return c.doConversion(src, dest, flags, meta, c.convert)
+}
+
+// DefaultConvert will translate src to dest like Convert, except that it won't
+// call a conversion func registered for the types of src and dest themselves,
+// instead copying their fields.
+func (c *Converter) DefaultConvert(src, dest interface{}, flags FieldMatchingFlags, meta *Meta) error {
+	return c.doConversion(src, dest, flags, meta, c.defaultConvert)
+}
+
+type conversionFunc func(sv, dv reflect.Value, scope *scope) error
+
+func (c *Converter) doConversion(src, dest interface{}, flags FieldMatchingFlags, meta *Meta, f conversionFunc) error {
 	dv, sv := reflect.ValueOf(dest), reflect.ValueOf(src)
 	if dv.Kind() != reflect.Ptr {
 		return fmt.Errorf("Need pointer, but got %#v", dest)
@@ -231,7 +255,7 @@ func (c *Converter) Convert(src, dest interface{}, flags FieldMatchingFlags, met
 		meta:      meta,
 	}
 	s.push() // Easy way to make SrcTag and DestTag never fail
-	return c.convert(sv, dv, s)
+	return f(sv, dv, s)
 }
 
 // convert recursively copies sv into dv, calling an appropriate conversion function if
@@ -252,6 +276,13 @@ func (c *Converter) convert(sv, dv reflect.Value, scope *scope) error {
 		return ret.(error)
 	}
 
+	return c.defaultConvert(sv, dv, scope)
+}
+
+// defaultConvert recursively copies sv into dv, without calling a conversion function
+// for their own types.
+func (c *Converter) defaultConvert(sv, dv reflect.Value, scope *scope) error {
+	dt, st := dv.Type(), sv.Type()
 	if !scope.flags.IsSet(AllowDifferentFieldTypeNames) && c.NameFunc(dt) != c.NameFunc(st) {
 		return fmt.Errorf("Can't convert %v to %v because type names don't match.", st, dt)
 	}
@@ -351,3 +382,58 @@ func (c *Converter) convert(sv, dv reflect.Value, scope *scope) error {
 	}
 	return nil
 }
+
+// UnmatchedFields returns the fields of src and dest, and of the types within
+// them, which the default conversion can't match up by name, as paths like
+// "Pod.DesiredState.Host". Types with a registered conversion func are assumed
+// to be converted by it, and aren't examined. Use it in tests to catch fields
+// which would be dropped by, or would break, the conversion between two types.
+func (c *Converter) UnmatchedFields(src, dest reflect.Type) []string {
+	unmatched := []string{}
+	c.unmatchedFields(src, dest, src.Name(), map[typePair]bool{}, &unmatched)
+	return unmatched
+}
+
+func (c *Converter) unmatchedFields(st, dt reflect.Type, path string, seen map[typePair]bool, unmatched *[]string) {
+	pair := typePair{st, dt}
+	if seen[pair] {
+		return
+	}
+	seen[pair] = true
+	if _, ok := c.funcs[pair]; ok {
+		return
+	}
+	if c.NameFunc(st) != c.NameFunc(dt) || st.Kind() != dt.Kind() {
+		*unmatched = append(*unmatched, fmt.Sprintf("%s has type %v in src, %v in dest", path, st, dt))
+		return
+	}
+	switch st.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		c.unmatchedFields(st.Elem(), dt.Elem(), path, seen, unmatched)
+	case reflect.Map:
+		c.unmatchedFields(st.Key(), dt.Key(), path, seen, unmatched)
+		c.unmatchedFields(st.Elem(), dt.Elem(), path, seen, unmatched)
+	case reflect.Struct:
+		for i := 0; i < st.NumField(); i++ {
+			sf := st.Field(i)
+			if sf.PkgPath != "" {
+				continue
+			}
+			df, ok := dt.FieldByName(sf.Name)
+			if !ok {
+				*unmatched = append(*unmatched, fmt.Sprintf("%s.%s not present in dest", path, sf.Name))
+				continue
+			}
+			c.unmatchedFields(sf.Type, df.Type, path+"."+sf.Name, seen, unmatched)
+		}
+		for i := 0; i < dt.NumField(); i++ {
+			df := dt.Field(i)
+			if df.PkgPath != "" {
+				continue
+			}
+			if _, ok := st.FieldByName(df.Name); !ok {
+				*unmatched = append(*unmatched, fmt.Sprintf("%s.%s not present in src", path, df.Name))
+			}
+		}
+	}
+}
@@ -290,3 +290,110 @@ func TestConverter_flags(t *testing.T) {
 		}
 	}
 }
+
+func TestConverter_DefaultConvert(t *testing.T) {
+	type Inner struct {
+		Value string
+	}
+	type A struct {
+		Foo   string
+		Baz   int
+		Inner Inner
+	}
+	type B struct {
+		Bar   string
+		Baz   int
+		Inner Inner
+	}
+	c := NewConverter()
+	c.Debug = t
+	c.NameFunc = func(t reflect.Type) string { return "MyType" }
+	err := c.Register(func(in *A, out *B, s Scope) error {
+		if err := s.DefaultConvert(in, out, IgnoreMissingFields); err != nil {
+			return err
+		}
+		out.Bar = in.Foo
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	err = c.Register(func(in *Inner, out *Inner, s Scope) error {
+		out.Value = in.Value + "!"
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	x := A{"hello", 3, Inner{"inner"}}
+	y := B{}
+	if err := c.Convert(&x, &y, 0, nil); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if e, a := (B{"hello", 3, Inner{"inner!"}}), y; e != a {
+		t.Errorf("expected %#v, got %#v", e, a)
+	}
+
+	// Without IgnoreMissingFields, the renamed field can't be defaulted.
+	if err := c.DefaultConvert(&x, &y, 0, nil); err == nil {
+		t.Errorf("expected an error for a missing field")
+	}
+}
+
+func TestConverter_UnmatchedFields(t *testing.T) {
+	type Inner struct {
+		Value string
+	}
+	type OtherInner struct {
+		Value  string
+		Extra  bool
+		hidden int
+	}
+	type A struct {
+		Foo    string
+		Baz    int
+		Inner  []Inner
+		Kept   map[string]*Inner
+		Same   Inner
+		Number int
+	}
+	type B struct {
+		Bar    string
+		Baz    int
+		Inner  []OtherInner
+		Kept   map[string]*Inner
+		Same   Inner
+		Number string
+	}
+	c := NewConverter()
+	a, b := reflect.TypeOf(A{}), reflect.TypeOf(B{})
+	// As with a Scheme, the types of the two versions have the same names.
+	c.NameFunc = func(t reflect.Type) string {
+		switch t {
+		case b:
+			return "A"
+		case reflect.TypeOf(OtherInner{}):
+			return "Inner"
+		}
+		return t.Name()
+	}
+
+	expected := []string{
+		"A.Foo not present in dest",
+		"A.Inner.Extra not present in src",
+		"A.Number has type int in src, string in dest",
+		"A.Bar not present in src",
+	}
+	if e, a := expected, c.UnmatchedFields(a, b); !reflect.DeepEqual(e, a) {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+
+	err := c.Register(func(in *A, out *B, s Scope) error { return nil })
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if unmatched := c.UnmatchedFields(a, b); len(unmatched) != 0 {
+		t.Errorf("expected a conversion func to cover the fields, got %v", unmatched)
+	}
+}
@@ -18,13 +18,16 @@ package conversion
 
 import (
 	"fmt"
+	"reflect"
+	"strings"
 
 	"gopkg.in/v1/yaml"
 )
 
 // Decode converts a YAML or JSON string back into a pointer to an api object.
 // Deduces the type based upon the fields added by the MetaInsertionFactory
-// technique. The object will be converted, if necessary, into the
+// technique. Defaults are set on the decoded object, by the registered defaulting
+// funcs for its version. The object will be converted, if necessary, into the
 // s.InternalVersion type before being returned. Decode will not decode
 // objects without version set unless InternalVersion is also "".
 func (s *Scheme) Decode(data []byte) (interface{}, error) {
@@ -45,6 +48,10 @@ func (s *Scheme) Decode(data []byte) (interface{}, error) {
 	if err != nil {
 		return nil, err
 	}
+	err = s.defaulter.Default(obj)
+	if err != nil {
+		return nil, err
+	}
 
 	// Version and Kind should be blank in memory.
 	err = s.SetVersionAndKind("", "", obj)
@@ -101,6 +108,10 @@ func (s *Scheme) DecodeInto(data []byte, obj interface{}) error {
 		if err != nil {
 			return err
 		}
+		err = s.defaulter.Default(obj)
+		if err != nil {
+			return err
+		}
 	} else {
 		external, err := s.NewObject(dataVersion, dataKind)
 		if err != nil {
@@ -112,6 +123,10 @@ func (s *Scheme) DecodeInto(data []byte, obj interface{}) error {
 		if err != nil {
 			return err
 		}
+		err = s.defaulter.Default(external)
+		if err != nil {
+			return err
+		}
 		err = s.converter.Convert(external, obj, 0, s.generateConvertMeta(dataVersion, objVersion))
 		if err != nil {
 			return err
@@ -121,3 +136,106 @@ func (s *Scheme) DecodeInto(data []byte, obj interface{}) error {
 	// Version and Kind should be blank in memory.
 	return s.SetVersionAndKind("", "", obj)
 }
+
+// UnknownFields parses a YAML or JSON string and returns the fields in it which
+// have no counterpart in obj's type, mapped from their paths (e.g.
+// "desiredState.replica") to their values. Decoding drops such fields silently,
+// so this lets callers refuse data with typos in it. Obj should be a pointer to
+// an object of the version the data is in.
+func UnknownFields(data []byte, obj interface{}) (map[string]interface{}, error) {
+	var raw interface{}
+	// yaml is a superset of json, so we use it to decode here. That way,
+	// we understand both.
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	unknown := map[string]interface{}{}
+	findUnknownFields(raw, reflect.TypeOf(obj), "", unknown)
+	return unknown, nil
+}
+
+var yamlSetterType = reflect.TypeOf((*yaml.Setter)(nil)).Elem()
+
+// findUnknownFields records in unknown the fields of raw, which was decoded from
+// the data at path, that t doesn't have.
+func findUnknownFields(raw interface{}, t reflect.Type, path string, unknown map[string]interface{}) {
+	if raw == nil {
+		return
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	// Types which decode themselves may accept anything.
+	if reflect.PtrTo(t).Implements(yamlSetterType) {
+		return
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := raw.(map[interface{}]interface{})
+		if !ok {
+			return
+		}
+		fields := map[string]reflect.Type{}
+		yamlFields(t, fields)
+		for k, v := range m {
+			key := fmt.Sprintf("%v", k)
+			fieldPath := key
+			if path != "" {
+				fieldPath = path + "." + key
+			}
+			fieldType, ok := fields[key]
+			if !ok {
+				unknown[fieldPath] = v
+				continue
+			}
+			findUnknownFields(v, fieldType, fieldPath, unknown)
+		}
+	case reflect.Slice, reflect.Array:
+		items, ok := raw.([]interface{})
+		if !ok {
+			return
+		}
+		for i, item := range items {
+			findUnknownFields(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), unknown)
+		}
+	case reflect.Map:
+		m, ok := raw.(map[interface{}]interface{})
+		if !ok {
+			return
+		}
+		for k, v := range m {
+			findUnknownFields(v, t.Elem(), fmt.Sprintf("%s[%v]", path, k), unknown)
+		}
+	}
+}
+
+// yamlFields adds the names yaml decodes t's fields from to fields, following
+// inlined structs.
+func yamlFields(t reflect.Type, fields map[string]reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+		tag := f.Tag.Get("yaml")
+		if tag == "-" {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		inline := false
+		for _, flag := range parts[1:] {
+			if flag == "inline" {
+				inline = true
+			}
+		}
+		if inline {
+			yamlFields(f.Type, fields)
+			continue
+		}
+		name := parts[0]
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f.Type
+	}
+}
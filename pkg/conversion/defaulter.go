@@ -0,0 +1,106 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conversion
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Defaulter sets the default values of fields which were left empty in objects
+// of a versioned API, by calling the functions registered for their types.
+type Defaulter struct {
+	// Map from a type to the function which sets its defaults.
+	funcs map[reflect.Type]reflect.Value
+}
+
+// NewDefaulter creates a new Defaulter object.
+func NewDefaulter() *Defaulter {
+	return &Defaulter{
+		funcs: map[reflect.Type]reflect.Value{},
+	}
+}
+
+// Register registers a defaulting func with the Defaulter. defaultingFunc must
+// take a single parameter, a pointer to the type whose defaults it sets, and
+// return nothing.
+//
+// Example:
+// d.Register(func(obj *v1beta1.Port) { if obj.Protocol == "" { obj.Protocol = "TCP" } })
+func (d *Defaulter) Register(defaultingFunc interface{}) error {
+	fv := reflect.ValueOf(defaultingFunc)
+	ft := fv.Type()
+	if ft.Kind() != reflect.Func {
+		return fmt.Errorf("expected func, got: %v", ft)
+	}
+	if ft.NumIn() != 1 {
+		return fmt.Errorf("expected one 'in' param, got: %v", ft)
+	}
+	if ft.NumOut() != 0 {
+		return fmt.Errorf("expected zero 'out' params, got: %v", ft)
+	}
+	if ft.In(0).Kind() != reflect.Ptr {
+		return fmt.Errorf("expected pointer arg for 'in' param 0, got: %v", ft)
+	}
+	d.funcs[ft.In(0).Elem()] = fv
+	return nil
+}
+
+// Default calls the registered defaulting funcs on obj, which must be a pointer,
+// and on everything within it. The defaults of the objects within obj are set
+// before obj's own, so that its defaulting func can rely on theirs.
+func (d *Defaulter) Default(obj interface{}) error {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Ptr {
+		return fmt.Errorf("Need pointer, but got %#v", obj)
+	}
+	if len(d.funcs) > 0 {
+		d.setDefaults(v.Elem())
+	}
+	return nil
+}
+
+// setDefaults recursively sets the defaults of v, which must be addressable.
+func (d *Defaulter) setDefaults(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if f := v.Field(i); f.CanSet() {
+				d.setDefaults(f)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			d.setDefaults(v.Index(i))
+		}
+	case reflect.Ptr:
+		if !v.IsNil() {
+			d.setDefaults(v.Elem())
+		}
+	case reflect.Map:
+		// Map elements can't be addressed, so default a copy of each and put it back.
+		for _, key := range v.MapKeys() {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(v.MapIndex(key))
+			d.setDefaults(elem)
+			v.SetMapIndex(key, elem)
+		}
+	}
+	if fv, ok := d.funcs[v.Type()]; ok {
+		fv.Call([]reflect.Value{v.Addr()})
+	}
+}
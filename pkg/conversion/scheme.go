@@ -19,6 +19,7 @@ package conversion
 import (
 	"fmt"
 	"reflect"
+	"sort"
 
 	"gopkg.in/v1/yaml"
 )
@@ -59,6 +60,10 @@ type Scheme struct {
 	// default coverting behavior.
 	converter *Converter
 
+	// defaulter stores all registered defaulting functions, which are applied
+	// to objects as they're decoded.
+	defaulter *Defaulter
+
 	// Indent will cause the JSON output from Encode to be indented, iff it is true.
 	Indent bool
 
@@ -79,6 +84,7 @@ func NewScheme() *Scheme {
 		typeToVersion:        map[reflect.Type]string{},
 		typeToKind:           map[reflect.Type]string{},
 		converter:            NewConverter(),
+		defaulter:            NewDefaulter(),
 		InternalVersion:      "",
 		MetaInsertionFactory: metaInsertion{},
 	}
@@ -197,6 +203,8 @@ func (s *Scheme) NewObject(versionName, typeName string) (interface{}, error) {
 // sanely copy fields that have the same names and same type names. It's OK if the
 // destination type has extra fields, but it must not remove any. So you only need to
 // add conversion functions for things with changed/removed fields.
+// Such a function can call DefaultConvert on its Scope to copy the fields which
+// didn't change, and only handle the others itself.
 func (s *Scheme) AddConversionFuncs(conversionFuncs ...interface{}) error {
 	for _, f := range conversionFuncs {
 		err := s.converter.Register(f)
@@ -207,6 +215,29 @@ func (s *Scheme) AddConversionFuncs(conversionFuncs ...interface{}) error {
 	return nil
 }
 
+// AddDefaultingFuncs adds functions to the list of default-setting funcs.
+// Each must take a single parameter, a pointer to the type whose empty fields
+// it sets to their defaults. They're called on decoded objects, and on every
+// object within them, before any conversion, so they're registered for the
+// types of a particular version. For example:
+//
+// s.AddDefaultingFuncs(
+//	func(obj *v1beta1.Port) {
+//		if obj.Protocol == "" {
+//			obj.Protocol = "TCP"
+//		}
+//	},
+// )
+func (s *Scheme) AddDefaultingFuncs(defaultingFuncs ...interface{}) error {
+	for _, f := range defaultingFuncs {
+		err := s.defaulter.Register(f)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Convert will attempt to convert in into out. Both must be pointers. For easy
 // testing of conversion functions. Returns an error if the conversion isn't
 // possible. You can call this with types that haven't been registered (for example,
@@ -225,6 +256,22 @@ func (s *Scheme) Convert(in, out interface{}) error {
 	return s.converter.Convert(in, out, 0, s.generateConvertMeta(inVersion, outVersion))
 }
 
+// UnmatchedFields returns the fields of the types registered in srcVersion
+// which can't be converted to the same kinds in destVersion by matching up
+// field names, and which aren't handled by a conversion func. See
+// Converter.UnmatchedFields.
+func (s *Scheme) UnmatchedFields(srcVersion, destVersion string) []string {
+	unmatched := []string{}
+	destTypes := s.versionMap[destVersion]
+	for kind, st := range s.versionMap[srcVersion] {
+		if dt, ok := destTypes[kind]; ok {
+			unmatched = append(unmatched, s.converter.UnmatchedFields(st, dt)...)
+		}
+	}
+	sort.Strings(unmatched)
+	return unmatched
+}
+
 // generateConvertMeta constructs the meta value we pass to Convert.
 func (s *Scheme) generateConvertMeta(srcVersion, destVersion string) *Meta {
 	return &Meta{
@@ -285,6 +285,35 @@ func TestBadJSONRejectionForSetInternalVersion(t *testing.T) {
 	}
 }
 
+func TestUnknownFields(t *testing.T) {
+	table := []struct {
+		data     string
+		expected map[string]interface{}
+	}{
+		{`{"myKindKey": "TestType1", "A": "a", "O": {"B": 1}, "Q": [{"A": "b"}], "N": {"x": {"B": 2}}}`, map[string]interface{}{}},
+		{`{"A": "a", "a": "b"}`, map[string]interface{}{"a": "b"}},
+		{`{"O": {"C": 1}}`, map[string]interface{}{"O.C": 1}},
+		{`{"Q": [{"A": "a"}, {"Z": "z"}]}`, map[string]interface{}{"Q[1].Z": "z"}},
+		{`{"N": {"x": {"Y": 3}}}`, map[string]interface{}{"N[x].Y": 3}},
+		// Go field names only count when they're the yaml name.
+		{`{"P": []}`, map[string]interface{}{"P": []interface{}{}}},
+	}
+	for _, item := range table {
+		unknown, err := UnknownFields([]byte(item.data), &ExternalTestType1{})
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", item.data, err)
+			continue
+		}
+		if !reflect.DeepEqual(item.expected, unknown) {
+			t.Errorf("%s: expected %#v, got %#v", item.data, item.expected, unknown)
+		}
+	}
+
+	if _, err := UnknownFields([]byte(`{"A": `), &ExternalTestType1{}); err == nil {
+		t.Errorf("expected an error for bad data")
+	}
+}
+
 func TestMetaValues(t *testing.T) {
 	type InternalSimple struct {
 		Version    string `json:"version,omitempty" yaml:"version,omitempty"`
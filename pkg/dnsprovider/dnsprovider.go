@@ -0,0 +1,34 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dnsprovider
+
+import (
+	"net"
+)
+
+// Interface is an abstract, pluggable interface for DNS providers.
+type Interface interface {
+	// EnsureRecord creates the A record name in zone, or updates it if it
+	// exists, so that it resolves to ip.
+	EnsureRecord(zone, name string, ip net.IP) error
+	// DeleteRecord deletes the A record name in zone.  Deleting a record that
+	// does not exist is not an error.
+	DeleteRecord(zone, name string) error
+	// ListRecords returns the A records in zone, mapping each name to the IP
+	// it resolves to.
+	ListRecords(zone string) (map[string]net.IP, error)
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake_dns
+
+import (
+	"net"
+	"strings"
+)
+
+// FakeDNS is a test-double implementation of dnsprovider.Interface. It keeps
+// its records in memory.
+type FakeDNS struct {
+	Err   error
+	Calls []string
+	// Records maps "name in zone" to the IP address the record resolves to.
+	Records map[string]net.IP
+}
+
+func (f *FakeDNS) addCall(desc string) {
+	f.Calls = append(f.Calls, desc)
+}
+
+// ClearCalls clears internal record of method calls to this FakeDNS.
+func (f *FakeDNS) ClearCalls() {
+	f.Calls = []string{}
+}
+
+// EnsureRecord is a test-spy implementation of Interface.EnsureRecord.
+// It adds an entry "ensure" into the internal method call record.
+func (f *FakeDNS) EnsureRecord(zone, name string, ip net.IP) error {
+	f.addCall("ensure")
+	if f.Err != nil {
+		return f.Err
+	}
+	if f.Records == nil {
+		f.Records = map[string]net.IP{}
+	}
+	f.Records[name+" in "+zone] = ip
+	return nil
+}
+
+// DeleteRecord is a test-spy implementation of Interface.DeleteRecord.
+// It adds an entry "delete" into the internal method call record.
+func (f *FakeDNS) DeleteRecord(zone, name string) error {
+	f.addCall("delete")
+	if f.Err != nil {
+		return f.Err
+	}
+	delete(f.Records, name+" in "+zone)
+	return nil
+}
+
+// ListRecords is a test-spy implementation of Interface.ListRecords.
+// It adds an entry "list" into the internal method call record.
+func (f *FakeDNS) ListRecords(zone string) (map[string]net.IP, error) {
+	f.addCall("list")
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	records := map[string]net.IP{}
+	for key, ip := range f.Records {
+		if strings.HasSuffix(key, " in "+zone) {
+			records[strings.TrimSuffix(key, " in "+zone)] = ip
+		}
+	}
+	return records, nil
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dnsprovider
+
+import (
+	"io"
+	"os"
+	"sync"
+
+	"github.com/golang/glog"
+)
+
+// Factory is a function that returns a dnsprovider.Interface.
+// The config parameter provides an io.Reader handler to the factory in
+// order to load specific configurations. If no configuration is provided
+// the parameter is nil.
+type Factory func(config io.Reader) (Interface, error)
+
+// All registered DNS providers.
+var providersMutex sync.Mutex
+var providers = make(map[string]Factory)
+
+// RegisterDNSProvider registers a dnsprovider.Factory by name.  This
+// is expected to happen during app startup.
+func RegisterDNSProvider(name string, dns Factory) {
+	providersMutex.Lock()
+	defer providersMutex.Unlock()
+	_, found := providers[name]
+	if found {
+		glog.Fatalf("DNS provider %q was registered twice", name)
+	}
+	glog.Infof("Registered DNS provider %q", name)
+	providers[name] = dns
+}
+
+// GetDNSProvider creates an instance of the named DNS provider, or nil if
+// the name is not known.  The error return is only used if the named provider
+// was known but failed to initialize. The config parameter specifies the
+// io.Reader handler of the configuration file for the DNS provider, or nil
+// for no configuration.
+func GetDNSProvider(name string, config io.Reader) (Interface, error) {
+	providersMutex.Lock()
+	defer providersMutex.Unlock()
+	f, found := providers[name]
+	if !found {
+		return nil, nil
+	}
+	return f(config)
+}
+
+// InitDNSProvider creates an instance of the named DNS provider, reading its
+// configuration from configFilePath if that is not empty.  It returns nil if
+// name is empty, and exits if the provider is unknown or fails to initialize.
+func InitDNSProvider(name string, configFilePath string) Interface {
+	var config *os.File
+
+	if name == "" {
+		glog.Info("No DNS provider specified.")
+		return nil
+	}
+
+	if configFilePath != "" {
+		var err error
+
+		config, err = os.Open(configFilePath)
+		if err != nil {
+			glog.Fatalf("Couldn't open DNS provider configuration %s: %#v",
+				configFilePath, err)
+		}
+
+		defer config.Close()
+	}
+
+	dns, err := GetDNSProvider(name, config)
+	if err != nil {
+		glog.Fatalf("Couldn't init DNS provider %q: %#v", name, err)
+	}
+	if dns == nil {
+		glog.Fatalf("Unknown DNS provider: %s", name)
+	}
+
+	return dns
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package skydns
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/dnsprovider"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/tools"
+	"github.com/coreos/go-etcd/etcd"
+)
+
+// SkyDNS implements dnsprovider.Interface by writing records to the etcd
+// that SkyDNS serves them from.  SkyDNS has a single domain, so zones only
+// serve to name records.
+type SkyDNS struct {
+	client tools.EtcdClient
+}
+
+func init() {
+	dnsprovider.RegisterDNSProvider("skydns", func(config io.Reader) (dnsprovider.Interface, error) {
+		servers, err := readServers(config)
+		if err != nil {
+			return nil, err
+		}
+		return New(etcd.NewClient(servers)), nil
+	})
+}
+
+// readServers reads the etcd servers, separated by commas or whitespace,
+// from the provider's configuration.
+func readServers(config io.Reader) ([]string, error) {
+	if config == nil {
+		return nil, fmt.Errorf("the skydns provider needs a configuration file listing etcd servers")
+	}
+	data, err := ioutil.ReadAll(config)
+	if err != nil {
+		return nil, err
+	}
+	servers := strings.FieldsFunc(string(data), func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("no etcd servers in the skydns configuration")
+	}
+	return servers, nil
+}
+
+// New returns a SkyDNS that writes records with client.
+func New(client tools.EtcdClient) *SkyDNS {
+	return &SkyDNS{client: client}
+}
+
+// record is a SkyDNS record, as stored in etcd.
+type record struct {
+	Host string `json:"host"`
+}
+
+// recordKey returns the etcd key of the record for a domain name, whose
+// labels SkyDNS expects in reverse order: a.b.local is /skydns/local/b/a.
+func recordKey(name string) string {
+	labels := strings.Split(strings.TrimSuffix(name, "."), ".")
+	for i, j := 0, len(labels)-1; i < j; i, j = i+1, j-1 {
+		labels[i], labels[j] = labels[j], labels[i]
+	}
+	return "/skydns/" + strings.Join(labels, "/")
+}
+
+// EnsureRecord implements dnsprovider.Interface.
+func (s *SkyDNS) EnsureRecord(zone, name string, ip net.IP) error {
+	data, err := json.Marshal(record{Host: ip.String()})
+	if err != nil {
+		return err
+	}
+	_, err = s.client.Set(recordKey(name), string(data), 0)
+	return err
+}
+
+// recordName returns the domain name of the record stored at an etcd key; it
+// is the inverse of recordKey.
+func recordName(key string) string {
+	labels := strings.Split(strings.TrimPrefix(key, "/skydns/"), "/")
+	for i, j := 0, len(labels)-1; i < j; i, j = i+1, j-1 {
+		labels[i], labels[j] = labels[j], labels[i]
+	}
+	return strings.Join(labels, ".")
+}
+
+// ListRecords implements dnsprovider.Interface.  Records which SkyDNS can't
+// resolve to an IP, e.g. CNAMEs, are left out.
+func (s *SkyDNS) ListRecords(zone string) (map[string]net.IP, error) {
+	records := map[string]net.IP{}
+	resp, err := s.client.Get(recordKey(zone), false, true)
+	if err != nil {
+		if tools.IsEtcdNotFound(err) {
+			return records, nil
+		}
+		return nil, err
+	}
+	addRecords(resp.Node, records)
+	return records, nil
+}
+
+// addRecords adds the records stored at node and below it to records.
+func addRecords(node *etcd.Node, records map[string]net.IP) {
+	if node.Dir {
+		for _, child := range node.Nodes {
+			addRecords(child, records)
+		}
+		return
+	}
+	var r record
+	if err := json.Unmarshal([]byte(node.Value), &r); err != nil {
+		return
+	}
+	if ip := net.ParseIP(r.Host); ip != nil {
+		records[recordName(node.Key)] = ip
+	}
+}
+
+// DeleteRecord implements dnsprovider.Interface.
+func (s *SkyDNS) DeleteRecord(zone, name string) error {
+	_, err := s.client.Delete(recordKey(name), false)
+	if err != nil && !tools.IsEtcdNotFound(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package skydns
+
+import (
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/tools"
+	"github.com/coreos/go-etcd/etcd"
+)
+
+func TestRecordKey(t *testing.T) {
+	tests := map[string]string{
+		"mysvc.kubernetes.local":  "/skydns/local/kubernetes/mysvc",
+		"mysvc.kubernetes.local.": "/skydns/local/kubernetes/mysvc",
+		"local":                   "/skydns/local",
+	}
+	for name, expected := range tests {
+		if key := recordKey(name); key != expected {
+			t.Errorf("Expected %s for %s, got %s", expected, name, key)
+		}
+	}
+}
+
+func TestSkyDNSRecords(t *testing.T) {
+	fakeClient := tools.NewFakeEtcdClient(t)
+	dns := New(fakeClient)
+	if err := dns.EnsureRecord("kubernetes.local", "mysvc.kubernetes.local", net.ParseIP("10.0.0.1")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp, err := fakeClient.Get("/skydns/local/kubernetes/mysvc", false, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if e, a := `{"host":"10.0.0.1"}`, resp.Node.Value; e != a {
+		t.Errorf("Expected %s, got %s", e, a)
+	}
+
+	if err := dns.DeleteRecord("kubernetes.local", "mysvc.kubernetes.local"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(fakeClient.DeletedKeys) != 1 || fakeClient.DeletedKeys[0] != "/skydns/local/kubernetes/mysvc" {
+		t.Errorf("Unexpected deletes: %v", fakeClient.DeletedKeys)
+	}
+}
+
+func TestSkyDNSListRecords(t *testing.T) {
+	fakeClient := tools.NewFakeEtcdClient(t)
+	fakeClient.Data["/skydns/local/kubernetes"] = tools.EtcdResponseWithError{
+		R: &etcd.Response{
+			Node: &etcd.Node{
+				Key: "/skydns/local/kubernetes",
+				Dir: true,
+				Nodes: etcd.Nodes{
+					{Key: "/skydns/local/kubernetes/foo", Value: `{"host":"10.0.0.1"}`},
+					{Key: "/skydns/local/kubernetes/alias", Value: `{"host":"foo.kubernetes.local"}`},
+					{
+						Key: "/skydns/local/kubernetes/bar",
+						Dir: true,
+						Nodes: etcd.Nodes{
+							{Key: "/skydns/local/kubernetes/bar/www", Value: `{"host":"10.0.0.2"}`},
+						},
+					},
+				},
+			},
+		},
+	}
+	dns := New(fakeClient)
+	records, err := dns.ListRecords("kubernetes.local")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := map[string]net.IP{
+		"foo.kubernetes.local":     net.ParseIP("10.0.0.1"),
+		"www.bar.kubernetes.local": net.ParseIP("10.0.0.2"),
+	}
+	if !reflect.DeepEqual(records, expected) {
+		t.Errorf("Expected %v, got %v", expected, records)
+	}
+
+	fakeClient.ExpectNotFoundGet("/skydns/local/example")
+	records, err = dns.ListRecords("example.local")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(records) != 0 {
+		t.Errorf("Expected no records, got %v", records)
+	}
+}
+
+func TestReadServers(t *testing.T) {
+	servers, err := readServers(strings.NewReader("http://a:4001,http://b:4001\nhttp://c:4001\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if e := []string{"http://a:4001", "http://b:4001", "http://c:4001"}; !reflect.DeepEqual(e, servers) {
+		t.Errorf("Expected %v, got %v", e, servers)
+	}
+	if _, err := readServers(nil); err == nil {
+		t.Errorf("Expected an error without a configuration")
+	}
+	if _, err := readServers(strings.NewReader("\n")); err == nil {
+		t.Errorf("Expected an error without servers")
+	}
+}
@@ -0,0 +1,45 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package election
+
+import (
+	"github.com/golang/glog"
+)
+
+// BecomeMaster enters id into the election for path and blocks until id is
+// elected. Afterwards, if another master is announced or the election ends,
+// lost is called; the caller must then stop acting as the master.
+func BecomeMaster(elector MasterElector, path, id string, lost func()) {
+	w := elector.Elect(path, id)
+	for event := range w.ResultChan() {
+		if master, ok := event.Object.(Master); ok && string(master) == id {
+			glog.Infof("Elected master of %s as %s", path, id)
+			go func() {
+				for event := range w.ResultChan() {
+					if master, ok := event.Object.(Master); ok && string(master) != id {
+						glog.Errorf("Lost mastership of %s to %s", path, master)
+						break
+					}
+				}
+				lost()
+			}()
+			return
+		}
+		glog.V(2).Infof("Waiting to become master of %s, current master is %v", path, event.Object)
+	}
+	lost()
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package election
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
+)
+
+type fakeElector struct {
+	w *watch.FakeWatcher
+}
+
+func (f fakeElector) Elect(path, id string) watch.Interface {
+	return f.w
+}
+
+func TestBecomeMaster(t *testing.T) {
+	elector := fakeElector{watch.NewFake()}
+	elected := make(chan struct{})
+	lost := make(chan struct{})
+	go func() {
+		BecomeMaster(elector, "/master", "me", func() { close(lost) })
+		close(elected)
+	}()
+
+	elector.w.Modify(Master("other"))
+	select {
+	case <-elected:
+		t.Fatalf("unexpectedly elected while another master holds the lock")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	elector.w.Modify(Master("me"))
+	<-elected
+	select {
+	case <-lost:
+		t.Fatalf("unexpected loss of mastership")
+	default:
+	}
+
+	elector.w.Modify(Master("other"))
+	select {
+	case <-lost:
+	case <-time.After(time.Second):
+		t.Errorf("expected mastership to be lost")
+	}
+}
+
+func TestBecomeMasterElectionStopped(t *testing.T) {
+	elector := fakeElector{watch.NewFake()}
+	lost := make(chan struct{})
+	go BecomeMaster(elector, "/master", "me", func() { close(lost) })
+	elector.w.Stop()
+	select {
+	case <-lost:
+	case <-time.After(time.Second):
+		t.Errorf("expected lost to be called when the election ends")
+	}
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fields
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+)
+
+// ConversionFunc converts the name and value of a field in an API version to
+// the internal ones, returning an error if the field isn't supported.
+type ConversionFunc func(name, value string) (internalName, internalValue string, err error)
+
+var (
+	lock        sync.RWMutex
+	supported   = map[string]util.StringSet{}
+	conversions = map[string]map[string]ConversionFunc{}
+)
+
+// Register declares the internal names of the fields a resource, e.g. "pods",
+// can be selected on. A resource which isn't registered can't be selected on
+// by any field.
+func Register(resource string, names ...string) {
+	lock.Lock()
+	defer lock.Unlock()
+	if supported[resource] == nil {
+		supported[resource] = util.StringSet{}
+	}
+	supported[resource].Insert(names...)
+}
+
+// AddConversionFunc registers the conversion of a resource's field names and
+// values in an API version to the internal ones. Without one, a version's
+// field names are taken to be the internal ones.
+func AddConversionFunc(version, resource string, fn ConversionFunc) {
+	lock.Lock()
+	defer lock.Unlock()
+	if conversions[version] == nil {
+		conversions[version] = map[string]ConversionFunc{}
+	}
+	conversions[version][resource] = fn
+}
+
+// RenameConversionFunc returns a ConversionFunc which renames fields using
+// names, a map from a version's names to the internal ones, leaving their
+// values alone. Fields which aren't in names aren't supported.
+func RenameConversionFunc(names map[string]string) ConversionFunc {
+	return func(name, value string) (string, string, error) {
+		internalName, ok := names[name]
+		if !ok {
+			return "", "", fmt.Errorf("field %q is not supported", name)
+		}
+		return internalName, value, nil
+	}
+}
+
+// ParseSelector parses a field selector on a resource in an API version, and
+// returns a selector on its internal fields, or an error if the selector uses
+// a field which the resource can't be selected on.
+func ParseSelector(version, resource, selector string) (labels.Selector, error) {
+	lock.RLock()
+	names := supported[resource]
+	convert := conversions[version][resource]
+	lock.RUnlock()
+
+	return labels.ParseAndTransformSelector(selector, func(name, value string) (string, string, error) {
+		if convert != nil {
+			var err error
+			if name, value, err = convert(name, value); err != nil {
+				return "", "", err
+			}
+		}
+		if !names.Has(name) {
+			return "", "", fmt.Errorf("%s can't be selected by the field %q", resource, name)
+		}
+		return name, value, nil
+	})
+}
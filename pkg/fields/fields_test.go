@@ -0,0 +1,73 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fields
+
+import (
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+)
+
+func TestParseSelector(t *testing.T) {
+	Register("testPods", "ID", "DesiredState.Host")
+	AddConversionFunc("testVersion", "testPods", RenameConversionFunc(map[string]string{
+		"id":                "ID",
+		"desiredState.host": "DesiredState.Host",
+	}))
+	pod := labels.Set{"ID": "foo", "DesiredState.Host": "bar"}
+
+	table := []struct {
+		version, resource, selector string
+		expected                    string
+		matches                     bool
+	}{
+		{"testVersion", "testPods", "", "", true},
+		{"testVersion", "testPods", "id=foo", "ID=foo", true},
+		{"testVersion", "testPods", "id=foo,desiredState.host!=bar", "DesiredState.Host!=bar,ID=foo", false},
+		{"testVersion", "testPods", "desiredState.host in (bar,baz)", "DesiredState.Host in (bar,baz)", true},
+		{"otherVersion", "testPods", "DesiredState.Host=", "DesiredState.Host=", false},
+		{"otherVersion", "testUnselectable", "", "", true},
+	}
+	for _, item := range table {
+		s, err := ParseSelector(item.version, item.resource, item.selector)
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", item.selector, err)
+			continue
+		}
+		if s.String() != item.expected {
+			t.Errorf("%v: expected %v, got %v", item.selector, item.expected, s.String())
+		}
+		if s.Matches(pod) != item.matches {
+			t.Errorf("%v: expected match to be %v", item.selector, item.matches)
+		}
+	}
+
+	errorCases := []struct {
+		version, resource, selector string
+	}{
+		{"testVersion", "testPods", "ID=foo"},
+		{"testVersion", "testPods", "desiredState.status=Running"},
+		{"otherVersion", "testPods", "id=foo"},
+		{"otherVersion", "testUnselectable", "ID=foo"},
+		{"testVersion", "testPods", "id==foo==bar"},
+	}
+	for _, item := range errorCases {
+		if _, err := ParseSelector(item.version, item.resource, item.selector); err == nil {
+			t.Errorf("%v: expected an error", item.selector)
+		}
+	}
+}
@@ -17,9 +17,12 @@ limitations under the License.
 package httplog
 
 import (
+	"encoding/base64"
 	"fmt"
 	"net/http"
 	"runtime"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/golang/glog"
@@ -40,6 +43,57 @@ func Handler(delegate http.Handler, pred StacktracePred) http.Handler {
 // StacktracePred returns true if a stacktrace should be logged for this status.
 type StacktracePred func(httpStatus int) (logStacktrace bool)
 
+// Entry is what is captured about each logged request.
+type Entry struct {
+	Method string
+	// Path is the request URI, including any query.
+	Path string
+	// User is the user named by the request's basic auth, or "-".
+	User    string
+	Status  int
+	Size    int
+	Latency time.Duration
+}
+
+// String formats the entry for the log.
+func (e Entry) String() string {
+	return fmt.Sprintf("method=%s path=%q user=%q status=%d size=%d latency=%v", e.Method, e.Path, e.User, e.Status, e.Size, e.Latency)
+}
+
+// Hook is called with the entry of every logged request, once it has been
+// served, so that others such as metrics can share what was captured.
+type Hook func(req *http.Request, entry Entry)
+
+var (
+	hooksLock sync.RWMutex
+	hooks     []Hook
+)
+
+// AddHook registers hook to be called for every logged request, whatever
+// the logger's verbosity.
+func AddHook(hook Hook) {
+	hooksLock.Lock()
+	defer hooksLock.Unlock()
+	hooks = append(hooks, hook)
+}
+
+// user returns the user named by req's basic auth, or "-".
+func user(req *http.Request) string {
+	auth := req.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Basic ") {
+		return "-"
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(auth, "Basic "))
+	if err != nil {
+		return "-"
+	}
+	name := strings.SplitN(string(decoded), ":", 2)[0]
+	if name == "" {
+		return "-"
+	}
+	return name
+}
+
 type logger interface {
 	Addf(format string, data ...interface{})
 }
@@ -48,9 +102,11 @@ type logger interface {
 // message sources.
 type respLogger struct {
 	status      int
+	size        int
 	statusStack string
 	addedInfo   string
 	startTime   time.Time
+	verbosity   glog.Level
 
 	req *http.Request
 	w   http.ResponseWriter
@@ -140,15 +196,41 @@ func StatusIsNot(statuses ...int) StacktracePred {
 	}
 }
 
+// Verbosity sets the glog verbosity at which requests are logged. The
+// default, 0, logs every request. Hooks are called either way.
+func (rl *respLogger) Verbosity(level glog.Level) *respLogger {
+	rl.verbosity = level
+	return rl
+}
+
 // Addf adds additional data to be logged with this request.
 func (rl *respLogger) Addf(format string, data ...interface{}) {
 	rl.addedInfo += "\n" + fmt.Sprintf(format, data...)
 }
 
+// Entry returns what has been captured about the request so far.
+func (rl *respLogger) Entry() Entry {
+	return Entry{
+		Method:  rl.req.Method,
+		Path:    rl.req.RequestURI,
+		User:    user(rl.req),
+		Status:  rl.status,
+		Size:    rl.size,
+		Latency: time.Since(rl.startTime),
+	}
+}
+
 // Log is intended to be called once at the end of your request handler, via defer
 func (rl *respLogger) Log() {
-	latency := time.Since(rl.startTime)
-	glog.Infof("%s %s: (%v) %v%v%v", rl.req.Method, rl.req.RequestURI, latency, rl.status, rl.statusStack, rl.addedInfo)
+	entry := rl.Entry()
+	if glog.V(rl.verbosity) {
+		glog.Infof("%v%v%v", entry, rl.statusStack, rl.addedInfo)
+	}
+	hooksLock.RLock()
+	defer hooksLock.RUnlock()
+	for _, hook := range hooks {
+		hook(rl.req, entry)
+	}
 }
 
 // Header implements http.ResponseWriter.
@@ -158,7 +240,13 @@ func (rl *respLogger) Header() http.Header {
 
 // Write implements http.ResponseWriter.
 func (rl *respLogger) Write(b []byte) (int, error) {
-	return rl.w.Write(b)
+	if rl.status == 0 {
+		// The first write implies a 200, as it does for http.ResponseWriter.
+		rl.status = http.StatusOK
+	}
+	n, err := rl.w.Write(b)
+	rl.size += n
+	return n, err
 }
 
 // WriteHeader implements http.ResponseWriter.
@@ -18,9 +18,11 @@ package httplog
 
 import (
 	"bytes"
+	"encoding/base64"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -129,3 +131,57 @@ func TestUnlogged(t *testing.T) {
 		handler(w, req)
 	}
 }
+
+func TestLogEntry(t *testing.T) {
+	var got []Entry
+	AddHook(func(req *http.Request, entry Entry) {
+		got = append(got, entry)
+	})
+	defer func() { hooks = nil }()
+
+	handler := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	}), DefaultStacktracePred)
+	req, err := http.NewRequest("GET", "http://example.com/kube?x=y", nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	req.RequestURI = "/kube?x=y"
+	req.SetBasicAuth("alice", "secret")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(got) != 1 {
+		t.Fatalf("Expected one entry, got %#v", got)
+	}
+	entry := got[0]
+	if entry.Method != "GET" || entry.Path != "/kube?x=y" || entry.User != "alice" || entry.Status != http.StatusOK || entry.Size != 5 {
+		t.Errorf("Unexpected entry %#v", entry)
+	}
+	if e, a := `method=GET path="/kube?x=y" user="alice" status=200 size=5`, entry.String(); !strings.HasPrefix(a, e) {
+		t.Errorf("Expected %q to start with %q", a, e)
+	}
+}
+
+func TestUser(t *testing.T) {
+	table := map[string]string{
+		"":                          "-",
+		"Bearer token":              "-",
+		"Basic !!!":                 "-",
+		"Basic " + b64("bob:pass"):  "bob",
+		"Basic " + b64("bob"):       "bob",
+		"Basic " + b64(":password"): "-",
+	}
+	for auth, expected := range table {
+		req, _ := http.NewRequest("GET", "http://example.com", nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		if got := user(req); got != expected {
+			t.Errorf("%q: expected %q, got %q", auth, expected, got)
+		}
+	}
+}
+
+func b64(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
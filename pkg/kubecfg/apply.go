@@ -0,0 +1,199 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubecfg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"gopkg.in/v1/yaml"
+)
+
+// Apply makes the live object resource/id match data, a JSON or YAML object
+// such as kubecfg reads with -c.  The fields given in data replace the live
+// object's, and the rest, such as those the server fills in, are kept, so
+// applying the same data again changes nothing.  Objects are merged field by
+// field, while lists are replaced whole.  Since no record is kept of earlier
+// applies, a field removed from data is left as it was.
+//
+// Apply only updates the object if it would change, and never if dryRun is
+// set.  It returns a diff of the change, in YAML, which is empty if there is
+// none.
+func Apply(resource, id string, data []byte, c *client.RESTClient, dryRun bool) (string, error) {
+	var given interface{}
+	if err := yaml.Unmarshal(data, &given); err != nil {
+		return "", err
+	}
+	fields, ok := jsonValue(given).(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("expected an object, got %v", given)
+	}
+	if givenID, ok := fields["id"]; ok && givenID != id {
+		return "", fmt.Errorf("the object's id is %v, not %s", givenID, id)
+	}
+
+	liveData, err := c.Get().Path(resource).Path(id).Do().Raw()
+	if err != nil {
+		return "", err
+	}
+	live, err := c.Codec.Decode(liveData)
+	if err != nil {
+		return "", err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(liveData))
+	decoder.UseNumber()
+	var merged map[string]interface{}
+	if err := decoder.Decode(&merged); err != nil {
+		return "", err
+	}
+	resourceVersion, hasVersion := merged["resourceVersion"]
+	mergeFields(merged, fields)
+	// Always update the version that was read, so that a concurrent
+	// change is refused rather than overwritten.
+	if hasVersion {
+		merged["resourceVersion"] = resourceVersion
+	} else {
+		delete(merged, "resourceVersion")
+	}
+	mergedData, err := json.Marshal(merged)
+	if err != nil {
+		return "", err
+	}
+	updated, err := c.Codec.Decode(mergedData)
+	if err != nil {
+		return "", err
+	}
+	if reflect.DeepEqual(live, updated) {
+		return "", nil
+	}
+
+	liveYAML, updatedYAML := &bytes.Buffer{}, &bytes.Buffer{}
+	printer := &YAMLPrinter{}
+	if err := printer.PrintObj(live, liveYAML); err != nil {
+		return "", err
+	}
+	if err := printer.PrintObj(updated, updatedYAML); err != nil {
+		return "", err
+	}
+	diff := diffLines(liveYAML.String(), updatedYAML.String())
+	if dryRun {
+		return diff, nil
+	}
+	return diff, c.Put().Path(resource).Path(id).Body(updated).Do().Error()
+}
+
+// jsonValue converts a value decoded from YAML, whose maps may have any keys,
+// into one that can be encoded as JSON.
+func jsonValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[interface{}]interface{}:
+		obj := map[string]interface{}{}
+		for k, v := range value {
+			obj[fmt.Sprint(k)] = jsonValue(v)
+		}
+		return obj
+	case []interface{}:
+		for i, v := range value {
+			value[i] = jsonValue(v)
+		}
+	}
+	return value
+}
+
+// mergeFields sets the fields of obj to those of fields, merging objects
+// field by field.
+func mergeFields(obj, fields map[string]interface{}) {
+	for k, v := range fields {
+		if vObj, ok := v.(map[string]interface{}); ok {
+			if existing, ok := obj[k].(map[string]interface{}); ok {
+				mergeFields(existing, vObj)
+				continue
+			}
+		}
+		obj[k] = v
+	}
+}
+
+// diffLines returns the lines which differ between a and b, prefixed with
+// "-" if they are only in a and "+" if they are only in b, with a few lines
+// of context.
+func diffLines(a, b string) string {
+	const context = 3
+	x := strings.Split(strings.TrimSuffix(a, "\n"), "\n")
+	y := strings.Split(strings.TrimSuffix(b, "\n"), "\n")
+	// common[i][j] is the length of the longest common subsequence of
+	// x[i:] and y[j:].
+	common := make([][]int, len(x)+1)
+	for i := range common {
+		common[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else if common[i+1][j] >= common[i][j+1] {
+				common[i][j] = common[i+1][j]
+			} else {
+				common[i][j] = common[i][j+1]
+			}
+		}
+	}
+	lines := []string{}
+	for i, j := 0, 0; i < len(x) || j < len(y); {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			lines = append(lines, " "+x[i])
+			i++
+			j++
+		case i < len(x) && (j == len(y) || common[i+1][j] >= common[i][j+1]):
+			lines = append(lines, "-"+x[i])
+			i++
+		default:
+			lines = append(lines, "+"+y[j])
+			j++
+		}
+	}
+
+	out := []string{}
+	skipped := false
+	for i, line := range lines {
+		near := false
+		for k := i - context; k <= i+context; k++ {
+			if k >= 0 && k < len(lines) && lines[k][0] != ' ' {
+				near = true
+				break
+			}
+		}
+		if !near {
+			if !skipped && len(out) > 0 {
+				out = append(out, "...")
+			}
+			skipped = true
+			continue
+		}
+		out = append(out, line)
+		skipped = false
+	}
+	if len(out) == 0 {
+		return ""
+	}
+	return strings.Join(out, "\n") + "\n"
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubecfg
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/latest"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+)
+
+// applyServer serves a pod, recording the updates it receives.
+type applyServer struct {
+	t       *testing.T
+	pod     api.Pod
+	updates []*api.Pod
+}
+
+func (s *applyServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path != "/api/v1beta1/pods/foo" {
+		http.NotFound(w, req)
+		return
+	}
+	if req.Method == "PUT" {
+		body, _ := ioutil.ReadAll(req.Body)
+		pod := &api.Pod{}
+		if err := latest.Codec.DecodeInto(body, pod); err != nil {
+			s.t.Errorf("Unexpected error: %v", err)
+		}
+		s.updates = append(s.updates, pod)
+	}
+	data, _ := latest.Codec.Encode(&s.pod)
+	w.Write(data)
+}
+
+func TestApply(t *testing.T) {
+	server := &applyServer{
+		t: t,
+		pod: api.Pod{
+			JSONBase: api.JSONBase{ID: "foo", ResourceVersion: 5},
+			Labels:   map[string]string{"name": "foo"},
+			DesiredState: api.PodState{
+				Manifest: api.ContainerManifest{
+					Version:    "v1beta1",
+					Containers: []api.Container{{Name: "web", Image: "nginx"}},
+				},
+			},
+			CurrentState: api.PodState{Host: "machine"},
+		},
+	}
+	testServer := httptest.NewServer(server)
+	defer testServer.Close()
+	c := client.NewOrDie(testServer.URL, nil)
+
+	unchanged := "id: foo\nlabels:\n  name: foo\ndesiredState:\n  manifest:\n    containers:\n    - name: web\n      image: nginx\n"
+	diff, err := Apply("pods", "foo", []byte(unchanged), c.RESTClient, false)
+	if err != nil || diff != "" || len(server.updates) != 0 {
+		t.Errorf("Expected no change, got %q %v %#v", diff, err, server.updates)
+	}
+
+	changed := `{"labels": {"name": "foo", "tier": "web"}, "desiredState": {"manifest": {"containers": [{"name": "web", "image": "nginx:1.7"}]}}}`
+	diff, err = Apply("pods", "foo", []byte(changed), c.RESTClient, true)
+	if err != nil || len(server.updates) != 0 {
+		t.Errorf("Expected a dry run, got %v %#v", err, server.updates)
+	}
+	for _, expected := range []string{"-    - image: nginx\n", "+    - image: nginx:1.7\n", "+  tier: web\n"} {
+		if !strings.Contains(diff, expected) {
+			t.Errorf("Expected %q in diff:\n%s", expected, diff)
+		}
+	}
+
+	if _, err := Apply("pods", "foo", []byte(changed), c.RESTClient, false); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(server.updates) != 1 {
+		t.Fatalf("Expected an update, got %#v", server.updates)
+	}
+	updated := server.updates[0]
+	if updated.ResourceVersion != 5 || updated.CurrentState.Host != "machine" || updated.DesiredState.Manifest.Version != "v1beta1" {
+		t.Errorf("Expected the fields not given to be kept, got %#v", updated)
+	}
+	if updated.Labels["tier"] != "web" || updated.DesiredState.Manifest.Containers[0].Image != "nginx:1.7" {
+		t.Errorf("Expected the fields given to be changed, got %#v", updated)
+	}
+
+	if _, err := Apply("pods", "foo", []byte("id: bar\n"), c.RESTClient, false); err == nil {
+		t.Errorf("Expected an error for a different id")
+	}
+	if _, err := Apply("pods", "missing", []byte("id: missing\n"), c.RESTClient, false); err == nil {
+		t.Errorf("Expected an error for a missing object")
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	a := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	b := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\n"
+	expected := " a\n-b\n+B\n c\n d\n e\n...\n h\n i\n j\n+k\n"
+	if diff := diffLines(a, b); diff != expected {
+		t.Errorf("Unexpected diff:\n%s", diff)
+	}
+	if diff := diffLines(a, a); diff != "" {
+		t.Errorf("Expected no diff, got:\n%s", diff)
+	}
+}
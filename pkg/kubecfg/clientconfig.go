@@ -0,0 +1,237 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubecfg
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"gopkg.in/v1/yaml"
+)
+
+// ClientConfig is the contents of a client config file.  It names clusters
+// and credentials, and contexts that pair them, so that one kubecfg can be
+// pointed at several clusters without editing credentials files by hand.
+type ClientConfig struct {
+	Clusters    map[string]Cluster         `json:"clusters,omitempty" yaml:"clusters,omitempty"`
+	Credentials map[string]client.AuthInfo `json:"credentials,omitempty" yaml:"credentials,omitempty"`
+	Contexts    map[string]Context         `json:"contexts,omitempty" yaml:"contexts,omitempty"`
+	// CurrentContext is the context used when no master is given.
+	CurrentContext string `json:"currentContext,omitempty" yaml:"currentContext,omitempty"`
+}
+
+// Cluster is a Kubernetes API server.
+type Cluster struct {
+	Server string `json:"server" yaml:"server"`
+}
+
+// Context pairs a cluster with the credentials used to talk to it.
+type Context struct {
+	Cluster     string `json:"cluster" yaml:"cluster"`
+	Credentials string `json:"credentials,omitempty" yaml:"credentials,omitempty"`
+}
+
+// Current returns the cluster and credentials of the current context, or
+// false if there is no current context.  auth is nil if the context has no
+// credentials.
+func (c *ClientConfig) Current() (cluster Cluster, auth *client.AuthInfo, ok bool) {
+	return c.Context(c.CurrentContext)
+}
+
+// Context returns the cluster and credentials of the named context, or false
+// if there is no such context.  auth is nil if the context has no credentials.
+func (c *ClientConfig) Context(name string) (cluster Cluster, auth *client.AuthInfo, ok bool) {
+	context, ok := c.Contexts[name]
+	if !ok {
+		return Cluster{}, nil, false
+	}
+	cluster, ok = c.Clusters[context.Cluster]
+	if !ok {
+		return Cluster{}, nil, false
+	}
+	if credentials, ok := c.Credentials[context.Credentials]; ok {
+		auth = &credentials
+	}
+	return cluster, auth, true
+}
+
+// SetCluster adds or replaces the named cluster.
+func (c *ClientConfig) SetCluster(name string, cluster Cluster) {
+	if c.Clusters == nil {
+		c.Clusters = map[string]Cluster{}
+	}
+	c.Clusters[name] = cluster
+}
+
+// SetCredentials adds or replaces the named credentials.
+func (c *ClientConfig) SetCredentials(name string, auth client.AuthInfo) {
+	if c.Credentials == nil {
+		c.Credentials = map[string]client.AuthInfo{}
+	}
+	c.Credentials[name] = auth
+}
+
+// SetContext adds or replaces the named context.
+func (c *ClientConfig) SetContext(name string, context Context) {
+	if c.Contexts == nil {
+		c.Contexts = map[string]Context{}
+	}
+	c.Contexts[name] = context
+}
+
+// UseContext makes the named context current.  It fails if there is no such
+// context.
+func (c *ClientConfig) UseContext(name string) error {
+	if _, ok := c.Contexts[name]; !ok {
+		return fmt.Errorf("no context named %q", name)
+	}
+	c.CurrentContext = name
+	return nil
+}
+
+// Minify returns a copy of c holding only the current context and the
+// cluster and credentials it uses.
+func (c *ClientConfig) Minify() *ClientConfig {
+	minified := &ClientConfig{CurrentContext: c.CurrentContext}
+	context, ok := c.Contexts[c.CurrentContext]
+	if !ok {
+		return minified
+	}
+	minified.Contexts = map[string]Context{c.CurrentContext: context}
+	if cluster, ok := c.Clusters[context.Cluster]; ok {
+		minified.Clusters = map[string]Cluster{context.Cluster: cluster}
+	}
+	if credentials, ok := c.Credentials[context.Credentials]; ok {
+		minified.Credentials = map[string]client.AuthInfo{context.Credentials: credentials}
+	}
+	return minified
+}
+
+// Redact returns a copy of c with passwords and tokens hidden, for printing.
+func (c *ClientConfig) Redact() *ClientConfig {
+	redacted := *c
+	if c.Credentials != nil {
+		redacted.Credentials = map[string]client.AuthInfo{}
+		for name, credentials := range c.Credentials {
+			if len(credentials.Password) > 0 {
+				credentials.Password = "REDACTED"
+			}
+			if len(credentials.BearerToken) > 0 {
+				credentials.BearerToken = "REDACTED"
+			}
+			redacted.Credentials[name] = credentials
+		}
+	}
+	return &redacted
+}
+
+// Print writes c to w as YAML.
+func (c *ClientConfig) Print(w io.Writer) error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// LoadClientConfig reads a client config file.  A missing file is an empty
+// config.
+func LoadClientConfig(path string) (*ClientConfig, error) {
+	config := &ClientConfig{}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return config, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("couldn't parse %s: %v", path, err)
+	}
+	return config, nil
+}
+
+// How long ModifyClientConfig waits for another kubecfg to release the lock.
+var clientConfigLockTimeout = 10 * time.Second
+
+// ModifyClientConfig applies modify to the client config file at path.  The
+// file is locked while it is read and written, so concurrent invocations of
+// kubecfg don't lose each other's changes, and it is replaced in one rename,
+// so readers never see a partial file.  Nothing is written if modify fails.
+func ModifyClientConfig(path string, modify func(*ClientConfig) error) error {
+	unlock, err := lockFile(path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	config, err := LoadClientConfig(path)
+	if err != nil {
+		return err
+	}
+	if err := modify(config); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+	temp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	_, err = temp.Write(data)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	// The file holds passwords.
+	if err := os.Chmod(temp.Name(), 0600); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), path)
+}
+
+// lockFile takes a lock by creating path, which must not already exist, and
+// returns a func that releases it.
+func lockFile(path string) (func(), error) {
+	deadline := time.Now().Add(clientConfigLockTimeout)
+	for {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for %s; remove it if no other kubecfg is running", path)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
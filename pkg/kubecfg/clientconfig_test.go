@@ -0,0 +1,188 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubecfg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+)
+
+func newTestConfig() *ClientConfig {
+	config := &ClientConfig{}
+	config.SetCluster("prod", Cluster{Server: "https://prod"})
+	config.SetCluster("dev", Cluster{Server: "http://dev"})
+	config.SetCredentials("admin", client.AuthInfo{User: "admin", Password: "secret"})
+	config.SetContext("prod", Context{Cluster: "prod", Credentials: "admin"})
+	config.SetContext("dev", Context{Cluster: "dev"})
+	return config
+}
+
+func TestClientConfigCurrent(t *testing.T) {
+	config := newTestConfig()
+	if _, _, ok := config.Current(); ok {
+		t.Errorf("Expected no current context")
+	}
+	if err := config.UseContext("missing"); err == nil {
+		t.Errorf("Expected an error using a missing context")
+	}
+
+	if err := config.UseContext("prod"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	cluster, auth, ok := config.Current()
+	if !ok || cluster.Server != "https://prod" || auth == nil || auth.User != "admin" {
+		t.Errorf("Unexpected current context: %v %v %v", cluster, auth, ok)
+	}
+
+	if err := config.UseContext("dev"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	cluster, auth, ok = config.Current()
+	if !ok || cluster.Server != "http://dev" || auth != nil {
+		t.Errorf("Unexpected current context: %v %v %v", cluster, auth, ok)
+	}
+}
+
+func TestClientConfigContext(t *testing.T) {
+	config := newTestConfig()
+	config.UseContext("dev")
+	cluster, auth, ok := config.Context("prod")
+	if !ok || cluster.Server != "https://prod" || auth == nil || auth.User != "admin" {
+		t.Errorf("Unexpected context: %v %v %v", cluster, auth, ok)
+	}
+	if _, _, ok := config.Context("missing"); ok {
+		t.Errorf("Expected no context named missing")
+	}
+}
+
+func TestLoadClientConfigCredentials(t *testing.T) {
+	file, err := ioutil.TempFile("", "clientconfig")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString(`{
+  "clusters": {"prod": {"server": "https://prod"}},
+  "credentials": {"robot": {"BearerToken": "token", "CAFile": "/ca.crt", "CertFile": "/robot.crt", "KeyFile": "/robot.key"}},
+  "contexts": {"prod": {"cluster": "prod", "credentials": "robot"}},
+  "currentContext": "prod"
+}`)
+	file.Close()
+
+	config, err := LoadClientConfig(file.Name())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	_, auth, ok := config.Current()
+	expected := client.AuthInfo{BearerToken: "token", CAFile: "/ca.crt", CertFile: "/robot.crt", KeyFile: "/robot.key"}
+	if !ok || auth == nil || *auth != expected {
+		t.Errorf("Expected %#v, got %#v", expected, auth)
+	}
+	if token := config.Redact().Credentials["robot"].BearerToken; token != "REDACTED" {
+		t.Errorf("Expected the token to be redacted, got %q", token)
+	}
+}
+
+func TestClientConfigMinifyAndRedact(t *testing.T) {
+	config := newTestConfig()
+	config.UseContext("prod")
+
+	minified := config.Minify().Redact()
+	expected := &ClientConfig{
+		Clusters:       map[string]Cluster{"prod": {Server: "https://prod"}},
+		Credentials:    map[string]client.AuthInfo{"admin": {User: "admin", Password: "REDACTED"}},
+		Contexts:       map[string]Context{"prod": {Cluster: "prod", Credentials: "admin"}},
+		CurrentContext: "prod",
+	}
+	if !reflect.DeepEqual(minified, expected) {
+		t.Errorf("Expected %#v, got %#v", expected, minified)
+	}
+	if config.Credentials["admin"].Password != "secret" {
+		t.Errorf("Redact modified the original config")
+	}
+}
+
+func TestModifyClientConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "clientconfig")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config")
+
+	config, err := LoadClientConfig(path)
+	if err != nil {
+		t.Fatalf("Unexpected error loading a missing file: %v", err)
+	}
+	if !reflect.DeepEqual(config, &ClientConfig{}) {
+		t.Errorf("Expected an empty config, got %#v", config)
+	}
+
+	// Concurrent modifications must not lose each other's changes.
+	names := []string{"a", "b", "c", "d", "e"}
+	wg := sync.WaitGroup{}
+	for _, name := range names {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			err := ModifyClientConfig(path, func(config *ClientConfig) error {
+				config.SetCluster(name, Cluster{Server: "http://" + name})
+				return nil
+			})
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}(name)
+	}
+	wg.Wait()
+
+	config, err = LoadClientConfig(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(config.Clusters) != len(names) {
+		t.Errorf("Expected %d clusters, got %#v", len(names), config.Clusters)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected mode 0600, got %v", info.Mode())
+	}
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("Expected the lock to be released, got %v", err)
+	}
+
+	err = ModifyClientConfig(path, func(config *ClientConfig) error {
+		config.SetCluster("f", Cluster{Server: "http://f"})
+		return config.UseContext("missing")
+	})
+	if err == nil {
+		t.Errorf("Expected an error")
+	}
+	config, _ = LoadClientConfig(path)
+	if _, ok := config.Clusters["f"]; ok {
+		t.Errorf("Expected a failed modification not to be written")
+	}
+}
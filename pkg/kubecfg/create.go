@@ -0,0 +1,197 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubecfg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/latest"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+)
+
+// LoadObjects reads the objects in path, which is a file or a directory.  Of
+// a directory, only the .json, .yaml and .yml files are read, in name order.
+// See DecodeObjects for what a file may hold.
+func LoadObjects(path string) ([]runtime.Object, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{path}
+	if info.IsDir() {
+		entries, err := ioutil.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		files = []string{}
+		for _, entry := range entries {
+			switch filepath.Ext(entry.Name()) {
+			case ".json", ".yaml", ".yml":
+				if !entry.IsDir() {
+					files = append(files, filepath.Join(path, entry.Name()))
+				}
+			}
+		}
+	}
+	objs := []runtime.Object{}
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		fileObjs, err := DecodeObjects(data)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %v", file, err)
+		}
+		objs = append(objs, fileObjs...)
+	}
+	return objs, nil
+}
+
+// DecodeObjects decodes the objects in data, which holds a JSON array of
+// objects, or one or more YAML (or JSON) documents separated by "---" lines.
+// Each object must give its kind.
+func DecodeObjects(data []byte) ([]runtime.Object, error) {
+	docs := [][]byte{}
+	if trimmed := bytes.TrimSpace(data); bytes.HasPrefix(trimmed, []byte("[")) {
+		var items []json.RawMessage
+		if err := json.Unmarshal(trimmed, &items); err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			docs = append(docs, item)
+		}
+	} else {
+		doc := []string{}
+		for _, line := range append(strings.Split(string(data), "\n"), "---") {
+			if strings.TrimSpace(line) != "---" {
+				doc = append(doc, line)
+				continue
+			}
+			if text := strings.Join(doc, "\n"); strings.TrimSpace(text) != "" {
+				docs = append(docs, []byte(text))
+			}
+			doc = []string{}
+		}
+	}
+	objs := []runtime.Object{}
+	for i, doc := range docs {
+		obj, err := latest.Codec.Decode(doc)
+		if err != nil {
+			return nil, fmt.Errorf("object %d: %v", i+1, err)
+		}
+		objs = append(objs, obj)
+	}
+	return objs, nil
+}
+
+// CreateResult is the outcome of creating an object.
+type CreateResult struct {
+	// Resource is the object's resource type, e.g. "pods".
+	Resource string
+	ID       string
+	Err      error
+}
+
+// createOrder ranks the kinds of object CreateObjects creates.  Services come
+// first, so that the pods started after them can find them.
+var createOrder = map[string]int{
+	"services":               0,
+	"replicationControllers": 1,
+	"pods":                   2,
+}
+
+// CreateObjects creates objs, services first, then replication controllers,
+// then pods, and otherwise in the order given.  It carries on past failures,
+// and returns the outcome for each object in the order they were created.
+func CreateObjects(objs []runtime.Object, c client.Interface) []CreateResult {
+	creates := make(byCreateOrder, len(objs))
+	for i, obj := range objs {
+		creates[i] = create{obj, CreateResult{Resource: resourceFor(obj)}}
+		if jsonBase, err := runtime.FindJSONBase(obj); err == nil {
+			creates[i].result.ID = jsonBase.ID()
+		}
+	}
+	sort.Stable(creates)
+
+	results := []CreateResult{}
+	for _, create := range creates {
+		result := create.result
+		switch obj := create.obj.(type) {
+		case *api.Service:
+			_, result.Err = c.CreateService(obj)
+		case *api.ReplicationController:
+			_, result.Err = c.CreateReplicationController(obj)
+		case *api.Pod:
+			_, result.Err = c.CreatePod(obj)
+		default:
+			result.Err = fmt.Errorf("can't create objects of type %T", obj)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// resourceFor returns the resource type of obj, e.g. "pods".
+func resourceFor(obj runtime.Object) string {
+	switch obj.(type) {
+	case *api.Service:
+		return "services"
+	case *api.ReplicationController:
+		return "replicationControllers"
+	case *api.Pod:
+		return "pods"
+	}
+	return fmt.Sprintf("%T", obj)
+}
+
+// create is an object for CreateObjects to create.
+type create struct {
+	obj    runtime.Object
+	result CreateResult
+}
+
+// byCreateOrder sorts creates by createOrder.
+type byCreateOrder []create
+
+func (b byCreateOrder) Len() int {
+	return len(b)
+}
+
+func (b byCreateOrder) Swap(i, j int) {
+	b[i], b[j] = b[j], b[i]
+}
+
+func (b byCreateOrder) Less(i, j int) bool {
+	return b.rank(i) < b.rank(j)
+}
+
+func (b byCreateOrder) rank(i int) int {
+	if rank, ok := createOrder[b[i].result.Resource]; ok {
+		return rank
+	}
+	return len(createOrder)
+}
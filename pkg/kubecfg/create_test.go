@@ -0,0 +1,139 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubecfg
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+)
+
+func TestDecodeObjects(t *testing.T) {
+	testCases := map[string][]string{
+		`{"kind": "Pod", "apiVersion": "v1beta1", "id": "foo"}`: {"foo"},
+		`[{"kind": "Pod", "apiVersion": "v1beta1", "id": "foo"},
+		  {"kind": "Service", "apiVersion": "v1beta1", "id": "bar", "port": 80}]`: {"foo", "bar"},
+		"kind: Pod\napiVersion: v1beta1\nid: foo\n":                                                               {"foo"},
+		"---\nkind: Pod\napiVersion: v1beta1\nid: foo\n---\n\n---\nkind: Service\napiVersion: v1beta1\nid: bar\n": {"foo", "bar"},
+		"": {},
+	}
+	for data, expected := range testCases {
+		objs, err := DecodeObjects([]byte(data))
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", data, err)
+			continue
+		}
+		ids := []string{}
+		for _, obj := range objs {
+			jsonBase, err := runtime.FindJSONBase(obj)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			ids = append(ids, jsonBase.ID())
+		}
+		if !reflect.DeepEqual(expected, ids) {
+			t.Errorf("%q: expected %v, got %v", data, expected, ids)
+		}
+	}
+
+	for _, data := range []string{`[{"kind": "Pod"`, "id: foo\n", "kind: Unknown\napiVersion: v1beta1\n"} {
+		if _, err := DecodeObjects([]byte(data)); err == nil {
+			t.Errorf("%q: expected an error", data)
+		}
+	}
+}
+
+func TestLoadObjects(t *testing.T) {
+	dir, err := ioutil.TempDir("", "objects")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"b.yaml":    "kind: Pod\napiVersion: v1beta1\nid: b\n",
+		"a.json":    `{"kind": "Pod", "apiVersion": "v1beta1", "id": "a"}`,
+		"README.md": "not an object",
+	}
+	for name, data := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	objs, err := LoadObjects(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(objs) != 2 || objs[0].(*api.Pod).ID != "a" || objs[1].(*api.Pod).ID != "b" {
+		t.Errorf("unexpected objects: %#v", objs)
+	}
+
+	objs, err = LoadObjects(filepath.Join(dir, "b.yaml"))
+	if err != nil || len(objs) != 1 {
+		t.Errorf("unexpected objects: %#v %v", objs, err)
+	}
+
+	if _, err := LoadObjects(filepath.Join(dir, "missing.json")); err == nil {
+		t.Errorf("expected an error for a missing file")
+	}
+}
+
+func TestCreateObjects(t *testing.T) {
+	fakeClient := &client.Fake{}
+	fakeClient.AddReactor("create-controller", func(client.FakeAction) (bool, interface{}, error) {
+		return true, nil, errors.New("test error")
+	})
+	objs := []runtime.Object{
+		&api.Pod{JSONBase: api.JSONBase{ID: "pod"}},
+		&api.ReplicationController{JSONBase: api.JSONBase{ID: "controller"}},
+		&api.Minion{JSONBase: api.JSONBase{ID: "minion"}},
+		&api.Service{JSONBase: api.JSONBase{ID: "service"}},
+	}
+	results := CreateObjects(objs, fakeClient)
+
+	expected := []struct {
+		resource, id string
+		err          bool
+	}{
+		{"services", "service", false},
+		{"replicationControllers", "controller", true},
+		{"pods", "pod", false},
+		{"*api.Minion", "minion", true},
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("unexpected results: %#v", results)
+	}
+	for i, e := range expected {
+		if a := results[i]; a.Resource != e.resource || a.ID != e.id || (a.Err != nil) != e.err {
+			t.Errorf("%d: expected %v, got %#v", i, e, a)
+		}
+	}
+	actions := []string{}
+	for _, action := range fakeClient.Actions {
+		actions = append(actions, action.Action)
+	}
+	if e := []string{"create-service", "create-controller", "create-pod"}; !reflect.DeepEqual(e, actions) {
+		t.Errorf("expected actions %v, got %v", e, actions)
+	}
+}
@@ -0,0 +1,241 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubecfg
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+)
+
+// Describe returns a readable report on the resource with the given id,
+// together with the objects related to it: the controllers and history of a
+// pod, the pods of a replicationController, the endpoints and pods of a
+// service, or the pods on a minion.
+func Describe(resource, id string, c client.Interface) (string, error) {
+	buf := &bytes.Buffer{}
+	w := tabwriter.NewWriter(buf, 0, 8, 2, ' ', 0)
+	var err error
+	switch resource {
+	case "pods":
+		err = describePod(id, c, w)
+	case "replicationControllers":
+		err = describeReplicationController(id, c, w)
+	case "services":
+		err = describeService(id, c, w)
+	case "minions":
+		err = describeMinion(id, c, w)
+	default:
+		return "", fmt.Errorf("describe is not supported for %s", resource)
+	}
+	if err != nil {
+		return "", err
+	}
+	w.Flush()
+	return buf.String(), nil
+}
+
+// podEvent is something which happened to a pod, for the history in a pod's
+// description.
+type podEvent struct {
+	Time    time.Time
+	Message string
+}
+
+type byTime []podEvent
+
+func (e byTime) Len() int           { return len(e) }
+func (e byTime) Swap(i, j int)      { e[i], e[j] = e[j], e[i] }
+func (e byTime) Less(i, j int) bool { return e[i].Time.Before(e[j].Time) }
+
+// podEvents returns the history of a pod which can be told from its
+// transition timestamps and the states of its containers, oldest first.
+func podEvents(pod *api.Pod) []podEvent {
+	events := []podEvent{}
+	add := func(t time.Time, format string, args ...interface{}) {
+		if !t.IsZero() {
+			events = append(events, podEvent{t, fmt.Sprintf(format, args...)})
+		}
+	}
+	add(pod.CreationTimestamp.Time, "Created")
+	add(pod.CurrentState.ScheduledAt.Time, "Scheduled to %s", pod.CurrentState.Host)
+	add(pod.CurrentState.StartedAt.Time, "Started")
+	add(pod.CurrentState.ReadyAt.Time, "Ready")
+	for _, container := range pod.DesiredState.Manifest.Containers {
+		info, ok := pod.CurrentState.Info[container.Name]
+		if !ok {
+			continue
+		}
+		add(info.State.StartedAt, "Container %s started", container.Name)
+		if !info.State.Running {
+			add(info.State.FinishedAt, "Container %s exited with code %d", container.Name, info.State.ExitCode)
+		}
+	}
+	sort.Stable(byTime(events))
+	return events
+}
+
+func describePod(id string, c client.Interface, w io.Writer) error {
+	pod, err := c.GetPod(id)
+	if err != nil {
+		return err
+	}
+	controllers, err := c.ListReplicationControllers(labels.Everything())
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "ID:\t%s\n", pod.ID)
+	fmt.Fprintf(w, "Image(s):\t%s\n", makeImageList(pod.DesiredState.Manifest))
+	fmt.Fprintf(w, "Host:\t%s\n", pod.CurrentState.Host+"/"+pod.CurrentState.HostIP)
+	fmt.Fprintf(w, "Pod IP:\t%s\n", pod.CurrentState.PodIP)
+	fmt.Fprintf(w, "Labels:\t%s\n", labels.Set(pod.Labels))
+	fmt.Fprintf(w, "Status:\t%s\n", pod.CurrentState.Status)
+	fmt.Fprintf(w, "Ready:\t%s\n", formatReady(pod))
+	fmt.Fprintf(w, "Age:\t%s\n", formatAge(pod.CreationTimestamp))
+
+	matching := []string{}
+	for _, controller := range controllers.Items {
+		selector := labels.Set(controller.DesiredState.ReplicaSelector)
+		if len(selector) > 0 && selector.AsSelector().Matches(labels.Set(pod.Labels)) {
+			matching = append(matching, fmt.Sprintf("%s (%d/%d replicas)",
+				controller.ID, controller.CurrentState.Replicas, controller.DesiredState.Replicas))
+		}
+	}
+	fmt.Fprintf(w, "Replication Controllers:\t%s\n", formatList(matching))
+
+	fmt.Fprintf(w, "Containers:\n")
+	for _, container := range pod.DesiredState.Manifest.Containers {
+		state := "Waiting"
+		if info, ok := pod.CurrentState.Info[container.Name]; ok {
+			state = info.State.String()
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\n", container.Name, container.Image, state)
+	}
+
+	fmt.Fprintf(w, "Events:\n")
+	for _, event := range podEvents(pod) {
+		fmt.Fprintf(w, "  %s\t%s\n", event.Time.Format(time.RFC3339), event.Message)
+	}
+	return nil
+}
+
+func describeReplicationController(id string, c client.Interface, w io.Writer) error {
+	controller, err := c.GetReplicationController(id)
+	if err != nil {
+		return err
+	}
+	selector := labels.Set(controller.DesiredState.ReplicaSelector)
+	pods, err := c.ListPods(selector.AsSelector())
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "ID:\t%s\n", controller.ID)
+	fmt.Fprintf(w, "Image(s):\t%s\n", makeImageList(controller.DesiredState.PodTemplate.DesiredState.Manifest))
+	fmt.Fprintf(w, "Selector:\t%s\n", selector)
+	fmt.Fprintf(w, "Labels:\t%s\n", labels.Set(controller.Labels))
+	fmt.Fprintf(w, "Replicas:\t%d current / %d desired\n", controller.CurrentState.Replicas, controller.DesiredState.Replicas)
+	fmt.Fprintf(w, "Pods Status:\t%s\n", formatPodStatuses(pods.Items))
+	describePods(pods.Items, w)
+	return nil
+}
+
+func describeService(id string, c client.Interface, w io.Writer) error {
+	service, err := c.GetService(id)
+	if err != nil {
+		return err
+	}
+	endpoints, err := c.GetEndpoints(id)
+	if err != nil {
+		return err
+	}
+	pods, err := c.ListPods(labels.Set(service.Selector).AsSelector())
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "ID:\t%s\n", service.ID)
+	fmt.Fprintf(w, "Labels:\t%s\n", labels.Set(service.Labels))
+	fmt.Fprintf(w, "Selector:\t%s\n", labels.Set(service.Selector))
+	fmt.Fprintf(w, "Port:\t%d\n", service.Port)
+	fmt.Fprintf(w, "Endpoints:\t%s\n", formatList(endpoints.Endpoints))
+	fmt.Fprintf(w, "Pods Status:\t%s\n", formatPodStatuses(pods.Items))
+	describePods(pods.Items, w)
+	return nil
+}
+
+func describeMinion(id string, c client.Interface, w io.Writer) error {
+	minion, err := c.Minions().Get(id)
+	if err != nil {
+		return err
+	}
+	pods, err := c.ListPods(labels.Everything())
+	if err != nil {
+		return err
+	}
+	running := []api.Pod{}
+	for _, pod := range pods.Items {
+		if pod.CurrentState.Host == id {
+			running = append(running, pod)
+		}
+	}
+
+	fmt.Fprintf(w, "ID:\t%s\n", minion.ID)
+	fmt.Fprintf(w, "Host IP:\t%s\n", minion.HostIP)
+	fmt.Fprintf(w, "Labels:\t%s\n", labels.Set(minion.Labels))
+	fmt.Fprintf(w, "CPU:\t%s\n", formatResource(minion.NodeResources.Capacity, api.ResourceCPU))
+	fmt.Fprintf(w, "Memory:\t%s\n", formatResource(minion.NodeResources.Capacity, api.ResourceMemory))
+	fmt.Fprintf(w, "Age:\t%s\n", formatAge(minion.CreationTimestamp))
+	describePods(running, w)
+	return nil
+}
+
+// describePods writes a line for each of pods.
+func describePods(pods []api.Pod, w io.Writer) {
+	fmt.Fprintf(w, "Pods:\n")
+	for i := range pods {
+		pod := &pods[i]
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", pod.ID, pod.CurrentState.Host, pod.CurrentState.Status, formatReady(pod))
+	}
+}
+
+// formatPodStatuses counts pods by status.
+func formatPodStatuses(pods []api.Pod) string {
+	counts := map[api.PodStatus]int{}
+	for _, pod := range pods {
+		counts[pod.CurrentState.Status]++
+	}
+	return fmt.Sprintf("%d Running / %d Waiting / %d Terminated",
+		counts[api.PodRunning], counts[api.PodWaiting], counts[api.PodTerminated])
+}
+
+// formatList joins items with commas, or returns "<none>" if there are none.
+func formatList(items []string) string {
+	if len(items) == 0 {
+		return "<none>"
+	}
+	return strings.Join(items, ", ")
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubecfg
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/fsouza/go-dockerclient"
+)
+
+// expectLines checks that report has each of the expected lines, ignoring
+// the amount of whitespace between fields.
+func expectLines(t *testing.T, report string, expected ...string) {
+	lines := map[string]bool{}
+	for _, line := range strings.Split(report, "\n") {
+		lines[strings.Join(strings.Fields(line), " ")] = true
+	}
+	for _, line := range expected {
+		if !lines[line] {
+			t.Errorf("Expected %q in report:\n%s", line, report)
+		}
+	}
+}
+
+func TestDescribePod(t *testing.T) {
+	created := time.Date(2014, 7, 1, 10, 0, 0, 0, time.UTC)
+	pod := &api.Pod{
+		JSONBase: api.JSONBase{ID: "foo", CreationTimestamp: util.Time{Time: created}},
+		Labels:   map[string]string{"name": "foo"},
+		DesiredState: api.PodState{Manifest: api.ContainerManifest{
+			Containers: []api.Container{{Name: "web", Image: "nginx"}, {Name: "log", Image: "fluentd"}},
+		}},
+		CurrentState: api.PodState{
+			Host:        "machine",
+			Status:      api.PodTerminated,
+			ScheduledAt: util.Time{Time: created.Add(time.Second)},
+			Info: api.PodInfo{
+				"web": docker.Container{State: docker.State{
+					StartedAt:  created.Add(3 * time.Second),
+					FinishedAt: created.Add(time.Minute),
+					ExitCode:   2,
+				}},
+			},
+		},
+	}
+	fakeClient := &client.Fake{}
+	fakeClient.AddReactor("get-pod", func(client.FakeAction) (bool, interface{}, error) {
+		return true, pod, nil
+	})
+	fakeClient.AddReactor("list-controllers", func(client.FakeAction) (bool, interface{}, error) {
+		return true, &api.ReplicationControllerList{Items: []api.ReplicationController{
+			{JSONBase: api.JSONBase{ID: "frontend"}, DesiredState: api.ReplicationControllerState{Replicas: 2, ReplicaSelector: map[string]string{"name": "foo"}}},
+			{JSONBase: api.JSONBase{ID: "backend"}, DesiredState: api.ReplicationControllerState{ReplicaSelector: map[string]string{"name": "bar"}}},
+			{JSONBase: api.JSONBase{ID: "everything"}},
+		}}, nil
+	})
+	report, err := Describe("pods", "foo", fakeClient)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expectLines(t, report,
+		"ID: foo",
+		"Status: Terminated",
+		"Replication Controllers: frontend (0/2 replicas)",
+		"web nginx Exit 2",
+		"log fluentd Waiting",
+		"2014-07-01T10:00:00Z Created",
+		"2014-07-01T10:00:01Z Scheduled to machine",
+		"2014-07-01T10:00:03Z Container web started",
+		"2014-07-01T10:01:00Z Container web exited with code 2",
+	)
+	if strings.Contains(report, "Z  Ready") {
+		t.Errorf("Expected no Ready event:\n%s", report)
+	}
+}
+
+func TestDescribeService(t *testing.T) {
+	fakeClient := &client.Fake{
+		PodList: api.PodList{Items: []api.Pod{
+			{JSONBase: api.JSONBase{ID: "foo"}, CurrentState: api.PodState{Status: api.PodRunning}},
+			{JSONBase: api.JSONBase{ID: "bar"}, CurrentState: api.PodState{Status: api.PodWaiting}},
+		}},
+	}
+	fakeClient.AddReactor("get-service", func(client.FakeAction) (bool, interface{}, error) {
+		return true, &api.Service{JSONBase: api.JSONBase{ID: "web"}, Port: 80, Selector: map[string]string{"name": "web"}}, nil
+	})
+	fakeClient.AddReactor("get-endpoints", func(client.FakeAction) (bool, interface{}, error) {
+		return true, &api.Endpoints{Endpoints: []string{"10.0.0.1:8080", "10.0.0.2:8080"}}, nil
+	})
+	report, err := Describe("services", "web", fakeClient)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expectLines(t, report,
+		"Selector: name=web",
+		"Endpoints: 10.0.0.1:8080, 10.0.0.2:8080",
+		"Pods Status: 1 Running / 1 Waiting / 0 Terminated",
+		"foo Running 0/0",
+	)
+}
+
+func TestDescribeReplicationController(t *testing.T) {
+	fakeClient := &client.Fake{
+		PodList: api.PodList{Items: []api.Pod{
+			{JSONBase: api.JSONBase{ID: "foo"}, CurrentState: api.PodState{Host: "machine", Status: api.PodRunning}},
+		}},
+	}
+	fakeClient.AddReactor("get-controller", func(client.FakeAction) (bool, interface{}, error) {
+		return true, &api.ReplicationController{
+			JSONBase:     api.JSONBase{ID: "frontend"},
+			DesiredState: api.ReplicationControllerState{Replicas: 2, ReplicaSelector: map[string]string{"name": "web"}},
+			CurrentState: api.ReplicationControllerState{Replicas: 1},
+		}, nil
+	})
+	report, err := Describe("replicationControllers", "frontend", fakeClient)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expectLines(t, report,
+		"Replicas: 1 current / 2 desired",
+		"Pods Status: 1 Running / 0 Waiting / 0 Terminated",
+		"foo machine Running 0/0",
+	)
+	if _, err := Describe("things", "foo", fakeClient); err == nil {
+		t.Errorf("Expected an error for an unsupported resource")
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"strconv"
 	"strings"
@@ -29,6 +30,7 @@ import (
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/util/wait"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/version"
@@ -125,19 +127,165 @@ func Update(name string, client client.Interface, updatePeriod time.Duration, im
 	})
 }
 
-// StopController stops a controller named 'name' by setting replicas to zero.
-func StopController(name string, client client.Interface) error {
-	return ResizeController(name, 0, client)
+// rollingUpdatePollInterval is how often RollingUpdate checks whether the new
+// controller's pods are running.  Overridden in tests.
+var rollingUpdatePollInterval = 5 * time.Second
+
+// RollingUpdate replaces the replication controller named 'oldName' with
+// 'newController' one pod at a time.
+// 'client' is used for creating, resizing and deleting controllers.
+// 'updatePeriod' is the time between steps.
+// 'healthTimeout' is how long to wait after growing the new controller for
+//     all of its pods to be running.  The update stops with an error, leaving
+//     both controllers in place, if they aren't.
+// If the new controller asks for zero replicas it gets as many as the old one
+// had.  Once the old controller has shrunk to zero it is deleted.
+func RollingUpdate(oldName string, newController *api.ReplicationController, client client.Interface, updatePeriod, healthTimeout time.Duration) error {
+	if newController.ID == oldName {
+		return fmt.Errorf("the new controller must have a different name than %s", oldName)
+	}
+	oldController, err := client.GetReplicationController(oldName)
+	if err != nil {
+		return err
+	}
+	oldSelector := labels.Set(oldController.DesiredState.ReplicaSelector).AsSelector()
+	if oldSelector.Matches(labels.Set(newController.DesiredState.PodTemplate.Labels)) {
+		return fmt.Errorf("the selector of %s matches the pods of %s; the new controller's pods need a distinguishing label", oldName, newController.ID)
+	}
+	newSelector := labels.Set(newController.DesiredState.ReplicaSelector).AsSelector()
+	if newSelector.Matches(labels.Set(oldController.DesiredState.PodTemplate.Labels)) {
+		return fmt.Errorf("the selector of %s matches the pods of %s; the new controller's selector needs a distinguishing label", newController.ID, oldName)
+	}
+
+	desired := newController.DesiredState.Replicas
+	if desired == 0 {
+		desired = oldController.DesiredState.Replicas
+	}
+	oldReplicas := oldController.DesiredState.Replicas
+	newReplicas := 0
+	newController.DesiredState.Replicas = newReplicas
+	if _, err := client.CreateReplicationController(newController); err != nil {
+		if isConflict(err) {
+			return fmt.Errorf("%s overlaps an existing replication controller: %s", newController.ID, statusMessage(err))
+		}
+		return err
+	}
+
+	for newReplicas < desired || oldReplicas > 0 {
+		if newReplicas < desired {
+			newReplicas++
+			if _, err := setReplicas(newController.ID, newReplicas, client); err != nil {
+				return err
+			}
+			if err := waitForRunningPods(newSelector, newReplicas, client, healthTimeout); err != nil {
+				return fmt.Errorf("%s did not reach %d running pods: %v", newController.ID, newReplicas, err)
+			}
+		}
+		if oldReplicas > 0 {
+			oldReplicas--
+			if _, err := setReplicas(oldName, oldReplicas, client); err != nil {
+				return err
+			}
+		}
+		time.Sleep(updatePeriod)
+	}
+	return client.DeleteReplicationController(oldName)
 }
 
-// ResizeController resizes a controller named 'name' by setting replicas to 'replicas'.
-func ResizeController(name string, replicas int, client client.Interface) error {
-	controller, err := client.GetReplicationController(name)
+// maxUpdateAttempts bounds how many times setReplicas and Label retry an update which
+// lost a race with another writer.
+const maxUpdateAttempts = 5
+
+// setReplicas sets the replica count of the controller named 'name', leaving
+// the rest of the controller alone.  The update carries the resource version
+// that was read, so a concurrent change to the controller makes it fail with a
+// conflict rather than be overwritten; the read and update are then retried.
+func setReplicas(name string, replicas int, c client.Interface) (*api.ReplicationController, error) {
+	var err error
+	for i := 0; i < maxUpdateAttempts; i++ {
+		var controller *api.ReplicationController
+		controller, err = c.GetReplicationController(name)
+		if err != nil {
+			return nil, err
+		}
+		controller.DesiredState.Replicas = replicas
+		controller, err = c.UpdateReplicationController(controller)
+		if !isConflict(err) {
+			return controller, err
+		}
+		glog.V(2).Infof("Conflict resizing %s, retrying: %v", name, err)
+	}
+	return nil, err
+}
+
+// isConflict returns true if err is the server refusing a write because the
+// object changed since it was read.
+func isConflict(err error) bool {
+	statusErr, ok := err.(*client.StatusErr)
+	return ok && statusErr.Status.Code == http.StatusConflict
+}
+
+// statusMessage returns the server's explanation of err, if err is a status
+// the server returned, rather than the whole status.
+func statusMessage(err error) string {
+	if statusErr, ok := err.(*client.StatusErr); ok && len(statusErr.Status.Message) > 0 {
+		return statusErr.Status.Message
+	}
+	return err.Error()
+}
+
+// waitForRunningPods waits until at least 'count' pods matching 'selector'
+// are running, or 'timeout' elapses.
+func waitForRunningPods(selector labels.Selector, count int, client client.Interface, timeout time.Duration) error {
+	return wait.Poll(rollingUpdatePollInterval, timeout, func() (bool, error) {
+		podList, err := client.ListPods(selector)
+		if err != nil {
+			return false, err
+		}
+		running := 0
+		for _, pod := range podList.Items {
+			if selector.Matches(labels.Set(pod.Labels)) && pod.CurrentState.Status == api.PodRunning {
+				running++
+			}
+		}
+		return running >= count, nil
+	})
+}
+
+// waitForNoPods waits until no pods match 'selector', or 'timeout' elapses.
+func waitForNoPods(selector labels.Selector, client client.Interface, timeout time.Duration) error {
+	return wait.Poll(rollingUpdatePollInterval, timeout, func() (bool, error) {
+		podList, err := client.ListPods(selector)
+		if err != nil {
+			return false, err
+		}
+		for _, pod := range podList.Items {
+			if selector.Matches(labels.Set(pod.Labels)) {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+}
+
+// StopController stops a controller named 'name' by setting replicas to zero, waiting up to
+// 'timeout' for its pods to be deleted, and then deleting the controller.  Deleting a controller
+// which still has pods would leave them running with nothing to manage them.
+func StopController(name string, client client.Interface, timeout time.Duration) error {
+	controller, err := setReplicas(name, 0, client)
 	if err != nil {
 		return err
 	}
-	controller.DesiredState.Replicas = replicas
-	controllerOut, err := client.UpdateReplicationController(controller)
+	selector := labels.Set(controller.DesiredState.ReplicaSelector).AsSelector()
+	if err := waitForNoPods(selector, client, timeout); err != nil {
+		return fmt.Errorf("controller %s was resized to 0, but its pods remain: %v", name, err)
+	}
+	return client.DeleteReplicationController(name)
+}
+
+// ResizeController resizes a controller named 'name' by setting replicas to 'replicas'.
+func ResizeController(name string, replicas int, client client.Interface) error {
+	controllerOut, err := setReplicas(name, replicas, client)
 	if err != nil {
 		return err
 	}
@@ -174,14 +322,24 @@ func portsFromString(spec string) []api.Port {
 }
 
 // RunController creates a new replication controller named 'name' which creates 'replicas' pods running 'image'.
+// The controller and its pods are labeled name='name'.  If 'servicePort' is positive, a service of
+// the same name is created on that port for the pods; unless 'portSpec' gives the container's ports,
+// the container then exposes 'servicePort' too.
 func RunController(image, name string, replicas int, client client.Interface, portSpec string, servicePort int) error {
 	if servicePort > 0 && !util.IsDNSLabel(name) {
 		return fmt.Errorf("Service creation requested, but an invalid name for a service was provided (%s). Service names must be valid DNS labels.", name)
 	}
+	ports := portsFromString(portSpec)
+	if len(ports) == 0 && servicePort > 0 {
+		ports = []api.Port{{ContainerPort: servicePort}}
+	}
 	controller := &api.ReplicationController{
 		JSONBase: api.JSONBase{
 			ID: name,
 		},
+		Labels: map[string]string{
+			"name": name,
+		},
 		DesiredState: api.ReplicationControllerState{
 			Replicas: replicas,
 			ReplicaSelector: map[string]string{
@@ -195,12 +353,13 @@ func RunController(image, name string, replicas int, client client.Interface, po
 							{
 								Name:  strings.ToLower(name),
 								Image: image,
-								Ports: portsFromString(portSpec),
+								Ports: ports,
 							},
 						},
 					},
 				},
 				Labels: map[string]string{
+					"name":                  name,
 					"replicationController": name,
 				},
 			},
@@ -258,3 +417,85 @@ func DeleteController(name string, client client.Interface) error {
 	}
 	return client.DeleteReplicationController(name)
 }
+
+// DeleteBySelector deletes the objects of a resource type, e.g. "pods", whose
+// labels match selector.  The server does the matching.  It stops at the
+// first failure, and returns the IDs of the objects it deleted.  An empty
+// selector is refused, since it would delete everything.
+func DeleteBySelector(resource string, selector labels.Selector, c *client.RESTClient) ([]string, error) {
+	if selector.Empty() {
+		return nil, fmt.Errorf("refusing to delete all %s; give a label selector", resource)
+	}
+	list, err := c.Get().Path(resource).SelectorParam("labels", selector).Do().Get()
+	if err != nil {
+		return nil, err
+	}
+	items, err := runtime.ExtractList(list)
+	if err != nil {
+		return nil, err
+	}
+	deleted := []string{}
+	for _, item := range items {
+		jsonBase, err := runtime.FindJSONBase(item)
+		if err != nil {
+			return deleted, err
+		}
+		if err := c.Delete().Path(resource).Path(jsonBase.ID()).Do().Error(); err != nil {
+			return deleted, fmt.Errorf("error deleting %s/%s: %v", resource, jsonBase.ID(), err)
+		}
+		deleted = append(deleted, jsonBase.ID())
+	}
+	return deleted, nil
+}
+
+// LatestResourceVersion returns the newest resource version of the items of
+// list, or 0 if it has none.
+func LatestResourceVersion(list runtime.Object) (uint64, error) {
+	items, err := runtime.ExtractList(list)
+	if err != nil {
+		return 0, err
+	}
+	var latest uint64
+	for _, item := range items {
+		jsonBase, err := runtime.FindJSONBase(item)
+		if err != nil {
+			return 0, err
+		}
+		if version := jsonBase.ResourceVersion(); version > latest {
+			latest = version
+		}
+	}
+	return latest, nil
+}
+
+// WatchList writes a line to w for each change to the objects of resource
+// whose labels match selector, made after resourceVersion.  A watch which the
+// server closes is started again from the last change seen, so it only
+// returns on an error.
+func WatchList(resource string, selector labels.Selector, resourceVersion uint64, c *client.RESTClient, w io.Writer) error {
+	for {
+		watcher, err := c.Get().
+			Path("watch").
+			Path(resource).
+			UintParam("resourceVersion", resourceVersion).
+			SelectorParam("labels", selector).
+			Watch()
+		if err != nil {
+			return err
+		}
+		for event := range watcher.ResultChan() {
+			jsonBase, err := runtime.FindJSONBase(event.Object)
+			if err != nil {
+				watcher.Stop()
+				return err
+			}
+			if version := jsonBase.ResourceVersion(); version > resourceVersion {
+				resourceVersion = version
+			}
+			if _, err := fmt.Fprintf(w, "%-8s %s/%s\n", event.Type, resource, jsonBase.ID()); err != nil {
+				watcher.Stop()
+				return err
+			}
+		}
+	}
+}
@@ -18,14 +18,21 @@ package kubecfg
 
 import (
 	"bytes"
+	"encoding/json"
 	"io"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/latest"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
 )
 
 func validateAction(expectedAction, actualAction client.FakeAction, t *testing.T) {
@@ -36,7 +43,7 @@ func validateAction(expectedAction, actualAction client.FakeAction, t *testing.T
 
 func TestUpdateWithPods(t *testing.T) {
 	fakeClient := client.Fake{
-		Pods: api.PodList{
+		PodList: api.PodList{
 			Items: []api.Pod{
 				{JSONBase: api.JSONBase{ID: "pod-1"}},
 				{JSONBase: api.JSONBase{ID: "pod-2"}},
@@ -67,7 +74,7 @@ func TestUpdateNoPods(t *testing.T) {
 
 func TestUpdateWithNewImage(t *testing.T) {
 	fakeClient := client.Fake{
-		Pods: api.PodList{
+		PodList: api.PodList{
 			Items: []api.Pod{
 				{JSONBase: api.JSONBase{ID: "pod-1"}},
 				{JSONBase: api.JSONBase{ID: "pod-2"}},
@@ -104,6 +111,149 @@ func TestUpdateWithNewImage(t *testing.T) {
 	validateAction(client.FakeAction{Action: "list-pods"}, fakeClient.Actions[5], t)
 }
 
+func newRollingUpdateController() *api.ReplicationController {
+	return &api.ReplicationController{
+		JSONBase: api.JSONBase{ID: "foo-v2"},
+		DesiredState: api.ReplicationControllerState{
+			ReplicaSelector: map[string]string{"name": "foo", "version": "2"},
+			PodTemplate: api.PodTemplate{
+				Labels: map[string]string{"name": "foo", "version": "2"},
+			},
+		},
+	}
+}
+
+func TestRollingUpdate(t *testing.T) {
+	rollingUpdatePollInterval = time.Millisecond
+	runningPod := api.Pod{
+		Labels:       map[string]string{"name": "foo", "version": "2"},
+		CurrentState: api.PodState{Status: api.PodRunning},
+	}
+	fakeClient := client.Fake{
+		PodList: api.PodList{Items: []api.Pod{runningPod, runningPod}},
+		Ctrl: api.ReplicationController{
+			DesiredState: api.ReplicationControllerState{
+				Replicas:        2,
+				ReplicaSelector: map[string]string{"name": "foo", "version": "1"},
+			},
+		},
+	}
+	if err := RollingUpdate("foo", newRollingUpdateController(), &fakeClient, 0, time.Second); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"get-controller", "create-controller",
+		"get-controller", "update-controller", "list-pods", "get-controller", "update-controller",
+		"get-controller", "update-controller", "list-pods", "get-controller", "update-controller",
+		"delete-controller",
+	}
+	if len(fakeClient.Actions) != len(expected) {
+		t.Fatalf("Unexpected action list %#v", fakeClient.Actions)
+	}
+	for i, action := range fakeClient.Actions {
+		if action.Action != expected[i] {
+			t.Errorf("Expected action %d to be %s, got %s", i, expected[i], action.Action)
+		}
+	}
+	created := fakeClient.Actions[1].Value.(*api.ReplicationController)
+	if created.ID != "foo-v2" || created.DesiredState.Replicas != 0 {
+		t.Errorf("Unexpected created controller: %#v", created)
+	}
+	replicas := []int{}
+	for _, action := range fakeClient.Actions {
+		if action.Action == "update-controller" {
+			replicas = append(replicas, action.Value.(*api.ReplicationController).DesiredState.Replicas)
+		}
+	}
+	if !reflect.DeepEqual(replicas, []int{1, 1, 2, 0}) {
+		t.Errorf("Unexpected replica steps: %v", replicas)
+	}
+	validateAction(client.FakeAction{Action: "delete-controller", Value: "foo"}, fakeClient.Actions[12], t)
+}
+
+func TestRollingUpdateUnhealthy(t *testing.T) {
+	rollingUpdatePollInterval = time.Millisecond
+	fakeClient := client.Fake{
+		PodList: api.PodList{Items: []api.Pod{{
+			Labels:       map[string]string{"name": "foo", "version": "2"},
+			CurrentState: api.PodState{Status: api.PodWaiting},
+		}}},
+		Ctrl: api.ReplicationController{
+			DesiredState: api.ReplicationControllerState{
+				Replicas:        2,
+				ReplicaSelector: map[string]string{"name": "foo", "version": "1"},
+			},
+		},
+	}
+	if err := RollingUpdate("foo", newRollingUpdateController(), &fakeClient, 0, 10*time.Millisecond); err == nil {
+		t.Fatalf("expected an error when the new pods never run")
+	}
+	for _, action := range fakeClient.Actions {
+		if action.Action == "delete-controller" {
+			t.Errorf("Unexpected delete of the old controller: %#v", fakeClient.Actions)
+		}
+	}
+}
+
+func TestRollingUpdateOverlappingSelector(t *testing.T) {
+	fakeClient := client.Fake{
+		Ctrl: api.ReplicationController{
+			DesiredState: api.ReplicationControllerState{
+				ReplicaSelector: map[string]string{"name": "foo"},
+			},
+		},
+	}
+	if err := RollingUpdate("foo", newRollingUpdateController(), &fakeClient, 0, time.Second); err == nil {
+		t.Fatalf("expected an error for an overlapping selector")
+	}
+	if len(fakeClient.Actions) != 1 {
+		t.Errorf("Unexpected action list %#v", fakeClient.Actions)
+	}
+}
+
+func TestRollingUpdateSelectorMatchesOldPods(t *testing.T) {
+	fakeClient := client.Fake{
+		Ctrl: api.ReplicationController{
+			DesiredState: api.ReplicationControllerState{
+				ReplicaSelector: map[string]string{"name": "foo", "version": "1"},
+				PodTemplate: api.PodTemplate{
+					Labels: map[string]string{"name": "foo", "version": "1"},
+				},
+			},
+		},
+	}
+	newController := newRollingUpdateController()
+	newController.DesiredState.ReplicaSelector = map[string]string{"name": "foo"}
+	if err := RollingUpdate("foo", newController, &fakeClient, 0, time.Second); err == nil {
+		t.Fatalf("expected an error for a selector matching the old pods")
+	}
+	if len(fakeClient.Actions) != 1 {
+		t.Errorf("Unexpected action list %#v", fakeClient.Actions)
+	}
+}
+
+func TestRollingUpdateConflict(t *testing.T) {
+	fakeClient := client.Fake{
+		Ctrl: api.ReplicationController{
+			DesiredState: api.ReplicationControllerState{
+				ReplicaSelector: map[string]string{"name": "foo", "version": "1"},
+			},
+		},
+	}
+	message := `replicationController "foo-v2" cannot be updated: its selector overlaps the pods of replicationController "bar"`
+	fakeClient.AddReactor("create-controller", func(client.FakeAction) (bool, interface{}, error) {
+		return true, nil, &client.StatusErr{Status: api.Status{Code: http.StatusConflict, Message: message}}
+	})
+	err := RollingUpdate("foo", newRollingUpdateController(), &fakeClient, 0, time.Second)
+	if err == nil {
+		t.Fatalf("expected an error for an overlapping controller")
+	}
+	if expected := "foo-v2 overlaps an existing replication controller: " + message; err.Error() != expected {
+		t.Errorf("Expected error %q, got %q", expected, err.Error())
+	}
+}
+
 func TestRunController(t *testing.T) {
 	fakeClient := client.Fake{}
 	name := "name"
@@ -130,7 +280,7 @@ func TestRunControllerWithService(t *testing.T) {
 	if len(fakeClient.Actions) != 2 ||
 		fakeClient.Actions[0].Action != "create-controller" ||
 		fakeClient.Actions[1].Action != "create-service" {
-		t.Errorf("Unexpected actions: %#v", fakeClient.Actions)
+		t.Fatalf("Unexpected actions: %#v", fakeClient.Actions)
 	}
 	controller := fakeClient.Actions[0].Value.(*api.ReplicationController)
 	if controller.ID != name ||
@@ -138,14 +288,50 @@ func TestRunControllerWithService(t *testing.T) {
 		controller.DesiredState.PodTemplate.DesiredState.Manifest.Containers[0].Image != image {
 		t.Errorf("Unexpected controller: %#v", controller)
 	}
+	ports := controller.DesiredState.PodTemplate.DesiredState.Manifest.Containers[0].Ports
+	if e := []api.Port{{ContainerPort: 8000}}; !reflect.DeepEqual(e, ports) {
+		t.Errorf("Expected the container to expose the service port, got %#v", ports)
+	}
+	service := fakeClient.Actions[1].Value.(*api.Service)
+	podLabels := labels.Set(controller.DesiredState.PodTemplate.Labels)
+	if service.ID != name || service.Port != 8000 || !labels.Set(service.Selector).AsSelector().Matches(podLabels) {
+		t.Errorf("Expected a service for the controller's pods, got %#v", service)
+	}
+	if !labels.Set(controller.DesiredState.ReplicaSelector).AsSelector().Matches(podLabels) {
+		t.Errorf("Expected the controller to select its pods, got %#v", controller)
+	}
 }
 
 func TestStopController(t *testing.T) {
+	defer func(interval time.Duration) { rollingUpdatePollInterval = interval }(rollingUpdatePollInterval)
+	rollingUpdatePollInterval = time.Millisecond
+
 	fakeClient := client.Fake{}
+	fakeClient.AddReactor("get-controller", func(client.FakeAction) (bool, interface{}, error) {
+		return true, &api.ReplicationController{
+			JSONBase:     api.JSONBase{ID: "name"},
+			DesiredState: api.ReplicationControllerState{Replicas: 2, ReplicaSelector: map[string]string{"name": "foo"}},
+		}, nil
+	})
+	fakeClient.AddReactor("update-controller", func(action client.FakeAction) (bool, interface{}, error) {
+		return true, action.Value, nil
+	})
+	// The pods are deleted on the third look.
+	lists := 0
+	fakeClient.AddReactor("list-pods", func(client.FakeAction) (bool, interface{}, error) {
+		lists++
+		pods := &api.PodList{Items: []api.Pod{{JSONBase: api.JSONBase{ID: "other"}, Labels: map[string]string{"name": "bar"}}}}
+		if lists < 3 {
+			pods.Items = append(pods.Items, api.Pod{JSONBase: api.JSONBase{ID: "foo"}, Labels: map[string]string{"name": "foo"}})
+		}
+		return true, pods, nil
+	})
 	name := "name"
-	StopController(name, &fakeClient)
-	if len(fakeClient.Actions) != 2 {
-		t.Errorf("Unexpected actions: %#v", fakeClient.Actions)
+	if err := StopController(name, &fakeClient, time.Minute); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(fakeClient.Actions) != 6 {
+		t.Fatalf("Unexpected actions: %#v", fakeClient.Actions)
 	}
 	if fakeClient.Actions[0].Action != "get-controller" ||
 		fakeClient.Actions[0].Value.(string) != name {
@@ -156,6 +342,22 @@ func TestStopController(t *testing.T) {
 		controller.DesiredState.Replicas != 0 {
 		t.Errorf("Unexpected Action: %#v", fakeClient.Actions[1])
 	}
+	if fakeClient.Actions[5].Action != "delete-controller" ||
+		fakeClient.Actions[5].Value.(string) != name {
+		t.Errorf("Unexpected Action: %#v", fakeClient.Actions[5])
+	}
+
+	// A controller whose pods remain isn't deleted.
+	fakeClient.Actions = nil
+	lists = -1000
+	if err := StopController(name, &fakeClient, 20*time.Millisecond); err == nil {
+		t.Errorf("Expected an error while pods remain")
+	}
+	for _, action := range fakeClient.Actions {
+		if action.Action == "delete-controller" {
+			t.Errorf("Unexpected delete: %#v", fakeClient.Actions)
+		}
+	}
 }
 
 func TestResizeController(t *testing.T) {
@@ -177,6 +379,47 @@ func TestResizeController(t *testing.T) {
 	}
 }
 
+// conflictingClient fails the first 'conflicts' controller updates with a conflict.
+type conflictingClient struct {
+	client.Fake
+	conflicts int
+}
+
+func (c *conflictingClient) UpdateReplicationController(controller *api.ReplicationController) (*api.ReplicationController, error) {
+	if c.conflicts > 0 {
+		c.conflicts--
+		c.Actions = append(c.Actions, client.FakeAction{Action: "update-controller", Value: controller})
+		return nil, &client.StatusErr{Status: api.Status{Status: api.StatusFailure, Code: http.StatusConflict}}
+	}
+	return c.Fake.UpdateReplicationController(controller)
+}
+
+func TestResizeControllerRetriesConflicts(t *testing.T) {
+	fakeClient := &conflictingClient{conflicts: 2}
+	if err := ResizeController("name", 3, fakeClient); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(fakeClient.Actions) != 6 {
+		t.Fatalf("Unexpected actions: %#v", fakeClient.Actions)
+	}
+	for i := 0; i < 6; i += 2 {
+		validateAction(client.FakeAction{Action: "get-controller", Value: "name"}, fakeClient.Actions[i], t)
+		if fakeClient.Actions[i+1].Action != "update-controller" {
+			t.Errorf("Unexpected Action: %#v", fakeClient.Actions[i+1])
+		}
+	}
+}
+
+func TestResizeControllerGivesUpOnConflicts(t *testing.T) {
+	fakeClient := &conflictingClient{conflicts: maxUpdateAttempts}
+	if err := ResizeController("name", 3, fakeClient); !isConflict(err) {
+		t.Errorf("Expected a conflict error, got %v", err)
+	}
+	if len(fakeClient.Actions) != 2*maxUpdateAttempts {
+		t.Errorf("Unexpected actions: %#v", fakeClient.Actions)
+	}
+}
+
 func TestCloudCfgDeleteController(t *testing.T) {
 	fakeClient := client.Fake{}
 	name := "name"
@@ -293,3 +536,119 @@ func TestMakePorts(t *testing.T) {
 		}
 	}
 }
+
+func TestDeleteBySelector(t *testing.T) {
+	requests := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests = append(requests, req.Method+" "+req.URL.Path+"?"+req.URL.RawQuery)
+		if req.Method == "DELETE" {
+			if req.URL.Path == "/api/v1beta1/pods/bad" {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			data, _ := latest.Codec.Encode(&api.Status{Status: api.StatusSuccess})
+			w.Write(data)
+			return
+		}
+		list := &api.PodList{Items: []api.Pod{{JSONBase: api.JSONBase{ID: "foo"}}, {JSONBase: api.JSONBase{ID: "bar"}}}}
+		if req.URL.Query().Get("labels") == "name=bad" {
+			list.Items = append(list.Items, api.Pod{JSONBase: api.JSONBase{ID: "bad"}})
+		}
+		data, _ := latest.Codec.Encode(list)
+		w.Write(data)
+	}))
+	defer server.Close()
+	c := client.NewOrDie(server.URL, nil)
+	c.Retries = 0
+
+	deleted, err := DeleteBySelector("pods", labels.Set{"name": "frontend"}.AsSelector(), c.RESTClient)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if e := []string{"foo", "bar"}; !reflect.DeepEqual(e, deleted) {
+		t.Errorf("Expected %v, got %v", e, deleted)
+	}
+	expected := []string{
+		"GET /api/v1beta1/pods?labels=name%3Dfrontend",
+		"DELETE /api/v1beta1/pods/foo?",
+		"DELETE /api/v1beta1/pods/bar?",
+	}
+	if !reflect.DeepEqual(expected, requests) {
+		t.Errorf("Expected %v, got %v", expected, requests)
+	}
+
+	deleted, err = DeleteBySelector("pods", labels.Set{"name": "bad"}.AsSelector(), c.RESTClient)
+	if err == nil || len(deleted) != 2 {
+		t.Errorf("Expected an error after deleting 2 pods, got %v %v", deleted, err)
+	}
+
+	requests = nil
+	if _, err := DeleteBySelector("pods", labels.Everything(), c.RESTClient); err == nil || len(requests) != 0 {
+		t.Errorf("Expected an empty selector to be refused, got %v %v", err, requests)
+	}
+}
+
+func TestLatestResourceVersion(t *testing.T) {
+	list := &api.PodList{Items: []api.Pod{
+		{JSONBase: api.JSONBase{ID: "foo", ResourceVersion: 7}},
+		{JSONBase: api.JSONBase{ID: "bar", ResourceVersion: 12}},
+		{JSONBase: api.JSONBase{ID: "baz", ResourceVersion: 3}},
+	}}
+	if version, err := LatestResourceVersion(list); err != nil || version != 12 {
+		t.Errorf("Expected 12, got %d %v", version, err)
+	}
+	if version, err := LatestResourceVersion(&api.PodList{}); err != nil || version != 0 {
+		t.Errorf("Expected 0, got %d %v", version, err)
+	}
+}
+
+func TestWatchList(t *testing.T) {
+	// Each watch sends its events and ends; once they run out, the server fails.
+	watches := [][]watch.Event{
+		{
+			{Type: watch.Added, Object: &api.Pod{JSONBase: api.JSONBase{ID: "foo", ResourceVersion: 5}}},
+			{Type: watch.Modified, Object: &api.Pod{JSONBase: api.JSONBase{ID: "bar", ResourceVersion: 7}}},
+		},
+		{
+			{Type: watch.Deleted, Object: &api.Pod{JSONBase: api.JSONBase{ID: "foo", ResourceVersion: 9}}},
+		},
+	}
+	requests := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests = append(requests, req.URL.Path+"?"+req.URL.RawQuery)
+		if len(watches) == 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		encoder := json.NewEncoder(w)
+		for _, event := range watches[0] {
+			data, err := api.NewJSONWatchEvent(latest.Codec, event)
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+				return
+			}
+			encoder.Encode(data)
+		}
+		watches = watches[1:]
+	}))
+	defer server.Close()
+	c := client.NewOrDie(server.URL, nil)
+	c.Retries = 0
+
+	buf := &bytes.Buffer{}
+	if err := WatchList("pods", labels.Set{"name": "foo"}.AsSelector(), 3, c.RESTClient, buf); err == nil {
+		t.Errorf("Expected an error once the server fails")
+	}
+	expected := "ADDED    pods/foo\nMODIFIED pods/bar\nDELETED  pods/foo\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+	expectedRequests := []string{
+		"/api/v1beta1/watch/pods?labels=name%3Dfoo&resourceVersion=3",
+		"/api/v1beta1/watch/pods?labels=name%3Dfoo&resourceVersion=7",
+		"/api/v1beta1/watch/pods?labels=name%3Dfoo&resourceVersion=9",
+	}
+	if !reflect.DeepEqual(expectedRequests, requests) {
+		t.Errorf("Expected %v, got %v", expectedRequests, requests)
+	}
+}
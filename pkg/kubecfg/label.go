@@ -0,0 +1,106 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubecfg
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/golang/glog"
+)
+
+// ParseLabelChanges parses label arguments: "key=value" sets a label, and
+// "key-" removes one.
+func ParseLabelChanges(args []string) (set map[string]string, remove []string, err error) {
+	set = map[string]string{}
+	for _, arg := range args {
+		switch {
+		case strings.Contains(arg, "="):
+			parts := strings.SplitN(arg, "=", 2)
+			if parts[0] == "" {
+				return nil, nil, fmt.Errorf("invalid label %q: the key is empty", arg)
+			}
+			set[parts[0]] = parts[1]
+		case strings.HasSuffix(arg, "-") && len(arg) > 1:
+			remove = append(remove, strings.TrimSuffix(arg, "-"))
+		default:
+			return nil, nil, fmt.Errorf("invalid label %q: expected key=value or key-", arg)
+		}
+	}
+	for _, key := range remove {
+		if _, ok := set[key]; ok {
+			return nil, nil, fmt.Errorf("label %q is both set and removed", key)
+		}
+	}
+	return set, remove, nil
+}
+
+// Label sets and removes labels on the object of a resource type, e.g.
+// "minions", with the given id, and returns its new labels.  Unless overwrite
+// is true, changing the value of an existing label is refused.  The update
+// carries the resource version of the object that was read, and is retried
+// if it conflicts with another change.
+func Label(resource, id string, set map[string]string, remove []string, overwrite bool, c *client.RESTClient) (map[string]string, error) {
+	var err error
+	for i := 0; i < maxUpdateAttempts; i++ {
+		var obj runtime.Object
+		obj, err = c.Get().Path(resource).Path(id).Do().Get()
+		if err != nil {
+			return nil, err
+		}
+		field := reflect.Indirect(reflect.ValueOf(obj)).FieldByName("Labels")
+		if !field.IsValid() || field.Type() != reflect.TypeOf(map[string]string{}) {
+			return nil, fmt.Errorf("%s can't be labeled", resource)
+		}
+		labels, _ := field.Interface().(map[string]string)
+		if labels == nil {
+			labels = map[string]string{}
+			field.Set(reflect.ValueOf(labels))
+		}
+		if !overwrite {
+			changed := []string{}
+			for key, value := range set {
+				if old, ok := labels[key]; ok && old != value {
+					changed = append(changed, key)
+				}
+			}
+			if len(changed) > 0 {
+				sort.Strings(changed)
+				return nil, fmt.Errorf("%s/%s already has a value for %s; overwrite it to change it", resource, id, strings.Join(changed, ", "))
+			}
+		}
+		for key, value := range set {
+			labels[key] = value
+		}
+		for _, key := range remove {
+			delete(labels, key)
+		}
+		err = c.Put().Path(resource).Path(id).Body(obj).Do().Error()
+		if !isConflict(err) {
+			if err != nil {
+				return nil, err
+			}
+			return labels, nil
+		}
+		glog.V(2).Infof("Conflict labeling %s/%s, retrying: %v", resource, id, err)
+	}
+	return nil, err
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubecfg
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/latest"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+)
+
+func TestParseLabelChanges(t *testing.T) {
+	set, remove, err := ParseLabelChanges([]string{"disk=ssd", "zone=", "a=b=c", "old-"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if e := map[string]string{"disk": "ssd", "zone": "", "a": "b=c"}; !reflect.DeepEqual(e, set) {
+		t.Errorf("Expected %v, got %v", e, set)
+	}
+	if e := []string{"old"}; !reflect.DeepEqual(e, remove) {
+		t.Errorf("Expected %v, got %v", e, remove)
+	}
+	for _, args := range [][]string{{"disk"}, {"=ssd"}, {"-"}, {"disk=ssd", "disk-"}} {
+		if _, _, err := ParseLabelChanges(args); err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+}
+
+func TestLabel(t *testing.T) {
+	pod := &api.Pod{
+		JSONBase: api.JSONBase{ID: "foo", ResourceVersion: 1},
+		Labels:   map[string]string{"name": "foo", "old": "x"},
+	}
+	conflicts := 1
+	var updated *api.Pod
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == "PUT" {
+			if conflicts > 0 {
+				conflicts--
+				// Another writer changed the pod.
+				pod.ResourceVersion++
+				pod.Labels["other"] = "y"
+				w.WriteHeader(http.StatusConflict)
+				data, _ := latest.Codec.Encode(&api.Status{Status: api.StatusFailure, Code: http.StatusConflict})
+				w.Write(data)
+				return
+			}
+			body, _ := ioutil.ReadAll(req.Body)
+			updated = &api.Pod{}
+			if err := latest.Codec.DecodeInto(body, updated); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}
+		data, _ := latest.Codec.Encode(pod)
+		w.Write(data)
+	}))
+	defer server.Close()
+	c := client.NewOrDie(server.URL, nil)
+
+	if _, err := Label("pods", "foo", map[string]string{"name": "bar"}, nil, false, c.RESTClient); err == nil {
+		t.Errorf("Expected an error changing a label without overwrite")
+	}
+	if updated != nil || conflicts != 1 {
+		t.Errorf("Expected no update, got %#v", updated)
+	}
+
+	labels, err := Label("pods", "foo", map[string]string{"name": "bar", "disk": "ssd"}, []string{"old"}, true, c.RESTClient)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := map[string]string{"name": "bar", "disk": "ssd", "other": "y"}
+	if !reflect.DeepEqual(expected, labels) {
+		t.Errorf("Expected %v, got %v", expected, labels)
+	}
+	if updated == nil || !reflect.DeepEqual(expected, updated.Labels) || updated.ResourceVersion != 2 {
+		t.Errorf("Expected the update to be retried on the new pod, got %#v", updated)
+	}
+
+	// Setting a label to the value it already has isn't a change.
+	if _, err := Label("pods", "foo", map[string]string{"name": "foo"}, nil, false, c.RESTClient); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
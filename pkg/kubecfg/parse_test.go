@@ -37,6 +37,10 @@ func TestParseBadStorage(t *testing.T) {
 
 func DoParseTest(t *testing.T, storage string, obj runtime.Object, p *Parser) {
 	jsonData, _ := latest.Codec.Encode(obj)
+	// The parser decodes its input, which fills in defaults, so compare
+	// against the object as it looks after decoding.
+	obj, _ = latest.Codec.Decode(jsonData)
+	jsonData, _ = latest.Codec.Encode(obj)
 	var tmp map[string]interface{}
 	json.Unmarshal(jsonData, &tmp)
 	yamlData, _ := yaml.Marshal(tmp)
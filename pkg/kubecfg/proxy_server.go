@@ -19,59 +19,64 @@ package kubecfg
 import (
 	"fmt"
 	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"time"
 
-	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
-	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/latest"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
 )
 
-// ProxyServer is a http.Handler which proxies Kubernetes APIs to remote API server.
+// ProxyServer is a http.Handler which proxies Kubernetes APIs to remote API server,
+// adding the client's credentials to each request, and serves static files.
 type ProxyServer struct {
 	Client *client.Client
+	mux    *http.ServeMux
 }
 
 func newFileHandler(prefix, base string) http.Handler {
 	return http.StripPrefix(prefix, http.FileServer(http.Dir(base)))
 }
 
-// NewProxyServer creates and installs a new ProxyServer.
-// It automatically registers the created ProxyServer to http.DefaultServeMux.
-func NewProxyServer(filebase string, kubeClient *client.Client) *ProxyServer {
-	server := &ProxyServer{
-		Client: kubeClient,
+// newAPIProxy returns a handler which passes requests on to the server of
+// kubeClient, streaming responses such as watches as they arrive.
+func newAPIProxy(kubeClient *client.Client) (http.Handler, error) {
+	target, err := url.Parse(kubeClient.Host())
+	if err != nil {
+		return nil, err
+	}
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	director := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		director(req)
+		req.Host = target.Host
 	}
-	http.Handle("/api/", server)
-	http.Handle("/static/", newFileHandler("/static/", filebase))
-	return server
+	proxy.Transport = kubeClient.RESTClient
+	proxy.FlushInterval = 100 * time.Millisecond
+	return proxy, nil
 }
 
-// Serve starts the server (http.DefaultServeMux) on TCP port 8001, loops forever.
-func (s *ProxyServer) Serve() error {
-	return http.ListenAndServe(":8001", nil)
+// NewProxyServer creates a ProxyServer which proxies /api/ to the server of
+// kubeClient, and serves the files in filebase under /static/.
+func NewProxyServer(filebase string, kubeClient *client.Client) (*ProxyServer, error) {
+	proxy, err := newAPIProxy(kubeClient)
+	if err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/api/", proxy)
+	mux.Handle("/static/", newFileHandler("/static/", filebase))
+	return &ProxyServer{
+		Client: kubeClient,
+		mux:    mux,
+	}, nil
 }
 
-func (s *ProxyServer) doError(w http.ResponseWriter, err error) {
-	w.WriteHeader(http.StatusInternalServerError)
-	w.Header().Add("Content-type", "application/json")
-	data, _ := latest.Codec.Encode(&api.Status{
-		Status:  api.StatusFailure,
-		Message: fmt.Sprintf("internal error: %#v", err),
-	})
-	w.Write(data)
+// Serve starts the server on localhost, so that only local clients can use
+// the credentials it adds, and loops forever.
+func (s *ProxyServer) Serve(port int) error {
+	return http.ListenAndServe(fmt.Sprintf("localhost:%d", port), s)
 }
 
 func (s *ProxyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	result := s.Client.Verb(r.Method).AbsPath(r.URL.Path).Body(r.Body).Do()
-	if result.Error() != nil {
-		s.doError(w, result.Error())
-		return
-	}
-	data, err := result.Raw()
-	if err != nil {
-		s.doError(w, err)
-		return
-	}
-	w.Header().Add("Content-type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write(data)
+	s.mux.ServeHTTP(w, r)
 }
@@ -21,6 +21,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
 )
 
 func TestFileServing(t *testing.T) {
@@ -57,3 +59,50 @@ func TestFileServing(t *testing.T) {
 		t.Errorf("Data doesn't match: %s vs %s", string(b), data)
 	}
 }
+
+func TestAPIProxy(t *testing.T) {
+	var received *http.Request
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		received = req
+		if req.URL.Path != "/api/v1beta1/pods" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		w.Write([]byte("response"))
+	}))
+	defer apiServer.Close()
+	kubeClient := client.NewOrDie(apiServer.URL, &client.AuthInfo{User: "user", Password: "pass"})
+	proxy, err := NewProxyServer("", kubeClient)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	server := httptest.NewServer(proxy)
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "/api/v1beta1/pods?labels=name%3Dfoo")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	body, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK || string(body) != "response" {
+		t.Errorf("Unexpected response: %d %q", res.StatusCode, body)
+	}
+	if user, pass, ok := received.BasicAuth(); !ok || user != "user" || pass != "pass" {
+		t.Errorf("Expected the client's credentials, got %v", received.Header)
+	}
+	if received.URL.Query().Get("labels") != "name=foo" {
+		t.Errorf("Expected the query to be kept, got %v", received.URL)
+	}
+
+	res, err = http.Get(server.URL + "/api/v1beta1/missing")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected the server's status, got %d", res.StatusCode)
+	}
+	if res, err := http.Get(server.URL + "/other"); err != nil || res.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected only the API to be proxied, got %v %v", res, err)
+	}
+}
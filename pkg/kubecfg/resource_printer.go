@@ -17,18 +17,23 @@ limitations under the License.
 package kubecfg
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"reflect"
+	"strconv"
 	"strings"
 	"text/tabwriter"
 	"text/template"
+	"time"
 
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/latest"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
 	"github.com/golang/glog"
 	"gopkg.in/v1/yaml"
 )
@@ -40,6 +45,44 @@ type ResourcePrinter interface {
 	PrintObj(runtime.Object, io.Writer) error
 }
 
+// GetPrinter returns the printer for a kubecfg output format: "json",
+// "yaml", "template=<Go template>", "templatefile=<file holding a Go
+// template>", "path=<dotted path>" (see JSONPathPrinter) or
+// "columns=<comma separated fields>" (see ColumnPrinter).  An empty format
+// uses defaultPrinter.
+func GetPrinter(format string, defaultPrinter ResourcePrinter) (ResourcePrinter, error) {
+	kind, arg := format, ""
+	if i := strings.Index(format, "="); i >= 0 {
+		kind, arg = format[:i], format[i+1:]
+	}
+	switch kind {
+	case "":
+		return defaultPrinter, nil
+	case "json":
+		return &IdentityPrinter{}, nil
+	case "yaml":
+		return &YAMLPrinter{}, nil
+	case "templatefile":
+		data, err := ioutil.ReadFile(arg)
+		if err != nil {
+			return nil, fmt.Errorf("error reading template %s: %v", arg, err)
+		}
+		arg = string(data)
+		fallthrough
+	case "template":
+		tmpl, err := template.New("output").Parse(arg)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing template %s: %v", arg, err)
+		}
+		return &TemplatePrinter{Template: tmpl}, nil
+	case "path":
+		return NewJSONPathPrinter(arg), nil
+	case "columns":
+		return NewColumnPrinter(arg), nil
+	}
+	return nil, fmt.Errorf("unknown output format %q", format)
+}
+
 // IdentityPrinter is an implementation of ResourcePrinter which simply copies the body out to the output stream.
 type IdentityPrinter struct{}
 
@@ -63,11 +106,13 @@ type YAMLPrinter struct{}
 
 // Print parses the data as JSON, re-formats as YAML and prints the YAML.
 func (y *YAMLPrinter) Print(data []byte, w io.Writer) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
 	var obj interface{}
-	if err := json.Unmarshal(data, &obj); err != nil {
+	if err := decoder.Decode(&obj); err != nil {
 		return err
 	}
-	output, err := yaml.Marshal(obj)
+	output, err := yaml.Marshal(yamlNumbers(obj))
 	if err != nil {
 		return err
 	}
@@ -75,14 +120,38 @@ func (y *YAMLPrinter) Print(data []byte, w io.Writer) error {
 	return err
 }
 
-// PrintObj prints the data as YAML.
+// PrintObj prints the obj as YAML, in the same version as the JSON printed by
+// IdentityPrinter, so that the output can be read back by kubecfg.
 func (y *YAMLPrinter) PrintObj(obj runtime.Object, w io.Writer) error {
-	output, err := yaml.Marshal(obj)
+	data, err := latest.Codec.Encode(obj)
 	if err != nil {
 		return err
 	}
-	_, err = fmt.Fprint(w, string(output))
-	return err
+	return y.Print(data, w)
+}
+
+// yamlNumbers replaces the json.Numbers in obj, which was decoded from JSON,
+// with ints where possible and floats otherwise, which YAML prints as
+// written.  Decoding straight to floats would print large ints in
+// exponent form.
+func yamlNumbers(obj interface{}) interface{} {
+	switch obj := obj.(type) {
+	case json.Number:
+		if i, err := obj.Int64(); err == nil {
+			return i
+		}
+		f, _ := obj.Float64()
+		return f
+	case map[string]interface{}:
+		for k, v := range obj {
+			obj[k] = yamlNumbers(v)
+		}
+	case []interface{}:
+		for i, v := range obj {
+			obj[i] = yamlNumbers(v)
+		}
+	}
+	return obj
 }
 
 type handlerEntry struct {
@@ -138,10 +207,10 @@ func (h *HumanReadablePrinter) validatePrintHandlerFunc(printFunc reflect.Value)
 	return nil
 }
 
-var podColumns = []string{"ID", "Image(s)", "Host", "Labels", "Status"}
-var replicationControllerColumns = []string{"ID", "Image(s)", "Selector", "Replicas"}
+var podColumns = []string{"ID", "Image(s)", "Host", "Labels", "Status", "Ready", "Age"}
+var replicationControllerColumns = []string{"ID", "Image(s)", "Selector", "Replicas", "Ready"}
 var serviceColumns = []string{"ID", "Labels", "Selector", "Port"}
-var minionColumns = []string{"Minion identifier"}
+var minionColumns = []string{"Minion identifier", "CPU", "Memory", "Max Pods", "Labels", "Age"}
 var statusColumns = []string{"Status"}
 
 // addDefaultHandlers adds print handlers for default Kubernetes types.
@@ -182,11 +251,45 @@ func makeImageList(manifest api.ContainerManifest) string {
 	return strings.Join(images, ",")
 }
 
+// now is replaced in tests.
+var now = time.Now
+
+// formatAge returns how long ago t was, in its largest whole unit, or
+// "<unknown>" if t is not set.
+func formatAge(t util.Time) string {
+	if t.IsZero() {
+		return "<unknown>"
+	}
+	age := now().Sub(t.Time)
+	switch {
+	case age < time.Minute:
+		return fmt.Sprintf("%ds", int(age.Seconds()))
+	case age < time.Hour:
+		return fmt.Sprintf("%dm", int(age.Minutes()))
+	case age < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(age.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(age.Hours()/24))
+}
+
+// formatReady returns how many of a pod's containers are running, out of
+// the number in its manifest.
+func formatReady(pod *api.Pod) string {
+	running := 0
+	for _, container := range pod.DesiredState.Manifest.Containers {
+		if info, ok := pod.CurrentState.Info[container.Name]; ok && info.State.Running {
+			running++
+		}
+	}
+	return fmt.Sprintf("%d/%d", running, len(pod.DesiredState.Manifest.Containers))
+}
+
 func printPod(pod *api.Pod, w io.Writer) error {
-	_, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+	_, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
 		pod.ID, makeImageList(pod.DesiredState.Manifest),
 		pod.CurrentState.Host+"/"+pod.CurrentState.HostIP,
-		labels.Set(pod.Labels), pod.CurrentState.Status)
+		labels.Set(pod.Labels), pod.CurrentState.Status,
+		formatReady(pod), formatAge(pod.CreationTimestamp))
 	return err
 }
 
@@ -200,9 +303,9 @@ func printPodList(podList *api.PodList, w io.Writer) error {
 }
 
 func printReplicationController(ctrl *api.ReplicationController, w io.Writer) error {
-	_, err := fmt.Fprintf(w, "%s\t%s\t%s\t%d\n",
+	_, err := fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\n",
 		ctrl.ID, makeImageList(ctrl.DesiredState.PodTemplate.DesiredState.Manifest),
-		labels.Set(ctrl.DesiredState.ReplicaSelector), ctrl.DesiredState.Replicas)
+		labels.Set(ctrl.DesiredState.ReplicaSelector), ctrl.DesiredState.Replicas, ctrl.Status.ReadyReplicas)
 	return err
 }
 
@@ -230,8 +333,27 @@ func printServiceList(list *api.ServiceList, w io.Writer) error {
 	return nil
 }
 
+// formatResource returns the named capacity of a minion, or "<unknown>" if
+// it was not reported.
+func formatResource(resources api.ResourceList, name api.ResourceName) string {
+	quantity, ok := resources[name]
+	if !ok {
+		return "<unknown>"
+	}
+	if quantity.Kind == util.IntstrString {
+		return quantity.StrVal
+	}
+	return strconv.Itoa(quantity.IntVal)
+}
+
 func printMinion(minion *api.Minion, w io.Writer) error {
-	_, err := fmt.Fprintf(w, "%s\n", minion.ID)
+	capacity := minion.NodeResources.Capacity
+	_, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", minion.ID,
+		formatResource(capacity, api.ResourceCPU),
+		formatResource(capacity, api.ResourceMemory),
+		formatResource(capacity, api.ResourcePods),
+		labels.Set(minion.Labels),
+		formatAge(minion.CreationTimestamp))
 	return err
 }
 
@@ -304,3 +426,219 @@ func (t *TemplatePrinter) Print(data []byte, w io.Writer) error {
 func (t *TemplatePrinter) PrintObj(obj runtime.Object, w io.Writer) error {
 	return t.Template.Execute(w, obj)
 }
+
+// JSONPathPrinter is an implementation of ResourcePrinter which prints the
+// value at a dotted path through an object's JSON, e.g. "currentState.podIP"
+// or "items.0.id".  A path which continues through a list without an index
+// prints the value for each item, one per line.  Objects and lists are
+// printed as JSON, and missing or null fields print nothing.
+type JSONPathPrinter struct {
+	Path []string
+}
+
+// NewJSONPathPrinter creates a JSONPathPrinter for a dotted path.
+func NewJSONPathPrinter(path string) *JSONPathPrinter {
+	path = strings.TrimPrefix(path, ".")
+	if path == "" {
+		return &JSONPathPrinter{}
+	}
+	return &JSONPathPrinter{Path: strings.Split(path, ".")}
+}
+
+// Print parses the data as JSON, and prints the values at the path.
+func (j *JSONPathPrinter) Print(data []byte, w io.Writer) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var obj interface{}
+	if err := decoder.Decode(&obj); err != nil {
+		return err
+	}
+	values, err := walkJSONPath(obj, j.Path)
+	if err != nil {
+		return err
+	}
+	lines := []string{}
+	for _, value := range values {
+		switch value := value.(type) {
+		case nil:
+			lines = append(lines, "")
+		case string:
+			lines = append(lines, value)
+		case map[string]interface{}, []interface{}:
+			data, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			lines = append(lines, string(data))
+		default:
+			lines = append(lines, fmt.Sprint(value))
+		}
+	}
+	_, err = io.WriteString(w, strings.Join(lines, "\n"))
+	return err
+}
+
+// PrintObj prints the values at the path through obj's JSON.
+func (j *JSONPathPrinter) PrintObj(obj runtime.Object, w io.Writer) error {
+	data, err := latest.Codec.Encode(obj)
+	if err != nil {
+		return err
+	}
+	return j.Print(data, w)
+}
+
+// walkJSONPath returns the values at path through obj, which was decoded
+// from JSON.
+func walkJSONPath(obj interface{}, path []string) ([]interface{}, error) {
+	if len(path) == 0 {
+		return []interface{}{obj}, nil
+	}
+	switch obj := obj.(type) {
+	case map[string]interface{}:
+		value, ok := obj[path[0]]
+		if !ok {
+			return nil, nil
+		}
+		return walkJSONPath(value, path[1:])
+	case []interface{}:
+		if i, err := strconv.Atoi(path[0]); err == nil {
+			if i < 0 || i >= len(obj) {
+				return nil, nil
+			}
+			return walkJSONPath(obj[i], path[1:])
+		}
+		values := []interface{}{}
+		for _, item := range obj {
+			itemValues, err := walkJSONPath(item, path)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, itemValues...)
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("can't look up %q in %v", path[0], obj)
+}
+
+// ColumnPrinter is an implementation of ResourcePrinter which prints a table
+// with a column for each of a set of dotted paths through an object's
+// fields, e.g. "ID" or "CurrentState.Host".  Field names are matched without
+// regard to case.  A list prints a row for each of its items.
+type ColumnPrinter struct {
+	Columns []string
+}
+
+// NewColumnPrinter creates a ColumnPrinter for a comma separated list of
+// columns.
+func NewColumnPrinter(columns string) *ColumnPrinter {
+	printer := &ColumnPrinter{}
+	for _, column := range strings.Split(columns, ",") {
+		if column = strings.TrimSpace(column); column != "" {
+			printer.Columns = append(printer.Columns, column)
+		}
+	}
+	return printer
+}
+
+// Print parses the data as JSON, and prints the columns of the object.
+func (c *ColumnPrinter) Print(data []byte, w io.Writer) error {
+	obj, err := latest.Codec.Decode(data)
+	if err != nil {
+		return err
+	}
+	return c.PrintObj(obj, w)
+}
+
+// PrintObj prints the columns of obj, or of each item of a list.
+func (c *ColumnPrinter) PrintObj(obj runtime.Object, output io.Writer) error {
+	if len(c.Columns) == 0 {
+		return fmt.Errorf("no columns to print")
+	}
+	items := []reflect.Value{}
+	value := reflect.Indirect(reflect.ValueOf(obj))
+	if list := value.FieldByName("Items"); list.IsValid() && list.Kind() == reflect.Slice {
+		for i := 0; i < list.Len(); i++ {
+			items = append(items, list.Index(i))
+		}
+	} else {
+		items = append(items, value)
+	}
+
+	rows := [][]string{}
+	for _, item := range items {
+		row := []string{}
+		for _, column := range c.Columns {
+			field, err := lookupColumn(item, column)
+			if err != nil {
+				return err
+			}
+			row = append(row, formatColumn(field))
+		}
+		rows = append(rows, row)
+	}
+
+	w := tabwriter.NewWriter(output, 20, 5, 3, ' ', 0)
+	defer w.Flush()
+	if err := (&HumanReadablePrinter{}).printHeader(c.Columns, w); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if _, err := fmt.Fprintf(w, "%s\n", strings.Join(row, "\t")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lookupColumn returns the field at a dotted path through value.  A nil
+// pointer along the path yields an invalid value.
+func lookupColumn(value reflect.Value, column string) (reflect.Value, error) {
+	for _, name := range strings.Split(column, ".") {
+		for value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				return reflect.Value{}, nil
+			}
+			value = value.Elem()
+		}
+		if value.Kind() != reflect.Struct {
+			return reflect.Value{}, fmt.Errorf("unknown column %q: %s is not a struct", column, value.Type())
+		}
+		field := value.FieldByNameFunc(func(fieldName string) bool {
+			return strings.EqualFold(fieldName, name)
+		})
+		if !field.IsValid() {
+			return reflect.Value{}, fmt.Errorf("unknown column %q: %s has no field %s", column, value.Type(), name)
+		}
+		value = field
+	}
+	return value, nil
+}
+
+// formatColumn formats a field for a ColumnPrinter table.
+func formatColumn(value reflect.Value) string {
+	if !value.IsValid() {
+		return ""
+	}
+	switch obj := value.Interface().(type) {
+	case map[string]string:
+		return labels.Set(obj).String()
+	case util.Time:
+		return formatAge(obj)
+	case fmt.Stringer:
+		return obj.String()
+	}
+	switch value.Kind() {
+	case reflect.Ptr:
+		if value.IsNil() {
+			return ""
+		}
+		return formatColumn(value.Elem())
+	case reflect.Slice:
+		items := []string{}
+		for i := 0; i < value.Len(); i++ {
+			items = append(items, formatColumn(value.Index(i)))
+		}
+		return strings.Join(items, ",")
+	}
+	return fmt.Sprintf("%v", value.Interface())
+}
@@ -22,10 +22,14 @@ import (
 	"fmt"
 	"io"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/latest"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/fsouza/go-dockerclient"
 	"gopkg.in/v1/yaml"
 )
 
@@ -68,12 +72,19 @@ func TestYAMLPrinterPrint(t *testing.T) {
 	}
 
 	obj := &api.Pod{
-		JSONBase: api.JSONBase{ID: "foo"},
+		JSONBase:     api.JSONBase{ID: "foo", ResourceVersion: 10000000},
+		DesiredState: alwaysRestart,
+		CurrentState: alwaysRestart,
 	}
 	buf.Reset()
 	printer.PrintObj(obj, buf)
+	for _, expected := range []string{"kind: Pod", "apiVersion: v1beta1", "resourceVersion: 10000000"} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("Expected %q in output:\n%s", expected, buf.String())
+		}
+	}
 	var objOut api.Pod
-	err = yaml.Unmarshal([]byte(buf.String()), &objOut)
+	err = latest.Codec.DecodeInto(buf.Bytes(), &objOut)
 	if err != nil {
 		t.Errorf("Unexpeted error: %#v", err)
 	}
@@ -92,7 +103,9 @@ func TestIdentityPrinter(t *testing.T) {
 	}
 
 	obj := &api.Pod{
-		JSONBase: api.JSONBase{ID: "foo"},
+		JSONBase:     api.JSONBase{ID: "foo"},
+		DesiredState: alwaysRestart,
+		CurrentState: alwaysRestart,
 	}
 	buff.Reset()
 	printer.PrintObj(obj, buff)
@@ -105,6 +118,13 @@ func TestIdentityPrinter(t *testing.T) {
 	}
 }
 
+// alwaysRestart is the pod state decoding defaults an empty one to.
+var alwaysRestart = api.PodState{
+	Manifest: api.ContainerManifest{
+		RestartPolicy: api.RestartPolicy{Always: &api.RestartPolicyAlways{}},
+	},
+}
+
 type TestPrintType struct {
 	Data string
 }
@@ -161,3 +181,212 @@ func TestUnknownTypePrinting(t *testing.T) {
 		t.Errorf("An error was expected from printing unknown type")
 	}
 }
+
+func TestPrintMinionResources(t *testing.T) {
+	minion := &api.Minion{
+		JSONBase: api.JSONBase{ID: "machine"},
+		Labels:   map[string]string{"disk": "ssd"},
+		NodeResources: api.NodeResources{
+			Capacity: api.ResourceList{
+				api.ResourceCPU:    util.NewIntOrStringFromInt(2000),
+				api.ResourceMemory: util.NewIntOrStringFromInt(1024),
+			},
+		},
+	}
+	buffer := &bytes.Buffer{}
+	if err := printMinion(minion, buffer); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expectedOutput := "machine\t2000\t1024\t<unknown>\tdisk=ssd\t<unknown>\n"
+	if buffer.String() != expectedOutput {
+		t.Errorf("Expected:\n%q\nGot:\n%q", expectedOutput, buffer.String())
+	}
+}
+
+func TestPrintPodReadyAndAge(t *testing.T) {
+	created := time.Date(2014, 9, 1, 12, 0, 0, 0, time.UTC)
+	oldNow := now
+	defer func() { now = oldNow }()
+	now = func() time.Time { return created.Add(3*time.Hour + 20*time.Minute) }
+
+	pod := &api.Pod{
+		JSONBase: api.JSONBase{ID: "web", CreationTimestamp: util.Time{Time: created}},
+		DesiredState: api.PodState{
+			Manifest: api.ContainerManifest{
+				Containers: []api.Container{
+					{Name: "nginx", Image: "nginx"},
+					{Name: "log", Image: "fluentd"},
+				},
+			},
+		},
+		CurrentState: api.PodState{
+			Host:   "machine",
+			HostIP: "10.0.0.1",
+			Status: api.PodRunning,
+			Info: api.PodInfo{
+				"nginx": docker.Container{State: docker.State{Running: true}},
+				"log":   docker.Container{State: docker.State{Running: false}},
+			},
+		},
+	}
+	buffer := &bytes.Buffer{}
+	if err := printPod(pod, buffer); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expectedOutput := "web\tnginx,fluentd\tmachine/10.0.0.1\t\tRunning\t1/2\t3h\n"
+	if buffer.String() != expectedOutput {
+		t.Errorf("Expected:\n%q\nGot:\n%q", expectedOutput, buffer.String())
+	}
+}
+
+func TestFormatAge(t *testing.T) {
+	base := time.Date(2014, 9, 1, 12, 0, 0, 0, time.UTC)
+	oldNow := now
+	defer func() { now = oldNow }()
+	now = func() time.Time { return base }
+
+	tests := []struct {
+		created  util.Time
+		expected string
+	}{
+		{util.Time{}, "<unknown>"},
+		{util.Time{Time: base.Add(-10 * time.Second)}, "10s"},
+		{util.Time{Time: base.Add(-90 * time.Second)}, "1m"},
+		{util.Time{Time: base.Add(-5 * time.Hour)}, "5h"},
+		{util.Time{Time: base.Add(-50 * time.Hour)}, "2d"},
+	}
+	for _, test := range tests {
+		if age := formatAge(test.created); age != test.expected {
+			t.Errorf("Expected %s for %v, got %s", test.expected, test.created, age)
+		}
+	}
+}
+
+func TestGetPrinter(t *testing.T) {
+	human := NewHumanReadablePrinter()
+	testCases := []struct {
+		format   string
+		expected reflect.Type
+		err      bool
+	}{
+		{"", reflect.TypeOf(human), false},
+		{"json", reflect.TypeOf(&IdentityPrinter{}), false},
+		{"yaml", reflect.TypeOf(&YAMLPrinter{}), false},
+		{"template={{.ID}}", reflect.TypeOf(&TemplatePrinter{}), false},
+		{"path=id", reflect.TypeOf(&JSONPathPrinter{}), false},
+		{"template={{.ID", nil, true},
+		{"templatefile=/does/not/exist", nil, true},
+		{"xml", nil, true},
+	}
+	for _, testCase := range testCases {
+		printer, err := GetPrinter(testCase.format, human)
+		if (err != nil) != testCase.err {
+			t.Errorf("%s: unexpected error: %v", testCase.format, err)
+			continue
+		}
+		if err == nil && reflect.TypeOf(printer) != testCase.expected {
+			t.Errorf("%s: expected a %v, got %#v", testCase.format, testCase.expected, printer)
+		}
+	}
+}
+
+func TestTemplatePrinter(t *testing.T) {
+	printer, err := GetPrinter("template={{.CurrentState.Host}}", nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	buf := &bytes.Buffer{}
+	if err := printer.PrintObj(&api.Pod{CurrentState: api.PodState{Host: "machine"}}, buf); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if buf.String() != "machine" {
+		t.Errorf("Unexpected output: %q", buf.String())
+	}
+}
+
+func TestJSONPathPrinter(t *testing.T) {
+	pods := &api.PodList{
+		Items: []api.Pod{
+			{JSONBase: api.JSONBase{ID: "foo", ResourceVersion: 10000000}, CurrentState: api.PodState{PodIP: "1.2.3.4"}},
+			{JSONBase: api.JSONBase{ID: "bar"}},
+		},
+	}
+	testCases := map[string]string{
+		"items.0.currentState.podIP":               "1.2.3.4",
+		".items.0.resourceVersion":                 "10000000",
+		"items.id":                                 "foo\nbar",
+		"items.currentState.podIP":                 "1.2.3.4",
+		"items.5.id":                               "",
+		"items.0.missing":                          "",
+		"items.1.labels":                           "",
+		"items.0.currentState.manifest.containers": "",
+	}
+	for path, expected := range testCases {
+		buf := &bytes.Buffer{}
+		if err := NewJSONPathPrinter(path).PrintObj(pods, buf); err != nil {
+			t.Errorf("%s: unexpected error: %v", path, err)
+			continue
+		}
+		if buf.String() != expected {
+			t.Errorf("%s: expected %q, got %q", path, expected, buf.String())
+		}
+	}
+	if err := NewJSONPathPrinter("items.0.id.foo").PrintObj(pods, &bytes.Buffer{}); err == nil {
+		t.Errorf("Expected an error looking up a field of a string")
+	}
+}
+
+func TestColumnPrinter(t *testing.T) {
+	pods := &api.PodList{
+		Items: []api.Pod{
+			{
+				JSONBase:     api.JSONBase{ID: "foo"},
+				Labels:       map[string]string{"name": "foo"},
+				CurrentState: api.PodState{Host: "machine", Status: api.PodRunning},
+				DesiredState: api.PodState{Manifest: api.ContainerManifest{
+					Containers: []api.Container{{Name: "a"}, {Name: "b"}},
+				}},
+			},
+			{JSONBase: api.JSONBase{ID: "bar"}},
+		},
+	}
+	buf := &bytes.Buffer{}
+	printer := NewColumnPrinter("ID, currentState.host,CurrentState.Status,Labels,DesiredState.Manifest.Containers.Name")
+	if err := printer.PrintObj(pods, buf); err == nil {
+		t.Errorf("Expected an error for a field of a list")
+	}
+
+	buf.Reset()
+	printer = NewColumnPrinter("ID, currentState.host,CurrentState.Status,Labels")
+	if err := printer.PrintObj(pods, buf); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	lines := strings.Split(buf.String(), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("Expected a header and 2 rows, got %q", buf.String())
+	}
+	for i, expected := range [][]string{
+		{"ID", "currentState.host", "CurrentState.Status", "Labels"},
+		{"----------", "----------", "----------", "----------"},
+		{"foo", "machine", "Running", "name=foo"},
+		{"bar", "", "", ""},
+	} {
+		if fields := strings.Fields(lines[i]); !reflect.DeepEqual(fields, expected[:len(fields)]) || len(fields) == 0 {
+			t.Errorf("Expected line %d to be %v, got %q", i, expected, lines[i])
+		}
+	}
+
+	buf.Reset()
+	if err := NewColumnPrinter("DesiredState.Manifest.Containers").PrintObj(&pods.Items[0], buf); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "{a ") {
+		t.Errorf("Expected the containers to be printed, got %q", buf.String())
+	}
+	if err := NewColumnPrinter("Missing").PrintObj(&pods.Items[0], buf); err == nil {
+		t.Errorf("Expected an error for a missing field")
+	}
+	if err := NewColumnPrinter("").PrintObj(&pods.Items[0], buf); err == nil {
+		t.Errorf("Expected an error for no columns")
+	}
+}
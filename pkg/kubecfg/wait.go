@@ -0,0 +1,103 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubecfg
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
+)
+
+// waitPollInterval is how often WaitForCondition checks the object when its
+// watch is quiet.  Overridden in tests.
+var waitPollInterval = 5 * time.Second
+
+// WaitForCondition blocks until the pod with the given id is running, or the
+// replicationController with the given id has as many running pods as it
+// desires, or timeout elapses.  It wakes on changes seen through a watch, but
+// also checks every waitPollInterval, since a pod's status is worked out from
+// its minion when it is read rather than stored.
+func WaitForCondition(resource, id string, c client.Interface, timeout time.Duration) error {
+	var startWatch func() (watch.Interface, error)
+	var check func() (bool, error)
+	switch resource {
+	case "pods":
+		startWatch = func() (watch.Interface, error) {
+			return c.Pods().Watch(labels.Everything(), labels.Set{"id": id}.AsSelector(), 0)
+		}
+		check = func() (bool, error) {
+			pod, err := c.Pods().Get(id)
+			if err != nil {
+				return false, err
+			}
+			if pod.CurrentState.Status == api.PodTerminated {
+				return false, fmt.Errorf("pod %s terminated", id)
+			}
+			return pod.CurrentState.Status == api.PodRunning, nil
+		}
+	case "replicationControllers":
+		startWatch = func() (watch.Interface, error) {
+			return c.ReplicationControllers().Watch(labels.Everything(), labels.Everything(), 0)
+		}
+		check = func() (bool, error) {
+			controller, err := c.ReplicationControllers().Get(id)
+			if err != nil {
+				return false, err
+			}
+			return controller.Status.ReadyReplicas >= controller.DesiredState.Replicas, nil
+		}
+	default:
+		return fmt.Errorf("waiting is not supported for %s", resource)
+	}
+
+	deadline := time.After(timeout)
+	var w watch.Interface
+	defer func() {
+		if w != nil {
+			w.Stop()
+		}
+	}()
+	var events <-chan watch.Event
+	for {
+		// Start watching before checking, so that no change is missed.  A
+		// watch which the server closes is started again.
+		if events == nil {
+			var err error
+			if w, err = startWatch(); err != nil {
+				return err
+			}
+			events = w.ResultChan()
+		}
+		done, err := check()
+		if err != nil || done {
+			return err
+		}
+		select {
+		case _, ok := <-events:
+			if !ok {
+				events = nil
+			}
+		case <-time.After(waitPollInterval):
+		case <-deadline:
+			return fmt.Errorf("timed out after %v waiting for %s/%s", timeout, resource, id)
+		}
+	}
+}
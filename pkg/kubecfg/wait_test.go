@@ -0,0 +1,141 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubecfg
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
+)
+
+// podStatusReactor serves a pod whose status can be changed while a test waits.
+type podStatusReactor struct {
+	sync.Mutex
+	status api.PodStatus
+}
+
+func (r *podStatusReactor) set(status api.PodStatus) {
+	r.Lock()
+	defer r.Unlock()
+	r.status = status
+}
+
+func (r *podStatusReactor) get(client.FakeAction) (bool, interface{}, error) {
+	r.Lock()
+	defer r.Unlock()
+	return true, &api.Pod{JSONBase: api.JSONBase{ID: "foo"}, CurrentState: api.PodState{Status: r.status}}, nil
+}
+
+func TestWaitForPodWatch(t *testing.T) {
+	defer func(interval time.Duration) { waitPollInterval = interval }(waitPollInterval)
+	waitPollInterval = time.Hour
+
+	reactor := &podStatusReactor{status: api.PodWaiting}
+	checks := make(chan bool)
+	watches := make(chan *watch.FakeWatcher, 2)
+	fakeClient := &client.Fake{}
+	fakeClient.AddReactor("get-pod", func(action client.FakeAction) (bool, interface{}, error) {
+		handled, pod, err := reactor.get(action)
+		checks <- true
+		return handled, pod, err
+	})
+	fakeClient.AddReactor("watch-pods", func(client.FakeAction) (bool, interface{}, error) {
+		w := watch.NewFake()
+		watches <- w
+		return true, w, nil
+	})
+
+	done := make(chan error)
+	go func() {
+		done <- WaitForCondition("pods", "foo", fakeClient, time.Minute)
+	}()
+	// A closed watch is started again, and a change wakes the wait.
+	w := <-watches
+	<-checks
+	w.Stop()
+	w = <-watches
+	<-checks
+	reactor.set(api.PodRunning)
+	w.Modify(&api.Pod{})
+	<-checks
+	if err := <-done; err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if !w.Stopped {
+		t.Errorf("Expected the watch to be stopped")
+	}
+}
+
+func TestWaitForPodPoll(t *testing.T) {
+	defer func(interval time.Duration) { waitPollInterval = interval }(waitPollInterval)
+	waitPollInterval = time.Millisecond
+
+	gets := 0
+	fakeClient := &client.Fake{Watch: watch.NewFake()}
+	fakeClient.AddReactor("get-pod", func(client.FakeAction) (bool, interface{}, error) {
+		gets++
+		status := api.PodWaiting
+		if gets == 3 {
+			status = api.PodRunning
+		}
+		return true, &api.Pod{CurrentState: api.PodState{Status: status}}, nil
+	})
+	if err := WaitForCondition("pods", "foo", fakeClient, time.Minute); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if gets != 3 {
+		t.Errorf("Expected 3 checks, got %d", gets)
+	}
+
+	reactor := &podStatusReactor{status: api.PodWaiting}
+	fakeClient.AddReactor("get-pod", reactor.get)
+	if err := WaitForCondition("pods", "foo", fakeClient, 20*time.Millisecond); err == nil {
+		t.Errorf("Expected a timeout")
+	}
+	reactor.set(api.PodTerminated)
+	if err := WaitForCondition("pods", "foo", fakeClient, time.Minute); err == nil {
+		t.Errorf("Expected an error for a terminated pod")
+	}
+}
+
+func TestWaitForReplicationController(t *testing.T) {
+	defer func(interval time.Duration) { waitPollInterval = interval }(waitPollInterval)
+	waitPollInterval = time.Millisecond
+
+	ready := 0
+	fakeClient := &client.Fake{Watch: watch.NewFake()}
+	fakeClient.AddReactor("get-controller", func(client.FakeAction) (bool, interface{}, error) {
+		ready++
+		return true, &api.ReplicationController{
+			DesiredState: api.ReplicationControllerState{Replicas: 3},
+			Status:       api.ReplicationControllerStatus{ReadyReplicas: ready},
+		}, nil
+	})
+	if err := WaitForCondition("replicationControllers", "foo", fakeClient, time.Minute); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if ready != 3 {
+		t.Errorf("Expected 3 checks, got %d", ready)
+	}
+	if err := WaitForCondition("services", "foo", fakeClient, time.Minute); err == nil {
+		t.Errorf("Expected an error for an unsupported resource")
+	}
+}
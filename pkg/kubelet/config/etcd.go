@@ -45,9 +45,9 @@ type SourceEtcd struct {
 // NewSourceEtcd creates a config source that watches and pulls from a key in etcd
 func NewSourceEtcd(key string, client tools.EtcdClient, updates chan<- interface{}) *SourceEtcd {
 	helper := tools.EtcdHelper{
-		client,
-		latest.Codec,
-		latest.ResourceVersioner,
+		Client:            client,
+		Codec:             latest.Codec,
+		ResourceVersioner: latest.ResourceVersioner,
 	}
 	source := &SourceEtcd{
 		key:     key,
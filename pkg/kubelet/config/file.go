@@ -32,7 +32,6 @@ import (
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/kubelet"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
 	"github.com/golang/glog"
-	"gopkg.in/v1/yaml"
 )
 
 type SourceFile struct {
@@ -123,7 +122,7 @@ func extractFromFile(name string) (kubelet.Pod, error) {
 		return pod, err
 	}
 
-	if err := yaml.Unmarshal(data, &pod.Manifest); err != nil {
+	if err := kubelet.UnmarshalManifest(data, &pod.Manifest); err != nil {
 		return pod, fmt.Errorf("could not unmarshal manifest: %v", err)
 	}
 
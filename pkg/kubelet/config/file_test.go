@@ -87,6 +87,28 @@ func TestReadFromFile(t *testing.T) {
 	}
 }
 
+func TestReadFromFileWithLowercaseProtocol(t *testing.T) {
+	file := writeTestFile(t, os.TempDir(), "test_pod_config", "version: v1beta1\nid: test\ncontainers:\n- name: web\n  image: test/image\n  ports:\n  - containerPort: 80\n    protocol: tcp\n  - containerPort: 53\n    protocol: udp")
+	defer os.Remove(file.Name())
+
+	ch := make(chan interface{}, 1)
+	c := SourceFile{file.Name(), ch}
+	if err := c.extractFromPath(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	update := (<-ch).(kubelet.PodUpdate)
+	expected := []api.Port{
+		{ContainerPort: 80, Protocol: api.ProtocolTCP},
+		{ContainerPort: 53, Protocol: api.ProtocolUDP},
+	}
+	if ports := update.Pods[0].Manifest.Containers[0].Ports; !reflect.DeepEqual(expected, ports) {
+		t.Errorf("Expected %#v, Got %#v", expected, ports)
+	}
+	if errs := kubelet.ValidatePod(&update.Pods[0]); len(errs) != 0 {
+		t.Errorf("Expected no validation errors, Got %#v", errs)
+	}
+}
+
 func TestExtractFromBadDataFile(t *testing.T) {
 	file := writeTestFile(t, os.TempDir(), "test_pod_config", string([]byte{1, 2, 3}))
 	defer os.Remove(file.Name())
@@ -29,7 +29,6 @@ import (
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/kubelet"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
 	"github.com/golang/glog"
-	"gopkg.in/v1/yaml"
 )
 
 type SourceURL struct {
@@ -79,7 +78,7 @@ func (s *SourceURL) extractFromURL() error {
 
 	// First try as if it's a single manifest
 	var manifest api.ContainerManifest
-	singleErr := yaml.Unmarshal(data, &manifest)
+	singleErr := kubelet.UnmarshalManifest(data, &manifest)
 	if singleErr == nil {
 		if errs := validation.ValidateManifest(&manifest); len(errs) > 0 {
 			singleErr = fmt.Errorf("invalid manifest: %v", errs)
@@ -95,8 +94,7 @@ func (s *SourceURL) extractFromURL() error {
 	}
 
 	// That didn't work, so try an array of manifests.
-	var manifests []api.ContainerManifest
-	multiErr := yaml.Unmarshal(data, &manifests)
+	manifests, multiErr := kubelet.UnmarshalManifests(data)
 	// We're not sure if the person reading the logs is going to care about the single or
 	// multiple manifest unmarshalling attempt, so we need to put both in the logs, as is
 	// done at the end. Hence not returning early here.
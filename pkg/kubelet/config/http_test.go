@@ -179,3 +179,20 @@ func TestExtractFromHTTP(t *testing.T) {
 		}
 	}
 }
+
+func TestExtractFromHTTPWithLowercaseProtocol(t *testing.T) {
+	fakeHandler := util.FakeHandler{
+		StatusCode:   200,
+		ResponseBody: `[{"version": "v1beta1", "id": "foo", "containers": [{"name": "web", "image": "foo", "ports": [{"containerPort": 80, "protocol": "tcp"}]}]}]`,
+	}
+	testServer := httptest.NewServer(&fakeHandler)
+	ch := make(chan interface{}, 1)
+	c := SourceURL{testServer.URL, ch, nil}
+	if err := c.extractFromURL(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	update := (<-ch).(kubelet.PodUpdate)
+	if protocol := update.Pods[0].Manifest.Containers[0].Ports[0].Protocol; protocol != api.ProtocolTCP {
+		t.Errorf("Expected %v, Got %v", api.ProtocolTCP, protocol)
+	}
+}
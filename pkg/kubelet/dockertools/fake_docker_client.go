@@ -34,6 +34,7 @@ type FakeDockerClient struct {
 	Stopped       []string
 	pulled        []string
 	Created       []string
+	HostConfigs   []*docker.HostConfig
 }
 
 func (f *FakeDockerClient) clearCalls() {
@@ -91,6 +92,7 @@ func (f *FakeDockerClient) StartContainer(id string, hostConfig *docker.HostConf
 	f.Lock()
 	defer f.Unlock()
 	f.called = append(f.called, "start")
+	f.HostConfigs = append(f.HostConfigs, hostConfig)
 	return f.Err
 }
 
@@ -20,8 +20,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"path"
+	"runtime"
 	"strconv"
 	"strings"
 	"sync"
@@ -48,6 +50,14 @@ const minShares = 2
 const sharesPerCPU = 1024
 const milliCPUToCPU = 1000
 
+// eventCoalescePeriod is how long a repeat of an event, e.g. the same container
+// being stopped again on every sync, is dropped instead of written to etcd.
+const eventCoalescePeriod = time.Minute
+
+// serviceDialTimeout bounds how long we wait for a service named in a
+// container's WaitFor to accept a connection.
+const serviceDialTimeout = 2 * time.Second
+
 // CadvisorInterface is an abstract interface for testability.  It abstracts the interface of "github.com/google/cadvisor/client".Client.
 type CadvisorInterface interface {
 	ContainerInfo(name string, req *info.ContainerInfoRequest) (*info.ContainerInfo, error)
@@ -68,7 +78,9 @@ func NewMainKubelet(
 	cc CadvisorInterface,
 	ec tools.EtcdClient,
 	rd string,
-	ri time.Duration) *Kubelet {
+	ri time.Duration,
+	clusterDNS net.IP,
+	clusterDomain string) *Kubelet {
 	return &Kubelet{
 		hostname:       hn,
 		dockerClient:   dc,
@@ -79,6 +91,8 @@ func NewMainKubelet(
 		podWorkers:     newPodWorkers(),
 		runner:         dockertools.NewDockerContainerCommandRunner(),
 		httpClient:     &http.Client{},
+		clusterDNS:     clusterDNS,
+		clusterDomain:  clusterDomain,
 	}
 }
 
@@ -120,6 +134,20 @@ type Kubelet struct {
 	runner dockertools.ContainerCommandRunner
 	// Optional, client for http requests, defaults to empty client
 	httpClient httpGetInterface
+	// Optional, used to check WaitFor services, defaults to net.DialTimeout
+	dialer func(network, address string, timeout time.Duration) (net.Conn, error)
+	// Optional, the nameserver that containers resolve names with
+	clusterDNS net.IP
+	// Optional, searched by containers for unqualified names
+	clusterDomain string
+
+	// When each distinct event was last written, so that repeats can be coalesced.
+	lastEventTimes     map[string]time.Time
+	lastEventTimesLock sync.Mutex
+
+	// When each container with a SoftMemoryLimit was first seen above it.
+	overSoftMemoryLimit     map[dockertools.DockerID]time.Time
+	overSoftMemoryLimitLock sync.Mutex
 }
 
 // Run starts the kubelet reacting to config updates
@@ -173,12 +201,19 @@ func (self *podWorkers) Run(podFullName string, action func()) {
 	}()
 }
 
-// LogEvent logs an event to the etcd backend.
+// LogEvent logs an event to the etcd backend. Repeats of an event written within
+// the last eventCoalescePeriod are dropped.
 func (kl *Kubelet) LogEvent(event *api.Event) error {
 	if kl.etcdClient == nil {
 		return fmt.Errorf("no etcd client connection")
 	}
-	event.Timestamp = time.Now().Unix()
+	now := time.Now()
+	key := eventKey(event)
+	if kl.eventWrittenSince(key, now.Add(-eventCoalescePeriod)) {
+		glog.V(2).Infof("Coalescing repeated event %s", key)
+		return nil
+	}
+	event.Timestamp = now.Unix()
 	data, err := json.Marshal(event)
 	if err != nil {
 		return err
@@ -192,8 +227,46 @@ func (kl *Kubelet) LogEvent(event *api.Event) error {
 		if response != nil {
 			glog.Infof("Response was: %v\n", *response)
 		}
+		return err
 	}
-	return err
+	kl.recordEventWritten(key, now)
+	return nil
+}
+
+// eventKey identifies events which are repeats of each other.
+func eventKey(event *api.Event) string {
+	key := event.Event
+	if event.Manifest != nil {
+		key += "/" + event.Manifest.ID + "/" + event.Manifest.UUID
+	}
+	if event.Container != nil {
+		key += "/" + event.Container.Name
+	}
+	return key
+}
+
+// eventWrittenSince returns true if the event with key was written after cutoff.
+func (kl *Kubelet) eventWrittenSince(key string, cutoff time.Time) bool {
+	kl.lastEventTimesLock.Lock()
+	defer kl.lastEventTimesLock.Unlock()
+	last, found := kl.lastEventTimes[key]
+	return found && last.After(cutoff)
+}
+
+// recordEventWritten notes that the event with key was written at now, and
+// forgets events too old to be coalesced.
+func (kl *Kubelet) recordEventWritten(key string, now time.Time) {
+	kl.lastEventTimesLock.Lock()
+	defer kl.lastEventTimesLock.Unlock()
+	if kl.lastEventTimes == nil {
+		kl.lastEventTimes = map[string]time.Time{}
+	}
+	for k, last := range kl.lastEventTimes {
+		if now.Sub(last) > eventCoalescePeriod {
+			delete(kl.lastEventTimes, k)
+		}
+	}
+	kl.lastEventTimes[key] = now
 }
 
 func makeEnvironmentVariables(container *api.Container) []string {
@@ -204,6 +277,75 @@ func makeEnvironmentVariables(container *api.Container) []string {
 	return result
 }
 
+// Docker labels identifying the pod and container a Docker container was created for.
+const (
+	podNameLabel       = "io.kubernetes.pod.name"
+	podNamespaceLabel  = "io.kubernetes.pod.namespace"
+	podUUIDLabel       = "io.kubernetes.pod.uuid"
+	containerNameLabel = "io.kubernetes.container.name"
+	// annotationLabelPrefix is prepended to annotation keys to make their Docker
+	// labels, so that annotations can't pass for the labels above.
+	annotationLabelPrefix = "io.kubernetes.annotation."
+)
+
+// makeLabels returns the Docker labels for a container: the pod's and then the
+// container's annotations, followed by labels identifying the pod and container,
+// so node-level tooling can map Docker containers back to pods.
+func makeLabels(pod *Pod, container *api.Container) map[string]string {
+	labels := map[string]string{}
+	for k, v := range pod.Manifest.Annotations {
+		labels[annotationLabelPrefix+k] = v
+	}
+	for k, v := range container.Annotations {
+		labels[annotationLabelPrefix+k] = v
+	}
+	labels[podNameLabel] = pod.Name
+	labels[podNamespaceLabel] = pod.Namespace
+	labels[podUUIDLabel] = pod.Manifest.UUID
+	labels[containerNameLabel] = container.Name
+	return labels
+}
+
+// makeServiceEnvVariableName mirrors the naming of the service environment
+// variables that the apiserver adds to each container.
+func makeServiceEnvVariableName(id string) string {
+	return strings.ToUpper(strings.Replace(id, "-", "_", -1))
+}
+
+// checkServiceDependencies returns an error unless every service listed in the
+// container's WaitFor accepts TCP connections. Services are located through
+// the SERVICE_HOST and <ID>_SERVICE_PORT variables in the container's
+// environment, so a service created after the pod was bound is never found.
+func (kl *Kubelet) checkServiceDependencies(container *api.Container) error {
+	if len(container.WaitFor) == 0 {
+		return nil
+	}
+	env := map[string]string{}
+	for _, value := range container.Env {
+		env[value.Name] = value.Value
+	}
+	host, found := env["SERVICE_HOST"]
+	if !found {
+		return fmt.Errorf("no SERVICE_HOST in environment")
+	}
+	dial := kl.dialer
+	if dial == nil {
+		dial = net.DialTimeout
+	}
+	for _, service := range container.WaitFor {
+		port, found := env[makeServiceEnvVariableName(service)+"_SERVICE_PORT"]
+		if !found {
+			return fmt.Errorf("service %s is not known", service)
+		}
+		conn, err := dial("tcp", net.JoinHostPort(host, port), serviceDialTimeout)
+		if err != nil {
+			return fmt.Errorf("service %s is not available: %v", service, err)
+		}
+		conn.Close()
+	}
+	return nil
+}
+
 func makeBinds(pod *Pod, container *api.Container, podVolumes volumeMap) []string {
 	binds := []string{}
 	for _, mount := range container.VolumeMounts {
@@ -233,7 +375,7 @@ func makePortsAndBindings(container *api.Container) (map[docker.Port]struct{}, m
 		// Some of this port stuff is under-documented voodoo.
 		// See http://stackoverflow.com/questions/20428302/binding-a-port-to-a-host-interface-using-the-rest-api
 		var protocol string
-		switch strings.ToUpper(port.Protocol) {
+		switch strings.ToUpper(string(port.Protocol)) {
 		case "UDP":
 			protocol = "/udp"
 		case "TCP":
@@ -326,7 +468,8 @@ func (kl *Kubelet) runContainer(pod *Pod, container *api.Container, podVolumes v
 			Env:          envVariables,
 			ExposedPorts: exposedPorts,
 			Hostname:     pod.Name,
-			Image:        container.Image,
+			Image:        containerImage(container),
+			Labels:       makeLabels(pod, container),
 			Memory:       int64(container.Memory),
 			CpuShares:    int64(milliCPUToShares(container.CPU)),
 			WorkingDir:   container.WorkingDir,
@@ -342,12 +485,20 @@ func (kl *Kubelet) runContainer(pod *Pod, container *api.Container, podVolumes v
 	} else if container.Privileged {
 		return "", fmt.Errorf("Container requested privileged mode, but it is disallowed globally.")
 	}
-	err = kl.dockerClient.StartContainer(dockerContainer.ID, &docker.HostConfig{
+	hostConfig := &docker.HostConfig{
 		PortBindings: portBindings,
 		Binds:        binds,
 		NetworkMode:  netMode,
 		Privileged:   privileged,
-	})
+	}
+	// The other containers share the network container's resolv.conf.
+	if netMode == "" && kl.clusterDNS != nil {
+		hostConfig.Dns = []string{kl.clusterDNS.String()}
+		if len(kl.clusterDomain) > 0 {
+			hostConfig.DnsSearch = []string{kl.clusterDomain}
+		}
+	}
+	err = kl.dockerClient.StartContainer(dockerContainer.ID, hostConfig)
 	if err == nil && container.Lifecycle != nil && container.Lifecycle.PostStart != nil {
 		handlerErr := kl.runHandler(GetPodFullName(pod), pod.Manifest.UUID, container, container.Lifecycle.PostStart)
 		if handlerErr != nil {
@@ -366,6 +517,7 @@ func (kl *Kubelet) killContainer(dockerContainer *docker.APIContainers) error {
 func (kl *Kubelet) killContainerByID(ID, name string) error {
 	glog.Infof("Killing: %s", ID)
 	err := kl.dockerClient.StopContainer(ID, 10)
+	kl.forgetSoftMemoryLimit(dockertools.DockerID(ID))
 	if len(name) == 0 {
 		return err
 	}
@@ -385,6 +537,17 @@ func (kl *Kubelet) killContainerByID(ID, name string) error {
 	return err
 }
 
+// platform is the "<os>/<arch>" of this node, by which containers' PlatformImages are keyed.
+var platform = runtime.GOOS + "/" + runtime.GOARCH
+
+// containerImage returns the image to run container from on this node's platform.
+func containerImage(container *api.Container) string {
+	if image, ok := container.PlatformImages[platform]; ok {
+		return image
+	}
+	return container.Image
+}
+
 const (
 	networkContainerName  = "net"
 	networkContainerImage = "kubernetes/pause:latest"
@@ -507,10 +670,28 @@ func (kl *Kubelet) syncPod(pod *Pod, dockerContainers dockertools.DockerContaine
 					continue
 				}
 				if healthy == health.Healthy {
-					containersToKeep[containerID] = empty{}
-					continue
+					exceeded, err := kl.exceedsSoftMemoryLimit(&container, containerID)
+					if err != nil {
+						glog.V(1).Infof("memory check errored: %v", err)
+					}
+					if !exceeded {
+						containersToKeep[containerID] = empty{}
+						continue
+					}
+					glog.Infof("pod %s container %s exceeded its soft memory limit.", podFullName, container.Name)
+					kl.LogEvent(&api.Event{
+						Event: "SOFT_MEMORY_LIMIT_EXCEEDED",
+						Manifest: &api.ContainerManifest{
+							ID:   podFullName,
+							UUID: uuid,
+						},
+						Container: &api.Container{
+							Name: container.Name,
+						},
+					})
+				} else {
+					glog.V(1).Infof("pod %s container %s is unhealthy.", podFullName, container.Name, healthy)
 				}
-				glog.V(1).Infof("pod %s container %s is unhealthy.", podFullName, container.Name, healthy)
 			} else {
 				glog.V(1).Infof("container hash changed %d vs %d.", hash, expectedHash)
 			}
@@ -544,9 +725,15 @@ func (kl *Kubelet) syncPod(pod *Pod, dockerContainers dockertools.DockerContaine
 			}
 		}
 
+		if err := kl.checkServiceDependencies(&container); err != nil {
+			glog.Infof("Delaying start of container %s--%s--%s: %v", podFullName, uuid, container.Name, err)
+			continue
+		}
+
 		glog.Infof("Container with name %s--%s--%s doesn't exist, creating %#v", podFullName, uuid, container.Name, container)
-		if err := kl.dockerPuller.Pull(container.Image); err != nil {
-			glog.Errorf("Failed to pull image %s: %v skipping pod %s container %s.", container.Image, err, podFullName, container.Name)
+		image := containerImage(&container)
+		if err := kl.dockerPuller.Pull(image); err != nil {
+			glog.Errorf("Failed to pull image %s: %v skipping pod %s container %s.", image, err, podFullName, container.Name)
 			continue
 		}
 		// TODO(dawnchen): Check RestartPolicy.DelaySeconds before restart a container
@@ -666,6 +853,7 @@ func (kl *Kubelet) SyncPods(pods []Pod) error {
 			}
 		}
 	}
+	kl.pruneSoftMemoryLimits(existingContainers)
 
 	// Remove any orphaned volumes.
 	kl.reconcileVolumes(pods)
@@ -748,6 +936,62 @@ func (kl *Kubelet) statsFromContainerPath(containerPath string, req *info.Contai
 	return cinfo, nil
 }
 
+// exceedsSoftMemoryLimit returns true if the container has used more memory than
+// its SoftMemoryLimit allows for at least the limit's duration.
+func (kl *Kubelet) exceedsSoftMemoryLimit(container *api.Container, id dockertools.DockerID) (bool, error) {
+	if container.SoftMemoryLimit == nil || kl.cadvisorClient == nil {
+		return false, nil
+	}
+	cinfo, err := kl.statsFromContainerPath(fmt.Sprintf("/docker/%s", id), &info.ContainerInfoRequest{NumStats: 1})
+	if err != nil {
+		return false, err
+	}
+	if len(cinfo.Stats) == 0 || cinfo.Stats[len(cinfo.Stats)-1].Memory == nil {
+		return false, nil
+	}
+	usage := cinfo.Stats[len(cinfo.Stats)-1].Memory.Usage
+
+	kl.overSoftMemoryLimitLock.Lock()
+	defer kl.overSoftMemoryLimitLock.Unlock()
+	if kl.overSoftMemoryLimit == nil {
+		kl.overSoftMemoryLimit = map[dockertools.DockerID]time.Time{}
+	}
+	if usage <= uint64(container.SoftMemoryLimit.Memory) {
+		delete(kl.overSoftMemoryLimit, id)
+		return false, nil
+	}
+	now := time.Now()
+	since, found := kl.overSoftMemoryLimit[id]
+	if !found {
+		kl.overSoftMemoryLimit[id] = now
+		since = now
+	}
+	if now.Sub(since) < time.Duration(container.SoftMemoryLimit.DurationSeconds)*time.Second {
+		return false, nil
+	}
+	delete(kl.overSoftMemoryLimit, id)
+	return true, nil
+}
+
+// forgetSoftMemoryLimit drops any soft memory limit bookkeeping for a container.
+func (kl *Kubelet) forgetSoftMemoryLimit(id dockertools.DockerID) {
+	kl.overSoftMemoryLimitLock.Lock()
+	defer kl.overSoftMemoryLimitLock.Unlock()
+	delete(kl.overSoftMemoryLimit, id)
+}
+
+// pruneSoftMemoryLimits drops soft memory limit bookkeeping for containers
+// that are no longer running.
+func (kl *Kubelet) pruneSoftMemoryLimits(running dockertools.DockerContainers) {
+	kl.overSoftMemoryLimitLock.Lock()
+	defer kl.overSoftMemoryLimitLock.Unlock()
+	for id := range kl.overSoftMemoryLimit {
+		if _, found := running[id]; !found {
+			delete(kl.overSoftMemoryLimit, id)
+		}
+	}
+}
+
 // GetPodInfo returns information from Docker about the containers in a pod
 func (kl *Kubelet) GetPodInfo(podFullName, uuid string) (api.PodInfo, error) {
 	return dockertools.GetDockerPodInfo(kl.dockerClient, podFullName, uuid)
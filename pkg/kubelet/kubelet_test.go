@@ -19,6 +19,7 @@ package kubelet
 import (
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
 	"reflect"
 	"regexp"
@@ -237,6 +238,77 @@ func TestSyncPodsCreatesNetAndContainer(t *testing.T) {
 	fakeDocker.Unlock()
 }
 
+func TestSyncPodsSetsClusterDNSOnNetContainer(t *testing.T) {
+	kubelet, _, fakeDocker := newTestKubelet(t)
+	kubelet.clusterDNS = net.ParseIP("10.0.0.10")
+	kubelet.clusterDomain = "kubernetes.local"
+	fakeDocker.ContainerList = []docker.APIContainers{}
+	err := kubelet.SyncPods([]Pod{
+		{
+			Name:      "foo",
+			Namespace: "test",
+			Manifest: api.ContainerManifest{
+				ID: "foo",
+				Containers: []api.Container{
+					{Name: "bar"},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	kubelet.drainWorkers()
+
+	fakeDocker.Lock()
+	defer fakeDocker.Unlock()
+	if len(fakeDocker.HostConfigs) != 2 {
+		t.Fatalf("Unexpected host configs %v", fakeDocker.HostConfigs)
+	}
+	netConfig, barConfig := fakeDocker.HostConfigs[0], fakeDocker.HostConfigs[1]
+	if !reflect.DeepEqual(netConfig.Dns, []string{"10.0.0.10"}) || !reflect.DeepEqual(netConfig.DnsSearch, []string{"kubernetes.local"}) {
+		t.Errorf("Unexpected DNS settings for the network container: %v %v", netConfig.Dns, netConfig.DnsSearch)
+	}
+	if len(barConfig.Dns) != 0 || len(barConfig.DnsSearch) != 0 {
+		t.Errorf("Unexpected DNS settings for a container sharing the network container's: %v %v", barConfig.Dns, barConfig.DnsSearch)
+	}
+}
+
+func TestSyncPodsPullsPlatformImage(t *testing.T) {
+	kubelet, _, fakeDocker := newTestKubelet(t)
+	puller := kubelet.dockerPuller.(*dockertools.FakeDockerPuller)
+	fakeDocker.ContainerList = []docker.APIContainers{}
+	err := kubelet.SyncPods([]Pod{
+		{
+			Name:      "foo",
+			Namespace: "test",
+			Manifest: api.ContainerManifest{
+				ID: "foo",
+				Containers: []api.Container{
+					{
+						Name:  "bar",
+						Image: "bar",
+						PlatformImages: map[string]string{
+							platform:          "bar-here",
+							"plan9/elsewhere": "bar-elsewhere",
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	kubelet.drainWorkers()
+
+	puller.Lock()
+	defer puller.Unlock()
+	if !reflect.DeepEqual(puller.ImagesPulled, []string{networkContainerImage, "bar-here"}) {
+		t.Errorf("Unexpected images pulled %v", puller.ImagesPulled)
+	}
+}
+
 func TestSyncPodsWithNetCreatesContainer(t *testing.T) {
 	kubelet, _, fakeDocker := newTestKubelet(t)
 	fakeDocker.ContainerList = []docker.APIContainers{
@@ -274,6 +346,111 @@ func TestSyncPodsWithNetCreatesContainer(t *testing.T) {
 	fakeDocker.Unlock()
 }
 
+func TestSyncPodsWithNetDelaysContainerForWaitFor(t *testing.T) {
+	kubelet, _, fakeDocker := newTestKubelet(t)
+	kubelet.dialer = func(network, address string, timeout time.Duration) (net.Conn, error) {
+		return nil, fmt.Errorf("connection refused")
+	}
+	fakeDocker.ContainerList = []docker.APIContainers{
+		{
+			// network container
+			Names: []string{"/k8s--net--foo.test--"},
+			ID:    "9876",
+		},
+	}
+	err := kubelet.SyncPods([]Pod{
+		{
+			Name:      "foo",
+			Namespace: "test",
+			Manifest: api.ContainerManifest{
+				ID: "foo",
+				Containers: []api.Container{
+					{
+						Name:    "bar",
+						WaitFor: []string{"db"},
+						Env: []api.EnvVar{
+							{Name: "SERVICE_HOST", Value: "machine"},
+							{Name: "DB_SERVICE_PORT", Value: "5432"},
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	kubelet.drainWorkers()
+
+	verifyCalls(t, fakeDocker, []string{
+		"list", "list", "list", "inspect", "list"})
+
+	fakeDocker.Lock()
+	if len(fakeDocker.Created) != 0 {
+		t.Errorf("Unexpected containers created %v", fakeDocker.Created)
+	}
+	fakeDocker.Unlock()
+}
+
+func TestCheckServiceDependencies(t *testing.T) {
+	env := []api.EnvVar{
+		{Name: "SERVICE_HOST", Value: "machine"},
+		{Name: "DB_SERVICE_PORT", Value: "5432"},
+		{Name: "MY_CACHE_SERVICE_PORT", Value: "11211"},
+	}
+	tests := []struct {
+		container api.Container
+		available map[string]bool
+		expectErr bool
+		test      string
+	}{
+		{
+			container: api.Container{},
+			test:      "no dependencies",
+		},
+		{
+			container: api.Container{WaitFor: []string{"db", "my-cache"}, Env: env},
+			available: map[string]bool{"machine:5432": true, "machine:11211": true},
+			test:      "all available",
+		},
+		{
+			container: api.Container{WaitFor: []string{"db", "my-cache"}, Env: env},
+			available: map[string]bool{"machine:5432": true},
+			expectErr: true,
+			test:      "one unavailable",
+		},
+		{
+			container: api.Container{WaitFor: []string{"queue"}, Env: env},
+			available: map[string]bool{"machine:5432": true, "machine:11211": true},
+			expectErr: true,
+			test:      "unknown service",
+		},
+		{
+			container: api.Container{WaitFor: []string{"db"}},
+			expectErr: true,
+			test:      "no service environment",
+		},
+	}
+	for _, test := range tests {
+		kubelet, _, _ := newTestKubelet(t)
+		kubelet.dialer = func(network, address string, timeout time.Duration) (net.Conn, error) {
+			if !test.available[address] {
+				return nil, fmt.Errorf("connection refused: %s", address)
+			}
+			client, server := net.Pipe()
+			server.Close()
+			return client, nil
+		}
+		err := kubelet.checkServiceDependencies(&test.container)
+		if test.expectErr && err == nil {
+			t.Errorf("%s: expected error", test.test)
+		}
+		if !test.expectErr && err != nil {
+			t.Errorf("%s: unexpected error: %v", test.test, err)
+		}
+	}
+}
+
 func TestSyncPodsWithNetCreatesContainerCallsHandler(t *testing.T) {
 	kubelet, _, fakeDocker := newTestKubelet(t)
 	fakeHttp := fakeHTTP{}
@@ -547,6 +724,150 @@ func TestSyncPodUnhealthy(t *testing.T) {
 	}
 }
 
+func memoryUsageInfo(usage uint64) *info.ContainerInfo {
+	return &info.ContainerInfo{
+		Stats: []*info.ContainerStats{
+			{Memory: &info.MemoryStats{Usage: usage}},
+		},
+	}
+}
+
+func TestSyncPodSoftMemoryLimitExceeded(t *testing.T) {
+	kubelet, fakeEtcd, fakeDocker := newTestKubelet(t)
+	mockCadvisor := &mockCadvisorClient{}
+	mockCadvisor.On("ContainerInfo", "/docker/1234", &info.ContainerInfoRequest{NumStats: 1}).Return(memoryUsageInfo(2048), nil)
+	kubelet.cadvisorClient = mockCadvisor
+	dockerContainers := dockertools.DockerContainers{
+		"1234": &docker.APIContainers{
+			// the k8s prefix is required for the kubelet to manage the container
+			Names: []string{"/k8s--bar--foo.test"},
+			ID:    "1234",
+		},
+		"9876": &docker.APIContainers{
+			// network container
+			Names: []string{"/k8s--net--foo.test--"},
+			ID:    "9876",
+		},
+	}
+	err := kubelet.syncPod(&Pod{
+		Name:      "foo",
+		Namespace: "test",
+		Manifest: api.ContainerManifest{
+			ID: "foo",
+			Containers: []api.Container{
+				{
+					Name:            "bar",
+					SoftMemoryLimit: &api.SoftMemoryLimit{Memory: 1024},
+				},
+			},
+		},
+	}, dockerContainers)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	verifyCalls(t, fakeDocker, []string{"list", "stop", "list", "create", "start"})
+	if len(fakeDocker.Stopped) != 1 || fakeDocker.Stopped[0] != "1234" {
+		t.Errorf("Wrong containers were stopped: %v", fakeDocker.Stopped)
+	}
+	response, err := fakeEtcd.Get("/events/bar/1", false, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var event api.Event
+	if err := json.Unmarshal([]byte(response.Node.Value), &event); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if event.Event != "SOFT_MEMORY_LIMIT_EXCEEDED" {
+		t.Errorf("Unexpected event: %#v", event)
+	}
+	mockCadvisor.AssertExpectations(t)
+}
+
+func TestExceedsSoftMemoryLimit(t *testing.T) {
+	tests := []struct {
+		limit    *api.SoftMemoryLimit
+		usage    uint64
+		since    time.Duration
+		expected bool
+		test     string
+	}{
+		{
+			usage: 2048,
+			test:  "no limit",
+		},
+		{
+			limit: &api.SoftMemoryLimit{Memory: 1024},
+			usage: 512,
+			test:  "below limit",
+		},
+		{
+			limit:    &api.SoftMemoryLimit{Memory: 1024},
+			usage:    2048,
+			expected: true,
+			test:     "above limit",
+		},
+		{
+			limit: &api.SoftMemoryLimit{Memory: 1024, DurationSeconds: 60},
+			usage: 2048,
+			test:  "above limit for less than duration",
+		},
+		{
+			limit: &api.SoftMemoryLimit{Memory: 1024, DurationSeconds: 60},
+			usage: 2048,
+			since: 30 * time.Second,
+			test:  "above limit for part of duration",
+		},
+		{
+			limit:    &api.SoftMemoryLimit{Memory: 1024, DurationSeconds: 60},
+			usage:    2048,
+			since:    90 * time.Second,
+			expected: true,
+			test:     "above limit for longer than duration",
+		},
+	}
+	for _, test := range tests {
+		kubelet, _, _ := newTestKubelet(t)
+		mockCadvisor := &mockCadvisorClient{}
+		mockCadvisor.On("ContainerInfo", "/docker/1234", &info.ContainerInfoRequest{NumStats: 1}).Return(memoryUsageInfo(test.usage), nil)
+		kubelet.cadvisorClient = mockCadvisor
+		if test.since != 0 {
+			kubelet.overSoftMemoryLimit = map[dockertools.DockerID]time.Time{
+				"1234": time.Now().Add(-test.since),
+			}
+		}
+		exceeded, err := kubelet.exceedsSoftMemoryLimit(&api.Container{SoftMemoryLimit: test.limit}, "1234")
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.test, err)
+		}
+		if exceeded != test.expected {
+			t.Errorf("%s: expected %v, got %v", test.test, test.expected, exceeded)
+		}
+	}
+}
+
+func TestSyncPodsForgetsSoftMemoryLimitsOfExitedContainers(t *testing.T) {
+	kubelet, _, fakeDocker := newTestKubelet(t)
+	fakeDocker.ContainerList = []docker.APIContainers{
+		{
+			Names: []string{"/k8s--foo--bar.test"},
+			ID:    "1234",
+		},
+	}
+	kubelet.overSoftMemoryLimit = map[dockertools.DockerID]time.Time{
+		"1234": time.Now(),
+		"5678": time.Now(),
+	}
+	if err := kubelet.SyncPods([]Pod{}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	kubelet.drainWorkers()
+
+	if len(kubelet.overSoftMemoryLimit) != 0 {
+		t.Errorf("expected soft memory limit bookkeeping to be cleared, got %v", kubelet.overSoftMemoryLimit)
+	}
+}
+
 func TestEventWriting(t *testing.T) {
 	kubelet, fakeEtcd, _ := newTestKubelet(t)
 	expectedEvent := api.Event{
@@ -594,6 +915,101 @@ func TestEventWritingError(t *testing.T) {
 	}
 }
 
+func TestEventWritingCoalesces(t *testing.T) {
+	kubelet, fakeEtcd, _ := newTestKubelet(t)
+	makeEvent := func(container string) *api.Event {
+		return &api.Event{
+			Event: "STOP",
+			Manifest: &api.ContainerManifest{
+				ID:   "foo.test",
+				UUID: "12345",
+			},
+			Container: &api.Container{
+				Name: container,
+			},
+		}
+	}
+	for i := 0; i < 3; i++ {
+		if err := kubelet.LogEvent(makeEvent("foo")); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	if fakeEtcd.Ix != 1 {
+		t.Errorf("Unexpected number of children added: %d, expected 1", fakeEtcd.Ix)
+	}
+
+	if err := kubelet.LogEvent(makeEvent("bar")); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if fakeEtcd.Ix != 2 {
+		t.Errorf("Unexpected number of children added: %d, expected 2", fakeEtcd.Ix)
+	}
+
+	// Once the coalescing period has passed the event is written again.
+	kubelet.lastEventTimes[eventKey(makeEvent("foo"))] = time.Now().Add(-2 * eventCoalescePeriod)
+	if err := kubelet.LogEvent(makeEvent("foo")); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if fakeEtcd.Ix != 3 {
+		t.Errorf("Unexpected number of children added: %d, expected 3", fakeEtcd.Ix)
+	}
+}
+
+func TestEventWritingErrorNotCoalesced(t *testing.T) {
+	kubelet, fakeEtcd, _ := newTestKubelet(t)
+	event := &api.Event{
+		Event: "test",
+		Container: &api.Container{
+			Name: "foo",
+		},
+	}
+	fakeEtcd.Err = fmt.Errorf("test error")
+	if err := kubelet.LogEvent(event); err == nil {
+		t.Errorf("Unexpected non-error")
+	}
+	fakeEtcd.Err = nil
+	if err := kubelet.LogEvent(event); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	// Both the failed and the retried write reach etcd.
+	if fakeEtcd.Ix != 2 {
+		t.Errorf("Unexpected number of writes: %d, expected 2", fakeEtcd.Ix)
+	}
+}
+
+func TestMakeLabels(t *testing.T) {
+	pod := &Pod{
+		Name:      "foo",
+		Namespace: "test",
+		Manifest: api.ContainerManifest{
+			UUID: "12345678",
+			Annotations: map[string]string{
+				"team":  "infra",
+				"owner": "pod",
+			},
+		},
+	}
+	container := &api.Container{
+		Name: "bar",
+		Annotations: map[string]string{
+			"owner":      "container",
+			podNameLabel: "spoofed",
+		},
+	}
+	expected := map[string]string{
+		"io.kubernetes.annotation.team":                   "infra",
+		"io.kubernetes.annotation.owner":                  "container",
+		"io.kubernetes.annotation.io.kubernetes.pod.name": "spoofed",
+		podNameLabel:       "foo",
+		podNamespaceLabel:  "test",
+		podUUIDLabel:       "12345678",
+		containerNameLabel: "bar",
+	}
+	if labels := makeLabels(pod, container); !reflect.DeepEqual(labels, expected) {
+		t.Errorf("expected %v, got %v", expected, labels)
+	}
+}
+
 func TestMakeEnvVariables(t *testing.T) {
 	container := api.Container{
 		Env: []api.EnvVar{
@@ -0,0 +1,50 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubelet
+
+import (
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/v1beta1"
+	"gopkg.in/v1/yaml"
+)
+
+// UnmarshalManifest decodes a manifest from YAML or JSON in the v1beta1
+// format manifests are written in.  The manifest goes through the same
+// conversion as manifests from the apiserver, so that e.g. protocols are
+// upper-cased before it is validated.
+func UnmarshalManifest(data []byte, manifest *api.ContainerManifest) error {
+	var versioned v1beta1.ContainerManifest
+	if err := yaml.Unmarshal(data, &versioned); err != nil {
+		return err
+	}
+	return api.Scheme.Convert(&versioned, manifest)
+}
+
+// UnmarshalManifests is like UnmarshalManifest, for a list of manifests.
+func UnmarshalManifests(data []byte) ([]api.ContainerManifest, error) {
+	var versioned []v1beta1.ContainerManifest
+	if err := yaml.Unmarshal(data, &versioned); err != nil {
+		return nil, err
+	}
+	manifests := make([]api.ContainerManifest, len(versioned))
+	for i := range versioned {
+		if err := api.Scheme.Convert(&versioned[i], &manifests[i]); err != nil {
+			return nil, err
+		}
+	}
+	return manifests, nil
+}
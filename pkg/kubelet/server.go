@@ -36,7 +36,6 @@ import (
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/kubelet/dockertools"
 	"github.com/golang/glog"
 	"github.com/google/cadvisor/info"
-	"gopkg.in/v1/yaml"
 )
 
 // Server is a http.Handler which exposes kubelet functionality over HTTP.
@@ -109,7 +108,7 @@ func (s *Server) handleContainer(w http.ResponseWriter, req *http.Request) {
 	}
 	// This is to provide backward compatibility. It only supports a single manifest
 	var pod Pod
-	err = yaml.Unmarshal(data, &pod.Manifest)
+	err = UnmarshalManifest(data, &pod.Manifest)
 	if err != nil {
 		s.error(w, err)
 		return
@@ -128,8 +127,7 @@ func (s *Server) handleContainers(w http.ResponseWriter, req *http.Request) {
 		s.error(w, err)
 		return
 	}
-	var manifests []api.ContainerManifest
-	err = yaml.Unmarshal(data, &manifests)
+	manifests, err := UnmarshalManifests(data)
 	if err != nil {
 		s.error(w, err)
 		return
@@ -22,12 +22,12 @@ import (
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
 )
 
-func ValidatePod(pod *Pod) (errors []error) {
+// ValidatePod tests that the pod has a valid name and manifest.
+func ValidatePod(pod *Pod) apierrs.ErrorList {
+	allErrs := apierrs.ErrorList{}
 	if !util.IsDNSSubdomain(pod.Name) {
-		errors = append(errors, apierrs.NewFieldInvalid("name", pod.Name))
+		allErrs = append(allErrs, apierrs.NewFieldInvalid("name", pod.Name))
 	}
-	if errs := validation.ValidateManifest(&pod.Manifest); len(errs) != 0 {
-		errors = append(errors, errs...)
-	}
-	return errors
+	allErrs = append(allErrs, validation.ValidateManifest(&pod.Manifest).Prefix("manifest")...)
+	return allErrs
 }
@@ -137,13 +137,14 @@ func (t andTerm) String() string {
 }
 
 // Operator represents a key's relationship
-// to a set of values in a Requirement.
-// TODO: Should also represent key's existence.
+// to a set of values in a Requirement, or its existence.
 type Operator int
 
 const (
 	IN Operator = iota + 1
 	NOT_IN
+	EXISTS
+	DOES_NOT_EXIST
 )
 
 // LabelSelector only not named 'Selector' due
@@ -152,23 +153,88 @@ type LabelSelector struct {
 	Requirements []Requirement
 }
 
+// Requirement is a selector on a single label: that its value is IN or
+// NOT_IN a set of values, or that it EXISTS or DOES_NOT_EXIST.  A label which
+// doesn't exist is NOT_IN any set.
 type Requirement struct {
 	key       string
 	operator  Operator
 	strValues util.StringSet
 }
 
+// NewRequirement creates a Requirement, checking that IN and NOT_IN are
+// given values, and EXISTS and DOES_NOT_EXIST are not.
+func NewRequirement(key string, op Operator, vals util.StringSet) (*Requirement, error) {
+	if err := validateLabelPart(key); err != nil {
+		return nil, err
+	}
+	switch op {
+	case IN, NOT_IN:
+		if len(vals) == 0 {
+			return nil, fmt.Errorf("a requirement on %q must have values", key)
+		}
+		for val := range vals {
+			if err := validateLabelPart(val); err != nil {
+				return nil, err
+			}
+		}
+	case EXISTS, DOES_NOT_EXIST:
+		if len(vals) != 0 {
+			return nil, fmt.Errorf("a requirement that %q exists or not can't have values", key)
+		}
+	default:
+		return nil, fmt.Errorf("unknown operator %d", op)
+	}
+	return &Requirement{key: key, operator: op, strValues: vals}, nil
+}
+
+// validateLabelPart checks that a label key or value can be written in a
+// selector string.
+func validateLabelPart(part string) error {
+	if part == "" || strings.ContainsAny(part, " \t=!(),") {
+		return fmt.Errorf("invalid label key or value %q", part)
+	}
+	return nil
+}
+
 func (r *Requirement) Matches(ls Labels) bool {
 	switch r.operator {
 	case IN:
-		return r.strValues.Has(ls.Get(r.key))
+		return ls.Has(r.key) && r.strValues.Has(ls.Get(r.key))
 	case NOT_IN:
-		return !r.strValues.Has(ls.Get(r.key))
+		return !ls.Has(r.key) || !r.strValues.Has(ls.Get(r.key))
+	case EXISTS:
+		return ls.Has(r.key)
+	case DOES_NOT_EXIST:
+		return !ls.Has(r.key)
 	default:
 		return false
 	}
 }
 
+func (r *Requirement) Empty() bool {
+	return false
+}
+
+func (r *Requirement) RequiresExactMatch(label string) (value string, found bool) {
+	if r.key == label && r.operator == IN && len(r.strValues) == 1 {
+		return r.strValues.List()[0], true
+	}
+	return "", false
+}
+
+func (r *Requirement) String() string {
+	switch r.operator {
+	case IN:
+		return fmt.Sprintf("%v in (%v)", r.key, strings.Join(r.strValues.List(), ","))
+	case NOT_IN:
+		return fmt.Sprintf("%v notin (%v)", r.key, strings.Join(r.strValues.List(), ","))
+	case DOES_NOT_EXIST:
+		return "!" + r.key
+	}
+	return r.key
+}
+
 func (sg *LabelSelector) Matches(ls Labels) bool {
 	for _, req := range sg.Requirements {
 		if !req.Matches(ls) {
@@ -178,6 +244,27 @@ func (sg *LabelSelector) Matches(ls Labels) bool {
 	return true
 }
 
+func (sg *LabelSelector) Empty() bool {
+	return len(sg.Requirements) == 0
+}
+
+func (sg *LabelSelector) RequiresExactMatch(label string) (value string, found bool) {
+	for i := range sg.Requirements {
+		if value, found := sg.Requirements[i].RequiresExactMatch(label); found {
+			return value, found
+		}
+	}
+	return "", false
+}
+
+func (sg *LabelSelector) String() string {
+	var terms []string
+	for i := range sg.Requirements {
+		terms = append(terms, sg.Requirements[i].String())
+	}
+	return strings.Join(terms, ",")
+}
+
 func try(selectorPiece, op string) (lhs, rhs string, ok bool) {
 	pieces := strings.Split(selectorPiece, op)
 	if len(pieces) == 2 {
@@ -186,6 +273,59 @@ func try(selectorPiece, op string) (lhs, rhs string, ok bool) {
 	return "", "", false
 }
 
+// splitSelector splits a selector at the commas which aren't within the
+// parentheses of a set of values.
+func splitSelector(selector string) ([]string, error) {
+	parts := []string{}
+	depth, start := 0, 0
+	for i, c := range selector {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, selector[start:i])
+				start = i + 1
+			}
+		}
+		if depth < 0 || depth > 1 {
+			return nil, fmt.Errorf("invalid selector: '%s'; unbalanced parentheses", selector)
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("invalid selector: '%s'; unbalanced parentheses", selector)
+	}
+	return append(parts, selector[start:]), nil
+}
+
+// parseSetRequirement parses "key in (a,b)" or "key notin (a,b)".
+func parseSetRequirement(part string) (*Requirement, error) {
+	open := strings.Index(part, "(")
+	if !strings.HasSuffix(part, ")") {
+		return nil, fmt.Errorf("expected a set of values in parentheses")
+	}
+	fields := strings.Fields(part[:open])
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("expected a key and an operator before the values")
+	}
+	var op Operator
+	switch fields[1] {
+	case "in":
+		op = IN
+	case "notin":
+		op = NOT_IN
+	default:
+		return nil, fmt.Errorf("unknown operator '%s'", fields[1])
+	}
+	vals := util.StringSet{}
+	for _, val := range strings.Split(part[open+1:len(part)-1], ",") {
+		vals.Insert(strings.TrimSpace(val))
+	}
+	return NewRequirement(fields[0], op, vals)
+}
+
 // SelectorFromSet returns a Selector which will match exactly the given Set. A
 // nil Set is considered equivalent to Everything().
 func SelectorFromSet(ls Set) Selector {
@@ -202,21 +342,95 @@ func SelectorFromSet(ls Set) Selector {
 	return andTerm(items)
 }
 
+// transformRequirement passes each of a set requirement's values, with its
+// key, through transform.
+func transformRequirement(req *Requirement, transform TransformFunc) (*Requirement, error) {
+	key := req.key
+	vals := util.StringSet{}
+	for _, val := range req.strValues.List() {
+		newKey, newVal, err := transform(req.key, val)
+		if err != nil {
+			return nil, err
+		}
+		key = newKey
+		vals.Insert(newVal)
+	}
+	return NewRequirement(key, req.operator, vals)
+}
+
 // ParseSelector takes a string representing a selector and returns an
-// object suitable for matching, or an error.
+// object suitable for matching, or an error.  A selector is a comma separated
+// list of terms, all of which must match:
+//
+//	key=value, key==value  the label has the value
+//	key!=value             the label doesn't have the value
+//	key in (a,b)           the label has one of the values
+//	key notin (a,b)        the label doesn't have any of the values
+//	key                    the label exists
+//	!key                   the label doesn't exist
 func ParseSelector(selector string) (Selector, error) {
-	parts := strings.Split(selector, ",")
+	return ParseAndTransformSelector(selector, nil)
+}
+
+// TransformFunc transforms the key and value of a selector term, e.g. to
+// convert a field selector from an API version's field names to internal ones.
+// Terms which only test for a key's existence are transformed with an empty value.
+type TransformFunc func(key, value string) (newKey, newValue string, err error)
+
+// ParseAndTransformSelector parses a selector like ParseSelector, passing the
+// key and value of each term through transform, if it isn't nil.
+func ParseAndTransformSelector(selector string, transform TransformFunc) (Selector, error) {
+	if transform == nil {
+		transform = func(key, value string) (string, string, error) { return key, value, nil }
+	}
+	parts, err := splitSelector(selector)
+	if err != nil {
+		return nil, err
+	}
 	sort.StringSlice(parts).Sort()
 	var items []Selector
 	for _, part := range parts {
+		part = strings.TrimSpace(part)
 		if part == "" {
 			continue
 		}
-		if lhs, rhs, ok := try(part, "!="); ok {
+		if strings.Contains(part, "(") {
+			req, err := parseSetRequirement(part)
+			if err == nil {
+				req, err = transformRequirement(req, transform)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("invalid selector: '%s'; can't understand '%s': %v", selector, part, err)
+			}
+			items = append(items, req)
+		} else if !strings.ContainsAny(part, "=!") || strings.HasPrefix(part, "!") && !strings.Contains(part, "=") {
+			op := EXISTS
+			if strings.HasPrefix(part, "!") {
+				op, part = DOES_NOT_EXIST, part[1:]
+			}
+			key, _, err := transform(part, "")
+			if err != nil {
+				return nil, fmt.Errorf("invalid selector: '%s': %v", selector, err)
+			}
+			req, err := NewRequirement(key, op, nil)
+			if err != nil {
+				return nil, fmt.Errorf("invalid selector: '%s': %v", selector, err)
+			}
+			items = append(items, req)
+		} else if lhs, rhs, ok := try(part, "!="); ok {
+			if lhs, rhs, err = transform(lhs, rhs); err != nil {
+				return nil, fmt.Errorf("invalid selector: '%s': %v", selector, err)
+			}
 			items = append(items, &notHasTerm{label: lhs, value: rhs})
 		} else if lhs, rhs, ok := try(part, "=="); ok {
+			if lhs, rhs, err = transform(lhs, rhs); err != nil {
+				return nil, fmt.Errorf("invalid selector: '%s': %v", selector, err)
+			}
 			items = append(items, &hasTerm{label: lhs, value: rhs})
 		} else if lhs, rhs, ok := try(part, "="); ok {
+			if lhs, rhs, err = transform(lhs, rhs); err != nil {
+				return nil, fmt.Errorf("invalid selector: '%s': %v", selector, err)
+			}
 			items = append(items, &hasTerm{label: lhs, value: rhs})
 		} else {
 			return nil, fmt.Errorf("invalid selector: '%s'; can't understand '%s'", selector, part)
@@ -17,6 +17,8 @@ limitations under the License.
 package labels
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
@@ -27,10 +29,19 @@ func TestSelectorParse(t *testing.T) {
 		"x=a,y=b,z=c",
 		"",
 		"x!=a,y=b",
+		"x in (a,b),y",
+		"!x,y notin (c)",
+		"a=b,x in (c)",
 	}
 	testBadStrings := []string{
 		"x=a||y=b",
 		"x==a==b",
+		"x in (a",
+		"x in ()",
+		"x in (a,)",
+		"x within (a)",
+		"x in (a)b",
+		"x y",
 	}
 	for _, test := range testGoodStrings {
 		lq, err := ParseSelector(test)
@@ -99,6 +110,16 @@ func TestSelectorMatches(t *testing.T) {
 	expectNoMatch(t, "x=y", Set{"x": "z"})
 	expectNoMatch(t, "x=y,z=w", Set{"x": "w", "z": "w"})
 	expectNoMatch(t, "x!=y,z!=w", Set{"x": "z", "z": "w"})
+	expectMatch(t, "x in (y,z)", Set{"x": "z"})
+	expectMatch(t, "x notin (y,z)", Set{"x": "w"})
+	expectMatch(t, "x notin (y,z)", Set{"a": "y"})
+	expectMatch(t, "x,!y", Set{"x": ""})
+	expectMatch(t, "x in (y, z), w=a", Set{"x": "y", "w": "a"})
+	expectNoMatch(t, "x in (y,z)", Set{"x": "w"})
+	expectNoMatch(t, "x in (y,z)", Set{"a": "y"})
+	expectNoMatch(t, "x notin (y,z)", Set{"x": "y"})
+	expectNoMatch(t, "x", Set{"y": "x"})
+	expectNoMatch(t, "!x", Set{"x": "y"})
 
 	labelset := Set{
 		"foo": "bar",
@@ -252,3 +273,33 @@ func TestLabelSelectorMatches(t *testing.T) {
 	expectMatchLabSelector(t, allMatch, s)
 	expectNoMatchLabSelector(t, singleNonMatch, s)
 }
+
+func TestParseAndTransformSelector(t *testing.T) {
+	upper := func(key, value string) (string, string, error) {
+		if key == "bad" {
+			return "", "", fmt.Errorf("bad key")
+		}
+		return strings.ToUpper(key), strings.ToUpper(value), nil
+	}
+	table := map[string]string{
+		"":                "",
+		"x=a,y!=b":        "X=A,Y!=B",
+		"x in (a,b),!y,z": "!Y,X in (A,B),Z",
+		"x notin (a)":     "X notin (A)",
+	}
+	for in, expected := range table {
+		s, err := ParseAndTransformSelector(in, upper)
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", in, err)
+			continue
+		}
+		if s.String() != expected {
+			t.Errorf("%v: expected %v, got %v", in, expected, s.String())
+		}
+	}
+	for _, in := range []string{"bad=a", "bad!=a", "bad in (a)", "!bad", "bad"} {
+		if _, err := ParseAndTransformSelector(in, upper); err == nil {
+			t.Errorf("%v: expected an error", in)
+		}
+	}
+}
@@ -17,10 +17,13 @@ limitations under the License.
 package master
 
 import (
+	"net"
 	"net/http"
 	"time"
 
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/v1beta1"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/v1beta2"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/apiserver"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/cloudprovider"
@@ -33,9 +36,8 @@ import (
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/registry/service"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
 	servicecontroller "github.com/GoogleCloudPlatform/kubernetes/pkg/service"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/tools"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
-
-	goetcd "github.com/coreos/go-etcd/etcd"
 	"github.com/golang/glog"
 )
 
@@ -43,12 +45,32 @@ import (
 type Config struct {
 	Client             *client.Client
 	Cloud              cloudprovider.Interface
-	EtcdServers        []string
+	EtcdClient         tools.EtcdClient
 	HealthCheckMinions bool
 	Minions            []string
 	MinionCacheTTL     time.Duration
 	MinionRegexp       string
 	PodInfoGetter      client.PodInfoGetter
+	NodeResources      api.NodeResources
+	// MinionSyncPeriod is how often minions matching MinionRegexp are synced
+	// from the cloud.  Zero means every 30 seconds.
+	MinionSyncPeriod time.Duration
+	// MinionTransport is used to health check minions.  Nil means
+	// http.DefaultTransport.
+	MinionTransport http.RoundTripper
+	// EndpointWorkers is the number of services whose endpoints are synced
+	// at once.  Zero means one at a time.
+	EndpointWorkers int
+	// PortalNet is the network from which services are given portal IPs.
+	// Nil means services don't get portal IPs.
+	PortalNet *net.IPNet
+	// EtcdPathPrefix is prepended to every etcd key, so that several
+	// clusters can share an etcd cluster.  Empty means keys start at
+	// /registry.
+	EtcdPathPrefix string
+	// EtcdCache serves lists and gets from memory, kept up to date by
+	// watches of etcd, rather than reading etcd every time.
+	EtcdCache bool
 }
 
 // Master contains state for a Kubernetes cluster master/api server.
@@ -65,35 +87,49 @@ type Master struct {
 
 // New returns a new instance of Master connected to the given etcdServer.
 func New(c *Config) *Master {
-	etcdClient := goetcd.NewClient(c.EtcdServers)
+	registry := etcd.NewRegistry(c.EtcdClient, c.EtcdPathPrefix)
+	if c.EtcdCache {
+		registry.EnableCache()
+	}
 	minionRegistry := makeMinionRegistry(c)
 	m := &Master{
-		podRegistry:        etcd.NewRegistry(etcdClient),
-		controllerRegistry: etcd.NewRegistry(etcdClient),
-		serviceRegistry:    etcd.NewRegistry(etcdClient),
-		endpointRegistry:   etcd.NewRegistry(etcdClient),
-		bindingRegistry:    etcd.NewRegistry(etcdClient),
+		podRegistry:        registry,
+		controllerRegistry: controller.NewEtcdRegistry(registry.EtcdHelper, registry.Cache("/registry/controllers")),
+		serviceRegistry:    registry,
+		endpointRegistry:   registry,
+		bindingRegistry:    registry,
 		minionRegistry:     minionRegistry,
 		client:             c.Client,
 	}
-	m.init(c.Cloud, c.PodInfoGetter)
+	m.init(c)
 	return m
 }
 
 func makeMinionRegistry(c *Config) minion.Registry {
 	var minionRegistry minion.Registry
 	if c.Cloud != nil && len(c.MinionRegexp) > 0 {
-		var err error
-		minionRegistry, err = minion.NewCloudRegistry(c.Cloud, c.MinionRegexp)
+		cloudRegistry, err := minion.NewCloudRegistry(c.Cloud, c.MinionRegexp, &c.NodeResources)
 		if err != nil {
 			glog.Errorf("Failed to initalize cloud minion registry reverting to static registry (%#v)", err)
+		} else {
+			period := c.MinionSyncPeriod
+			if period == 0 {
+				period = 30 * time.Second
+			}
+			minionRegistry = minion.NewRegistry(nil, c.NodeResources)
+			minion.NewCloudSyncer(cloudRegistry, minionRegistry).Run(period)
 		}
 	}
 	if minionRegistry == nil {
-		minionRegistry = minion.NewRegistry(c.Minions)
+		minionRegistry = minion.NewRegistry(c.Minions, c.NodeResources)
 	}
 	if c.HealthCheckMinions {
-		minionRegistry = minion.NewHealthyRegistry(minionRegistry, &http.Client{})
+		minionClient := &http.Client{Transport: c.MinionTransport}
+		minionRegistry = minion.NewHealthyRegistry(minionRegistry, minionClient)
+		minionRegistry = minion.NewCapacityRegistry(minionRegistry, &client.HTTPContainerInfoGetter{
+			Client: minionClient,
+			Port:   10250,
+		})
 	}
 	if c.MinionCacheTTL > 0 {
 		cachingMinionRegistry, err := minion.NewCachingRegistry(minionRegistry, c.MinionCacheTTL)
@@ -106,23 +142,27 @@ func makeMinionRegistry(c *Config) minion.Registry {
 	return minionRegistry
 }
 
-func (m *Master) init(cloud cloudprovider.Interface, podInfoGetter client.PodInfoGetter) {
-	podCache := NewPodCache(podInfoGetter, m.podRegistry)
+func (m *Master) init(c *Config) {
+	podCache := NewPodCache(c.PodInfoGetter, m.podRegistry)
 	go util.Forever(func() { podCache.UpdateAllContainers() }, time.Second*30)
 
-	endpoints := servicecontroller.NewEndpointController(m.serviceRegistry, m.client)
+	endpointWorkers := c.EndpointWorkers
+	if endpointWorkers < 1 {
+		endpointWorkers = 1
+	}
+	endpoints := servicecontroller.NewShardedEndpointController(m.serviceRegistry, m.client, util.Shard{}, endpointWorkers)
 	go util.Forever(func() { endpoints.SyncServiceEndpoints() }, time.Second*10)
 
 	m.storage = map[string]apiserver.RESTStorage{
 		"pods": pod.NewREST(&pod.RESTConfig{
-			CloudProvider: cloud,
+			CloudProvider: c.Cloud,
 			PodCache:      podCache,
-			PodInfoGetter: podInfoGetter,
+			PodInfoGetter: c.PodInfoGetter,
 			Registry:      m.podRegistry,
 			Minions:       m.client,
 		}),
 		"replicationControllers": controller.NewREST(m.controllerRegistry, m.podRegistry),
-		"services":               service.NewREST(m.serviceRegistry, cloud, m.minionRegistry),
+		"services":               service.NewREST(m.serviceRegistry, c.Cloud, m.minionRegistry, c.PortalNet),
 		"endpoints":              endpoint.NewREST(m.endpointRegistry),
 		"minions":                minion.NewREST(m.minionRegistry),
 
@@ -133,9 +173,20 @@ func (m *Master) init(cloud cloudprovider.Interface, podInfoGetter client.PodInf
 
 // API_v1beta1 returns the resources and codec for API version v1beta1.
 func (m *Master) API_v1beta1() (map[string]apiserver.RESTStorage, runtime.Codec) {
+	return m.copyStorage(), v1beta1.StrictCodec
+}
+
+// API_v1beta2 returns the resources and codec for API version v1beta2. They're
+// the same resources v1beta1 serves, converted to and from the internal types
+// by v1beta2's codec, so both versions can be served at once.
+func (m *Master) API_v1beta2() (map[string]apiserver.RESTStorage, runtime.Codec) {
+	return m.copyStorage(), v1beta2.StrictCodec
+}
+
+func (m *Master) copyStorage() map[string]apiserver.RESTStorage {
 	storage := make(map[string]apiserver.RESTStorage)
 	for k, v := range m.storage {
 		storage[k] = v
 	}
-	return storage, v1beta1.Codec
+	return storage
 }
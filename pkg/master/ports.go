@@ -17,6 +17,9 @@ limitations under the License.
 package master
 
 const (
+	// ProxyPort is the default port for the proxy status server on each host machine.
+	// May be overridden by a flag at startup.
+	ProxyPort = 10249
 	// KubeletPort is the default port for the kubelet status server on each host machine.
 	// May be overridden by a flag at startup.
 	KubeletPort = 10250
@@ -17,9 +17,13 @@ limitations under the License.
 package config
 
 import (
+	"fmt"
+	"os"
+	"path"
 	"time"
 
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/util/wait"
@@ -36,87 +40,203 @@ type Watcher interface {
 }
 
 // SourceAPI implements a configuration source for services and endpoints that
-// uses the client watch API to efficiently detect changes.
+// uses the client watch API to efficiently detect changes. While a watch can't
+// be established it falls back to listing them, at most period apart.
 type SourceAPI struct {
 	client    Watcher
 	services  chan<- ServiceUpdate
 	endpoints chan<- EndpointsUpdate
 
-	waitDuration      time.Duration
 	reconnectDuration time.Duration
+	// Delays between failed attempts to list or watch.
+	servicesBackoff  client.Backoff
+	endpointsBackoff client.Backoff
+
+	// If set, the services and endpoints seen so far are checkpointed so that
+	// a restarted proxy resumes its watches instead of listing everything.
+	servicesCheckpoint  *client.WatchCheckpoint
+	endpointsCheckpoint *client.WatchCheckpoint
+	serviceState        map[string]api.Service
+	endpointsState      map[string]api.Endpoints
 }
 
 // NewSourceAPI creates a config source that watches for changes to the services and endpoints.
-func NewSourceAPI(client Watcher, period time.Duration, services chan<- ServiceUpdate, endpoints chan<- EndpointsUpdate) *SourceAPI {
+// If checkpointDir is not empty the services and endpoints are checkpointed there.
+func NewSourceAPI(watcher Watcher, period time.Duration, checkpointDir string, services chan<- ServiceUpdate, endpoints chan<- EndpointsUpdate) *SourceAPI {
 	config := &SourceAPI{
-		client:    client,
+		client:    watcher,
 		services:  services,
 		endpoints: endpoints,
 
-		waitDuration: period,
 		// prevent hot loops if the server starts to misbehave
 		reconnectDuration: time.Second * 1,
 	}
-	serviceVersion := uint64(0)
-	go util.Forever(func() {
-		config.runServices(&serviceVersion)
-		time.Sleep(wait.Jitter(config.reconnectDuration, 0.0))
-	}, period)
-	endpointVersion := uint64(0)
-	go util.Forever(func() {
-		config.runEndpoints(&endpointVersion)
-		time.Sleep(wait.Jitter(config.reconnectDuration, 0.0))
-	}, period)
+	config.servicesBackoff = client.Backoff{Initial: config.reconnectDuration, Max: period}
+	config.endpointsBackoff = client.Backoff{Initial: config.reconnectDuration, Max: period}
+	if len(checkpointDir) > 0 {
+		config.servicesCheckpoint = client.NewWatchCheckpoint(path.Join(checkpointDir, "services"))
+		config.endpointsCheckpoint = client.NewWatchCheckpoint(path.Join(checkpointDir, "endpoints"))
+	}
+	go func() {
+		serviceVersion := config.restoreServices()
+		util.Forever(func() {
+			config.runServices(&serviceVersion)
+			time.Sleep(wait.Jitter(config.reconnectDuration, 0.0))
+		}, period)
+	}()
+	go func() {
+		endpointVersion := config.restoreEndpoints()
+		util.Forever(func() {
+			config.runEndpoints(&endpointVersion)
+			time.Sleep(wait.Jitter(config.reconnectDuration, 0.0))
+		}, period)
+	}()
 	return config
 }
 
+// restoreServices sends the services saved in the checkpoint, if any, and
+// returns the resource version to resume watching from.
+func (s *SourceAPI) restoreServices() uint64 {
+	if s.servicesCheckpoint == nil {
+		return 0
+	}
+	var services api.ServiceList
+	if err := s.servicesCheckpoint.Load(&services); err != nil {
+		if !os.IsNotExist(err) {
+			glog.Errorf("Unable to load services checkpoint: %v", err)
+		}
+		return 0
+	}
+	glog.Infof("Resuming services watch from checkpoint at %d", services.ResourceVersion)
+	s.setServiceState(services.Items)
+	s.services <- ServiceUpdate{Op: SET, Services: services.Items}
+	return services.ResourceVersion
+}
+
 // runServices loops forever looking for changes to services.
 func (s *SourceAPI) runServices(resourceVersion *uint64) {
 	if *resourceVersion == 0 {
 		services, err := s.client.ListServices(labels.Everything())
 		if err != nil {
 			glog.Errorf("Unable to load services: %v", err)
-			time.Sleep(wait.Jitter(s.waitDuration, 0.0))
+			time.Sleep(wait.Jitter(s.servicesBackoff.Next(), 0.0))
 			return
 		}
 		*resourceVersion = services.ResourceVersion
+		s.setServiceState(services.Items)
+		s.checkpointServices(*resourceVersion)
 		s.services <- ServiceUpdate{Op: SET, Services: services.Items}
 	}
 
 	watcher, err := s.client.WatchServices(labels.Everything(), labels.Everything(), *resourceVersion)
 	if err != nil {
-		glog.Errorf("Unable to watch for services changes: %v", err)
-		time.Sleep(wait.Jitter(s.waitDuration, 0.0))
+		// Poll until the watch works again, so that changes are still seen
+		// if the server can't watch from resourceVersion.
+		glog.Errorf("Unable to watch for services changes, listing them instead: %v", err)
+		*resourceVersion = 0
+		time.Sleep(wait.Jitter(s.servicesBackoff.Next(), 0.0))
 		return
 	}
 	defer watcher.Stop()
 
 	ch := watcher.ResultChan()
-	handleServicesWatch(resourceVersion, ch, s.services)
+	if err := s.handleServicesWatch(resourceVersion, ch); err != nil {
+		glog.Errorf("Unable to keep watching services from %d, listing them instead: %v", *resourceVersion, err)
+		*resourceVersion = 0
+		time.Sleep(wait.Jitter(s.servicesBackoff.Next(), 0.0))
+	}
 }
 
-// handleServicesWatch loops over an event channel and delivers config changes to an update channel.
-func handleServicesWatch(resourceVersion *uint64, ch <-chan watch.Event, updates chan<- ServiceUpdate) {
+// handleServicesWatch loops over an event channel and delivers config changes to
+// the services channel. It returns an error if the watch ended with one, e.g.
+// because *resourceVersion is too old to watch from, and nil if it just closed.
+func (s *SourceAPI) handleServicesWatch(resourceVersion *uint64, ch <-chan watch.Event) error {
 	for {
 		select {
 		case event, ok := <-ch:
 			if !ok {
 				glog.V(2).Infof("WatchServices channel closed")
-				return
+				return nil
 			}
-
-			service := event.Object.(*api.Service)
-			*resourceVersion = service.ResourceVersion + 1
+			if event.Type == watch.Error {
+				return fmt.Errorf("watch failed: %#v", event.Object)
+			}
+			service, ok := event.Object.(*api.Service)
+			if !ok {
+				glog.Errorf("Unexpected object in services watch: %#v", event.Object)
+				continue
+			}
+			s.servicesBackoff.Reset()
+			*resourceVersion = service.ResourceVersion
 
 			switch event.Type {
 			case watch.Added, watch.Modified:
-				updates <- ServiceUpdate{Op: ADD, Services: []api.Service{*service}}
+				s.updateServiceState(service, false)
+				s.services <- ServiceUpdate{Op: ADD, Services: []api.Service{*service}}
 
 			case watch.Deleted:
-				updates <- ServiceUpdate{Op: REMOVE, Services: []api.Service{*service}}
+				s.updateServiceState(service, true)
+				s.services <- ServiceUpdate{Op: REMOVE, Services: []api.Service{*service}}
 			}
+			s.checkpointServices(*resourceVersion)
+		}
+	}
+}
+
+// setServiceState replaces the services tracked for checkpointing.
+func (s *SourceAPI) setServiceState(services []api.Service) {
+	if s.servicesCheckpoint == nil {
+		return
+	}
+	s.serviceState = map[string]api.Service{}
+	for _, service := range services {
+		s.serviceState[service.ID] = service
+	}
+}
+
+// updateServiceState records a change to a service for checkpointing.
+func (s *SourceAPI) updateServiceState(service *api.Service, deleted bool) {
+	if s.servicesCheckpoint == nil {
+		return
+	}
+	if deleted {
+		delete(s.serviceState, service.ID)
+	} else {
+		s.serviceState[service.ID] = *service
+	}
+}
+
+// checkpointServices saves the tracked services as of resourceVersion.
+func (s *SourceAPI) checkpointServices(resourceVersion uint64) {
+	if s.servicesCheckpoint == nil {
+		return
+	}
+	services := &api.ServiceList{JSONBase: api.JSONBase{ResourceVersion: resourceVersion}}
+	for _, service := range s.serviceState {
+		services.Items = append(services.Items, service)
+	}
+	if err := s.servicesCheckpoint.Save(services); err != nil {
+		glog.Errorf("Unable to checkpoint services: %v", err)
+	}
+}
+
+// restoreEndpoints sends the endpoints saved in the checkpoint, if any, and
+// returns the resource version to resume watching from.
+func (s *SourceAPI) restoreEndpoints() uint64 {
+	if s.endpointsCheckpoint == nil {
+		return 0
+	}
+	var endpoints api.EndpointsList
+	if err := s.endpointsCheckpoint.Load(&endpoints); err != nil {
+		if !os.IsNotExist(err) {
+			glog.Errorf("Unable to load endpoints checkpoint: %v", err)
 		}
+		return 0
 	}
+	glog.Infof("Resuming endpoints watch from checkpoint at %d", endpoints.ResourceVersion)
+	s.setEndpointsState(endpoints.Items)
+	s.endpoints <- EndpointsUpdate{Op: SET, Endpoints: endpoints.Items}
+	return endpoints.ResourceVersion
 }
 
 // runEndpoints loops forever looking for changes to endpoints.
@@ -125,45 +245,103 @@ func (s *SourceAPI) runEndpoints(resourceVersion *uint64) {
 		endpoints, err := s.client.ListEndpoints(labels.Everything())
 		if err != nil {
 			glog.Errorf("Unable to load endpoints: %v", err)
-			time.Sleep(wait.Jitter(s.waitDuration, 0.0))
+			time.Sleep(wait.Jitter(s.endpointsBackoff.Next(), 0.0))
 			return
 		}
 		*resourceVersion = endpoints.ResourceVersion
+		s.setEndpointsState(endpoints.Items)
+		s.checkpointEndpoints(*resourceVersion)
 		s.endpoints <- EndpointsUpdate{Op: SET, Endpoints: endpoints.Items}
 	}
 
 	watcher, err := s.client.WatchEndpoints(labels.Everything(), labels.Everything(), *resourceVersion)
 	if err != nil {
-		glog.Errorf("Unable to watch for endpoints changes: %v", err)
-		time.Sleep(wait.Jitter(s.waitDuration, 0.0))
+		// Poll until the watch works again, so that changes are still seen
+		// if the server can't watch from resourceVersion.
+		glog.Errorf("Unable to watch for endpoints changes, listing them instead: %v", err)
+		*resourceVersion = 0
+		time.Sleep(wait.Jitter(s.endpointsBackoff.Next(), 0.0))
 		return
 	}
 	defer watcher.Stop()
 
 	ch := watcher.ResultChan()
-	handleEndpointsWatch(resourceVersion, ch, s.endpoints)
+	if err := s.handleEndpointsWatch(resourceVersion, ch); err != nil {
+		glog.Errorf("Unable to keep watching endpoints from %d, listing them instead: %v", *resourceVersion, err)
+		*resourceVersion = 0
+		time.Sleep(wait.Jitter(s.endpointsBackoff.Next(), 0.0))
+	}
 }
 
-// handleEndpointsWatch loops over an event channel and delivers config changes to an update channel.
-func handleEndpointsWatch(resourceVersion *uint64, ch <-chan watch.Event, updates chan<- EndpointsUpdate) {
+// handleEndpointsWatch loops over an event channel and delivers config changes to
+// the endpoints channel. It returns an error if the watch ended with one, e.g.
+// because *resourceVersion is too old to watch from, and nil if it just closed.
+func (s *SourceAPI) handleEndpointsWatch(resourceVersion *uint64, ch <-chan watch.Event) error {
 	for {
 		select {
 		case event, ok := <-ch:
 			if !ok {
 				glog.V(2).Infof("WatchEndpoints channel closed")
-				return
+				return nil
 			}
-
-			endpoints := event.Object.(*api.Endpoints)
-			*resourceVersion = endpoints.ResourceVersion + 1
+			if event.Type == watch.Error {
+				return fmt.Errorf("watch failed: %#v", event.Object)
+			}
+			endpoints, ok := event.Object.(*api.Endpoints)
+			if !ok {
+				glog.Errorf("Unexpected object in endpoints watch: %#v", event.Object)
+				continue
+			}
+			s.endpointsBackoff.Reset()
+			*resourceVersion = endpoints.ResourceVersion
 
 			switch event.Type {
 			case watch.Added, watch.Modified:
-				updates <- EndpointsUpdate{Op: ADD, Endpoints: []api.Endpoints{*endpoints}}
+				s.updateEndpointsState(endpoints, false)
+				s.endpoints <- EndpointsUpdate{Op: ADD, Endpoints: []api.Endpoints{*endpoints}}
 
 			case watch.Deleted:
-				updates <- EndpointsUpdate{Op: REMOVE, Endpoints: []api.Endpoints{*endpoints}}
+				s.updateEndpointsState(endpoints, true)
+				s.endpoints <- EndpointsUpdate{Op: REMOVE, Endpoints: []api.Endpoints{*endpoints}}
 			}
+			s.checkpointEndpoints(*resourceVersion)
 		}
 	}
 }
+
+// setEndpointsState replaces the endpoints tracked for checkpointing.
+func (s *SourceAPI) setEndpointsState(endpoints []api.Endpoints) {
+	if s.endpointsCheckpoint == nil {
+		return
+	}
+	s.endpointsState = map[string]api.Endpoints{}
+	for _, e := range endpoints {
+		s.endpointsState[e.ID] = e
+	}
+}
+
+// updateEndpointsState records a change to endpoints for checkpointing.
+func (s *SourceAPI) updateEndpointsState(endpoints *api.Endpoints, deleted bool) {
+	if s.endpointsCheckpoint == nil {
+		return
+	}
+	if deleted {
+		delete(s.endpointsState, endpoints.ID)
+	} else {
+		s.endpointsState[endpoints.ID] = *endpoints
+	}
+}
+
+// checkpointEndpoints saves the tracked endpoints as of resourceVersion.
+func (s *SourceAPI) checkpointEndpoints(resourceVersion uint64) {
+	if s.endpointsCheckpoint == nil {
+		return
+	}
+	endpoints := &api.EndpointsList{JSONBase: api.JSONBase{ResourceVersion: resourceVersion}}
+	for _, e := range s.endpointsState {
+		endpoints.Items = append(endpoints.Items, e)
+	}
+	if err := s.endpointsCheckpoint.Save(endpoints); err != nil {
+		glog.Errorf("Unable to checkpoint endpoints: %v", err)
+	}
+}
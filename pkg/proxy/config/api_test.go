@@ -18,6 +18,10 @@ package config
 
 import (
 	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
 	"reflect"
 	"testing"
 
@@ -42,7 +46,7 @@ func TestServices(t *testing.T) {
 
 	// test adding a service to the watch
 	fakeWatch.Add(&service)
-	if !reflect.DeepEqual(fakeClient.Actions, []client.FakeAction{{"watch-services", uint64(1)}}) {
+	if !reflect.DeepEqual(fakeClient.Actions, []client.FakeAction{{Action: "watch-services", Value: uint64(1)}}) {
 		t.Errorf("expected call to watch-services, got %#v", fakeClient)
 	}
 
@@ -66,7 +70,7 @@ func TestServices(t *testing.T) {
 	fakeWatch.Stop()
 
 	newFakeWatch.Add(&service)
-	if !reflect.DeepEqual(fakeClient.Actions, []client.FakeAction{{"watch-services", uint64(1)}, {"watch-services", uint64(3)}}) {
+	if !reflect.DeepEqual(fakeClient.Actions, []client.FakeAction{{Action: "watch-services", Value: uint64(1)}, {Action: "watch-services", Value: uint64(2)}}) {
 		t.Errorf("expected call to watch-endpoints, got %#v", fakeClient)
 	}
 }
@@ -104,7 +108,7 @@ func TestServicesFromZero(t *testing.T) {
 	if resourceVersion != 2 {
 		t.Errorf("unexpected resource version, got %#v", resourceVersion)
 	}
-	if !reflect.DeepEqual(fakeClient.Actions, []client.FakeAction{{"list-services", nil}, {"watch-services", uint64(2)}}) {
+	if !reflect.DeepEqual(fakeClient.Actions, []client.FakeAction{{Action: "list-services", Value: nil}, {Action: "watch-services", Value: uint64(2)}}) {
 		t.Errorf("unexpected actions, got %#v", fakeClient)
 	}
 }
@@ -120,16 +124,41 @@ func TestServicesError(t *testing.T) {
 		close(ch)
 	}()
 
-	// should have listed only
+	// should have watched, then fallen back to listing next time
 	<-ch
-	if resourceVersion != 1 {
+	if resourceVersion != 0 {
 		t.Errorf("unexpected resource version, got %#v", resourceVersion)
 	}
-	if !reflect.DeepEqual(fakeClient.Actions, []client.FakeAction{{"watch-services", uint64(1)}}) {
+	if !reflect.DeepEqual(fakeClient.Actions, []client.FakeAction{{Action: "watch-services", Value: uint64(1)}}) {
 		t.Errorf("unexpected actions, got %#v", fakeClient)
 	}
 }
 
+func TestServicesWatchErrorPolls(t *testing.T) {
+	service := api.Service{JSONBase: api.JSONBase{ID: "bar", ResourceVersion: uint64(2)}}
+	fakeWatch := watch.NewFake()
+	fakeWatch.Stop()
+	fakeClient := &client.Fake{Err: errors.New("test"), Watch: fakeWatch}
+	fakeClient.ServiceList = api.ServiceList{JSONBase: api.JSONBase{ResourceVersion: 2}, Items: []api.Service{service}}
+	services := make(chan ServiceUpdate, 1)
+	source := SourceAPI{client: fakeClient, services: services}
+	resourceVersion := uint64(1)
+
+	// The watch fails, so the next run lists the services again.
+	source.runServices(&resourceVersion)
+	fakeClient.Err = nil
+	source.runServices(&resourceVersion)
+
+	expected := ServiceUpdate{Op: SET, Services: []api.Service{service}}
+	if actual := <-services; !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected %#v, got %#v", expected, actual)
+	}
+	expectedActions := []client.FakeAction{{Action: "watch-services", Value: uint64(1)}, {Action: "list-services", Value: nil}, {Action: "watch-services", Value: uint64(2)}}
+	if !reflect.DeepEqual(fakeClient.Actions, expectedActions) {
+		t.Errorf("unexpected actions, got %#v", fakeClient.Actions)
+	}
+}
+
 func TestServicesFromZeroError(t *testing.T) {
 	fakeClient := &client.Fake{Err: errors.New("test")}
 	services := make(chan ServiceUpdate)
@@ -146,7 +175,7 @@ func TestServicesFromZeroError(t *testing.T) {
 	if resourceVersion != 0 {
 		t.Errorf("unexpected resource version, got %#v", resourceVersion)
 	}
-	if !reflect.DeepEqual(fakeClient.Actions, []client.FakeAction{{"list-services", nil}}) {
+	if !reflect.DeepEqual(fakeClient.Actions, []client.FakeAction{{Action: "list-services", Value: nil}}) {
 		t.Errorf("unexpected actions, got %#v", fakeClient)
 	}
 }
@@ -167,7 +196,7 @@ func TestEndpoints(t *testing.T) {
 
 	// test adding an endpoint to the watch
 	fakeWatch.Add(&endpoint)
-	if !reflect.DeepEqual(fakeClient.Actions, []client.FakeAction{{"watch-endpoints", uint64(1)}}) {
+	if !reflect.DeepEqual(fakeClient.Actions, []client.FakeAction{{Action: "watch-endpoints", Value: uint64(1)}}) {
 		t.Errorf("expected call to watch-endpoints, got %#v", fakeClient)
 	}
 
@@ -191,7 +220,7 @@ func TestEndpoints(t *testing.T) {
 	fakeWatch.Stop()
 
 	newFakeWatch.Add(&endpoint)
-	if !reflect.DeepEqual(fakeClient.Actions, []client.FakeAction{{"watch-endpoints", uint64(1)}, {"watch-endpoints", uint64(3)}}) {
+	if !reflect.DeepEqual(fakeClient.Actions, []client.FakeAction{{Action: "watch-endpoints", Value: uint64(1)}, {Action: "watch-endpoints", Value: uint64(2)}}) {
 		t.Errorf("expected call to watch-endpoints, got %#v", fakeClient)
 	}
 }
@@ -229,7 +258,7 @@ func TestEndpointsFromZero(t *testing.T) {
 	if resourceVersion != 2 {
 		t.Errorf("unexpected resource version, got %#v", resourceVersion)
 	}
-	if !reflect.DeepEqual(fakeClient.Actions, []client.FakeAction{{"list-endpoints", nil}, {"watch-endpoints", uint64(2)}}) {
+	if !reflect.DeepEqual(fakeClient.Actions, []client.FakeAction{{Action: "list-endpoints", Value: nil}, {Action: "watch-endpoints", Value: uint64(2)}}) {
 		t.Errorf("unexpected actions, got %#v", fakeClient)
 	}
 }
@@ -245,12 +274,12 @@ func TestEndpointsError(t *testing.T) {
 		close(ch)
 	}()
 
-	// should have listed only
+	// should have watched, then fallen back to listing next time
 	<-ch
-	if resourceVersion != 1 {
+	if resourceVersion != 0 {
 		t.Errorf("unexpected resource version, got %#v", resourceVersion)
 	}
-	if !reflect.DeepEqual(fakeClient.Actions, []client.FakeAction{{"watch-endpoints", uint64(1)}}) {
+	if !reflect.DeepEqual(fakeClient.Actions, []client.FakeAction{{Action: "watch-endpoints", Value: uint64(1)}}) {
 		t.Errorf("unexpected actions, got %#v", fakeClient)
 	}
 }
@@ -271,7 +300,104 @@ func TestEndpointsFromZeroError(t *testing.T) {
 	if resourceVersion != 0 {
 		t.Errorf("unexpected resource version, got %#v", resourceVersion)
 	}
-	if !reflect.DeepEqual(fakeClient.Actions, []client.FakeAction{{"list-endpoints", nil}}) {
+	if !reflect.DeepEqual(fakeClient.Actions, []client.FakeAction{{Action: "list-endpoints", Value: nil}}) {
 		t.Errorf("unexpected actions, got %#v", fakeClient)
 	}
 }
+
+func TestServicesCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "proxy-checkpoint")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	checkpoint := client.NewWatchCheckpoint(path.Join(dir, "services"))
+
+	// A proxy lists the services, then sees one added.
+	foo := api.Service{JSONBase: api.JSONBase{ID: "foo", ResourceVersion: uint64(2)}, Port: 80}
+	bar := api.Service{JSONBase: api.JSONBase{ID: "bar", ResourceVersion: uint64(4)}, Port: 81}
+	fakeWatch := watch.NewFake()
+	fakeClient := &client.Fake{Watch: fakeWatch}
+	fakeClient.ServiceList = api.ServiceList{
+		JSONBase: api.JSONBase{ResourceVersion: 3},
+		Items:    []api.Service{foo},
+	}
+	services := make(chan ServiceUpdate)
+	source := SourceAPI{client: fakeClient, services: services, servicesCheckpoint: checkpoint}
+	resourceVersion := uint64(0)
+	ch := make(chan struct{})
+	go func() {
+		source.runServices(&resourceVersion)
+		close(ch)
+	}()
+	<-services
+	fakeWatch.Add(&bar)
+	<-services
+	fakeWatch.Stop()
+	<-ch
+
+	// A restarted proxy sends the checkpointed services and resumes watching
+	// after the last one seen, without listing.
+	fakeWatch = watch.NewFake()
+	fakeClient = &client.Fake{Watch: fakeWatch}
+	source = SourceAPI{client: fakeClient, services: services, servicesCheckpoint: checkpoint}
+	go func() {
+		resourceVersion = source.restoreServices()
+		source.runServices(&resourceVersion)
+	}()
+	actual := <-services
+	if actual.Op != SET || len(actual.Services) != 2 {
+		t.Errorf("expected both services to be restored, got %#v", actual)
+	}
+	fakeWatch.Add(&bar)
+	<-services
+	if !reflect.DeepEqual(fakeClient.Actions, []client.FakeAction{{Action: "watch-services", Value: uint64(4)}}) {
+		t.Errorf("expected a watch from the checkpoint, got %#v", fakeClient.Actions)
+	}
+	fakeWatch.Stop()
+}
+
+func TestServicesCheckpointTooOld(t *testing.T) {
+	dir, err := ioutil.TempDir("", "proxy-checkpoint")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	checkpoint := client.NewWatchCheckpoint(path.Join(dir, "services"))
+	if err := checkpoint.Save(&api.ServiceList{JSONBase: api.JSONBase{ResourceVersion: 5}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The server can no longer watch from the checkpoint.
+	fakeWatch := watch.NewFake()
+	go func() {
+		fakeWatch.Error(&api.Status{Status: api.StatusFailure, Code: http.StatusGone})
+		fakeWatch.Stop()
+	}()
+	fakeClient := &client.Fake{Watch: fakeWatch}
+	services := make(chan ServiceUpdate, 1)
+	source := SourceAPI{client: fakeClient, services: services, servicesCheckpoint: checkpoint}
+	resourceVersion := source.restoreServices()
+	<-services
+	source.runServices(&resourceVersion)
+	if resourceVersion != 0 {
+		t.Errorf("expected the proxy to fall back to listing, got resource version %d", resourceVersion)
+	}
+}
+
+func TestServicesQuietWatchResumes(t *testing.T) {
+	// The watch ends without delivering anything, as it does when the
+	// server times it out, and with an object that isn't a service.
+	fakeWatch := watch.NewFake()
+	go func() {
+		fakeWatch.Add(&api.Endpoints{JSONBase: api.JSONBase{ID: "foo", ResourceVersion: 7}})
+		fakeWatch.Stop()
+	}()
+	fakeClient := &client.Fake{Watch: fakeWatch}
+	source := SourceAPI{client: fakeClient, services: make(chan ServiceUpdate)}
+	resourceVersion := uint64(5)
+	source.runServices(&resourceVersion)
+	if resourceVersion != 5 {
+		t.Errorf("expected the proxy to resume watching from 5, got resource version %d", resourceVersion)
+	}
+}
@@ -69,6 +69,13 @@ type EndpointsConfigHandler interface {
 	OnUpdate(endpoints []api.Endpoints)
 }
 
+// EndpointsConfigHandlerFunc is an EndpointsConfigHandler that calls a function.
+type EndpointsConfigHandlerFunc func(endpoints []api.Endpoints)
+
+func (f EndpointsConfigHandlerFunc) OnUpdate(endpoints []api.Endpoints) {
+	f(endpoints)
+}
+
 // EndpointsConfig tracks a set of endpoints configurations.
 // It accepts "set", "add" and "remove" operations of endpoints via channels, and invokes registered handlers on change.
 type EndpointsConfig struct {
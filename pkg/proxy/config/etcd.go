@@ -47,20 +47,23 @@ import (
 )
 
 // registryRoot is the key prefix for service configs in etcd.
-const registryRoot = "registry/services"
+const registryRoot = "/registry/services"
 
 // ConfigSourceEtcd communicates with a etcd via the client, and sends the change notification of services and endpoints to the specified channels.
 type ConfigSourceEtcd struct {
 	client           *etcd.Client
+	prefix           string
 	serviceChannel   chan ServiceUpdate
 	endpointsChannel chan EndpointsUpdate
 	interval         time.Duration
 }
 
 // NewConfigSourceEtcd creates a new ConfigSourceEtcd and immediately runs the created ConfigSourceEtcd in a goroutine.
-func NewConfigSourceEtcd(client *etcd.Client, serviceChannel chan ServiceUpdate, endpointsChannel chan EndpointsUpdate) ConfigSourceEtcd {
+// prefix is prepended to every key read, and must match the apiserver's etcd path prefix.
+func NewConfigSourceEtcd(client *etcd.Client, prefix string, serviceChannel chan ServiceUpdate, endpointsChannel chan EndpointsUpdate) ConfigSourceEtcd {
 	config := ConfigSourceEtcd{
 		client:           client,
+		prefix:           prefix,
 		serviceChannel:   serviceChannel,
 		endpointsChannel: endpointsChannel,
 		interval:         2 * time.Second,
@@ -118,12 +121,13 @@ func (s ConfigSourceEtcd) Run() {
 // GetServices finds the list of services and their endpoints from etcd.
 // This operation is akin to a set a known good at regular intervals.
 func (s ConfigSourceEtcd) GetServices() ([]api.Service, []api.Endpoints, error) {
-	response, err := s.client.Get(registryRoot+"/specs", true, false)
+	root := s.prefix + registryRoot
+	response, err := s.client.Get(root+"/specs", true, false)
 	if err != nil {
 		if tools.IsEtcdNotFound(err) {
-			glog.V(1).Infof("Failed to get the key %s: %v", registryRoot, err)
+			glog.V(1).Infof("Failed to get the key %s: %v", root, err)
 		} else {
-			glog.Errorf("Failed to contact etcd for key %s: %v", registryRoot, err)
+			glog.Errorf("Failed to contact etcd for key %s: %v", root, err)
 		}
 		return []api.Service{}, []api.Endpoints{}, err
 	}
@@ -155,12 +159,12 @@ func (s ConfigSourceEtcd) GetServices() ([]api.Service, []api.Endpoints, error)
 		}
 		return retServices, retEndpoints, err
 	}
-	return nil, nil, fmt.Errorf("did not get the root of the registry %s", registryRoot)
+	return nil, nil, fmt.Errorf("did not get the root of the registry %s", root)
 }
 
 // GetEndpoints finds the list of endpoints of the service from etcd.
 func (s ConfigSourceEtcd) GetEndpoints(service string) (api.Endpoints, error) {
-	key := fmt.Sprintf(registryRoot + "/endpoints/" + service)
+	key := s.prefix + registryRoot + "/endpoints/" + service
 	response, err := s.client.Get(key, true, false)
 	if err != nil {
 		glog.Errorf("Failed to get the key: %s %v", key, err)
@@ -188,7 +192,7 @@ func etcdResponseToService(response *etcd.Response) (*api.Service, error) {
 func (s ConfigSourceEtcd) WatchForChanges() {
 	glog.Info("Setting up a watch for new services")
 	watchChannel := make(chan *etcd.Response)
-	go s.client.Watch("/registry/services/", 0, true, watchChannel, nil)
+	go s.client.Watch(s.prefix+registryRoot+"/", 0, true, watchChannel, nil)
 	for {
 		watchResponse, ok := <-watchChannel
 		if !ok {
@@ -218,7 +222,7 @@ func (s ConfigSourceEtcd) ProcessChange(response *etcd.Response) {
 		return
 	}
 	if response.Action == "delete" {
-		parts := strings.Split(response.Node.Key[1:], "/")
+		parts := strings.Split(strings.TrimPrefix(response.Node.Key, s.prefix)[1:], "/")
 		if len(parts) == 4 {
 			glog.Infof("Deleting service: %s", parts[3])
 			serviceUpdate := ServiceUpdate{Op: REMOVE, Services: []api.Service{{JSONBase: api.JSONBase{ID: parts[3]}}}}
@@ -0,0 +1,191 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util/iptables"
+	"github.com/golang/glog"
+)
+
+// The chain that PREROUTING and OUTPUT jump to, holding a rule per service.
+const iptablesServicesChain iptables.Chain = "KUBE-SERVICES"
+
+// IptablesProxier proxies services by programming iptables DNAT rules that
+// send connections to a service's port straight to one of its endpoints,
+// chosen at random, so that the proxy does not copy any traffic itself.
+//
+// Session affinity is not supported; every connection may go to a different
+// endpoint.  Connections made from the proxy's own host to a loopback
+// address are only proxied if the kernel allows routing them off the host
+// (net.ipv4.conf.all.route_localnet).
+type IptablesProxier struct {
+	iptables iptables.Interface
+	address  net.IP
+
+	mu        sync.Mutex // protects the fields below
+	services  map[string]api.Service
+	endpoints map[string][]string
+	// The per-service chains in the last rules that were loaded.
+	chains map[iptables.Chain]bool
+}
+
+// NewIptablesProxier returns a new IptablesProxier that proxies services on
+// address, or on every local address if address is unspecified.  It fails if
+// iptables can't be used, in which case the userspace Proxier should be used
+// instead.
+func NewIptablesProxier(ipt iptables.Interface, address net.IP) (*IptablesProxier, error) {
+	if err := ensureNATChain(ipt, iptablesServicesChain); err != nil {
+		return nil, err
+	}
+	return &IptablesProxier{
+		iptables:  ipt,
+		address:   address,
+		services:  map[string]api.Service{},
+		endpoints: map[string][]string{},
+		chains:    map[iptables.Chain]bool{},
+	}, nil
+}
+
+// ensureNATChain creates a chain in the nat table that PREROUTING and OUTPUT
+// jump to, so that it sees connections from other hosts and from this one.
+func ensureNATChain(ipt iptables.Interface, chain iptables.Chain) error {
+	if err := ipt.EnsureChain(iptables.TableNAT, chain); err != nil {
+		return err
+	}
+	for _, from := range []iptables.Chain{iptables.ChainPrerouting, iptables.ChainOutput} {
+		if err := ipt.EnsureRule(iptables.TableNAT, from, "-j", string(chain)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OnUpdate replaces the set of services and reloads the rules.
+func (proxier *IptablesProxier) OnUpdate(services []api.Service) {
+	proxier.mu.Lock()
+	defer proxier.mu.Unlock()
+	proxier.services = map[string]api.Service{}
+	for _, service := range services {
+		if service.SessionAffinity == api.AffinityTypeClientIP {
+			glog.Warningf("Session affinity for %s is not supported by the iptables proxy", service.ID)
+		}
+		proxier.services[service.ID] = service
+	}
+	proxier.syncRules()
+}
+
+// OnEndpointsUpdate replaces the set of endpoints and reloads the rules.
+func (proxier *IptablesProxier) OnEndpointsUpdate(endpoints []api.Endpoints) {
+	proxier.mu.Lock()
+	defer proxier.mu.Unlock()
+	proxier.endpoints = map[string][]string{}
+	for _, e := range endpoints {
+		proxier.endpoints[e.ID] = filterValidEndpoints(e.Endpoints)
+	}
+	proxier.syncRules()
+}
+
+// serviceChain returns the chain holding the endpoints of a service.  Chain
+// names are limited to 28 characters, so the service name is hashed.
+func serviceChain(service string) iptables.Chain {
+	hash := sha256.Sum256([]byte(service))
+	return iptables.Chain("KUBE-SVC-" + strings.ToUpper(hex.EncodeToString(hash[:8])))
+}
+
+// syncRules loads the rules for the current services and endpoints in a
+// single iptables-restore, so that no connection sees a partial update.
+// Must be called with proxier.mu held.
+func (proxier *IptablesProxier) syncRules() {
+	var names []string
+	for name := range proxier.services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	chains := map[iptables.Chain]bool{}
+	var declarations, rules bytes.Buffer
+	fmt.Fprintf(&declarations, ":%s - [0:0]\n", iptablesServicesChain)
+	for _, name := range names {
+		service := proxier.services[name]
+		endpoints := proxier.endpoints[name]
+		if len(endpoints) == 0 {
+			continue
+		}
+		protocol := strings.ToLower(string(service.Protocol))
+		if protocol == "" {
+			protocol = "tcp"
+		}
+		chain := serviceChain(name)
+		chains[chain] = true
+		fmt.Fprintf(&declarations, ":%s - [0:0]\n", chain)
+
+		match := fmt.Sprintf("-p %s -m %s --dport %d", protocol, protocol, service.Port)
+		if proxier.address == nil || proxier.address.IsUnspecified() {
+			fmt.Fprintf(&rules, "-A %s %s -m addrtype --dst-type LOCAL -m comment --comment %q -j %s\n", iptablesServicesChain, match, name, chain)
+		} else {
+			fmt.Fprintf(&rules, "-A %s %s -d %s -m comment --comment %q -j %s\n", iptablesServicesChain, match, proxier.address, name, chain)
+		}
+		// Portal and public IPs may be routed to this host without being
+		// local to it, so they get their own rules.
+		destinations := service.PublicIPs
+		if len(service.PortalIP) > 0 {
+			destinations = append([]string{service.PortalIP}, destinations...)
+		}
+		for _, ip := range destinations {
+			fmt.Fprintf(&rules, "-A %s %s -d %s -m comment --comment %q -j %s\n", iptablesServicesChain, match, ip, name, chain)
+		}
+
+		// Pick endpoint i with probability 1/(n-i), which gives each
+		// endpoint an equal share overall.
+		for i, endpoint := range endpoints {
+			fmt.Fprintf(&rules, "-A %s", chain)
+			if left := len(endpoints) - i; left > 1 {
+				fmt.Fprintf(&rules, " -m statistic --mode random --probability %s", strconv.FormatFloat(1/float64(left), 'f', 5, 64))
+			}
+			fmt.Fprintf(&rules, " -p %s -j DNAT --to-destination %s\n", protocol, endpoint)
+		}
+	}
+	// Chains that were loaded before are now empty; delete them.
+	for chain := range proxier.chains {
+		if !chains[chain] {
+			fmt.Fprintf(&declarations, ":%s - [0:0]\n", chain)
+			fmt.Fprintf(&rules, "-X %s\n", chain)
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("*nat\n")
+	buf.Write(declarations.Bytes())
+	buf.Write(rules.Bytes())
+	buf.WriteString("COMMIT\n")
+	if err := proxier.iptables.Restore(buf.Bytes()); err != nil {
+		glog.Errorf("Failed to load service rules: %v", err)
+		return
+	}
+	proxier.chains = chains
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util/iptables"
+)
+
+type fakeIptables struct {
+	chains   []string
+	rules    []string
+	restored []string
+	err      error
+}
+
+func (f *fakeIptables) EnsureChain(table iptables.Table, chain iptables.Chain) error {
+	f.chains = append(f.chains, string(table)+" "+string(chain))
+	return f.err
+}
+
+func (f *fakeIptables) EnsureRule(table iptables.Table, chain iptables.Chain, args ...string) error {
+	f.rules = append(f.rules, string(table)+" "+string(chain)+" "+strings.Join(args, " "))
+	return f.err
+}
+
+func (f *fakeIptables) Restore(rules []byte) error {
+	f.restored = append(f.restored, string(rules))
+	return f.err
+}
+
+func (f *fakeIptables) lastRestore() string {
+	if len(f.restored) == 0 {
+		return ""
+	}
+	return f.restored[len(f.restored)-1]
+}
+
+func TestIptablesProxierSetsUpChains(t *testing.T) {
+	fake := &fakeIptables{}
+	if _, err := NewIptablesProxier(fake, net.IPv4zero); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(fake.chains) != 1 || fake.chains[0] != "nat KUBE-SERVICES" {
+		t.Errorf("Unexpected chains: %v", fake.chains)
+	}
+	if len(fake.rules) != 2 || fake.rules[0] != "nat PREROUTING -j KUBE-SERVICES" || fake.rules[1] != "nat OUTPUT -j KUBE-SERVICES" {
+		t.Errorf("Unexpected rules: %v", fake.rules)
+	}
+}
+
+func TestIptablesProxierUnavailable(t *testing.T) {
+	fake := &fakeIptables{err: errors.New("iptables: command not found")}
+	if _, err := NewIptablesProxier(fake, net.IPv4zero); err == nil {
+		t.Errorf("Expected an error")
+	}
+}
+
+func TestIptablesProxierRules(t *testing.T) {
+	fake := &fakeIptables{}
+	proxier, err := NewIptablesProxier(fake, net.IPv4zero)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	proxier.OnUpdate([]api.Service{
+		{JSONBase: api.JSONBase{ID: "echo"}, Port: 80, Protocol: "TCP"},
+		{JSONBase: api.JSONBase{ID: "dns"}, Port: 53, Protocol: "UDP"},
+	})
+	proxier.OnEndpointsUpdate([]api.Endpoints{
+		{JSONBase: api.JSONBase{ID: "echo"}, Endpoints: []string{"10.0.0.1:8080", "10.0.0.2:8080", "10.0.0.3:8080"}},
+		{JSONBase: api.JSONBase{ID: "dns"}, Endpoints: []string{"10.0.0.4:53"}},
+	})
+	echo, dns := serviceChain("echo"), serviceChain("dns")
+	expected := "*nat\n" +
+		":KUBE-SERVICES - [0:0]\n" +
+		":" + string(dns) + " - [0:0]\n" +
+		":" + string(echo) + " - [0:0]\n" +
+		"-A KUBE-SERVICES -p udp -m udp --dport 53 -m addrtype --dst-type LOCAL -m comment --comment \"dns\" -j " + string(dns) + "\n" +
+		"-A " + string(dns) + " -p udp -j DNAT --to-destination 10.0.0.4:53\n" +
+		"-A KUBE-SERVICES -p tcp -m tcp --dport 80 -m addrtype --dst-type LOCAL -m comment --comment \"echo\" -j " + string(echo) + "\n" +
+		"-A " + string(echo) + " -m statistic --mode random --probability 0.33333 -p tcp -j DNAT --to-destination 10.0.0.1:8080\n" +
+		"-A " + string(echo) + " -m statistic --mode random --probability 0.50000 -p tcp -j DNAT --to-destination 10.0.0.2:8080\n" +
+		"-A " + string(echo) + " -p tcp -j DNAT --to-destination 10.0.0.3:8080\n" +
+		"COMMIT\n"
+	if rules := fake.lastRestore(); rules != expected {
+		t.Errorf("Expected rules:\n%s\ngot:\n%s", expected, rules)
+	}
+}
+
+func TestIptablesProxierBindAddress(t *testing.T) {
+	fake := &fakeIptables{}
+	proxier, err := NewIptablesProxier(fake, net.ParseIP("10.1.2.3"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	proxier.OnEndpointsUpdate([]api.Endpoints{
+		{JSONBase: api.JSONBase{ID: "echo"}, Endpoints: []string{"10.0.0.1:8080"}},
+	})
+	proxier.OnUpdate([]api.Service{{JSONBase: api.JSONBase{ID: "echo"}, Port: 80}})
+	if rules := fake.lastRestore(); !strings.Contains(rules, "-p tcp -m tcp --dport 80 -d 10.1.2.3 ") {
+		t.Errorf("Expected a rule for 10.1.2.3, got:\n%s", rules)
+	}
+}
+
+func TestIptablesProxierPortalAndPublicIPs(t *testing.T) {
+	fake := &fakeIptables{}
+	proxier, err := NewIptablesProxier(fake, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	proxier.OnEndpointsUpdate([]api.Endpoints{
+		{JSONBase: api.JSONBase{ID: "echo"}, Endpoints: []string{"10.0.0.1:8080"}},
+	})
+	proxier.OnUpdate([]api.Service{{JSONBase: api.JSONBase{ID: "echo"}, Port: 80, PublicIPs: []string{"1.2.3.4"}, PortalIP: "10.0.0.1"}})
+	rules := fake.lastRestore()
+	for _, match := range []string{"--dport 80 -m addrtype --dst-type LOCAL ", "--dport 80 -d 10.0.0.1 ", "--dport 80 -d 1.2.3.4 "} {
+		if !strings.Contains(rules, match) {
+			t.Errorf("Expected a rule matching %q, got:\n%s", match, rules)
+		}
+	}
+}
+
+func TestIptablesProxierDeletesStaleChains(t *testing.T) {
+	fake := &fakeIptables{}
+	proxier, err := NewIptablesProxier(fake, net.IPv4zero)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	proxier.OnEndpointsUpdate([]api.Endpoints{
+		{JSONBase: api.JSONBase{ID: "echo"}, Endpoints: []string{"10.0.0.1:8080"}},
+	})
+	proxier.OnUpdate([]api.Service{{JSONBase: api.JSONBase{ID: "echo"}, Port: 80}})
+	echo := string(serviceChain("echo"))
+	if rules := fake.lastRestore(); !strings.Contains(rules, "-j DNAT --to-destination 10.0.0.1:8080") {
+		t.Errorf("Expected a DNAT rule, got:\n%s", rules)
+	}
+
+	proxier.OnUpdate([]api.Service{})
+	expected := "*nat\n" +
+		":KUBE-SERVICES - [0:0]\n" +
+		":" + echo + " - [0:0]\n" +
+		"-X " + echo + "\n" +
+		"COMMIT\n"
+	if rules := fake.lastRestore(); rules != expected {
+		t.Errorf("Expected rules:\n%s\ngot:\n%s", expected, rules)
+	}
+
+	proxier.OnUpdate([]api.Service{})
+	if rules := fake.lastRestore(); strings.Contains(rules, echo) {
+		t.Errorf("Expected %s to be forgotten, got:\n%s", echo, rules)
+	}
+}
@@ -18,6 +18,9 @@ package proxy
 
 import (
 	"net"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 )
 
 // LoadBalancer is an interface for distributing incoming requests to service endpoints.
@@ -25,4 +28,10 @@ type LoadBalancer interface {
 	// NextEndpoint returns the endpoint to handle a request for the given
 	// service and source address.
 	NextEndpoint(service string, srcAddr net.Addr) (string, error)
+	// NewService sets the session affinity used for the given service.
+	// Clients stick to an endpoint until they have been idle for ttl.
+	NewService(service string, affinityType api.AffinityType, ttl time.Duration) error
+	// EndpointFailed reports that connecting to an endpoint of the given
+	// service failed, so that the endpoint is avoided for a while.
+	EndpointFailed(service, endpoint string)
 }
@@ -0,0 +1,79 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util/iptables"
+	"github.com/golang/glog"
+)
+
+// The chain holding the userspace proxy's rules for portal IPs.
+const iptablesPortalsChain iptables.Chain = "KUBE-PORTALS"
+
+// InterceptPortals makes the proxier intercept connections to the portal IPs
+// of services, by programming iptables to redirect them to its own socket for
+// each service.  It must be called before the first OnUpdate.
+func (proxier *Proxier) InterceptPortals(ipt iptables.Interface) error {
+	if err := ensureNATChain(ipt, iptablesPortalsChain); err != nil {
+		return err
+	}
+	proxier.iptables = ipt
+	return nil
+}
+
+// syncPortals replaces the portal rules with ones for services.
+func (proxier *Proxier) syncPortals(services []api.Service) {
+	if proxier.iptables == nil {
+		return
+	}
+	ip := net.ParseIP(proxier.address)
+	anyAddress := proxier.address == "" || ip != nil && ip.IsUnspecified()
+
+	var buf bytes.Buffer
+	buf.WriteString("*nat\n")
+	fmt.Fprintf(&buf, ":%s - [0:0]\n", iptablesPortalsChain)
+	for _, service := range services {
+		if len(service.PortalIP) == 0 {
+			continue
+		}
+		protocol := strings.ToLower(string(service.Protocol))
+		if protocol == "" {
+			protocol = "tcp"
+		}
+		fmt.Fprintf(&buf, "-A %s -p %s -m %s -d %s --dport %d -m comment --comment %q",
+			iptablesPortalsChain, protocol, protocol, service.PortalIP, service.Port, service.ID)
+		// REDIRECT sends connections to the address they arrived on, or to
+		// loopback for local ones, which only works if the proxier listens on
+		// every address.
+		if anyAddress {
+			fmt.Fprintf(&buf, " -j REDIRECT --to-ports %d\n", service.Port)
+		} else {
+			fmt.Fprintf(&buf, " -j DNAT --to-destination %s\n", net.JoinHostPort(proxier.address, strconv.Itoa(service.Port)))
+		}
+	}
+	buf.WriteString("COMMIT\n")
+	if err := proxier.iptables.Restore(buf.Bytes()); err != nil {
+		glog.Errorf("Failed to load portal rules: %v", err)
+	}
+}
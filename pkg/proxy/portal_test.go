@@ -0,0 +1,71 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+)
+
+func TestProxierInterceptPortals(t *testing.T) {
+	tests := []struct {
+		address  string
+		expected string
+	}{
+		{
+			address: "0.0.0.0",
+			expected: "*nat\n" +
+				":KUBE-PORTALS - [0:0]\n" +
+				"-A KUBE-PORTALS -p tcp -m tcp -d 10.0.0.1 --dport 80 -m comment --comment \"echo\" -j REDIRECT --to-ports 80\n" +
+				"-A KUBE-PORTALS -p udp -m udp -d 10.0.0.2 --dport 53 -m comment --comment \"dns\" -j REDIRECT --to-ports 53\n" +
+				"COMMIT\n",
+		},
+		{
+			address: "10.1.2.3",
+			expected: "*nat\n" +
+				":KUBE-PORTALS - [0:0]\n" +
+				"-A KUBE-PORTALS -p tcp -m tcp -d 10.0.0.1 --dport 80 -m comment --comment \"echo\" -j DNAT --to-destination 10.1.2.3:80\n" +
+				"-A KUBE-PORTALS -p udp -m udp -d 10.0.0.2 --dport 53 -m comment --comment \"dns\" -j DNAT --to-destination 10.1.2.3:53\n" +
+				"COMMIT\n",
+		},
+	}
+	for _, test := range tests {
+		fake := &fakeIptables{}
+		p := NewProxier(NewLoadBalancerRR(), test.address)
+		if err := p.InterceptPortals(fake); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(fake.chains) != 1 || fake.chains[0] != "nat KUBE-PORTALS" {
+			t.Errorf("Unexpected chains: %v", fake.chains)
+		}
+		p.syncPortals([]api.Service{
+			{JSONBase: api.JSONBase{ID: "echo"}, Port: 80, Protocol: api.ProtocolTCP, PortalIP: "10.0.0.1"},
+			{JSONBase: api.JSONBase{ID: "dns"}, Port: 53, Protocol: api.ProtocolUDP, PortalIP: "10.0.0.2"},
+			{JSONBase: api.JSONBase{ID: "noportal"}, Port: 8080, Protocol: api.ProtocolTCP},
+		})
+		if rules := fake.lastRestore(); rules != test.expected {
+			t.Errorf("Expected rules:\n%s\ngot:\n%s", test.expected, rules)
+		}
+	}
+}
+
+func TestProxierWithoutPortals(t *testing.T) {
+	p := NewProxier(NewLoadBalancerRR(), "127.0.0.1")
+	// Must not panic without an iptables.Interface.
+	p.syncPortals([]api.Service{{JSONBase: api.JSONBase{ID: "echo"}, Port: 80, PortalIP: "10.0.0.1"}})
+}
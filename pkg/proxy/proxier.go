@@ -20,23 +20,28 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"reflect"
 	"strconv"
-	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util/iptables"
 	"github.com/golang/glog"
 )
 
 type serviceInfo struct {
-	port     int
-	protocol string
-	socket   proxySocket
-	timeout  time.Duration
-	mu       sync.Mutex // protects active
-	active   bool
+	port      int
+	protocol  api.Protocol
+	socket    proxySocket
+	publicIPs []string
+	// publicSockets listen on those of the public IPs that belong to this host.
+	publicSockets []proxySocket
+	timeout       time.Duration
+	mu            sync.Mutex // protects active
+	active        bool
 }
 
 func (si *serviceInfo) isActive() bool {
@@ -91,35 +96,59 @@ func (tcp *tcpProxySocket) ProxyLoop(service string, proxier *Proxier) {
 			continue
 		}
 		glog.Infof("Accepted TCP connection from %v to %v", inConn.RemoteAddr(), inConn.LocalAddr())
-		endpoint, err := proxier.loadBalancer.NextEndpoint(service, inConn.RemoteAddr())
+		// Connect asynchronously, so that endpoints which are slow to dial
+		// don't hold up accepting other connections.
+		go func(inConn net.Conn) {
+			defer util.HandleCrash()
+			outConn, err := proxier.tryConnect(service, inConn.RemoteAddr(), "tcp")
+			if err != nil {
+				inConn.Close()
+				return
+			}
+			stats := proxier.stats.forService(service)
+			atomic.AddInt64(&stats.ActiveConnections, 1)
+			defer atomic.AddInt64(&stats.ActiveConnections, -1)
+			proxyTCP(inConn.(*net.TCPConn), outConn.(*net.TCPConn), stats)
+		}(inConn)
+	}
+}
+
+// How many endpoints we try to dial before giving up on a connection.
+const endpointDialAttempts = 3
+
+// tryConnect dials an endpoint of service for a client at srcAddr.  When a
+// dial fails, the endpoint is reported to the load balancer so that it is
+// skipped for a while, and another endpoint is tried.
+func (proxier *Proxier) tryConnect(service string, srcAddr net.Addr, protocol string) (net.Conn, error) {
+	var lastErr error
+	for i := 0; i < endpointDialAttempts; i++ {
+		endpoint, err := proxier.loadBalancer.NextEndpoint(service, srcAddr)
 		if err != nil {
 			glog.Errorf("Couldn't find an endpoint for %s %v", service, err)
-			inConn.Close()
-			continue
+			return nil, err
 		}
 		glog.Infof("Mapped service %s to endpoint %s", service, endpoint)
-		// TODO: This could spin up a new goroutine to make the outbound connection,
-		// and keep accepting inbound traffic.
-		outConn, err := net.DialTimeout("tcp", endpoint, endpointDialTimeout)
-		if err != nil {
-			// TODO: Try another endpoint?
-			glog.Errorf("Dial failed: %v", err)
-			inConn.Close()
-			continue
+		outConn, err := net.DialTimeout(protocol, endpoint, endpointDialTimeout)
+		if err == nil {
+			return outConn, nil
 		}
-		// Spin up an async copy loop.
-		go proxyTCP(inConn.(*net.TCPConn), outConn.(*net.TCPConn))
+		glog.Errorf("Dial failed: %v", err)
+		atomic.AddInt64(&proxier.stats.forService(service).DialFailures, 1)
+		proxier.loadBalancer.EndpointFailed(service, endpoint)
+		lastErr = err
 	}
+	return nil, lastErr
 }
 
-// proxyTCP proxies data bi-directionally between in and out.
-func proxyTCP(in, out *net.TCPConn) {
+// proxyTCP proxies data bi-directionally between in and out, counting the
+// bytes copied in stats.
+func proxyTCP(in, out *net.TCPConn, stats *ServiceStats) {
 	var wg sync.WaitGroup
 	wg.Add(2)
 	glog.Infof("Creating proxy between %v <-> %v <-> %v <-> %v",
 		in.RemoteAddr(), in.LocalAddr(), out.LocalAddr(), out.RemoteAddr())
-	go copyBytes(in, out, &wg)
-	go copyBytes(out, in, &wg)
+	go copyBytes(in, out, &wg, stats)
+	go copyBytes(out, in, &wg, stats)
 	wg.Wait()
 	in.Close()
 	out.Close()
@@ -130,6 +159,9 @@ func proxyTCP(in, out *net.TCPConn) {
 // TODO: We could lame-duck this ourselves, if it becomes important.
 type udpProxySocket struct {
 	*net.UDPConn
+	// The clients that have sent packets recently, each with its own
+	// connection to an endpoint.
+	activeClients *clientCache
 }
 
 func (udp *udpProxySocket) Addr() net.Addr {
@@ -152,7 +184,8 @@ func (udp *udpProxySocket) ProxyLoop(service string, proxier *Proxier) {
 		glog.Errorf("Failed to find service: %s", service)
 		return
 	}
-	activeClients := newClientCache()
+	activeClients := udp.activeClients
+	stats := proxier.stats.forService(service)
 	var buffer [4096]byte // 4KiB should be enough for most whole-packets
 	for {
 		if !info.isActive() {
@@ -179,7 +212,8 @@ func (udp *udpProxySocket) ProxyLoop(service string, proxier *Proxier) {
 		}
 		// TODO: It would be nice to let the goroutine handle this write, but we don't
 		// really want to copy the buffer.  We could do a pool of buffers or something.
-		_, err = svrConn.Write(buffer[0:n])
+		n, err = svrConn.Write(buffer[0:n])
+		atomic.AddInt64(&stats.BytesProxied, int64(n))
 		if err != nil {
 			if !logTimeout(err) {
 				glog.Errorf("Write failed: %v", err)
@@ -187,8 +221,7 @@ func (udp *udpProxySocket) ProxyLoop(service string, proxier *Proxier) {
 			}
 			continue
 		}
-		svrConn.SetDeadline(time.Now().Add(info.timeout))
-		if err != nil {
+		if err := svrConn.SetDeadline(time.Now().Add(info.timeout)); err != nil {
 			glog.Errorf("SetDeadline failed: %v", err)
 			continue
 		}
@@ -204,29 +237,25 @@ func (udp *udpProxySocket) getBackendConn(activeClients *clientCache, cliAddr ne
 		// TODO: This could spin up a new goroutine to make the outbound connection,
 		// and keep accepting inbound traffic.
 		glog.Infof("New UDP connection from %s", cliAddr)
-		endpoint, err := proxier.loadBalancer.NextEndpoint(service, cliAddr)
-		if err != nil {
-			glog.Errorf("Couldn't find an endpoint for %s %v", service, err)
-			return nil, err
-		}
-		glog.Infof("Mapped service %s to endpoint %s", service, endpoint)
-		svrConn, err = net.DialTimeout("udp", endpoint, endpointDialTimeout)
+		var err error
+		svrConn, err = proxier.tryConnect(service, cliAddr, "udp")
 		if err != nil {
-			// TODO: Try another endpoint?
-			glog.Errorf("Dial failed: %v", err)
 			return nil, err
 		}
 		activeClients.clients[cliAddr.String()] = svrConn
+		stats := proxier.stats.forService(service)
 		go func(cliAddr net.Addr, svrConn net.Conn, activeClients *clientCache, timeout time.Duration) {
 			defer util.HandleCrash()
-			udp.proxyClient(cliAddr, svrConn, activeClients, timeout)
+			atomic.AddInt64(&stats.ActiveConnections, 1)
+			defer atomic.AddInt64(&stats.ActiveConnections, -1)
+			udp.proxyClient(cliAddr, svrConn, activeClients, timeout, stats)
 		}(cliAddr, svrConn, activeClients, timeout)
 	}
 	return svrConn, nil
 }
 
 // This function is expected to be called as a goroutine.
-func (udp *udpProxySocket) proxyClient(cliAddr net.Addr, svrConn net.Conn, activeClients *clientCache, timeout time.Duration) {
+func (udp *udpProxySocket) proxyClient(cliAddr net.Addr, svrConn net.Conn, activeClients *clientCache, timeout time.Duration, stats *ServiceStats) {
 	defer svrConn.Close()
 	var buffer [4096]byte
 	for {
@@ -237,12 +266,12 @@ func (udp *udpProxySocket) proxyClient(cliAddr net.Addr, svrConn net.Conn, activ
 			}
 			break
 		}
-		svrConn.SetDeadline(time.Now().Add(timeout))
-		if err != nil {
+		if err := svrConn.SetDeadline(time.Now().Add(timeout)); err != nil {
 			glog.Errorf("SetDeadline failed: %v", err)
 			break
 		}
 		n, err = udp.WriteTo(buffer[0:n], cliAddr)
+		atomic.AddInt64(&stats.BytesProxied, int64(n))
 		if err != nil {
 			if !logTimeout(err) {
 				glog.Errorf("WriteTo failed: %v", err)
@@ -265,15 +294,15 @@ func logTimeout(err error) bool {
 	return false
 }
 
-func newProxySocket(protocol string, host string, port int) (proxySocket, error) {
-	switch strings.ToUpper(protocol) {
-	case "TCP":
+func newProxySocket(protocol api.Protocol, host string, port int) (proxySocket, error) {
+	switch protocol {
+	case api.ProtocolTCP:
 		listener, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
 		if err != nil {
 			return nil, err
 		}
 		return &tcpProxySocket{listener}, nil
-	case "UDP":
+	case api.ProtocolUDP:
 		addr, err := net.ResolveUDPAddr("udp", net.JoinHostPort(host, strconv.Itoa(port)))
 		if err != nil {
 			return nil, err
@@ -282,7 +311,7 @@ func newProxySocket(protocol string, host string, port int) (proxySocket, error)
 		if err != nil {
 			return nil, err
 		}
-		return &udpProxySocket{conn}, nil
+		return &udpProxySocket{conn, newClientCache()}, nil
 	}
 	return nil, fmt.Errorf("Unknown protocol %q", protocol)
 }
@@ -294,6 +323,9 @@ type Proxier struct {
 	mu           sync.Mutex // protects serviceMap
 	serviceMap   map[string]*serviceInfo
 	address      string
+	// iptables is nil unless the proxier intercepts portal IPs.
+	iptables iptables.Interface
+	stats    *ConnectionStats
 }
 
 // NewProxier returns a new Proxier given a LoadBalancer and an
@@ -303,14 +335,20 @@ func NewProxier(loadBalancer LoadBalancer, address string) *Proxier {
 		loadBalancer: loadBalancer,
 		serviceMap:   make(map[string]*serviceInfo),
 		address:      address,
+		stats:        NewConnectionStats(),
 	}
 }
 
-func copyBytes(in, out *net.TCPConn, wg *sync.WaitGroup) {
+// Stats returns the per-service connection counters of the proxier.
+func (proxier *Proxier) Stats() *ConnectionStats {
+	return proxier.stats
+}
+
+func copyBytes(in, out *net.TCPConn, wg *sync.WaitGroup, stats *ServiceStats) {
 	defer wg.Done()
 	glog.Infof("Copying from %v <-> %v <-> %v <-> %v",
 		in.RemoteAddr(), in.LocalAddr(), out.LocalAddr(), out.RemoteAddr())
-	if _, err := io.Copy(in, out); err != nil {
+	if _, err := io.Copy(countingWriter{in, stats}, out); err != nil {
 		glog.Errorf("I/O error: %v", err)
 	}
 	in.CloseRead()
@@ -332,6 +370,11 @@ func (proxier *Proxier) stopProxyInternal(service string, info *serviceInfo) err
 		return nil
 	}
 	glog.Infof("Removing service: %s", service)
+	for _, sock := range info.publicSockets {
+		if err := sock.Close(); err != nil {
+			glog.Errorf("Failed to close public socket for %s: %v", service, err)
+		}
+	}
 	return info.socket.Close()
 }
 
@@ -354,7 +397,7 @@ var unusedPortLock sync.Mutex
 // addServiceOnUnusedPort starts listening for a new service, returning the
 // port it's using.  For testing on a system with unknown ports used.  The timeout only applies to UDP
 // connections, for now.
-func (proxier *Proxier) addServiceOnUnusedPort(service, protocol string, timeout time.Duration) (string, error) {
+func (proxier *Proxier) addServiceOnUnusedPort(service string, protocol api.Protocol, timeout time.Duration) (string, error) {
 	unusedPortLock.Lock()
 	defer unusedPortLock.Unlock()
 	sock, err := newProxySocket(protocol, proxier.address, 0)
@@ -391,6 +434,54 @@ func (proxier *Proxier) startAccepting(service string, sock proxySocket) {
 // How long we leave idle UDP connections open.
 const udpIdleTimeout = 1 * time.Minute
 
+// How long a client with session affinity may be idle before it can be
+// sent to a different endpoint.
+const sessionAffinityTTL = 3 * time.Hour
+
+// interfaceAddrs lists the addresses of this host.  Replaced in tests.
+var interfaceAddrs = net.InterfaceAddrs
+
+// openPublicSockets opens sockets for a service on those of its public IPs
+// that belong to this host.  If the proxier listens on every address, its
+// socket for the service already covers them.
+func (proxier *Proxier) openPublicSockets(service api.Service) []proxySocket {
+	if len(service.PublicIPs) == 0 {
+		return nil
+	}
+	if ip := net.ParseIP(proxier.address); proxier.address == "" || ip != nil && ip.IsUnspecified() {
+		return nil
+	}
+	addrs, err := interfaceAddrs()
+	if err != nil {
+		glog.Errorf("Failed to list local addresses for %s: %v", service.ID, err)
+		return nil
+	}
+	var sockets []proxySocket
+	for _, publicIP := range service.PublicIPs {
+		ip := net.ParseIP(publicIP)
+		if ip == nil || !hasAddr(addrs, ip) || publicIP == proxier.address {
+			continue
+		}
+		sock, err := newProxySocket(service.Protocol, publicIP, service.Port)
+		if err != nil {
+			glog.Errorf("Failed to get a socket for %s on %s: %v", service.ID, publicIP, err)
+			continue
+		}
+		sockets = append(sockets, sock)
+	}
+	return sockets
+}
+
+// hasAddr returns whether ip is one of addrs.
+func hasAddr(addrs []net.Addr, ip net.IP) bool {
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
 // OnUpdate manages the active set of service proxies.
 // Active service proxies are reinitialized if found in the update set or
 // shutdown if missing from the update set.
@@ -399,12 +490,15 @@ func (proxier *Proxier) OnUpdate(services []api.Service) {
 	activeServices := util.StringSet{}
 	for _, service := range services {
 		activeServices.Insert(service.ID)
+		if err := proxier.loadBalancer.NewService(service.ID, service.SessionAffinity, sessionAffinityTTL); err != nil {
+			glog.Errorf("Failed to set session affinity for %s: %v", service.ID, err)
+		}
 		info, exists := proxier.getServiceInfo(service.ID)
 		// TODO: check health of the socket?  What if ProxyLoop exited?
-		if exists && info.isActive() && info.port == service.Port {
+		if exists && info.isActive() && info.port == service.Port && info.protocol == service.Protocol && reflect.DeepEqual(info.publicIPs, service.PublicIPs) {
 			continue
 		}
-		if exists && info.port != service.Port {
+		if exists {
 			err := proxier.stopProxyInternal(service.ID, info)
 			if err != nil {
 				glog.Errorf("error stopping %s: %v", service.ID, err)
@@ -416,15 +510,22 @@ func (proxier *Proxier) OnUpdate(services []api.Service) {
 			glog.Errorf("Failed to get a socket for %s: %+v", service.ID, err)
 			continue
 		}
+		publicSockets := proxier.openPublicSockets(service)
 		proxier.setServiceInfo(service.ID, &serviceInfo{
-			port:     service.Port,
-			protocol: service.Protocol,
-			active:   true,
-			socket:   sock,
-			timeout:  udpIdleTimeout,
+			port:          service.Port,
+			protocol:      service.Protocol,
+			active:        true,
+			socket:        sock,
+			publicIPs:     service.PublicIPs,
+			publicSockets: publicSockets,
+			timeout:       udpIdleTimeout,
 		})
 		proxier.startAccepting(service.ID, sock)
+		for _, publicSocket := range publicSockets {
+			proxier.startAccepting(service.ID, publicSocket)
+		}
 	}
+	proxier.syncPortals(services)
 	proxier.mu.Lock()
 	defer proxier.mu.Unlock()
 	for name, info := range proxier.serviceMap {
@@ -153,6 +153,111 @@ func TestTCPProxy(t *testing.T) {
 	testEchoTCP(t, "127.0.0.1", proxyPort)
 }
 
+func TestTCPProxyRetriesFailedEndpoint(t *testing.T) {
+	// Find a port nothing listens on.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	deadEndpoint := l.Addr().String()
+	l.Close()
+
+	lb := NewLoadBalancerRR()
+	lb.OnUpdate([]api.Endpoints{
+		{
+			JSONBase:  api.JSONBase{ID: "echo"},
+			Endpoints: []string{deadEndpoint, net.JoinHostPort("127.0.0.1", tcpServerPort)},
+		},
+	})
+
+	p := NewProxier(lb, "127.0.0.1")
+
+	proxyPort, err := p.addServiceOnUnusedPort("echo", "TCP", 0)
+	if err != nil {
+		t.Fatalf("error adding new service: %#v", err)
+	}
+	testEchoTCP(t, "127.0.0.1", proxyPort)
+	testEchoTCP(t, "127.0.0.1", proxyPort)
+	lb.lock.Lock()
+	defer lb.lock.Unlock()
+	if _, failed := lb.failedUntil[deadEndpoint]; !failed {
+		t.Errorf("expected %s to be skipped after failing", deadEndpoint)
+	}
+	if stats := p.Stats().Get()["echo"]; stats.DialFailures != 1 {
+		t.Errorf("expected 1 dial failure, got %#v", stats)
+	}
+}
+
+// waitForStats polls the proxier's counters for service until check passes.
+func waitForStats(p *Proxier, service string, check func(ServiceStats) bool) (ServiceStats, bool) {
+	var stats ServiceStats
+	for i := 0; i < 50; i++ {
+		stats = p.Stats().Get()[service]
+		if check(stats) {
+			return stats, true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return stats, false
+}
+
+func TestTCPProxyStats(t *testing.T) {
+	lb := NewLoadBalancerRR()
+	lb.OnUpdate([]api.Endpoints{
+		{
+			JSONBase:  api.JSONBase{ID: "echo"},
+			Endpoints: []string{net.JoinHostPort("127.0.0.1", tcpServerPort)},
+		},
+	})
+
+	p := NewProxier(lb, "127.0.0.1")
+
+	proxyPort, err := p.addServiceOnUnusedPort("echo", "TCP", 0)
+	if err != nil {
+		t.Fatalf("error adding new service: %#v", err)
+	}
+	testEchoTCP(t, "127.0.0.1", proxyPort)
+	stats, ok := waitForStats(p, "echo", func(stats ServiceStats) bool {
+		return stats.ActiveConnections == 1 && stats.BytesProxied > 0
+	})
+	if !ok || stats.DialFailures != 0 {
+		t.Errorf("unexpected stats: %#v", stats)
+	}
+	// The proxied connection is torn down once the client closes its end.
+	http.DefaultTransport.(*http.Transport).CloseIdleConnections()
+	stats, ok = waitForStats(p, "echo", func(stats ServiceStats) bool {
+		return stats.ActiveConnections == 0
+	})
+	if !ok {
+		t.Errorf("unexpected stats: %#v", stats)
+	}
+}
+
+func TestUDPProxyStats(t *testing.T) {
+	lb := NewLoadBalancerRR()
+	lb.OnUpdate([]api.Endpoints{
+		{
+			JSONBase:  api.JSONBase{ID: "echo"},
+			Endpoints: []string{net.JoinHostPort("127.0.0.1", udpServerPort)},
+		},
+	})
+
+	p := NewProxier(lb, "127.0.0.1")
+
+	proxyPort, err := p.addServiceOnUnusedPort("echo", "UDP", time.Second)
+	if err != nil {
+		t.Fatalf("error adding new service: %#v", err)
+	}
+	testEchoUDP(t, "127.0.0.1", proxyPort)
+	// testEchoUDP sends and receives 6 bytes.
+	stats, ok := waitForStats(p, "echo", func(stats ServiceStats) bool {
+		return stats.ActiveConnections == 1 && stats.BytesProxied == 12
+	})
+	if !ok || stats.DialFailures != 0 {
+		t.Errorf("unexpected stats: %#v", stats)
+	}
+}
+
 func TestUDPProxy(t *testing.T) {
 	lb := NewLoadBalancerRR()
 	lb.OnUpdate([]api.Endpoints{
@@ -435,4 +540,119 @@ func TestUDPProxyUpdatePort(t *testing.T) {
 	pc.Close()
 }
 
-// TODO: Test UDP timeouts.
+func TestProxyUpdateProtocol(t *testing.T) {
+	lb := NewLoadBalancerRR()
+	lb.OnUpdate([]api.Endpoints{
+		{
+			JSONBase:  api.JSONBase{ID: "echo"},
+			Endpoints: []string{net.JoinHostPort("127.0.0.1", udpServerPort)},
+		},
+	})
+
+	p := NewProxier(lb, "127.0.0.1")
+
+	proxyPort, err := p.addServiceOnUnusedPort("echo", "TCP", 0)
+	if err != nil {
+		t.Fatalf("error adding new service: %#v", err)
+	}
+	proxyPortNum, _ := strconv.Atoi(proxyPort)
+	p.OnUpdate([]api.Service{
+		{JSONBase: api.JSONBase{ID: "echo"}, Port: proxyPortNum, Protocol: "UDP"},
+	})
+	if err := waitForClosedPortTCP(p, proxyPort); err != nil {
+		t.Fatalf(err.Error())
+	}
+	testEchoUDP(t, "127.0.0.1", proxyPort)
+}
+
+func TestTCPProxyPublicIPs(t *testing.T) {
+	lb := NewLoadBalancerRR()
+	lb.OnUpdate([]api.Endpoints{
+		{
+			JSONBase:  api.JSONBase{ID: "echo"},
+			Endpoints: []string{net.JoinHostPort("127.0.0.1", tcpServerPort)},
+		},
+	})
+
+	oldInterfaceAddrs := interfaceAddrs
+	defer func() { interfaceAddrs = oldInterfaceAddrs }()
+	interfaceAddrs = func() ([]net.Addr, error) {
+		return []net.Addr{
+			&net.IPNet{IP: net.ParseIP("127.0.0.1"), Mask: net.CIDRMask(8, 32)},
+			&net.IPNet{IP: net.ParseIP("127.0.0.2"), Mask: net.CIDRMask(8, 32)},
+		}, nil
+	}
+
+	p := NewProxier(lb, "127.0.0.1")
+
+	// add a new dummy listener in order to get a port that is free
+	l, _ := net.Listen("tcp", ":0")
+	_, port, _ := net.SplitHostPort(l.Addr().String())
+	portNum, _ := strconv.Atoi(port)
+	l.Close()
+
+	// 10.255.255.1 is another minion's public IP, so isn't listened on here.
+	p.OnUpdate([]api.Service{
+		{JSONBase: api.JSONBase{ID: "echo"}, Port: portNum, Protocol: "TCP", PublicIPs: []string{"127.0.0.2", "10.255.255.1"}},
+	})
+	testEchoTCP(t, "127.0.0.1", port)
+	testEchoTCP(t, "127.0.0.2", port)
+	info, _ := p.getServiceInfo("echo")
+	if len(info.publicSockets) != 1 {
+		t.Errorf("expected one public socket, got %d", len(info.publicSockets))
+	}
+
+	p.OnUpdate([]api.Service{
+		{JSONBase: api.JSONBase{ID: "echo"}, Port: portNum, Protocol: "TCP"},
+	})
+	testEchoTCP(t, "127.0.0.1", port)
+	for i := 0; ; i++ {
+		conn, err := net.Dial("tcp", net.JoinHostPort("127.0.0.2", port))
+		if err != nil {
+			break
+		}
+		conn.Close()
+		if i == 50 {
+			t.Fatalf("expected the public IP to be closed")
+		}
+		time.Sleep(1 * time.Millisecond)
+	}
+}
+
+func numActiveClients(p *Proxier, service string) int {
+	info, _ := p.getServiceInfo(service)
+	clients := info.socket.(*udpProxySocket).activeClients
+	clients.mu.Lock()
+	defer clients.mu.Unlock()
+	return len(clients.clients)
+}
+
+func TestUDPProxyTimeout(t *testing.T) {
+	lb := NewLoadBalancerRR()
+	lb.OnUpdate([]api.Endpoints{
+		{
+			JSONBase:  api.JSONBase{ID: "echo"},
+			Endpoints: []string{net.JoinHostPort("127.0.0.1", udpServerPort)},
+		},
+	})
+
+	p := NewProxier(lb, "127.0.0.1")
+
+	timeout := 100 * time.Millisecond
+	proxyPort, err := p.addServiceOnUnusedPort("echo", "UDP", timeout)
+	if err != nil {
+		t.Fatalf("error adding new service: %#v", err)
+	}
+	testEchoUDP(t, "127.0.0.1", proxyPort)
+	if n := numActiveClients(p, "echo"); n != 1 {
+		t.Errorf("expected 1 active client, got %d", n)
+	}
+	// The client's session ends once it has been idle for the timeout.
+	for i := 0; i < 50; i++ {
+		if numActiveClients(p, "echo") == 0 {
+			return
+		}
+		time.Sleep(timeout)
+	}
+	t.Errorf("expected the client to time out")
+}
@@ -22,8 +22,10 @@ import (
 	"reflect"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
 	"github.com/golang/glog"
 )
 
@@ -32,11 +34,34 @@ var (
 	ErrMissingEndpoints    = errors.New("missing endpoints")
 )
 
+// How long an endpoint which failed is skipped for.
+const failedEndpointBackoff = 10 * time.Second
+
+// affinityState records the endpoint last used by a client.
+type affinityState struct {
+	endpoint string
+	lastUsed time.Time
+}
+
+// affinityPolicy is the session affinity of a service, with the endpoint
+// each client is currently stuck to, keyed by client IP.
+type affinityPolicy struct {
+	affinityType api.AffinityType
+	ttl          time.Duration
+	clients      map[string]*affinityState
+	// When clients idle past the TTL were last forgotten.
+	lastPruned time.Time
+}
+
 // LoadBalancerRR is a round-robin load balancer.
 type LoadBalancerRR struct {
-	lock         sync.RWMutex
+	lock         sync.Mutex
 	endpointsMap map[string][]string
 	rrIndex      map[string]int
+	affinityMap  map[string]*affinityPolicy
+	// Maps an endpoint which failed to when it may be used again.
+	failedUntil map[string]time.Time
+	now         func() time.Time
 }
 
 // NewLoadBalancerRR returns a new LoadBalancerRR.
@@ -44,29 +69,98 @@ func NewLoadBalancerRR() *LoadBalancerRR {
 	return &LoadBalancerRR{
 		endpointsMap: make(map[string][]string),
 		rrIndex:      make(map[string]int),
+		affinityMap:  make(map[string]*affinityPolicy),
+		failedUntil:  make(map[string]time.Time),
+		now:          time.Now,
 	}
 }
 
+// NewService sets the session affinity of a service.  Clients already stuck
+// to an endpoint stay there unless the affinity type changes.
+func (lb *LoadBalancerRR) NewService(service string, affinityType api.AffinityType, ttl time.Duration) error {
+	lb.lock.Lock()
+	defer lb.lock.Unlock()
+	policy, exists := lb.affinityMap[service]
+	if !exists || policy.affinityType != affinityType {
+		policy = &affinityPolicy{
+			affinityType: affinityType,
+			clients:      make(map[string]*affinityState),
+		}
+		lb.affinityMap[service] = policy
+	}
+	policy.ttl = ttl
+	return nil
+}
+
 // NextEndpoint returns a service endpoint.
-// The service endpoint is chosen using the round-robin algorithm.
+// The service endpoint is chosen using the round-robin algorithm, unless
+// the service has client IP affinity and srcAddr was recently sent to an
+// endpoint, in which case that endpoint is used again.  Endpoints which
+// recently failed are skipped, unless every endpoint has.
 func (lb *LoadBalancerRR) NextEndpoint(service string, srcAddr net.Addr) (string, error) {
-	lb.lock.RLock()
+	lb.lock.Lock()
+	defer lb.lock.Unlock()
 	endpoints, exists := lb.endpointsMap[service]
-	index := lb.rrIndex[service]
-	lb.lock.RUnlock()
 	if !exists {
 		return "", ErrMissingServiceEntry
 	}
 	if len(endpoints) == 0 {
 		return "", ErrMissingEndpoints
 	}
+	now := lb.now()
+	policy := lb.affinityMap[service]
+	clientIP := ""
+	if policy != nil && policy.affinityType == api.AffinityTypeClientIP && srcAddr != nil {
+		if host, _, err := net.SplitHostPort(srcAddr.String()); err == nil {
+			clientIP = host
+		}
+	}
+	if clientIP != "" {
+		// Forget idle clients at most once per TTL, so that clients which
+		// never come back don't accumulate.
+		if now.Sub(policy.lastPruned) >= policy.ttl {
+			lb.pruneAffinity(service)
+		}
+		if state, ok := policy.clients[clientIP]; ok && now.Sub(state.lastUsed) < policy.ttl && !lb.isFailed(state.endpoint, now) {
+			state.lastUsed = now
+			return state.endpoint, nil
+		}
+	}
+	index := lb.rrIndex[service]
+	for i := 0; i < len(endpoints); i++ {
+		candidate := (lb.rrIndex[service] + i) % len(endpoints)
+		if !lb.isFailed(endpoints[candidate], now) {
+			index = candidate
+			break
+		}
+	}
 	endpoint := endpoints[index]
-	lb.lock.Lock()
 	lb.rrIndex[service] = (index + 1) % len(endpoints)
-	lb.lock.Unlock()
+	if clientIP != "" {
+		policy.clients[clientIP] = &affinityState{endpoint: endpoint, lastUsed: now}
+	}
 	return endpoint, nil
 }
 
+// EndpointFailed skips endpoint for failedEndpointBackoff.
+func (lb *LoadBalancerRR) EndpointFailed(service, endpoint string) {
+	lb.lock.Lock()
+	defer lb.lock.Unlock()
+	glog.Infof("LoadBalancerRR: Skipping endpoint %s of %s for %v", endpoint, service, failedEndpointBackoff)
+	lb.failedUntil[endpoint] = lb.now().Add(failedEndpointBackoff)
+}
+
+// isFailed returns whether endpoint failed too recently to be used.
+// Must be called with lb.lock held.
+func (lb *LoadBalancerRR) isFailed(endpoint string, now time.Time) bool {
+	until, failed := lb.failedUntil[endpoint]
+	if failed && !now.Before(until) {
+		delete(lb.failedUntil, endpoint)
+		return false
+	}
+	return failed
+}
+
 func isValidEndpoint(spec string) bool {
 	_, port, err := net.SplitHostPort(spec)
 	if err != nil {
@@ -107,12 +201,33 @@ func (lb *LoadBalancerRR) OnUpdate(endpoints []api.Endpoints) {
 			lb.rrIndex[endpoint.ID] = 0
 		}
 		registeredEndpoints[endpoint.ID] = true
+		lb.pruneAffinity(endpoint.ID)
 	}
 	// Remove endpoints missing from the update.
 	for k, v := range lb.endpointsMap {
 		if _, exists := registeredEndpoints[k]; !exists {
 			glog.Infof("LoadBalancerRR: Removing endpoints for %s -> %+v", k, v)
 			delete(lb.endpointsMap, k)
+			delete(lb.rrIndex, k)
+			delete(lb.affinityMap, k)
+		}
+	}
+}
+
+// pruneAffinity forgets clients of a service that have been idle past the
+// TTL or are stuck to an endpoint that no longer exists.
+// Must be called with lb.lock held.
+func (lb *LoadBalancerRR) pruneAffinity(service string) {
+	policy, exists := lb.affinityMap[service]
+	if !exists {
+		return
+	}
+	valid := util.NewStringSet(lb.endpointsMap[service]...)
+	now := lb.now()
+	policy.lastPruned = now
+	for clientIP, state := range policy.clients {
+		if !valid.Has(state.endpoint) || now.Sub(state.lastUsed) >= policy.ttl {
+			delete(policy.clients, clientIP)
 		}
 	}
 }
@@ -17,7 +17,9 @@ limitations under the License.
 package proxy
 
 import (
+	"net"
 	"testing"
+	"time"
 
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 )
@@ -192,3 +194,162 @@ func TestLoadBalanceWorksWithServiceRemoval(t *testing.T) {
 	expectEndpoint(t, loadBalancer, "bar", "endpoint:5")
 	expectEndpoint(t, loadBalancer, "bar", "endpoint:4")
 }
+
+func expectEndpointFrom(t *testing.T, loadBalancer *LoadBalancerRR, service string, client net.Addr, expected string) {
+	endpoint, err := loadBalancer.NextEndpoint(service, client)
+	if err != nil {
+		t.Errorf("Didn't find a service for %s, expected %s, failed with: %v", service, expected, err)
+	}
+	if endpoint != expected {
+		t.Errorf("Didn't get expected endpoint for service %s and client %v, expected %s, got: %s", service, client, expected, endpoint)
+	}
+}
+
+func TestLoadBalanceWorksWithClientIPAffinity(t *testing.T) {
+	loadBalancer := NewLoadBalancerRR()
+	now := time.Unix(0, 0)
+	loadBalancer.now = func() time.Time { return now }
+	loadBalancer.NewService("foo", api.AffinityTypeClientIP, time.Minute)
+	loadBalancer.OnUpdate([]api.Endpoints{
+		{
+			JSONBase:  api.JSONBase{ID: "foo"},
+			Endpoints: []string{"endpoint:1", "endpoint:2", "endpoint:3"},
+		},
+	})
+	client1 := &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 1000}
+	client2 := &net.TCPAddr{IP: net.IPv4(10, 0, 0, 2), Port: 1000}
+	// A new connection from the same client on another port still sticks.
+	client1Again := &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 2000}
+
+	expectEndpointFrom(t, loadBalancer, "foo", client1, "endpoint:1")
+	expectEndpointFrom(t, loadBalancer, "foo", client2, "endpoint:2")
+	expectEndpointFrom(t, loadBalancer, "foo", client1Again, "endpoint:1")
+	expectEndpointFrom(t, loadBalancer, "foo", client2, "endpoint:2")
+	// Clients without an address are balanced as usual.
+	expectEndpoint(t, loadBalancer, "foo", "endpoint:3")
+
+	// Once idle past the TTL a client is balanced again.
+	now = now.Add(time.Minute)
+	expectEndpointFrom(t, loadBalancer, "foo", client2, "endpoint:1")
+	expectEndpointFrom(t, loadBalancer, "foo", client2, "endpoint:1")
+}
+
+func TestLoadBalanceClientIPAffinityForgetsRemovedEndpoints(t *testing.T) {
+	loadBalancer := NewLoadBalancerRR()
+	loadBalancer.NewService("foo", api.AffinityTypeClientIP, time.Minute)
+	endpoints := []api.Endpoints{
+		{
+			JSONBase:  api.JSONBase{ID: "foo"},
+			Endpoints: []string{"endpoint:1", "endpoint:2"},
+		},
+	}
+	loadBalancer.OnUpdate(endpoints)
+	client1 := &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 1000}
+	client2 := &net.TCPAddr{IP: net.IPv4(10, 0, 0, 2), Port: 1000}
+	expectEndpointFrom(t, loadBalancer, "foo", client1, "endpoint:1")
+	expectEndpointFrom(t, loadBalancer, "foo", client2, "endpoint:2")
+
+	endpoints[0].Endpoints = []string{"endpoint:2", "endpoint:3"}
+	loadBalancer.OnUpdate(endpoints)
+	expectEndpointFrom(t, loadBalancer, "foo", client2, "endpoint:2")
+	expectEndpointFrom(t, loadBalancer, "foo", client1, "endpoint:2")
+	expectEndpointFrom(t, loadBalancer, "foo", client1, "endpoint:2")
+}
+
+func TestLoadBalanceWithoutAffinityIgnoresClient(t *testing.T) {
+	loadBalancer := NewLoadBalancerRR()
+	loadBalancer.NewService("foo", api.AffinityTypeNone, time.Minute)
+	loadBalancer.OnUpdate([]api.Endpoints{
+		{
+			JSONBase:  api.JSONBase{ID: "foo"},
+			Endpoints: []string{"endpoint:1", "endpoint:2"},
+		},
+	})
+	client := &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 1000}
+	expectEndpointFrom(t, loadBalancer, "foo", client, "endpoint:1")
+	expectEndpointFrom(t, loadBalancer, "foo", client, "endpoint:2")
+}
+
+func TestLoadBalanceSkipsFailedEndpoints(t *testing.T) {
+	loadBalancer := NewLoadBalancerRR()
+	now := time.Unix(0, 0)
+	loadBalancer.now = func() time.Time { return now }
+	loadBalancer.OnUpdate([]api.Endpoints{
+		{
+			JSONBase:  api.JSONBase{ID: "foo"},
+			Endpoints: []string{"endpoint:1", "endpoint:2", "endpoint:3"},
+		},
+	})
+	loadBalancer.EndpointFailed("foo", "endpoint:2")
+	expectEndpoint(t, loadBalancer, "foo", "endpoint:1")
+	expectEndpoint(t, loadBalancer, "foo", "endpoint:3")
+	expectEndpoint(t, loadBalancer, "foo", "endpoint:1")
+
+	// Once every endpoint has failed they are balanced as usual.
+	loadBalancer.EndpointFailed("foo", "endpoint:1")
+	loadBalancer.EndpointFailed("foo", "endpoint:3")
+	expectEndpoint(t, loadBalancer, "foo", "endpoint:2")
+	expectEndpoint(t, loadBalancer, "foo", "endpoint:3")
+
+	// After the backoff failed endpoints are used again.
+	now = now.Add(failedEndpointBackoff)
+	expectEndpoint(t, loadBalancer, "foo", "endpoint:1")
+	expectEndpoint(t, loadBalancer, "foo", "endpoint:2")
+}
+
+func TestLoadBalanceClientIPAffinityMovesOffFailedEndpoint(t *testing.T) {
+	loadBalancer := NewLoadBalancerRR()
+	loadBalancer.NewService("foo", api.AffinityTypeClientIP, time.Minute)
+	loadBalancer.OnUpdate([]api.Endpoints{
+		{
+			JSONBase:  api.JSONBase{ID: "foo"},
+			Endpoints: []string{"endpoint:1", "endpoint:2"},
+		},
+	})
+	client := &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 1000}
+	expectEndpointFrom(t, loadBalancer, "foo", client, "endpoint:1")
+	loadBalancer.EndpointFailed("foo", "endpoint:1")
+	expectEndpointFrom(t, loadBalancer, "foo", client, "endpoint:2")
+	expectEndpointFrom(t, loadBalancer, "foo", client, "endpoint:2")
+}
+
+func TestLoadBalanceClientIPAffinityForgetsIdleClients(t *testing.T) {
+	loadBalancer := NewLoadBalancerRR()
+	now := time.Unix(0, 0)
+	loadBalancer.now = func() time.Time { return now }
+	loadBalancer.NewService("foo", api.AffinityTypeClientIP, time.Minute)
+	loadBalancer.OnUpdate([]api.Endpoints{
+		{
+			JSONBase:  api.JSONBase{ID: "foo"},
+			Endpoints: []string{"endpoint:1", "endpoint:2"},
+		},
+	})
+	client1 := &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 1000}
+	client2 := &net.TCPAddr{IP: net.IPv4(10, 0, 0, 2), Port: 1000}
+	expectEndpointFrom(t, loadBalancer, "foo", client1, "endpoint:1")
+
+	// Looking up another client after the TTL forgets client1.
+	now = now.Add(time.Minute)
+	expectEndpointFrom(t, loadBalancer, "foo", client2, "endpoint:2")
+	clients := loadBalancer.affinityMap["foo"].clients
+	if _, found := clients["10.0.0.1"]; found || len(clients) != 1 {
+		t.Errorf("expected only the active client to be remembered, got %v", clients)
+	}
+}
+
+func TestLoadBalanceForgetsAffinityOfRemovedServices(t *testing.T) {
+	loadBalancer := NewLoadBalancerRR()
+	loadBalancer.NewService("foo", api.AffinityTypeClientIP, time.Minute)
+	loadBalancer.OnUpdate([]api.Endpoints{
+		{
+			JSONBase:  api.JSONBase{ID: "foo"},
+			Endpoints: []string{"endpoint:1"},
+		},
+	})
+	expectEndpointFrom(t, loadBalancer, "foo", &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 1000}, "endpoint:1")
+
+	loadBalancer.OnUpdate([]api.Endpoints{})
+	if _, found := loadBalancer.affinityMap["foo"]; found {
+		t.Errorf("expected the affinity of a removed service to be forgotten")
+	}
+}
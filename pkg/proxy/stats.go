@@ -0,0 +1,98 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// ServiceStats counts the traffic the userspace proxy has carried for a service.
+type ServiceStats struct {
+	// ActiveConnections is the number of TCP connections, and UDP clients, currently proxied.
+	ActiveConnections int64 `json:"activeConnections"`
+	// BytesProxied is the number of bytes copied between clients and endpoints, in both directions.
+	BytesProxied int64 `json:"bytesProxied"`
+	// DialFailures is the number of times connecting to an endpoint failed.
+	DialFailures int64 `json:"dialFailures"`
+}
+
+// ConnectionStats records a ServiceStats for each service the proxier has
+// carried traffic for, and serves them as JSON keyed by service name.
+type ConnectionStats struct {
+	lock     sync.Mutex
+	services map[string]*ServiceStats
+}
+
+// NewConnectionStats returns an empty ConnectionStats.
+func NewConnectionStats() *ConnectionStats {
+	return &ConnectionStats{services: map[string]*ServiceStats{}}
+}
+
+// forService returns the counters of service, which must only be accessed atomically.
+func (s *ConnectionStats) forService(service string) *ServiceStats {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	stats, ok := s.services[service]
+	if !ok {
+		stats = &ServiceStats{}
+		s.services[service] = stats
+	}
+	return stats
+}
+
+// Get returns a snapshot of the counters of every service.
+func (s *ConnectionStats) Get() map[string]ServiceStats {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	result := make(map[string]ServiceStats, len(s.services))
+	for service, stats := range s.services {
+		result[service] = ServiceStats{
+			ActiveConnections: atomic.LoadInt64(&stats.ActiveConnections),
+			BytesProxied:      atomic.LoadInt64(&stats.BytesProxied),
+			DialFailures:      atomic.LoadInt64(&stats.DialFailures),
+		}
+	}
+	return result
+}
+
+// ServeHTTP implements http.Handler.
+func (s *ConnectionStats) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	data, err := json.Marshal(s.Get())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+// countingWriter adds the number of bytes written through it to BytesProxied.
+type countingWriter struct {
+	io.Writer
+	stats *ServiceStats
+}
+
+func (c countingWriter) Write(p []byte) (int, error) {
+	n, err := c.Writer.Write(p)
+	atomic.AddInt64(&c.stats.BytesProxied, int64(n))
+	return n, err
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestConnectionStatsServeHTTP(t *testing.T) {
+	stats := NewConnectionStats()
+	stats.forService("foo").DialFailures = 2
+	stats.forService("bar").ActiveConnections = 1
+	stats.forService("bar").BytesProxied = 100
+
+	server := httptest.NewServer(stats)
+	defer server.Close()
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	var got map[string]ServiceStats
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]ServiceStats{
+		"foo": {DialFailures: 2},
+		"bar": {ActiveConnections: 1, BytesProxied: 100},
+	}
+	if !reflect.DeepEqual(expected, got) {
+		t.Errorf("expected %#v, got %#v", expected, got)
+	}
+}
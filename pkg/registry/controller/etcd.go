@@ -0,0 +1,90 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/registry/generic"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/tools"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
+)
+
+// etcdRegistry implements Registry with controllers kept in etcd.
+type etcdRegistry struct {
+	store *generic.Etcd
+}
+
+// NewEtcdRegistry returns a Registry which keeps controllers in etcd through
+// helper. If cache isn't nil, lists and gets are served from it.
+func NewEtcdRegistry(helper tools.EtcdHelper, cache *tools.EtcdCache) Registry {
+	return &etcdRegistry{
+		store: &generic.Etcd{
+			Kind:        "replicationController",
+			NewFunc:     func() runtime.Object { return &api.ReplicationController{} },
+			NewListFunc: func() runtime.Object { return &api.ReplicationControllerList{} },
+			KeyRoot:     "/registry/controllers",
+			Labels: func(obj runtime.Object) labels.Set {
+				return labels.Set(obj.(*api.ReplicationController).Labels)
+			},
+			Helper: helper,
+			Cache:  cache,
+		},
+	}
+}
+
+// ListControllers obtains a list of ReplicationControllers.
+func (r *etcdRegistry) ListControllers() (*api.ReplicationControllerList, error) {
+	list, err := r.store.List(labels.Everything(), labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	return list.(*api.ReplicationControllerList), nil
+}
+
+// WatchControllers begins watching for new, changed, or deleted controllers
+// matching label. Controllers which start or stop matching are reported as
+// added or deleted.
+func (r *etcdRegistry) WatchControllers(label, field labels.Selector, resourceVersion uint64) (watch.Interface, error) {
+	return r.store.Watch(label, field, resourceVersion)
+}
+
+// GetController gets a specific ReplicationController specified by its ID.
+func (r *etcdRegistry) GetController(controllerID string) (*api.ReplicationController, error) {
+	obj, err := r.store.Get(controllerID)
+	if err != nil {
+		return nil, err
+	}
+	return obj.(*api.ReplicationController), nil
+}
+
+// CreateController creates a new ReplicationController.
+func (r *etcdRegistry) CreateController(controller *api.ReplicationController) error {
+	return r.store.CreateObj(controller.ID, controller)
+}
+
+// UpdateController replaces an existing ReplicationController.
+func (r *etcdRegistry) UpdateController(controller *api.ReplicationController) error {
+	return r.store.UpdateObj(controller.ID, controller)
+}
+
+// DeleteController deletes a ReplicationController specified by its ID. If
+// resourceVersion is non-zero, it is only deleted if still at that version.
+func (r *etcdRegistry) DeleteController(controllerID string, resourceVersion uint64) error {
+	return r.store.DeleteObj(controllerID, resourceVersion)
+}
@@ -0,0 +1,284 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/latest"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/tools"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
+
+	"github.com/coreos/go-etcd/etcd"
+)
+
+func newTestEtcdRegistry(client tools.EtcdClient) Registry {
+	return NewEtcdRegistry(tools.EtcdHelper{
+		Client:            client,
+		Codec:             latest.Codec,
+		ResourceVersioner: latest.ResourceVersioner,
+	}, nil)
+}
+
+func TestEtcdListControllersNotFound(t *testing.T) {
+	fakeClient := tools.NewFakeEtcdClient(t)
+	key := "/registry/controllers"
+	fakeClient.Data[key] = tools.EtcdResponseWithError{
+		R: &etcd.Response{},
+		E: tools.EtcdErrorNotFound,
+	}
+	registry := newTestEtcdRegistry(fakeClient)
+	controllers, err := registry.ListControllers()
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if len(controllers.Items) != 0 {
+		t.Errorf("Unexpected controller list: %#v", controllers)
+	}
+}
+
+func TestEtcdListControllers(t *testing.T) {
+	fakeClient := tools.NewFakeEtcdClient(t)
+	key := "/registry/controllers"
+	fakeClient.Data[key] = tools.EtcdResponseWithError{
+		R: &etcd.Response{
+			Node: &etcd.Node{
+				Nodes: []*etcd.Node{
+					{
+						Value: runtime.EncodeOrDie(latest.Codec, &api.ReplicationController{JSONBase: api.JSONBase{ID: "foo"}}),
+					},
+					{
+						Value: runtime.EncodeOrDie(latest.Codec, &api.ReplicationController{JSONBase: api.JSONBase{ID: "bar"}}),
+					},
+				},
+			},
+		},
+		E: nil,
+	}
+	registry := newTestEtcdRegistry(fakeClient)
+	controllers, err := registry.ListControllers()
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if len(controllers.Items) != 2 || controllers.Items[0].ID != "foo" || controllers.Items[1].ID != "bar" {
+		t.Errorf("Unexpected controller list: %#v", controllers)
+	}
+}
+
+func TestEtcdGetController(t *testing.T) {
+	fakeClient := tools.NewFakeEtcdClient(t)
+	fakeClient.Set("/registry/controllers/foo", runtime.EncodeOrDie(latest.Codec, &api.ReplicationController{JSONBase: api.JSONBase{ID: "foo"}}), 0)
+	registry := newTestEtcdRegistry(fakeClient)
+	ctrl, err := registry.GetController("foo")
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if ctrl.ID != "foo" {
+		t.Errorf("Unexpected controller: %#v", ctrl)
+	}
+}
+
+func TestEtcdGetControllerNotFound(t *testing.T) {
+	fakeClient := tools.NewFakeEtcdClient(t)
+	fakeClient.Data["/registry/controllers/foo"] = tools.EtcdResponseWithError{
+		R: &etcd.Response{
+			Node: nil,
+		},
+		E: tools.EtcdErrorNotFound,
+	}
+	registry := newTestEtcdRegistry(fakeClient)
+	ctrl, err := registry.GetController("foo")
+	if ctrl != nil {
+		t.Errorf("Unexpected non-nil controller: %#v", ctrl)
+	}
+	if !errors.IsNotFound(err) {
+		t.Errorf("Unexpected error returned: %#v", err)
+	}
+}
+
+func TestEtcdDeleteController(t *testing.T) {
+	fakeClient := tools.NewFakeEtcdClient(t)
+	registry := newTestEtcdRegistry(fakeClient)
+	err := registry.DeleteController("foo", 0)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if len(fakeClient.DeletedKeys) != 1 {
+		t.Errorf("Expected 1 delete, found %#v", fakeClient.DeletedKeys)
+	}
+	key := "/registry/controllers/foo"
+	if fakeClient.DeletedKeys[0] != key {
+		t.Errorf("Unexpected key: %s, expected %s", fakeClient.DeletedKeys[0], key)
+	}
+}
+
+func TestEtcdDeleteControllerIfVersion(t *testing.T) {
+	fakeClient := tools.NewFakeEtcdClient(t)
+	fakeClient.TestIndex = true
+	resp, _ := fakeClient.Set("/registry/controllers/foo", runtime.EncodeOrDie(latest.Codec, &api.ReplicationController{JSONBase: api.JSONBase{ID: "foo"}}), 0)
+	registry := newTestEtcdRegistry(fakeClient)
+
+	err := registry.DeleteController("foo", resp.Node.ModifiedIndex+1)
+	if !errors.IsConflict(err) {
+		t.Errorf("Expected a conflict error, got %#v", err)
+	}
+	if len(fakeClient.DeletedKeys) != 0 {
+		t.Errorf("Expected no deletes, found %#v", fakeClient.DeletedKeys)
+	}
+
+	if err := registry.DeleteController("foo", resp.Node.ModifiedIndex); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if len(fakeClient.DeletedKeys) != 1 {
+		t.Errorf("Expected 1 delete, found %#v", fakeClient.DeletedKeys)
+	}
+}
+
+func TestEtcdCreateController(t *testing.T) {
+	fakeClient := tools.NewFakeEtcdClient(t)
+	registry := newTestEtcdRegistry(fakeClient)
+	err := registry.CreateController(&api.ReplicationController{
+		JSONBase: api.JSONBase{
+			ID: "foo",
+		},
+	})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	resp, err := fakeClient.Get("/registry/controllers/foo", false, false)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	var ctrl api.ReplicationController
+	err = latest.Codec.DecodeInto([]byte(resp.Node.Value), &ctrl)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if ctrl.ID != "foo" {
+		t.Errorf("Unexpected pod: %#v %s", ctrl, resp.Node.Value)
+	}
+}
+
+func TestEtcdCreateControllerAlreadyExisting(t *testing.T) {
+	fakeClient := tools.NewFakeEtcdClient(t)
+	fakeClient.Set("/registry/controllers/foo", runtime.EncodeOrDie(latest.Codec, &api.ReplicationController{JSONBase: api.JSONBase{ID: "foo"}}), 0)
+
+	registry := newTestEtcdRegistry(fakeClient)
+	err := registry.CreateController(&api.ReplicationController{
+		JSONBase: api.JSONBase{
+			ID: "foo",
+		},
+	})
+	if !errors.IsAlreadyExists(err) {
+		t.Errorf("expected already exists err, got %#v", err)
+	}
+}
+
+func TestEtcdUpdateController(t *testing.T) {
+	fakeClient := tools.NewFakeEtcdClient(t)
+	fakeClient.TestIndex = true
+
+	resp, _ := fakeClient.Set("/registry/controllers/foo", runtime.EncodeOrDie(latest.Codec, &api.ReplicationController{JSONBase: api.JSONBase{ID: "foo"}}), 0)
+	registry := newTestEtcdRegistry(fakeClient)
+	err := registry.UpdateController(&api.ReplicationController{
+		JSONBase: api.JSONBase{ID: "foo", ResourceVersion: resp.Node.ModifiedIndex},
+		DesiredState: api.ReplicationControllerState{
+			Replicas: 2,
+		},
+	})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	ctrl, err := registry.GetController("foo")
+	if ctrl.DesiredState.Replicas != 2 {
+		t.Errorf("Unexpected controller: %#v", ctrl)
+	}
+}
+
+func TestEtcdUpdateControllerConflict(t *testing.T) {
+	fakeClient := tools.NewFakeEtcdClient(t)
+	fakeClient.TestIndex = true
+
+	resp, _ := fakeClient.Set("/registry/controllers/foo", runtime.EncodeOrDie(latest.Codec, &api.ReplicationController{JSONBase: api.JSONBase{ID: "foo"}}), 0)
+	registry := newTestEtcdRegistry(fakeClient)
+	// Another writer got there first.
+	fakeClient.Set("/registry/controllers/foo", runtime.EncodeOrDie(latest.Codec, &api.ReplicationController{JSONBase: api.JSONBase{ID: "foo"}}), 0)
+	err := registry.UpdateController(&api.ReplicationController{
+		JSONBase: api.JSONBase{ID: "foo", ResourceVersion: resp.Node.ModifiedIndex},
+	})
+	if !errors.IsConflict(err) {
+		t.Errorf("Expected a conflict error, got %#v", err)
+	}
+}
+
+func TestEtcdUpdateControllerNoResourceVersion(t *testing.T) {
+	fakeClient := tools.NewFakeEtcdClient(t)
+	fakeClient.TestIndex = true
+
+	fakeClient.Set("/registry/controllers/foo", runtime.EncodeOrDie(latest.Codec, &api.ReplicationController{JSONBase: api.JSONBase{ID: "foo"}}), 0)
+	registry := newTestEtcdRegistry(fakeClient)
+	err := registry.UpdateController(&api.ReplicationController{JSONBase: api.JSONBase{ID: "foo"}})
+	if !errors.IsInvalid(err) {
+		t.Errorf("Expected an invalid error, got %#v", err)
+	}
+}
+
+func TestEtcdWatchControllers(t *testing.T) {
+	fakeClient := tools.NewFakeEtcdClient(t)
+	registry := newTestEtcdRegistry(fakeClient)
+	watching, err := registry.WatchControllers(labels.SelectorFromSet(labels.Set{"name": "foo"}), labels.Everything(), 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fakeClient.WaitForWatchCompletion()
+
+	for _, name := range []string{"bar", "foo"} {
+		controller := &api.ReplicationController{JSONBase: api.JSONBase{ID: name}, Labels: map[string]string{"name": name}}
+		fakeClient.WatchResponse <- &etcd.Response{
+			Action: "create",
+			Node:   &etcd.Node{Value: runtime.EncodeOrDie(latest.Codec, controller), CreatedIndex: 2, ModifiedIndex: 2},
+		}
+	}
+	event := <-watching.ResultChan()
+	if e, a := watch.Added, event.Type; e != a {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+	if controller, ok := event.Object.(*api.ReplicationController); !ok || controller.ID != "foo" {
+		t.Errorf("unexpected object: %#v", event.Object)
+	}
+	watching.Stop()
+}
+
+func TestEtcdWatchControllersBadSelector(t *testing.T) {
+	fakeClient := tools.NewFakeEtcdClient(t)
+	registry := newTestEtcdRegistry(fakeClient)
+	_, err := registry.WatchControllers(labels.Everything(), labels.SelectorFromSet(labels.Set{"ID": "foo"}), 0)
+	if err == nil {
+		t.Errorf("unexpected non-error: %v", err)
+	}
+}
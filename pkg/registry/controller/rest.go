@@ -67,6 +67,9 @@ func (rs *REST) Create(obj runtime.Object) (<-chan runtime.Object, error) {
 	if errs := validation.ValidateReplicationController(controller); len(errs) > 0 {
 		return nil, errors.NewInvalid("replicationController", controller.ID, errs)
 	}
+	if err := rs.checkOverlap(controller); err != nil {
+		return nil, err
+	}
 
 	controller.CreationTimestamp = util.Now()
 
@@ -81,8 +84,14 @@ func (rs *REST) Create(obj runtime.Object) (<-chan runtime.Object, error) {
 
 // Delete asynchronously deletes the ReplicationController specified by its id.
 func (rs *REST) Delete(id string) (<-chan runtime.Object, error) {
+	return rs.DeleteIfVersion(id, 0)
+}
+
+// DeleteIfVersion asynchronously deletes the ReplicationController specified by
+// its id, if its resourceVersion is still resourceVersion.
+func (rs *REST) DeleteIfVersion(id string, resourceVersion uint64) (<-chan runtime.Object, error) {
 	return apiserver.MakeAsync(func() (runtime.Object, error) {
-		return &api.Status{Status: api.StatusSuccess}, rs.registry.DeleteController(id)
+		return &api.Status{Status: api.StatusSuccess}, rs.registry.DeleteController(id, resourceVersion)
 	}), nil
 }
 
@@ -131,6 +140,9 @@ func (rs *REST) Update(obj runtime.Object) (<-chan runtime.Object, error) {
 	if errs := validation.ValidateReplicationController(controller); len(errs) > 0 {
 		return nil, errors.NewInvalid("replicationController", controller.ID, errs)
 	}
+	if err := rs.checkOverlap(controller); err != nil {
+		return nil, err
+	}
 	return apiserver.MakeAsync(func() (runtime.Object, error) {
 		err := rs.registry.UpdateController(controller)
 		if err != nil {
@@ -143,20 +155,15 @@ func (rs *REST) Update(obj runtime.Object) (<-chan runtime.Object, error) {
 // Watch returns ReplicationController events via a watch.Interface.
 // It implements apiserver.ResourceWatcher.
 func (rs *REST) Watch(label, field labels.Selector, resourceVersion uint64) (watch.Interface, error) {
-	if !field.Empty() {
-		return nil, fmt.Errorf("no field selector implemented for controllers")
-	}
-	incoming, err := rs.registry.WatchControllers(resourceVersion)
+	incoming, err := rs.registry.WatchControllers(label, field, resourceVersion)
 	if err != nil {
 		return nil, err
 	}
 	return watch.Filter(incoming, func(e watch.Event) (watch.Event, bool) {
-		repController := e.Object.(*api.ReplicationController)
-		match := label.Matches(labels.Set(repController.Labels))
-		if match {
-			rs.fillCurrentState(repController)
+		if controller, ok := e.Object.(*api.ReplicationController); ok {
+			rs.fillCurrentState(controller)
 		}
-		return e, match
+		return e, true
 	}), nil
 }
 
@@ -185,3 +192,29 @@ func (rs *REST) fillCurrentState(ctrl *api.ReplicationController) error {
 	ctrl.CurrentState.Replicas = len(list.Items)
 	return nil
 }
+
+// checkOverlap returns a conflict error if controller and another existing
+// controller would each count the other's pods as their own. Such controllers
+// fight over the number of pods, each deleting what the other creates.
+func (rs *REST) checkOverlap(controller *api.ReplicationController) error {
+	controllers, err := rs.registry.ListControllers()
+	if err != nil {
+		return err
+	}
+	if controllers == nil {
+		return nil
+	}
+	selector := labels.Set(controller.DesiredState.ReplicaSelector).AsSelector()
+	templateLabels := labels.Set(controller.DesiredState.PodTemplate.Labels)
+	for _, existing := range controllers.Items {
+		if existing.ID == controller.ID {
+			continue
+		}
+		existingSelector := labels.Set(existing.DesiredState.ReplicaSelector).AsSelector()
+		if existingSelector.Matches(templateLabels) || selector.Matches(labels.Set(existing.DesiredState.PodTemplate.Labels)) {
+			return errors.NewConflict("replicationController", controller.ID,
+				fmt.Errorf("its selector overlaps the pods of replicationController %q", existing.ID))
+		}
+	}
+	return nil
+}
@@ -28,8 +28,11 @@ import (
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/latest"
 	_ "github.com/GoogleCloudPlatform/kubernetes/pkg/api/v1beta1"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/apiserver"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/registry/registrytest"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/tools"
 )
 
 func TestListControllersError(t *testing.T) {
@@ -108,10 +111,21 @@ func TestControllerDecode(t *testing.T) {
 	storage := REST{
 		registry: &mockRegistry,
 	}
+	alwaysRestart := api.ReplicationControllerState{
+		PodTemplate: api.PodTemplate{
+			DesiredState: api.PodState{
+				Manifest: api.ContainerManifest{
+					RestartPolicy: api.RestartPolicy{Always: &api.RestartPolicyAlways{}},
+				},
+			},
+		},
+	}
 	controller := &api.ReplicationController{
 		JSONBase: api.JSONBase{
 			ID: "foo",
 		},
+		DesiredState: alwaysRestart,
+		CurrentState: alwaysRestart,
 	}
 	body, err := latest.Codec.Encode(controller)
 	if err != nil {
@@ -256,6 +270,85 @@ func TestCreateController(t *testing.T) {
 	}
 }
 
+func TestControllerStorageRejectsOverlappingSelectors(t *testing.T) {
+	existing := api.ReplicationController{
+		JSONBase: api.JSONBase{ID: "existing"},
+		DesiredState: api.ReplicationControllerState{
+			ReplicaSelector: map[string]string{"a": "b"},
+			PodTemplate:     validPodTemplate,
+		},
+	}
+	mockRegistry := registrytest.ControllerRegistry{
+		Controllers: &api.ReplicationControllerList{
+			Items: []api.ReplicationController{existing},
+		},
+	}
+	storage := REST{
+		registry:   &mockRegistry,
+		pollPeriod: time.Millisecond * 1,
+	}
+
+	narrowTemplate := validPodTemplate
+	narrowTemplate.Labels = map[string]string{"a": "b", "c": "d"}
+	otherTemplate := validPodTemplate
+	otherTemplate.Labels = map[string]string{"a": "x"}
+	tests := map[string]struct {
+		controller api.ReplicationController
+		overlaps   bool
+	}{
+		"same selector": {
+			controller: api.ReplicationController{
+				JSONBase: api.JSONBase{ID: "new"},
+				DesiredState: api.ReplicationControllerState{
+					ReplicaSelector: map[string]string{"a": "b"},
+					PodTemplate:     validPodTemplate,
+				},
+			},
+			overlaps: true,
+		},
+		"existing selector matches new pods": {
+			controller: api.ReplicationController{
+				JSONBase: api.JSONBase{ID: "new"},
+				DesiredState: api.ReplicationControllerState{
+					ReplicaSelector: map[string]string{"c": "d"},
+					PodTemplate:     narrowTemplate,
+				},
+			},
+			overlaps: true,
+		},
+		"disjoint": {
+			controller: api.ReplicationController{
+				JSONBase: api.JSONBase{ID: "new"},
+				DesiredState: api.ReplicationControllerState{
+					ReplicaSelector: map[string]string{"a": "x"},
+					PodTemplate:     otherTemplate,
+				},
+			},
+			overlaps: false,
+		},
+		"updating itself": {
+			controller: existing,
+			overlaps:   false,
+		},
+	}
+	for name, test := range tests {
+		_, err := storage.Create(&test.controller)
+		if test.overlaps && !errors.IsConflict(err) {
+			t.Errorf("%s: expected a conflict error on create, got %v", name, err)
+		}
+		if !test.overlaps && err != nil {
+			t.Errorf("%s: unexpected error on create: %v", name, err)
+		}
+		_, err = storage.Update(&test.controller)
+		if test.overlaps && !errors.IsConflict(err) {
+			t.Errorf("%s: expected a conflict error on update, got %v", name, err)
+		}
+		if !test.overlaps && err != nil {
+			t.Errorf("%s: unexpected error on update: %v", name, err)
+		}
+	}
+}
+
 func TestControllerStorageValidatesCreate(t *testing.T) {
 	mockRegistry := registrytest.ControllerRegistry{}
 	storage := REST{
@@ -357,3 +450,31 @@ func TestFillCurrentState(t *testing.T) {
 		t.Errorf("unexpected output: %#v %#v", labels.Set(controller.DesiredState.ReplicaSelector).AsSelector(), fakeLister.s)
 	}
 }
+
+func TestRESTConformance(t *testing.T) {
+	tester := registrytest.Tester{
+		NewStorage: func(client tools.EtcdClient) apiserver.RESTStorage {
+			return NewREST(newTestEtcdRegistry(client), nil)
+		},
+		Valid: func() runtime.Object {
+			return &api.ReplicationController{
+				DesiredState: api.ReplicationControllerState{
+					Replicas:        2,
+					ReplicaSelector: map[string]string{"a": "b"},
+					PodTemplate:     validPodTemplate,
+				},
+			}
+		},
+		Invalid: []runtime.Object{
+			&api.ReplicationController{
+				JSONBase: api.JSONBase{ID: "foo"},
+				DesiredState: api.ReplicationControllerState{
+					Replicas:    2,
+					PodTemplate: validPodTemplate,
+				},
+			},
+		},
+		GeneratesIDs: true,
+	}
+	tester.Run(t)
+}
@@ -15,5 +15,5 @@ limitations under the License.
 */
 
 // Package etcd provides etcd backend implementation for storing
-// PodRegistry, ControllerRegistry and ServiceRegistry api objects.
+// PodRegistry and ServiceRegistry api objects.
 package etcd
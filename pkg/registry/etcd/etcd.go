@@ -26,6 +26,7 @@ import (
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/tools"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
 
 	"github.com/golang/glog"
@@ -34,20 +35,23 @@ import (
 // TODO: Need to add a reconciler loop that makes sure that things in pods are reflected into
 //       kubelet (and vice versa)
 
-// Registry implements PodRegistry, ControllerRegistry and ServiceRegistry
-// with backed by etcd.
+// Registry implements PodRegistry and ServiceRegistry with backed by etcd.
 type Registry struct {
 	tools.EtcdHelper
 	manifestFactory ManifestFactory
+	// caches maps list keys to in-memory caches of them, if EnableCache
+	// was called.
+	caches map[string]*tools.EtcdCache
 }
 
-// NewRegistry creates an etcd registry.
-func NewRegistry(client tools.EtcdClient) *Registry {
+// NewRegistry creates an etcd registry, which keeps its keys under pathPrefix.
+func NewRegistry(client tools.EtcdClient, pathPrefix string) *Registry {
 	registry := &Registry{
 		EtcdHelper: tools.EtcdHelper{
-			client,
-			latest.Codec,
-			latest.ResourceVersioner,
+			Client:            client,
+			Codec:             latest.Codec,
+			ResourceVersioner: latest.ResourceVersioner,
+			PathPrefix:        pathPrefix,
 		},
 	}
 	registry.manifestFactory = &BasicManifestFactory{
@@ -56,6 +60,40 @@ func NewRegistry(client tools.EtcdClient) *Registry {
 	return registry
 }
 
+// EnableCache serves lists and gets of pods, controllers, services and
+// endpoints from memory, kept up to date by watches of etcd, rather than
+// reading etcd every time. Reads may lag writes slightly. It must be called
+// before the registry is used.
+func (r *Registry) EnableCache() {
+	r.caches = map[string]*tools.EtcdCache{}
+	for _, key := range []string{"/registry/pods", "/registry/controllers", "/registry/services/specs", "/registry/services/endpoints"} {
+		cache := tools.NewEtcdCache(r.EtcdHelper, key)
+		cache.Run()
+		r.caches[key] = cache
+	}
+}
+
+// Cache returns the cache of key, or nil if there isn't one.
+func (r *Registry) Cache(key string) *tools.EtcdCache {
+	return r.caches[key]
+}
+
+// extractList is ExtractList, served from key's cache if there is one.
+func (r *Registry) extractList(key string, slicePtr interface{}, resourceVersion *uint64) error {
+	if cache, ok := r.caches[key]; ok {
+		return cache.ExtractList(slicePtr, resourceVersion)
+	}
+	return r.ExtractList(key, slicePtr, resourceVersion)
+}
+
+// extractObj is ExtractObj of key/id, served from key's cache if there is one.
+func (r *Registry) extractObj(key, id string, objPtr runtime.Object) error {
+	if cache, ok := r.caches[key]; ok {
+		return cache.ExtractObj(id, objPtr)
+	}
+	return r.ExtractObj(key+"/"+id, objPtr, false)
+}
+
 func makePodKey(podID string) string {
 	return "/registry/pods/" + podID
 }
@@ -70,7 +108,7 @@ func (r *Registry) ListPods(selector labels.Selector) (*api.PodList, error) {
 // ListPodsPredicate obtains a list of pods that match filter.
 func (r *Registry) ListPodsPredicate(filter func(*api.Pod) bool) (*api.PodList, error) {
 	allPods := api.PodList{}
-	err := r.ExtractList("/registry/pods", &allPods.Items, &allPods.ResourceVersion)
+	err := r.extractList("/registry/pods", &allPods.Items, &allPods.ResourceVersion)
 	if err != nil {
 		return nil, err
 	}
@@ -103,7 +141,7 @@ func (r *Registry) WatchPods(resourceVersion uint64, filter func(*api.Pod) bool)
 // GetPod gets a specific pod specified by its ID.
 func (r *Registry) GetPod(podID string) (*api.Pod, error) {
 	var pod api.Pod
-	if err := r.ExtractObj(makePodKey(podID), &pod, false); err != nil {
+	if err := r.extractObj("/registry/pods", podID, &pod); err != nil {
 		return nil, etcderr.InterpretGetError(err, "pod", podID)
 	}
 	// TODO: Currently nothing sets CurrentState.Host. We need a feedback loop that sets
@@ -147,6 +185,11 @@ func (r *Registry) setPodHostTo(podID, oldMachine, machine string) (finalPod *ap
 			return nil, fmt.Errorf("pod %v is already assigned to host %v", pod.ID, pod.DesiredState.Host)
 		}
 		pod.DesiredState.Host = machine
+		if machine != "" {
+			pod.CurrentState.ScheduledAt = util.Now()
+		} else {
+			pod.CurrentState.ScheduledAt = util.Time{}
+		}
 		finalPod = pod
 		return pod, nil
 	})
@@ -183,12 +226,75 @@ func (r *Registry) assignPod(podID string, machine string) error {
 	return err
 }
 
+// UpdatePod replaces an existing pod, and the manifest of the machine it's
+// bound to. The pod's host can only be changed by a binding.
 func (r *Registry) UpdatePod(pod *api.Pod) error {
-	return fmt.Errorf("unimplemented!")
+	podKey := makePodKey(pod.ID)
+	var current api.Pod
+	if err := r.ExtractObj(podKey, &current, false); err != nil {
+		return etcderr.InterpretUpdateError(err, "pod", pod.ID)
+	}
+	pod.DesiredState.Host = current.DesiredState.Host
+	pod.CurrentState = current.CurrentState
+	if err := r.UpdateObj(podKey, pod); err != nil {
+		return etcderr.InterpretUpdateError(err, "pod", pod.ID)
+	}
+	machine := pod.DesiredState.Host
+	if machine == "" {
+		return nil
+	}
+	// TODO: move this to a watch/rectification loop.
+	manifest, err := r.manifestFactory.MakeManifest(machine, *pod)
+	if err != nil {
+		return err
+	}
+	contKey := makeContainerKey(machine)
+	err = r.AtomicUpdate(contKey, &api.ContainerManifestList{}, func(in runtime.Object) (runtime.Object, error) {
+		manifests := *in.(*api.ContainerManifestList)
+		found := false
+		for i := range manifests.Items {
+			if manifests.Items[i].ID == pod.ID {
+				manifests.Items[i] = manifest
+				found = true
+			}
+		}
+		if !found {
+			manifests.Items = append(manifests.Items, manifest)
+		}
+		if !constraint.Allowed(manifests.Items) {
+			return nil, fmt.Errorf("The update would cause a constraint violation")
+		}
+		return &manifests, nil
+	})
+	if err != nil {
+		// Put the pod back the way it was, as assignPod does, so that it keeps
+		// matching its host's manifest.
+		if err2 := r.restorePod(podKey, machine, &current); err2 != nil {
+			glog.Errorf("Pod %v no longer matches its manifest; couldn't restore it after previous error: %v", pod.ID, err2)
+		}
+	}
+	return err
 }
 
-// DeletePod deletes an existing pod specified by its ID.
-func (r *Registry) DeletePod(podID string) error {
+// restorePod puts back a pod's previous state, unless it has since been bound
+// away from machine.
+func (r *Registry) restorePod(podKey, machine string, previous *api.Pod) error {
+	return r.AtomicUpdate(podKey, &api.Pod{}, func(obj runtime.Object) (runtime.Object, error) {
+		pod, ok := obj.(*api.Pod)
+		if !ok {
+			return nil, fmt.Errorf("unexpected object: %#v", obj)
+		}
+		if pod.DesiredState.Host != machine {
+			return nil, fmt.Errorf("pod %v has been assigned to host %v", pod.ID, pod.DesiredState.Host)
+		}
+		restored := *previous
+		return &restored, nil
+	})
+}
+
+// DeletePod deletes an existing pod specified by its ID. If resourceVersion
+// is non-zero, the pod is only deleted if it is still at that version.
+func (r *Registry) DeletePod(podID string, resourceVersion uint64) error {
 	var pod api.Pod
 	podKey := makePodKey(podID)
 	err := r.ExtractObj(podKey, &pod, false)
@@ -197,7 +303,7 @@ func (r *Registry) DeletePod(podID string) error {
 	}
 	// First delete the pod, so a scheduler doesn't notice it getting removed from the
 	// machine and attempt to put it somewhere.
-	err = r.Delete(podKey, true)
+	err = r.DeleteIfVersion(podKey, resourceVersion)
 	if err != nil {
 		return etcderr.InterpretDeleteError(err, "pod", podID)
 	}
@@ -230,52 +336,6 @@ func (r *Registry) DeletePod(podID string) error {
 	})
 }
 
-// ListControllers obtains a list of ReplicationControllers.
-func (r *Registry) ListControllers() (*api.ReplicationControllerList, error) {
-	controllers := &api.ReplicationControllerList{}
-	err := r.ExtractList("/registry/controllers", &controllers.Items, &controllers.ResourceVersion)
-	return controllers, err
-}
-
-// WatchControllers begins watching for new, changed, or deleted controllers.
-func (r *Registry) WatchControllers(resourceVersion uint64) (watch.Interface, error) {
-	return r.WatchList("/registry/controllers", resourceVersion, tools.Everything)
-}
-
-func makeControllerKey(id string) string {
-	return "/registry/controllers/" + id
-}
-
-// GetController gets a specific ReplicationController specified by its ID.
-func (r *Registry) GetController(controllerID string) (*api.ReplicationController, error) {
-	var controller api.ReplicationController
-	key := makeControllerKey(controllerID)
-	err := r.ExtractObj(key, &controller, false)
-	if err != nil {
-		return nil, etcderr.InterpretGetError(err, "replicationController", controllerID)
-	}
-	return &controller, nil
-}
-
-// CreateController creates a new ReplicationController.
-func (r *Registry) CreateController(controller *api.ReplicationController) error {
-	err := r.CreateObj(makeControllerKey(controller.ID), controller)
-	return etcderr.InterpretCreateError(err, "replicationController", controller.ID)
-}
-
-// UpdateController replaces an existing ReplicationController.
-func (r *Registry) UpdateController(controller *api.ReplicationController) error {
-	err := r.SetObj(makeControllerKey(controller.ID), controller)
-	return etcderr.InterpretUpdateError(err, "replicationController", controller.ID)
-}
-
-// DeleteController deletes a ReplicationController specified by its ID.
-func (r *Registry) DeleteController(controllerID string) error {
-	key := makeControllerKey(controllerID)
-	err := r.Delete(key, false)
-	return etcderr.InterpretDeleteError(err, "replicationController", controllerID)
-}
-
 func makeServiceKey(name string) string {
 	return "/registry/services/specs/" + name
 }
@@ -283,7 +343,7 @@ func makeServiceKey(name string) string {
 // ListServices obtains a list of Services.
 func (r *Registry) ListServices() (*api.ServiceList, error) {
 	list := &api.ServiceList{}
-	err := r.ExtractList("/registry/services/specs", &list.Items, &list.ResourceVersion)
+	err := r.extractList("/registry/services/specs", &list.Items, &list.ResourceVersion)
 	return list, err
 }
 
@@ -295,9 +355,8 @@ func (r *Registry) CreateService(svc *api.Service) error {
 
 // GetService obtains a Service specified by its name.
 func (r *Registry) GetService(name string) (*api.Service, error) {
-	key := makeServiceKey(name)
 	var svc api.Service
-	err := r.ExtractObj(key, &svc, false)
+	err := r.extractObj("/registry/services/specs", name, &svc)
 	if err != nil {
 		return nil, etcderr.InterpretGetError(err, "service", name)
 	}
@@ -306,9 +365,8 @@ func (r *Registry) GetService(name string) (*api.Service, error) {
 
 // GetEndpoints obtains the endpoints for the service identified by 'name'.
 func (r *Registry) GetEndpoints(name string) (*api.Endpoints, error) {
-	key := makeServiceEndpointsKey(name)
 	var endpoints api.Endpoints
-	err := r.ExtractObj(key, &endpoints, false)
+	err := r.extractObj("/registry/services/endpoints", name, &endpoints)
 	if err != nil {
 		return nil, etcderr.InterpretGetError(err, "endpoints", name)
 	}
@@ -319,10 +377,11 @@ func makeServiceEndpointsKey(name string) string {
 	return "/registry/services/endpoints/" + name
 }
 
-// DeleteService deletes a Service specified by its name.
-func (r *Registry) DeleteService(name string) error {
+// DeleteService deletes a Service specified by its name. If resourceVersion
+// is non-zero, it is only deleted if still at that version.
+func (r *Registry) DeleteService(name string, resourceVersion uint64) error {
 	key := makeServiceKey(name)
-	err := r.Delete(key, true)
+	err := r.DeleteIfVersion(key, resourceVersion)
 	if err != nil {
 		return etcderr.InterpretDeleteError(err, "service", name)
 	}
@@ -338,28 +397,55 @@ func (r *Registry) DeleteService(name string) error {
 
 // UpdateService replaces an existing Service.
 func (r *Registry) UpdateService(svc *api.Service) error {
-	err := r.SetObj(makeServiceKey(svc.ID), svc)
+	err := r.UpdateObj(makeServiceKey(svc.ID), svc)
 	return etcderr.InterpretUpdateError(err, "service", svc.ID)
 }
 
-// WatchServices begins watching for new, changed, or deleted service configurations.
+func makePortalIPKey(ip string) string {
+	return "/registry/services/portalips/" + ip
+}
+
+// AllocatePortalIP records that the named service uses a portal IP, unless
+// another service already does.
+func (r *Registry) AllocatePortalIP(ip, service string) error {
+	_, err := r.Client.Create(r.PathPrefix+makePortalIPKey(ip), service, 0)
+	return etcderr.InterpretCreateError(err, "portalIP", ip)
+}
+
+// ReleasePortalIP records that a portal IP is no longer in use.
+func (r *Registry) ReleasePortalIP(ip string) error {
+	err := r.Delete(makePortalIPKey(ip), false)
+	if err != nil && !tools.IsEtcdNotFound(err) {
+		return etcderr.InterpretDeleteError(err, "portalIP", ip)
+	}
+	return nil
+}
+
+// WatchServices begins watching for new, changed, or deleted service configurations
+// matching label and field. Services which start or stop matching are reported
+// as added or deleted.
 func (r *Registry) WatchServices(label, field labels.Selector, resourceVersion uint64) (watch.Interface, error) {
-	if !label.Empty() {
-		return nil, fmt.Errorf("label selectors are not supported on services")
+	value, found := field.RequiresExactMatch("ID")
+	if !found && !field.Empty() {
+		return nil, fmt.Errorf("only the 'ID' and default (everything) field selectors are supported")
 	}
-	if value, found := field.RequiresExactMatch("ID"); found {
+	if found && label.Empty() {
 		return r.Watch(makeServiceKey(value), resourceVersion)
 	}
-	if field.Empty() {
-		return r.WatchList("/registry/services/specs", resourceVersion, tools.Everything)
-	}
-	return nil, fmt.Errorf("only the 'ID' and default (everything) field selectors are supported")
+	return r.WatchList("/registry/services/specs", resourceVersion, func(obj runtime.Object) bool {
+		service, ok := obj.(*api.Service)
+		if !ok {
+			glog.Errorf("Unexpected object during service watch: %#v", obj)
+			return false
+		}
+		return label.Matches(labels.Set(service.Labels)) && field.Matches(labels.Set{"ID": service.ID})
+	})
 }
 
 // ListEndpoints obtains a list of Services.
 func (r *Registry) ListEndpoints() (*api.EndpointsList, error) {
 	list := &api.EndpointsList{}
-	err := r.ExtractList("/registry/services/endpoints", &list.Items, &list.ResourceVersion)
+	err := r.extractList("/registry/services/endpoints", &list.Items, &list.ResourceVersion)
 	return list, err
 }
 
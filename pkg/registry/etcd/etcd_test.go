@@ -17,6 +17,7 @@ limitations under the License.
 package etcd
 
 import (
+	"fmt"
 	"reflect"
 	"testing"
 
@@ -27,12 +28,13 @@ import (
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/registry/registrytest"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/tools"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
 
 	"github.com/coreos/go-etcd/etcd"
 )
 
 func NewTestEtcdRegistry(client tools.EtcdClient) *Registry {
-	registry := NewRegistry(client)
+	registry := NewRegistry(client, "")
 	registry.manifestFactory = &BasicManifestFactory{
 		serviceRegistry: &registrytest.ServiceRegistry{},
 	}
@@ -116,6 +118,9 @@ func TestEtcdCreatePod(t *testing.T) {
 	if pod.ID != "foo" {
 		t.Errorf("Unexpected pod: %#v %s", pod, resp.Node.Value)
 	}
+	if pod.CurrentState.ScheduledAt.IsZero() {
+		t.Errorf("Expected scheduledAt to be set: %#v", pod.CurrentState)
+	}
 	var manifests api.ContainerManifestList
 	resp, err = fakeClient.Get("/registry/hosts/machine/kubelet", false, false)
 	if err != nil {
@@ -320,6 +325,114 @@ func TestEtcdCreatePodWithExistingContainers(t *testing.T) {
 	}
 }
 
+func TestEtcdUpdatePod(t *testing.T) {
+	fakeClient := tools.NewFakeEtcdClient(t)
+	fakeClient.TestIndex = true
+	resp, _ := fakeClient.Set("/registry/pods/foo", runtime.EncodeOrDie(latest.Codec, &api.Pod{
+		JSONBase:     api.JSONBase{ID: "foo"},
+		DesiredState: api.PodState{Host: "machine"},
+	}), 0)
+	fakeClient.Set("/registry/hosts/machine/kubelet", runtime.EncodeOrDie(latest.Codec, &api.ContainerManifestList{
+		Items: []api.ContainerManifest{{ID: "foo"}, {ID: "bar"}},
+	}), 0)
+	registry := NewTestEtcdRegistry(fakeClient)
+	err := registry.UpdatePod(&api.Pod{
+		JSONBase: api.JSONBase{ID: "foo", ResourceVersion: resp.Node.ModifiedIndex},
+		DesiredState: api.PodState{
+			Host: "other",
+			Manifest: api.ContainerManifest{
+				Containers: []api.Container{{Name: "baz"}},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pod, err := registry.GetPod("foo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pod.DesiredState.Host != "machine" || len(pod.DesiredState.Manifest.Containers) != 1 {
+		t.Errorf("Unexpected pod: %#v", pod)
+	}
+
+	var manifests api.ContainerManifestList
+	resp, err = fakeClient.Get("/registry/hosts/machine/kubelet", false, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := latest.Codec.DecodeInto([]byte(resp.Node.Value), &manifests); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(manifests.Items) != 2 || manifests.Items[0].ID != "foo" || len(manifests.Items[0].Containers) != 1 || manifests.Items[1].ID != "bar" {
+		t.Errorf("Unexpected manifest list: %#v", manifests)
+	}
+}
+
+func TestEtcdUpdatePodConstraintViolation(t *testing.T) {
+	fakeClient := tools.NewFakeEtcdClient(t)
+	fakeClient.TestIndex = true
+	resp, _ := fakeClient.Set("/registry/pods/foo", runtime.EncodeOrDie(latest.Codec, &api.Pod{
+		JSONBase:     api.JSONBase{ID: "foo"},
+		DesiredState: api.PodState{Host: "machine"},
+	}), 0)
+	fakeClient.Set("/registry/hosts/machine/kubelet", runtime.EncodeOrDie(latest.Codec, &api.ContainerManifestList{
+		Items: []api.ContainerManifest{
+			{ID: "foo"},
+			{ID: "bar", Containers: []api.Container{{Name: "bar", Ports: []api.Port{{HostPort: 80}}}}},
+		},
+	}), 0)
+	registry := NewTestEtcdRegistry(fakeClient)
+	err := registry.UpdatePod(&api.Pod{
+		JSONBase: api.JSONBase{ID: "foo", ResourceVersion: resp.Node.ModifiedIndex},
+		DesiredState: api.PodState{
+			Manifest: api.ContainerManifest{
+				Containers: []api.Container{{Name: "baz", Ports: []api.Port{{HostPort: 80}}}},
+			},
+		},
+	})
+	if err == nil {
+		t.Fatalf("Expected a constraint violation")
+	}
+
+	// The pod is put back the way it was, matching the manifest.
+	pod, err := registry.GetPod("foo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pod.DesiredState.Host != "machine" || len(pod.DesiredState.Manifest.Containers) != 0 {
+		t.Errorf("Unexpected pod: %#v", pod)
+	}
+}
+
+func TestEtcdUpdatePodConflict(t *testing.T) {
+	fakeClient := tools.NewFakeEtcdClient(t)
+	fakeClient.TestIndex = true
+	resp, _ := fakeClient.Set("/registry/pods/foo", runtime.EncodeOrDie(latest.Codec, &api.Pod{JSONBase: api.JSONBase{ID: "foo"}}), 0)
+	registry := NewTestEtcdRegistry(fakeClient)
+	err := registry.UpdatePod(&api.Pod{
+		JSONBase: api.JSONBase{ID: "foo", ResourceVersion: resp.Node.ModifiedIndex + 1},
+	})
+	if !errors.IsConflict(err) {
+		t.Errorf("Expected a conflict error, got %#v", err)
+	}
+}
+
+func TestEtcdUpdatePodNotFound(t *testing.T) {
+	fakeClient := tools.NewFakeEtcdClient(t)
+	fakeClient.TestIndex = true
+	fakeClient.Data["/registry/pods/foo"] = tools.EtcdResponseWithError{
+		R: &etcd.Response{},
+		E: tools.EtcdErrorNotFound,
+	}
+	registry := NewTestEtcdRegistry(fakeClient)
+	err := registry.UpdatePod(&api.Pod{JSONBase: api.JSONBase{ID: "foo", ResourceVersion: 1}})
+	if !errors.IsNotFound(err) {
+		t.Errorf("Expected a not found error, got %#v", err)
+	}
+}
+
 func TestEtcdDeletePod(t *testing.T) {
 	fakeClient := tools.NewFakeEtcdClient(t)
 	fakeClient.TestIndex = true
@@ -335,7 +448,7 @@ func TestEtcdDeletePod(t *testing.T) {
 		},
 	}), 0)
 	registry := NewTestEtcdRegistry(fakeClient)
-	err := registry.DeletePod("foo")
+	err := registry.DeletePod("foo", 0)
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
@@ -372,7 +485,7 @@ func TestEtcdDeletePodMultipleContainers(t *testing.T) {
 		},
 	}), 0)
 	registry := NewTestEtcdRegistry(fakeClient)
-	err := registry.DeletePod("foo")
+	err := registry.DeletePod("foo", 0)
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
@@ -476,24 +589,6 @@ func TestEtcdListPods(t *testing.T) {
 	}
 }
 
-func TestEtcdListControllersNotFound(t *testing.T) {
-	fakeClient := tools.NewFakeEtcdClient(t)
-	key := "/registry/controllers"
-	fakeClient.Data[key] = tools.EtcdResponseWithError{
-		R: &etcd.Response{},
-		E: tools.EtcdErrorNotFound,
-	}
-	registry := NewTestEtcdRegistry(fakeClient)
-	controllers, err := registry.ListControllers()
-	if err != nil {
-		t.Errorf("unexpected error: %v", err)
-	}
-
-	if len(controllers.Items) != 0 {
-		t.Errorf("Unexpected controller list: %#v", controllers)
-	}
-}
-
 func TestEtcdListServicesNotFound(t *testing.T) {
 	fakeClient := tools.NewFakeEtcdClient(t)
 	key := "/registry/services/specs"
@@ -512,148 +607,6 @@ func TestEtcdListServicesNotFound(t *testing.T) {
 	}
 }
 
-func TestEtcdListControllers(t *testing.T) {
-	fakeClient := tools.NewFakeEtcdClient(t)
-	key := "/registry/controllers"
-	fakeClient.Data[key] = tools.EtcdResponseWithError{
-		R: &etcd.Response{
-			Node: &etcd.Node{
-				Nodes: []*etcd.Node{
-					{
-						Value: runtime.EncodeOrDie(latest.Codec, &api.ReplicationController{JSONBase: api.JSONBase{ID: "foo"}}),
-					},
-					{
-						Value: runtime.EncodeOrDie(latest.Codec, &api.ReplicationController{JSONBase: api.JSONBase{ID: "bar"}}),
-					},
-				},
-			},
-		},
-		E: nil,
-	}
-	registry := NewTestEtcdRegistry(fakeClient)
-	controllers, err := registry.ListControllers()
-	if err != nil {
-		t.Errorf("unexpected error: %v", err)
-	}
-
-	if len(controllers.Items) != 2 || controllers.Items[0].ID != "foo" || controllers.Items[1].ID != "bar" {
-		t.Errorf("Unexpected controller list: %#v", controllers)
-	}
-}
-
-func TestEtcdGetController(t *testing.T) {
-	fakeClient := tools.NewFakeEtcdClient(t)
-	fakeClient.Set("/registry/controllers/foo", runtime.EncodeOrDie(latest.Codec, &api.ReplicationController{JSONBase: api.JSONBase{ID: "foo"}}), 0)
-	registry := NewTestEtcdRegistry(fakeClient)
-	ctrl, err := registry.GetController("foo")
-	if err != nil {
-		t.Errorf("unexpected error: %v", err)
-	}
-
-	if ctrl.ID != "foo" {
-		t.Errorf("Unexpected controller: %#v", ctrl)
-	}
-}
-
-func TestEtcdGetControllerNotFound(t *testing.T) {
-	fakeClient := tools.NewFakeEtcdClient(t)
-	fakeClient.Data["/registry/controllers/foo"] = tools.EtcdResponseWithError{
-		R: &etcd.Response{
-			Node: nil,
-		},
-		E: tools.EtcdErrorNotFound,
-	}
-	registry := NewTestEtcdRegistry(fakeClient)
-	ctrl, err := registry.GetController("foo")
-	if ctrl != nil {
-		t.Errorf("Unexpected non-nil controller: %#v", ctrl)
-	}
-	if !errors.IsNotFound(err) {
-		t.Errorf("Unexpected error returned: %#v", err)
-	}
-}
-
-func TestEtcdDeleteController(t *testing.T) {
-	fakeClient := tools.NewFakeEtcdClient(t)
-	registry := NewTestEtcdRegistry(fakeClient)
-	err := registry.DeleteController("foo")
-	if err != nil {
-		t.Errorf("unexpected error: %v", err)
-	}
-
-	if len(fakeClient.DeletedKeys) != 1 {
-		t.Errorf("Expected 1 delete, found %#v", fakeClient.DeletedKeys)
-	}
-	key := "/registry/controllers/foo"
-	if fakeClient.DeletedKeys[0] != key {
-		t.Errorf("Unexpected key: %s, expected %s", fakeClient.DeletedKeys[0], key)
-	}
-}
-
-func TestEtcdCreateController(t *testing.T) {
-	fakeClient := tools.NewFakeEtcdClient(t)
-	registry := NewTestEtcdRegistry(fakeClient)
-	err := registry.CreateController(&api.ReplicationController{
-		JSONBase: api.JSONBase{
-			ID: "foo",
-		},
-	})
-	if err != nil {
-		t.Errorf("unexpected error: %v", err)
-	}
-
-	resp, err := fakeClient.Get("/registry/controllers/foo", false, false)
-	if err != nil {
-		t.Fatalf("Unexpected error %v", err)
-	}
-	var ctrl api.ReplicationController
-	err = latest.Codec.DecodeInto([]byte(resp.Node.Value), &ctrl)
-	if err != nil {
-		t.Errorf("unexpected error: %v", err)
-	}
-
-	if ctrl.ID != "foo" {
-		t.Errorf("Unexpected pod: %#v %s", ctrl, resp.Node.Value)
-	}
-}
-
-func TestEtcdCreateControllerAlreadyExisting(t *testing.T) {
-	fakeClient := tools.NewFakeEtcdClient(t)
-	fakeClient.Set("/registry/controllers/foo", runtime.EncodeOrDie(latest.Codec, &api.ReplicationController{JSONBase: api.JSONBase{ID: "foo"}}), 0)
-
-	registry := NewTestEtcdRegistry(fakeClient)
-	err := registry.CreateController(&api.ReplicationController{
-		JSONBase: api.JSONBase{
-			ID: "foo",
-		},
-	})
-	if !errors.IsAlreadyExists(err) {
-		t.Errorf("expected already exists err, got %#v", err)
-	}
-}
-
-func TestEtcdUpdateController(t *testing.T) {
-	fakeClient := tools.NewFakeEtcdClient(t)
-	fakeClient.TestIndex = true
-
-	resp, _ := fakeClient.Set("/registry/controllers/foo", runtime.EncodeOrDie(latest.Codec, &api.ReplicationController{JSONBase: api.JSONBase{ID: "foo"}}), 0)
-	registry := NewTestEtcdRegistry(fakeClient)
-	err := registry.UpdateController(&api.ReplicationController{
-		JSONBase: api.JSONBase{ID: "foo", ResourceVersion: resp.Node.ModifiedIndex},
-		DesiredState: api.ReplicationControllerState{
-			Replicas: 2,
-		},
-	})
-	if err != nil {
-		t.Errorf("unexpected error: %v", err)
-	}
-
-	ctrl, err := registry.GetController("foo")
-	if ctrl.DesiredState.Replicas != 2 {
-		t.Errorf("Unexpected controller: %#v", ctrl)
-	}
-}
-
 func TestEtcdListServices(t *testing.T) {
 	fakeClient := tools.NewFakeEtcdClient(t)
 	key := "/registry/services/specs"
@@ -721,6 +674,28 @@ func TestEtcdCreateServiceAlreadyExisting(t *testing.T) {
 	}
 }
 
+func TestEtcdAllocatePortalIP(t *testing.T) {
+	fakeClient := tools.NewFakeEtcdClient(t)
+	registry := NewTestEtcdRegistry(fakeClient)
+	if err := registry.AllocatePortalIP("10.0.0.1", "foo"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp, err := fakeClient.Get("/registry/services/portalips/10.0.0.1", false, false)
+	if err != nil || resp.Node.Value != "foo" {
+		t.Errorf("expected the IP to be recorded for foo, got %#v, %v", resp, err)
+	}
+	if err := registry.AllocatePortalIP("10.0.0.1", "bar"); !errors.IsAlreadyExists(err) {
+		t.Errorf("expected already exists err, got %#v", err)
+	}
+
+	if err := registry.ReleasePortalIP("10.0.0.1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := registry.AllocatePortalIP("10.0.0.1", "bar"); err != nil {
+		t.Errorf("unexpected error reallocating a released IP: %v", err)
+	}
+}
+
 func TestEtcdGetService(t *testing.T) {
 	fakeClient := tools.NewFakeEtcdClient(t)
 	fakeClient.Set("/registry/services/specs/foo", runtime.EncodeOrDie(latest.Codec, &api.Service{JSONBase: api.JSONBase{ID: "foo"}}), 0)
@@ -753,7 +728,7 @@ func TestEtcdGetServiceNotFound(t *testing.T) {
 func TestEtcdDeleteService(t *testing.T) {
 	fakeClient := tools.NewFakeEtcdClient(t)
 	registry := NewTestEtcdRegistry(fakeClient)
-	err := registry.DeleteService("foo")
+	err := registry.DeleteService("foo", 0)
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
@@ -785,6 +760,8 @@ func TestEtcdUpdateService(t *testing.T) {
 		Selector: map[string]string{
 			"baz": "bar",
 		},
+		Protocol:        api.ProtocolTCP,
+		SessionAffinity: api.AffinityTypeNone,
 	}
 	err := registry.UpdateService(&testService)
 	if err != nil {
@@ -804,6 +781,35 @@ func TestEtcdUpdateService(t *testing.T) {
 	}
 }
 
+func TestEtcdUpdateServiceConflict(t *testing.T) {
+	fakeClient := tools.NewFakeEtcdClient(t)
+	fakeClient.TestIndex = true
+
+	resp, _ := fakeClient.Set("/registry/services/specs/foo", runtime.EncodeOrDie(latest.Codec, &api.Service{JSONBase: api.JSONBase{ID: "foo"}}), 0)
+	registry := NewTestEtcdRegistry(fakeClient)
+	fakeClient.Set("/registry/services/specs/foo", runtime.EncodeOrDie(latest.Codec, &api.Service{JSONBase: api.JSONBase{ID: "foo"}}), 0)
+	err := registry.UpdateService(&api.Service{
+		JSONBase: api.JSONBase{ID: "foo", ResourceVersion: resp.Node.ModifiedIndex},
+	})
+	if !errors.IsConflict(err) {
+		t.Errorf("Expected a conflict error, got %#v", err)
+	}
+}
+
+func TestEtcdUpdateServiceNotFound(t *testing.T) {
+	fakeClient := tools.NewFakeEtcdClient(t)
+	fakeClient.TestIndex = true
+	fakeClient.Data["/registry/services/specs/foo"] = tools.EtcdResponseWithError{
+		R: &etcd.Response{},
+		E: tools.EtcdErrorNotFound,
+	}
+	registry := NewTestEtcdRegistry(fakeClient)
+	err := registry.UpdateService(&api.Service{JSONBase: api.JSONBase{ID: "foo", ResourceVersion: 1}})
+	if !errors.IsNotFound(err) {
+		t.Errorf("Expected a not found error, got %#v", err)
+	}
+}
+
 func TestEtcdListEndpoints(t *testing.T) {
 	fakeClient := tools.NewFakeEtcdClient(t)
 	key := "/registry/services/endpoints"
@@ -918,15 +924,67 @@ func TestEtcdWatchServicesBadSelector(t *testing.T) {
 	if err == nil {
 		t.Errorf("unexpected non-error: %v", err)
 	}
+}
 
-	_, err = registry.WatchServices(
-		labels.SelectorFromSet(labels.Set{"Label.Selector": "foo"}),
-		labels.Everything(),
-		0,
+// sendLabelChanges sends a watch on fakeClient an object which doesn't match the
+// label name=foo, then changes it to match and back again. Watches selecting
+// name=foo should see it added and then deleted.
+func sendLabelChanges(t *testing.T, fakeClient *tools.FakeEtcdClient, matching, other runtime.Object, watching watch.Interface) {
+	fakeClient.WatchResponse <- &etcd.Response{
+		Action: "create",
+		Node:   &etcd.Node{Value: runtime.EncodeOrDie(latest.Codec, other), CreatedIndex: 2, ModifiedIndex: 2},
+	}
+	changes := []struct {
+		from, to  runtime.Object
+		eventType watch.EventType
+	}{
+		{other, matching, watch.Added},
+		{matching, other, watch.Deleted},
+	}
+	for i, change := range changes {
+		index := uint64(i + 3)
+		fakeClient.WatchResponse <- &etcd.Response{
+			Action:   "set",
+			Node:     &etcd.Node{Value: runtime.EncodeOrDie(latest.Codec, change.to), CreatedIndex: 2, ModifiedIndex: index},
+			PrevNode: &etcd.Node{Value: runtime.EncodeOrDie(latest.Codec, change.from), CreatedIndex: 2, ModifiedIndex: index - 1},
+		}
+		event, ok := <-watching.ResultChan()
+		if !ok {
+			t.Fatalf("unexpected close")
+		}
+		if e, a := change.eventType, event.Type; e != a {
+			t.Errorf("expected %v, got %v", e, a)
+		}
+		// The deleted object is the last one which matched, at the version
+		// which stopped it matching.
+		expected, err := latest.Codec.Decode([]byte(runtime.EncodeOrDie(latest.Codec, matching)))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		latest.ResourceVersioner.SetResourceVersion(expected, index)
+		if !reflect.DeepEqual(expected, event.Object) {
+			t.Errorf("expected %#v, got %#v", expected, event.Object)
+		}
+	}
+	watching.Stop()
+}
+
+func TestEtcdWatchServicesLabels(t *testing.T) {
+	fakeClient := tools.NewFakeEtcdClient(t)
+	registry := NewTestEtcdRegistry(fakeClient)
+	watching, err := registry.WatchServices(
+		labels.SelectorFromSet(labels.Set{"name": "foo"}),
+		labels.SelectorFromSet(labels.Set{"ID": "foo"}),
+		1,
 	)
-	if err == nil {
-		t.Errorf("unexpected non-error: %v", err)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
 	}
+	fakeClient.WaitForWatchCompletion()
+	sendLabelChanges(t, fakeClient,
+		&api.Service{JSONBase: api.JSONBase{ID: "foo"}, Labels: map[string]string{"name": "foo"}},
+		&api.Service{JSONBase: api.JSONBase{ID: "foo"}, Labels: map[string]string{"name": "bar"}},
+		watching)
 }
 
 func TestEtcdWatchEndpoints(t *testing.T) {
@@ -992,3 +1050,49 @@ func TestEtcdWatchEndpointsBadSelector(t *testing.T) {
 //         Update
 //   In the buggy case, this will result in lost data.  In the correct case, the second update should fail
 //   and be retried.
+
+// quietLogger drops the per-request logging of FakeEtcdClient, which would
+// otherwise swamp the benchmark output.
+type quietLogger struct {
+	*testing.B
+}
+
+func (quietLogger) Logf(format string, args ...interface{}) {}
+
+func benchmarkEtcdListPods(b *testing.B, count int) {
+	fakeClient := tools.NewFakeEtcdClient(quietLogger{b})
+	nodes := make([]*etcd.Node, count)
+	for i := range nodes {
+		nodes[i] = &etcd.Node{
+			Value: runtime.EncodeOrDie(latest.Codec, &api.Pod{
+				JSONBase:     api.JSONBase{ID: fmt.Sprintf("pod-%d", i)},
+				Labels:       map[string]string{"name": "foo"},
+				DesiredState: api.PodState{Host: fmt.Sprintf("machine-%d", i%100)},
+			}),
+		}
+	}
+	fakeClient.Data["/registry/pods"] = tools.EtcdResponseWithError{
+		R: &etcd.Response{
+			Node: &etcd.Node{
+				Nodes: nodes,
+			},
+		},
+		E: nil,
+	}
+	registry := NewTestEtcdRegistry(fakeClient)
+	selector := labels.Set{"name": "foo"}.AsSelector()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pods, err := registry.ListPods(selector)
+		if err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+		if len(pods.Items) != count {
+			b.Fatalf("expected %d pods, got %d", count, len(pods.Items))
+		}
+	}
+}
+
+func BenchmarkEtcdListPods1000(b *testing.B) { benchmarkEtcdListPods(b, 1000) }
+func BenchmarkEtcdListPods5000(b *testing.B) { benchmarkEtcdListPods(b, 5000) }
@@ -0,0 +1,257 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package generic
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	etcderr "github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors/etcd"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/apiserver"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/tools"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
+)
+
+// Etcd implements apiserver.RESTStorage, apiserver.VersionedDeleter and
+// apiserver.ResourceWatcher for one kind of object kept in etcd. Updates
+// and conditional deletes compare resourceVersions, and errors are reported
+// as the API errors the other registries return.
+type Etcd struct {
+	// Kind names the resource in errors, e.g. "replicationController".
+	Kind string
+	// NewFunc returns an empty object of the resource.
+	NewFunc func() runtime.Object
+	// NewListFunc returns an empty list of the resource.
+	NewListFunc func() runtime.Object
+	// KeyRoot is the key under which every object is kept, e.g.
+	// "/registry/controllers".
+	KeyRoot string
+	// KeyFunc returns the key of the object with the given ID, which must
+	// be under KeyRoot. Nil means KeyRoot + "/" + id.
+	KeyFunc func(id string) string
+	// Labels returns an object's labels, which label selectors match.
+	Labels func(obj runtime.Object) labels.Set
+	// Fields returns the fields of an object which field selectors may
+	// match. Nil means field selectors are refused.
+	Fields func(obj runtime.Object) labels.Set
+	// BeforeCreate, if set, is called on objects before they're validated
+	// and created, to fill in defaults such as a generated ID.
+	BeforeCreate func(obj runtime.Object)
+	// Validate, if set, checks objects before they're created or updated.
+	Validate func(obj runtime.Object) errors.ErrorList
+
+	Helper tools.EtcdHelper
+	// Cache, if set, serves List and Get from memory rather than etcd. It
+	// must be a cache of KeyRoot.
+	Cache *tools.EtcdCache
+}
+
+func (e *Etcd) key(id string) string {
+	if e.KeyFunc != nil {
+		return e.KeyFunc(id)
+	}
+	return e.KeyRoot + "/" + id
+}
+
+// New returns an empty object of the resource.
+func (e *Etcd) New() runtime.Object {
+	return e.NewFunc()
+}
+
+// filter returns a func which matches objects against the selectors.
+func (e *Etcd) filter(label, field labels.Selector) (tools.FilterFunc, error) {
+	if !field.Empty() && e.Fields == nil {
+		return nil, fmt.Errorf("field selectors are not supported on %s", e.Kind)
+	}
+	return func(obj runtime.Object) bool {
+		if !label.Matches(e.Labels(obj)) {
+			return false
+		}
+		return field.Empty() || field.Matches(e.Fields(obj))
+	}, nil
+}
+
+// List returns a list of the objects matching the selectors.
+func (e *Etcd) List(label, field labels.Selector) (runtime.Object, error) {
+	filter, err := e.filter(label, field)
+	if err != nil {
+		return nil, err
+	}
+	list := e.NewListFunc()
+	items := reflect.ValueOf(list).Elem().FieldByName("Items")
+	if !items.IsValid() || items.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("list of %s has no Items: %#v", e.Kind, list)
+	}
+	jsonBase, err := runtime.FindJSONBase(list)
+	if err != nil {
+		return nil, err
+	}
+	var resourceVersion uint64
+	if e.Cache != nil {
+		err = e.Cache.ExtractList(items.Addr().Interface(), &resourceVersion)
+	} else {
+		err = e.Helper.ExtractList(e.KeyRoot, items.Addr().Interface(), &resourceVersion)
+	}
+	if err != nil {
+		return nil, err
+	}
+	jsonBase.SetResourceVersion(resourceVersion)
+	matching := reflect.MakeSlice(items.Type(), 0, items.Len())
+	for i := 0; i < items.Len(); i++ {
+		if filter(items.Index(i).Addr().Interface().(runtime.Object)) {
+			matching = reflect.Append(matching, items.Index(i))
+		}
+	}
+	items.Set(matching)
+	return list, nil
+}
+
+// Get returns the object with the given ID.
+func (e *Etcd) Get(id string) (runtime.Object, error) {
+	obj := e.NewFunc()
+	var err error
+	if e.Cache != nil {
+		err = e.Cache.ExtractObj(id, obj)
+	} else {
+		err = e.Helper.ExtractObj(e.key(id), obj, false)
+	}
+	if err != nil {
+		return nil, etcderr.InterpretGetError(err, e.Kind, id)
+	}
+	return obj, nil
+}
+
+// checkType returns an error if obj isn't an object of the resource.
+func (e *Etcd) checkType(obj runtime.Object) error {
+	if reflect.TypeOf(obj) != reflect.TypeOf(e.NewFunc()) {
+		return fmt.Errorf("not a %s: %#v", e.Kind, obj)
+	}
+	return nil
+}
+
+// validate returns obj's ID, or an invalid error if obj has no ID or fails
+// Validate.
+func (e *Etcd) validate(obj runtime.Object) (string, error) {
+	jsonBase, err := runtime.FindJSONBase(obj)
+	if err != nil {
+		return "", err
+	}
+	id := jsonBase.ID()
+	errs := errors.ErrorList{}
+	if len(id) == 0 {
+		errs = append(errs, errors.NewFieldRequired("id", id))
+	}
+	if e.Validate != nil {
+		errs = append(errs, e.Validate(obj)...)
+	}
+	if len(errs) > 0 {
+		return "", errors.NewInvalid(e.Kind, id, errs)
+	}
+	return id, nil
+}
+
+// Create creates the given object, which must not already exist.
+func (e *Etcd) Create(obj runtime.Object) (<-chan runtime.Object, error) {
+	if err := e.checkType(obj); err != nil {
+		return nil, err
+	}
+	if e.BeforeCreate != nil {
+		e.BeforeCreate(obj)
+	}
+	id, err := e.validate(obj)
+	if err != nil {
+		return nil, err
+	}
+	return apiserver.MakeAsync(func() (runtime.Object, error) {
+		if err := e.CreateObj(id, obj); err != nil {
+			return nil, err
+		}
+		// obj now carries the version it was stored at. Reading it back
+		// could return an older version from the cache.
+		return obj, nil
+	}), nil
+}
+
+// CreateObj stores obj under the given ID, which must not already be in
+// use, and sets obj's resourceVersion to the one it was stored at. Unlike
+// Create, it neither fills in nor validates obj.
+func (e *Etcd) CreateObj(id string, obj runtime.Object) error {
+	err := e.Helper.CreateObj(e.key(id), obj)
+	return etcderr.InterpretCreateError(err, e.Kind, id)
+}
+
+// Update replaces the given object, if it's still at the resourceVersion
+// the object carries.
+func (e *Etcd) Update(obj runtime.Object) (<-chan runtime.Object, error) {
+	if err := e.checkType(obj); err != nil {
+		return nil, err
+	}
+	id, err := e.validate(obj)
+	if err != nil {
+		return nil, err
+	}
+	return apiserver.MakeAsync(func() (runtime.Object, error) {
+		if err := e.UpdateObj(id, obj); err != nil {
+			return nil, err
+		}
+		return obj, nil
+	}), nil
+}
+
+// UpdateObj replaces the object with the given ID by obj, if it's still at
+// the resourceVersion obj carries, and sets obj's resourceVersion to the new
+// one. Unlike Update, it doesn't validate obj.
+func (e *Etcd) UpdateObj(id string, obj runtime.Object) error {
+	err := e.Helper.UpdateObj(e.key(id), obj)
+	return etcderr.InterpretUpdateError(err, e.Kind, id)
+}
+
+// Delete deletes the object with the given ID.
+func (e *Etcd) Delete(id string) (<-chan runtime.Object, error) {
+	return e.DeleteIfVersion(id, 0)
+}
+
+// DeleteIfVersion deletes the object with the given ID, if it's still at
+// resourceVersion. A zero resourceVersion deletes it whatever its version.
+func (e *Etcd) DeleteIfVersion(id string, resourceVersion uint64) (<-chan runtime.Object, error) {
+	return apiserver.MakeAsync(func() (runtime.Object, error) {
+		if err := e.DeleteObj(id, resourceVersion); err != nil {
+			return nil, err
+		}
+		return &api.Status{Status: api.StatusSuccess}, nil
+	}), nil
+}
+
+// DeleteObj deletes the object with the given ID, if it's still at
+// resourceVersion. A zero resourceVersion deletes it whatever its version.
+func (e *Etcd) DeleteObj(id string, resourceVersion uint64) error {
+	err := e.Helper.DeleteIfVersion(e.key(id), resourceVersion)
+	return etcderr.InterpretDeleteError(err, e.Kind, id)
+}
+
+// Watch watches the objects matching the selectors, from the change after
+// resourceVersion.
+func (e *Etcd) Watch(label, field labels.Selector, resourceVersion uint64) (watch.Interface, error) {
+	filter, err := e.filter(label, field)
+	if err != nil {
+		return nil, err
+	}
+	return e.Helper.WatchList(e.KeyRoot, resourceVersion, filter)
+}
@@ -0,0 +1,297 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package generic
+
+import (
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/latest"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/apiserver"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/registry/registrytest"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/tools"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
+	"github.com/coreos/go-etcd/etcd"
+)
+
+func newTestEtcd(client tools.EtcdClient) *Etcd {
+	return &Etcd{
+		Kind:        "replicationController",
+		NewFunc:     func() runtime.Object { return &api.ReplicationController{} },
+		NewListFunc: func() runtime.Object { return &api.ReplicationControllerList{} },
+		KeyRoot:     "/registry/controllers",
+		Labels: func(obj runtime.Object) labels.Set {
+			return labels.Set(obj.(*api.ReplicationController).Labels)
+		},
+		BeforeCreate: func(obj runtime.Object) {
+			controller := obj.(*api.ReplicationController)
+			if controller.ID == "generate" {
+				controller.ID = "generated"
+			}
+		},
+		Validate: func(obj runtime.Object) errors.ErrorList {
+			if obj.(*api.ReplicationController).DesiredState.Replicas < 0 {
+				return errors.ErrorList{errors.NewFieldInvalid("desiredState.replicas", -1)}
+			}
+			return nil
+		},
+		Helper: tools.EtcdHelper{
+			Client:            client,
+			Codec:             latest.Codec,
+			ResourceVersioner: latest.ResourceVersioner,
+		},
+	}
+}
+
+func newTestClient(t *testing.T) *tools.FakeEtcdClient {
+	fakeClient := tools.NewFakeEtcdClient(t)
+	fakeClient.TestIndex = true
+	return fakeClient
+}
+
+func controllerNode(id string, index uint64, labels map[string]string) *etcd.Node {
+	return &etcd.Node{
+		Key:           "/registry/controllers/" + id,
+		Value:         runtime.EncodeOrDie(latest.Codec, &api.ReplicationController{JSONBase: api.JSONBase{ID: id}, Labels: labels}),
+		CreatedIndex:  index,
+		ModifiedIndex: index,
+	}
+}
+
+func TestEtcdList(t *testing.T) {
+	fakeClient := newTestClient(t)
+	fakeClient.Data["/registry/controllers"] = tools.EtcdResponseWithError{
+		R: &etcd.Response{
+			EtcdIndex: 10,
+			Node: &etcd.Node{
+				Nodes: []*etcd.Node{
+					controllerNode("foo", 1, map[string]string{"name": "foo"}),
+					controllerNode("bar", 2, map[string]string{"name": "bar"}),
+				},
+			},
+		},
+	}
+	storage := newTestEtcd(fakeClient)
+
+	obj, err := storage.List(labels.SelectorFromSet(labels.Set{"name": "bar"}), labels.Everything())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	list := obj.(*api.ReplicationControllerList)
+	if list.ResourceVersion != 10 || len(list.Items) != 1 || list.Items[0].ID != "bar" || list.Items[0].ResourceVersion != 2 {
+		t.Errorf("unexpected list: %#v", list)
+	}
+
+	if _, err := storage.List(labels.Everything(), labels.SelectorFromSet(labels.Set{"ID": "foo"})); err == nil {
+		t.Errorf("expected field selectors to be refused")
+	}
+}
+
+func TestEtcdListEmpty(t *testing.T) {
+	fakeClient := newTestClient(t)
+	fakeClient.ExpectNotFoundGet("/registry/controllers")
+	storage := newTestEtcd(fakeClient)
+	obj, err := storage.List(labels.Everything(), labels.Everything())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if list := obj.(*api.ReplicationControllerList); len(list.Items) != 0 {
+		t.Errorf("unexpected list: %#v", list)
+	}
+}
+
+func TestEtcdFields(t *testing.T) {
+	fakeClient := newTestClient(t)
+	fakeClient.Data["/registry/controllers"] = tools.EtcdResponseWithError{
+		R: &etcd.Response{
+			Node: &etcd.Node{
+				Nodes: []*etcd.Node{
+					controllerNode("foo", 1, nil),
+					controllerNode("bar", 2, nil),
+				},
+			},
+		},
+	}
+	storage := newTestEtcd(fakeClient)
+	storage.Fields = func(obj runtime.Object) labels.Set {
+		return labels.Set{"ID": obj.(*api.ReplicationController).ID}
+	}
+	obj, err := storage.List(labels.Everything(), labels.SelectorFromSet(labels.Set{"ID": "foo"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if list := obj.(*api.ReplicationControllerList); len(list.Items) != 1 || list.Items[0].ID != "foo" {
+		t.Errorf("unexpected list: %#v", list)
+	}
+}
+
+func TestEtcdCreateAndGet(t *testing.T) {
+	fakeClient := newTestClient(t)
+	storage := newTestEtcd(fakeClient)
+
+	channel, err := storage.Create(&api.ReplicationController{JSONBase: api.JSONBase{ID: "generate"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	created, ok := (<-channel).(*api.ReplicationController)
+	if !ok || created.ID != "generated" || created.ResourceVersion == 0 {
+		t.Errorf("unexpected result: %#v", created)
+	}
+
+	obj, err := storage.Get("generated")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if obj.(*api.ReplicationController).ID != "generated" {
+		t.Errorf("unexpected object: %#v", obj)
+	}
+
+	// Creating it again conflicts.
+	channel, err = storage.Create(&api.ReplicationController{JSONBase: api.JSONBase{ID: "generated"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status, ok := (<-channel).(*api.Status); !ok || status.Reason != api.StatusReasonAlreadyExists {
+		t.Errorf("expected already exists, got %#v", status)
+	}
+}
+
+func TestEtcdCreateInvalid(t *testing.T) {
+	storage := newTestEtcd(newTestClient(t))
+	table := map[string]runtime.Object{
+		"no id":        &api.ReplicationController{},
+		"invalid":      &api.ReplicationController{JSONBase: api.JSONBase{ID: "foo"}, DesiredState: api.ReplicationControllerState{Replicas: -1}},
+		"another kind": &api.Pod{JSONBase: api.JSONBase{ID: "foo"}},
+	}
+	for name, obj := range table {
+		if _, err := storage.Create(obj); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+		if _, err := storage.Update(obj); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if _, err := storage.Create(&api.ReplicationController{}); !errors.IsInvalid(err) {
+		t.Errorf("expected an invalid error, got %v", err)
+	}
+}
+
+func TestEtcdGetNotFound(t *testing.T) {
+	fakeClient := newTestClient(t)
+	fakeClient.ExpectNotFoundGet("/registry/controllers/foo")
+	storage := newTestEtcd(fakeClient)
+	if _, err := storage.Get("foo"); !errors.IsNotFound(err) {
+		t.Errorf("expected not found, got %v", err)
+	}
+}
+
+func TestEtcdUpdate(t *testing.T) {
+	fakeClient := newTestClient(t)
+	storage := newTestEtcd(fakeClient)
+	fakeClient.Set("/registry/controllers/foo", runtime.EncodeOrDie(latest.Codec, &api.ReplicationController{JSONBase: api.JSONBase{ID: "foo"}}), 0)
+
+	controller := &api.ReplicationController{JSONBase: api.JSONBase{ID: "foo", ResourceVersion: 1}, DesiredState: api.ReplicationControllerState{Replicas: 2}}
+	channel, err := storage.Update(controller)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	updated, ok := (<-channel).(*api.ReplicationController)
+	if !ok || updated.DesiredState.Replicas != 2 || updated.ResourceVersion != 2 {
+		t.Errorf("unexpected result: %#v", updated)
+	}
+
+	// An update from version 1, which is now stale, conflicts.
+	stale := &api.ReplicationController{JSONBase: api.JSONBase{ID: "foo", ResourceVersion: 1}}
+	channel, err = storage.Update(stale)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status, ok := (<-channel).(*api.Status); !ok || status.Reason != api.StatusReasonConflict {
+		t.Errorf("expected a conflict, got %#v", status)
+	}
+}
+
+func TestEtcdDelete(t *testing.T) {
+	fakeClient := newTestClient(t)
+	storage := newTestEtcd(fakeClient)
+	fakeClient.Set("/registry/controllers/foo", runtime.EncodeOrDie(latest.Codec, &api.ReplicationController{JSONBase: api.JSONBase{ID: "foo"}}), 0)
+
+	channel, err := storage.DeleteIfVersion("foo", 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status, ok := (<-channel).(*api.Status); !ok || status.Reason != api.StatusReasonConflict {
+		t.Errorf("expected a conflict, got %#v", status)
+	}
+
+	channel, err = storage.Delete("foo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status, ok := (<-channel).(*api.Status); !ok || status.Status != api.StatusSuccess {
+		t.Errorf("unexpected result: %#v", status)
+	}
+	if len(fakeClient.DeletedKeys) != 1 || fakeClient.DeletedKeys[0] != "/registry/controllers/foo" {
+		t.Errorf("unexpected deletes: %v", fakeClient.DeletedKeys)
+	}
+}
+
+func TestEtcdWatch(t *testing.T) {
+	fakeClient := newTestClient(t)
+	storage := newTestEtcd(fakeClient)
+	watching, err := storage.Watch(labels.SelectorFromSet(labels.Set{"name": "foo"}), labels.Everything(), 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fakeClient.WaitForWatchCompletion()
+	if fakeClient.WatchIndex != 2 {
+		t.Errorf("expected a watch from index 2, got %d", fakeClient.WatchIndex)
+	}
+
+	for _, node := range []*etcd.Node{
+		controllerNode("bar", 2, map[string]string{"name": "bar"}),
+		controllerNode("foo", 3, map[string]string{"name": "foo"}),
+	} {
+		fakeClient.WatchResponse <- &etcd.Response{Action: "create", Node: node}
+	}
+	event := <-watching.ResultChan()
+	if event.Type != watch.Added || event.Object.(*api.ReplicationController).ID != "foo" {
+		t.Errorf("unexpected event: %#v", event)
+	}
+	watching.Stop()
+
+	if _, err := storage.Watch(labels.Everything(), labels.SelectorFromSet(labels.Set{"ID": "foo"}), 0); err == nil {
+		t.Errorf("expected field selectors to be refused")
+	}
+}
+
+func TestEtcdConformance(t *testing.T) {
+	tester := registrytest.Tester{
+		NewStorage: func(client tools.EtcdClient) apiserver.RESTStorage {
+			return newTestEtcd(client)
+		},
+		Valid: func() runtime.Object {
+			return &api.ReplicationController{}
+		},
+		Invalid: []runtime.Object{
+			&api.ReplicationController{JSONBase: api.JSONBase{ID: "foo"}, DesiredState: api.ReplicationControllerState{Replicas: -1}},
+		},
+	}
+	tester.Run(t)
+}
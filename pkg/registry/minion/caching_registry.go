@@ -20,6 +20,8 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 )
 
 type Clock interface {
@@ -35,7 +37,7 @@ func (SystemClock) Now() time.Time {
 type CachingRegistry struct {
 	delegate   Registry
 	ttl        time.Duration
-	minions    []string
+	minions    *api.MinionList
 	lastUpdate int64
 	lock       sync.RWMutex
 	clock      Clock
@@ -64,8 +66,8 @@ func (r *CachingRegistry) Contains(minion string) (bool, error) {
 	// block updates in the middle of a contains.
 	r.lock.RLock()
 	defer r.lock.RUnlock()
-	for _, name := range r.minions {
-		if name == minion {
+	for _, node := range r.minions.Items {
+		if node.ID == minion {
 			return true, nil
 		}
 	}
@@ -79,20 +81,35 @@ func (r *CachingRegistry) Delete(minion string) error {
 	return r.refresh(true)
 }
 
-func (r *CachingRegistry) Insert(minion string) error {
+func (r *CachingRegistry) Insert(minion *api.Minion) error {
 	if err := r.delegate.Insert(minion); err != nil {
 		return err
 	}
 	return r.refresh(true)
 }
 
-func (r *CachingRegistry) List() ([]string, error) {
+func (r *CachingRegistry) Update(minion *api.Minion) error {
+	if err := r.delegate.Update(minion); err != nil {
+		return err
+	}
+	return r.refresh(true)
+}
+
+// List returns a copy of the cached minions, so callers may modify it freely.
+// If the cache has expired and can't be refreshed, the previous list is
+// returned along with the error.
+func (r *CachingRegistry) List() (*api.MinionList, error) {
+	var refreshErr error
 	if r.expired() {
-		if err := r.refresh(false); err != nil {
-			return r.minions, err
-		}
+		refreshErr = r.refresh(false)
 	}
-	return r.minions, nil
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	list, err := api.Scheme.Copy(r.minions)
+	if err != nil {
+		return nil, err
+	}
+	return list.(*api.MinionList), refreshErr
 }
 
 func (r *CachingRegistry) expired() bool {
@@ -102,16 +119,19 @@ func (r *CachingRegistry) expired() bool {
 }
 
 // refresh updates the current store.  It double checks expired under lock with the assumption
-// of optimistic concurrency with the other functions.
+// of optimistic concurrency with the other functions.  If the delegate fails, the current
+// store is kept and stays expired, so the next call tries again.
 func (r *CachingRegistry) refresh(force bool) error {
 	r.lock.Lock()
 	defer r.lock.Unlock()
 	if force || r.expired() {
-		var err error
-		r.minions, err = r.delegate.List()
+		list, err := r.delegate.List()
+		if err != nil {
+			return err
+		}
+		r.minions = list
 		time := r.clock.Now()
 		atomic.SwapInt64(&r.lastUpdate, time.Unix())
-		return err
 	}
 	return nil
 }
@@ -17,10 +17,12 @@ limitations under the License.
 package minion
 
 import (
+	"fmt"
 	"reflect"
 	"testing"
 	"time"
 
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/registry/registrytest"
 )
 
@@ -36,8 +38,8 @@ func TestCachingHit(t *testing.T) {
 	fakeClock := fakeClock{
 		now: time.Unix(0, 0),
 	}
-	fakeRegistry := registrytest.NewMinionRegistry([]string{"m1", "m2"})
-	expected := []string{"m1", "m2", "m3"}
+	fakeRegistry := registrytest.NewMinionRegistry([]string{"m1", "m2"}, api.NodeResources{})
+	expected := registrytest.MakeMinionList([]string{"m1", "m2", "m3"}, api.NodeResources{})
 	cache := CachingRegistry{
 		delegate:   fakeRegistry,
 		ttl:        1 * time.Second,
@@ -58,8 +60,8 @@ func TestCachingMiss(t *testing.T) {
 	fakeClock := fakeClock{
 		now: time.Unix(0, 0),
 	}
-	fakeRegistry := registrytest.NewMinionRegistry([]string{"m1", "m2"})
-	expected := []string{"m1", "m2", "m3"}
+	fakeRegistry := registrytest.NewMinionRegistry([]string{"m1", "m2"}, api.NodeResources{})
+	expected := registrytest.MakeMinionList([]string{"m1", "m2", "m3"}, api.NodeResources{})
 	cache := CachingRegistry{
 		delegate:   fakeRegistry,
 		ttl:        1 * time.Second,
@@ -72,7 +74,7 @@ func TestCachingMiss(t *testing.T) {
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
-	if !reflect.DeepEqual(list, fakeRegistry.Minions) {
+	if !reflect.DeepEqual(list, &fakeRegistry.Minions) {
 		t.Errorf("expected: %v, got %v", fakeRegistry.Minions, list)
 	}
 }
@@ -81,8 +83,8 @@ func TestCachingInsert(t *testing.T) {
 	fakeClock := fakeClock{
 		now: time.Unix(0, 0),
 	}
-	fakeRegistry := registrytest.NewMinionRegistry([]string{"m1", "m2"})
-	expected := []string{"m1", "m2", "m3"}
+	fakeRegistry := registrytest.NewMinionRegistry([]string{"m1", "m2"}, api.NodeResources{})
+	expected := registrytest.MakeMinionList([]string{"m1", "m2", "m3"}, api.NodeResources{})
 	cache := CachingRegistry{
 		delegate:   fakeRegistry,
 		ttl:        1 * time.Second,
@@ -90,7 +92,7 @@ func TestCachingInsert(t *testing.T) {
 		lastUpdate: fakeClock.Now().Unix(),
 		minions:    expected,
 	}
-	err := cache.Insert("foo")
+	err := cache.Insert(&api.Minion{JSONBase: api.JSONBase{ID: "foo"}})
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
@@ -98,7 +100,7 @@ func TestCachingInsert(t *testing.T) {
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
-	if !reflect.DeepEqual(list, fakeRegistry.Minions) {
+	if !reflect.DeepEqual(list, &fakeRegistry.Minions) {
 		t.Errorf("expected: %v, got %v", fakeRegistry.Minions, list)
 	}
 }
@@ -107,8 +109,8 @@ func TestCachingDelete(t *testing.T) {
 	fakeClock := fakeClock{
 		now: time.Unix(0, 0),
 	}
-	fakeRegistry := registrytest.NewMinionRegistry([]string{"m1", "m2"})
-	expected := []string{"m1", "m2", "m3"}
+	fakeRegistry := registrytest.NewMinionRegistry([]string{"m1", "m2"}, api.NodeResources{})
+	expected := registrytest.MakeMinionList([]string{"m1", "m2", "m3"}, api.NodeResources{})
 	cache := CachingRegistry{
 		delegate:   fakeRegistry,
 		ttl:        1 * time.Second,
@@ -124,7 +126,75 @@ func TestCachingDelete(t *testing.T) {
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
-	if !reflect.DeepEqual(list, fakeRegistry.Minions) {
+	if !reflect.DeepEqual(list, &fakeRegistry.Minions) {
 		t.Errorf("expected: %v, got %v", fakeRegistry.Minions, list)
 	}
 }
+
+func TestCachingRefreshError(t *testing.T) {
+	fakeClock := fakeClock{
+		now: time.Unix(0, 0),
+	}
+	fakeRegistry := registrytest.NewMinionRegistry([]string{"m1", "m2"}, api.NodeResources{})
+	fakeRegistry.Err = fmt.Errorf("test error")
+	expected := registrytest.MakeMinionList([]string{"m1", "m2", "m3"}, api.NodeResources{})
+	cache := CachingRegistry{
+		delegate:   fakeRegistry,
+		ttl:        1 * time.Second,
+		clock:      &fakeClock,
+		lastUpdate: fakeClock.Now().Unix(),
+		minions:    expected,
+	}
+	fakeClock.now = time.Unix(3, 0)
+	list, err := cache.List()
+	if err == nil {
+		t.Errorf("unexpected non-error")
+	}
+	if !reflect.DeepEqual(list, expected) {
+		t.Errorf("expected: %v, got %v", expected, list)
+	}
+	ok, err := cache.Contains("m3")
+	if err == nil {
+		t.Errorf("unexpected non-error")
+	}
+	if ok {
+		t.Errorf("unexpected presence of 'm3'")
+	}
+
+	// The cache stays expired, so it's refreshed once the delegate recovers.
+	fakeRegistry.Err = nil
+	list, err = cache.List()
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(list, &fakeRegistry.Minions) {
+		t.Errorf("expected: %v, got %v", fakeRegistry.Minions, list)
+	}
+}
+
+func TestCachingListReturnsCopy(t *testing.T) {
+	fakeClock := fakeClock{
+		now: time.Unix(0, 0),
+	}
+	fakeRegistry := registrytest.NewMinionRegistry([]string{"m1", "m2"}, api.NodeResources{})
+	expected := registrytest.MakeMinionList([]string{"m1", "m2"}, api.NodeResources{})
+	cache := CachingRegistry{
+		delegate:   fakeRegistry,
+		ttl:        1 * time.Second,
+		clock:      &fakeClock,
+		lastUpdate: fakeClock.Now().Unix(),
+		minions:    registrytest.MakeMinionList([]string{"m1", "m2"}, api.NodeResources{}),
+	}
+	list, err := cache.List()
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	list.Items[0].ID = "changed"
+	list, err = cache.List()
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(list, expected) {
+		t.Errorf("expected: %v, got %v", expected, list)
+	}
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package minion
+
+import (
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+
+	"github.com/golang/glog"
+	"github.com/google/cadvisor/info"
+)
+
+// MachineInfoGetter returns the machine info reported by the kubelet on a host.
+type MachineInfoGetter interface {
+	GetMachineInfo(host string) (*info.MachineInfo, error)
+}
+
+// CapacityRegistry reports the cpu and memory capacity of each minion as given
+// by its kubelet.  Minions whose kubelet can't be reached keep the capacity
+// reported by the delegate, i.e. the cloud provider's or the static one.
+type CapacityRegistry struct {
+	delegate Registry
+	client   MachineInfoGetter
+}
+
+func NewCapacityRegistry(delegate Registry, client MachineInfoGetter) Registry {
+	return &CapacityRegistry{
+		delegate: delegate,
+		client:   client,
+	}
+}
+
+func (r *CapacityRegistry) Contains(minion string) (bool, error) {
+	return r.delegate.Contains(minion)
+}
+
+func (r *CapacityRegistry) Delete(minion string) error {
+	return r.delegate.Delete(minion)
+}
+
+func (r *CapacityRegistry) Insert(minion *api.Minion) error {
+	return r.delegate.Insert(minion)
+}
+
+func (r *CapacityRegistry) Update(minion *api.Minion) error {
+	return r.delegate.Update(minion)
+}
+
+func (r *CapacityRegistry) List() (*api.MinionList, error) {
+	list, err := r.delegate.List()
+	if err != nil {
+		return list, err
+	}
+	result := &api.MinionList{JSONBase: list.JSONBase}
+	for _, minion := range list.Items {
+		machine, err := r.client.GetMachineInfo(minion.ID)
+		if err != nil {
+			glog.Errorf("Failed to get machine info of %s: %v", minion.ID, err)
+			result.Items = append(result.Items, minion)
+			continue
+		}
+		// Don't modify the delegate's capacity in place.
+		capacity := api.ResourceList{}
+		for name, quantity := range minion.NodeResources.Capacity {
+			capacity[name] = quantity
+		}
+		capacity[api.ResourceCPU] = util.NewIntOrStringFromInt(machine.NumCores * 1000)
+		capacity[api.ResourceMemory] = util.NewIntOrStringFromInt(int(machine.MemoryCapacity))
+		minion.NodeResources.Capacity = capacity
+		result.Items = append(result.Items, minion)
+	}
+	return result, nil
+}
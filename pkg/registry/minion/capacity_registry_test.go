@@ -0,0 +1,107 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package minion
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/registry/registrytest"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+
+	"github.com/google/cadvisor/info"
+)
+
+type fakeMachineInfoGetter map[string]*info.MachineInfo
+
+func (f fakeMachineInfoGetter) GetMachineInfo(host string) (*info.MachineInfo, error) {
+	machine, ok := f[host]
+	if !ok {
+		return nil, fmt.Errorf("no kubelet on %s", host)
+	}
+	return machine, nil
+}
+
+func TestCapacityFromKubelet(t *testing.T) {
+	static := api.NodeResources{
+		Capacity: api.ResourceList{
+			api.ResourceCPU:    util.NewIntOrStringFromInt(1000),
+			api.ResourceMemory: util.NewIntOrStringFromInt(1024),
+			api.ResourcePods:   util.NewIntOrStringFromInt(10),
+		},
+	}
+	mockMinionRegistry := registrytest.NewMinionRegistry([]string{"m1", "m2"}, static)
+	capacity := CapacityRegistry{
+		delegate: mockMinionRegistry,
+		client: fakeMachineInfoGetter{
+			"m1": {NumCores: 4, MemoryCapacity: 8 << 30},
+		},
+	}
+	list, err := capacity.List()
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	expected := []api.Minion{
+		{
+			JSONBase: api.JSONBase{ID: "m1"},
+			NodeResources: api.NodeResources{
+				Capacity: api.ResourceList{
+					api.ResourceCPU:    util.NewIntOrStringFromInt(4000),
+					api.ResourceMemory: util.NewIntOrStringFromInt(8 << 30),
+					api.ResourcePods:   util.NewIntOrStringFromInt(10),
+				},
+			},
+		},
+		{
+			JSONBase:      api.JSONBase{ID: "m2"},
+			NodeResources: static,
+		},
+	}
+	if !reflect.DeepEqual(list.Items, expected) {
+		t.Errorf("Expected %#v, Got %#v", expected, list.Items)
+	}
+	if !reflect.DeepEqual(mockMinionRegistry.Minions.Items[0].NodeResources, static) {
+		t.Errorf("Unexpected change to the delegate's minion: %#v", mockMinionRegistry.Minions.Items[0])
+	}
+}
+
+func TestCapacityDelegation(t *testing.T) {
+	mockMinionRegistry := registrytest.NewMinionRegistry([]string{"m1", "m2"}, api.NodeResources{})
+	capacity := CapacityRegistry{
+		delegate: mockMinionRegistry,
+		client:   fakeMachineInfoGetter{},
+	}
+	if err := capacity.Insert(&api.Minion{JSONBase: api.JSONBase{ID: "foo"}}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if mockMinionRegistry.Minion != "foo" {
+		t.Errorf("Expected 'foo' to be inserted, got %q", mockMinionRegistry.Minion)
+	}
+	ok, err := capacity.Contains("m1")
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !ok {
+		t.Errorf("Unexpected absence of 'm1'")
+	}
+	mockMinionRegistry.Err = fmt.Errorf("test error")
+	if _, err := capacity.List(); err == nil {
+		t.Errorf("unexpected non-error")
+	}
+}
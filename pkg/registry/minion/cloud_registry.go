@@ -19,18 +19,24 @@ package minion
 import (
 	"fmt"
 
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/cloudprovider"
 )
 
 type CloudRegistry struct {
-	cloud   cloudprovider.Interface
-	matchRE string
+	cloud           cloudprovider.Interface
+	matchRE         string
+	staticResources *api.NodeResources
 }
 
-func NewCloudRegistry(cloud cloudprovider.Interface, matchRE string) (*CloudRegistry, error) {
+// NewCloudRegistry returns a Registry backed by the cloud provider's list of instances.
+// staticResources, if not nil, are reported for any minion whose resources the cloud
+// provider does not know.
+func NewCloudRegistry(cloud cloudprovider.Interface, matchRE string, staticResources *api.NodeResources) (*CloudRegistry, error) {
 	return &CloudRegistry{
-		cloud:   cloud,
-		matchRE: matchRE,
+		cloud:           cloud,
+		matchRE:         matchRE,
+		staticResources: staticResources,
 	}, nil
 }
 
@@ -39,8 +45,8 @@ func (r *CloudRegistry) Contains(minion string) (bool, error) {
 	if err != nil {
 		return false, err
 	}
-	for _, name := range instances {
-		if name == minion {
+	for _, node := range instances.Items {
+		if node.ID == minion {
 			return true, nil
 		}
 	}
@@ -51,14 +57,61 @@ func (r CloudRegistry) Delete(minion string) error {
 	return fmt.Errorf("unsupported")
 }
 
-func (r CloudRegistry) Insert(minion string) error {
+func (r CloudRegistry) Insert(minion *api.Minion) error {
 	return fmt.Errorf("unsupported")
 }
 
-func (r *CloudRegistry) List() ([]string, error) {
+func (r CloudRegistry) Update(minion *api.Minion) error {
+	return fmt.Errorf("unsupported")
+}
+
+// zoneLabels returns the labels describing zone, or nil if it is unknown.
+func zoneLabels(zone cloudprovider.Zone) map[string]string {
+	var labels map[string]string
+	if zone.FailureDomain != "" {
+		labels = map[string]string{cloudprovider.LabelFailureDomain: zone.FailureDomain}
+	}
+	if zone.Region != "" {
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[cloudprovider.LabelRegion] = zone.Region
+	}
+	return labels
+}
+
+func (r *CloudRegistry) List() (*api.MinionList, error) {
 	instances, ok := r.cloud.Instances()
 	if !ok {
 		return nil, fmt.Errorf("cloud doesn't support instances")
 	}
-	return instances.List(r.matchRE)
+	names, err := instances.List(r.matchRE)
+	if err != nil {
+		return nil, err
+	}
+	zones, hasZones := r.cloud.Zones()
+	result := &api.MinionList{
+		Items: make([]api.Minion, len(names)),
+	}
+	for ix := range names {
+		result.Items[ix].ID = names[ix]
+		resources, err := instances.GetNodeResources(names[ix])
+		if err != nil {
+			return nil, err
+		}
+		if resources == nil {
+			resources = r.staticResources
+		}
+		if resources != nil {
+			result.Items[ix].NodeResources = *resources
+		}
+		if hasZones {
+			zone, err := zones.GetInstanceZone(names[ix])
+			if err != nil {
+				return nil, err
+			}
+			result.Items[ix].Labels = zoneLabels(zone)
+		}
+	}
+	return result, nil
 }
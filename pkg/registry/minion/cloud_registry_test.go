@@ -20,7 +20,11 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/cloudprovider"
 	fake_cloud "github.com/GoogleCloudPlatform/kubernetes/pkg/cloudprovider/fake"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/registry/registrytest"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
 )
 
 func TestCloudList(t *testing.T) {
@@ -28,7 +32,7 @@ func TestCloudList(t *testing.T) {
 	fakeCloud := fake_cloud.FakeCloud{
 		Machines: instances,
 	}
-	registry, err := NewCloudRegistry(&fakeCloud, ".*")
+	registry, err := NewCloudRegistry(&fakeCloud, ".*", nil)
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
@@ -38,7 +42,7 @@ func TestCloudList(t *testing.T) {
 		t.Errorf("unexpected error: %v", err)
 	}
 
-	if !reflect.DeepEqual(list, instances) {
+	if !reflect.DeepEqual(list, registrytest.MakeMinionList(instances, api.NodeResources{})) {
 		t.Errorf("Unexpected inequality: %#v, %#v", list, instances)
 	}
 }
@@ -48,7 +52,7 @@ func TestCloudContains(t *testing.T) {
 	fakeCloud := fake_cloud.FakeCloud{
 		Machines: instances,
 	}
-	registry, err := NewCloudRegistry(&fakeCloud, ".*")
+	registry, err := NewCloudRegistry(&fakeCloud, ".*", nil)
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
@@ -77,7 +81,7 @@ func TestCloudListRegexp(t *testing.T) {
 	fakeCloud := fake_cloud.FakeCloud{
 		Machines: instances,
 	}
-	registry, err := NewCloudRegistry(&fakeCloud, "m[0-9]+")
+	registry, err := NewCloudRegistry(&fakeCloud, "m[0-9]+", nil)
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
@@ -87,8 +91,75 @@ func TestCloudListRegexp(t *testing.T) {
 		t.Errorf("unexpected error: %v", err)
 	}
 
-	expectedList := []string{"m1", "m2"}
+	expectedList := registrytest.MakeMinionList([]string{"m1", "m2"}, api.NodeResources{})
 	if !reflect.DeepEqual(list, expectedList) {
 		t.Errorf("Unexpected inequality: %#v, %#v", list, expectedList)
 	}
 }
+
+func TestCloudListResources(t *testing.T) {
+	static := api.NodeResources{
+		Capacity: api.ResourceList{
+			api.ResourceCPU: util.NewIntOrStringFromInt(1000),
+		},
+	}
+	fakeCloud := fake_cloud.FakeCloud{
+		Machines: []string{"m1", "m2"},
+	}
+	registry, err := NewCloudRegistry(&fakeCloud, ".*", &static)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	list, err := registry.List()
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(list, registrytest.MakeMinionList([]string{"m1", "m2"}, static)) {
+		t.Errorf("Unexpected static resources: %#v", list)
+	}
+
+	cloudResources := api.NodeResources{
+		Capacity: api.ResourceList{
+			api.ResourceCPU:    util.NewIntOrStringFromInt(4000),
+			api.ResourceMemory: util.NewIntOrStringFromInt(1024),
+		},
+	}
+	fakeCloud.NodeResources = &cloudResources
+	list, err = registry.List()
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(list, registrytest.MakeMinionList([]string{"m1", "m2"}, cloudResources)) {
+		t.Errorf("Unexpected cloud resources: %#v", list)
+	}
+}
+
+func TestCloudListZoneLabels(t *testing.T) {
+	fakeCloud := fake_cloud.FakeCloud{
+		Machines: []string{"m1", "m2", "m3"},
+		InstanceZones: map[string]cloudprovider.Zone{
+			"m1": {FailureDomain: "us-central1-a", Region: "us-central1"},
+			"m2": {Region: "us-central1"},
+		},
+	}
+	registry, err := NewCloudRegistry(&fakeCloud, ".*", nil)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	list, err := registry.List()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []map[string]string{
+		{cloudprovider.LabelFailureDomain: "us-central1-a", cloudprovider.LabelRegion: "us-central1"},
+		{cloudprovider.LabelRegion: "us-central1"},
+		nil,
+	}
+	for i, minion := range list.Items {
+		if !reflect.DeepEqual(expected[i], minion.Labels) {
+			t.Errorf("expected labels %v for %s, got %v", expected[i], minion.ID, minion.Labels)
+		}
+	}
+}
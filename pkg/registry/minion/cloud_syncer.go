@@ -0,0 +1,136 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package minion
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/golang/glog"
+)
+
+// CloudSyncer periodically copies the minions of a cloud registry into
+// another registry, so that listing minions reads a stored list rather than
+// asking the cloud provider on every request. Minions which the syncer added
+// are deleted once they leave the cloud; minions added by other means are
+// left alone.
+type CloudSyncer struct {
+	cloud    Registry
+	registry Registry
+
+	lock sync.Mutex
+	// The minions added by the syncer which are still in the cloud.
+	synced util.StringSet
+}
+
+// NewCloudSyncer creates a CloudSyncer which copies the minions of cloud into registry.
+func NewCloudSyncer(cloud, registry Registry) *CloudSyncer {
+	return &CloudSyncer{
+		cloud:    cloud,
+		registry: registry,
+		synced:   util.StringSet{},
+	}
+}
+
+// Run begins syncing the minions every period.
+func (s *CloudSyncer) Run(period time.Duration) {
+	go util.Forever(func() {
+		if err := s.Sync(); err != nil {
+			glog.Errorf("Error syncing minions with the cloud: %v", err)
+		}
+	}, period)
+}
+
+// Sync inserts the minions which are new to the cloud, updates the resources
+// and cloud labels of the ones which have changed, and deletes the ones which
+// are gone.
+func (s *CloudSyncer) Sync() error {
+	instances, err := s.cloud.List()
+	if err != nil {
+		return err
+	}
+	current, err := s.registry.List()
+	if err != nil {
+		return err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	existing := map[string]int{}
+	for ix := range current.Items {
+		existing[current.Items[ix].ID] = ix
+	}
+	found := util.StringSet{}
+	for _, instance := range instances.Items {
+		found.Insert(instance.ID)
+		minion := instance
+		if ix, ok := existing[instance.ID]; ok {
+			// Keep what was set through the API, such as the pod CIDR.
+			minion = current.Items[ix]
+			minion.NodeResources = instance.NodeResources
+			minion.Labels = mergeLabels(minion.Labels, instance.Labels)
+			if reflect.DeepEqual(minion, current.Items[ix]) {
+				s.synced.Insert(minion.ID)
+				continue
+			}
+		}
+		write := s.registry.Insert
+		if _, ok := existing[minion.ID]; ok {
+			// A minion changed through the API since it was listed is
+			// left for the next sync rather than overwritten.
+			write = s.registry.Update
+		}
+		if err := write(&minion); err != nil {
+			glog.Errorf("Error adding minion %s: %v", minion.ID, err)
+			continue
+		}
+		if _, ok := existing[minion.ID]; !ok {
+			glog.Infof("Added minion %s", minion.ID)
+		}
+		s.synced.Insert(minion.ID)
+	}
+	for _, id := range s.synced.List() {
+		if found.Has(id) {
+			continue
+		}
+		if err := s.registry.Delete(id); err != nil {
+			glog.Errorf("Error deleting minion %s: %v", id, err)
+			continue
+		}
+		glog.Infof("Deleted minion %s", id)
+		s.synced.Delete(id)
+	}
+	return nil
+}
+
+// mergeLabels returns the labels of a minion with the labels the cloud reports
+// for it, such as its zone, overriding any of the same name. Labels set
+// through the API are kept.
+func mergeLabels(labels, cloudLabels map[string]string) map[string]string {
+	if len(cloudLabels) == 0 {
+		return labels
+	}
+	merged := map[string]string{}
+	for k, v := range labels {
+		merged[k] = v
+	}
+	for k, v := range cloudLabels {
+		merged[k] = v
+	}
+	return merged
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package minion
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/cloudprovider"
+	fake_cloud "github.com/GoogleCloudPlatform/kubernetes/pkg/cloudprovider/fake"
+)
+
+func minionIDs(t *testing.T, registry Registry) []string {
+	list, err := registry.List()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ids := []string{}
+	for _, minion := range list.Items {
+		ids = append(ids, minion.ID)
+	}
+	return ids
+}
+
+func TestCloudSyncerAddsAndDeletesMinions(t *testing.T) {
+	fakeCloud := &fake_cloud.FakeCloud{Machines: []string{"m1", "m2"}}
+	cloud, _ := NewCloudRegistry(fakeCloud, ".*", nil)
+	registry := NewRegistry([]string{"static"}, api.NodeResources{})
+	syncer := NewCloudSyncer(cloud, registry)
+
+	if err := syncer.Sync(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ids := minionIDs(t, registry); !reflect.DeepEqual(ids, []string{"m1", "m2", "static"}) {
+		t.Errorf("unexpected minions: %v", ids)
+	}
+
+	// Minions which leave the cloud are deleted, but ones we didn't add are kept.
+	fakeCloud.Machines = []string{"m2", "m3"}
+	if err := syncer.Sync(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ids := minionIDs(t, registry); !reflect.DeepEqual(ids, []string{"m2", "m3", "static"}) {
+		t.Errorf("unexpected minions: %v", ids)
+	}
+}
+
+func TestCloudSyncerKeepsMinionFields(t *testing.T) {
+	fakeCloud := &fake_cloud.FakeCloud{
+		Machines: []string{"m1"},
+		Zone:     cloudprovider.Zone{FailureDomain: "zone-a"},
+	}
+	cloud, _ := NewCloudRegistry(fakeCloud, ".*", nil)
+	registry := NewRegistry(nil, api.NodeResources{})
+	registry.Insert(&api.Minion{
+		JSONBase: api.JSONBase{ID: "m1"},
+		Labels:   map[string]string{"env": "prod", cloudprovider.LabelFailureDomain: "zone-b"},
+		PodCIDR:  "10.244.1.0/24",
+	})
+	syncer := NewCloudSyncer(cloud, registry)
+
+	if err := syncer.Sync(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	list, err := registry.List()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := api.Minion{
+		JSONBase: api.JSONBase{ID: "m1", ResourceVersion: 2},
+		Labels:   map[string]string{"env": "prod", cloudprovider.LabelFailureDomain: "zone-a"},
+		PodCIDR:  "10.244.1.0/24",
+	}
+	if len(list.Items) != 1 || !reflect.DeepEqual(list.Items[0], expected) {
+		t.Errorf("expected %#v, got %#v", expected, list.Items)
+	}
+}
+
+func TestCloudSyncerKeepsMinionsOnError(t *testing.T) {
+	fakeCloud := &fake_cloud.FakeCloud{Machines: []string{"m1"}}
+	cloud, _ := NewCloudRegistry(fakeCloud, ".*", nil)
+	registry := NewRegistry(nil, api.NodeResources{})
+	syncer := NewCloudSyncer(cloud, registry)
+	if err := syncer.Sync(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	fakeCloud.Err = fmt.Errorf("cloud is down")
+	if err := syncer.Sync(); err == nil {
+		t.Errorf("expected an error")
+	}
+	if ids := minionIDs(t, registry); !reflect.DeepEqual(ids, []string{"m1"}) {
+		t.Errorf("unexpected minions: %v", ids)
+	}
+}
+
+// changingRegistry calls change once, right after the first List.
+type changingRegistry struct {
+	Registry
+	change func()
+}
+
+func (r *changingRegistry) List() (*api.MinionList, error) {
+	list, err := r.Registry.List()
+	if r.change != nil {
+		r.change()
+		r.change = nil
+	}
+	return list, err
+}
+
+func TestCloudSyncerKeepsConcurrentChanges(t *testing.T) {
+	fakeCloud := &fake_cloud.FakeCloud{
+		Machines: []string{"m1"},
+		Zone:     cloudprovider.Zone{FailureDomain: "zone-a"},
+	}
+	cloud, _ := NewCloudRegistry(fakeCloud, ".*", nil)
+	registry := NewRegistry(nil, api.NodeResources{})
+	registry.Insert(&api.Minion{JSONBase: api.JSONBase{ID: "m1"}})
+	syncer := NewCloudSyncer(cloud, &changingRegistry{
+		Registry: registry,
+		change: func() {
+			// The minion is labeled through the API while the syncer runs.
+			registry.Update(&api.Minion{
+				JSONBase: api.JSONBase{ID: "m1", ResourceVersion: 1},
+				Labels:   map[string]string{"env": "prod"},
+			})
+		},
+	})
+
+	if err := syncer.Sync(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	list, err := registry.List()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(list.Items) != 1 || !reflect.DeepEqual(list.Items[0].Labels, map[string]string{"env": "prod"}) {
+		t.Errorf("expected the API's change to be kept, got %#v", list.Items)
+	}
+
+	// The next sync adds the cloud's labels.
+	if err := syncer.Sync(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	list, err = registry.List()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]string{"env": "prod", cloudprovider.LabelFailureDomain: "zone-a"}
+	if len(list.Items) != 1 || !reflect.DeepEqual(list.Items[0].Labels, expected) {
+		t.Errorf("expected %v, got %#v", expected, list.Items)
+	}
+}
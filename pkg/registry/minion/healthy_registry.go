@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/health"
 
 	"github.com/golang/glog"
@@ -61,26 +62,30 @@ func (r *HealthyRegistry) Delete(minion string) error {
 	return r.delegate.Delete(minion)
 }
 
-func (r *HealthyRegistry) Insert(minion string) error {
+func (r *HealthyRegistry) Insert(minion *api.Minion) error {
 	return r.delegate.Insert(minion)
 }
 
-func (r *HealthyRegistry) List() (currentMinions []string, err error) {
-	var result []string
+func (r *HealthyRegistry) Update(minion *api.Minion) error {
+	return r.delegate.Update(minion)
+}
+
+func (r *HealthyRegistry) List() (currentMinions *api.MinionList, err error) {
+	result := &api.MinionList{}
 	list, err := r.delegate.List()
 	if err != nil {
 		return result, err
 	}
-	for _, minion := range list {
-		status, err := health.DoHTTPCheck(r.makeMinionURL(minion), r.client)
+	for _, minion := range list.Items {
+		status, err := health.DoHTTPCheck(r.makeMinionURL(minion.ID), r.client)
 		if err != nil {
-			glog.Errorf("%s failed health check with error: %s", minion, err)
+			glog.Errorf("%s failed health check with error: %s", minion.ID, err)
 			continue
 		}
 		if status == health.Healthy {
-			result = append(result, minion)
+			result.Items = append(result.Items, minion)
 		} else {
-			glog.Errorf("%s failed a health check, ignoring.", minion)
+			glog.Errorf("%s failed a health check, ignoring.", minion.ID)
 		}
 	}
 	return result, nil
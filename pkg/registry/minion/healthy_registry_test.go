@@ -23,6 +23,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/registry/registrytest"
 )
 
@@ -40,7 +41,7 @@ func (alwaysYes) Get(url string) (*http.Response, error) {
 }
 
 func TestBasicDelegation(t *testing.T) {
-	mockMinionRegistry := registrytest.NewMinionRegistry([]string{"m1", "m2", "m3"})
+	mockMinionRegistry := registrytest.NewMinionRegistry([]string{"m1", "m2", "m3"}, api.NodeResources{})
 	healthy := HealthyRegistry{
 		delegate: mockMinionRegistry,
 		client:   alwaysYes{},
@@ -49,10 +50,10 @@ func TestBasicDelegation(t *testing.T) {
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
-	if !reflect.DeepEqual(list, mockMinionRegistry.Minions) {
+	if !reflect.DeepEqual(list, &mockMinionRegistry.Minions) {
 		t.Errorf("Expected %v, Got %v", mockMinionRegistry.Minions, list)
 	}
-	err = healthy.Insert("foo")
+	err = healthy.Insert(&api.Minion{JSONBase: api.JSONBase{ID: "foo"}})
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
@@ -85,13 +86,13 @@ func (n *notMinion) Get(url string) (*http.Response, error) {
 }
 
 func TestFiltering(t *testing.T) {
-	mockMinionRegistry := registrytest.NewMinionRegistry([]string{"m1", "m2", "m3"})
+	mockMinionRegistry := registrytest.NewMinionRegistry([]string{"m1", "m2", "m3"}, api.NodeResources{})
 	healthy := HealthyRegistry{
 		delegate: mockMinionRegistry,
 		client:   &notMinion{minion: "m1"},
 		port:     10250,
 	}
-	expected := []string{"m2", "m3"}
+	expected := registrytest.MakeMinionList([]string{"m2", "m3"}, api.NodeResources{})
 	list, err := healthy.List()
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
@@ -18,64 +18,109 @@ package minion
 
 import (
 	"fmt"
-	"sort"
 	"sync"
 
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
 )
 
 var ErrDoesNotExist = fmt.Errorf("The requested resource does not exist.")
 
+// ErrConflict is returned by Update when the minion has changed since the
+// resourceVersion of the update.
+var ErrConflict = fmt.Errorf("The minion has been changed since it was read.")
+
 // Registry keeps track of a set of minions. Safe for concurrent reading/writing.
 type Registry interface {
-	List() (currentMinions []string, err error)
-	Insert(minion string) error
+	List() (currentMinions *api.MinionList, err error)
+	Insert(minion *api.Minion) error
+	// Update replaces an existing minion, if it's still at the
+	// resourceVersion minion carries.
+	Update(minion *api.Minion) error
 	Delete(minion string) error
 	Contains(minion string) (bool, error)
 }
 
-// NewRegistry initializes a minion registry with a list of minions.
-func NewRegistry(minions []string) Registry {
+// NewRegistry initializes a minion registry with a list of minions, all of
+// which are reported as having the given resources.
+func NewRegistry(minions []string, nodeResources api.NodeResources) Registry {
 	m := &minionList{
-		minions: util.StringSet{},
+		minions:       map[string]api.Minion{},
+		nodeResources: nodeResources,
 	}
 	for _, minion := range minions {
-		m.minions.Insert(minion)
+		m.Insert(&api.Minion{JSONBase: api.JSONBase{ID: minion}})
 	}
 	return m
 }
 
 type minionList struct {
-	minions util.StringSet
-	lock    sync.Mutex
+	minions       map[string]api.Minion
+	lock          sync.Mutex
+	nodeResources api.NodeResources
+	// version is the resourceVersion given to the last minion written.
+	version uint64
 }
 
 func (m *minionList) Contains(minion string) (bool, error) {
 	m.lock.Lock()
 	defer m.lock.Unlock()
-	return m.minions.Has(minion), nil
+	_, found := m.minions[minion]
+	return found, nil
 }
 
 func (m *minionList) Delete(minion string) error {
 	m.lock.Lock()
 	defer m.lock.Unlock()
-	m.minions.Delete(minion)
+	delete(m.minions, minion)
+	return nil
+}
+
+// Insert adds or replaces a minion. Minions inserted without a capacity are
+// reported as having the registry's resources.
+func (m *minionList) Insert(newMinion *api.Minion) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.put(*newMinion)
 	return nil
 }
 
-func (m *minionList) Insert(newMinion string) error {
+// Update replaces an existing minion like Insert, if it's still at the
+// resourceVersion newMinion carries.
+func (m *minionList) Update(newMinion *api.Minion) error {
 	m.lock.Lock()
 	defer m.lock.Unlock()
-	m.minions.Insert(newMinion)
+	existing, ok := m.minions[newMinion.ID]
+	if !ok {
+		return ErrDoesNotExist
+	}
+	if existing.ResourceVersion != newMinion.ResourceVersion {
+		return ErrConflict
+	}
+	m.put(*newMinion)
 	return nil
 }
 
-func (m *minionList) List() (currentMinions []string, err error) {
+// put stores minion at a new resourceVersion. The caller must hold the lock.
+func (m *minionList) put(minion api.Minion) {
+	if len(minion.NodeResources.Capacity) == 0 {
+		minion.NodeResources = m.nodeResources
+	}
+	m.version++
+	minion.ResourceVersion = m.version
+	m.minions[minion.ID] = minion
+}
+
+func (m *minionList) List() (currentMinions *api.MinionList, err error) {
 	m.lock.Lock()
 	defer m.lock.Unlock()
-	for minion := range m.minions {
-		currentMinions = append(currentMinions, minion)
+	names := util.StringSet{}
+	for name := range m.minions {
+		names.Insert(name)
+	}
+	minions := []api.Minion{}
+	for _, name := range names.List() {
+		minions = append(minions, m.minions[name])
 	}
-	sort.StringSlice(currentMinions).Sort()
-	return
+	return &api.MinionList{Items: minions}, nil
 }
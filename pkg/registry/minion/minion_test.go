@@ -19,10 +19,13 @@ package minion
 import (
 	"reflect"
 	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/registry/registrytest"
 )
 
 func TestRegistry(t *testing.T) {
-	m := NewRegistry([]string{"foo", "bar"})
+	m := NewRegistry([]string{"foo", "bar"}, api.NodeResources{})
 	if has, err := m.Contains("foo"); !has || err != nil {
 		t.Errorf("missing expected object")
 	}
@@ -32,7 +35,7 @@ func TestRegistry(t *testing.T) {
 	if has, err := m.Contains("baz"); has || err != nil {
 		t.Errorf("has unexpected object")
 	}
-	if err := m.Insert("baz"); err != nil {
+	if err := m.Insert(&api.Minion{JSONBase: api.JSONBase{ID: "baz"}}); err != nil {
 		t.Errorf("insert failed")
 	}
 	if has, err := m.Contains("baz"); !has || err != nil {
@@ -48,7 +51,37 @@ func TestRegistry(t *testing.T) {
 	if err != nil {
 		t.Errorf("got error calling List")
 	}
-	if !reflect.DeepEqual(list, []string{"baz", "foo"}) {
+	expected := registrytest.MakeMinionList([]string{"baz", "foo"}, api.NodeResources{})
+	expected.Items[0].ResourceVersion = 3
+	expected.Items[1].ResourceVersion = 1
+	if !reflect.DeepEqual(list, expected) {
+		t.Errorf("Unexpected list value: %#v", list)
+	}
+}
+
+func TestRegistryUpdate(t *testing.T) {
+	m := NewRegistry([]string{"foo"}, api.NodeResources{})
+	list, err := m.List()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	minion := list.Items[0]
+	minion.Labels = map[string]string{"disk": "ssd"}
+	if err := m.Update(&minion); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	// minion's resourceVersion is now stale.
+	if err := m.Update(&minion); err != ErrConflict {
+		t.Errorf("expected a conflict, got %v", err)
+	}
+	if err := m.Update(&api.Minion{JSONBase: api.JSONBase{ID: "bar"}}); err != ErrDoesNotExist {
+		t.Errorf("expected a missing minion, got %v", err)
+	}
+	list, err = m.List()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(list.Items) != 1 || list.Items[0].Labels["disk"] != "ssd" || list.Items[0].ResourceVersion != 2 {
 		t.Errorf("Unexpected list value: %#v", list)
 	}
 }
@@ -20,6 +20,8 @@ import (
 	"fmt"
 
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/validation"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/apiserver"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
@@ -43,14 +45,14 @@ func (rs *REST) Create(obj runtime.Object) (<-chan runtime.Object, error) {
 	if !ok {
 		return nil, fmt.Errorf("not a minion: %#v", obj)
 	}
-	if minion.ID == "" {
-		return nil, fmt.Errorf("ID should not be empty: %#v", minion)
+	if errs := validation.ValidateMinion(minion); len(errs) > 0 {
+		return nil, errors.NewInvalid("minion", minion.ID, errs)
 	}
 
 	minion.CreationTimestamp = util.Now()
 
 	return apiserver.MakeAsync(func() (runtime.Object, error) {
-		err := rs.registry.Insert(minion.ID)
+		err := rs.registry.Insert(minion)
 		if err != nil {
 			return nil, err
 		}
@@ -59,7 +61,7 @@ func (rs *REST) Create(obj runtime.Object) (<-chan runtime.Object, error) {
 			return nil, err
 		}
 		if contains {
-			return rs.toApiMinion(minion.ID), nil
+			return rs.Get(minion.ID)
 		}
 		return nil, fmt.Errorf("unable to add minion %#v", minion)
 	}), nil
@@ -79,33 +81,63 @@ func (rs *REST) Delete(id string) (<-chan runtime.Object, error) {
 }
 
 func (rs *REST) Get(id string) (runtime.Object, error) {
-	exists, err := rs.registry.Contains(id)
-	if !exists {
-		return nil, ErrDoesNotExist
+	list, err := rs.registry.List()
+	if err != nil {
+		return nil, err
 	}
-	return rs.toApiMinion(id), err
+	for i := range list.Items {
+		if list.Items[i].ID == id {
+			// Registries may share the minions they list, so hand out a copy.
+			return api.Scheme.Copy(&list.Items[i])
+		}
+	}
+	return nil, ErrDoesNotExist
 }
 
 func (rs *REST) List(label, field labels.Selector) (runtime.Object, error) {
-	nameList, err := rs.registry.List()
+	list, err := rs.registry.List()
 	if err != nil {
 		return nil, err
 	}
-	var list api.MinionList
-	for _, name := range nameList {
-		list.Items = append(list.Items, *rs.toApiMinion(name))
+	if label.Empty() {
+		return list, nil
+	}
+	filtered := &api.MinionList{}
+	for _, minion := range list.Items {
+		if label.Matches(labels.Set(minion.Labels)) {
+			filtered.Items = append(filtered.Items, minion)
+		}
 	}
-	return &list, nil
+	return filtered, nil
 }
 
 func (*REST) New() runtime.Object {
 	return &api.Minion{}
 }
 
-func (rs *REST) Update(minion runtime.Object) (<-chan runtime.Object, error) {
-	return nil, fmt.Errorf("Minions can only be created (inserted) and deleted.")
-}
+// Update replaces an existing minion, e.g. to change its labels, keeping its
+// creation time. The update must carry the minion's current resourceVersion.
+func (rs *REST) Update(obj runtime.Object) (<-chan runtime.Object, error) {
+	minion, ok := obj.(*api.Minion)
+	if !ok {
+		return nil, fmt.Errorf("not a minion: %#v", obj)
+	}
+	if errs := validation.ValidateMinion(minion); len(errs) > 0 {
+		return nil, errors.NewInvalid("minion", minion.ID, errs)
+	}
+	existing, err := rs.Get(minion.ID)
+	if err != nil {
+		return nil, err
+	}
+	minion.CreationTimestamp = existing.(*api.Minion).CreationTimestamp
 
-func (rs *REST) toApiMinion(name string) *api.Minion {
-	return &api.Minion{JSONBase: api.JSONBase{ID: name}}
+	return apiserver.MakeAsync(func() (runtime.Object, error) {
+		if err := rs.registry.Update(minion); err != nil {
+			if err == ErrConflict {
+				return nil, errors.NewConflict("minion", minion.ID, err)
+			}
+			return nil, err
+		}
+		return rs.Get(minion.ID)
+	}), nil
 }
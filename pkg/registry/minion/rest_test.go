@@ -21,11 +21,13 @@ import (
 	"testing"
 
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
 )
 
 func TestMinionREST(t *testing.T) {
-	m := NewRegistry([]string{"foo", "bar"})
+	m := NewRegistry([]string{"foo", "bar"}, api.NodeResources{})
 	ms := NewREST(m)
 
 	if obj, err := ms.Get("foo"); err != nil || obj.(*api.Minion).ID != "foo" {
@@ -38,7 +40,7 @@ func TestMinionREST(t *testing.T) {
 		t.Errorf("has unexpected object")
 	}
 
-	c, err := ms.Create(&api.Minion{JSONBase: api.JSONBase{ID: "baz"}})
+	c, err := ms.Create(&api.Minion{JSONBase: api.JSONBase{ID: "baz"}, Labels: map[string]string{"disk": "ssd"}})
 	if err != nil {
 		t.Errorf("insert failed")
 	}
@@ -71,14 +73,84 @@ func TestMinionREST(t *testing.T) {
 	if err != nil {
 		t.Errorf("got error calling List")
 	}
+	items := list.(*api.MinionList).Items
+	for i := range items {
+		items[i].CreationTimestamp = util.Time{}
+	}
 	expect := []api.Minion{
 		{
-			JSONBase: api.JSONBase{ID: "baz"},
+			JSONBase: api.JSONBase{ID: "baz", ResourceVersion: 3},
+			Labels:   map[string]string{"disk": "ssd"},
 		}, {
-			JSONBase: api.JSONBase{ID: "foo"},
+			JSONBase: api.JSONBase{ID: "foo", ResourceVersion: 1},
 		},
 	}
-	if !reflect.DeepEqual(list.(*api.MinionList).Items, expect) {
+	if !reflect.DeepEqual(items, expect) {
+		t.Errorf("Unexpected list value: %#v", list)
+	}
+
+	list, err = ms.List(labels.Set{"disk": "ssd"}.AsSelector(), labels.Everything())
+	if err != nil {
+		t.Errorf("got error calling List")
+	}
+	if items := list.(*api.MinionList).Items; len(items) != 1 || items[0].ID != "baz" {
 		t.Errorf("Unexpected list value: %#v", list)
 	}
 }
+
+func TestMinionRESTInvalidPodCIDR(t *testing.T) {
+	ms := NewREST(NewRegistry([]string{}, api.NodeResources{}))
+	if _, err := ms.Create(&api.Minion{JSONBase: api.JSONBase{ID: "foo"}, PodCIDR: "10.244.1.0"}); !errors.IsInvalid(err) {
+		t.Errorf("expected an error for a podCIDR without a prefix length")
+	}
+	c, err := ms.Create(&api.Minion{JSONBase: api.JSONBase{ID: "foo"}, PodCIDR: "10.244.1.0/24"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m, ok := (<-c).(*api.Minion); !ok || m.PodCIDR != "10.244.1.0/24" {
+		t.Errorf("expected the podCIDR to be stored, got %#v", m)
+	}
+}
+
+func TestMinionRESTUpdate(t *testing.T) {
+	ms := NewREST(NewRegistry([]string{"foo"}, api.NodeResources{}))
+	existing, err := ms.Get("foo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	created := existing.(*api.Minion).CreationTimestamp
+	version := existing.(*api.Minion).ResourceVersion
+
+	c, err := ms.Update(&api.Minion{JSONBase: api.JSONBase{ID: "foo", ResourceVersion: version}, Labels: map[string]string{"disk": "ssd"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m, ok := (<-c).(*api.Minion); !ok || m.Labels["disk"] != "ssd" || m.CreationTimestamp != created || m.ResourceVersion == version {
+		t.Errorf("expected the updated minion, got %#v", m)
+	}
+	if obj, err := ms.Get("foo"); err != nil || obj.(*api.Minion).Labels["disk"] != "ssd" {
+		t.Errorf("update didn't actually update: %#v %v", obj, err)
+	}
+
+	// The update was from a version which is now stale.
+	c, err = ms.Update(&api.Minion{JSONBase: api.JSONBase{ID: "foo", ResourceVersion: version}, Labels: map[string]string{"disk": "hdd"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status, ok := (<-c).(*api.Status); !ok || status.Reason != api.StatusReasonConflict {
+		t.Errorf("expected a conflict, got %#v", status)
+	}
+	if obj, err := ms.Get("foo"); err != nil || obj.(*api.Minion).Labels["disk"] != "ssd" {
+		t.Errorf("stale update changed the minion: %#v %v", obj, err)
+	}
+
+	if _, err := ms.Update(&api.Minion{JSONBase: api.JSONBase{ID: "bar"}}); err != ErrDoesNotExist {
+		t.Errorf("expected an error updating a missing minion, got %v", err)
+	}
+	if _, err := ms.Update(&api.Minion{JSONBase: api.JSONBase{ID: "foo"}, PodCIDR: "bad"}); err == nil {
+		t.Errorf("expected an error for an invalid podCIDR")
+	}
+	if _, err := ms.Update(&api.Minion{JSONBase: api.JSONBase{ID: "foo"}, Labels: map[string]string{"disk type": "ssd"}}); !errors.IsInvalid(err) {
+		t.Errorf("expected an invalid error for a bad label, got %v", err)
+	}
+}
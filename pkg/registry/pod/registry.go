@@ -36,6 +36,7 @@ type Registry interface {
 	CreatePod(pod *api.Pod) error
 	// Update an existing pod
 	UpdatePod(pod *api.Pod) error
-	// Delete an existing pod
-	DeletePod(podID string) error
+	// Delete an existing pod. If resourceVersion is non-zero, only a pod with
+	// that resourceVersion is deleted.
+	DeletePod(podID string, resourceVersion uint64) error
 }
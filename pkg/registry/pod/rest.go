@@ -89,8 +89,13 @@ func (rs *REST) Create(obj runtime.Object) (<-chan runtime.Object, error) {
 }
 
 func (rs *REST) Delete(id string) (<-chan runtime.Object, error) {
+	return rs.DeleteIfVersion(id, 0)
+}
+
+// DeleteIfVersion deletes the pod only if its resourceVersion is still resourceVersion.
+func (rs *REST) DeleteIfVersion(id string, resourceVersion uint64) (<-chan runtime.Object, error) {
 	return apiserver.MakeAsync(func() (runtime.Object, error) {
-		return &api.Status{Status: api.StatusSuccess}, rs.registry.DeletePod(id)
+		return &api.Status{Status: api.StatusSuccess}, rs.registry.DeletePod(id, resourceVersion)
 	}), nil
 }
 
@@ -109,6 +114,7 @@ func (rs *REST) Get(id string) (runtime.Object, error) {
 			return pod, err
 		}
 		pod.CurrentState.Status = status
+		fillPodTransitionTimes(pod)
 	}
 	pod.CurrentState.HostIP = getInstanceIP(rs.cloudProvider, pod.CurrentState.Host)
 	return pod, err
@@ -142,6 +148,7 @@ func (rs *REST) List(label, field labels.Selector) (runtime.Object, error) {
 				return pod, err
 			}
 			pod.CurrentState.Status = status
+			fillPodTransitionTimes(pod)
 			pod.CurrentState.HostIP = getInstanceIP(rs.cloudProvider, pod.CurrentState.Host)
 		}
 	}
@@ -207,6 +214,28 @@ func (rs *REST) fillPodInfo(pod *api.Pod) {
 	}
 }
 
+// fillPodTransitionTimes derives the pod's StartedAt and ReadyAt from the
+// container info reported by the kubelet. ScheduledAt is recorded at binding
+// time and is left alone.
+func fillPodTransitionTimes(pod *api.Pod) {
+	if netInfo, ok := pod.CurrentState.Info["net"]; ok && !netInfo.State.StartedAt.IsZero() {
+		pod.CurrentState.StartedAt = util.Time{Time: netInfo.State.StartedAt}
+	}
+	if pod.CurrentState.Status != api.PodRunning {
+		return
+	}
+	var ready time.Time
+	for _, container := range pod.DesiredState.Manifest.Containers {
+		startedAt := pod.CurrentState.Info[container.Name].State.StartedAt
+		if startedAt.After(ready) {
+			ready = startedAt
+		}
+	}
+	if !ready.IsZero() {
+		pod.CurrentState.ReadyAt = util.Time{Time: ready}
+	}
+}
+
 func getInstanceIP(cloud cloudprovider.Interface, host string) string {
 	if cloud == nil {
 		return ""
@@ -279,10 +279,17 @@ func TestPodDecode(t *testing.T) {
 	storage := REST{
 		registry: podRegistry,
 	}
+	alwaysRestart := api.PodState{
+		Manifest: api.ContainerManifest{
+			RestartPolicy: api.RestartPolicy{Always: &api.RestartPolicyAlways{}},
+		},
+	}
 	expected := &api.Pod{
 		JSONBase: api.JSONBase{
 			ID: "foo",
 		},
+		DesiredState: alwaysRestart,
+		CurrentState: alwaysRestart,
 	}
 	body, err := latest.Codec.Encode(expected)
 	if err != nil {
@@ -340,7 +347,7 @@ func TestGetPodCloud(t *testing.T) {
 
 func TestMakePodStatus(t *testing.T) {
 	fakeClient := client.Fake{
-		Minions: api.MinionList{
+		MinionList: api.MinionList{
 			Items: []api.Minion{
 				{
 					JSONBase: api.JSONBase{ID: "machine"},
@@ -481,6 +488,20 @@ func TestMakePodStatus(t *testing.T) {
 	}
 }
 
+func TestMakePodStatusMinionError(t *testing.T) {
+	fakeClient := client.Fake{}
+	fakeClient.AddReactor("list-minions", func(client.FakeAction) (bool, interface{}, error) {
+		return true, nil, fmt.Errorf("test error")
+	})
+	pod := &api.Pod{CurrentState: api.PodState{Host: "machine"}}
+	if _, err := getPodStatus(pod, &fakeClient); err == nil {
+		t.Errorf("Expected an error")
+	}
+	if len(fakeClient.Actions) != 1 || fakeClient.Actions[0].Action != "list-minions" {
+		t.Errorf("Unexpected actions: %#v", fakeClient.Actions)
+	}
+}
+
 func TestPodStorageValidatesCreate(t *testing.T) {
 	podRegistry := registrytest.NewPodRegistry(nil)
 	podRegistry.Err = fmt.Errorf("test error")
@@ -604,3 +625,41 @@ func TestFillPodInfoNoData(t *testing.T) {
 		t.Errorf("Expected %s, Got %s", expectedIP, pod.CurrentState.PodIP)
 	}
 }
+
+func TestFillPodTransitionTimes(t *testing.T) {
+	netStart := time.Date(2014, 7, 1, 10, 0, 0, 0, time.UTC)
+	fooStart := netStart.Add(2 * time.Second)
+	barStart := netStart.Add(5 * time.Second)
+	manifest := api.ContainerManifest{
+		Containers: []api.Container{{Name: "foo"}, {Name: "bar"}},
+	}
+	info := api.PodInfo{
+		"net": {State: docker.State{Running: true, StartedAt: netStart}},
+		"foo": {State: docker.State{Running: true, StartedAt: fooStart}},
+		"bar": {State: docker.State{Running: true, StartedAt: barStart}},
+	}
+
+	pod := api.Pod{
+		DesiredState: api.PodState{Manifest: manifest},
+		CurrentState: api.PodState{Status: api.PodRunning, Info: info},
+	}
+	fillPodTransitionTimes(&pod)
+	if !pod.CurrentState.StartedAt.Equal(netStart) {
+		t.Errorf("Expected startedAt %v, got %v", netStart, pod.CurrentState.StartedAt)
+	}
+	if !pod.CurrentState.ReadyAt.Equal(barStart) {
+		t.Errorf("Expected readyAt %v, got %v", barStart, pod.CurrentState.ReadyAt)
+	}
+
+	pod = api.Pod{
+		DesiredState: api.PodState{Manifest: manifest},
+		CurrentState: api.PodState{Status: api.PodWaiting, Info: info},
+	}
+	fillPodTransitionTimes(&pod)
+	if !pod.CurrentState.StartedAt.Equal(netStart) {
+		t.Errorf("Expected startedAt %v, got %v", netStart, pod.CurrentState.StartedAt)
+	}
+	if !pod.CurrentState.ReadyAt.IsZero() {
+		t.Errorf("Expected no readyAt for a waiting pod, got %v", pod.CurrentState.ReadyAt)
+	}
+}
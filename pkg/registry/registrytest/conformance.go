@@ -0,0 +1,296 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package registrytest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/apiserver"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/tools"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
+	"github.com/coreos/go-etcd/etcd"
+)
+
+// Tester checks that a RESTStorage kept in etcd behaves the way every
+// resource should: it assigns or requires IDs, refuses invalid objects,
+// reports conflicting creates, updates and deletes, and watches from the
+// change after a resourceVersion. Plug a storage in by setting the fields
+// below, then call Run from a test.
+type Tester struct {
+	// NewStorage returns the storage under test, kept in client.
+	NewStorage func(client tools.EtcdClient) apiserver.RESTStorage
+	// Valid returns a new object which may be created once given an ID.
+	Valid func() runtime.Object
+	// Invalid are objects, with IDs, which must be refused as invalid.
+	Invalid []runtime.Object
+	// GeneratesIDs is true if the storage assigns IDs to objects created
+	// without one, rather than refusing them as invalid.
+	GeneratesIDs bool
+}
+
+// quietLogger lets the storage read keys FakeEtcdClient wasn't told to
+// expect, which are simply not found.
+type quietLogger struct {
+	*testing.T
+}
+
+func (l quietLogger) Errorf(format string, args ...interface{}) {
+	l.Logf(format, args...)
+}
+
+// Run runs every check against a fresh storage and etcd.
+func (tester *Tester) Run(t *testing.T) {
+	checks := []struct {
+		name  string
+		check func(c *check)
+	}{
+		{"create without ID", (*check).createWithoutID},
+		{"create", (*check).create},
+		{"create existing", (*check).createExisting},
+		{"invalid", (*check).invalid},
+		{"get missing", (*check).getMissing},
+		{"update stale", (*check).updateStale},
+		{"delete", (*check).delete},
+		{"delete stale", (*check).deleteStale},
+		{"watch", (*check).watch},
+	}
+	for _, item := range checks {
+		client := tools.NewFakeEtcdClient(quietLogger{t})
+		client.TestIndex = true
+		item.check(&check{
+			t:       t,
+			name:    item.name,
+			tester:  tester,
+			client:  client,
+			storage: tester.NewStorage(client),
+		})
+	}
+}
+
+// check holds the state of one check.
+type check struct {
+	t       *testing.T
+	name    string
+	tester  *Tester
+	client  *tools.FakeEtcdClient
+	storage apiserver.RESTStorage
+}
+
+func (c *check) errorf(format string, args ...interface{}) {
+	c.t.Errorf("%s: %s", c.name, fmt.Sprintf(format, args...))
+}
+
+// valid returns a valid object with the given ID.
+func (c *check) valid(id string) runtime.Object {
+	obj := c.tester.Valid()
+	jsonBase, err := runtime.FindJSONBase(obj)
+	if err != nil {
+		c.t.Fatalf("%s: unexpected object %#v: %v", c.name, obj, err)
+	}
+	jsonBase.SetID(id)
+	return obj
+}
+
+// validAt returns a valid object with the given ID and resourceVersion.
+func (c *check) validAt(id string, version uint64) runtime.Object {
+	obj := c.valid(id)
+	jsonBase, err := runtime.FindJSONBase(obj)
+	if err != nil {
+		c.t.Fatalf("%s: unexpected object %#v: %v", c.name, obj, err)
+	}
+	jsonBase.SetResourceVersion(version)
+	return obj
+}
+
+// result waits for the result of an asynchronous operation, returning
+// failure statuses as their reasons.
+func (c *check) result(out <-chan runtime.Object, err error) (runtime.Object, api.StatusReason, error) {
+	if err != nil {
+		return nil, "", err
+	}
+	obj := <-out
+	if status, ok := obj.(*api.Status); ok && status.Status != api.StatusSuccess {
+		return nil, status.Reason, nil
+	}
+	return obj, "", nil
+}
+
+// mustCreate creates a valid object with the given ID, and returns it as
+// stored.
+func (c *check) mustCreate(id string) runtime.Object {
+	obj, reason, err := c.result(c.storage.Create(c.valid(id)))
+	if err != nil || reason != "" {
+		c.t.Fatalf("%s: unexpected error creating %q: %v %v", c.name, id, reason, err)
+	}
+	return obj
+}
+
+func idAndVersion(obj runtime.Object) (string, uint64) {
+	jsonBase, err := runtime.FindJSONBase(obj)
+	if err != nil {
+		return "", 0
+	}
+	return jsonBase.ID(), jsonBase.ResourceVersion()
+}
+
+func (c *check) createWithoutID() {
+	obj, reason, err := c.result(c.storage.Create(c.valid("")))
+	if !c.tester.GeneratesIDs {
+		if !errors.IsInvalid(err) {
+			c.errorf("expected an invalid error, got %v %v", reason, err)
+		}
+		return
+	}
+	if err != nil || reason != "" {
+		c.errorf("unexpected error: %v %v", reason, err)
+		return
+	}
+	if id, _ := idAndVersion(obj); id == "" {
+		c.errorf("expected an ID to be assigned: %#v", obj)
+	}
+}
+
+func (c *check) create() {
+	created := c.mustCreate("foo")
+	if id, version := idAndVersion(created); id != "foo" || version == 0 {
+		c.errorf("expected foo with a resourceVersion, got %#v", created)
+	}
+	got, err := c.storage.Get("foo")
+	if err != nil {
+		c.errorf("unexpected error: %v", err)
+		return
+	}
+	if id, _ := idAndVersion(got); id != "foo" {
+		c.errorf("expected foo, got %#v", got)
+	}
+}
+
+func (c *check) createExisting() {
+	c.mustCreate("foo")
+	_, reason, err := c.result(c.storage.Create(c.valid("foo")))
+	if reason != api.StatusReasonAlreadyExists && !errors.IsAlreadyExists(err) {
+		c.errorf("expected already exists, got %v %v", reason, err)
+	}
+}
+
+func (c *check) invalid() {
+	for _, obj := range c.tester.Invalid {
+		if _, err := c.storage.Create(obj); !errors.IsInvalid(err) {
+			c.errorf("expected create of %#v to be invalid, got %v", obj, err)
+		}
+		if _, err := c.storage.Update(obj); !errors.IsInvalid(err) {
+			c.errorf("expected update of %#v to be invalid, got %v", obj, err)
+		}
+	}
+}
+
+func (c *check) getMissing() {
+	if _, err := c.storage.Get("missing"); !errors.IsNotFound(err) {
+		c.errorf("expected not found, got %v", err)
+	}
+}
+
+func (c *check) updateStale() {
+	_, version := idAndVersion(c.mustCreate("foo"))
+	updated, reason, err := c.result(c.storage.Update(c.validAt("foo", version)))
+	if err != nil || reason != "" {
+		c.errorf("unexpected error: %v %v", reason, err)
+		return
+	}
+	if _, newVersion := idAndVersion(updated); newVersion == version {
+		c.errorf("expected the updated object's new resourceVersion, got %d", newVersion)
+	}
+	// The object has changed since version.
+	_, reason, err = c.result(c.storage.Update(c.validAt("foo", version)))
+	if reason != api.StatusReasonConflict && !errors.IsConflict(err) {
+		c.errorf("expected a conflict, got %v %v", reason, err)
+	}
+}
+
+func (c *check) delete() {
+	c.mustCreate("foo")
+	if _, reason, err := c.result(c.storage.Delete("foo")); err != nil || reason != "" {
+		c.errorf("unexpected error: %v %v", reason, err)
+	}
+	if _, err := c.storage.Get("foo"); !errors.IsNotFound(err) {
+		c.errorf("expected foo to be gone, got %v", err)
+	}
+}
+
+func (c *check) deleteStale() {
+	deleter, ok := c.storage.(apiserver.VersionedDeleter)
+	if !ok {
+		return
+	}
+	_, version := idAndVersion(c.mustCreate("foo"))
+	_, reason, err := c.result(deleter.DeleteIfVersion("foo", version+1))
+	if reason != api.StatusReasonConflict && !errors.IsConflict(err) {
+		c.errorf("expected a conflict, got %v %v", reason, err)
+	}
+	if _, reason, err := c.result(deleter.DeleteIfVersion("foo", version)); err != nil || reason != "" {
+		c.errorf("unexpected error: %v %v", reason, err)
+	}
+}
+
+func (c *check) watch() {
+	watcher, ok := c.storage.(apiserver.ResourceWatcher)
+	if !ok {
+		return
+	}
+	_, version := idAndVersion(c.mustCreate("foo"))
+	w, err := watcher.Watch(labels.Everything(), labels.Everything(), version)
+	if err != nil {
+		c.errorf("unexpected error: %v", err)
+		return
+	}
+	defer w.Stop()
+	c.client.WaitForWatchCompletion()
+	if c.client.WatchIndex != version+1 {
+		c.errorf("expected a watch from the change after %d, got %d", version, c.client.WatchIndex)
+	}
+
+	// Deliver the etcd change made by the next create.
+	c.client.Mutex.Lock()
+	before := map[string]bool{}
+	for key := range c.client.Data {
+		before[key] = true
+	}
+	c.client.Mutex.Unlock()
+	c.mustCreate("bar")
+	c.client.Mutex.Lock()
+	var node *etcd.Node
+	for key, value := range c.client.Data {
+		if !before[key] && value.R != nil && value.R.Node != nil {
+			node = value.R.Node
+		}
+	}
+	c.client.Mutex.Unlock()
+	if node == nil {
+		c.errorf("expected the create to write to etcd")
+		return
+	}
+	c.client.WatchResponse <- &etcd.Response{Action: "create", Node: node}
+
+	event := <-w.ResultChan()
+	if id, eventVersion := idAndVersion(event.Object); event.Type != watch.Added || id != "bar" || eventVersion != node.ModifiedIndex {
+		c.errorf("unexpected event: %#v", event)
+	}
+}
@@ -18,6 +18,7 @@ package registrytest
 
 import (
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
 )
 
@@ -43,10 +44,10 @@ func (r *ControllerRegistry) UpdateController(controller *api.ReplicationControl
 	return r.Err
 }
 
-func (r *ControllerRegistry) DeleteController(ID string) error {
+func (r *ControllerRegistry) DeleteController(ID string, resourceVersion uint64) error {
 	return r.Err
 }
 
-func (r *ControllerRegistry) WatchControllers(resourceVersion uint64) (watch.Interface, error) {
+func (r *ControllerRegistry) WatchControllers(label, field labels.Selector, resourceVersion uint64) (watch.Interface, error) {
 	return nil, r.Err
 }
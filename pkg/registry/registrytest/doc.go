@@ -15,5 +15,6 @@ limitations under the License.
 */
 
 // Package registrytest provides tests for Registry implementations
-// for storing Minions, Pods, Schedulers and Services.
+// for storing Minions, Pods and Services, and a Tester which checks that
+// a RESTStorage behaves the way every resource should.
 package registrytest
@@ -16,39 +16,62 @@ limitations under the License.
 
 package registrytest
 
-import "sync"
+import (
+	"sync"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+)
+
+// MakeMinionList constructs api.MinionList from list of minion names and a NodeResource.
+func MakeMinionList(minions []string, nodeResources api.NodeResources) *api.MinionList {
+	list := api.MinionList{
+		Items: make([]api.Minion, len(minions)),
+	}
+	for i := range minions {
+		list.Items[i].ID = minions[i]
+		list.Items[i].NodeResources = nodeResources
+	}
+	return &list
+}
 
 type MinionRegistry struct {
 	Err     error
 	Minion  string
-	Minions []string
+	Minions api.MinionList
 	sync.Mutex
 }
 
-func NewMinionRegistry(minions []string) *MinionRegistry {
+func NewMinionRegistry(minions []string, nodeResources api.NodeResources) *MinionRegistry {
 	return &MinionRegistry{
-		Minions: minions,
+		Minions: *MakeMinionList(minions, nodeResources),
 	}
 }
 
-func (r *MinionRegistry) List() ([]string, error) {
+func (r *MinionRegistry) List() (*api.MinionList, error) {
 	r.Lock()
 	defer r.Unlock()
-	return r.Minions, r.Err
+	return &r.Minions, r.Err
+}
+
+func (r *MinionRegistry) Insert(minion *api.Minion) error {
+	r.Lock()
+	defer r.Unlock()
+	r.Minion = minion.ID
+	return r.Err
 }
 
-func (r *MinionRegistry) Insert(minion string) error {
+func (r *MinionRegistry) Update(minion *api.Minion) error {
 	r.Lock()
 	defer r.Unlock()
-	r.Minion = minion
+	r.Minion = minion.ID
 	return r.Err
 }
 
 func (r *MinionRegistry) Contains(minion string) (bool, error) {
 	r.Lock()
 	defer r.Unlock()
-	for _, name := range r.Minions {
-		if name == minion {
+	for _, node := range r.Minions.Items {
+		if node.ID == minion {
 			return true, r.Err
 		}
 	}
@@ -58,12 +81,12 @@ func (r *MinionRegistry) Contains(minion string) (bool, error) {
 func (r *MinionRegistry) Delete(minion string) error {
 	r.Lock()
 	defer r.Unlock()
-	var newList []string
-	for _, name := range r.Minions {
-		if name != minion {
-			newList = append(newList, name)
+	var newList []api.Minion
+	for _, node := range r.Minions.Items {
+		if node.ID != minion {
+			newList = append(newList, node)
 		}
 	}
-	r.Minions = newList
+	r.Minions.Items = newList
 	return r.Err
 }
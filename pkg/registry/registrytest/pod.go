@@ -30,13 +30,13 @@ type PodRegistry struct {
 	Pods *api.PodList
 	sync.Mutex
 
-	mux *watch.Mux
+	broadcaster *watch.Broadcaster
 }
 
 func NewPodRegistry(pods *api.PodList) *PodRegistry {
 	return &PodRegistry{
-		Pods: pods,
-		mux:  watch.NewMux(0),
+		Pods:        pods,
+		broadcaster: watch.NewBroadcaster(0, watch.WaitIfChannelFull),
 	}
 }
 
@@ -64,8 +64,8 @@ func (r *PodRegistry) ListPods(selector labels.Selector) (*api.PodList, error) {
 }
 
 func (r *PodRegistry) WatchPods(resourceVersion uint64, filter func(*api.Pod) bool) (watch.Interface, error) {
-	// TODO: wire filter down into the mux; it needs access to current and previous state :(
-	return r.mux.Watch(), nil
+	// TODO: wire filter down into the broadcaster; it needs access to current and previous state :(
+	return r.broadcaster.Watch(), nil
 }
 
 func (r *PodRegistry) GetPod(podId string) (*api.Pod, error) {
@@ -78,7 +78,7 @@ func (r *PodRegistry) CreatePod(pod *api.Pod) error {
 	r.Lock()
 	defer r.Unlock()
 	r.Pod = pod
-	r.mux.Action(watch.Added, pod)
+	r.broadcaster.Action(watch.Added, pod)
 	return r.Err
 }
 
@@ -86,13 +86,13 @@ func (r *PodRegistry) UpdatePod(pod *api.Pod) error {
 	r.Lock()
 	defer r.Unlock()
 	r.Pod = pod
-	r.mux.Action(watch.Modified, pod)
+	r.broadcaster.Action(watch.Modified, pod)
 	return r.Err
 }
 
-func (r *PodRegistry) DeletePod(podId string) error {
+func (r *PodRegistry) DeletePod(podId string, resourceVersion uint64) error {
 	r.Lock()
 	defer r.Unlock()
-	r.mux.Action(watch.Deleted, r.Pod)
+	r.broadcaster.Action(watch.Deleted, r.Pod)
 	return r.Err
 }
@@ -18,6 +18,7 @@ package registrytest
 
 import (
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
 )
@@ -32,6 +33,8 @@ type ServiceRegistry struct {
 	Err           error
 	Endpoints     api.Endpoints
 	EndpointsList api.EndpointsList
+	// PortalIPs maps allocated portal IPs to the services using them.
+	PortalIPs map[string]string
 
 	DeletedID string
 	GottenID  string
@@ -53,7 +56,7 @@ func (r *ServiceRegistry) GetService(id string) (*api.Service, error) {
 	return r.Service, r.Err
 }
 
-func (r *ServiceRegistry) DeleteService(id string) error {
+func (r *ServiceRegistry) DeleteService(id string, resourceVersion uint64) error {
 	r.DeletedID = id
 	return r.Err
 }
@@ -67,6 +70,22 @@ func (r *ServiceRegistry) WatchServices(label, field labels.Selector, resourceVe
 	return nil, r.Err
 }
 
+func (r *ServiceRegistry) AllocatePortalIP(ip, service string) error {
+	if r.PortalIPs == nil {
+		r.PortalIPs = map[string]string{}
+	}
+	if _, ok := r.PortalIPs[ip]; ok {
+		return errors.NewAlreadyExists("portalIP", ip)
+	}
+	r.PortalIPs[ip] = service
+	return nil
+}
+
+func (r *ServiceRegistry) ReleasePortalIP(ip string) error {
+	delete(r.PortalIPs, ip)
+	return nil
+}
+
 func (r *ServiceRegistry) ListEndpoints() (*api.EndpointsList, error) {
 	return &r.EndpointsList, r.Err
 }
@@ -0,0 +1,99 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"sync"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
+)
+
+// The most addresses of a portal network that are handed out.
+const maxPortalIPs = 1 << 16
+
+// portalIPAllocator hands out IPs from the portal network.  Allocations are
+// recorded in the registry, so that two services never get the same IP, even
+// if they are created through different apiservers.
+type portalIPAllocator struct {
+	registry Registry
+	network  *net.IPNet
+	size     int64
+
+	lock sync.Mutex // protects next
+	// next is the offset in network of the next IP to try.
+	next int64
+}
+
+// newPortalIPAllocator returns an allocator for the IPs of network, except
+// its network and broadcast addresses.
+func newPortalIPAllocator(registry Registry, network *net.IPNet) *portalIPAllocator {
+	ones, bits := network.Mask.Size()
+	size := int64(maxPortalIPs)
+	if bits-ones < 17 {
+		size = 1 << uint(bits-ones)
+	}
+	return &portalIPAllocator{
+		registry: registry,
+		network:  network,
+		size:     size,
+		next:     1,
+	}
+}
+
+// Allocate returns a portal IP for the named service.
+func (a *portalIPAllocator) Allocate(service string) (net.IP, error) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	// Skip the network address, and the broadcast address if the network
+	// is small enough to include it.
+	usable := a.size - 2
+	if usable < 1 {
+		return nil, fmt.Errorf("portal network %s is too small", a.network)
+	}
+	for i := int64(0); i < usable; i++ {
+		offset := a.next
+		a.next = a.next%usable + 1
+		ip := addIPOffset(a.network.IP, offset)
+		err := a.registry.AllocatePortalIP(ip.String(), service)
+		if err == nil {
+			return ip, nil
+		}
+		if !errors.IsAlreadyExists(err) {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("no free IPs in portal network %s", a.network)
+}
+
+// Release makes a portal IP available again.
+func (a *portalIPAllocator) Release(ip string) error {
+	return a.registry.ReleasePortalIP(ip)
+}
+
+// addIPOffset returns the IP offset addresses after base.
+func addIPOffset(base net.IP, offset int64) net.IP {
+	if ip4 := base.To4(); ip4 != nil {
+		base = ip4
+	}
+	sum := new(big.Int).Add(new(big.Int).SetBytes(base), big.NewInt(offset)).Bytes()
+	ip := make(net.IP, len(base))
+	copy(ip[len(ip)-len(sum):], sum)
+	return ip
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"net"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/registry/registrytest"
+)
+
+func mustParseCIDR(t *testing.T, cidr string) *net.IPNet {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return network
+}
+
+func TestPortalIPAllocator(t *testing.T) {
+	registry := registrytest.NewServiceRegistry()
+	allocator := newPortalIPAllocator(registry, mustParseCIDR(t, "10.0.0.0/29"))
+
+	// Another apiserver already allocated 10.0.0.2.
+	registry.AllocatePortalIP("10.0.0.2", "other")
+
+	var got []string
+	for i := 0; i < 5; i++ {
+		ip, err := allocator.Allocate("foo")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got = append(got, ip.String())
+	}
+	expected := []string{"10.0.0.1", "10.0.0.3", "10.0.0.4", "10.0.0.5", "10.0.0.6"}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected, got)
+			break
+		}
+	}
+	if _, err := allocator.Allocate("foo"); err == nil {
+		t.Errorf("Expected an error from a full network")
+	}
+
+	if err := allocator.Release("10.0.0.4"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ip, err := allocator.Allocate("bar")
+	if err != nil || ip.String() != "10.0.0.4" {
+		t.Errorf("Expected the released IP, got %v, %v", ip, err)
+	}
+}
+
+func TestPortalIPAllocatorTooSmall(t *testing.T) {
+	allocator := newPortalIPAllocator(registrytest.NewServiceRegistry(), mustParseCIDR(t, "10.0.0.1/32"))
+	if _, err := allocator.Allocate("foo"); err == nil {
+		t.Errorf("Expected an error")
+	}
+}
+
+func TestAddIPOffset(t *testing.T) {
+	tests := []struct {
+		base     string
+		offset   int64
+		expected string
+	}{
+		{"10.0.0.0", 1, "10.0.0.1"},
+		{"10.0.0.0", 256, "10.0.1.0"},
+		{"fd00::", 255, "fd00::ff"},
+	}
+	for _, test := range tests {
+		if ip := addIPOffset(net.ParseIP(test.base), test.offset); ip.String() != test.expected {
+			t.Errorf("Expected %s + %d to be %s, got %s", test.base, test.offset, test.expected, ip)
+		}
+	}
+}
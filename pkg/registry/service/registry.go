@@ -28,10 +28,16 @@ type Registry interface {
 	ListServices() (*api.ServiceList, error)
 	CreateService(svc *api.Service) error
 	GetService(name string) (*api.Service, error)
-	DeleteService(name string) error
+	DeleteService(name string, resourceVersion uint64) error
 	UpdateService(svc *api.Service) error
 	WatchServices(labels, fields labels.Selector, resourceVersion uint64) (watch.Interface, error)
 
+	// AllocatePortalIP records that the named service uses a portal IP.  It
+	// fails with an AlreadyExists error if the IP is already in use.
+	AllocatePortalIP(ip, service string) error
+	// ReleasePortalIP records that a portal IP is no longer in use.
+	ReleasePortalIP(ip string) error
+
 	// TODO: endpoints and their implementation should be separated, setting endpoints should be
 	// supported via the API, and the endpoints-controller should use the API to update endpoints.
 	endpoint.Registry
@@ -19,8 +19,10 @@ package service
 import (
 	"fmt"
 	"math/rand"
+	"net"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
@@ -31,7 +33,16 @@ import (
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/registry/minion"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util/wait"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
+	"github.com/golang/glog"
+)
+
+// How often, and for how long, to look for the IP of a new external load
+// balancer, which the cloud provider may take a little while to allocate.
+var (
+	externalIPPollInterval = time.Second
+	externalIPTimeout      = time.Minute
 )
 
 // REST adapts a service registry into apiserver's RESTStorage model.
@@ -39,15 +50,22 @@ type REST struct {
 	registry Registry
 	cloud    cloudprovider.Interface
 	machines minion.Registry
+	// portalIPs is nil if services don't get portal IPs.
+	portalIPs *portalIPAllocator
 }
 
-// NewREST returns a new REST.
-func NewREST(registry Registry, cloud cloudprovider.Interface, machines minion.Registry) *REST {
-	return &REST{
+// NewREST returns a new REST.  If portalNet is not nil, each new service is
+// given a portal IP from it.
+func NewREST(registry Registry, cloud cloudprovider.Interface, machines minion.Registry, portalNet *net.IPNet) *REST {
+	rs := &REST{
 		registry: registry,
 		cloud:    cloud,
 		machines: machines,
 	}
+	if portalNet != nil {
+		rs.portalIPs = newPortalIPAllocator(registry, portalNet)
+	}
+	return rs
 }
 
 func (rs *REST) Create(obj runtime.Object) (<-chan runtime.Object, error) {
@@ -57,8 +75,31 @@ func (rs *REST) Create(obj runtime.Object) (<-chan runtime.Object, error) {
 	}
 
 	srv.CreationTimestamp = util.Now()
+	srv.Status = api.ServiceStatus{}
+	srv.PortalIP = ""
 
-	return apiserver.MakeAsync(func() (runtime.Object, error) {
+	return apiserver.MakeAsync(func() (out runtime.Object, err error) {
+		// Whatever was allocated for the service is given back if it isn't
+		// stored.
+		balancerCreated, stored := false, false
+		defer func() {
+			if err == nil || stored {
+				return
+			}
+			if balancerCreated {
+				if err := rs.deleteExternalLoadBalancer(srv); err != nil {
+					glog.Errorf("Failed to delete the external load balancer of service %s: %v", srv.ID, err)
+				}
+			}
+			rs.releasePortalIP(srv)
+		}()
+		if rs.portalIPs != nil {
+			ip, err := rs.portalIPs.Allocate(srv.ID)
+			if err != nil {
+				return nil, err
+			}
+			srv.PortalIP = ip.String()
+		}
 		// TODO: Consider moving this to a rectification loop, so that we make/remove external load balancers
 		// correctly no matter what http operations happen.
 		if srv.CreateExternalLoadBalancer {
@@ -73,7 +114,7 @@ func (rs *REST) Create(obj runtime.Object) (<-chan runtime.Object, error) {
 			if !ok {
 				return nil, fmt.Errorf("The cloud provider does not support zone enumeration.")
 			}
-			hosts, err := rs.machines.List()
+			hosts, err := rs.listMinionNames()
 			if err != nil {
 				return nil, err
 			}
@@ -85,23 +126,59 @@ func (rs *REST) Create(obj runtime.Object) (<-chan runtime.Object, error) {
 			if err != nil {
 				return nil, err
 			}
+			balancerCreated = true
+			ip, err := waitForExternalIP(balancer, srv.ID, zone.Region)
+			if err != nil {
+				glog.Errorf("Couldn't get the external IP of service %s: %v", srv.ID, err)
+			} else {
+				srv.Status.ExternalIP = ip.String()
+			}
 		}
-		err := rs.registry.CreateService(srv)
-		if err != nil {
+		if err := rs.registry.CreateService(srv); err != nil {
 			return nil, err
 		}
+		stored = true
 		return rs.registry.GetService(srv.ID)
 	}), nil
 }
 
+// releasePortalIP makes a service's portal IP, if it has one, available to
+// other services.
+func (rs *REST) releasePortalIP(service *api.Service) {
+	if rs.portalIPs == nil || len(service.PortalIP) == 0 {
+		return
+	}
+	if err := rs.portalIPs.Release(service.PortalIP); err != nil {
+		glog.Errorf("Failed to release portal IP %s of service %s: %v", service.PortalIP, service.ID, err)
+	}
+}
+
 func (rs *REST) Delete(id string) (<-chan runtime.Object, error) {
+	return rs.DeleteIfVersion(id, 0)
+}
+
+// DeleteIfVersion deletes the service only if its resourceVersion is still
+// resourceVersion.
+func (rs *REST) DeleteIfVersion(id string, resourceVersion uint64) (<-chan runtime.Object, error) {
 	service, err := rs.registry.GetService(id)
 	if err != nil {
 		return nil, err
 	}
+	// Fail early on a stale version; the registry checks again atomically.
+	if resourceVersion != 0 && service.ResourceVersion != resourceVersion {
+		return nil, errors.NewConflict("service", id, fmt.Errorf("resourceVersion is %d, not %d", service.ResourceVersion, resourceVersion))
+	}
 	return apiserver.MakeAsync(func() (runtime.Object, error) {
-		rs.deleteExternalLoadBalancer(service)
-		return &api.Status{Status: api.StatusSuccess}, rs.registry.DeleteService(id)
+		// Delete the service before its load balancer, so that a conflicting
+		// delete leaves the service working.
+		if err := rs.registry.DeleteService(id, resourceVersion); err != nil {
+			return nil, err
+		}
+		if err := rs.deleteExternalLoadBalancer(service); err != nil {
+			glog.Errorf("Failed to delete the external load balancer of service %s: %v", id, err)
+		}
+		rs.releasePortalIP(service)
+		return &api.Status{Status: api.StatusSuccess}, nil
 	}), nil
 }
 
@@ -151,7 +228,13 @@ func GetServiceEnvironmentVariables(registry Registry, machine string) ([]api.En
 		name := makeEnvVariableName(service.ID) + "_SERVICE_PORT"
 		value := strconv.Itoa(service.Port)
 		result = append(result, api.EnvVar{Name: name, Value: value})
-		result = append(result, makeLinkVariables(service, machine)...)
+		// Services with a portal IP can be reached at it from any machine.
+		host := machine
+		if len(service.PortalIP) > 0 {
+			host = service.PortalIP
+			result = append(result, api.EnvVar{Name: makeEnvVariableName(service.ID) + "_SERVICE_HOST", Value: host})
+		}
+		result = append(result, makeLinkVariables(service, host)...)
 	}
 	result = append(result, api.EnvVar{Name: "SERVICE_HOST", Value: machine})
 	return result, nil
@@ -164,7 +247,13 @@ func (rs *REST) Update(obj runtime.Object) (<-chan runtime.Object, error) {
 	}
 	return apiserver.MakeAsync(func() (runtime.Object, error) {
 		// TODO: check to see if external load balancer status changed
-		err := rs.registry.UpdateService(srv)
+		old, err := rs.registry.GetService(srv.ID)
+		if err != nil {
+			return nil, err
+		}
+		srv.Status = old.Status
+		srv.PortalIP = old.PortalIP
+		err = rs.registry.UpdateService(srv)
 		if err != nil {
 			return nil, err
 		}
@@ -184,6 +273,18 @@ func (rs *REST) ResourceLocation(id string) (string, error) {
 	return "http://" + e.Endpoints[rand.Intn(len(e.Endpoints))], nil
 }
 
+// waitForExternalIP returns the IP of the named load balancer once the cloud
+// provider has allocated one.
+func waitForExternalIP(balancer cloudprovider.TCPLoadBalancer, name, region string) (net.IP, error) {
+	var ip net.IP
+	err := wait.Poll(externalIPPollInterval, externalIPTimeout, func() (bool, error) {
+		var err error
+		ip, err = balancer.TCPLoadBalancerIP(name, region)
+		return err == nil && ip != nil, nil
+	})
+	return ip, err
+}
+
 func (rs *REST) deleteExternalLoadBalancer(service *api.Service) error {
 	if !service.CreateExternalLoadBalancer || rs.cloud == nil {
 		return nil
@@ -246,3 +347,16 @@ func makeLinkVariables(service api.Service, machine string) []api.EnvVar {
 		},
 	}
 }
+
+// listMinionNames returns the IDs of all minions known to the minion registry.
+func (rs *REST) listMinionNames() ([]string, error) {
+	minions, err := rs.machines.List()
+	if err != nil {
+		return nil, err
+	}
+	hosts := []string{}
+	for _, minion := range minions.Items {
+		hosts = append(hosts, minion.ID)
+	}
+	return hosts, nil
+}
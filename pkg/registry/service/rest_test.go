@@ -18,7 +18,10 @@ package service
 
 import (
 	"fmt"
+	"net"
+	"net/http"
 	"testing"
+	"time"
 
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/errors"
@@ -34,11 +37,13 @@ func TestServiceRegistryCreate(t *testing.T) {
 	registry := registrytest.NewServiceRegistry()
 	fakeCloud := &cloud.FakeCloud{}
 	machines := []string{"foo", "bar", "baz"}
-	storage := NewREST(registry, fakeCloud, minion.NewRegistry(machines))
+	storage := NewREST(registry, fakeCloud, minion.NewRegistry(machines, api.NodeResources{}), nil)
 	svc := &api.Service{
-		Port:     6502,
-		JSONBase: api.JSONBase{ID: "foo"},
-		Selector: map[string]string{"bar": "baz"},
+		Protocol:        api.ProtocolTCP,
+		SessionAffinity: api.AffinityTypeNone,
+		Port:            6502,
+		JSONBase:        api.JSONBase{ID: "foo"},
+		Selector:        map[string]string{"bar": "baz"},
 	}
 	c, _ := storage.Create(svc)
 	created_svc := <-c
@@ -63,7 +68,7 @@ func TestServiceRegistryCreate(t *testing.T) {
 
 func TestServiceStorageValidatesCreate(t *testing.T) {
 	registry := registrytest.NewServiceRegistry()
-	storage := NewREST(registry, nil, nil)
+	storage := NewREST(registry, nil, nil, nil)
 	failureCases := map[string]api.Service{
 		"empty ID": {
 			Port:     6502,
@@ -94,11 +99,13 @@ func TestServiceRegistryUpdate(t *testing.T) {
 		JSONBase: api.JSONBase{ID: "foo"},
 		Selector: map[string]string{"bar": "baz1"},
 	})
-	storage := NewREST(registry, nil, nil)
+	storage := NewREST(registry, nil, nil, nil)
 	c, err := storage.Update(&api.Service{
-		Port:     6502,
-		JSONBase: api.JSONBase{ID: "foo"},
-		Selector: map[string]string{"bar": "baz2"},
+		Protocol:        api.ProtocolTCP,
+		SessionAffinity: api.AffinityTypeNone,
+		Port:            6502,
+		JSONBase:        api.JSONBase{ID: "foo"},
+		Selector:        map[string]string{"bar": "baz2"},
 	})
 	if c == nil {
 		t.Errorf("Expected non-nil channel")
@@ -123,7 +130,7 @@ func TestServiceStorageValidatesUpdate(t *testing.T) {
 		JSONBase: api.JSONBase{ID: "foo"},
 		Selector: map[string]string{"bar": "baz"},
 	})
-	storage := NewREST(registry, nil, nil)
+	storage := NewREST(registry, nil, nil, nil)
 	failureCases := map[string]api.Service{
 		"empty ID": {
 			Port:     6502,
@@ -148,11 +155,14 @@ func TestServiceStorageValidatesUpdate(t *testing.T) {
 }
 
 func TestServiceRegistryExternalService(t *testing.T) {
+	externalIPPollInterval = time.Millisecond
 	registry := registrytest.NewServiceRegistry()
-	fakeCloud := &cloud.FakeCloud{}
+	fakeCloud := &cloud.FakeCloud{ExternalIP: net.ParseIP("1.2.3.4")}
 	machines := []string{"foo", "bar", "baz"}
-	storage := NewREST(registry, fakeCloud, minion.NewRegistry(machines))
+	storage := NewREST(registry, fakeCloud, minion.NewRegistry(machines, api.NodeResources{}), nil)
 	svc := &api.Service{
+		Protocol:                   api.ProtocolTCP,
+		SessionAffinity:            api.AffinityTypeNone,
 		Port:                       6502,
 		JSONBase:                   api.JSONBase{ID: "foo"},
 		Selector:                   map[string]string{"bar": "baz"},
@@ -160,7 +170,7 @@ func TestServiceRegistryExternalService(t *testing.T) {
 	}
 	c, _ := storage.Create(svc)
 	<-c
-	if len(fakeCloud.Calls) != 2 || fakeCloud.Calls[0] != "get-zone" || fakeCloud.Calls[1] != "create" {
+	if len(fakeCloud.Calls) != 3 || fakeCloud.Calls[0] != "get-zone" || fakeCloud.Calls[1] != "create" || fakeCloud.Calls[2] != "external-ip" {
 		t.Errorf("Unexpected call(s): %#v", fakeCloud.Calls)
 	}
 	srv, err := registry.GetService(svc.ID)
@@ -168,7 +178,51 @@ func TestServiceRegistryExternalService(t *testing.T) {
 		t.Errorf("Unexpected error: %v", err)
 	}
 	if srv == nil {
-		t.Errorf("Failed to find service: %s", svc.ID)
+		t.Fatalf("Failed to find service: %s", svc.ID)
+	}
+	if srv.Status.ExternalIP != "1.2.3.4" {
+		t.Errorf("Expected external IP 1.2.3.4, got %q", srv.Status.ExternalIP)
+	}
+}
+
+func TestServiceRegistryCreateIgnoresStatus(t *testing.T) {
+	registry := registrytest.NewServiceRegistry()
+	storage := NewREST(registry, nil, nil, nil)
+	svc := &api.Service{
+		Protocol:        api.ProtocolTCP,
+		SessionAffinity: api.AffinityTypeNone,
+		Port:            6502,
+		JSONBase:        api.JSONBase{ID: "foo"},
+		Selector:        map[string]string{"bar": "baz"},
+		Status:          api.ServiceStatus{ExternalIP: "1.2.3.4"},
+	}
+	c, _ := storage.Create(svc)
+	<-c
+	if registry.Service.Status.ExternalIP != "" {
+		t.Errorf("Expected no external IP, got %q", registry.Service.Status.ExternalIP)
+	}
+}
+
+func TestServiceRegistryUpdateKeepsStatus(t *testing.T) {
+	registry := registrytest.NewServiceRegistry()
+	registry.CreateService(&api.Service{
+		Port:     6502,
+		JSONBase: api.JSONBase{ID: "foo"},
+		Selector: map[string]string{"bar": "baz1"},
+		Status:   api.ServiceStatus{ExternalIP: "1.2.3.4"},
+	})
+	storage := NewREST(registry, nil, nil, nil)
+	svc := &api.Service{
+		Protocol:        api.ProtocolTCP,
+		SessionAffinity: api.AffinityTypeNone,
+		Port:            6502,
+		JSONBase:        api.JSONBase{ID: "foo"},
+		Selector:        map[string]string{"bar": "baz2"},
+	}
+	c, _ := storage.Update(svc)
+	<-c
+	if svc.Status.ExternalIP != "1.2.3.4" {
+		t.Errorf("Expected external IP 1.2.3.4 to be kept, got %q", svc.Status.ExternalIP)
 	}
 }
 
@@ -178,8 +232,10 @@ func TestServiceRegistryExternalServiceError(t *testing.T) {
 		Err: fmt.Errorf("test error"),
 	}
 	machines := []string{"foo", "bar", "baz"}
-	storage := NewREST(registry, fakeCloud, minion.NewRegistry(machines))
+	storage := NewREST(registry, fakeCloud, minion.NewRegistry(machines, api.NodeResources{}), nil)
 	svc := &api.Service{
+		Protocol:                   api.ProtocolTCP,
+		SessionAffinity:            api.AffinityTypeNone,
 		Port:                       6502,
 		JSONBase:                   api.JSONBase{ID: "foo"},
 		Selector:                   map[string]string{"bar": "baz"},
@@ -199,10 +255,12 @@ func TestServiceRegistryDelete(t *testing.T) {
 	registry := registrytest.NewServiceRegistry()
 	fakeCloud := &cloud.FakeCloud{}
 	machines := []string{"foo", "bar", "baz"}
-	storage := NewREST(registry, fakeCloud, minion.NewRegistry(machines))
+	storage := NewREST(registry, fakeCloud, minion.NewRegistry(machines, api.NodeResources{}), nil)
 	svc := &api.Service{
-		JSONBase: api.JSONBase{ID: "foo"},
-		Selector: map[string]string{"bar": "baz"},
+		Protocol:        api.ProtocolTCP,
+		SessionAffinity: api.AffinityTypeNone,
+		JSONBase:        api.JSONBase{ID: "foo"},
+		Selector:        map[string]string{"bar": "baz"},
 	}
 	registry.CreateService(svc)
 	c, _ := storage.Delete(svc.ID)
@@ -215,12 +273,76 @@ func TestServiceRegistryDelete(t *testing.T) {
 	}
 }
 
+func TestServiceRegistryDeleteIfVersion(t *testing.T) {
+	registry := registrytest.NewServiceRegistry()
+	fakeCloud := &cloud.FakeCloud{}
+	machines := []string{"foo", "bar", "baz"}
+	storage := NewREST(registry, fakeCloud, minion.NewRegistry(machines, api.NodeResources{}), nil)
+	svc := &api.Service{
+		Protocol:                   api.ProtocolTCP,
+		SessionAffinity:            api.AffinityTypeNone,
+		JSONBase:                   api.JSONBase{ID: "foo", ResourceVersion: 2},
+		Selector:                   map[string]string{"bar": "baz"},
+		CreateExternalLoadBalancer: true,
+	}
+	registry.CreateService(svc)
+	if _, err := storage.DeleteIfVersion(svc.ID, 1); !errors.IsConflict(err) {
+		t.Errorf("Expected a conflict error, got %#v", err)
+	}
+	if len(fakeCloud.Calls) != 0 {
+		t.Errorf("Unexpected call(s): %#v", fakeCloud.Calls)
+	}
+	if registry.DeletedID != "" {
+		t.Errorf("Unexpected delete of %v", registry.DeletedID)
+	}
+
+	c, _ := storage.DeleteIfVersion(svc.ID, 2)
+	<-c
+	if e, a := "foo", registry.DeletedID; e != a {
+		t.Errorf("Expected %v, but got %v", e, a)
+	}
+}
+
+// conflictingDeleteRegistry is a service registry whose deletes lose a race
+// with another update.
+type conflictingDeleteRegistry struct {
+	*registrytest.ServiceRegistry
+}
+
+func (r conflictingDeleteRegistry) DeleteService(id string, resourceVersion uint64) error {
+	return errors.NewConflict("service", id, fmt.Errorf("resourceVersion has changed"))
+}
+
+func TestServiceRegistryDeleteConflictKeepsLoadBalancer(t *testing.T) {
+	registry := conflictingDeleteRegistry{registrytest.NewServiceRegistry()}
+	fakeCloud := &cloud.FakeCloud{}
+	machines := []string{"foo", "bar", "baz"}
+	storage := NewREST(registry, fakeCloud, minion.NewRegistry(machines, api.NodeResources{}), nil)
+	svc := &api.Service{
+		Protocol:                   api.ProtocolTCP,
+		SessionAffinity:            api.AffinityTypeNone,
+		JSONBase:                   api.JSONBase{ID: "foo", ResourceVersion: 2},
+		Selector:                   map[string]string{"bar": "baz"},
+		CreateExternalLoadBalancer: true,
+	}
+	registry.CreateService(svc)
+	c, _ := storage.DeleteIfVersion(svc.ID, 2)
+	if status, ok := (<-c).(*api.Status); !ok || status.Code != http.StatusConflict {
+		t.Errorf("Expected a conflict, got %#v", status)
+	}
+	if len(fakeCloud.Calls) != 0 {
+		t.Errorf("Unexpected call(s): %#v", fakeCloud.Calls)
+	}
+}
+
 func TestServiceRegistryDeleteExternal(t *testing.T) {
 	registry := registrytest.NewServiceRegistry()
 	fakeCloud := &cloud.FakeCloud{}
 	machines := []string{"foo", "bar", "baz"}
-	storage := NewREST(registry, fakeCloud, minion.NewRegistry(machines))
+	storage := NewREST(registry, fakeCloud, minion.NewRegistry(machines, api.NodeResources{}), nil)
 	svc := &api.Service{
+		Protocol:                   api.ProtocolTCP,
+		SessionAffinity:            api.AffinityTypeNone,
 		JSONBase:                   api.JSONBase{ID: "foo"},
 		Selector:                   map[string]string{"bar": "baz"},
 		CreateExternalLoadBalancer: true,
@@ -262,7 +384,7 @@ func TestServiceRegistryGet(t *testing.T) {
 	registry := registrytest.NewServiceRegistry()
 	fakeCloud := &cloud.FakeCloud{}
 	machines := []string{"foo", "bar", "baz"}
-	storage := NewREST(registry, fakeCloud, minion.NewRegistry(machines))
+	storage := NewREST(registry, fakeCloud, minion.NewRegistry(machines, api.NodeResources{}), nil)
 	registry.CreateService(&api.Service{
 		JSONBase: api.JSONBase{ID: "foo"},
 		Selector: map[string]string{"bar": "baz"},
@@ -281,7 +403,7 @@ func TestServiceRegistryResourceLocation(t *testing.T) {
 	registry.Endpoints = api.Endpoints{Endpoints: []string{"foo:80"}}
 	fakeCloud := &cloud.FakeCloud{}
 	machines := []string{"foo", "bar", "baz"}
-	storage := NewREST(registry, fakeCloud, minion.NewRegistry(machines))
+	storage := NewREST(registry, fakeCloud, minion.NewRegistry(machines, api.NodeResources{}), nil)
 	registry.CreateService(&api.Service{
 		JSONBase: api.JSONBase{ID: "foo"},
 		Selector: map[string]string{"bar": "baz"},
@@ -309,7 +431,7 @@ func TestServiceRegistryList(t *testing.T) {
 	registry := registrytest.NewServiceRegistry()
 	fakeCloud := &cloud.FakeCloud{}
 	machines := []string{"foo", "bar", "baz"}
-	storage := NewREST(registry, fakeCloud, minion.NewRegistry(machines))
+	storage := NewREST(registry, fakeCloud, minion.NewRegistry(machines, api.NodeResources{}), nil)
 	registry.CreateService(&api.Service{
 		JSONBase: api.JSONBase{ID: "foo"},
 		Selector: map[string]string{"bar": "baz"},
@@ -337,3 +459,132 @@ func TestServiceRegistryList(t *testing.T) {
 		t.Errorf("Unexpected resource version: %#v", sl)
 	}
 }
+
+func TestServiceRegistryPortalIP(t *testing.T) {
+	registry := registrytest.NewServiceRegistry()
+	storage := NewREST(registry, nil, nil, mustParseCIDR(t, "10.0.0.0/24"))
+	svc := &api.Service{
+		Protocol:        api.ProtocolTCP,
+		SessionAffinity: api.AffinityTypeNone,
+		Port:            6502,
+		JSONBase:        api.JSONBase{ID: "foo"},
+		Selector:        map[string]string{"bar": "baz"},
+		PortalIP:        "1.2.3.4",
+	}
+	c, _ := storage.Create(svc)
+	created := (<-c).(*api.Service)
+	if created.PortalIP != "10.0.0.1" {
+		t.Errorf("Expected an IP from the portal network, got %q", created.PortalIP)
+	}
+	if registry.PortalIPs["10.0.0.1"] != "foo" {
+		t.Errorf("Expected the portal IP to be recorded, got %v", registry.PortalIPs)
+	}
+
+	update := *created
+	update.PortalIP = "1.2.3.4"
+	c, _ = storage.Update(&update)
+	<-c
+	if update.PortalIP != "10.0.0.1" {
+		t.Errorf("Expected the portal IP to be kept, got %q", update.PortalIP)
+	}
+
+	c, _ = storage.Delete(svc.ID)
+	<-c
+	if len(registry.PortalIPs) != 0 {
+		t.Errorf("Expected the portal IP to be released, got %v", registry.PortalIPs)
+	}
+}
+
+func TestServiceRegistryCreateFailureReleasesPortalIP(t *testing.T) {
+	registry := registrytest.NewServiceRegistry()
+	registry.Err = fmt.Errorf("test error")
+	storage := NewREST(registry, nil, nil, mustParseCIDR(t, "10.0.0.0/24"))
+	svc := &api.Service{
+		Protocol:        api.ProtocolTCP,
+		SessionAffinity: api.AffinityTypeNone,
+		Port:            6502,
+		JSONBase:        api.JSONBase{ID: "foo"},
+		Selector:        map[string]string{"bar": "baz"},
+	}
+	c, _ := storage.Create(svc)
+	<-c
+	if len(registry.PortalIPs) != 0 {
+		t.Errorf("Expected the portal IP to be released, got %v", registry.PortalIPs)
+	}
+}
+
+func TestServiceRegistryExternalServiceWithoutCloudReleasesPortalIP(t *testing.T) {
+	registry := registrytest.NewServiceRegistry()
+	storage := NewREST(registry, nil, nil, mustParseCIDR(t, "10.0.0.0/24"))
+	svc := &api.Service{
+		Protocol:                   api.ProtocolTCP,
+		SessionAffinity:            api.AffinityTypeNone,
+		Port:                       6502,
+		JSONBase:                   api.JSONBase{ID: "foo"},
+		Selector:                   map[string]string{"bar": "baz"},
+		CreateExternalLoadBalancer: true,
+	}
+	c, _ := storage.Create(svc)
+	if status, ok := (<-c).(*api.Status); !ok || status.Status != api.StatusFailure {
+		t.Errorf("Expected a failure without a cloud provider, got %#v", status)
+	}
+	if len(registry.PortalIPs) != 0 {
+		t.Errorf("Expected the portal IP to be released, got %v", registry.PortalIPs)
+	}
+}
+
+func TestServiceRegistryCreateFailureDeletesExternalLoadBalancer(t *testing.T) {
+	registry := registrytest.NewServiceRegistry()
+	registry.Err = fmt.Errorf("test error")
+	fakeCloud := &cloud.FakeCloud{ExternalIP: net.ParseIP("1.2.3.4")}
+	machines := []string{"foo", "bar", "baz"}
+	storage := NewREST(registry, fakeCloud, minion.NewRegistry(machines, api.NodeResources{}), mustParseCIDR(t, "10.0.0.0/24"))
+	svc := &api.Service{
+		Protocol:                   api.ProtocolTCP,
+		SessionAffinity:            api.AffinityTypeNone,
+		Port:                       6502,
+		JSONBase:                   api.JSONBase{ID: "foo"},
+		Selector:                   map[string]string{"bar": "baz"},
+		CreateExternalLoadBalancer: true,
+	}
+	c, _ := storage.Create(svc)
+	<-c
+	if len(fakeCloud.Calls) == 0 || fakeCloud.Calls[len(fakeCloud.Calls)-1] != "delete" {
+		t.Errorf("Expected the load balancer to be deleted, got calls %#v", fakeCloud.Calls)
+	}
+	if len(registry.PortalIPs) != 0 {
+		t.Errorf("Expected the portal IP to be released, got %v", registry.PortalIPs)
+	}
+}
+
+func TestServiceEnvironmentVariablesUsePortalIP(t *testing.T) {
+	registry := registrytest.NewServiceRegistry()
+	registry.List = api.ServiceList{
+		Items: []api.Service{
+			{JSONBase: api.JSONBase{ID: "foo-bar"}, Port: 8080, PortalIP: "10.0.0.1"},
+			{JSONBase: api.JSONBase{ID: "baz"}, Port: 9090},
+		},
+	}
+	vars, err := GetServiceEnvironmentVariables(registry, "machine")
+	if err != nil {
+		t.Fatalf("Unexpected err: %v", err)
+	}
+	env := map[string]string{}
+	for _, v := range vars {
+		env[v.Name] = v.Value
+	}
+	expected := map[string]string{
+		"FOO_BAR_SERVICE_HOST": "10.0.0.1",
+		"FOO_BAR_PORT":         "tcp://10.0.0.1:8080",
+		"BAZ_PORT":             "tcp://machine:9090",
+		"SERVICE_HOST":         "machine",
+	}
+	for name, value := range expected {
+		if env[name] != value {
+			t.Errorf("Expected %s=%s, got %q", name, value, env[name])
+		}
+	}
+	if _, ok := env["BAZ_SERVICE_HOST"]; ok {
+		t.Errorf("Unexpected BAZ_SERVICE_HOST for a service without a portal IP")
+	}
+}
@@ -20,6 +20,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/conversion"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
@@ -43,6 +45,45 @@ func CodecFor(scheme *Scheme, version string) Codec {
 	return &codecWrapper{scheme, version}
 }
 
+// strictCodecWrapper is a codecWrapper which refuses data with unknown fields.
+type strictCodecWrapper struct {
+	codecWrapper
+}
+
+// Decode implements Codec
+func (c *strictCodecWrapper) Decode(data []byte) (Object, error) {
+	return c.Scheme.DecodeStrict(data)
+}
+
+// DecodeInto implements Codec
+func (c *strictCodecWrapper) DecodeInto(data []byte, obj Object) error {
+	return c.Scheme.DecodeIntoStrict(data, obj)
+}
+
+// StrictCodecFor returns a Codec like CodecFor, except that decoding data with
+// fields its object doesn't have fails with an *UnknownFieldsError, rather than
+// dropping them.
+func StrictCodecFor(scheme *Scheme, version string) Codec {
+	return &strictCodecWrapper{codecWrapper{scheme, version}}
+}
+
+// UnknownFieldsError is returned by strict decoding when the data has fields
+// which the object's type doesn't.
+type UnknownFieldsError struct {
+	Kind string
+	// Fields maps the path of each unknown field to its value.
+	Fields map[string]interface{}
+}
+
+func (e *UnknownFieldsError) Error() string {
+	fields := make([]string, 0, len(e.Fields))
+	for field := range e.Fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fmt.Sprintf("%s has unknown fields: %s", e.Kind, strings.Join(fields, ", "))
+}
+
 // EncodeOrDie is a version of Encode which will panic instead of returning an error. For tests.
 func EncodeOrDie(codec Codec, obj Object) string {
 	bytes, err := codec.Encode(obj)
@@ -224,10 +265,21 @@ func (s *Scheme) New(versionName, typeName string) (Object, error) {
 // sanely copy fields that have the same names. It's OK if the destination type has
 // extra fields, but it must not remove any. So you only need to add a conversion
 // function for things with changed/removed fields.
+// Such a function can call DefaultConvert on its Scope to copy the fields which
+// didn't change, and only handle the others itself.
 func (s *Scheme) AddConversionFuncs(conversionFuncs ...interface{}) error {
 	return s.raw.AddConversionFuncs(conversionFuncs...)
 }
 
+// AddDefaultingFuncs adds functions to the list of default-setting funcs.
+// Each must take a single parameter, a pointer to a type of a particular API
+// version, and set its empty fields to their defaults. They're called when
+// objects of that version are decoded, so that validation and storage see
+// complete objects.
+func (s *Scheme) AddDefaultingFuncs(defaultingFuncs ...interface{}) error {
+	return s.raw.AddDefaultingFuncs(defaultingFuncs...)
+}
+
 // Convert will attempt to convert in into out. Both must be pointers.
 // For easy testing of conversion functions. Returns an error if the conversion isn't
 // possible.
@@ -235,6 +287,14 @@ func (s *Scheme) Convert(in, out interface{}) error {
 	return s.raw.Convert(in, out)
 }
 
+// UnmatchedFields returns the fields of the types registered in srcVersion
+// which neither match a field of the same name in destVersion's type of the
+// same kind, nor are handled by a conversion func. Tests should check that
+// it's empty between each version and the internal one.
+func (s *Scheme) UnmatchedFields(srcVersion, destVersion string) []string {
+	return s.raw.UnmatchedFields(srcVersion, destVersion)
+}
+
 // FindJSONBase takes an arbitary api type, returns pointer to its JSONBase field.
 // obj must be a pointer to an api type.
 func FindJSONBase(obj Object) (JSONBaseInterface, error) {
@@ -338,6 +398,61 @@ func (s *Scheme) DecodeInto(data []byte, obj Object) error {
 	return s.raw.DecodeInto(data, obj)
 }
 
+// DecodeStrict is like Decode, but returns an *UnknownFieldsError if data has
+// fields which its object doesn't.
+func (s *Scheme) DecodeStrict(data []byte) (Object, error) {
+	version, kind, err := s.raw.DataVersionAndKind(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkUnknownFields(data, version, kind); err != nil {
+		return nil, err
+	}
+	return s.Decode(data)
+}
+
+// DecodeIntoStrict is like DecodeInto, but returns an *UnknownFieldsError if
+// data has fields which obj doesn't.
+func (s *Scheme) DecodeIntoStrict(data []byte, obj Object) error {
+	dataVersion, dataKind, err := s.raw.DataVersionAndKind(data)
+	if err != nil {
+		return err
+	}
+	objVersion, objKind, err := s.raw.ObjectVersionAndKind(obj)
+	if err != nil {
+		return err
+	}
+	// Data without a version or kind is decoded as obj's.
+	if dataVersion == "" {
+		dataVersion = objVersion
+	}
+	if dataKind == "" {
+		dataKind = objKind
+	}
+	if err := s.checkUnknownFields(data, dataVersion, dataKind); err != nil {
+		return err
+	}
+	return s.DecodeInto(data, obj)
+}
+
+// checkUnknownFields returns an *UnknownFieldsError if data has fields which the
+// type registered for version and kind doesn't. Unregistered types are left for
+// decoding to complain about.
+func (s *Scheme) checkUnknownFields(data []byte, version, kind string) error {
+	obj, err := s.raw.NewObject(version, kind)
+	if err != nil {
+		return nil
+	}
+	fields, err := conversion.UnknownFields(data, obj)
+	if err != nil {
+		return err
+	}
+	if len(fields) > 0 {
+		return &UnknownFieldsError{Kind: kind, Fields: fields}
+	}
+	return nil
+}
+
 // Copy does a deep copy of an API object.  Useful mostly for tests.
 // TODO(dbsmith): implement directly instead of via Encode/Decode
 func (s *Scheme) Copy(obj Object) (Object, error) {
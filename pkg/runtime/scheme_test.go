@@ -139,6 +139,44 @@ func TestBadJSONRejection(t *testing.T) {
 	}*/
 }
 
+func TestStrictCodec(t *testing.T) {
+	scheme := runtime.NewScheme()
+	scheme.AddKnownTypeWithName("", "Simple", &InternalSimple{})
+	scheme.AddKnownTypeWithName("externalVersion", "Simple", &ExternalSimple{})
+	codec := runtime.StrictCodecFor(scheme, "externalVersion")
+
+	good := []byte(`{"kind": "Simple", "apiVersion": "externalVersion", "testString": "foo"}`)
+	obj, err := codec.Decode(good)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e, a := "foo", obj.(*InternalSimple).TestString; e != a {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+
+	bad := []byte(`{"kind": "Simple", "apiVersion": "externalVersion", "testStrng": "foo"}`)
+	_, err = codec.Decode(bad)
+	unknown, ok := err.(*runtime.UnknownFieldsError)
+	if !ok {
+		t.Fatalf("expected an unknown fields error, got %#v", err)
+	}
+	expected := &runtime.UnknownFieldsError{Kind: "Simple", Fields: map[string]interface{}{"testStrng": "foo"}}
+	if !reflect.DeepEqual(expected, unknown) {
+		t.Errorf("expected %#v, got %#v", expected, unknown)
+	}
+
+	// Data without a version or kind is checked against the object decoded into.
+	err = codec.DecodeInto([]byte(`{"testStrng": "foo"}`), &InternalSimple{})
+	if _, ok := err.(*runtime.UnknownFieldsError); !ok {
+		t.Errorf("expected an unknown fields error, got %#v", err)
+	}
+
+	// Non-strict codecs ignore unknown fields.
+	if _, err := runtime.CodecFor(scheme, "externalVersion").Decode(bad); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
 type ExtensionA struct {
 	runtime.PluginBase `json:",inline" yaml:",inline"`
 	TestString         string `json:"testString" yaml:"testString"`
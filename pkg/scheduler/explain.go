@@ -0,0 +1,96 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"fmt"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+)
+
+// MachineExplanation describes how a Scheduler judged a single machine for a pod.
+type MachineExplanation struct {
+	Host string `json:"host"`
+	// FailedPredicates names the predicates which rejected the pod on this
+	// machine; the pod fits if there are none.
+	FailedPredicates []string `json:"failedPredicates,omitempty"`
+	// Scores holds the unweighted score given by each priority function, and
+	// Score their weighted sum. Only machines the pod fits on are scored.
+	Scores map[string]int `json:"scores,omitempty"`
+	Score  int            `json:"score"`
+}
+
+// Explanation describes how a Scheduler would place a pod, without placing it.
+type Explanation struct {
+	Pod      string               `json:"pod"`
+	Machines []MachineExplanation `json:"machines"`
+}
+
+// Explainer is implemented by Schedulers which can explain their decisions.
+type Explainer interface {
+	Explain(api.Pod, MinionLister) (*Explanation, error)
+}
+
+// Explain evaluates every predicate and priority function for pod against the
+// machines listed by minionLister, as Schedule would, but binds nothing.
+func (g *genericScheduler) Explain(pod api.Pod, minionLister MinionLister) (*Explanation, error) {
+	machines, err := minionLister.List()
+	if err != nil {
+		return nil, err
+	}
+	filteredMachines, failedPredicates, err := findMachinesThatFit(pod, g.pods, g.predicates, machines)
+	if err != nil {
+		return nil, err
+	}
+
+	scores := map[string]map[string]int{}
+	totals := map[string]int{}
+	if len(filteredMachines) > 0 {
+		prioritizers := g.prioritizers
+		if len(prioritizers) == 0 {
+			prioritizers = []PriorityConfig{{Name: "EqualPriority", Function: EqualPriority, Weight: 1}}
+		}
+		for i, priorityConfig := range prioritizers {
+			name := priorityConfig.Name
+			if name == "" {
+				name = fmt.Sprintf("priority-%d", i)
+			}
+			prioritizedList, err := priorityConfig.Function(pod, g.pods, FakeMinionLister(filteredMachines))
+			if err != nil {
+				return nil, err
+			}
+			for _, hostEntry := range prioritizedList {
+				if scores[hostEntry.host] == nil {
+					scores[hostEntry.host] = map[string]int{}
+				}
+				scores[hostEntry.host][name] = hostEntry.score
+				totals[hostEntry.host] += hostEntry.score * priorityConfig.Weight
+			}
+		}
+	}
+
+	explanation := &Explanation{Pod: pod.ID, Machines: []MachineExplanation{}}
+	for _, machine := range machines {
+		explanation.Machines = append(explanation.Machines, MachineExplanation{
+			Host:             machine,
+			FailedPredicates: failedPredicates[machine].List(),
+			Scores:           scores[machine],
+			Score:            totals[machine],
+		})
+	}
+	return explanation, nil
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+)
+
+func TestExplain(t *testing.T) {
+	scheduler := NewGenericScheduler(
+		map[string]FitPredicate{"true": truePredicate, "matches": matchesPredicate},
+		[]PriorityConfig{
+			{Name: "numeric", Function: numericPriority, Weight: 1},
+			{Name: "reverse", Function: reverseNumericPriority, Weight: 2},
+		},
+		FakePodLister([]api.Pod{}),
+		rand.New(rand.NewSource(0)))
+
+	explanation, err := scheduler.(Explainer).Explain(api.Pod{JSONBase: api.JSONBase{ID: "2"}}, FakeMinionLister([]string{"1", "2", "3"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := &Explanation{
+		Pod: "2",
+		Machines: []MachineExplanation{
+			{Host: "1", FailedPredicates: []string{"matches"}},
+			{Host: "2", FailedPredicates: []string{}, Scores: map[string]int{"numeric": 2, "reverse": -2}, Score: -2},
+			{Host: "3", FailedPredicates: []string{"matches"}},
+		},
+	}
+	if !reflect.DeepEqual(expected, explanation) {
+		t.Errorf("expected %#v, got %#v", expected, explanation)
+	}
+}
+
+func TestExplainNoPrioritizers(t *testing.T) {
+	scheduler := NewGenericScheduler(
+		map[string]FitPredicate{"true": truePredicate},
+		nil,
+		FakePodLister([]api.Pod{}),
+		rand.New(rand.NewSource(0)))
+
+	explanation, err := scheduler.(Explainer).Explain(api.Pod{}, FakeMinionLister([]string{"m1"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []MachineExplanation{
+		{Host: "m1", FailedPredicates: []string{}, Scores: map[string]int{"EqualPriority": 1}, Score: 1},
+	}
+	if !reflect.DeepEqual(expected, explanation.Machines) {
+		t.Errorf("expected %#v, got %#v", expected, explanation.Machines)
+	}
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+)
+
+// FailedPredicateMap maps each machine to the names of the predicates which
+// rejected a pod there.
+type FailedPredicateMap map[string]util.StringSet
+
+// FitError is returned by a Scheduler when no machine satisfies all of its
+// predicates for a pod.
+type FitError struct {
+	Pod              api.Pod
+	FailedPredicates FailedPredicateMap
+}
+
+// Error returns a message naming the predicates which rejected the pod.
+func (f *FitError) Error() string {
+	predicates := util.StringSet{}
+	for _, failed := range f.FailedPredicates {
+		predicates.Insert(failed.List()...)
+	}
+	return fmt.Sprintf("failed to find fit for pod %s, rejected by %v", f.Pod.ID, predicates.List())
+}
+
+// genericScheduler is a Scheduler which filters the available machines with a set of
+// FitPredicates, ranks the machines which remain by the weighted sum of the scores
+// given by a set of PriorityFunctions, and then chooses randomly amongst the highest
+// ranked machines.
+type genericScheduler struct {
+	predicates   map[string]FitPredicate
+	prioritizers []PriorityConfig
+	pods         PodLister
+	random       *rand.Rand
+	randomLock   sync.Mutex
+}
+
+// NewGenericScheduler returns a Scheduler which places a pod on one of the machines
+// that satisfy all of the given predicates and have the highest priority. If no
+// prioritizers are given, all machines which fit have equal priority.
+func NewGenericScheduler(predicates map[string]FitPredicate, prioritizers []PriorityConfig, pods PodLister, random *rand.Rand) Scheduler {
+	return &genericScheduler{
+		predicates:   predicates,
+		prioritizers: prioritizers,
+		pods:         pods,
+		random:       random,
+	}
+}
+
+// Schedule schedules a pod on a random machine which satisfies all of the predicates
+// and has the highest priority.
+func (g *genericScheduler) Schedule(pod api.Pod, minionLister MinionLister) (string, error) {
+	machines, err := minionLister.List()
+	if err != nil {
+		return "", err
+	}
+	filteredMachines, failedPredicates, err := findMachinesThatFit(pod, g.pods, g.predicates, machines)
+	if err != nil {
+		return "", err
+	}
+	if len(filteredMachines) == 0 {
+		return "", &FitError{Pod: pod, FailedPredicates: failedPredicates}
+	}
+	priorityList, err := prioritizeMachines(pod, g.pods, g.prioritizers, FakeMinionLister(filteredMachines))
+	if err != nil {
+		return "", err
+	}
+	return g.selectHost(priorityList)
+}
+
+// selectHost chooses randomly amongst the hosts with the highest score.
+func (g *genericScheduler) selectHost(priorityList HostPriorityList) (string, error) {
+	if len(priorityList) == 0 {
+		return "", fmt.Errorf("empty priorityList")
+	}
+	// Keep hosts with equal scores in the order they were listed.
+	sort.Stable(sort.Reverse(priorityList))
+
+	hosts := getBestHosts(priorityList)
+	g.randomLock.Lock()
+	defer g.randomLock.Unlock()
+	return hosts[g.random.Int()%len(hosts)], nil
+}
+
+// getBestHosts returns the hosts which share the score of the first entry of a
+// list sorted by descending score.
+func getBestHosts(list HostPriorityList) []string {
+	result := []string{}
+	for _, hostEntry := range list {
+		if hostEntry.score != list[0].score {
+			break
+		}
+		result = append(result, hostEntry.host)
+	}
+	return result
+}
+
+// prioritizeMachines scores each machine returned by minionLister with the weighted
+// sum of the scores given by each of the priorityConfigs.
+func prioritizeMachines(pod api.Pod, podLister PodLister, priorityConfigs []PriorityConfig, minionLister MinionLister) (HostPriorityList, error) {
+	if len(priorityConfigs) == 0 {
+		return EqualPriority(pod, podLister, minionLister)
+	}
+	combinedScores := map[string]int{}
+	for _, priorityConfig := range priorityConfigs {
+		if priorityConfig.Weight == 0 {
+			continue
+		}
+		prioritizedList, err := priorityConfig.Function(pod, podLister, minionLister)
+		if err != nil {
+			return nil, err
+		}
+		for _, hostEntry := range prioritizedList {
+			combinedScores[hostEntry.host] += hostEntry.score * priorityConfig.Weight
+		}
+	}
+	machines, err := minionLister.List()
+	if err != nil {
+		return nil, err
+	}
+	result := HostPriorityList{}
+	for _, machine := range machines {
+		result = append(result, HostPriority{host: machine, score: combinedScores[machine]})
+	}
+	return result, nil
+}
+
+// findMachinesThatFit returns the machines for which every predicate returns true,
+// and the predicates which failed on each of the other machines. Predicates are
+// evaluated in order of name, so the first error they return is always the same.
+func findMachinesThatFit(pod api.Pod, podLister PodLister, predicates map[string]FitPredicate, machines []string) ([]string, FailedPredicateMap, error) {
+	// TODO: perform more targeted query...
+	pods, err := podLister.ListPods(labels.Everything())
+	if err != nil {
+		return nil, nil, err
+	}
+	names := []string{}
+	for name := range predicates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	machineToPods := MapPodsToMachines(pods)
+	filtered := []string{}
+	failedPredicates := FailedPredicateMap{}
+	for _, machine := range machines {
+		failed := util.StringSet{}
+		for _, name := range names {
+			fit, err := predicates[name](pod, machineToPods[machine], machine)
+			if err != nil {
+				return nil, nil, err
+			}
+			if !fit {
+				failed.Insert(name)
+			}
+		}
+		if len(failed) == 0 {
+			filtered = append(filtered, machine)
+		} else {
+			failedPredicates[machine] = failed
+		}
+	}
+	return filtered, failedPredicates, nil
+}
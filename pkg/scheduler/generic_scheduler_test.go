@@ -0,0 +1,263 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+)
+
+func falsePredicate(pod api.Pod, existingPods []api.Pod, machine string) (bool, error) {
+	return false, nil
+}
+
+func truePredicate(pod api.Pod, existingPods []api.Pod, machine string) (bool, error) {
+	return true, nil
+}
+
+func matchesPredicate(pod api.Pod, existingPods []api.Pod, machine string) (bool, error) {
+	return pod.ID == machine, nil
+}
+
+func numericPriority(pod api.Pod, podLister PodLister, minionLister MinionLister) (HostPriorityList, error) {
+	nodes, err := minionLister.List()
+	result := []HostPriority{}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %v", err)
+	}
+	for _, minion := range nodes {
+		score, err := strconv.Atoi(minion)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, HostPriority{
+			host:  minion,
+			score: score,
+		})
+	}
+	return result, nil
+}
+
+func reverseNumericPriority(pod api.Pod, podLister PodLister, minionLister MinionLister) (HostPriorityList, error) {
+	result, err := numericPriority(pod, podLister, minionLister)
+	if err != nil {
+		return nil, err
+	}
+	for i := range result {
+		result[i].score = -result[i].score
+	}
+	return result, nil
+}
+
+func TestSelectHost(t *testing.T) {
+	scheduler := genericScheduler{random: rand.New(rand.NewSource(0))}
+	tests := []struct {
+		list          HostPriorityList
+		possibleHosts util.StringSet
+		expectsErr    bool
+	}{
+		{
+			list: []HostPriority{
+				{host: "machine1.1", score: 1},
+				{host: "machine2.1", score: 2},
+			},
+			possibleHosts: util.NewStringSet("machine2.1"),
+		},
+		// equal scores
+		{
+			list: []HostPriority{
+				{host: "machine1.1", score: 1},
+				{host: "machine1.2", score: 2},
+				{host: "machine1.3", score: 2},
+				{host: "machine2.1", score: 2},
+			},
+			possibleHosts: util.NewStringSet("machine1.2", "machine1.3", "machine2.1"),
+		},
+		{
+			list:       []HostPriority{},
+			expectsErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		// increase the randomness
+		for i := 0; i < 10; i++ {
+			got, err := scheduler.selectHost(test.list)
+			if test.expectsErr {
+				if err == nil {
+					t.Error("Unexpected non-error")
+				}
+			} else {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				if !test.possibleHosts.Has(got) {
+					t.Errorf("got %s is not in the possible map %v", got, test.possibleHosts)
+				}
+			}
+		}
+	}
+}
+
+func TestGenericScheduler(t *testing.T) {
+	tests := []struct {
+		predicates   map[string]FitPredicate
+		prioritizers []PriorityConfig
+		minions      FakeMinionLister
+		pod          api.Pod
+		expectedHost string
+		expectsErr   bool
+	}{
+		{
+			predicates:   map[string]FitPredicate{"false": falsePredicate},
+			prioritizers: []PriorityConfig{{Function: EqualPriority, Weight: 1}},
+			minions:      FakeMinionLister{"m1", "m2"},
+			expectsErr:   true,
+		},
+		{
+			predicates:   map[string]FitPredicate{"true": truePredicate},
+			prioritizers: []PriorityConfig{{Function: EqualPriority, Weight: 1}},
+			minions:      FakeMinionLister{"m1", "m2", "m3"},
+			expectedHost: "m3",
+		},
+		{
+			predicates:   map[string]FitPredicate{"matches": matchesPredicate},
+			prioritizers: []PriorityConfig{{Function: EqualPriority, Weight: 1}},
+			minions:      FakeMinionLister{"m1", "m2", "m3"},
+			pod:          api.Pod{JSONBase: api.JSONBase{ID: "m2"}},
+			expectedHost: "m2",
+		},
+		{
+			predicates:   map[string]FitPredicate{"true": truePredicate},
+			prioritizers: []PriorityConfig{{Function: numericPriority, Weight: 1}},
+			minions:      FakeMinionLister{"3", "2", "1"},
+			expectedHost: "3",
+		},
+		{
+			predicates:   map[string]FitPredicate{"matches": matchesPredicate},
+			prioritizers: []PriorityConfig{{Function: numericPriority, Weight: 1}},
+			minions:      FakeMinionLister{"3", "2", "1"},
+			pod:          api.Pod{JSONBase: api.JSONBase{ID: "2"}},
+			expectedHost: "2",
+		},
+		{
+			predicates:   map[string]FitPredicate{"true": truePredicate},
+			prioritizers: []PriorityConfig{{Function: numericPriority, Weight: 1}, {Function: reverseNumericPriority, Weight: 2}},
+			minions:      FakeMinionLister{"3", "2", "1"},
+			expectedHost: "1",
+		},
+		{
+			predicates:   map[string]FitPredicate{"true": truePredicate},
+			prioritizers: []PriorityConfig{{Function: numericPriority, Weight: 1}, {Function: reverseNumericPriority, Weight: 0}},
+			minions:      FakeMinionLister{"3", "2", "1"},
+			expectedHost: "3",
+		},
+		{
+			predicates:   map[string]FitPredicate{"true": truePredicate, "false": falsePredicate},
+			prioritizers: []PriorityConfig{{Function: numericPriority, Weight: 1}},
+			minions:      FakeMinionLister{"3", "2", "1"},
+			expectsErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		random := rand.New(rand.NewSource(0))
+		scheduler := NewGenericScheduler(test.predicates, test.prioritizers, FakePodLister([]api.Pod{}), random)
+		machine, err := scheduler.Schedule(test.pod, test.minions)
+		if test.expectsErr {
+			if err == nil {
+				t.Error("Unexpected non-error")
+			}
+		} else {
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if test.expectedHost != machine {
+				t.Errorf("Expected: %s, Saw: %s", test.expectedHost, machine)
+			}
+		}
+	}
+}
+
+func TestGenericSchedulerFitError(t *testing.T) {
+	predicates := map[string]FitPredicate{"true": truePredicate, "matches": matchesPredicate}
+	scheduler := NewGenericScheduler(predicates, nil, FakePodLister([]api.Pod{}), rand.New(rand.NewSource(0)))
+	pod := api.Pod{JSONBase: api.JSONBase{ID: "m4"}}
+	_, err := scheduler.Schedule(pod, FakeMinionLister{"m1", "m2"})
+	fitErr, ok := err.(*FitError)
+	if !ok {
+		t.Fatalf("Expected a FitError, got %#v", err)
+	}
+	expected := FailedPredicateMap{
+		"m1": util.NewStringSet("matches"),
+		"m2": util.NewStringSet("matches"),
+	}
+	if !reflect.DeepEqual(expected, fitErr.FailedPredicates) {
+		t.Errorf("Expected %v, got %v", expected, fitErr.FailedPredicates)
+	}
+}
+
+func TestFindMachinesThatFitEvaluatesPredicatesByName(t *testing.T) {
+	evaluated := []string{}
+	recordingPredicate := func(name string) FitPredicate {
+		return func(pod api.Pod, existingPods []api.Pod, machine string) (bool, error) {
+			evaluated = append(evaluated, name)
+			return true, nil
+		}
+	}
+	predicates := map[string]FitPredicate{}
+	for _, name := range []string{"d", "b", "e", "a", "c"} {
+		predicates[name] = recordingPredicate(name)
+	}
+	if _, _, err := findMachinesThatFit(api.Pod{}, FakePodLister([]api.Pod{}), predicates, []string{"m1"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []string{"a", "b", "c", "d", "e"}
+	if !reflect.DeepEqual(expected, evaluated) {
+		t.Errorf("Expected predicates to be evaluated in order %v, got %v", expected, evaluated)
+	}
+}
+
+func TestFindMachinesThatFitCountsBoundPods(t *testing.T) {
+	// Pods just bound to a machine have no current state yet.
+	pods := []api.Pod{
+		{DesiredState: api.PodState{Host: "m1", Manifest: api.ContainerManifest{
+			Containers: []api.Container{{Ports: []api.Port{{HostPort: 8080}}}},
+		}}},
+	}
+	pod := api.Pod{DesiredState: api.PodState{Manifest: api.ContainerManifest{
+		Containers: []api.Container{{Ports: []api.Port{{HostPort: 8080}}}},
+	}}}
+	predicates := map[string]FitPredicate{"PodFitsPorts": PodFitsPorts}
+	machines, failed, err := findMachinesThatFit(pod, FakePodLister(pods), predicates, []string{"m1", "m2"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := []string{"m2"}; !reflect.DeepEqual(expected, machines) {
+		t.Errorf("Expected %v, got %v", expected, machines)
+	}
+	if expected := (FailedPredicateMap{"m1": util.NewStringSet("PodFitsPorts")}); !reflect.DeepEqual(expected, failed) {
+		t.Errorf("Expected %v, got %v", expected, failed)
+	}
+}
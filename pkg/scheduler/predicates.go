@@ -0,0 +1,256 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/golang/glog"
+)
+
+// FitPredicate is a function that indicates if a pod fits on an existing machine.
+// existingPods are the pods already assigned to that machine.
+type FitPredicate func(pod api.Pod, existingPods []api.Pod, machine string) (bool, error)
+
+// NodeInfo knows how to look up the details of a single minion.
+type NodeInfo interface {
+	GetNodeInfo(nodeID string) (*api.Minion, error)
+}
+
+// StaticNodeInfo implements NodeInfo on an api.MinionList.
+type StaticNodeInfo struct {
+	*api.MinionList
+}
+
+// GetNodeInfo returns the minion in the list with the given ID.
+func (nodes StaticNodeInfo) GetNodeInfo(nodeID string) (*api.Minion, error) {
+	for ix := range nodes.Items {
+		if nodes.Items[ix].ID == nodeID {
+			return &nodes.Items[ix], nil
+		}
+	}
+	return nil, fmt.Errorf("failed to find node: %s, %#v", nodeID, nodes)
+}
+
+// ResourceFit checks the capacity of a machine against the resources requested by
+// the pods placed on it.
+type ResourceFit struct {
+	info NodeInfo
+}
+
+type resourceRequest struct {
+	milliCPU int
+	memory   int
+}
+
+func getResourceRequest(pod *api.Pod) resourceRequest {
+	result := resourceRequest{}
+	for _, container := range pod.DesiredState.Manifest.Containers {
+		result.memory += container.Memory
+		result.milliCPU += container.CPU
+	}
+	return result
+}
+
+// getResource returns the quantity of the named resource, or 0 if it is not set.
+func getResource(resources api.ResourceList, name api.ResourceName) int {
+	value, ok := resources[name]
+	if !ok {
+		return 0
+	}
+	if value.Kind == util.IntstrString {
+		result, err := strconv.Atoi(value.StrVal)
+		if err != nil {
+			glog.Errorf("Ignoring invalid value %q for resource %s", value.StrVal, name)
+			return 0
+		}
+		return result
+	}
+	return value.IntVal
+}
+
+// PodFitsResources returns true if the machine has enough free CPU and memory for
+// the pod, and has not reached its maximum number of pods. Resources which the
+// machine does not report a capacity for are treated as unlimited.
+func (r *ResourceFit) PodFitsResources(pod api.Pod, existingPods []api.Pod, machine string) (bool, error) {
+	info, err := r.info.GetNodeInfo(machine)
+	if err != nil {
+		return false, err
+	}
+	capacity := info.NodeResources.Capacity
+	if maxPods := getResource(capacity, api.ResourcePods); maxPods > 0 && len(existingPods) >= maxPods {
+		return false, nil
+	}
+	podRequest := getResourceRequest(&pod)
+	if podRequest.milliCPU == 0 && podRequest.memory == 0 {
+		// no resources requested always fits.
+		return true, nil
+	}
+	milliCPURequested := 0
+	memoryRequested := 0
+	for ix := range existingPods {
+		existingRequest := getResourceRequest(&existingPods[ix])
+		milliCPURequested += existingRequest.milliCPU
+		memoryRequested += existingRequest.memory
+	}
+	totalMilliCPU := getResource(capacity, api.ResourceCPU)
+	totalMemory := getResource(capacity, api.ResourceMemory)
+	fitsCPU := totalMilliCPU == 0 || (totalMilliCPU-milliCPURequested) >= podRequest.milliCPU
+	fitsMemory := totalMemory == 0 || (totalMemory-memoryRequested) >= podRequest.memory
+	glog.V(3).Infof("Calculated fit for %s on %s: cpu: %v, memory %v", pod.ID, machine, fitsCPU, fitsMemory)
+	return fitsCPU && fitsMemory, nil
+}
+
+// NewResourceFitPredicate returns a FitPredicate which checks machine capacity,
+// as reported by info, against the resources requested by pods.
+func NewResourceFitPredicate(info NodeInfo) FitPredicate {
+	fit := &ResourceFit{
+		info: info,
+	}
+	return fit.PodFitsResources
+}
+
+// NodeSelector checks the labels of a machine against the NodeSelector of pods
+// placed on it.
+type NodeSelector struct {
+	info NodeInfo
+}
+
+// PodSelectorMatches returns true if the machine has every label in the pod's
+// NodeSelector, with the same value.
+func (n *NodeSelector) PodSelectorMatches(pod api.Pod, existingPods []api.Pod, machine string) (bool, error) {
+	if len(pod.NodeSelector) == 0 {
+		return true, nil
+	}
+	minion, err := n.info.GetNodeInfo(machine)
+	if err != nil {
+		return false, err
+	}
+	return labels.SelectorFromSet(pod.NodeSelector).Matches(labels.Set(minion.Labels)), nil
+}
+
+// NewSelectorMatchPredicate returns a FitPredicate which checks the labels of
+// machines, as reported by info, against the NodeSelector of pods.
+func NewSelectorMatchPredicate(info NodeInfo) FitPredicate {
+	selector := &NodeSelector{
+		info: info,
+	}
+	return selector.PodSelectorMatches
+}
+
+// PlatformMatch checks the operating system and architecture of a machine
+// against the Platforms of pods placed on it.
+type PlatformMatch struct {
+	info NodeInfo
+}
+
+// PodPlatformMatches returns true if the pod lists no Platforms, or lists the
+// machine's. A machine which does not report its platform fits no pod that
+// lists Platforms.
+func (p *PlatformMatch) PodPlatformMatches(pod api.Pod, existingPods []api.Pod, machine string) (bool, error) {
+	if len(pod.Platforms) == 0 {
+		return true, nil
+	}
+	minion, err := p.info.GetNodeInfo(machine)
+	if err != nil {
+		return false, err
+	}
+	if len(minion.OperatingSystem) == 0 || len(minion.Architecture) == 0 {
+		return false, nil
+	}
+	platform := minion.OperatingSystem + "/" + minion.Architecture
+	for _, wanted := range pod.Platforms {
+		if wanted == platform {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// NewPlatformMatchPredicate returns a FitPredicate which checks the platform of
+// machines, as reported by info, against the Platforms of pods.
+func NewPlatformMatchPredicate(info NodeInfo) FitPredicate {
+	match := &PlatformMatch{
+		info: info,
+	}
+	return match.PodPlatformMatches
+}
+
+// PodFitsAntiAffinity returns true unless the pod's AntiAffinitySelector matches
+// one of the existing pods on the machine, or one of their AntiAffinitySelectors
+// matches the pod.
+func PodFitsAntiAffinity(pod api.Pod, existingPods []api.Pod, machine string) (bool, error) {
+	for _, existing := range existingPods {
+		if selectsPod(pod.AntiAffinitySelector, existing) || selectsPod(existing.AntiAffinitySelector, pod) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// selectsPod returns true if selector is not empty and matches the pod's labels.
+func selectsPod(selector map[string]string, pod api.Pod) bool {
+	if len(selector) == 0 {
+		return false
+	}
+	return labels.SelectorFromSet(selector).Matches(labels.Set(pod.Labels))
+}
+
+// PodFitsPorts returns true if none of the host ports requested by the pod are
+// already in use by the existing pods on the machine.
+func PodFitsPorts(pod api.Pod, existingPods []api.Pod, machine string) (bool, error) {
+	existingPorts := getUsedPorts(existingPods...)
+	wantPorts := getUsedPorts(pod)
+	for wport := range wantPorts {
+		if wport == 0 {
+			continue
+		}
+		if existingPorts[wport] {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// getUsedPorts returns the set of host ports requested by the given pods.
+func getUsedPorts(pods ...api.Pod) map[int]bool {
+	ports := make(map[int]bool)
+	for _, pod := range pods {
+		for _, container := range pod.DesiredState.Manifest.Containers {
+			for _, podPort := range container.Ports {
+				ports[podPort.HostPort] = true
+			}
+		}
+	}
+	return ports
+}
+
+// MapPodsToMachines groups pods by the machine they are bound to. A pod's
+// current state only names its machine once the kubelet has reported on it,
+// so pods are grouped by their desired host, which is set when they're bound.
+func MapPodsToMachines(pods []api.Pod) map[string][]api.Pod {
+	machineToPods := map[string][]api.Pod{}
+	for _, scheduledPod := range pods {
+		host := scheduledPod.DesiredState.Host
+		machineToPods[host] = append(machineToPods[host], scheduledPod)
+	}
+	return machineToPods
+}
@@ -0,0 +1,452 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+)
+
+type FakeNodeInfo api.Minion
+
+func (n FakeNodeInfo) GetNodeInfo(nodeName string) (*api.Minion, error) {
+	node := api.Minion(n)
+	return &node, nil
+}
+
+func makeResources(milliCPU int, memory int, maxPods int) api.NodeResources {
+	resources := api.ResourceList{
+		api.ResourceCPU:    util.NewIntOrStringFromInt(milliCPU),
+		api.ResourceMemory: util.NewIntOrStringFromInt(memory),
+	}
+	if maxPods > 0 {
+		resources[api.ResourcePods] = util.NewIntOrStringFromInt(maxPods)
+	}
+	return api.NodeResources{
+		Capacity: resources,
+	}
+}
+
+func newResourcePod(usage ...resourceRequest) api.Pod {
+	containers := []api.Container{}
+	for _, req := range usage {
+		containers = append(containers, api.Container{
+			Memory: req.memory,
+			CPU:    req.milliCPU,
+		})
+	}
+	return api.Pod{
+		DesiredState: api.PodState{
+			Manifest: api.ContainerManifest{
+				Containers: containers,
+			},
+		},
+	}
+}
+
+func TestPodFitsResources(t *testing.T) {
+	tests := []struct {
+		pod          api.Pod
+		existingPods []api.Pod
+		resources    api.NodeResources
+		fits         bool
+		test         string
+	}{
+		{
+			pod: api.Pod{},
+			existingPods: []api.Pod{
+				newResourcePod(resourceRequest{milliCPU: 10, memory: 20}),
+			},
+			resources: makeResources(10, 20, 0),
+			fits:      true,
+			test:      "no resources requested always fits",
+		},
+		{
+			pod: newResourcePod(resourceRequest{milliCPU: 1, memory: 1}),
+			existingPods: []api.Pod{
+				newResourcePod(resourceRequest{milliCPU: 10, memory: 20}),
+			},
+			resources: makeResources(10, 20, 0),
+			fits:      false,
+			test:      "too many resources fails",
+		},
+		{
+			pod: newResourcePod(resourceRequest{milliCPU: 1, memory: 1}),
+			existingPods: []api.Pod{
+				newResourcePod(resourceRequest{milliCPU: 5, memory: 5}),
+			},
+			resources: makeResources(10, 20, 0),
+			fits:      true,
+			test:      "both resources fit",
+		},
+		{
+			pod: newResourcePod(resourceRequest{milliCPU: 1, memory: 2}),
+			existingPods: []api.Pod{
+				newResourcePod(resourceRequest{milliCPU: 5, memory: 19}),
+			},
+			resources: makeResources(10, 20, 0),
+			fits:      false,
+			test:      "one resource fits",
+		},
+		{
+			pod: newResourcePod(resourceRequest{milliCPU: 5, memory: 1}),
+			existingPods: []api.Pod{
+				newResourcePod(resourceRequest{milliCPU: 5, memory: 19}),
+			},
+			resources: makeResources(10, 20, 0),
+			fits:      true,
+			test:      "equal edge case",
+		},
+		{
+			pod: newResourcePod(resourceRequest{milliCPU: 1000, memory: 1000}),
+			existingPods: []api.Pod{
+				newResourcePod(resourceRequest{milliCPU: 5, memory: 19}),
+			},
+			resources: api.NodeResources{},
+			fits:      true,
+			test:      "unknown capacity is unlimited",
+		},
+		{
+			pod: api.Pod{},
+			existingPods: []api.Pod{
+				newResourcePod(),
+				newResourcePod(),
+			},
+			resources: makeResources(10, 20, 2),
+			fits:      false,
+			test:      "too many pods fails",
+		},
+	}
+
+	for _, test := range tests {
+		node := api.Minion{NodeResources: test.resources}
+
+		fit := ResourceFit{FakeNodeInfo(node)}
+		fits, err := fit.PodFitsResources(test.pod, test.existingPods, "machine")
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if fits != test.fits {
+			t.Errorf("%s: expected: %v got %v", test.test, test.fits, fits)
+		}
+	}
+}
+
+func TestStaticNodeInfo(t *testing.T) {
+	minions := &api.MinionList{
+		Items: []api.Minion{
+			{JSONBase: api.JSONBase{ID: "m1"}},
+			{JSONBase: api.JSONBase{ID: "m2"}},
+		},
+	}
+	info := StaticNodeInfo{minions}
+	node, err := info.GetNodeInfo("m2")
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(node, &minions.Items[1]) {
+		t.Errorf("expected %#v, got %#v", minions.Items[1], node)
+	}
+	if _, err := info.GetNodeInfo("m3"); err == nil {
+		t.Errorf("expected error looking up missing node")
+	}
+}
+
+func TestPodFitsSelector(t *testing.T) {
+	tests := []struct {
+		pod    api.Pod
+		labels map[string]string
+		fits   bool
+		test   string
+	}{
+		{
+			pod:  api.Pod{},
+			fits: true,
+			test: "no selector",
+		},
+		{
+			pod: api.Pod{
+				NodeSelector: map[string]string{
+					"foo": "bar",
+				},
+			},
+			fits: false,
+			test: "missing labels",
+		},
+		{
+			pod: api.Pod{
+				NodeSelector: map[string]string{
+					"foo": "bar",
+				},
+			},
+			labels: map[string]string{
+				"foo": "bar",
+			},
+			fits: true,
+			test: "same labels",
+		},
+		{
+			pod: api.Pod{
+				NodeSelector: map[string]string{
+					"foo": "bar",
+				},
+			},
+			labels: map[string]string{
+				"foo": "bar",
+				"baz": "blah",
+			},
+			fits: true,
+			test: "node labels are superset",
+		},
+		{
+			pod: api.Pod{
+				NodeSelector: map[string]string{
+					"foo": "bar",
+					"baz": "blah",
+				},
+			},
+			labels: map[string]string{
+				"foo": "bar",
+			},
+			fits: false,
+			test: "node labels are subset",
+		},
+		{
+			pod: api.Pod{
+				NodeSelector: map[string]string{
+					"foo": "bar",
+				},
+			},
+			labels: map[string]string{
+				"foo": "baz",
+			},
+			fits: false,
+			test: "different value",
+		},
+	}
+	for _, test := range tests {
+		node := api.Minion{Labels: test.labels}
+		fit := NewSelectorMatchPredicate(FakeNodeInfo(node))
+		fits, err := fit(test.pod, []api.Pod{}, "machine")
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if fits != test.fits {
+			t.Errorf("%s: expected: %v got %v", test.test, test.fits, fits)
+		}
+	}
+}
+
+func TestPodPlatformMatches(t *testing.T) {
+	tests := []struct {
+		pod  api.Pod
+		os   string
+		arch string
+		fits bool
+		test string
+	}{
+		{
+			pod:  api.Pod{},
+			fits: true,
+			test: "no platforms, unknown node",
+		},
+		{
+			pod:  api.Pod{Platforms: []string{"linux/amd64", "linux/arm"}},
+			os:   "linux",
+			arch: "arm",
+			fits: true,
+			test: "node platform listed",
+		},
+		{
+			pod:  api.Pod{Platforms: []string{"linux/amd64"}},
+			os:   "linux",
+			arch: "arm",
+			fits: false,
+			test: "node platform not listed",
+		},
+		{
+			pod:  api.Pod{Platforms: []string{"linux/amd64"}},
+			fits: false,
+			test: "unknown node platform",
+		},
+	}
+	for _, test := range tests {
+		node := api.Minion{OperatingSystem: test.os, Architecture: test.arch}
+		fit := NewPlatformMatchPredicate(FakeNodeInfo(node))
+		fits, err := fit(test.pod, []api.Pod{}, "machine")
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if fits != test.fits {
+			t.Errorf("%s: expected: %v got %v", test.test, test.fits, fits)
+		}
+	}
+}
+
+func TestPodFitsAntiAffinity(t *testing.T) {
+	db := map[string]string{"name": "db"}
+	web := map[string]string{"name": "web"}
+	tests := []struct {
+		pod          api.Pod
+		existingPods []api.Pod
+		fits         bool
+		test         string
+	}{
+		{
+			pod:  api.Pod{Labels: db, AntiAffinitySelector: db},
+			fits: true,
+			test: "nothing running",
+		},
+		{
+			pod:          api.Pod{Labels: db},
+			existingPods: []api.Pod{{Labels: db}},
+			fits:         true,
+			test:         "no selectors",
+		},
+		{
+			pod:          api.Pod{Labels: db, AntiAffinitySelector: db},
+			existingPods: []api.Pod{{Labels: web}},
+			fits:         true,
+			test:         "selector matches nothing",
+		},
+		{
+			pod:          api.Pod{Labels: db, AntiAffinitySelector: db},
+			existingPods: []api.Pod{{Labels: web}, {Labels: db}},
+			fits:         false,
+			test:         "selector matches existing pod",
+		},
+		{
+			pod:          api.Pod{Labels: db},
+			existingPods: []api.Pod{{Labels: web, AntiAffinitySelector: db}},
+			fits:         false,
+			test:         "existing pod's selector matches pod",
+		},
+	}
+	for _, test := range tests {
+		fits, err := PodFitsAntiAffinity(test.pod, test.existingPods, "machine")
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if fits != test.fits {
+			t.Errorf("%s: expected: %v got %v", test.test, test.fits, fits)
+		}
+	}
+}
+
+func TestPodFitsPorts(t *testing.T) {
+	tests := []struct {
+		pod          api.Pod
+		existingPods []api.Pod
+		fits         bool
+		test         string
+	}{
+		{
+			pod:          api.Pod{},
+			existingPods: []api.Pod{},
+			fits:         true,
+			test:         "nothing running",
+		},
+		{
+			pod: newPod("m1", 8080),
+			existingPods: []api.Pod{
+				newPod("m1", 9090),
+			},
+			fits: true,
+			test: "other port",
+		},
+		{
+			pod: newPod("m1", 8080),
+			existingPods: []api.Pod{
+				newPod("m1", 8080),
+			},
+			fits: false,
+			test: "same port",
+		},
+		{
+			pod: newPod("m1", 8000, 8080),
+			existingPods: []api.Pod{
+				newPod("m1", 8080),
+			},
+			fits: false,
+			test: "second port",
+		},
+		{
+			pod: newPod("m1", 8000, 8080),
+			existingPods: []api.Pod{
+				newPod("m1", 8001, 8080),
+			},
+			fits: false,
+			test: "second port, both pods use two ports",
+		},
+		{
+			pod: newPod("m1"),
+			existingPods: []api.Pod{
+				newPod("m1", 0),
+			},
+			fits: true,
+			test: "no host ports",
+		},
+	}
+	for _, test := range tests {
+		fits, err := PodFitsPorts(test.pod, test.existingPods, "machine")
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if test.fits != fits {
+			t.Errorf("%s: expected %v, saw %v", test.test, test.fits, fits)
+		}
+	}
+}
+
+func TestGetUsedPorts(t *testing.T) {
+	tests := []struct {
+		pods []api.Pod
+
+		ports map[int]bool
+	}{
+		{
+			[]api.Pod{
+				newPod("m1", 9090),
+			},
+			map[int]bool{9090: true},
+		},
+		{
+			[]api.Pod{
+				newPod("m1", 9090),
+				newPod("m1", 9091),
+			},
+			map[int]bool{9090: true, 9091: true},
+		},
+		{
+			[]api.Pod{
+				newPod("m1", 9090),
+				newPod("m2", 9091),
+			},
+			map[int]bool{9090: true, 9091: true},
+		},
+	}
+
+	for _, test := range tests {
+		ports := getUsedPorts(test.pods...)
+		if !reflect.DeepEqual(test.ports, ports) {
+			t.Errorf("expect %v, got %v", test.ports, ports)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+)
+
+// HostPriority represents the priority of scheduling to a particular host, higher is better.
+type HostPriority struct {
+	host  string
+	score int
+}
+
+// HostPriorityList is a sortable list of HostPriority, ordered by ascending score.
+type HostPriorityList []HostPriority
+
+func (h HostPriorityList) Len() int {
+	return len(h)
+}
+
+func (h HostPriorityList) Less(i, j int) bool {
+	return h[i].score < h[j].score
+}
+
+func (h HostPriorityList) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+
+// PriorityFunction ranks the machines returned by minionLister for running pod.
+type PriorityFunction func(pod api.Pod, podLister PodLister, minionLister MinionLister) (HostPriorityList, error)
+
+// PriorityConfig is a PriorityFunction and the weight given to its scores.
+// Name is optional and only used to label the function's scores when
+// explaining a scheduling decision.
+type PriorityConfig struct {
+	Name     string
+	Function PriorityFunction
+	Weight   int
+}
+
+// EqualPriority is a prioritizer function that gives an equal weight of one to all minions.
+func EqualPriority(pod api.Pod, podLister PodLister, minionLister MinionLister) (HostPriorityList, error) {
+	machines, err := minionLister.List()
+	if err != nil {
+		return nil, err
+	}
+	result := []HostPriority{}
+	for _, minion := range machines {
+		result = append(result, HostPriority{host: minion, score: 1})
+	}
+	return result, nil
+}
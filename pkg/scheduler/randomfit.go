@@ -17,78 +17,11 @@ limitations under the License.
 package scheduler
 
 import (
-	"fmt"
 	"math/rand"
-	"sync"
-
-	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
-	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
 )
 
-// RandomFitScheduler is a Scheduler which schedules a Pod on a random machine which matches its requirement.
-type RandomFitScheduler struct {
-	podLister  PodLister
-	random     *rand.Rand
-	randomLock sync.Mutex
-}
-
+// NewRandomFitScheduler returns a Scheduler which schedules a Pod on a random machine
+// with none of the pod's host ports in use.
 func NewRandomFitScheduler(podLister PodLister, random *rand.Rand) Scheduler {
-	return &RandomFitScheduler{
-		podLister: podLister,
-		random:    random,
-	}
-}
-
-func (s *RandomFitScheduler) containsPort(pod api.Pod, port api.Port) bool {
-	for _, container := range pod.DesiredState.Manifest.Containers {
-		for _, podPort := range container.Ports {
-			if podPort.HostPort == port.HostPort {
-				return true
-			}
-		}
-	}
-	return false
-}
-
-// Schedule schedules a pod on a random machine which matches its requirement.
-func (s *RandomFitScheduler) Schedule(pod api.Pod, minionLister MinionLister) (string, error) {
-	machines, err := minionLister.List()
-	if err != nil {
-		return "", err
-	}
-	machineToPods := map[string][]api.Pod{}
-	// TODO: perform more targeted query...
-	pods, err := s.podLister.ListPods(labels.Everything())
-	if err != nil {
-		return "", err
-	}
-	for _, scheduledPod := range pods {
-		host := scheduledPod.CurrentState.Host
-		machineToPods[host] = append(machineToPods[host], scheduledPod)
-	}
-	var machineOptions []string
-	for _, machine := range machines {
-		podFits := true
-		for _, scheduledPod := range machineToPods[machine] {
-			for _, container := range pod.DesiredState.Manifest.Containers {
-				for _, port := range container.Ports {
-					if port.HostPort == 0 {
-						continue
-					}
-					if s.containsPort(scheduledPod, port) {
-						podFits = false
-					}
-				}
-			}
-		}
-		if podFits {
-			machineOptions = append(machineOptions, machine)
-		}
-	}
-	if len(machineOptions) == 0 {
-		return "", fmt.Errorf("failed to find fit for %#v", pod)
-	}
-	s.randomLock.Lock()
-	defer s.randomLock.Unlock()
-	return machineOptions[s.random.Int()%len(machineOptions)], nil
+	return NewGenericScheduler(map[string]FitPredicate{"PodFitsPorts": PodFitsPorts}, nil, podLister, random)
 }
@@ -17,6 +17,7 @@ limitations under the License.
 package scheduler
 
 import (
+	"fmt"
 	"math/rand"
 	"testing"
 
@@ -76,3 +77,26 @@ func TestRandomFitSchedulerFirstScheduledImpossible(t *testing.T) {
 	}
 	st.expectFailure(newPod("", 8080, 8081))
 }
+
+func benchmarkRandomFitScheduler(b *testing.B, podCount, minionCount int) {
+	minions := FakeMinionLister{}
+	for i := 0; i < minionCount; i++ {
+		minions = append(minions, fmt.Sprintf("m%d", i))
+	}
+	pods := FakePodLister{}
+	for i := 0; i < podCount; i++ {
+		pods = append(pods, newPod(minions[i%minionCount], 8000+i/minionCount))
+	}
+	scheduler := NewRandomFitScheduler(pods, rand.New(rand.NewSource(0)))
+	pod := newPod("", 80)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := scheduler.Schedule(pod, minions); err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func BenchmarkRandomFitScheduler1000Pods(b *testing.B) { benchmarkRandomFitScheduler(b, 1000, 100) }
+func BenchmarkRandomFitScheduler5000Pods(b *testing.B) { benchmarkRandomFitScheduler(b, 5000, 100) }
@@ -65,10 +65,8 @@ func newPod(host string, hostPorts ...int) api.Pod {
 		networkPorts = append(networkPorts, api.Port{HostPort: port})
 	}
 	return api.Pod{
-		CurrentState: api.PodState{
-			Host: host,
-		},
 		DesiredState: api.PodState{
+			Host: host,
 			Manifest: api.ContainerManifest{
 				Containers: []api.Container{
 					{
@@ -0,0 +1,131 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/labels"
+)
+
+// CalculateSpreadPriority spreads pods which share the same labels, such as the
+// replicas of a replication controller, across minions. Minions running fewer of
+// those pods get a higher score, from 0 for the most loaded minion up to 10 for
+// minions running none.
+func CalculateSpreadPriority(pod api.Pod, podLister PodLister, minionLister MinionLister) (HostPriorityList, error) {
+	pods, err := podLister.ListPods(labels.SelectorFromSet(pod.Labels))
+	if err != nil {
+		return nil, err
+	}
+	minions, err := minionLister.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var maxCount int
+	counts := map[string]int{}
+	for _, pod := range pods {
+		counts[pod.CurrentState.Host]++
+		if counts[pod.CurrentState.Host] > maxCount {
+			maxCount = counts[pod.CurrentState.Host]
+		}
+	}
+
+	result := []HostPriority{}
+	for _, minion := range minions {
+		score := 10
+		if maxCount > 0 {
+			score = 10 * (maxCount - counts[minion]) / maxCount
+		}
+		result = append(result, HostPriority{host: minion, score: score})
+	}
+	return result, nil
+}
+
+// ZoneSpread spreads pods which share the same labels across zones, where the
+// zone of a minion is the value of one of its labels, such as its failure domain.
+type ZoneSpread struct {
+	info  NodeInfo
+	label string
+}
+
+// CalculateZoneSpreadPriority gives minions in zones running fewer of the pods
+// sharing the pod's labels a higher score, from 0 for the most loaded zone up to
+// 10 for zones running none. Minions without the label count as one zone.
+func (z *ZoneSpread) CalculateZoneSpreadPriority(pod api.Pod, podLister PodLister, minionLister MinionLister) (HostPriorityList, error) {
+	pods, err := podLister.ListPods(labels.SelectorFromSet(pod.Labels))
+	if err != nil {
+		return nil, err
+	}
+	minions, err := minionLister.List()
+	if err != nil {
+		return nil, err
+	}
+
+	zones := map[string]string{}
+	zoneOf := func(host string) (string, error) {
+		if zone, ok := zones[host]; ok {
+			return zone, nil
+		}
+		minion, err := z.info.GetNodeInfo(host)
+		if err != nil {
+			return "", err
+		}
+		zones[host] = minion.Labels[z.label]
+		return zones[host], nil
+	}
+
+	var maxCount int
+	counts := map[string]int{}
+	for _, pod := range pods {
+		if pod.CurrentState.Host == "" {
+			continue
+		}
+		zone, err := zoneOf(pod.CurrentState.Host)
+		if err != nil {
+			// The pod's minion is gone, so it doesn't count towards any zone.
+			continue
+		}
+		counts[zone]++
+		if counts[zone] > maxCount {
+			maxCount = counts[zone]
+		}
+	}
+
+	result := []HostPriority{}
+	for _, minion := range minions {
+		zone, err := zoneOf(minion)
+		if err != nil {
+			return nil, err
+		}
+		score := 10
+		if maxCount > 0 {
+			score = 10 * (maxCount - counts[zone]) / maxCount
+		}
+		result = append(result, HostPriority{host: minion, score: score})
+	}
+	return result, nil
+}
+
+// NewZoneSpreadPriority returns a PriorityFunction which spreads pods across
+// the zones given by label of the minions, as reported by info.
+func NewZoneSpreadPriority(info NodeInfo, label string) PriorityFunction {
+	spread := &ZoneSpread{
+		info:  info,
+		label: label,
+	}
+	return spread.CalculateZoneSpreadPriority
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+)
+
+func TestSpreadPriority(t *testing.T) {
+	labels1 := map[string]string{
+		"foo": "bar",
+		"baz": "blah",
+	}
+	labels2 := map[string]string{
+		"bar": "foo",
+		"baz": "blah",
+	}
+	machine1State := api.PodState{
+		Host: "machine1",
+	}
+	machine2State := api.PodState{
+		Host: "machine2",
+	}
+	tests := []struct {
+		pod          api.Pod
+		pods         []api.Pod
+		nodes        []string
+		expectedList HostPriorityList
+		test         string
+	}{
+		{
+			nodes:        []string{"machine1", "machine2"},
+			expectedList: []HostPriority{{"machine1", 10}, {"machine2", 10}},
+			test:         "nothing scheduled",
+		},
+		{
+			pod:          api.Pod{Labels: labels1},
+			pods:         []api.Pod{{CurrentState: machine1State}},
+			nodes:        []string{"machine1", "machine2"},
+			expectedList: []HostPriority{{"machine1", 10}, {"machine2", 10}},
+			test:         "no labels",
+		},
+		{
+			pod:          api.Pod{Labels: labels1},
+			pods:         []api.Pod{{CurrentState: machine1State, Labels: labels2}},
+			nodes:        []string{"machine1", "machine2"},
+			expectedList: []HostPriority{{"machine1", 10}, {"machine2", 10}},
+			test:         "different labels",
+		},
+		{
+			pod: api.Pod{Labels: labels1},
+			pods: []api.Pod{
+				{CurrentState: machine1State, Labels: labels2},
+				{CurrentState: machine2State, Labels: labels1},
+			},
+			nodes:        []string{"machine1", "machine2"},
+			expectedList: []HostPriority{{"machine1", 10}, {"machine2", 0}},
+			test:         "one label match",
+		},
+		{
+			pod: api.Pod{Labels: labels1},
+			pods: []api.Pod{
+				{CurrentState: machine1State, Labels: labels2},
+				{CurrentState: machine1State, Labels: labels1},
+				{CurrentState: machine2State, Labels: labels1},
+			},
+			nodes:        []string{"machine1", "machine2"},
+			expectedList: []HostPriority{{"machine1", 0}, {"machine2", 0}},
+			test:         "two label matches on different machines",
+		},
+		{
+			pod: api.Pod{Labels: labels1},
+			pods: []api.Pod{
+				{CurrentState: machine1State, Labels: labels2},
+				{CurrentState: machine1State, Labels: labels1},
+				{CurrentState: machine2State, Labels: labels1},
+				{CurrentState: machine2State, Labels: labels1},
+			},
+			nodes:        []string{"machine1", "machine2"},
+			expectedList: []HostPriority{{"machine1", 5}, {"machine2", 0}},
+			test:         "three label matches",
+		},
+	}
+
+	for _, test := range tests {
+		list, err := CalculateSpreadPriority(test.pod, FakePodLister(test.pods), FakeMinionLister(test.nodes))
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(test.expectedList, list) {
+			t.Errorf("%s: expected %#v, got %#v", test.test, test.expectedList, list)
+		}
+	}
+}
+
+func TestZoneSpreadPriority(t *testing.T) {
+	labels1 := map[string]string{"foo": "bar"}
+	labels2 := map[string]string{"bar": "foo"}
+	zone := func(host, zone string) api.Minion {
+		minion := api.Minion{JSONBase: api.JSONBase{ID: host}}
+		if zone != "" {
+			minion.Labels = map[string]string{"zone": zone}
+		}
+		return minion
+	}
+	onHost := func(host string, labels map[string]string) api.Pod {
+		return api.Pod{CurrentState: api.PodState{Host: host}, Labels: labels}
+	}
+	info := StaticNodeInfo{&api.MinionList{Items: []api.Minion{
+		zone("machine1", "a"),
+		zone("machine2", "a"),
+		zone("machine3", "b"),
+		zone("machine4", ""),
+	}}}
+	nodes := []string{"machine1", "machine2", "machine3", "machine4"}
+	tests := []struct {
+		pod          api.Pod
+		pods         []api.Pod
+		expectedList HostPriorityList
+		test         string
+	}{
+		{
+			pod:          api.Pod{Labels: labels1},
+			expectedList: []HostPriority{{"machine1", 10}, {"machine2", 10}, {"machine3", 10}, {"machine4", 10}},
+			test:         "nothing scheduled",
+		},
+		{
+			pod:          api.Pod{Labels: labels1},
+			pods:         []api.Pod{onHost("machine1", labels1), onHost("machine3", labels2)},
+			expectedList: []HostPriority{{"machine1", 0}, {"machine2", 0}, {"machine3", 10}, {"machine4", 10}},
+			test:         "one match in zone a",
+		},
+		{
+			pod: api.Pod{Labels: labels1},
+			pods: []api.Pod{
+				onHost("machine1", labels1),
+				onHost("machine2", labels1),
+				onHost("machine3", labels1),
+				onHost("gone", labels1),
+			},
+			expectedList: []HostPriority{{"machine1", 0}, {"machine2", 0}, {"machine3", 5}, {"machine4", 10}},
+			test:         "matches in both zones, and on a minion which is gone",
+		},
+	}
+
+	for _, test := range tests {
+		list, err := NewZoneSpreadPriority(info, "zone")(test.pod, FakePodLister(test.pods), FakeMinionLister(nodes))
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(test.expectedList, list) {
+			t.Errorf("%s: expected %#v, got %#v", test.test, test.expectedList, list)
+		}
+	}
+}
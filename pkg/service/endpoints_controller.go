@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"net"
 	"strconv"
+	"sync"
 
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
@@ -34,13 +35,25 @@ import (
 type EndpointController struct {
 	client          *client.Client
 	serviceRegistry service.Registry
+	// shard selects the services whose endpoints this controller maintains.
+	shard util.Shard
+	// workers is the number of services synced at once.
+	workers int
 }
 
 // NewEndpointController returns a new *EndpointController.
 func NewEndpointController(serviceRegistry service.Registry, client *client.Client) *EndpointController {
+	return NewShardedEndpointController(serviceRegistry, client, util.Shard{}, 1)
+}
+
+// NewShardedEndpointController returns an *EndpointController that only syncs
+// the services in shard, using at most workers goroutines at a time.
+func NewShardedEndpointController(serviceRegistry service.Registry, client *client.Client, shard util.Shard, workers int) *EndpointController {
 	return &EndpointController{
 		serviceRegistry: serviceRegistry,
 		client:          client,
+		shard:           shard,
+		workers:         workers,
 	}
 }
 
@@ -51,38 +64,56 @@ func (e *EndpointController) SyncServiceEndpoints() error {
 		glog.Errorf("Failed to list services: %v", err)
 		return err
 	}
-	var resultErr error
+	var owned []api.Service
 	for _, service := range services.Items {
-		pods, err := e.client.ListPods(labels.Set(service.Selector).AsSelector())
-		if err != nil {
-			glog.Errorf("Error syncing service: %#v, skipping.", service)
-			resultErr = err
-			continue
+		if e.shard.Owns(service.ID) {
+			owned = append(owned, service)
 		}
-		endpoints := make([]string, len(pods.Items))
-		for ix, pod := range pods.Items {
-			port, err := findPort(&pod.DesiredState.Manifest, service.ContainerPort)
-			if err != nil {
-				glog.Errorf("Failed to find port for service: %v, %v", service, err)
-				continue
-			}
-			if len(pod.CurrentState.PodIP) == 0 {
-				glog.Errorf("Failed to find an IP for pod: %v", pod)
-				continue
+	}
+	var lock sync.Mutex
+	var resultErr error
+	util.WorkByName(len(owned), e.workers,
+		func(ix int) string { return owned[ix].ID },
+		func(ix int) {
+			if err := e.syncService(owned[ix]); err != nil {
+				lock.Lock()
+				defer lock.Unlock()
+				resultErr = err
 			}
-			endpoints[ix] = net.JoinHostPort(pod.CurrentState.PodIP, strconv.Itoa(port))
-		}
-		// TODO: this is totally broken, we need to compute this and store inside an AtomicUpdate loop.
-		err = e.serviceRegistry.UpdateEndpoints(&api.Endpoints{
-			JSONBase:  api.JSONBase{ID: service.ID},
-			Endpoints: endpoints,
 		})
+	return resultErr
+}
+
+// syncService updates the endpoints of a single service. It returns an error
+// only if the service's pods could not be listed.
+func (e *EndpointController) syncService(service api.Service) error {
+	pods, err := e.client.ListPods(labels.Set(service.Selector).AsSelector())
+	if err != nil {
+		glog.Errorf("Error syncing service: %#v, skipping.", service)
+		return err
+	}
+	endpoints := make([]string, len(pods.Items))
+	for ix, pod := range pods.Items {
+		port, err := findPort(&pod.DesiredState.Manifest, service.ContainerPort)
 		if err != nil {
-			glog.Errorf("Error updating endpoints: %#v", err)
+			glog.Errorf("Failed to find port for service: %v, %v", service, err)
 			continue
 		}
+		if len(pod.CurrentState.PodIP) == 0 {
+			glog.Errorf("Failed to find an IP for pod: %v", pod)
+			continue
+		}
+		endpoints[ix] = net.JoinHostPort(pod.CurrentState.PodIP, strconv.Itoa(port))
 	}
-	return resultErr
+	// TODO: this is totally broken, we need to compute this and store inside an AtomicUpdate loop.
+	err = e.serviceRegistry.UpdateEndpoints(&api.Endpoints{
+		JSONBase:  api.JSONBase{ID: service.ID},
+		Endpoints: endpoints,
+	})
+	if err != nil {
+		glog.Errorf("Error updating endpoints: %#v", err)
+	}
+	return nil
 }
 
 // findPort locates the container port for the given manifest and portName.
@@ -163,6 +163,8 @@ func TestSyncEndpointsError(t *testing.T) {
 		serverResponse{http.StatusOK, newPodList(0)},
 		serverResponse{http.StatusInternalServerError, api.ServiceList{}})
 	client := client.NewOrDie(testServer.URL, nil)
+	// The server errors on purpose; don't wait for retries.
+	client.Retries = 0
 	serviceRegistry := registrytest.ServiceRegistry{
 		Err: fmt.Errorf("test error"),
 	}
@@ -211,6 +213,8 @@ func TestSyncEndpointsPodError(t *testing.T) {
 		serverResponse{http.StatusInternalServerError, api.PodList{}},
 		serverResponse{http.StatusOK, serviceList})
 	client := client.NewOrDie(testServer.URL, nil)
+	// The server errors on purpose; don't wait for retries.
+	client.Retries = 0
 	serviceRegistry := registrytest.ServiceRegistry{
 		List: api.ServiceList{
 			Items: []api.Service{
@@ -227,3 +231,32 @@ func TestSyncEndpointsPodError(t *testing.T) {
 		t.Error("Unexpected non-error")
 	}
 }
+
+func TestSyncEndpointsOtherShard(t *testing.T) {
+	serviceList := api.ServiceList{
+		Items: []api.Service{
+			{
+				JSONBase: api.JSONBase{ID: "foo"},
+				Selector: map[string]string{
+					"foo": "bar",
+				},
+			},
+		},
+	}
+	testServer := makeTestServer(t,
+		serverResponse{http.StatusOK, newPodList(1)},
+		serverResponse{http.StatusOK, serviceList})
+	client := client.NewOrDie(testServer.URL, nil)
+	serviceRegistry := registrytest.ServiceRegistry{}
+	shard := util.Shard{Index: 0, Count: 2}
+	if shard.Owns("foo") {
+		shard.Index = 1
+	}
+	endpoints := NewShardedEndpointController(&serviceRegistry, client, shard, 2)
+	if err := endpoints.SyncServiceEndpoints(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if len(serviceRegistry.Endpoints.ID) != 0 {
+		t.Errorf("Unexpected endpoints update: %#v", serviceRegistry.Endpoints)
+	}
+}
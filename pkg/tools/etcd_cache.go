@@ -0,0 +1,193 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package tools
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
+	"github.com/golang/glog"
+)
+
+// EtcdCache holds every object under one etcd key in memory, kept up to date
+// by a watch, so that lists and gets of those objects don't read etcd. Reads
+// reflect a recent resourceVersion, which may lag the latest write slightly.
+// Until the cache has listed the key, and while it lists again after its
+// watch ends, reads fall through to etcd.
+type EtcdCache struct {
+	helper EtcdHelper
+	key    string
+
+	lock            sync.RWMutex
+	synced          bool
+	resourceVersion uint64
+	// items maps object IDs to objects. Readers are given copies.
+	items map[string]runtime.Object
+}
+
+// NewEtcdCache creates a cache of the objects under key. Call Run to fill it.
+func NewEtcdCache(helper EtcdHelper, key string) *EtcdCache {
+	return &EtcdCache{
+		helper: helper,
+		key:    key,
+		items:  map[string]runtime.Object{},
+	}
+}
+
+// Run lists the key and then follows a watch of it, listing again whenever
+// the watch ends. Run starts a goroutine and returns immediately.
+func (c *EtcdCache) Run() {
+	go util.Forever(c.listAndWatch, time.Second)
+}
+
+func (c *EtcdCache) listAndWatch() {
+	defer c.unsync()
+	nodes, resourceVersion, err := c.helper.listEtcdNode(c.key)
+	if err != nil {
+		glog.Errorf("Failed to list %s for the cache: %v", c.key, err)
+		return
+	}
+	items := map[string]runtime.Object{}
+	for _, node := range nodes {
+		obj, err := c.helper.Codec.Decode([]byte(node.Value))
+		if err != nil {
+			glog.Errorf("Failed to decode %s for the cache: %v", node.Key, err)
+			return
+		}
+		if c.helper.ResourceVersioner != nil {
+			_ = c.helper.ResourceVersioner.SetResourceVersion(obj, node.ModifiedIndex)
+		}
+		jsonBase, err := runtime.FindJSONBase(obj)
+		if err != nil {
+			glog.Errorf("Unable to cache %s: %v", node.Key, err)
+			return
+		}
+		items[jsonBase.ID()] = obj
+	}
+
+	w, err := c.helper.WatchList(c.key, resourceVersion, Everything)
+	if err != nil {
+		glog.Errorf("Failed to watch %s for the cache: %v", c.key, err)
+		return
+	}
+	defer w.Stop()
+
+	c.lock.Lock()
+	c.items = items
+	c.resourceVersion = resourceVersion
+	c.synced = true
+	c.lock.Unlock()
+
+	for event := range w.ResultChan() {
+		if event.Type == watch.Error {
+			glog.Errorf("Watch of %s for the cache failed: %#v", c.key, event.Object)
+			return
+		}
+		jsonBase, err := runtime.FindJSONBase(event.Object)
+		if err != nil {
+			glog.Errorf("Unable to understand watch event %#v", event)
+			return
+		}
+		c.lock.Lock()
+		switch event.Type {
+		case watch.Added, watch.Modified:
+			c.items[jsonBase.ID()] = event.Object
+		case watch.Deleted:
+			delete(c.items, jsonBase.ID())
+		}
+		c.resourceVersion = jsonBase.ResourceVersion()
+		c.lock.Unlock()
+	}
+	glog.V(2).Infof("Watch of %s for the cache ended, listing again", c.key)
+}
+
+func (c *EtcdCache) unsync() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.synced = false
+}
+
+// ExtractList is like EtcdHelper.ExtractList of the cache's key, but is served
+// from memory once the cache has synced. Items are sorted by ID.
+func (c *EtcdCache) ExtractList(slicePtr interface{}, resourceVersion *uint64) error {
+	c.lock.RLock()
+	if !c.synced {
+		c.lock.RUnlock()
+		return c.helper.ExtractList(c.key, slicePtr, resourceVersion)
+	}
+	ids := make([]string, 0, len(c.items))
+	for id := range c.items {
+		ids = append(ids, id)
+	}
+	objs := make([]runtime.Object, 0, len(ids))
+	sort.Strings(ids)
+	for _, id := range ids {
+		objs = append(objs, c.items[id])
+	}
+	if resourceVersion != nil {
+		*resourceVersion = c.resourceVersion
+	}
+	c.lock.RUnlock()
+
+	pv := reflect.ValueOf(slicePtr)
+	if pv.Type().Kind() != reflect.Ptr || pv.Type().Elem().Kind() != reflect.Slice {
+		// This should not happen at runtime.
+		panic("need ptr to slice")
+	}
+	v := pv.Elem()
+	for _, obj := range objs {
+		item := reflect.New(v.Type().Elem())
+		if err := c.copyInto(obj, item.Interface().(runtime.Object)); err != nil {
+			return err
+		}
+		v.Set(reflect.Append(v, item.Elem()))
+	}
+	return nil
+}
+
+// ExtractObj is like EtcdHelper.ExtractObj of the object with the given ID
+// under the cache's key, but is served from memory if the object is cached.
+// Objects missing from the cache are read from etcd, in case they were
+// created since the cache's resourceVersion.
+func (c *EtcdCache) ExtractObj(id string, objPtr runtime.Object) error {
+	c.lock.RLock()
+	obj, found := c.items[id]
+	found = found && c.synced
+	c.lock.RUnlock()
+	if !found {
+		return c.helper.ExtractObj(c.key+"/"+id, objPtr, false)
+	}
+	return c.copyInto(obj, objPtr)
+}
+
+// copyInto sets *objPtr to a deep copy of the cached obj, so that callers
+// can modify what they read without changing the cache.
+func (c *EtcdCache) copyInto(obj, objPtr runtime.Object) error {
+	if e, a := reflect.TypeOf(objPtr), reflect.TypeOf(obj); e != a {
+		return fmt.Errorf("cache of %s holds %v, not %v", c.key, a, e)
+	}
+	data, err := c.helper.Codec.Encode(obj)
+	if err != nil {
+		return err
+	}
+	return c.helper.Codec.DecodeInto(data, objPtr)
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package tools
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/latest"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+	"github.com/coreos/go-etcd/etcd"
+)
+
+func podListResponse(index uint64, ids ...string) EtcdResponseWithError {
+	nodes := etcd.Nodes{}
+	for i, id := range ids {
+		nodes = append(nodes, &etcd.Node{
+			Key:           "/some/key/" + id,
+			Value:         runtime.EncodeOrDie(latest.Codec, &api.Pod{JSONBase: api.JSONBase{ID: id}}),
+			ModifiedIndex: uint64(i + 1),
+		})
+	}
+	return EtcdResponseWithError{
+		R: &etcd.Response{
+			Node:      &etcd.Node{Dir: true, Nodes: nodes},
+			EtcdIndex: index,
+		},
+	}
+}
+
+func cachedIDs(t *testing.T, c *EtcdCache) ([]string, uint64) {
+	var pods []api.Pod
+	var resourceVersion uint64
+	if err := c.ExtractList(&pods, &resourceVersion); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ids := []string{}
+	for _, pod := range pods {
+		ids = append(ids, pod.ID)
+	}
+	return ids, resourceVersion
+}
+
+// waitForCache waits until the cache has reached resourceVersion.
+func waitForCache(t *testing.T, c *EtcdCache, resourceVersion uint64) {
+	for i := 0; i < 100; i++ {
+		c.lock.RLock()
+		done := c.synced && c.resourceVersion >= resourceVersion
+		c.lock.RUnlock()
+		if done {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Cache never reached %d", resourceVersion)
+}
+
+func TestEtcdCache(t *testing.T) {
+	fakeClient := NewFakeEtcdClient(t)
+	fakeClient.Data["/some/key"] = podListResponse(3, "foo", "bar")
+	helper := EtcdHelper{Client: fakeClient, Codec: latest.Codec, ResourceVersioner: versioner}
+	c := NewEtcdCache(helper, "/some/key")
+
+	// Until it has synced, the cache reads etcd.
+	if ids, rv := cachedIDs(t, c); len(ids) != 2 || rv != 3 {
+		t.Errorf("Unexpected list at %d: %v", rv, ids)
+	}
+
+	go c.listAndWatch()
+	fakeClient.WaitForWatchCompletion()
+	if fakeClient.WatchIndex != 4 {
+		t.Errorf("Expected a watch from index 4, got %d", fakeClient.WatchIndex)
+	}
+	fakeClient.WatchResponse <- &etcd.Response{
+		Action: "create",
+		Node: &etcd.Node{
+			Value:         runtime.EncodeOrDie(latest.Codec, &api.Pod{JSONBase: api.JSONBase{ID: "baz"}}),
+			ModifiedIndex: 4,
+		},
+	}
+	fakeClient.WatchResponse <- &etcd.Response{
+		Action: "delete",
+		Node: &etcd.Node{
+			ModifiedIndex: 5,
+		},
+		PrevNode: &etcd.Node{
+			Value:         runtime.EncodeOrDie(latest.Codec, &api.Pod{JSONBase: api.JSONBase{ID: "foo"}}),
+			ModifiedIndex: 1,
+		},
+	}
+	waitForCache(t, c, 5)
+
+	// Once synced, etcd is no longer read.
+	fakeClient.Data["/some/key"] = podListResponse(6, "other")
+	ids, rv := cachedIDs(t, c)
+	if rv != 5 || len(ids) != 2 || ids[0] != "bar" || ids[1] != "baz" {
+		t.Errorf("Unexpected list at %d: %v", rv, ids)
+	}
+
+	var pod api.Pod
+	if err := c.ExtractObj("baz", &pod); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if pod.ID != "baz" || pod.ResourceVersion != 4 {
+		t.Errorf("Unexpected pod: %#v", pod)
+	}
+
+	// Objects missing from the cache are read from etcd.
+	fakeClient.Set("/some/key/new", runtime.EncodeOrDie(latest.Codec, &api.Pod{JSONBase: api.JSONBase{ID: "new"}}), 0)
+	pod = api.Pod{}
+	if err := c.ExtractObj("new", &pod); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if pod.ID != "new" {
+		t.Errorf("Unexpected pod: %#v", pod)
+	}
+
+	// When the watch ends, reads go to etcd until the cache lists again.
+	fakeClient.WatchInjectError <- errors.New("watch failed")
+	for i := 0; i < 100; i++ {
+		if ids, _ := cachedIDs(t, c); len(ids) == 1 && ids[0] == "other" {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Errorf("Expected reads from etcd after the watch ended")
+}
+
+func TestEtcdCacheWrongType(t *testing.T) {
+	fakeClient := NewFakeEtcdClient(t)
+	fakeClient.Data["/some/key"] = podListResponse(1, "foo")
+	helper := EtcdHelper{Client: fakeClient, Codec: latest.Codec, ResourceVersioner: versioner}
+	c := NewEtcdCache(helper, "/some/key")
+	go c.listAndWatch()
+	fakeClient.WaitForWatchCompletion()
+	waitForCache(t, c, 1)
+
+	var controllers []api.ReplicationController
+	if err := c.ExtractList(&controllers, nil); err == nil {
+		t.Errorf("Expected an error extracting pods as controllers")
+	}
+	if err := c.ExtractObj("foo", &api.Service{}); err == nil {
+		t.Errorf("Expected an error extracting a pod as a service")
+	}
+}
+
+func TestEtcdCacheReturnsCopies(t *testing.T) {
+	fakeClient := NewFakeEtcdClient(t)
+	fakeClient.Data["/some/key"] = EtcdResponseWithError{
+		R: &etcd.Response{
+			Node: &etcd.Node{Dir: true, Nodes: etcd.Nodes{{
+				Key: "/some/key/foo",
+				Value: runtime.EncodeOrDie(latest.Codec, &api.Pod{
+					JSONBase: api.JSONBase{ID: "foo"},
+					Labels:   map[string]string{"name": "foo"},
+				}),
+				ModifiedIndex: 1,
+			}}},
+			EtcdIndex: 1,
+		},
+	}
+	helper := EtcdHelper{Client: fakeClient, Codec: latest.Codec, ResourceVersioner: versioner}
+	c := NewEtcdCache(helper, "/some/key")
+	go c.listAndWatch()
+	fakeClient.WaitForWatchCompletion()
+	waitForCache(t, c, 1)
+
+	var pod api.Pod
+	if err := c.ExtractObj("foo", &pod); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if pod.ResourceVersion != 1 {
+		t.Errorf("Unexpected pod: %#v", pod)
+	}
+	pod.Labels["name"] = "changed"
+	var pods []api.Pod
+	if err := c.ExtractList(&pods, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(pods) != 1 || pods[0].Labels["name"] != "foo" {
+		t.Errorf("Expected the cache to be unchanged, got %#v", pods)
+	}
+	pods[0].Labels["name"] = "changed"
+	pod = api.Pod{}
+	if err := c.ExtractObj("foo", &pod); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if pod.Labels["name"] != "foo" {
+		t.Errorf("Expected the cache to be unchanged, got %#v", pod)
+	}
+}
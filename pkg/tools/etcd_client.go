@@ -0,0 +1,302 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tools
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+	"github.com/coreos/go-etcd/etcd"
+	"github.com/golang/glog"
+)
+
+// etcdHealthCheckPeriod is how often each server of a failover client is
+// checked.
+const etcdHealthCheckPeriod = 5 * time.Second
+
+// NewEtcdClient returns a client for the given etcd servers. If caFile is set,
+// the servers' certificates are verified against it, and if certFile and
+// keyFile are set they are presented to the servers for client auth. Given
+// more than one server, requests go to a healthy one, failing over to the
+// others when it can't be reached (see failoverEtcdClient.do), and the
+// servers are health checked in the background.
+func NewEtcdClient(servers []string, certFile, keyFile, caFile string) (EtcdClient, error) {
+	var transport *http.Transport
+	if certFile != "" || keyFile != "" || caFile != "" {
+		config, err := etcdTLSConfig(certFile, keyFile, caFile)
+		if err != nil {
+			return nil, err
+		}
+		transport = &http.Transport{TLSClientConfig: config}
+	}
+	if len(servers) <= 1 {
+		client := etcd.NewClient(servers)
+		if transport != nil {
+			client.SetTransport(transport)
+		}
+		return client, nil
+	}
+	client := newFailoverEtcdClient(servers, transport)
+	go util.Forever(client.checkHealth, etcdHealthCheckPeriod)
+	return client, nil
+}
+
+func etcdTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	config := &tls.Config{}
+	if certFile != "" || keyFile != "" {
+		if certFile == "" || keyFile == "" {
+			return nil, fmt.Errorf("both a client certificate and key are required for etcd client auth")
+		}
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	if caFile != "" {
+		data, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificates found in %s", caFile)
+		}
+		config.RootCAs = pool
+	}
+	return config, nil
+}
+
+// memberUnreachableError is returned by requests to a server of a failover
+// client that can't be reached, so that they fail over to another server.
+type memberUnreachableError struct {
+	server string
+	err    error
+}
+
+func (e *memberUnreachableError) Error() string {
+	return fmt.Sprintf("etcd server %s is unreachable: %v", e.server, e.err)
+}
+
+// notSent returns whether the request certainly never reached the server,
+// because no connection to it could be made.
+func (e *memberUnreachableError) notSent() bool {
+	err := e.err
+	if urlErr, ok := err.(*url.Error); ok {
+		err = urlErr.Err
+	}
+	opErr, ok := err.(*net.OpError)
+	return ok && opErr.Op == "dial"
+}
+
+// failFast is the CheckRetry of each server's client. Rather than retrying
+// a failed request against the same server, it gives up so that the request
+// can be sent to another.
+func failFast(cluster *etcd.Cluster, numReqs int, lastResp http.Response, err error) error {
+	return &memberUnreachableError{server: cluster.Leader, err: err}
+}
+
+// failoverEtcdClient is an EtcdClient that sends each request to one of
+// several etcd servers, trying the others in turn if it can't be reached.
+type failoverEtcdClient struct {
+	servers []string
+	members []*etcd.Client
+
+	lock sync.Mutex
+	// current is the server requests are sent to first.
+	current int
+	// healthy records which servers answered their last health check or
+	// request. Unhealthy servers are tried only once the healthy ones fail.
+	healthy []bool
+}
+
+func newFailoverEtcdClient(servers []string, transport *http.Transport) *failoverEtcdClient {
+	c := &failoverEtcdClient{
+		servers: servers,
+		healthy: make([]bool, len(servers)),
+	}
+	for i, server := range servers {
+		member := etcd.NewClient([]string{server})
+		if transport != nil {
+			member.SetTransport(transport)
+		}
+		member.CheckRetry = failFast
+		c.members = append(c.members, member)
+		c.healthy[i] = true
+	}
+	return c
+}
+
+// checkHealth sends a read to every server, and records which answer.
+func (c *failoverEtcdClient) checkHealth() {
+	for i, member := range c.members {
+		_, err := member.Get("/", false, false)
+		_, unreachable := err.(*memberUnreachableError)
+		c.lock.Lock()
+		if c.healthy[i] == unreachable {
+			if unreachable {
+				glog.Warningf("etcd server %s failed its health check: %v", c.servers[i], err)
+			} else {
+				glog.Infof("etcd server %s is healthy again", c.servers[i])
+			}
+		}
+		c.healthy[i] = !unreachable
+		c.lock.Unlock()
+	}
+}
+
+// order returns the servers to try a request against: the healthy ones,
+// starting from the current one, then the unhealthy ones.
+func (c *failoverEtcdClient) order() []int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	healthy, unhealthy := []int{}, []int{}
+	for i := range c.members {
+		n := (c.current + i) % len(c.members)
+		if c.healthy[n] {
+			healthy = append(healthy, n)
+		} else {
+			unhealthy = append(unhealthy, n)
+		}
+	}
+	return append(healthy, unhealthy...)
+}
+
+// do calls fn with each server's client in turn, until one can be reached,
+// and sends later requests to that server first.  Requests that aren't
+// idempotent are only sent to another server if they certainly weren't sent
+// to the first: one that failed after it was sent may have been applied, and
+// applying it twice would fail or, for AddChild, add two children.
+func (c *failoverEtcdClient) do(idempotent bool, fn func(member *etcd.Client) error) error {
+	for _, n := range c.order() {
+		err := fn(c.members[n])
+		unreachableErr, unreachable := err.(*memberUnreachableError)
+		c.lock.Lock()
+		c.healthy[n] = !unreachable
+		if !unreachable {
+			c.current = n
+		}
+		c.lock.Unlock()
+		if !unreachable {
+			return err
+		}
+		if !idempotent && !unreachableErr.notSent() {
+			return err
+		}
+		glog.Warningf("Failing over to another etcd server: %v", err)
+	}
+	return &etcd.EtcdError{
+		ErrorCode: etcd.ErrCodeEtcdNotReachable,
+		Message:   "All the given peers are not reachable",
+		Cause:     fmt.Sprintf("tried %v", c.servers),
+	}
+}
+
+func (c *failoverEtcdClient) AddChild(key, data string, ttl uint64) (resp *etcd.Response, err error) {
+	err = c.do(false, func(member *etcd.Client) error {
+		resp, err = member.AddChild(key, data, ttl)
+		return err
+	})
+	return resp, err
+}
+
+func (c *failoverEtcdClient) Get(key string, sort, recursive bool) (resp *etcd.Response, err error) {
+	err = c.do(true, func(member *etcd.Client) error {
+		resp, err = member.Get(key, sort, recursive)
+		return err
+	})
+	return resp, err
+}
+
+func (c *failoverEtcdClient) Set(key, value string, ttl uint64) (resp *etcd.Response, err error) {
+	err = c.do(true, func(member *etcd.Client) error {
+		resp, err = member.Set(key, value, ttl)
+		return err
+	})
+	return resp, err
+}
+
+func (c *failoverEtcdClient) Create(key, value string, ttl uint64) (resp *etcd.Response, err error) {
+	err = c.do(false, func(member *etcd.Client) error {
+		resp, err = member.Create(key, value, ttl)
+		return err
+	})
+	return resp, err
+}
+
+func (c *failoverEtcdClient) CompareAndSwap(key, value string, ttl uint64, prevValue string, prevIndex uint64) (resp *etcd.Response, err error) {
+	err = c.do(false, func(member *etcd.Client) error {
+		resp, err = member.CompareAndSwap(key, value, ttl, prevValue, prevIndex)
+		return err
+	})
+	return resp, err
+}
+
+func (c *failoverEtcdClient) Delete(key string, recursive bool) (resp *etcd.Response, err error) {
+	err = c.do(true, func(member *etcd.Client) error {
+		resp, err = member.Delete(key, recursive)
+		return err
+	})
+	return resp, err
+}
+
+func (c *failoverEtcdClient) CompareAndDelete(key string, prevValue string, prevIndex uint64) (resp *etcd.Response, err error) {
+	err = c.do(false, func(member *etcd.Client) error {
+		resp, err = member.CompareAndDelete(key, prevValue, prevIndex)
+		return err
+	})
+	return resp, err
+}
+
+// Watch is like etcd.Client's Watch. A long-running watch whose server
+// becomes unreachable continues on another server from the change after the
+// last one it received.
+func (c *failoverEtcdClient) Watch(prefix string, waitIndex uint64, recursive bool, receiver chan *etcd.Response, stop chan bool) (resp *etcd.Response, err error) {
+	if receiver == nil {
+		err = c.do(true, func(member *etcd.Client) error {
+			resp, err = member.Watch(prefix, waitIndex, recursive, nil, stop)
+			return err
+		})
+		return resp, err
+	}
+	defer close(receiver)
+	err = c.do(true, func(member *etcd.Client) error {
+		// Each server's client closes the channel it's given, so receiver
+		// is fed through another.
+		responses := make(chan *etcd.Response)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for r := range responses {
+				waitIndex = r.Node.ModifiedIndex + 1
+				receiver <- r
+			}
+		}()
+		resp, err = member.Watch(prefix, waitIndex, recursive, responses, stop)
+		<-done
+		return err
+	})
+	return resp, err
+}
@@ -0,0 +1,234 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tools
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/coreos/go-etcd/etcd"
+)
+
+func writeTempFile(t *testing.T, data []byte) string {
+	f, err := ioutil.TempFile("", "etcd_client_test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return f.Name()
+}
+
+func TestNewEtcdClientTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"action":"get","node":{"key":"/foo","value":"bar","modifiedIndex":1,"createdIndex":1}}`))
+	}))
+	defer server.Close()
+	caFile := writeTempFile(t, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.TLS.Certificates[0].Certificate[0]}))
+	defer os.Remove(caFile)
+
+	client, err := NewEtcdClient([]string{server.URL}, "", "", caFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp, err := client.Get("/foo", false, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Node.Value != "bar" {
+		t.Errorf("unexpected response: %#v", resp.Node)
+	}
+}
+
+func TestNewEtcdClientNoTLS(t *testing.T) {
+	client, err := NewEtcdClient([]string{"http://localhost:4001"}, "", "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if client == nil {
+		t.Errorf("expected a client")
+	}
+}
+
+func TestNewEtcdClientInvalid(t *testing.T) {
+	garbage := writeTempFile(t, []byte("not a certificate"))
+	defer os.Remove(garbage)
+
+	table := map[string]struct {
+		certFile, keyFile, caFile string
+	}{
+		"cert without key": {certFile: garbage},
+		"key without cert": {keyFile: garbage},
+		"invalid key pair": {certFile: garbage, keyFile: garbage},
+		"missing ca":       {caFile: "/nonexistent/ca.crt"},
+		"invalid ca":       {caFile: garbage},
+	}
+	for name, item := range table {
+		if _, err := NewEtcdClient([]string{"https://localhost:4001"}, item.certFile, item.keyFile, item.caFile); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+// newDeadServer returns the URL of a server that refuses connections.
+func newDeadServer() string {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	return server.URL
+}
+
+func newEtcdServer(value string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"action":"get","node":{"key":"/foo","value":"` + value + `","modifiedIndex":1,"createdIndex":1}}`))
+	}))
+}
+
+func TestFailoverEtcdClient(t *testing.T) {
+	server := newEtcdServer("bar")
+	defer server.Close()
+	dead := newDeadServer()
+	client := newFailoverEtcdClient([]string{dead, server.URL}, nil)
+
+	resp, err := client.Get("/foo", false, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Node.Value != "bar" {
+		t.Errorf("unexpected response: %#v", resp.Node)
+	}
+	if client.current != 1 || client.healthy[0] || !client.healthy[1] {
+		t.Errorf("expected to fail over to the live server, got current %d, healthy %v", client.current, client.healthy)
+	}
+
+	// The dead server is tried last from now on.
+	if order := client.order(); order[0] != 1 || order[1] != 0 {
+		t.Errorf("unexpected order %v", order)
+	}
+}
+
+func TestFailoverEtcdClientAllUnreachable(t *testing.T) {
+	client := newFailoverEtcdClient([]string{newDeadServer(), newDeadServer()}, nil)
+	_, err := client.Set("/foo", "bar", 0)
+	etcdErr, ok := err.(*etcd.EtcdError)
+	if !ok || etcdErr.ErrorCode != etcd.ErrCodeEtcdNotReachable {
+		t.Errorf("expected an unreachable error, got %#v", err)
+	}
+}
+
+func TestFailoverEtcdClientNonIdempotent(t *testing.T) {
+	// A server that drops each connection once it has read the request.
+	dropping := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+	}))
+	defer dropping.Close()
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"action":"create","node":{"key":"/foo","value":"bar","modifiedIndex":1,"createdIndex":1}}`))
+	}))
+	defer server.Close()
+
+	// A create that may have been applied isn't sent again.
+	client := newFailoverEtcdClient([]string{dropping.URL, server.URL}, nil)
+	if _, err := client.Create("/foo", "bar", 0); err == nil {
+		t.Errorf("expected an error")
+	}
+	if requests != 0 {
+		t.Errorf("expected no failover, got %d requests", requests)
+	}
+	// Reads are.
+	client = newFailoverEtcdClient([]string{dropping.URL, server.URL}, nil)
+	if _, err := client.Get("/foo", false, false); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("expected a failover, got %d requests", requests)
+	}
+
+	// A create that couldn't be sent is sent to another server.
+	client = newFailoverEtcdClient([]string{newDeadServer(), server.URL}, nil)
+	if _, err := client.Create("/foo", "bar", 0); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("expected a failover, got %d requests", requests)
+	}
+}
+
+func TestFailoverEtcdClientEtcdErrors(t *testing.T) {
+	notFound := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"errorCode":100,"message":"Key not found","cause":"/foo","index":3}`))
+	}))
+	defer notFound.Close()
+	server := newEtcdServer("bar")
+	defer server.Close()
+	client := newFailoverEtcdClient([]string{notFound.URL, server.URL}, nil)
+
+	// Errors from etcd itself are returned rather than failed over.
+	_, err := client.Get("/foo", false, false)
+	if !IsEtcdNotFound(err) {
+		t.Errorf("expected a not found error, got %#v", err)
+	}
+	if client.current != 0 || !client.healthy[0] {
+		t.Errorf("unexpected failover, current %d, healthy %v", client.current, client.healthy)
+	}
+}
+
+func TestFailoverEtcdClientHealthCheck(t *testing.T) {
+	server := newEtcdServer("bar")
+	defer server.Close()
+	client := newFailoverEtcdClient([]string{newDeadServer(), server.URL}, nil)
+	client.checkHealth()
+	if client.healthy[0] || !client.healthy[1] {
+		t.Errorf("unexpected health %v", client.healthy)
+	}
+	if order := client.order(); order[0] != 1 {
+		t.Errorf("expected the healthy server first, got %v", order)
+	}
+}
+
+func TestFailoverEtcdClientWatch(t *testing.T) {
+	server := newEtcdServer("bar")
+	defer server.Close()
+	client := newFailoverEtcdClient([]string{newDeadServer(), server.URL}, nil)
+
+	receiver := make(chan *etcd.Response)
+	stop := make(chan bool)
+	go client.Watch("/foo", 1, false, receiver, stop)
+	resp, ok := <-receiver
+	if !ok || resp.Node.Value != "bar" {
+		t.Fatalf("unexpected response: %#v", resp)
+	}
+	close(stop)
+	for range receiver {
+	}
+}
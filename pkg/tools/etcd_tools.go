@@ -30,6 +30,7 @@ const (
 	EtcdErrorCodeTestFailed    = 101
 	EtcdErrorCodeNodeExist     = 105
 	EtcdErrorCodeValueRequired = 200
+	EtcdErrorCodeIndexCleared  = 401
 )
 
 var (
@@ -37,6 +38,10 @@ var (
 	EtcdErrorTestFailed    = &etcd.EtcdError{ErrorCode: EtcdErrorCodeTestFailed}
 	EtcdErrorNodeExist     = &etcd.EtcdError{ErrorCode: EtcdErrorCodeNodeExist}
 	EtcdErrorValueRequired = &etcd.EtcdError{ErrorCode: EtcdErrorCodeValueRequired}
+	EtcdErrorIndexCleared  = &etcd.EtcdError{ErrorCode: EtcdErrorCodeIndexCleared}
+
+	// ErrResourceVersionRequired is returned by UpdateObj for objects without a resourceVersion.
+	ErrResourceVersionRequired = errors.New("resourceVersion must be set on objects to be updated")
 )
 
 // EtcdClient is an injectable interface for testing.
@@ -47,6 +52,7 @@ type EtcdClient interface {
 	Create(key, value string, ttl uint64) (*etcd.Response, error)
 	CompareAndSwap(key, value string, ttl uint64, prevValue string, prevIndex uint64) (*etcd.Response, error)
 	Delete(key string, recursive bool) (*etcd.Response, error)
+	CompareAndDelete(key string, prevValue string, prevIndex uint64) (*etcd.Response, error)
 	// I'd like to use directional channels here (e.g. <-chan) but this interface mimics
 	// the etcd client interface which doesn't, and it doesn't seem worth it to wrap the api.
 	Watch(prefix string, waitIndex uint64, recursive bool, receiver chan *etcd.Response, stop chan bool) (*etcd.Response, error)
@@ -58,6 +64,7 @@ type EtcdGetSet interface {
 	Set(key, value string, ttl uint64) (*etcd.Response, error)
 	Create(key, value string, ttl uint64) (*etcd.Response, error)
 	Delete(key string, recursive bool) (*etcd.Response, error)
+	CompareAndDelete(key string, prevValue string, prevIndex uint64) (*etcd.Response, error)
 	CompareAndSwap(key, value string, ttl uint64, prevValue string, prevIndex uint64) (*etcd.Response, error)
 	Watch(prefix string, waitIndex uint64, recursive bool, receiver chan *etcd.Response, stop chan bool) (*etcd.Response, error)
 }
@@ -68,6 +75,16 @@ type EtcdHelper struct {
 	Codec  runtime.Codec
 	// optional, no atomic operations can be performed without this interface
 	ResourceVersioner runtime.ResourceVersioner
+	// optional, objects never expire without this function
+	TTL TTLStrategy
+	// optional, prepended to every key so that several clusters can share
+	// an etcd cluster. TTL strategies see keys without it.
+	PathPrefix string
+}
+
+// etcdKey returns the etcd key which key is stored under.
+func (h *EtcdHelper) etcdKey(key string) string {
+	return h.PathPrefix + key
 }
 
 // IsEtcdNotFound returns true iff err is an etcd not found error.
@@ -85,6 +102,18 @@ func IsEtcdTestFailed(err error) bool {
 	return isEtcdErrorNum(err, EtcdErrorCodeTestFailed)
 }
 
+// IsEtcdIndexCleared returns true iff err is an etcd error for a watch from an
+// index so old that etcd no longer has the history after it.
+func IsEtcdIndexCleared(err error) bool {
+	return isEtcdErrorNum(err, EtcdErrorCodeIndexCleared)
+}
+
+// IsEtcdNotReachable returns true iff err is an etcd error for servers that
+// couldn't be reached.
+func IsEtcdNotReachable(err error) bool {
+	return isEtcdErrorNum(err, etcd.ErrCodeEtcdNotReachable)
+}
+
 // IsEtcdWatchStoppedByUser returns true iff err is a client triggered stop.
 func IsEtcdWatchStoppedByUser(err error) bool {
 	return etcd.ErrWatchStoppedByUser == err
@@ -106,7 +135,7 @@ func etcdErrorIndex(err error) (uint64, bool) {
 }
 
 func (h *EtcdHelper) listEtcdNode(key string) ([]*etcd.Node, uint64, error) {
-	result, err := h.Client.Get(key, false, true)
+	result, err := h.Client.Get(h.etcdKey(key), false, true)
 	if err != nil {
 		index, ok := etcdErrorIndex(err)
 		if !ok {
@@ -161,7 +190,7 @@ func (h *EtcdHelper) ExtractObj(key string, objPtr runtime.Object, ignoreNotFoun
 }
 
 func (h *EtcdHelper) bodyAndExtractObj(key string, objPtr runtime.Object, ignoreNotFound bool) (body string, modifiedIndex uint64, err error) {
-	response, err := h.Client.Get(key, false, false)
+	response, err := h.Client.Get(h.etcdKey(key), false, false)
 
 	if err != nil && !IsEtcdNotFound(err) {
 		return "", 0, err
@@ -185,7 +214,8 @@ func (h *EtcdHelper) bodyAndExtractObj(key string, objPtr runtime.Object, ignore
 	return body, response.Node.ModifiedIndex, err
 }
 
-// CreateObj adds a new object at a key unless it already exists.
+// CreateObj adds a new object at a key unless it already exists. On success,
+// obj's ResourceVersion is set to the version it was stored at.
 func (h *EtcdHelper) CreateObj(key string, obj runtime.Object) error {
 	data, err := h.Codec.Encode(obj)
 	if err != nil {
@@ -197,13 +227,38 @@ func (h *EtcdHelper) CreateObj(key string, obj runtime.Object) error {
 		}
 	}
 
-	_, err = h.Client.Create(key, string(data), 0)
-	return err
+	response, err := h.Client.Create(h.etcdKey(key), string(data), h.ttl(key, obj))
+	if err != nil {
+		return err
+	}
+	h.setStoredVersion(obj, response)
+	return nil
+}
+
+// setStoredVersion sets obj's ResourceVersion to the index etcd stored it at,
+// so that callers can return what they wrote without reading it back.
+func (h *EtcdHelper) setStoredVersion(obj runtime.Object, response *etcd.Response) {
+	if h.ResourceVersioner == nil || response == nil || response.Node == nil {
+		return
+	}
+	// being unable to set the version does not make the write fail
+	_ = h.ResourceVersioner.SetResourceVersion(obj, response.Node.ModifiedIndex)
 }
 
 // Delete removes the specified key.
 func (h *EtcdHelper) Delete(key string, recursive bool) error {
-	_, err := h.Client.Delete(key, recursive)
+	_, err := h.Client.Delete(h.etcdKey(key), recursive)
+	return err
+}
+
+// DeleteIfVersion removes the specified key, but only if its current
+// ResourceVersion is resourceVersion. A zero resourceVersion deletes
+// unconditionally.
+func (h *EtcdHelper) DeleteIfVersion(key string, resourceVersion uint64) error {
+	if resourceVersion == 0 {
+		return h.Delete(key, false)
+	}
+	_, err := h.Client.CompareAndDelete(h.etcdKey(key), "", resourceVersion)
 	return err
 }
 
@@ -216,16 +271,44 @@ func (h *EtcdHelper) SetObj(key string, obj runtime.Object) error {
 	}
 	if h.ResourceVersioner != nil {
 		if version, err := h.ResourceVersioner.ResourceVersion(obj); err == nil && version != 0 {
-			_, err = h.Client.CompareAndSwap(key, string(data), 0, "", version)
+			_, err = h.Client.CompareAndSwap(h.etcdKey(key), string(data), h.ttl(key, obj), "", version)
 			return err // err is shadowed!
 		}
 	}
 
 	// Create will fail if a key already exists.
-	_, err = h.Client.Create(key, string(data), 0)
+	_, err = h.Client.Create(h.etcdKey(key), string(data), h.ttl(key, obj))
 	return err
 }
 
+// UpdateObj replaces the object stored under key with obj, but only if obj's
+// ResourceVersion matches the stored object's. It fails if the key doesn't
+// exist or obj has no ResourceVersion, so updates can't silently overwrite
+// changes made since obj was read. On success, obj's ResourceVersion is set to
+// the version it was stored at.
+func (h *EtcdHelper) UpdateObj(key string, obj runtime.Object) error {
+	if h.ResourceVersioner == nil {
+		return fmt.Errorf("no ResourceVersioner to update %s with", key)
+	}
+	version, err := h.ResourceVersioner.ResourceVersion(obj)
+	if err != nil {
+		return err
+	}
+	if version == 0 {
+		return ErrResourceVersionRequired
+	}
+	data, err := h.Codec.Encode(obj)
+	if err != nil {
+		return err
+	}
+	response, err := h.Client.CompareAndSwap(h.etcdKey(key), string(data), h.ttl(key, obj), "", version)
+	if err != nil {
+		return err
+	}
+	h.setStoredVersion(obj, response)
+	return nil
+}
+
 // Pass an EtcdUpdateFunc to EtcdHelper.AtomicUpdate to make an atomic etcd update.
 // See the comment for AtomicUpdate for more detail.
 type EtcdUpdateFunc func(input runtime.Object) (output runtime.Object, err error)
@@ -235,7 +318,7 @@ type EtcdUpdateFunc func(input runtime.Object) (output runtime.Object, err error
 //
 // Example:
 //
-// h := &util.EtcdHelper{client, encoding, versioning}
+// h := &util.EtcdHelper{Client: client, Codec: encoding, ResourceVersioner: versioning}
 // err := h.AtomicUpdate("myKey", &MyType{}, func(input runtime.Object) (runtime.Object, error) {
 //	// Before this function is called, currentObj has been reset to etcd's current
 //	// contents for "myKey".
@@ -272,9 +355,11 @@ func (h *EtcdHelper) AtomicUpdate(key string, ptrToType runtime.Object, tryUpdat
 			return err
 		}
 
+		ttl := h.ttl(key, ret)
+
 		// First time this key has been used, try creating new value.
 		if index == 0 {
-			_, err = h.Client.Create(key, string(data), 0)
+			_, err = h.Client.Create(h.etcdKey(key), string(data), ttl)
 			if IsEtcdNodeExist(err) {
 				continue
 			}
@@ -285,7 +370,7 @@ func (h *EtcdHelper) AtomicUpdate(key string, ptrToType runtime.Object, tryUpdat
 			return nil
 		}
 
-		_, err = h.Client.CompareAndSwap(key, string(data), 0, origBody, index)
+		_, err = h.Client.CompareAndSwap(h.etcdKey(key), string(data), ttl, origBody, index)
 		if IsEtcdTestFailed(err) {
 			continue
 		}
@@ -95,7 +95,7 @@ func TestExtractList(t *testing.T) {
 	}
 
 	var got []api.Pod
-	helper := EtcdHelper{fakeClient, latest.Codec, versioner}
+	helper := EtcdHelper{Client: fakeClient, Codec: latest.Codec, ResourceVersioner: versioner}
 	resourceVersion := uint64(0)
 	err := helper.ExtractList("/some/key", &got, &resourceVersion)
 	if err != nil {
@@ -116,7 +116,7 @@ func TestExtractObj(t *testing.T) {
 	fakeClient := NewFakeEtcdClient(t)
 	expect := api.Pod{JSONBase: api.JSONBase{ID: "foo"}}
 	fakeClient.Set("/some/key", util.EncodeJSON(expect), 0)
-	helper := EtcdHelper{fakeClient, latest.Codec, versioner}
+	helper := EtcdHelper{Client: fakeClient, Codec: latest.Codec, ResourceVersioner: versioner}
 	var got api.Pod
 	err := helper.ExtractObj("/some/key", &got, false)
 	if err != nil {
@@ -149,7 +149,7 @@ func TestExtractObjNotFoundErr(t *testing.T) {
 			},
 		},
 	}
-	helper := EtcdHelper{fakeClient, codec, versioner}
+	helper := EtcdHelper{Client: fakeClient, Codec: codec, ResourceVersioner: versioner}
 	try := func(key string) {
 		var got api.Pod
 		err := helper.ExtractObj(key, &got, false)
@@ -170,7 +170,7 @@ func TestExtractObjNotFoundErr(t *testing.T) {
 func TestSetObj(t *testing.T) {
 	obj := &api.Pod{JSONBase: api.JSONBase{ID: "foo"}}
 	fakeClient := NewFakeEtcdClient(t)
-	helper := EtcdHelper{fakeClient, latest.Codec, versioner}
+	helper := EtcdHelper{Client: fakeClient, Codec: latest.Codec, ResourceVersioner: versioner}
 	err := helper.SetObj("/some/key", obj)
 	if err != nil {
 		t.Errorf("Unexpected error %#v", err)
@@ -186,6 +186,56 @@ func TestSetObj(t *testing.T) {
 	}
 }
 
+func TestCreateObj(t *testing.T) {
+	obj := &api.Pod{JSONBase: api.JSONBase{ID: "foo"}}
+	fakeClient := NewFakeEtcdClient(t)
+	fakeClient.TestIndex = true
+	helper := EtcdHelper{Client: fakeClient, Codec: latest.Codec, ResourceVersioner: versioner}
+	expect := runtime.EncodeOrDie(latest.Codec, obj)
+	if err := helper.CreateObj("/some/key", obj); err != nil {
+		t.Fatalf("Unexpected error %#v", err)
+	}
+	node := fakeClient.Data["/some/key"].R.Node
+	if node.Value != expect {
+		t.Errorf("Wanted %v, got %v", expect, node.Value)
+	}
+	if obj.ResourceVersion == 0 || obj.ResourceVersion != node.ModifiedIndex {
+		t.Errorf("Expected the stored version %d, got %d", node.ModifiedIndex, obj.ResourceVersion)
+	}
+	if err := helper.CreateObj("/some/key", &api.Pod{JSONBase: api.JSONBase{ID: "foo"}}); !IsEtcdNodeExist(err) {
+		t.Errorf("Expected a node exists error, got %#v", err)
+	}
+}
+
+func TestPathPrefix(t *testing.T) {
+	obj := &api.Pod{JSONBase: api.JSONBase{ID: "foo"}}
+	fakeClient := NewFakeEtcdClient(t)
+	helper := EtcdHelper{Client: fakeClient, Codec: latest.Codec, ResourceVersioner: versioner, PathPrefix: "/cluster"}
+	if err := helper.SetObj("/some/key", obj); err != nil {
+		t.Fatalf("Unexpected error %#v", err)
+	}
+	if _, ok := fakeClient.Data["/cluster/some/key"]; !ok {
+		t.Errorf("Expected the object under the prefix, got %#v", fakeClient.Data)
+	}
+	if _, ok := fakeClient.Data["/some/key"]; ok {
+		t.Errorf("Unexpected object outside the prefix")
+	}
+
+	var got api.Pod
+	if err := helper.ExtractObj("/some/key", &got, false); err != nil {
+		t.Fatalf("Unexpected error %#v", err)
+	}
+	if got.ID != "foo" {
+		t.Errorf("Wanted foo, got %#v", got)
+	}
+	if err := helper.Delete("/some/key", false); err != nil {
+		t.Fatalf("Unexpected error %#v", err)
+	}
+	if len(fakeClient.DeletedKeys) != 1 || fakeClient.DeletedKeys[0] != "/cluster/some/key" {
+		t.Errorf("Unexpected deleted keys %#v", fakeClient.DeletedKeys)
+	}
+}
+
 func TestSetObjWithVersion(t *testing.T) {
 	obj := &api.Pod{JSONBase: api.JSONBase{ID: "foo", ResourceVersion: 1}}
 	fakeClient := NewFakeEtcdClient(t)
@@ -199,7 +249,7 @@ func TestSetObjWithVersion(t *testing.T) {
 		},
 	}
 
-	helper := EtcdHelper{fakeClient, latest.Codec, versioner}
+	helper := EtcdHelper{Client: fakeClient, Codec: latest.Codec, ResourceVersioner: versioner}
 	err := helper.SetObj("/some/key", obj)
 	if err != nil {
 		t.Fatalf("Unexpected error %#v", err)
@@ -215,10 +265,77 @@ func TestSetObjWithVersion(t *testing.T) {
 	}
 }
 
+func TestUpdateObj(t *testing.T) {
+	fakeClient := NewFakeEtcdClient(t)
+	fakeClient.TestIndex = true
+	resp, _ := fakeClient.Set("/some/key", runtime.EncodeOrDie(latest.Codec, &api.Pod{JSONBase: api.JSONBase{ID: "foo"}}), 0)
+	obj := &api.Pod{JSONBase: api.JSONBase{ID: "foo", ResourceVersion: resp.Node.ModifiedIndex}, DesiredState: api.PodState{Host: "machine"}}
+
+	helper := EtcdHelper{Client: fakeClient, Codec: latest.Codec, ResourceVersioner: versioner}
+	expect := runtime.EncodeOrDie(latest.Codec, obj)
+	if err := helper.UpdateObj("/some/key", obj); err != nil {
+		t.Fatalf("Unexpected error %#v", err)
+	}
+	got := fakeClient.Data["/some/key"].R.Node.Value
+	if expect != got {
+		t.Errorf("Wanted %v, got %v", expect, got)
+	}
+	if obj.ResourceVersion != fakeClient.Data["/some/key"].R.Node.ModifiedIndex {
+		t.Errorf("Expected the stored version, got %d", obj.ResourceVersion)
+	}
+
+	// The stored object has moved on, so an update from the old version now conflicts.
+	stale := &api.Pod{JSONBase: api.JSONBase{ID: "foo", ResourceVersion: resp.Node.ModifiedIndex}}
+	if err := helper.UpdateObj("/some/key", stale); !IsEtcdTestFailed(err) {
+		t.Errorf("Expected a test failed error, got %#v", err)
+	}
+}
+
+func TestUpdateObjErrors(t *testing.T) {
+	fakeClient := NewFakeEtcdClient(t)
+	fakeClient.TestIndex = true
+	fakeClient.Data["/some/key"] = EtcdResponseWithError{
+		R: &etcd.Response{},
+		E: EtcdErrorNotFound,
+	}
+	helper := EtcdHelper{Client: fakeClient, Codec: latest.Codec, ResourceVersioner: versioner}
+
+	if err := helper.UpdateObj("/some/key", &api.Pod{JSONBase: api.JSONBase{ID: "foo", ResourceVersion: 1}}); !IsEtcdNotFound(err) {
+		t.Errorf("Expected a not found error, got %#v", err)
+	}
+	if err := helper.UpdateObj("/some/key", &api.Pod{JSONBase: api.JSONBase{ID: "foo"}}); err != ErrResourceVersionRequired {
+		t.Errorf("Expected a resourceVersion required error, got %#v", err)
+	}
+	helper.ResourceVersioner = nil
+	if err := helper.UpdateObj("/some/key", &api.Pod{JSONBase: api.JSONBase{ID: "foo", ResourceVersion: 1}}); err == nil {
+		t.Errorf("Expected an error without a ResourceVersioner")
+	}
+	if fakeClient.Data["/some/key"].R.Node != nil {
+		t.Errorf("Expected nothing to be stored: %#v", fakeClient.Data["/some/key"])
+	}
+}
+
+func TestDeleteIfVersion(t *testing.T) {
+	fakeClient := NewFakeEtcdClient(t)
+	fakeClient.TestIndex = true
+	resp, _ := fakeClient.Set("/some/key", runtime.EncodeOrDie(latest.Codec, &api.Pod{JSONBase: api.JSONBase{ID: "foo"}}), 0)
+	helper := EtcdHelper{Client: fakeClient, Codec: latest.Codec, ResourceVersioner: versioner}
+
+	if err := helper.DeleteIfVersion("/some/key", resp.Node.ModifiedIndex+1); !IsEtcdTestFailed(err) {
+		t.Errorf("Expected a test failed error, got %#v", err)
+	}
+	if err := helper.DeleteIfVersion("/some/key", resp.Node.ModifiedIndex); err != nil {
+		t.Errorf("Unexpected error %#v", err)
+	}
+	if _, err := fakeClient.Get("/some/key", false, false); !IsEtcdNotFound(err) {
+		t.Errorf("Expected the key to be deleted, got %#v", err)
+	}
+}
+
 func TestSetObjWithoutResourceVersioner(t *testing.T) {
 	obj := &api.Pod{JSONBase: api.JSONBase{ID: "foo"}}
 	fakeClient := NewFakeEtcdClient(t)
-	helper := EtcdHelper{fakeClient, latest.Codec, nil}
+	helper := EtcdHelper{Client: fakeClient, Codec: latest.Codec}
 	err := helper.SetObj("/some/key", obj)
 	if err != nil {
 		t.Errorf("Unexpected error %#v", err)
@@ -237,7 +354,7 @@ func TestSetObjWithoutResourceVersioner(t *testing.T) {
 func TestAtomicUpdate(t *testing.T) {
 	fakeClient := NewFakeEtcdClient(t)
 	fakeClient.TestIndex = true
-	helper := EtcdHelper{fakeClient, codec, runtime.NewJSONBaseResourceVersioner()}
+	helper := EtcdHelper{Client: fakeClient, Codec: codec, ResourceVersioner: runtime.NewJSONBaseResourceVersioner()}
 
 	// Create a new node.
 	fakeClient.ExpectNotFoundGet("/some/key")
@@ -291,7 +408,7 @@ func TestAtomicUpdate(t *testing.T) {
 func TestAtomicUpdateNoChange(t *testing.T) {
 	fakeClient := NewFakeEtcdClient(t)
 	fakeClient.TestIndex = true
-	helper := EtcdHelper{fakeClient, codec, runtime.NewJSONBaseResourceVersioner()}
+	helper := EtcdHelper{Client: fakeClient, Codec: codec, ResourceVersioner: runtime.NewJSONBaseResourceVersioner()}
 
 	// Create a new node.
 	fakeClient.ExpectNotFoundGet("/some/key")
@@ -322,7 +439,7 @@ func TestAtomicUpdateNoChange(t *testing.T) {
 func TestAtomicUpdate_CreateCollision(t *testing.T) {
 	fakeClient := NewFakeEtcdClient(t)
 	fakeClient.TestIndex = true
-	helper := EtcdHelper{fakeClient, codec, runtime.NewJSONBaseResourceVersioner()}
+	helper := EtcdHelper{Client: fakeClient, Codec: codec, ResourceVersioner: runtime.NewJSONBaseResourceVersioner()}
 
 	fakeClient.ExpectNotFoundGet("/some/key")
 
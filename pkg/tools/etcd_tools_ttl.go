@@ -0,0 +1,68 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tools
+
+import (
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+)
+
+// TTLStrategy returns how many seconds obj, stored under key, lives before
+// etcd deletes it, or 0 if it should never expire.  EtcdHelper consults it on
+// every write, so updating an object does not make it permanent.
+type TTLStrategy func(key string, obj runtime.Object) uint64
+
+// TTLByPrefix returns a TTLStrategy that expires objects stored under each
+// key prefix after the given number of seconds.  The longest matching prefix
+// wins; objects under no prefix never expire.
+func TTLByPrefix(ttls map[string]uint64) TTLStrategy {
+	return func(key string, obj runtime.Object) uint64 {
+		ttl, longest := uint64(0), -1
+		for prefix, t := range ttls {
+			if strings.HasPrefix(key, prefix) && len(prefix) > longest {
+				ttl, longest = t, len(prefix)
+			}
+		}
+		return ttl
+	}
+}
+
+func (h *EtcdHelper) ttl(key string, obj runtime.Object) uint64 {
+	if h.TTL == nil {
+		return 0
+	}
+	return h.TTL(key, obj)
+}
+
+// Renew restarts the TTL of the object at key, keeping it alive for another
+// period without changing it.  ptrToType must point to the type stored at key.
+// Returns a not found error if the object has already expired.
+func (h *EtcdHelper) Renew(key string, ptrToType runtime.Object) error {
+	for {
+		body, index, err := h.bodyAndExtractObj(key, ptrToType, false)
+		if err != nil {
+			return err
+		}
+		// etcd resets the TTL of a key whenever it is written.
+		_, err = h.Client.CompareAndSwap(h.etcdKey(key), body, h.ttl(key, ptrToType), body, index)
+		if IsEtcdTestFailed(err) {
+			continue
+		}
+		return err
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tools
+
+import (
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
+)
+
+func TestTTLByPrefix(t *testing.T) {
+	ttl := TTLByPrefix(map[string]uint64{
+		"/registry/events":        60,
+		"/registry/events/tokens": 10,
+	})
+	table := map[string]uint64{
+		"/registry/events/foo":      60,
+		"/registry/events/tokens/a": 10,
+		"/registry/pods/foo":        0,
+	}
+	for key, expected := range table {
+		if got := ttl(key, &TestResource{}); got != expected {
+			t.Errorf("%s: expected %d, got %d", key, expected, got)
+		}
+	}
+}
+
+func TestCreateObjWithTTL(t *testing.T) {
+	fakeClient := NewFakeEtcdClient(t)
+	helper := EtcdHelper{Client: fakeClient, Codec: codec, ResourceVersioner: versioner, TTL: TTLByPrefix(map[string]uint64{"/some": 30})}
+	if err := helper.CreateObj("/some/key", &TestResource{JSONBase: api.JSONBase{ID: "foo"}}); err != nil {
+		t.Fatalf("Unexpected error %#v", err)
+	}
+	if ttl := fakeClient.Data["/some/key"].R.Node.TTL; ttl != 30 {
+		t.Errorf("Expected a TTL of 30, got %d", ttl)
+	}
+	if err := helper.CreateObj("/other/key", &TestResource{JSONBase: api.JSONBase{ID: "foo"}}); err != nil {
+		t.Fatalf("Unexpected error %#v", err)
+	}
+	if ttl := fakeClient.Data["/other/key"].R.Node.TTL; ttl != 0 {
+		t.Errorf("Expected no TTL, got %d", ttl)
+	}
+}
+
+func TestAtomicUpdateKeepsTTL(t *testing.T) {
+	fakeClient := NewFakeEtcdClient(t)
+	fakeClient.TestIndex = true
+	helper := EtcdHelper{Client: fakeClient, Codec: codec, ResourceVersioner: versioner, TTL: TTLByPrefix(map[string]uint64{"/some": 30})}
+	if err := helper.CreateObj("/some/key", &TestResource{JSONBase: api.JSONBase{ID: "foo"}, Value: 1}); err != nil {
+		t.Fatalf("Unexpected error %#v", err)
+	}
+
+	err := helper.AtomicUpdate("/some/key", &TestResource{}, func(in runtime.Object) (runtime.Object, error) {
+		in.(*TestResource).Value = 2
+		return in, nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error %#v", err)
+	}
+	if ttl := fakeClient.Data["/some/key"].R.Node.TTL; ttl != 30 {
+		t.Errorf("Expected the update to keep a TTL of 30, got %d", ttl)
+	}
+}
+
+func TestRenew(t *testing.T) {
+	fakeClient := NewFakeEtcdClient(t)
+	fakeClient.TestIndex = true
+	helper := EtcdHelper{Client: fakeClient, Codec: codec, ResourceVersioner: versioner, TTL: TTLByPrefix(map[string]uint64{"/some": 30})}
+	if err := helper.CreateObj("/some/key", &TestResource{JSONBase: api.JSONBase{ID: "foo"}, Value: 1}); err != nil {
+		t.Fatalf("Unexpected error %#v", err)
+	}
+	before := fakeClient.Data["/some/key"].R.Node
+
+	if err := helper.Renew("/some/key", &TestResource{}); err != nil {
+		t.Fatalf("Unexpected error %#v", err)
+	}
+	after := fakeClient.Data["/some/key"].R.Node
+	if after.Value != before.Value {
+		t.Errorf("Expected the object to be unchanged, got %s", after.Value)
+	}
+	if after.ModifiedIndex == before.ModifiedIndex || after.TTL != 30 {
+		t.Errorf("Expected the key to be rewritten with a TTL of 30, got %#v", after)
+	}
+}
+
+func TestRenewExpired(t *testing.T) {
+	fakeClient := NewFakeEtcdClient(t)
+	fakeClient.TestIndex = true
+	fakeClient.ExpectNotFoundGet("/some/key")
+	helper := EtcdHelper{Client: fakeClient, Codec: codec, ResourceVersioner: versioner, TTL: TTLByPrefix(map[string]uint64{"/some": 30})}
+	if err := helper.Renew("/some/key", &TestResource{}); !IsEtcdNotFound(err) {
+		t.Errorf("Expected a not found error, got %#v", err)
+	}
+}
@@ -17,8 +17,10 @@ limitations under the License.
 package tools
 
 import (
+	"net/http"
 	"sync"
 
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/runtime"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/watch"
@@ -37,16 +39,19 @@ func Everything(runtime.Object) bool {
 
 // WatchList begins watching the specified key's items. Items are decoded into
 // API objects, and any items passing 'filter' are sent down the returned
-// watch.Interface. resourceVersion may be used to specify what version to begin
-// watching (e.g., for reconnecting without missing any updates).
+// watch.Interface. Only changes made after resourceVersion are sent, so the
+// resourceVersion of a list, or of the last event seen, may be passed to
+// continue from there without missing or repeating any updates. A zero
+// resourceVersion first sends every current item as added.
 func (h *EtcdHelper) WatchList(key string, resourceVersion uint64, filter FilterFunc) (watch.Interface, error) {
 	w := newEtcdWatcher(true, filter, h.Codec, h.ResourceVersioner, nil)
-	go w.etcdWatch(h.Client, key, resourceVersion)
+	go w.etcdWatch(h.Client, h.etcdKey(key), resourceVersion)
 	return w, nil
 }
 
 // Watch begins watching the specified key. Events are decoded into
-// API objects and sent down the returned watch.Interface.
+// API objects and sent down the returned watch.Interface. As with WatchList,
+// only changes after resourceVersion are sent.
 func (h *EtcdHelper) Watch(key string, resourceVersion uint64) (watch.Interface, error) {
 	return h.WatchAndTransform(key, resourceVersion, nil)
 }
@@ -68,7 +73,7 @@ func (h *EtcdHelper) Watch(key string, resourceVersion uint64) (watch.Interface,
 //
 func (h *EtcdHelper) WatchAndTransform(key string, resourceVersion uint64, transform TransformFunc) (watch.Interface, error) {
 	w := newEtcdWatcher(false, Everything, h.Codec, h.ResourceVersioner, transform)
-	go w.etcdWatch(h.Client, key, resourceVersion)
+	go w.etcdWatch(h.Client, h.etcdKey(key), resourceVersion)
 	return w, nil
 }
 
@@ -87,6 +92,8 @@ type etcdWatcher struct {
 	etcdIncoming  chan *etcd.Response
 	etcdStop      chan bool
 	etcdCallEnded chan struct{}
+	// Set to why the etcd watch failed, if it did, before etcdCallEnded is closed.
+	etcdErr error
 
 	outgoing chan watch.Event
 	userStop chan struct{}
@@ -127,11 +134,14 @@ func (w *etcdWatcher) etcdWatch(client EtcdGetSet, key string, resourceVersion u
 		if !ok {
 			return
 		}
-		resourceVersion = latest + 1
+		resourceVersion = latest
 	}
-	_, err := client.Watch(key, resourceVersion, w.list, w.etcdIncoming, w.etcdStop)
+	// A resourceVersion is the etcd index of the change which produced it, so
+	// the first change not yet seen is at the next index.
+	_, err := client.Watch(key, resourceVersion+1, w.list, w.etcdIncoming, w.etcdStop)
 	if err != etcd.ErrWatchStoppedByUser {
 		glog.Errorf("etcd.Watch stopped unexpectedly: %v (%#v)", err, key)
+		w.etcdErr = err
 	}
 }
 
@@ -180,12 +190,16 @@ func (w *etcdWatcher) translate() {
 	for {
 		select {
 		case <-w.etcdCallEnded:
+			w.sendError()
 			return
 		case <-w.userStop:
 			w.etcdStop <- true
 			return
 		case res, ok := <-w.etcdIncoming:
 			if !ok {
+				// etcd closes the channel as its watch returns.
+				<-w.etcdCallEnded
+				w.sendError()
 				return
 			}
 			w.sendResult(res)
@@ -193,6 +207,24 @@ func (w *etcdWatcher) translate() {
 	}
 }
 
+// sendError ends the watch with an error event if the etcd watch failed.
+// Watching from a resourceVersion etcd no longer has the history after is
+// reported as 410 Gone, so that clients know to list again.
+func (w *etcdWatcher) sendError() {
+	if w.etcdErr == nil {
+		return
+	}
+	status := &api.Status{
+		Status:  api.StatusFailure,
+		Code:    http.StatusInternalServerError,
+		Message: w.etcdErr.Error(),
+	}
+	if IsEtcdIndexCleared(w.etcdErr) {
+		status.Code = http.StatusGone
+	}
+	w.emit(watch.Event{Type: watch.Error, Object: status})
+}
+
 func (w *etcdWatcher) decodeObject(data []byte, index uint64) (runtime.Object, error) {
 	obj, err := w.encoding.Decode(data)
 	if err != nil {
@@ -263,8 +295,9 @@ func (w *etcdWatcher) sendModify(res *etcd.Response) {
 	oldObjPasses := false
 	var oldObj runtime.Object
 	if res.PrevNode != nil && res.PrevNode.Value != "" {
-		// Ignore problems reading the old object.
-		if oldObj, err = w.decodeObject([]byte(res.PrevNode.Value), res.PrevNode.ModifiedIndex); err == nil {
+		// Ignore problems reading the old object. Like a delete, it is given
+		// the index of this change, so a watch resumed from it continues after it.
+		if oldObj, err = w.decodeObject([]byte(res.PrevNode.Value), res.Node.ModifiedIndex); err == nil {
 			oldObjPasses = w.filter(oldObj)
 		}
 	}
@@ -325,9 +358,9 @@ func (w *etcdWatcher) sendResult(res *etcd.Response) {
 	switch res.Action {
 	case "create", "get":
 		w.sendAdd(res)
-	case "set", "compareAndSwap":
+	case "set", "update", "compareAndSwap":
 		w.sendModify(res)
-	case "delete":
+	case "delete", "compareAndDelete", "expire":
 		w.sendDelete(res)
 	default:
 		glog.Errorf("unknown action: %v", res.Action)
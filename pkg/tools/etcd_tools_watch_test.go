@@ -18,6 +18,7 @@ package tools
 
 import (
 	"fmt"
+	"net/http"
 	"reflect"
 	"testing"
 	"time"
@@ -29,12 +30,19 @@ import (
 	"github.com/coreos/go-etcd/etcd"
 )
 
+// alwaysRestart is the pod state decoding defaults an empty one to.
+var alwaysRestart = api.PodState{
+	Manifest: api.ContainerManifest{
+		RestartPolicy: api.RestartPolicy{Always: &api.RestartPolicyAlways{}},
+	},
+}
+
 func TestWatchInterpretations(t *testing.T) {
 	codec := latest.Codec
 	// Declare some pods to make the test cases compact.
-	podFoo := &api.Pod{JSONBase: api.JSONBase{ID: "foo"}}
-	podBar := &api.Pod{JSONBase: api.JSONBase{ID: "bar"}}
-	podBaz := &api.Pod{JSONBase: api.JSONBase{ID: "baz"}}
+	podFoo := &api.Pod{JSONBase: api.JSONBase{ID: "foo"}, DesiredState: alwaysRestart, CurrentState: alwaysRestart}
+	podBar := &api.Pod{JSONBase: api.JSONBase{ID: "bar"}, DesiredState: alwaysRestart, CurrentState: alwaysRestart}
+	podBaz := &api.Pod{JSONBase: api.JSONBase{ID: "baz"}, DesiredState: alwaysRestart, CurrentState: alwaysRestart}
 	firstLetterIsB := func(obj runtime.Object) bool {
 		return obj.(*api.Pod).ID[0] == 'b'
 	}
@@ -61,26 +69,26 @@ func TestWatchInterpretations(t *testing.T) {
 			expectEmit: false,
 		},
 		"delete": {
-			actions:       []string{"delete"},
+			actions:       []string{"delete", "compareAndDelete", "expire"},
 			prevNodeValue: runtime.EncodeOrDie(codec, podBar),
 			expectEmit:    true,
 			expectType:    watch.Deleted,
 			expectObject:  podBar,
 		},
 		"delete but filter blocks": {
-			actions:    []string{"delete"},
+			actions:    []string{"delete", "compareAndDelete", "expire"},
 			nodeValue:  runtime.EncodeOrDie(codec, podFoo),
 			expectEmit: false,
 		},
 		"modify appears to create 1": {
-			actions:      []string{"set", "compareAndSwap"},
+			actions:      []string{"set", "update", "compareAndSwap"},
 			nodeValue:    runtime.EncodeOrDie(codec, podBar),
 			expectEmit:   true,
 			expectType:   watch.Added,
 			expectObject: podBar,
 		},
 		"modify appears to create 2": {
-			actions:       []string{"set", "compareAndSwap"},
+			actions:       []string{"set", "update", "compareAndSwap"},
 			prevNodeValue: runtime.EncodeOrDie(codec, podFoo),
 			nodeValue:     runtime.EncodeOrDie(codec, podBar),
 			expectEmit:    true,
@@ -88,7 +96,7 @@ func TestWatchInterpretations(t *testing.T) {
 			expectObject:  podBar,
 		},
 		"modify appears to delete": {
-			actions:       []string{"set", "compareAndSwap"},
+			actions:       []string{"set", "update", "compareAndSwap"},
 			prevNodeValue: runtime.EncodeOrDie(codec, podBar),
 			nodeValue:     runtime.EncodeOrDie(codec, podFoo),
 			expectEmit:    true,
@@ -96,7 +104,7 @@ func TestWatchInterpretations(t *testing.T) {
 			expectObject:  podBar, // Should return last state that passed the filter!
 		},
 		"modify modifies": {
-			actions:       []string{"set", "compareAndSwap"},
+			actions:       []string{"set", "update", "compareAndSwap"},
 			prevNodeValue: runtime.EncodeOrDie(codec, podBar),
 			nodeValue:     runtime.EncodeOrDie(codec, podBaz),
 			expectEmit:    true,
@@ -104,7 +112,7 @@ func TestWatchInterpretations(t *testing.T) {
 			expectObject:  podBaz,
 		},
 		"modify ignores": {
-			actions:    []string{"set", "compareAndSwap"},
+			actions:    []string{"set", "update", "compareAndSwap"},
 			nodeValue:  runtime.EncodeOrDie(codec, podFoo),
 			expectEmit: false,
 		},
@@ -202,7 +210,7 @@ func TestWatch(t *testing.T) {
 	codec := latest.Codec
 	fakeClient := NewFakeEtcdClient(t)
 	fakeClient.expectNotFoundGetSet["/some/key"] = struct{}{}
-	h := EtcdHelper{fakeClient, codec, versioner}
+	h := EtcdHelper{Client: fakeClient, Codec: codec, ResourceVersioner: versioner}
 
 	watching, err := h.Watch("/some/key", 0)
 	if err != nil {
@@ -216,7 +224,7 @@ func TestWatch(t *testing.T) {
 	}
 
 	// Test normal case
-	pod := &api.Pod{JSONBase: api.JSONBase{ID: "foo"}}
+	pod := &api.Pod{JSONBase: api.JSONBase{ID: "foo"}, DesiredState: alwaysRestart, CurrentState: alwaysRestart}
 	podBytes, _ := codec.Encode(pod)
 	fakeClient.WatchResponse <- &etcd.Response{
 		Action: "set",
@@ -240,11 +248,42 @@ func TestWatch(t *testing.T) {
 	if _, open := <-fakeClient.WatchResponse; open {
 		t.Errorf("An injected error did not cause a graceful shutdown")
 	}
+	event, open := <-watching.ResultChan()
+	if !open || event.Type != watch.Error {
+		t.Fatalf("Expected an error event, got %#v", event)
+	}
+	if status, ok := event.Object.(*api.Status); !ok || status.Code != http.StatusInternalServerError {
+		t.Errorf("Expected an internal error status, got %#v", event.Object)
+	}
 	if _, open := <-watching.ResultChan(); open {
 		t.Errorf("An injected error did not cause a graceful shutdown")
 	}
 }
 
+func TestWatchFromClearedIndex(t *testing.T) {
+	codec := latest.Codec
+	fakeClient := NewFakeEtcdClient(t)
+	h := EtcdHelper{Client: fakeClient, Codec: codec, ResourceVersioner: versioner}
+
+	watching, err := h.Watch("/some/key", 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	fakeClient.WaitForWatchCompletion()
+	fakeClient.WatchInjectError <- EtcdErrorIndexCleared
+
+	event, open := <-watching.ResultChan()
+	if !open || event.Type != watch.Error {
+		t.Fatalf("Expected an error event, got %#v", event)
+	}
+	if status, ok := event.Object.(*api.Status); !ok || status.Code != http.StatusGone {
+		t.Errorf("Expected a gone status, got %#v", event.Object)
+	}
+	if _, open := <-watching.ResultChan(); open {
+		t.Errorf("Expected the watch to end after the error")
+	}
+}
+
 func TestWatchEtcdState(t *testing.T) {
 	codec := latest.Codec
 	type T struct {
@@ -335,7 +374,7 @@ func TestWatchEtcdState(t *testing.T) {
 		for key, value := range testCase.Initial {
 			fakeClient.Data[key] = value
 		}
-		h := EtcdHelper{fakeClient, codec, versioner}
+		h := EtcdHelper{Client: fakeClient, Codec: codec, ResourceVersioner: versioner}
 		watching, err := h.Watch("/somekey/foo", testCase.From)
 		if err != nil {
 			t.Errorf("%s: unexpected error: %v", k, err)
@@ -364,7 +403,7 @@ func TestWatchEtcdState(t *testing.T) {
 
 func TestWatchFromZeroIndex(t *testing.T) {
 	codec := latest.Codec
-	pod := &api.Pod{JSONBase: api.JSONBase{ID: "foo"}}
+	pod := &api.Pod{JSONBase: api.JSONBase{ID: "foo"}, DesiredState: alwaysRestart, CurrentState: alwaysRestart}
 
 	testCases := map[string]struct {
 		Response        EtcdResponseWithError
@@ -406,7 +445,7 @@ func TestWatchFromZeroIndex(t *testing.T) {
 	for k, testCase := range testCases {
 		fakeClient := NewFakeEtcdClient(t)
 		fakeClient.Data["/some/key"] = testCase.Response
-		h := EtcdHelper{fakeClient, codec, versioner}
+		h := EtcdHelper{Client: fakeClient, Codec: codec, ResourceVersioner: versioner}
 
 		watching, err := h.Watch("/some/key", 0)
 		if err != nil {
@@ -440,7 +479,7 @@ func TestWatchFromZeroIndex(t *testing.T) {
 
 func TestWatchListFromZeroIndex(t *testing.T) {
 	codec := latest.Codec
-	pod := &api.Pod{JSONBase: api.JSONBase{ID: "foo"}}
+	pod := &api.Pod{JSONBase: api.JSONBase{ID: "foo"}, DesiredState: alwaysRestart, CurrentState: alwaysRestart}
 
 	fakeClient := NewFakeEtcdClient(t)
 	fakeClient.Data["/some/key"] = EtcdResponseWithError{
@@ -466,7 +505,7 @@ func TestWatchListFromZeroIndex(t *testing.T) {
 			EtcdIndex: 3,
 		},
 	}
-	h := EtcdHelper{fakeClient, codec, versioner}
+	h := EtcdHelper{Client: fakeClient, Codec: codec, ResourceVersioner: versioner}
 
 	watching, err := h.WatchList("/some/key", 0, Everything)
 	if err != nil {
@@ -499,6 +538,71 @@ func TestWatchListFromZeroIndex(t *testing.T) {
 	watching.Stop()
 }
 
+func TestWatchListAfterList(t *testing.T) {
+	codec := latest.Codec
+	fakeClient := NewFakeEtcdClient(t)
+	fakeClient.Data["/some/key"] = EtcdResponseWithError{
+		R: &etcd.Response{
+			Node: &etcd.Node{
+				Dir: true,
+				Nodes: etcd.Nodes{
+					&etcd.Node{
+						Value:         runtime.EncodeOrDie(codec, &api.Pod{JSONBase: api.JSONBase{ID: "foo"}}),
+						CreatedIndex:  1,
+						ModifiedIndex: 1,
+					},
+				},
+			},
+			EtcdIndex: 3,
+		},
+	}
+	h := EtcdHelper{Client: fakeClient, Codec: codec, ResourceVersioner: versioner}
+
+	var pods []api.Pod
+	var listVersion uint64
+	if err := h.ExtractList("/some/key", &pods, &listVersion); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(pods) != 1 || pods[0].ResourceVersion != 1 || listVersion != 3 {
+		t.Fatalf("Unexpected list at %d: %#v", listVersion, pods)
+	}
+
+	// Watching from the list's version starts with the first change after it.
+	watching, err := h.WatchList("/some/key", listVersion, Everything)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	fakeClient.WaitForWatchCompletion()
+	if fakeClient.WatchIndex != 4 {
+		t.Errorf("Expected a watch from index 4, got %d", fakeClient.WatchIndex)
+	}
+	fakeClient.WatchResponse <- &etcd.Response{
+		Action: "create",
+		Node: &etcd.Node{
+			Value:         runtime.EncodeOrDie(codec, &api.Pod{JSONBase: api.JSONBase{ID: "bar"}}),
+			CreatedIndex:  4,
+			ModifiedIndex: 4,
+		},
+	}
+	event := <-watching.ResultChan()
+	eventVersion := event.Object.(*api.Pod).ResourceVersion
+	if event.Type != watch.Added || eventVersion != 4 {
+		t.Errorf("Unexpected event: %#v", event)
+	}
+	watching.Stop()
+
+	// Resuming from the event's version starts with the next change.
+	watching, err = h.WatchList("/some/key", eventVersion, Everything)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	fakeClient.WaitForWatchCompletion()
+	if fakeClient.WatchIndex != 5 {
+		t.Errorf("Expected a watch from index 5, got %d", fakeClient.WatchIndex)
+	}
+	watching.Stop()
+}
+
 func TestWatchFromNotFound(t *testing.T) {
 	fakeClient := NewFakeEtcdClient(t)
 	fakeClient.Data["/some/key"] = EtcdResponseWithError{
@@ -510,7 +614,7 @@ func TestWatchFromNotFound(t *testing.T) {
 			ErrorCode: 100,
 		},
 	}
-	h := EtcdHelper{fakeClient, codec, versioner}
+	h := EtcdHelper{Client: fakeClient, Codec: codec, ResourceVersioner: versioner}
 
 	watching, err := h.Watch("/some/key", 0)
 	if err != nil {
@@ -536,7 +640,7 @@ func TestWatchFromOtherError(t *testing.T) {
 			ErrorCode: 101,
 		},
 	}
-	h := EtcdHelper{fakeClient, codec, versioner}
+	h := EtcdHelper{Client: fakeClient, Codec: codec, ResourceVersioner: versioner}
 
 	watching, err := h.Watch("/some/key", 0)
 	if err != nil {
@@ -559,7 +663,7 @@ func TestWatchFromOtherError(t *testing.T) {
 
 func TestWatchPurposefulShutdown(t *testing.T) {
 	fakeClient := NewFakeEtcdClient(t)
-	h := EtcdHelper{fakeClient, codec, versioner}
+	h := EtcdHelper{Client: fakeClient, Codec: codec, ResourceVersioner: versioner}
 	fakeClient.expectNotFoundGetSet["/some/key"] = struct{}{}
 
 	// Test purposeful shutdown
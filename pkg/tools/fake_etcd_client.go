@@ -149,6 +149,7 @@ func (f *FakeEtcdClient) setLocked(key, value string, ttl uint64) (*etcd.Respons
 					Value:         value,
 					CreatedIndex:  createdIndex,
 					ModifiedIndex: i,
+					TTL:           int64(ttl),
 				},
 			},
 		}
@@ -163,6 +164,7 @@ func (f *FakeEtcdClient) setLocked(key, value string, ttl uint64) (*etcd.Respons
 				Value:         value,
 				CreatedIndex:  i,
 				ModifiedIndex: i,
+				TTL:           int64(ttl),
 			},
 		},
 	}
@@ -245,6 +247,51 @@ func (f *FakeEtcdClient) Delete(key string, recursive bool) (*etcd.Response, err
 	return &etcd.Response{}, nil
 }
 
+func (f *FakeEtcdClient) CompareAndDelete(key string, prevValue string, prevIndex uint64) (*etcd.Response, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	if !f.TestIndex {
+		f.t.Errorf("Enable TestIndex for test involving CompareAndDelete")
+		return nil, errors.New("Enable TestIndex for test involving CompareAndDelete")
+	}
+
+	if prevValue == "" && prevIndex == 0 {
+		return nil, errors.New("Either prevValue or prevIndex must be specified.")
+	}
+
+	f.Mutex.Lock()
+	defer f.Mutex.Unlock()
+
+	if !f.nodeExists(key) {
+		f.t.Logf("c&d: node doesn't exist")
+		return nil, EtcdErrorNotFound
+	}
+
+	prevNode := f.Data[key].R.Node
+
+	if prevValue != "" && prevValue != prevNode.Value {
+		f.t.Logf("body didn't match")
+		return nil, EtcdErrorTestFailed
+	}
+
+	if prevIndex != 0 && prevIndex != prevNode.ModifiedIndex {
+		f.t.Logf("got index %v but needed %v", prevIndex, prevNode.ModifiedIndex)
+		return nil, EtcdErrorTestFailed
+	}
+
+	f.Data[key] = EtcdResponseWithError{
+		R: &etcd.Response{
+			Node: nil,
+		},
+		E: EtcdErrorNotFound,
+	}
+
+	f.DeletedKeys = append(f.DeletedKeys, key)
+	return &etcd.Response{}, nil
+}
+
 func (f *FakeEtcdClient) WaitForWatchCompletion() {
 	<-f.watchCompletedChan
 }
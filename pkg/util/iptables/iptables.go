@@ -0,0 +1,97 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package iptables
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+)
+
+// Table is an iptables table, such as "nat".
+type Table string
+
+// Chain is a chain in an iptables table, such as "PREROUTING".
+type Chain string
+
+const (
+	TableNAT Table = "nat"
+
+	ChainPrerouting Chain = "PREROUTING"
+	ChainOutput     Chain = "OUTPUT"
+)
+
+// Interface is an injectable interface for running iptables commands.
+type Interface interface {
+	// EnsureChain creates chain in table unless it already exists.
+	EnsureChain(table Table, chain Chain) error
+	// EnsureRule appends a rule to chain in table unless it is already there.
+	EnsureRule(table Table, chain Chain, args ...string) error
+	// Restore loads rules in iptables-restore format.  Chains that are not
+	// declared in rules are left alone.
+	Restore(rules []byte) error
+}
+
+// execFunc runs a command with the given stdin and returns its combined output.
+type execFunc func(stdin []byte, name string, args ...string) ([]byte, error)
+
+func execCommand(stdin []byte, name string, args ...string) ([]byte, error) {
+	cmd := exec.Command(name, args...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	return cmd.CombinedOutput()
+}
+
+// runner implements Interface by running the iptables binaries.
+type runner struct {
+	exec execFunc
+}
+
+// New returns an Interface that runs the iptables binaries.
+func New() Interface {
+	return &runner{exec: execCommand}
+}
+
+func (r *runner) EnsureChain(table Table, chain Chain) error {
+	if _, err := r.exec(nil, "iptables", "-t", string(table), "-n", "-L", string(chain)); err == nil {
+		return nil
+	}
+	if out, err := r.exec(nil, "iptables", "-t", string(table), "-N", string(chain)); err != nil {
+		return fmt.Errorf("error creating chain %s: %v: %s", chain, err, out)
+	}
+	return nil
+}
+
+func (r *runner) EnsureRule(table Table, chain Chain, args ...string) error {
+	rule := append([]string{"-t", string(table), "-C", string(chain)}, args...)
+	if _, err := r.exec(nil, "iptables", rule...); err == nil {
+		return nil
+	}
+	rule[2] = "-A"
+	if out, err := r.exec(nil, "iptables", rule...); err != nil {
+		return fmt.Errorf("error appending rule to %s: %v: %s", chain, err, out)
+	}
+	return nil
+}
+
+func (r *runner) Restore(rules []byte) error {
+	if out, err := r.exec(rules, "iptables-restore", "--noflush"); err != nil {
+		return fmt.Errorf("error restoring rules: %v: %s", err, out)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package iptables
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type fakeExec struct {
+	commands []string
+	stdin    []byte
+	// Commands to fail, by their arguments.
+	failures map[string]bool
+}
+
+func (f *fakeExec) exec(stdin []byte, name string, args ...string) ([]byte, error) {
+	command := name + " " + strings.Join(args, " ")
+	f.commands = append(f.commands, command)
+	if stdin != nil {
+		f.stdin = stdin
+	}
+	if f.failures[command] {
+		return []byte("failed"), errors.New("exit status 1")
+	}
+	return nil, nil
+}
+
+func TestEnsureChain(t *testing.T) {
+	fake := &fakeExec{failures: map[string]bool{"iptables -t nat -n -L FOO": true}}
+	r := &runner{exec: fake.exec}
+	if err := r.EnsureChain(TableNAT, "FOO"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := r.EnsureChain(TableNAT, "BAR"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	expected := []string{
+		"iptables -t nat -n -L FOO",
+		"iptables -t nat -N FOO",
+		"iptables -t nat -n -L BAR",
+	}
+	if !reflect.DeepEqual(fake.commands, expected) {
+		t.Errorf("Expected %v, got %v", expected, fake.commands)
+	}
+}
+
+func TestEnsureRule(t *testing.T) {
+	fake := &fakeExec{failures: map[string]bool{"iptables -t nat -C OUTPUT -j FOO": true}}
+	r := &runner{exec: fake.exec}
+	if err := r.EnsureRule(TableNAT, ChainOutput, "-j", "FOO"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := r.EnsureRule(TableNAT, ChainPrerouting, "-j", "FOO"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	expected := []string{
+		"iptables -t nat -C OUTPUT -j FOO",
+		"iptables -t nat -A OUTPUT -j FOO",
+		"iptables -t nat -C PREROUTING -j FOO",
+	}
+	if !reflect.DeepEqual(fake.commands, expected) {
+		t.Errorf("Expected %v, got %v", expected, fake.commands)
+	}
+}
+
+func TestEnsureRuleFails(t *testing.T) {
+	fake := &fakeExec{failures: map[string]bool{
+		"iptables -t nat -C OUTPUT -j FOO": true,
+		"iptables -t nat -A OUTPUT -j FOO": true,
+	}}
+	r := &runner{exec: fake.exec}
+	if err := r.EnsureRule(TableNAT, ChainOutput, "-j", "FOO"); err == nil {
+		t.Errorf("Expected an error")
+	}
+}
+
+func TestRestore(t *testing.T) {
+	fake := &fakeExec{}
+	r := &runner{exec: fake.exec}
+	rules := []byte("*nat\nCOMMIT\n")
+	if err := r.Restore(rules); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(fake.commands, []string{"iptables-restore --noflush"}) {
+		t.Errorf("Unexpected commands: %v", fake.commands)
+	}
+	if string(fake.stdin) != string(rules) {
+		t.Errorf("Expected %q on stdin, got %q", rules, fake.stdin)
+	}
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"hash/fnv"
+	"sync"
+)
+
+// Shard is one of Count disjoint subsets of objects, chosen by a hash of each
+// object's name, so that several processes can split work between them.  The
+// zero Shard holds every object.
+type Shard struct {
+	Index int
+	Count int
+}
+
+// Owns returns whether the object called name is in s.
+func (s Shard) Owns(name string) bool {
+	if s.Count <= 1 {
+		return true
+	}
+	return hashName(name)%uint32(s.Count) == uint32(s.Index)
+}
+
+func hashName(name string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return h.Sum32()
+}
+
+// WorkByName calls work(i) for each i in [0, n), using at most workers
+// goroutines, and returns once every call has finished.  Items with the same
+// name(i) are always handled by the same goroutine, one after another.  If
+// workers is not positive, every item gets its own goroutine.
+func WorkByName(n, workers int, name func(i int) string, work func(i int)) {
+	if workers <= 0 || workers > n {
+		workers = n
+	}
+	queues := make([][]int, workers)
+	for i := 0; i < n; i++ {
+		q := i
+		if workers < n {
+			q = int(hashName(name(i)) % uint32(workers))
+		}
+		queues[q] = append(queues[q], i)
+	}
+	wg := sync.WaitGroup{}
+	wg.Add(workers)
+	for _, queue := range queues {
+		go func(queue []int) {
+			defer wg.Done()
+			defer HandleCrash()
+			for _, i := range queue {
+				work(i)
+			}
+		}(queue)
+	}
+	wg.Wait()
+}
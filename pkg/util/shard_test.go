@@ -0,0 +1,76 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestShardOwns(t *testing.T) {
+	if !(Shard{}).Owns("foo") {
+		t.Errorf("Expected the zero shard to own everything")
+	}
+	shards := []Shard{{0, 3}, {1, 3}, {2, 3}}
+	for i := 0; i < 100; i++ {
+		name := fmt.Sprintf("controller-%d", i)
+		owners := 0
+		for _, shard := range shards {
+			if shard.Owns(name) {
+				owners++
+			}
+		}
+		if owners != 1 {
+			t.Errorf("Expected %s to be owned by one shard, got %d", name, owners)
+		}
+	}
+}
+
+func TestWorkByName(t *testing.T) {
+	names := []string{"a", "b", "a", "c", "b", "a"}
+	for _, workers := range []int{0, 1, 2, 10} {
+		lock := sync.Mutex{}
+		done := map[int]bool{}
+		WorkByName(len(names), workers, func(i int) string { return names[i] }, func(i int) {
+			lock.Lock()
+			defer lock.Unlock()
+			done[i] = true
+		})
+		if len(done) != len(names) {
+			t.Errorf("%d workers: expected every item to be handled, got %v", workers, done)
+		}
+	}
+}
+
+func TestWorkByNameSerializesNames(t *testing.T) {
+	lock := sync.Mutex{}
+	running := map[string]bool{}
+	names := []string{"a", "a", "a", "b", "b", "b"}
+	WorkByName(len(names), 2, func(i int) string { return names[i] }, func(i int) {
+		lock.Lock()
+		if running[names[i]] {
+			t.Errorf("%s handled concurrently", names[i])
+		}
+		running[names[i]] = true
+		lock.Unlock()
+
+		lock.Lock()
+		running[names[i]] = false
+		lock.Unlock()
+	})
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter limits how often something may happen.
+type RateLimiter interface {
+	// CanAccept returns true, and counts it as happening, if it may happen
+	// now without exceeding the rate.
+	CanAccept() bool
+	// Accept blocks until it may happen without exceeding the rate.
+	Accept()
+}
+
+// tokenBucket is a RateLimiter which holds up to burst tokens, refilled at
+// qps per second, and takes one token per event.
+type tokenBucket struct {
+	qps   float64
+	burst float64
+	now   func() time.Time
+	sleep func(time.Duration)
+
+	lock   sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucketRateLimiter creates a RateLimiter which allows events at qps
+// per second on average, in bursts of up to burst events.
+func NewTokenBucketRateLimiter(qps float32, burst int) RateLimiter {
+	return newTokenBucket(qps, burst, time.Now, time.Sleep)
+}
+
+func newTokenBucket(qps float32, burst int, now func() time.Time, sleep func(time.Duration)) *tokenBucket {
+	return &tokenBucket{
+		qps:    float64(qps),
+		burst:  float64(burst),
+		now:    now,
+		sleep:  sleep,
+		tokens: float64(burst),
+		last:   now(),
+	}
+}
+
+// refill adds the tokens earned since the last refill.  t.lock must be held.
+func (t *tokenBucket) refill() {
+	now := t.now()
+	t.tokens += now.Sub(t.last).Seconds() * t.qps
+	if t.tokens > t.burst {
+		t.tokens = t.burst
+	}
+	t.last = now
+}
+
+func (t *tokenBucket) CanAccept() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.refill()
+	if t.tokens < 1 {
+		return false
+	}
+	t.tokens--
+	return true
+}
+
+func (t *tokenBucket) Accept() {
+	t.lock.Lock()
+	t.refill()
+	// Take the token now, going into debt if need be, so that waiting
+	// callers are served in order.
+	t.tokens--
+	wait := time.Duration(-t.tokens / t.qps * float64(time.Second))
+	t.lock.Unlock()
+	if wait > 0 {
+		t.sleep(wait)
+	}
+}
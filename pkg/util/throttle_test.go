@@ -0,0 +1,92 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	now   time.Time
+	slept []time.Duration
+}
+
+func (f *fakeClock) Now() time.Time {
+	return f.now
+}
+
+func (f *fakeClock) Sleep(d time.Duration) {
+	f.slept = append(f.slept, d)
+	f.now = f.now.Add(d)
+}
+
+func TestTokenBucketCanAccept(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	limiter := newTokenBucket(10, 3, clock.Now, clock.Sleep)
+	for i := 0; i < 3; i++ {
+		if !limiter.CanAccept() {
+			t.Errorf("%d: expected the burst to be accepted", i)
+		}
+	}
+	if limiter.CanAccept() {
+		t.Errorf("expected the rate to be exceeded")
+	}
+	clock.now = clock.now.Add(100 * time.Millisecond)
+	if !limiter.CanAccept() {
+		t.Errorf("expected a token after 100ms")
+	}
+	if limiter.CanAccept() {
+		t.Errorf("expected the rate to be exceeded")
+	}
+	// The bucket never holds more than burst tokens.
+	clock.now = clock.now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		limiter.CanAccept()
+	}
+	if limiter.CanAccept() {
+		t.Errorf("expected the rate to be exceeded")
+	}
+}
+
+func TestTokenBucketAccept(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	limiter := newTokenBucket(10, 2, clock.Now, clock.Sleep)
+	for i := 0; i < 4; i++ {
+		limiter.Accept()
+	}
+	expected := []time.Duration{100 * time.Millisecond, 100 * time.Millisecond}
+	if len(clock.slept) != len(expected) {
+		t.Fatalf("Expected sleeps %v, got %v", expected, clock.slept)
+	}
+	for i := range expected {
+		if clock.slept[i] != expected[i] {
+			t.Errorf("Expected sleeps %v, got %v", expected, clock.slept)
+		}
+	}
+}
+
+func TestTokenBucketRealTime(t *testing.T) {
+	limiter := NewTokenBucketRateLimiter(100, 1)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		limiter.Accept()
+	}
+	if elapsed := time.Since(start); elapsed < 15*time.Millisecond {
+		t.Errorf("Expected to be throttled, took %v", elapsed)
+	}
+}
@@ -18,6 +18,7 @@ package util
 
 import (
 	"regexp"
+	"strings"
 )
 
 const dnsLabelFmt string = "[a-z0-9]([-a-z0-9]*[a-z0-9])?"
@@ -68,3 +69,34 @@ const dns952MaxLength = 24
 func IsDNS952Label(value string) bool {
 	return len(value) <= dns952MaxLength && dns952Regexp.MatchString(value)
 }
+
+const qualifiedNameFmt string = "[A-Za-z0-9]([-A-Za-z0-9_.]*[A-Za-z0-9])?"
+
+var qualifiedNameRegexp = regexp.MustCompile("^" + qualifiedNameFmt + "$")
+
+const qualifiedNameMaxLength int = 63
+
+// IsQualifiedName tests for a string that is a name, optionally prefixed by a
+// DNS subdomain and a slash, e.g. "example.com/name". The name must be at most
+// 63 characters, begin and end with an alphanumeric character, and otherwise
+// contain only alphanumerics, '-', '_' and '.'.
+func IsQualifiedName(value string) bool {
+	parts := strings.Split(value, "/")
+	switch len(parts) {
+	case 1:
+		return isQualifiedNamePart(parts[0])
+	case 2:
+		return IsDNSSubdomain(parts[0]) && isQualifiedNamePart(parts[1])
+	}
+	return false
+}
+
+func isQualifiedNamePart(value string) bool {
+	return len(value) <= qualifiedNameMaxLength && qualifiedNameRegexp.MatchString(value)
+}
+
+// IsValidLabelValue tests for a string that is either empty or follows the
+// rules for the name part of a qualified name.
+func IsValidLabelValue(value string) bool {
+	return value == "" || isQualifiedNamePart(value)
+}
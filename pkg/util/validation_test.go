@@ -147,3 +147,48 @@ func TestIsDNS952(t *testing.T) {
 		}
 	}
 }
+
+func TestIsQualifiedName(t *testing.T) {
+	goodValues := []string{
+		"a", "A", "1", "a-b", "a_b", "a.b", "A1.b-c_d",
+		"example.com/a", "k8s.io/Name_1", "a/b",
+		strings.Repeat("a", 63),
+	}
+	for _, val := range goodValues {
+		if !IsQualifiedName(val) {
+			t.Errorf("expected true for '%s'", val)
+		}
+	}
+
+	badValues := []string{
+		"", "-a", "a-", "_a", "a.", "a b", "a=b", "a,b", "a!",
+		"/a", "a/", "a/b/c", "Example.com/a", "-a.com/b",
+		strings.Repeat("a", 64),
+	}
+	for _, val := range badValues {
+		if IsQualifiedName(val) {
+			t.Errorf("expected false for '%s'", val)
+		}
+	}
+}
+
+func TestIsValidLabelValue(t *testing.T) {
+	goodValues := []string{
+		"", "a", "A", "1", "a-b", "a_b", "a.b", "A1.b-c_d",
+	}
+	for _, val := range goodValues {
+		if !IsValidLabelValue(val) {
+			t.Errorf("expected true for '%s'", val)
+		}
+	}
+
+	badValues := []string{
+		"-a", "a-", "a b", "a/b", "a,b", "a=b", "a(b)",
+		strings.Repeat("a", 64),
+	}
+	for _, val := range badValues {
+		if IsValidLabelValue(val) {
+			t.Errorf("expected false for '%s'", val)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"expvar"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// wedgedLoops counts, by loop name, the calls a Watchdog found running past
+// their deadline.  It is served with the other expvars on /debug/vars.
+var wedgedLoops = expvar.NewMap("wedged_loops")
+
+// Watchdog notices when a call made through Do has been running for longer
+// than a deadline, e.g. because it is blocked on a request that will never
+// return or on a deadlock.  Only calls made through Do are timed, so a loop
+// may wait as long as it likes between them.  A nil *Watchdog times nothing.
+type Watchdog struct {
+	name     string
+	deadline time.Duration
+	wedged   func(name string)
+	now      func() time.Time
+
+	lock sync.Mutex
+	// When the call in progress started, or zero when idle.
+	started time.Time
+	// Whether the call in progress has already been reported.
+	reported bool
+}
+
+// NewWatchdog creates a Watchdog for the loop called name, which calls wedged
+// when a call has run for longer than deadline.
+func NewWatchdog(name string, deadline time.Duration, wedged func(name string)) *Watchdog {
+	return &Watchdog{
+		name:     name,
+		deadline: deadline,
+		wedged:   wedged,
+		now:      time.Now,
+	}
+}
+
+// Do calls f, timing it against the deadline.
+func (w *Watchdog) Do(f func()) {
+	if w == nil {
+		f()
+		return
+	}
+	w.lock.Lock()
+	w.started, w.reported = w.now(), false
+	w.lock.Unlock()
+	defer func() {
+		w.lock.Lock()
+		w.started = time.Time{}
+		w.lock.Unlock()
+	}()
+	f()
+}
+
+// Check reports a call which has run past the deadline, once per call: it
+// logs the stacks of all goroutines, counts the call in wedged_loops, then
+// calls wedged.  Returns whether it did.
+func (w *Watchdog) Check() bool {
+	w.lock.Lock()
+	started, reported := w.started, w.reported
+	if started.IsZero() || reported || w.now().Sub(started) <= w.deadline {
+		w.lock.Unlock()
+		return false
+	}
+	w.reported = true
+	w.lock.Unlock()
+
+	buf := make([]byte, 1<<20)
+	buf = buf[:runtime.Stack(buf, true)]
+	glog.Errorf("The %s loop has been stuck since %v:\n%s", w.name, started, buf)
+	wedgedLoops.Add(w.name, 1)
+	w.wedged(w.name)
+	return true
+}
+
+// Run checks for wedged calls every period.  It starts a goroutine and
+// returns immediately.
+func (w *Watchdog) Run(period time.Duration) {
+	go Forever(func() { w.Check() }, period)
+}
+
+// ExitWhenWedged exits the process, so that whatever supervises it restarts
+// the wedged loop.  Stuck goroutines can't be stopped, and running another
+// copy of the loop beside one which might recover would do its work twice.
+func ExitWhenWedged(name string) {
+	glog.Errorf("Exiting to restart the %s loop", name)
+	glog.Flush()
+	os.Exit(1)
+}
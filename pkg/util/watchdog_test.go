@@ -0,0 +1,65 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWatchdog(t *testing.T) {
+	wedged := []string{}
+	w := NewWatchdog("test", time.Minute, func(name string) { wedged = append(wedged, name) })
+	now := time.Unix(0, 0)
+	w.now = func() time.Time { return now }
+
+	if w.Check() {
+		t.Errorf("Expected an idle loop not to be wedged")
+	}
+	w.Do(func() {
+		now = now.Add(30 * time.Second)
+		if w.Check() {
+			t.Errorf("Expected a call within the deadline not to be wedged")
+		}
+		now = now.Add(time.Minute)
+		if !w.Check() {
+			t.Errorf("Expected a call past the deadline to be wedged")
+		}
+		if w.Check() {
+			t.Errorf("Expected a wedged call to be reported once")
+		}
+	})
+	now = now.Add(time.Hour)
+	if w.Check() {
+		t.Errorf("Expected the loop to be idle once the call returned")
+	}
+	if len(wedged) != 1 || wedged[0] != "test" {
+		t.Errorf("Unexpected wedged calls: %v", wedged)
+	}
+	if count := wedgedLoops.Get("test"); count == nil || count.String() != "1" {
+		t.Errorf("Expected the wedged call to be counted, got %v", count)
+	}
+}
+
+func TestNilWatchdog(t *testing.T) {
+	called := false
+	var w *Watchdog
+	w.Do(func() { called = true })
+	if !called {
+		t.Errorf("Expected a nil watchdog to call f")
+	}
+}
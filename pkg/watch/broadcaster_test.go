@@ -0,0 +1,204 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package watch
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type myType struct {
+	ID    string
+	Value string
+}
+
+func (*myType) IsAnAPIObject() {}
+
+func TestBroadcaster(t *testing.T) {
+	table := []Event{
+		{Added, &myType{"foo", "hello world 1"}},
+		{Added, &myType{"bar", "hello world 2"}},
+		{Modified, &myType{"foo", "goodbye world 3"}},
+		{Deleted, &myType{"bar", "hello world 4"}},
+	}
+
+	// The broadcaster we're testing
+	m := NewBroadcaster(0, WaitIfChannelFull)
+
+	// Add a bunch of watchers
+	const testWatchers = 2
+	wg := sync.WaitGroup{}
+	wg.Add(testWatchers)
+	for i := 0; i < testWatchers; i++ {
+		// Verify that each watcher gets the events in the correct order
+		go func(watcher int, w Interface) {
+			tableLine := 0
+			for {
+				event, ok := <-w.ResultChan()
+				if !ok {
+					break
+				}
+				if e, a := table[tableLine], event; !reflect.DeepEqual(e, a) {
+					t.Errorf("Watcher %v, line %v: Expected (%v, %#v), got (%v, %#v)",
+						watcher, tableLine, e.Type, e.Object, a.Type, a.Object)
+				} else {
+					t.Logf("Got (%v, %#v)", event.Type, event.Object)
+				}
+				tableLine++
+			}
+			wg.Done()
+		}(i, m.Watch())
+	}
+
+	for i, item := range table {
+		t.Logf("Sending %v", i)
+		m.Action(item.Type, item.Object)
+	}
+
+	m.Shutdown()
+
+	wg.Wait()
+}
+
+func TestBroadcasterWatcherClose(t *testing.T) {
+	m := NewBroadcaster(0, WaitIfChannelFull)
+	w := m.Watch()
+	w2 := m.Watch()
+	w.Stop()
+	m.Shutdown()
+	if _, open := <-w.ResultChan(); open {
+		t.Errorf("Stop didn't work?")
+	}
+	if _, open := <-w2.ResultChan(); open {
+		t.Errorf("Shutdown didn't work?")
+	}
+	// Extra stops don't hurt things
+	w.Stop()
+	w2.Stop()
+}
+
+func TestBroadcasterWatcherStopDeadlock(t *testing.T) {
+	done := make(chan bool)
+	m := NewBroadcaster(0, WaitIfChannelFull)
+	go func(w0, w1 Interface) {
+		// We know Broadcaster is in the distribute loop once one watcher receives
+		// an event. Stop the other watcher while distribute is trying to
+		// send to it.
+		select {
+		case <-w0.ResultChan():
+			w1.Stop()
+		case <-w1.ResultChan():
+			w0.Stop()
+		}
+		close(done)
+	}(m.Watch(), m.Watch())
+	m.Action(Added, &myType{})
+	select {
+	case <-time.After(5 * time.Second):
+		t.Error("timeout: deadlocked")
+	case <-done:
+	}
+	m.Shutdown()
+}
+
+func TestBroadcasterWatcherQueue(t *testing.T) {
+	m := NewBroadcaster(2, WaitIfChannelFull)
+	w := m.Watch()
+	// Each watcher queues events, so Action doesn't wait for them to be read.
+	m.Action(Added, &myType{"foo", "1"})
+	m.Action(Modified, &myType{"foo", "2"})
+	m.Shutdown()
+	for _, value := range []string{"1", "2"} {
+		event, ok := <-w.ResultChan()
+		if !ok {
+			t.Fatalf("unexpected close")
+		}
+		if e, a := value, event.Object.(*myType).Value; e != a {
+			t.Errorf("expected %v, got %v", e, a)
+		}
+	}
+	if _, open := <-w.ResultChan(); open {
+		t.Errorf("Shutdown didn't work?")
+	}
+}
+
+func TestBroadcasterStopIfChannelFull(t *testing.T) {
+	m := NewBroadcaster(1, StopIfChannelFull)
+	slow := m.Watch()
+	fast := m.Watch()
+	received := make(chan Event)
+	go func() {
+		for event := range fast.ResultChan() {
+			received <- event
+		}
+		close(received)
+	}()
+
+	// The slow watcher never reads, so the second event finds its queue full.
+	for _, value := range []string{"1", "2", "3"} {
+		m.Action(Modified, &myType{"foo", value})
+		select {
+		case event := <-received:
+			if e, a := value, event.Object.(*myType).Value; e != a {
+				t.Errorf("expected %v, got %v", e, a)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout: the slow watcher held up the fast one")
+		}
+	}
+
+	// The slow watcher gets what was queued for it, then its channel closes.
+	if event, ok := <-slow.ResultChan(); !ok || event.Object.(*myType).Value != "1" {
+		t.Errorf("expected the first event, got %#v (%v)", event, ok)
+	}
+	if _, open := <-slow.ResultChan(); open {
+		t.Errorf("expected the slow watcher to be stopped")
+	}
+	// Stopping it again is harmless.
+	slow.Stop()
+
+	m.Shutdown()
+	if _, open := <-received; open {
+		t.Errorf("Shutdown didn't work?")
+	}
+}
+
+func benchmarkBroadcasterFanOut(b *testing.B, watchers int) {
+	m := NewBroadcaster(0, WaitIfChannelFull)
+	wg := sync.WaitGroup{}
+	wg.Add(watchers)
+	for i := 0; i < watchers; i++ {
+		go func(w Interface) {
+			for _ = range w.ResultChan() {
+			}
+			wg.Done()
+		}(m.Watch())
+	}
+
+	obj := &myType{"foo", "hello world"}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Action(Modified, obj)
+	}
+	m.Shutdown()
+	wg.Wait()
+}
+
+func BenchmarkBroadcasterFanOut10(b *testing.B)  { benchmarkBroadcasterFanOut(b, 10) }
+func BenchmarkBroadcasterFanOut100(b *testing.B) { benchmarkBroadcasterFanOut(b, 100) }
@@ -41,6 +41,7 @@ const (
 	Added    EventType = "ADDED"
 	Modified EventType = "MODIFIED"
 	Deleted  EventType = "DELETED"
+	Error    EventType = "ERROR"
 )
 
 // Event represents a single event to a watched resource.
@@ -49,6 +50,8 @@ type Event struct {
 
 	// If Type == Deleted, then this is the state of the object
 	// immediately before deletion.
+	// If Type == Error, then this describes the error, usually as an
+	// *api.Status, and the watch ends after it.
 	Object runtime.Object
 }
 
@@ -94,6 +97,11 @@ func (f *FakeWatcher) Delete(lastValue runtime.Object) {
 	f.result <- Event{Deleted, lastValue}
 }
 
+// Error sends an error event.
+func (f *FakeWatcher) Error(errValue runtime.Object) {
+	f.result <- Event{Error, errValue}
+}
+
 // Action sends an event of the requested type, for table-based testing.
 func (f *FakeWatcher) Action(action EventType, obj runtime.Object) {
 	f.result <- Event{action, obj}
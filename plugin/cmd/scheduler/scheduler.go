@@ -20,11 +20,16 @@ import (
 	"flag"
 	"net"
 	"net/http"
+	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/client"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/election"
 	_ "github.com/GoogleCloudPlatform/kubernetes/pkg/healthz"
 	masterPkg "github.com/GoogleCloudPlatform/kubernetes/pkg/master"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/tools"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
 	"github.com/GoogleCloudPlatform/kubernetes/pkg/version/verflag"
 	"github.com/GoogleCloudPlatform/kubernetes/plugin/pkg/scheduler"
@@ -33,11 +38,41 @@ import (
 )
 
 var (
-	master  = flag.String("master", "", "The address of the Kubernetes API server")
-	port    = flag.Int("port", masterPkg.SchedulerPort, "The port that the scheduler's http service runs on")
-	address = flag.String("address", "127.0.0.1", "The address to serve from")
+	master       = flag.String("master", "", "The address of the Kubernetes API server")
+	port         = flag.Int("port", masterPkg.SchedulerPort, "The port that the scheduler's http service runs on")
+	address      = flag.String("address", "127.0.0.1", "The address to serve from")
+	loopDeadline = flag.Duration("loop_deadline", 5*time.Minute, "How long scheduling one pod may take before the scheduler is considered wedged, and exits to be restarted.  0 to never exit.")
+	policy       = flag.String("policy_config_file", "", "Path to a JSON file selecting the fit predicates and priority functions to use. Empty string for the default policy. Known predicates: "+strings.Join(factory.FitPredicateNames(), ", ")+". Known priorities: "+strings.Join(factory.PriorityFunctionNames(), ", ")+".")
+
+	etcdServerList util.StringList
+	etcdPrefix     = flag.String("etcd_prefix", "", "Prepended to every etcd key, so that several clusters can share an etcd cluster.  Must match the apiserver's -etcd_prefix.")
 )
 
+func loadPolicy() (*factory.Policy, error) {
+	if len(*policy) == 0 {
+		return factory.DefaultPolicy(), nil
+	}
+	file, err := os.Open(*policy)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return factory.ReadPolicy(file)
+}
+
+func init() {
+	flag.Var(&etcdServerList, "etcd_servers", "List of etcd servers (http://ip:port), comma separated, used to elect a single active scheduler amongst replicas (optional)")
+}
+
+// masterID identifies this replica in the master election.
+func masterID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		glog.Fatalf("Couldn't get hostname: %v", err)
+	}
+	return hostname + ":" + strconv.Itoa(os.Getpid())
+}
+
 func main() {
 	flag.Parse()
 	util.InitLogs()
@@ -51,10 +86,42 @@ func main() {
 		glog.Fatalf("Invalid -master: %v", err)
 	}
 
+	schedulerPolicy, err := loadPolicy()
+	if err != nil {
+		glog.Fatalf("Couldn't read scheduler policy %q: %v", *policy, err)
+	}
+	configFactory := &factory.ConfigFactory{Client: kubeClient}
+	config, err := configFactory.CreateFromPolicy(schedulerPolicy)
+	if err != nil {
+		glog.Fatalf("Invalid scheduler policy: %v", err)
+	}
+
+	// Serve a summary of pods which fit on no minion, e.g. for an autoscaler.
+	http.Handle("/unschedulable", config.Unschedulable)
+	// Serve the per-minion predicate results and priority scores for a POSTed pod.
+	http.Handle("/explain", &scheduler.ExplainHandler{
+		MinionLister: config.MinionLister,
+		Algorithm:    config.Algorithm,
+	})
 	go http.ListenAndServe(net.JoinHostPort(*address, strconv.Itoa(*port)), nil)
 
-	configFactory := &factory.ConfigFactory{Client: kubeClient}
-	config := configFactory.Create()
+	if len(etcdServerList) > 0 {
+		// Only one replica may act at a time; the others wait here.
+		etcdClient, err := tools.NewEtcdClient(etcdServerList, "", "", "")
+		if err != nil {
+			glog.Fatalf("Invalid etcd client configuration: %v", err)
+		}
+		elector := election.NewEtcdMasterElector(etcdClient)
+		election.BecomeMaster(elector, *etcdPrefix+"/registry/masters/scheduler", masterID(), func() {
+			glog.Fatalf("Lost mastership, exiting so that another replica takes over")
+		})
+	}
+
+	if *loopDeadline > 0 {
+		config.Watchdog = util.NewWatchdog("scheduler", *loopDeadline, util.ExitWhenWedged)
+		config.Watchdog.Run(*loopDeadline / 10)
+	}
+
 	s := scheduler.New(config)
 	s.Run()
 
@@ -0,0 +1,70 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/latest"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scheduler"
+)
+
+// ExplainHandler serves, for a pod POSTed to it, the predicate results and
+// priority scores of every minion, without scheduling the pod. The pod need
+// not exist.
+type ExplainHandler struct {
+	MinionLister scheduler.MinionLister
+	Algorithm    scheduler.Scheduler
+}
+
+// ServeHTTP decodes a pod from the request body and serves its explanation as JSON.
+func (e *ExplainHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		http.Error(w, "explain requires a POSTed pod", http.StatusMethodNotAllowed)
+		return
+	}
+	explainer, ok := e.Algorithm.(scheduler.Explainer)
+	if !ok {
+		http.Error(w, "the scheduling algorithm can't explain its decisions", http.StatusNotImplemented)
+		return
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var pod api.Pod
+	if err := latest.Codec.DecodeInto(body, &pod); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	explanation, err := explainer.Explain(pod, e.MinionLister)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	data, err := json.Marshal(explanation)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api/latest"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scheduler"
+)
+
+func TestExplainHandler(t *testing.T) {
+	predicates := map[string]scheduler.FitPredicate{
+		"OnlyM1": func(pod api.Pod, existingPods []api.Pod, machine string) (bool, error) {
+			return machine == "m1", nil
+		},
+	}
+	handler := &ExplainHandler{
+		MinionLister: scheduler.FakeMinionLister([]string{"m1", "m2"}),
+		Algorithm:    scheduler.NewGenericScheduler(predicates, nil, scheduler.FakePodLister([]api.Pod{}), rand.New(rand.NewSource(0))),
+	}
+	body, err := latest.Codec.Encode(&api.Pod{JSONBase: api.JSONBase{ID: "foo"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	req, _ := http.NewRequest("POST", "/explain", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var explanation scheduler.Explanation
+	if err := json.Unmarshal(w.Body.Bytes(), &explanation); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := scheduler.Explanation{
+		Pod: "foo",
+		Machines: []scheduler.MachineExplanation{
+			{Host: "m1", Scores: map[string]int{"EqualPriority": 1}, Score: 1},
+			{Host: "m2", FailedPredicates: []string{"OnlyM1"}},
+		},
+	}
+	if !reflect.DeepEqual(expected, explanation) {
+		t.Errorf("Expected %#v, got %#v", expected, explanation)
+	}
+}
+
+func TestExplainHandlerErrors(t *testing.T) {
+	explainer := scheduler.NewGenericScheduler(nil, nil, scheduler.FakePodLister([]api.Pod{}), rand.New(rand.NewSource(0)))
+	tests := []struct {
+		method    string
+		body      string
+		algorithm scheduler.Scheduler
+		code      int
+	}{
+		{"GET", "", explainer, http.StatusMethodNotAllowed},
+		{"POST", "{}", mockScheduler{}, http.StatusNotImplemented},
+		{"POST", "{", explainer, http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		handler := &ExplainHandler{
+			MinionLister: scheduler.FakeMinionLister([]string{"m1"}),
+			Algorithm:    test.algorithm,
+		}
+		req, _ := http.NewRequest(test.method, "/explain", bytes.NewBufferString(test.body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != test.code {
+			t.Errorf("%s %q: expected %d, got %d", test.method, test.body, test.code, w.Code)
+		}
+	}
+}
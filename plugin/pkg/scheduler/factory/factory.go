@@ -19,6 +19,7 @@ limitations under the License.
 package factory
 
 import (
+	"fmt"
 	"math/rand"
 	"time"
 
@@ -40,20 +41,49 @@ type ConfigFactory struct {
 	Client *client.Client
 }
 
-// Create creates a scheduler and all support functions.
+// Create creates a scheduler and all support functions, using the DefaultPolicy.
 func (factory *ConfigFactory) Create() *scheduler.Config {
+	config, err := factory.CreateFromPolicy(DefaultPolicy())
+	if err != nil {
+		glog.Fatalf("Invalid default scheduler policy: %v", err)
+	}
+	return config
+}
+
+// CreateFromPolicy creates a scheduler and all support functions, using the fit
+// predicates and priority functions enabled by policy.
+func (factory *ConfigFactory) CreateFromPolicy(policy *Policy) (*scheduler.Config, error) {
 	// Watch and queue pods that need scheduling.
 	podQueue := cache.NewFIFO()
-	cache.NewReflector(factory.createUnassignedPodLW(), &api.Pod{}, podQueue).Run()
 
 	// Watch and cache all running pods. Scheduler needs to find all pods
 	// so it knows where it's safe to place a pod. Cache this locally.
 	podCache := cache.NewStore()
-	cache.NewReflector(factory.createAssignedPodLW(), &api.Pod{}, podCache).Run()
 
 	// Watch minions.
 	// Minions may be listed frequently, so provide a local up-to-date cache.
 	minionCache := cache.NewStore()
+
+	podLister := &storeToPodLister{podCache}
+	minionLister := &storeToMinionLister{minionCache}
+	args := PluginFactoryArgs{
+		PodLister:    podLister,
+		MinionLister: minionLister,
+		NodeInfo:     minionLister,
+	}
+	predicates, err := getFitPredicates(policy.Predicates, args)
+	if err != nil {
+		return nil, err
+	}
+	priorities, err := getPriorityConfigs(policy.Priorities, args)
+	if err != nil {
+		return nil, err
+	}
+
+	cache.NewReflector(factory.createUnassignedPodLW(), &api.Pod{}, podQueue).Run()
+	// A pod missing from the cache would make its minion look emptier than
+	// it is, so list the pods again now and then in case a watch missed one.
+	cache.NewResyncingReflector(factory.createAssignedPodLW(), &api.Pod{}, podCache, 5*time.Minute).Run()
 	if false {
 		// Disable this code until minions support watches.
 		cache.NewReflector(factory.createMinionLW(), &api.Minion{}, minionCache).Run()
@@ -62,11 +92,10 @@ func (factory *ConfigFactory) Create() *scheduler.Config {
 	}
 
 	r := rand.New(rand.NewSource(time.Now().UnixNano()))
-	algo := algorithm.NewRandomFitScheduler(
-		&storeToPodLister{podCache}, r)
+	algo := algorithm.NewGenericScheduler(predicates, priorities, podLister, r)
 
 	return &scheduler.Config{
-		MinionLister: &storeToMinionLister{minionCache},
+		MinionLister: minionLister,
 		Algorithm:    algo,
 		Binder:       &binder{factory.Client},
 		NextPod: func() *api.Pod {
@@ -79,8 +108,12 @@ func (factory *ConfigFactory) Create() *scheduler.Config {
 				pod.ID, minionCache.Contains(), podCache.Contains())
 			return pod
 		},
-		Error: factory.makeDefaultErrorFunc(podQueue),
-	}
+		Error:         factory.makeDefaultErrorFunc(podQueue),
+		Unschedulable: scheduler.NewUnschedulablePods(),
+		Assume: func(pod *api.Pod) {
+			podCache.Add(pod.ID, pod)
+		},
+	}, nil
 }
 
 type listWatch struct {
@@ -113,7 +146,7 @@ func (lw *listWatch) Watch(resourceVersion uint64) (watch.Interface, error) {
 func (factory *ConfigFactory) createUnassignedPodLW() *listWatch {
 	return &listWatch{
 		client:        factory.Client,
-		fieldSelector: labels.Set{"DesiredState.Host": ""}.AsSelector(),
+		fieldSelector: labels.Set{"desiredState.host": ""}.AsSelector(),
 		resource:      "pods",
 	}
 }
@@ -131,7 +164,7 @@ func parseSelectorOrDie(s string) labels.Selector {
 func (factory *ConfigFactory) createAssignedPodLW() *listWatch {
 	return &listWatch{
 		client:        factory.Client,
-		fieldSelector: parseSelectorOrDie("DesiredState.Host!="),
+		fieldSelector: parseSelectorOrDie("desiredState.host!="),
 		resource:      "pods",
 	}
 }
@@ -190,6 +223,14 @@ func (s *storeToMinionLister) List() (machines []string, err error) {
 	return machines, nil
 }
 
+// GetNodeInfo returns cached data for the minion 'id'.
+func (s *storeToMinionLister) GetNodeInfo(id string) (*api.Minion, error) {
+	if minion, ok := s.Get(id); ok {
+		return minion.(*api.Minion), nil
+	}
+	return nil, fmt.Errorf("minion '%v' is not in cache", id)
+}
+
 // storeToPodLister turns a store into a pod lister. The store must contain (only) pods.
 type storeToPodLister struct {
 	cache.Store
@@ -40,10 +40,33 @@ func TestCreate(t *testing.T) {
 	}
 	server := httptest.NewServer(&handler)
 	client := client.NewOrDie(server.URL, nil)
+	client.Retries = 0
 	factory := ConfigFactory{client}
 	factory.Create()
 }
 
+func TestCreateFromPolicy(t *testing.T) {
+	handler := util.FakeHandler{
+		StatusCode:   500,
+		ResponseBody: "",
+		T:            t,
+	}
+	server := httptest.NewServer(&handler)
+	client := client.NewOrDie(server.URL, nil)
+	client.Retries = 0
+	factory := ConfigFactory{client}
+
+	if _, err := factory.CreateFromPolicy(&Policy{Predicates: []string{"PodFitsPorts"}}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if _, err := factory.CreateFromPolicy(&Policy{Predicates: []string{"NoSuchPredicate"}}); err == nil {
+		t.Errorf("Expected error for unknown predicate")
+	}
+	if _, err := factory.CreateFromPolicy(&Policy{Priorities: []PriorityPolicy{{Name: "NoSuchPriority", Weight: 1}}}); err == nil {
+		t.Errorf("Expected error for unknown priority")
+	}
+}
+
 func TestCreateLists(t *testing.T) {
 	factory := ConfigFactory{nil}
 	table := []struct {
@@ -57,12 +80,12 @@ func TestCreateLists(t *testing.T) {
 		},
 		// Assigned pod
 		{
-			location: "/api/v1beta1/pods?fields=DesiredState.Host!%3D",
+			location: "/api/v1beta1/pods?fields=desiredState.host!%3D",
 			factory:  factory.createAssignedPodLW,
 		},
 		// Unassigned pod
 		{
-			location: "/api/v1beta1/pods?fields=DesiredState.Host%3D",
+			location: "/api/v1beta1/pods?fields=desiredState.host%3D",
 			factory:  factory.createUnassignedPodLW,
 		},
 	}
@@ -75,6 +98,7 @@ func TestCreateLists(t *testing.T) {
 		}
 		server := httptest.NewServer(&handler)
 		factory.Client = client.NewOrDie(server.URL, nil)
+		factory.Client.Retries = 0
 		// This test merely tests that the correct request is made.
 		item.factory().List()
 		handler.ValidateRequest(t, item.location, "GET", nil)
@@ -101,21 +125,21 @@ func TestCreateWatches(t *testing.T) {
 		// Assigned pod watches
 		{
 			rv:       0,
-			location: "/api/v1beta1/watch/pods?fields=DesiredState.Host!%3D&resourceVersion=0",
+			location: "/api/v1beta1/watch/pods?fields=desiredState.host!%3D&resourceVersion=0",
 			factory:  factory.createAssignedPodLW,
 		}, {
 			rv:       42,
-			location: "/api/v1beta1/watch/pods?fields=DesiredState.Host!%3D&resourceVersion=42",
+			location: "/api/v1beta1/watch/pods?fields=desiredState.host!%3D&resourceVersion=42",
 			factory:  factory.createAssignedPodLW,
 		},
 		// Unassigned pod watches
 		{
 			rv:       0,
-			location: "/api/v1beta1/watch/pods?fields=DesiredState.Host%3D&resourceVersion=0",
+			location: "/api/v1beta1/watch/pods?fields=desiredState.host%3D&resourceVersion=0",
 			factory:  factory.createUnassignedPodLW,
 		}, {
 			rv:       42,
-			location: "/api/v1beta1/watch/pods?fields=DesiredState.Host%3D&resourceVersion=42",
+			location: "/api/v1beta1/watch/pods?fields=desiredState.host%3D&resourceVersion=42",
 			factory:  factory.createUnassignedPodLW,
 		},
 	}
@@ -128,6 +152,7 @@ func TestCreateWatches(t *testing.T) {
 		}
 		server := httptest.NewServer(&handler)
 		factory.Client = client.NewOrDie(server.URL, nil)
+		factory.Client.Retries = 0
 		// This test merely tests that the correct request is made.
 		item.factory().Watch(item.rv)
 		handler.ValidateRequest(t, item.location, "GET", nil)
@@ -174,7 +199,12 @@ func TestPollMinions(t *testing.T) {
 }
 
 func TestDefaultErrorFunc(t *testing.T) {
-	testPod := &api.Pod{JSONBase: api.JSONBase{ID: "foo"}}
+	alwaysRestart := api.PodState{
+		Manifest: api.ContainerManifest{
+			RestartPolicy: api.RestartPolicy{Always: &api.RestartPolicyAlways{}},
+		},
+	}
+	testPod := &api.Pod{JSONBase: api.JSONBase{ID: "foo"}, DesiredState: alwaysRestart, CurrentState: alwaysRestart}
 	handler := util.FakeHandler{
 		StatusCode:   200,
 		ResponseBody: runtime.EncodeOrDie(latest.Codec, testPod),
@@ -221,6 +251,17 @@ func TestStoreToMinionLister(t *testing.T) {
 	if !ids.HasAll(got...) || len(got) != len(ids) {
 		t.Errorf("Expected %v, got %v", ids, got)
 	}
+
+	minion, err := sml.GetNodeInfo("foo")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if minion.ID != "foo" {
+		t.Errorf("Expected foo, got %v", minion.ID)
+	}
+	if _, err := sml.GetNodeInfo("missing"); err == nil {
+		t.Errorf("Expected error for missing minion")
+	}
 }
 
 func TestStoreToPodLister(t *testing.T) {
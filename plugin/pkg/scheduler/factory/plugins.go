@@ -0,0 +1,124 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package factory
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	algorithm "github.com/GoogleCloudPlatform/kubernetes/pkg/scheduler"
+	"github.com/golang/glog"
+)
+
+// PluginFactoryArgs gives fit predicate and priority function factories access
+// to the scheduler's caches.
+type PluginFactoryArgs struct {
+	PodLister    algorithm.PodLister
+	MinionLister algorithm.MinionLister
+	NodeInfo     algorithm.NodeInfo
+}
+
+// FitPredicateFactory returns a FitPredicate built from args.
+type FitPredicateFactory func(args PluginFactoryArgs) algorithm.FitPredicate
+
+// PriorityFunctionFactory returns a PriorityFunction built from args.
+type PriorityFunctionFactory func(args PluginFactoryArgs) algorithm.PriorityFunction
+
+// All registered fit predicates and priority functions.
+var pluginsMutex sync.Mutex
+var fitPredicates = make(map[string]FitPredicateFactory)
+var priorityFunctions = make(map[string]PriorityFunctionFactory)
+
+// RegisterFitPredicate registers a FitPredicateFactory by name, so that it can
+// be enabled in a Policy. This is expected to happen during app startup.
+func RegisterFitPredicate(name string, factory FitPredicateFactory) {
+	pluginsMutex.Lock()
+	defer pluginsMutex.Unlock()
+	if _, found := fitPredicates[name]; found {
+		glog.Fatalf("Fit predicate %q was registered twice", name)
+	}
+	fitPredicates[name] = factory
+}
+
+// RegisterPriorityFunction registers a PriorityFunctionFactory by name, so that
+// it can be enabled in a Policy. This is expected to happen during app startup.
+func RegisterPriorityFunction(name string, factory PriorityFunctionFactory) {
+	pluginsMutex.Lock()
+	defer pluginsMutex.Unlock()
+	if _, found := priorityFunctions[name]; found {
+		glog.Fatalf("Priority function %q was registered twice", name)
+	}
+	priorityFunctions[name] = factory
+}
+
+// FitPredicateNames returns the sorted names of all registered fit predicates.
+func FitPredicateNames() []string {
+	pluginsMutex.Lock()
+	defer pluginsMutex.Unlock()
+	names := []string{}
+	for name := range fitPredicates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PriorityFunctionNames returns the sorted names of all registered priority functions.
+func PriorityFunctionNames() []string {
+	pluginsMutex.Lock()
+	defer pluginsMutex.Unlock()
+	names := []string{}
+	for name := range priorityFunctions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// getFitPredicates builds the fit predicates with the given names.
+func getFitPredicates(names []string, args PluginFactoryArgs) (map[string]algorithm.FitPredicate, error) {
+	pluginsMutex.Lock()
+	defer pluginsMutex.Unlock()
+	predicates := map[string]algorithm.FitPredicate{}
+	for _, name := range names {
+		factory, found := fitPredicates[name]
+		if !found {
+			return nil, fmt.Errorf("unknown fit predicate %q", name)
+		}
+		predicates[name] = factory(args)
+	}
+	return predicates, nil
+}
+
+// getPriorityConfigs builds the weighted priority functions described by policies.
+func getPriorityConfigs(policies []PriorityPolicy, args PluginFactoryArgs) ([]algorithm.PriorityConfig, error) {
+	pluginsMutex.Lock()
+	defer pluginsMutex.Unlock()
+	configs := []algorithm.PriorityConfig{}
+	for _, policy := range policies {
+		factory, found := priorityFunctions[policy.Name]
+		if !found {
+			return nil, fmt.Errorf("unknown priority function %q", policy.Name)
+		}
+		if policy.Weight < 0 {
+			return nil, fmt.Errorf("priority function %q has negative weight %d", policy.Name, policy.Weight)
+		}
+		configs = append(configs, algorithm.PriorityConfig{Name: policy.Name, Function: factory(args), Weight: policy.Weight})
+	}
+	return configs, nil
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package factory
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	algorithm "github.com/GoogleCloudPlatform/kubernetes/pkg/scheduler"
+)
+
+func TestDefaultPluginsRegistered(t *testing.T) {
+	if e, a := []string{"MatchNodeSelector", "MatchPlatform", "PodFitsAntiAffinity", "PodFitsPorts", "PodFitsResources"}, FitPredicateNames(); !reflect.DeepEqual(e, a) {
+		t.Errorf("Expected %v, got %v", e, a)
+	}
+	if e, a := []string{"EqualPriority", "SpreadingPriority", "ZoneSpreadingPriority"}, PriorityFunctionNames(); !reflect.DeepEqual(e, a) {
+		t.Errorf("Expected %v, got %v", e, a)
+	}
+}
+
+func TestGetFitPredicates(t *testing.T) {
+	predicates, err := getFitPredicates(DefaultPolicy().Predicates, PluginFactoryArgs{NodeInfo: algorithm.StaticNodeInfo{MinionList: &api.MinionList{}}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(predicates) != 5 {
+		t.Errorf("Expected 5 predicates, got %d", len(predicates))
+	}
+
+	_, err = getFitPredicates([]string{"PodFitsPorts", "NoSuchPredicate"}, PluginFactoryArgs{})
+	if err == nil || !strings.Contains(err.Error(), "NoSuchPredicate") {
+		t.Errorf("Expected an error naming the unknown predicate, got %v", err)
+	}
+}
+
+func TestGetPriorityConfigs(t *testing.T) {
+	configs, err := getPriorityConfigs([]PriorityPolicy{{Name: "EqualPriority", Weight: 2}}, PluginFactoryArgs{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(configs) != 1 || configs[0].Weight != 2 || configs[0].Function == nil {
+		t.Errorf("Unexpected priority configs: %#v", configs)
+	}
+
+	table := []PriorityPolicy{
+		{Name: "NoSuchPriority", Weight: 1},
+		{Name: "EqualPriority", Weight: -1},
+	}
+	for _, item := range table {
+		if _, err := getPriorityConfigs([]PriorityPolicy{item}, PluginFactoryArgs{}); err == nil {
+			t.Errorf("Expected error for %#v", item)
+		}
+	}
+}
+
+func TestRegisterPlugins(t *testing.T) {
+	RegisterFitPredicate("TestPredicate", func(PluginFactoryArgs) algorithm.FitPredicate {
+		return func(pod api.Pod, existingPods []api.Pod, machine string) (bool, error) {
+			return machine == "foo", nil
+		}
+	})
+	RegisterPriorityFunction("TestPriority", func(PluginFactoryArgs) algorithm.PriorityFunction {
+		return algorithm.EqualPriority
+	})
+	defer func() {
+		pluginsMutex.Lock()
+		defer pluginsMutex.Unlock()
+		delete(fitPredicates, "TestPredicate")
+		delete(priorityFunctions, "TestPriority")
+	}()
+
+	predicates, err := getFitPredicates([]string{"TestPredicate"}, PluginFactoryArgs{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if fits, _ := predicates["TestPredicate"](api.Pod{}, nil, "foo"); !fits {
+		t.Errorf("Expected the registered predicate to be used")
+	}
+	if _, err := getPriorityConfigs([]PriorityPolicy{{Name: "TestPriority", Weight: 1}}, PluginFactoryArgs{}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package factory
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/cloudprovider"
+	algorithm "github.com/GoogleCloudPlatform/kubernetes/pkg/scheduler"
+)
+
+// Policy selects, by their registered names, the fit predicates and priority
+// functions the scheduler uses. A pod is only placed on a minion for which every
+// predicate holds, and among those the minion with the highest weighted sum of
+// priority scores is chosen.
+type Policy struct {
+	Predicates []string         `json:"predicates"`
+	Priorities []PriorityPolicy `json:"priorities"`
+}
+
+// PriorityPolicy enables a priority function with a weight.
+type PriorityPolicy struct {
+	Name   string `json:"name"`
+	Weight int    `json:"weight"`
+}
+
+func init() {
+	RegisterFitPredicate("PodFitsPorts", func(PluginFactoryArgs) algorithm.FitPredicate {
+		return algorithm.PodFitsPorts
+	})
+	RegisterFitPredicate("PodFitsResources", func(args PluginFactoryArgs) algorithm.FitPredicate {
+		return algorithm.NewResourceFitPredicate(args.NodeInfo)
+	})
+	RegisterFitPredicate("PodFitsAntiAffinity", func(PluginFactoryArgs) algorithm.FitPredicate {
+		return algorithm.PodFitsAntiAffinity
+	})
+	RegisterFitPredicate("MatchNodeSelector", func(args PluginFactoryArgs) algorithm.FitPredicate {
+		return algorithm.NewSelectorMatchPredicate(args.NodeInfo)
+	})
+	RegisterFitPredicate("MatchPlatform", func(args PluginFactoryArgs) algorithm.FitPredicate {
+		return algorithm.NewPlatformMatchPredicate(args.NodeInfo)
+	})
+	RegisterPriorityFunction("EqualPriority", func(PluginFactoryArgs) algorithm.PriorityFunction {
+		return algorithm.EqualPriority
+	})
+	RegisterPriorityFunction("SpreadingPriority", func(PluginFactoryArgs) algorithm.PriorityFunction {
+		return algorithm.CalculateSpreadPriority
+	})
+	RegisterPriorityFunction("ZoneSpreadingPriority", func(args PluginFactoryArgs) algorithm.PriorityFunction {
+		return algorithm.NewZoneSpreadPriority(args.NodeInfo, cloudprovider.LabelFailureDomain)
+	})
+}
+
+// DefaultPolicy returns the policy used when none is configured.
+func DefaultPolicy() *Policy {
+	return &Policy{
+		Predicates: []string{
+			// Fit is defined based on the absence of port conflicts.
+			"PodFitsPorts",
+			// Fit is determined by resource availability.
+			"PodFitsResources",
+			// Fit is determined by the minion's labels matching the pod's NodeSelector.
+			"MatchNodeSelector",
+			// Fit is determined by the minion's platform being one the pod lists.
+			"MatchPlatform",
+			// Fit is determined by the absence of pods the pod must not share a minion with.
+			"PodFitsAntiAffinity",
+		},
+		Priorities: []PriorityPolicy{
+			// Prioritize minions running the fewest pods with the same labels.
+			{Name: "SpreadingPriority", Weight: 1},
+			// Prioritize minions in the failure domains running the fewest pods with the same labels.
+			{Name: "ZoneSpreadingPriority", Weight: 1},
+		},
+	}
+}
+
+// ReadPolicy decodes a JSON encoded Policy.
+func ReadPolicy(r io.Reader) (*Policy, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	policy := &Policy{}
+	if err := json.Unmarshal(data, policy); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
@@ -0,0 +1,44 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package factory
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadPolicy(t *testing.T) {
+	policy, err := ReadPolicy(strings.NewReader(`{
+		"predicates": ["PodFitsPorts"],
+		"priorities": [{"name": "SpreadingPriority", "weight": 2}]
+	}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := &Policy{
+		Predicates: []string{"PodFitsPorts"},
+		Priorities: []PriorityPolicy{{Name: "SpreadingPriority", Weight: 2}},
+	}
+	if !reflect.DeepEqual(expected, policy) {
+		t.Errorf("Expected %#v, got %#v", expected, policy)
+	}
+
+	if _, err := ReadPolicy(strings.NewReader("not json")); err == nil {
+		t.Errorf("Expected error")
+	}
+}
@@ -48,6 +48,17 @@ type Config struct {
 	// Error is called if there is an error. It is passed the pod in
 	// question, and the error
 	Error func(*api.Pod, error)
+
+	// Assume, if set, is called with each pod once it has been bound, with
+	// its desired host set, so that the next pods scheduled see it on its
+	// minion before the watch of scheduled pods reports it.
+	Assume func(*api.Pod)
+
+	// Unschedulable, if set, records pods which fit on no minion.
+	Unschedulable *UnschedulablePods
+
+	// Watchdog, if set, times the scheduling of each pod.
+	Watchdog *util.Watchdog
 }
 
 // New returns a new scheduler.
@@ -65,16 +76,32 @@ func (s *Scheduler) Run() {
 
 func (s *Scheduler) scheduleOne() {
 	pod := s.config.NextPod()
+	s.config.Watchdog.Do(func() { s.schedule(pod) })
+}
+
+func (s *Scheduler) schedule(pod *api.Pod) {
 	dest, err := s.config.Algorithm.Schedule(*pod, s.config.MinionLister)
 	if err != nil {
+		if fitErr, ok := err.(*scheduler.FitError); ok && s.config.Unschedulable != nil {
+			s.config.Unschedulable.Failed(*pod, fitErr)
+		}
 		s.config.Error(pod, err)
 		return
 	}
+	if s.config.Unschedulable != nil {
+		s.config.Unschedulable.Scheduled(*pod)
+	}
 	b := &api.Binding{
 		PodID: pod.ID,
 		Host:  dest,
 	}
 	if err := s.config.Binder.Bind(b); err != nil {
 		s.config.Error(pod, err)
+		return
+	}
+	if s.config.Assume != nil {
+		assumed := *pod
+		assumed.DesiredState.Host = dest
+		s.config.Assume(&assumed)
 	}
 }
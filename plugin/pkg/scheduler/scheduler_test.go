@@ -56,11 +56,13 @@ func TestScheduler(t *testing.T) {
 		expectErrorPod  *api.Pod
 		expectError     error
 		expectBind      *api.Binding
+		expectAssumed   string
 	}{
 		{
-			sendPod:    podWithID("foo"),
-			algo:       mockScheduler{"machine1", nil},
-			expectBind: &api.Binding{PodID: "foo", Host: "machine1"},
+			sendPod:       podWithID("foo"),
+			algo:          mockScheduler{"machine1", nil},
+			expectBind:    &api.Binding{PodID: "foo", Host: "machine1"},
+			expectAssumed: "machine1",
 		}, {
 			sendPod:        podWithID("foo"),
 			algo:           mockScheduler{"machine1", errS},
@@ -80,6 +82,7 @@ func TestScheduler(t *testing.T) {
 		var gotError error
 		var gotPod *api.Pod
 		var gotBinding *api.Binding
+		gotAssumed := ""
 		c := &Config{
 			MinionLister: scheduler.FakeMinionLister{"machine1"},
 			Algorithm:    item.algo,
@@ -94,6 +97,9 @@ func TestScheduler(t *testing.T) {
 			NextPod: func() *api.Pod {
 				return item.sendPod
 			},
+			Assume: func(p *api.Pod) {
+				gotAssumed = p.DesiredState.Host
+			},
 		}
 		s := New(c)
 		s.scheduleOne()
@@ -106,5 +112,35 @@ func TestScheduler(t *testing.T) {
 		if e, a := item.expectBind, gotBinding; !reflect.DeepEqual(e, a) {
 			t.Errorf("%v: error: wanted %v, got %v", i, e, a)
 		}
+		if e, a := item.expectAssumed, gotAssumed; e != a {
+			t.Errorf("%v: assumed host: wanted %v, got %v", i, e, a)
+		}
+		if item.sendPod.DesiredState.Host != "" {
+			t.Errorf("%v: unexpected change to the scheduled pod: %#v", i, item.sendPod)
+		}
+	}
+}
+
+func TestSchedulerRecordsUnschedulablePods(t *testing.T) {
+	fitErr := &scheduler.FitError{FailedPredicates: scheduler.FailedPredicateMap{}}
+	algo := &mockScheduler{"", fitErr}
+	c := &Config{
+		MinionLister:  scheduler.FakeMinionLister{"machine1"},
+		Algorithm:     algo,
+		Binder:        fakeBinder{func(b *api.Binding) error { return nil }},
+		Error:         func(p *api.Pod, err error) {},
+		NextPod:       func() *api.Pod { return podWithID("foo") },
+		Unschedulable: NewUnschedulablePods(),
+	}
+	s := New(c)
+	s.scheduleOne()
+	if e, a := 1, c.Unschedulable.Summary().Pods; e != a {
+		t.Errorf("Expected %v unschedulable pods, got %v", e, a)
+	}
+
+	*algo = mockScheduler{"machine1", nil}
+	s.scheduleOne()
+	if e, a := 0, c.Unschedulable.Summary().Pods; e != a {
+		t.Errorf("Expected %v unschedulable pods, got %v", e, a)
 	}
 }
@@ -0,0 +1,122 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scheduler"
+)
+
+// unschedulableTTL is how long a pod counts as unschedulable after the last
+// failed attempt to place it. Pods are retried continuously, so a pod which has
+// not failed for this long has been scheduled or deleted.
+const unschedulableTTL = time.Minute
+
+// UnschedulableSummary describes the pods which currently fit on no minion. An
+// autoscaler can use it to decide when to add minions.
+type UnschedulableSummary struct {
+	// Pods is the number of pods which fit on no minion.
+	Pods int `json:"pods"`
+	// Predicates counts, for each fit predicate, the pods it rejected on at least one minion.
+	Predicates map[string]int `json:"predicates"`
+	// MilliCPU is the total CPU requested by the pods, in millicores.
+	MilliCPU int `json:"milliCPU"`
+	// Memory is the total memory requested by the pods, in bytes.
+	Memory int `json:"memory"`
+}
+
+type unschedulablePod struct {
+	lastFailure time.Time
+	pod         api.Pod
+	err         *scheduler.FitError
+}
+
+// UnschedulablePods records the pods which the scheduler failed to fit on any
+// minion, and serves an UnschedulableSummary of them as JSON.
+type UnschedulablePods struct {
+	lock sync.Mutex
+	pods map[string]unschedulablePod
+	// now is replaceable for testing.
+	now func() time.Time
+}
+
+// NewUnschedulablePods returns an empty UnschedulablePods.
+func NewUnschedulablePods() *UnschedulablePods {
+	return &UnschedulablePods{
+		pods: map[string]unschedulablePod{},
+		now:  time.Now,
+	}
+}
+
+// Failed records that pod fit on no minion.
+func (u *UnschedulablePods) Failed(pod api.Pod, err *scheduler.FitError) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	u.pods[pod.ID] = unschedulablePod{u.now(), pod, err}
+}
+
+// Scheduled records that pod was placed on a minion.
+func (u *UnschedulablePods) Scheduled(pod api.Pod) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	delete(u.pods, pod.ID)
+}
+
+// Summary summarizes the pods which recently failed to fit on any minion.
+func (u *UnschedulablePods) Summary() UnschedulableSummary {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	summary := UnschedulableSummary{Predicates: map[string]int{}}
+	now := u.now()
+	for id, entry := range u.pods {
+		if now.Sub(entry.lastFailure) > unschedulableTTL {
+			delete(u.pods, id)
+			continue
+		}
+		summary.Pods++
+		predicates := map[string]bool{}
+		for _, failed := range entry.err.FailedPredicates {
+			for _, name := range failed.List() {
+				predicates[name] = true
+			}
+		}
+		for name := range predicates {
+			summary.Predicates[name]++
+		}
+		for _, container := range entry.pod.DesiredState.Manifest.Containers {
+			summary.MilliCPU += container.CPU
+			summary.Memory += container.Memory
+		}
+	}
+	return summary
+}
+
+// ServeHTTP serves the Summary as JSON.
+func (u *UnschedulablePods) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	data, err := json.Marshal(u.Summary())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/api"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/scheduler"
+	"github.com/GoogleCloudPlatform/kubernetes/pkg/util"
+)
+
+func podWithResources(id string, milliCPU, memory int) api.Pod {
+	return api.Pod{
+		JSONBase: api.JSONBase{ID: id},
+		DesiredState: api.PodState{
+			Manifest: api.ContainerManifest{
+				Containers: []api.Container{{CPU: milliCPU, Memory: memory}},
+			},
+		},
+	}
+}
+
+func fitError(failed map[string][]string) *scheduler.FitError {
+	err := &scheduler.FitError{FailedPredicates: scheduler.FailedPredicateMap{}}
+	for machine, predicates := range failed {
+		err.FailedPredicates[machine] = util.NewStringSet(predicates...)
+	}
+	return err
+}
+
+func TestUnschedulablePodsSummary(t *testing.T) {
+	now := time.Unix(1000, 0)
+	u := NewUnschedulablePods()
+	u.now = func() time.Time { return now }
+
+	u.Failed(podWithResources("foo", 100, 1000), fitError(map[string][]string{
+		"m1": {"PodFitsResources"},
+		"m2": {"PodFitsResources", "PodFitsPorts"},
+	}))
+	u.Failed(podWithResources("bar", 200, 2000), fitError(map[string][]string{
+		"m1": {"PodFitsResources"},
+	}))
+	u.Failed(podWithResources("baz", 400, 4000), fitError(map[string][]string{
+		"m1": {"PodFitsPorts"},
+	}))
+	u.Scheduled(podWithResources("baz", 400, 4000))
+
+	expected := UnschedulableSummary{
+		Pods:       2,
+		Predicates: map[string]int{"PodFitsResources": 2, "PodFitsPorts": 1},
+		MilliCPU:   300,
+		Memory:     3000,
+	}
+	if e, a := expected, u.Summary(); !reflect.DeepEqual(e, a) {
+		t.Errorf("Expected %#v, got %#v", e, a)
+	}
+
+	// Pods which have not failed recently are forgotten.
+	now = now.Add(unschedulableTTL / 2)
+	u.Failed(podWithResources("bar", 200, 2000), fitError(map[string][]string{
+		"m1": {"PodFitsResources"},
+	}))
+	now = now.Add(unschedulableTTL)
+	expected = UnschedulableSummary{
+		Pods:       1,
+		Predicates: map[string]int{"PodFitsResources": 1},
+		MilliCPU:   200,
+		Memory:     2000,
+	}
+	if e, a := expected, u.Summary(); !reflect.DeepEqual(e, a) {
+		t.Errorf("Expected %#v, got %#v", e, a)
+	}
+}
+
+func TestUnschedulablePodsServeHTTP(t *testing.T) {
+	u := NewUnschedulablePods()
+	u.Failed(podWithResources("foo", 100, 1000), fitError(map[string][]string{
+		"m1": {"PodFitsResources"},
+	}))
+	w := httptest.NewRecorder()
+	u.ServeHTTP(w, nil)
+
+	var summary UnschedulableSummary
+	if err := json.Unmarshal(w.Body.Bytes(), &summary); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if e, a := u.Summary(), summary; !reflect.DeepEqual(e, a) {
+		t.Errorf("Expected %#v, got %#v", e, a)
+	}
+}
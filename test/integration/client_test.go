@@ -37,7 +37,7 @@ func init() {
 
 func TestClient(t *testing.T) {
 	m := master.New(&master.Config{
-		EtcdServers: newEtcdClient().GetCluster(),
+		EtcdClient: newEtcdClient(),
 	})
 
 	storage, codec := m.API_v1beta1()